@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"io/fs"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+// chatTransformOptions are the flags common to the transformations of
+// the chat services other than Slack
+type chatTransformOptions struct {
+	Team            string
+	AttachmentsDir  string
+	SkipAttachments bool
+	Logger          log.FieldLogger
+}
+
+// chatTransformFunc parses and transforms the export of a chat
+// service, returning its intermediate entities and the function
+// exporting them to the output path
+type chatTransformFunc func(exportFS fs.FS, options chatTransformOptions) (*slack.Intermediate, func(outputPath string) error, error)
+
+// chatTransformCmd describes the transform command of a chat service
+type chatTransformCmd struct {
+	Use     string
+	Short   string
+	Long    string
+	Example string
+	// Service is the name of the chat service in the flag usages
+	Service              string
+	SkipAttachmentsUsage string
+	Transform            chatTransformFunc
+}
+
+// newChatTransformCmd returns the transform command of a chat service,
+// reading its export from either a zipfile or a directory
+func newChatTransformCmd(command chatTransformCmd) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     command.Use,
+		Short:   command.Short,
+		Long:    command.Long,
+		Example: command.Example,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return transformChatCmdF(cmd, command.Transform)
+		},
+	}
+
+	cmd.Flags().StringP("team", "t", "", "the team in Mattermost to import the data into, which must exist unless --create-team is set")
+	if err := cmd.MarkFlagRequired("team"); err != nil {
+		panic(err)
+	}
+	cmd.Flags().Bool("create-team", false, "adds the team definition to the output so the import creates the team")
+	cmd.Flags().String("team-display-name", "", "the display name of the team created with --create-team, defaults to the team name")
+	cmd.Flags().StringP("file", "f", "", "the "+command.Service+" export to transform, either a zipfile or a directory")
+	if err := cmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+	cmd.Flags().StringP("output", "o", "bulk-export.jsonl", "the output path")
+	cmd.Flags().StringP("attachments-dir", "d", "bulk-export-attachments", "the path for the attachments directory")
+	cmd.Flags().BoolP("skip-attachments", "a", false, command.SkipAttachmentsUsage)
+	cmd.Flags().Bool("debug", true, "Whether to show debug logs or not")
+	return cmd
+}
+
+func transformChatCmdF(cmd *cobra.Command, transform chatTransformFunc) error {
+	team, _ := cmd.Flags().GetString("team")
+	createTeam, _ := cmd.Flags().GetBool("create-team")
+	teamDisplayName, _ := cmd.Flags().GetString("team-display-name")
+	inputPath, _ := cmd.Flags().GetString("file")
+	outputFilePath, _ := cmd.Flags().GetString("output")
+	attachmentsDir, _ := cmd.Flags().GetString("attachments-dir")
+	skipAttachments, _ := cmd.Flags().GetBool("skip-attachments")
+	debug, _ := cmd.Flags().GetBool("debug")
+
+	logger := log.New()
+	if debug {
+		logger.Level = log.DebugLevel
+	}
+
+	exportFS, closeExport, err := openExportFS(inputPath)
+	if err != nil {
+		return err
+	}
+	defer closeExport()
+
+	intermediate, export, err := transform(exportFS, chatTransformOptions{
+		Team:            team,
+		AttachmentsDir:  attachmentsDir,
+		SkipAttachments: skipAttachments,
+		Logger:          logger,
+	})
+	if err != nil {
+		return err
+	}
+	if createTeam {
+		intermediate.Team = slack.NewIntermediateTeam(team, teamDisplayName)
+	}
+
+	if err := export(outputFilePath); err != nil {
+		return err
+	}
+
+	logger.Infof("Transformation succeeded: %d users, %d channels, %d direct and group channels, %d posts", len(intermediate.UsersById), len(intermediate.PublicChannels)+len(intermediate.PrivateChannels), len(intermediate.DirectChannels)+len(intermediate.GroupChannels), len(intermediate.Posts))
+	return nil
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"archive/zip"
+	"fmt"
 	"os"
 
 	log "github.com/sirupsen/logrus"
@@ -23,15 +24,47 @@ var CheckSlackCmd = &cobra.Command{
 	RunE:  checkSlackCmdF,
 }
 
+var CheckManifestCmd = &cobra.Command{
+	Use:     "manifest",
+	Short:   "Verifies the files of a transformation output against its manifest.",
+	Example: "  check manifest --file mm_export.jsonl.sha256",
+	Args:    cobra.NoArgs,
+	RunE:    checkManifestCmdF,
+}
+
+var CheckMemoryCmd = &cobra.Command{
+	Use:     "memory",
+	Short:   "Estimates the peak memory of the transformation of a Slack export.",
+	Long:    "Estimates the peak memory of the transformation of a Slack export from the sizes of its files, without reading them, and recommends the flags keeping it under the memory limit.",
+	Example: "  check memory --file my_export.zip --memory-limit 4GB",
+	Args:    cobra.NoArgs,
+	RunE:    checkMemoryCmdF,
+}
+
 func init() {
+	CheckMemoryCmd.Flags().StringP("file", "f", "", "the Slack export file to transform")
+	if err := CheckMemoryCmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+	CheckMemoryCmd.Flags().String("memory-limit", "4GB", "the memory available to the transformation, e.g. 512MB or 8GB")
+
+	CheckManifestCmd.Flags().StringP("file", "f", "", "the manifest file written by transform --manifest")
+	if err := CheckManifestCmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+	CheckManifestCmd.Flags().String("base-dir", ".", "the directory the relative paths of the manifest are resolved against")
+
 	CheckSlackCmd.Flags().StringP("file", "f", "", "the Slack export file to transform")
 	CheckSlackCmd.Flags().Bool("debug", true, "Whether to show debug logs or not")
+	CheckSlackCmd.Flags().String("emoji-report", "", "the path for a JSON report of the custom emojis used in the reactions and messages, to create them before the import")
 	if err := CheckSlackCmd.MarkFlagRequired("file"); err != nil {
 		panic(err)
 	}
 
 	CheckCmd.AddCommand(
 		CheckSlackCmd,
+		CheckManifestCmd,
+		CheckMemoryCmd,
 	)
 
 	RootCmd.AddCommand(
@@ -42,6 +75,7 @@ func init() {
 func checkSlackCmdF(cmd *cobra.Command, args []string) error {
 	inputFilePath, _ := cmd.Flags().GetString("file")
 	debug, _ := cmd.Flags().GetBool("debug")
+	emojiReportPath, _ := cmd.Flags().GetString("emoji-report")
 
 	// input file
 	fileReader, err := os.Open(inputFilePath)
@@ -64,6 +98,12 @@ func checkSlackCmdF(cmd *cobra.Command, args []string) error {
 	if debug {
 		logger.Level = log.DebugLevel
 	}
+
+	if encoding, renamed := slack.DecodeZipNames(zipReader); encoding != "" {
+		logger.Infof("Decoded %d zip entry names from %s", renamed, encoding)
+	} else if renamed > 0 {
+		logger.Infof("Decoded %d zip entry names from their unicode path", renamed)
+	}
 	slackTransformer := slack.NewTransformer("test", logger)
 
 	valid := slackTransformer.Precheck(zipReader)
@@ -76,7 +116,7 @@ func checkSlackCmdF(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	err = slackTransformer.Transform(
+	_, err = slackTransformer.Transform(cmd.Context(),
 		&slack.TransformConfig{
 			SkipAttachments:        true,
 			DiscardInvalidProps:    true,
@@ -89,5 +129,82 @@ func checkSlackCmdF(cmd *cobra.Command, args []string) error {
 
 	slackTransformer.CheckIntermediate()
 
+	emojiUsage := slack.CustomEmojiUsage(slackExport)
+	for _, emoji := range emojiUsage {
+		logger.Infof("Custom emoji :%s: used in %d reactions and %d messages", emoji.Name, emoji.Reactions, emoji.Messages)
+	}
+	if emojiReportPath != "" {
+		if err := slack.WriteEmojiUsageReport(emojiReportPath, emojiUsage); err != nil {
+			return err
+		}
+		logger.Infof("Emoji usage report written to %s", emojiReportPath)
+	}
+
 	return nil
 }
+
+func checkManifestCmdF(cmd *cobra.Command, args []string) error {
+	manifestPath, _ := cmd.Flags().GetString("file")
+	baseDir, _ := cmd.Flags().GetString("base-dir")
+
+	manifestFile, err := os.Open(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer manifestFile.Close()
+
+	manifest, err := slack.ReadManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	failed := slack.VerifyManifest(manifest, baseDir)
+	for _, filePath := range failed {
+		log.Errorf("Checksum verification failed for %s", filePath)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d files failed the checksum verification", len(failed), len(manifest.Entries))
+	}
+
+	log.Infof("All %d files match the manifest", len(manifest.Entries))
+	return nil
+}
+
+func checkMemoryCmdF(cmd *cobra.Command, args []string) error {
+	inputFilePath, _ := cmd.Flags().GetString("file")
+	memoryLimitFlag, _ := cmd.Flags().GetString("memory-limit")
+
+	memoryLimit, err := slack.ParseByteSize(memoryLimitFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --memory-limit %q: %w", memoryLimitFlag, err)
+	}
+
+	// only the central directory of the zip file is read
+	zipReader, err := zip.OpenReader(inputFilePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	estimate := slack.EstimateMemory(zipReader.File, slack.DefaultPipelineBufferSize)
+	log.Infof("%d channels, %d day files, %.1f MiB of posts, %.1f MiB of users and channels", estimate.Channels, estimate.DayFiles, mebibytes(estimate.PostBytes), mebibytes(estimate.MetadataBytes))
+	for _, channel := range estimate.LargestChannels {
+		log.Debugf("Channel %s: %.1f MiB of posts", channel.Channel, mebibytes(channel.Bytes))
+	}
+	log.Infof("Estimated peak memory with the threads in memory: %.1f MiB", mebibytes(estimate.InMemory))
+	log.Infof("Estimated peak memory with --threads-cache-size: %.1f MiB, with up to %.1f MiB of threads in temporary files or redis", mebibytes(estimate.WithThreadsCache), mebibytes(estimate.SpillBytes))
+
+	recommendations := estimate.Recommend(memoryLimit)
+	if len(recommendations) == 0 {
+		log.Infof("The transformation fits in %s without additional flags", memoryLimitFlag)
+		return nil
+	}
+	for _, recommendation := range recommendations {
+		log.Warnf("Recommended: %s", recommendation)
+	}
+	return nil
+}
+
+func mebibytes(size int64) float64 {
+	return float64(size) / (1 << 20)
+}
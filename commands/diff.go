@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compares an export with the target server.",
+	Long:  "Compares the entities an export would import with those existing on the target server, without importing anything.",
+}
+
+var DiffSlackCmd = &cobra.Command{
+	Use:     "slack",
+	Short:   "Compares the users and channels of a Slack export with the target server.",
+	Example: "  diff slack --team myteam --file my_export.zip --server-url https://chat.example.com --token <admin token>",
+	Args:    cobra.NoArgs,
+	RunE:    diffSlackCmdF,
+}
+
+func init() {
+	DiffSlackCmd.Flags().StringP("team", "t", "", "an existing team in Mattermost to import the data into")
+	if err := DiffSlackCmd.MarkFlagRequired("team"); err != nil {
+		panic(err)
+	}
+	DiffSlackCmd.Flags().Bool("create-team", false, "the team is created by the import when it does not exist")
+	DiffSlackCmd.Flags().StringP("file", "f", "", "the Slack export file to compare")
+	if err := DiffSlackCmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+	DiffSlackCmd.Flags().String("zip-password", "", "the password of encrypted export zipfiles. Read from the MMETL_ZIP_PASSWORD environment variable when not set")
+	DiffSlackCmd.Flags().String("channel-prefix", "", "the --channel-prefix the export is to be transformed with")
+	DiffSlackCmd.Flags().String("server-url", "", "the URL of the target Mattermost server")
+	if err := DiffSlackCmd.MarkFlagRequired("server-url"); err != nil {
+		panic(err)
+	}
+	DiffSlackCmd.Flags().String("token", "", "the access token of a system administrator of the target server. Read from the MMETL_SERVER_TOKEN environment variable when not set")
+	DiffSlackCmd.Flags().StringP("output", "o", "", "the path for the diff, printed when not set")
+	DiffSlackCmd.Flags().Bool("debug", false, "Whether to show debug logs or not")
+
+	DiffCmd.AddCommand(
+		DiffSlackCmd,
+	)
+
+	RootCmd.AddCommand(
+		DiffCmd,
+	)
+}
+
+func diffSlackCmdF(cmd *cobra.Command, args []string) error {
+	team, _ := cmd.Flags().GetString("team")
+	createTeam, _ := cmd.Flags().GetBool("create-team")
+	inputFilePath, _ := cmd.Flags().GetString("file")
+	zipPassword, _ := cmd.Flags().GetString("zip-password")
+	if zipPassword == "" {
+		zipPassword = os.Getenv("MMETL_ZIP_PASSWORD")
+	}
+	channelPrefix, _ := cmd.Flags().GetString("channel-prefix")
+	serverURL, _ := cmd.Flags().GetString("server-url")
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" {
+		token = os.Getenv("MMETL_SERVER_TOKEN")
+	}
+	outputPath, _ := cmd.Flags().GetString("output")
+	debug, _ := cmd.Flags().GetBool("debug")
+
+	if createTeam && !model.IsValidTeamName(team) {
+		return fmt.Errorf("\"%s\" is not a valid team name to create", team)
+	}
+	if token == "" {
+		return errors.New("--token or MMETL_SERVER_TOKEN is required to query the server")
+	}
+	if err := slack.ValidateSiteURL(serverURL); err != nil {
+		return err
+	}
+	if channelPrefix != "" {
+		if err := slack.ValidateChannelPrefix(channelPrefix); err != nil {
+			return err
+		}
+	}
+
+	logger := log.New()
+	if debug {
+		logger.Level = log.DebugLevel
+	}
+
+	fileReader, fileSize, closeFile, err := openExport(cmd.Context(), inputFilePath, exportSource{})
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	// only the users and channels are compared
+	result, err := slack.TransformZip(cmd.Context(), fileReader, fileSize, slack.Options{
+		TeamName:         team,
+		CreateTeam:       createTeam,
+		Logger:           logger,
+		SkipConvertPosts: true,
+		ZipPassword:      zipPassword,
+		ChannelPrefix:    channelPrefix,
+		TransformConfig:  slack.TransformConfig{SkipPosts: true, SkipAttachments: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	diff, err := result.DiffWithServer(slack.NewServerLookup(serverURL, token))
+	if err != nil {
+		return err
+	}
+
+	var writer io.Writer = os.Stdout
+	if outputPath != "" {
+		outputFile, err := os.Create(outputPath)
+		if err != nil {
+			return err
+		}
+		defer outputFile.Close()
+		writer = outputFile
+	}
+	if err := slack.WriteServerDiff(writer, diff); err != nil {
+		return err
+	}
+
+	if conflicts := diff.Count(slack.DiffConflict); conflicts > 0 {
+		logger.Warnf("%d entities conflict with the target server", conflicts)
+	}
+	return nil
+}
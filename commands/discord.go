@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"io/fs"
+
+	"github.com/mattermost/mmetl/services/discord"
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+var TransformDiscordCmd = newChatTransformCmd(chatTransformCmd{
+	Use:                  "discord",
+	Short:                "Transforms a Discord export.",
+	Long:                 "Transforms a DiscordChatExporter JSON export of the channels of a Discord server, either a zipfile or a directory, into a Mattermost export JSONL file.",
+	Example:              "  transform discord --team myteam --file discord_export.zip --output mm_export.json",
+	Service:              "Discord",
+	SkipAttachmentsUsage: "Skips copying the attachments from the export, linking to the Discord files instead",
+	Transform:            transformDiscord,
+})
+
+func init() {
+	TransformCmd.AddCommand(
+		TransformDiscordCmd,
+	)
+}
+
+func transformDiscord(exportFS fs.FS, options chatTransformOptions) (*slack.Intermediate, func(outputPath string) error, error) {
+	export, err := discord.ParseExport(exportFS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transformer := discord.NewTransformer(options.Team, options.Logger)
+	transformer.AttachmentsDir = options.AttachmentsDir
+	transformer.SkipAttachments = options.SkipAttachments
+	if err := transformer.Transform(export); err != nil {
+		return nil, nil, err
+	}
+	return transformer.Intermediate, transformer.Exporter.Export, nil
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+var FixtureCmd = &cobra.Command{
+	Use:   "fixture",
+	Short: "Generates synthetic exports.",
+	Long:  "Generates small synthetic exports covering edge cases, to test a whole migration pipeline against the Mattermost version it targets.",
+}
+
+var FixtureSlackCmd = &cobra.Command{
+	Use:     "slack",
+	Short:   "Generates a synthetic Slack export.",
+	Example: "  fixture slack --output fixture.zip --cases threads,unicode",
+	Args:    cobra.NoArgs,
+	RunE:    fixtureSlackCmdF,
+}
+
+var FixtureScaleCmd = &cobra.Command{
+	Use:     "scale",
+	Short:   "Multiplies a Slack export.",
+	Long:    "Multiplies a Slack export, cloning its users, channels and messages with renamed users and channels and shifted timestamps, to produce load testing corpora for the capacity planning of the target server.",
+	Example: "  fixture scale --file export.zip --copies 10 --output scaled.zip",
+	Args:    cobra.NoArgs,
+	RunE:    fixtureScaleCmdF,
+}
+
+func init() {
+	cases := make([]string, len(slack.FixtureCases))
+	for i, fixtureCase := range slack.FixtureCases {
+		cases[i] = string(fixtureCase)
+	}
+
+	FixtureSlackCmd.Flags().StringP("output", "o", "", "the path of the export zip file to write")
+	if err := FixtureSlackCmd.MarkFlagRequired("output"); err != nil {
+		panic(err)
+	}
+	FixtureSlackCmd.Flags().StringSlice("cases", []string{}, fmt.Sprintf("the edge cases the export covers, among %s. All of them when not set", strings.Join(cases, ", ")))
+
+	FixtureScaleCmd.Flags().StringP("file", "f", "", "the Slack export file to multiply")
+	if err := FixtureScaleCmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+	FixtureScaleCmd.Flags().String("zip-password", "", "the password of encrypted export zipfiles. Read from the MMETL_ZIP_PASSWORD environment variable when not set")
+	FixtureScaleCmd.Flags().StringP("output", "o", "", "the path of the scaled export zip file to write")
+	if err := FixtureScaleCmd.MarkFlagRequired("output"); err != nil {
+		panic(err)
+	}
+	FixtureScaleCmd.Flags().Int("copies", 2, "the number of copies of the export in the scaled one, including the original")
+	FixtureScaleCmd.Flags().Duration("shift", 24*time.Hour, "the time the messages of each copy are moved forward by from the previous one")
+
+	FixtureCmd.AddCommand(
+		FixtureSlackCmd,
+		FixtureScaleCmd,
+	)
+
+	RootCmd.AddCommand(
+		FixtureCmd,
+	)
+}
+
+func fixtureSlackCmdF(cmd *cobra.Command, args []string) error {
+	outputPath, _ := cmd.Flags().GetString("output")
+	caseNames, _ := cmd.Flags().GetStringSlice("cases")
+
+	cases, err := slack.ParseFixtureCases(caseNames)
+	if err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("could not create the fixture file \"%s\": %w", outputPath, err)
+	}
+	if err := slack.WriteFixture(outputFile, cases); err != nil {
+		outputFile.Close()
+		return err
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("could not write the fixture file \"%s\": %w", outputPath, err)
+	}
+
+	fmt.Printf("Fixture export written to %s\n", outputPath)
+	return nil
+}
+
+func fixtureScaleCmdF(cmd *cobra.Command, args []string) error {
+	inputFilePath, _ := cmd.Flags().GetString("file")
+	zipPassword, _ := cmd.Flags().GetString("zip-password")
+	if zipPassword == "" {
+		zipPassword = os.Getenv("MMETL_ZIP_PASSWORD")
+	}
+	outputPath, _ := cmd.Flags().GetString("output")
+	copies, _ := cmd.Flags().GetInt("copies")
+	shift, _ := cmd.Flags().GetDuration("shift")
+
+	if copies < 1 {
+		return fmt.Errorf("--copies must be at least 1, got %d", copies)
+	}
+	if shift < 0 {
+		return fmt.Errorf("--shift cannot be negative, got %s", shift)
+	}
+
+	fileReader, fileSize, closeFile, err := openExport(cmd.Context(), inputFilePath, exportSource{})
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	fsys, err := slack.OpenZipFS(fileReader, fileSize, zipPassword)
+	if err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("could not create the scaled export file \"%s\": %w", outputPath, err)
+	}
+	if err := slack.ScaleExport(fsys, outputFile, slack.ScaleOptions{Copies: copies, Shift: shift}); err != nil {
+		outputFile.Close()
+		return err
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("could not write the scaled export file \"%s\": %w", outputPath, err)
+	}
+
+	fmt.Printf("Export scaled %d times written to %s\n", copies, outputPath)
+	return nil
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+// exportSource configures how the remote export files are read
+type exportSource struct {
+	httpHeaders   []string
+	objectStorage slack.ObjectStorageConfig
+}
+
+// openExport opens a local export file, an export served over HTTP or
+// an export stored in an S3 or GCS bucket, returning a reader of the
+// export, its size and a function to close it.
+func openExport(ctx context.Context, path string, source exportSource) (io.ReaderAt, int64, func() error, error) {
+	if slack.IsObjectStorageURL(path) {
+		remoteFile, err := slack.OpenObject(ctx, path, source.objectStorage)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return remoteFile, remoteFile.Size(), func() error { return nil }, nil
+	}
+
+	if slack.IsURL(path) {
+		headers, err := parseHTTPHeaders(source.httpHeaders)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		remoteFile, err := slack.OpenURL(ctx, path, headers)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return remoteFile, remoteFile.Size(), func() error { return nil }, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, nil, err
+	}
+	return file, fileInfo.Size(), file.Close, nil
+}
+
+// openExportFS opens a local export, either a zipfile or a directory,
+// returning its file system and a function to close it.
+func openExportFS(path string) (fs.FS, func() error, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(path), func() error { return nil }, nil
+	}
+
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zipReader, zipReader.Close, nil
+}
+
+// parseHTTPHeaders parses headers given as "Name: value"
+func parseHTTPHeaders(httpHeaders []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, header := range httpHeaders {
+		colon := strings.Index(header, ":")
+		if colon <= 0 {
+			return nil, fmt.Errorf("invalid HTTP header \"%s\", expected \"Name: value\"", header)
+		}
+		headers.Add(strings.TrimSpace(header[:colon]), strings.TrimSpace(header[colon+1:]))
+	}
+	return headers, nil
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"io/fs"
+
+	"github.com/mattermost/mmetl/services/msteams"
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+var TransformMSTeamsCmd = newChatTransformCmd(chatTransformCmd{
+	Use:                  "msteams",
+	Short:                "Transforms a Microsoft Teams export.",
+	Long:                 "Transforms a Graph API export of a Microsoft Teams team, either a zipfile or a directory, into a Mattermost export JSONL file.",
+	Example:              "  transform msteams --team myteam --file teams_export.zip --output mm_export.json",
+	Service:              "Microsoft Teams",
+	SkipAttachmentsUsage: "Skips copying the attachments from the export, linking to the shared files instead",
+	Transform:            transformMSTeams,
+})
+
+func init() {
+	TransformCmd.AddCommand(
+		TransformMSTeamsCmd,
+	)
+}
+
+func transformMSTeams(exportFS fs.FS, options chatTransformOptions) (*slack.Intermediate, func(outputPath string) error, error) {
+	export, err := msteams.ParseExport(exportFS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transformer := msteams.NewTransformer(options.Team, options.Logger)
+	transformer.AttachmentsDir = options.AttachmentsDir
+	transformer.SkipAttachments = options.SkipAttachments
+	if err := transformer.Transform(export); err != nil {
+		return nil, nil, err
+	}
+	return transformer.Intermediate, transformer.Exporter.Export, nil
+}
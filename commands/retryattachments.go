@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+var TransformRetryAttachmentsCmd = &cobra.Command{
+	Use:     "retry-attachments",
+	Short:   "Copies the attachments whose copy failed during a transformation.",
+	Long:    "Copies again from the Slack export the attachments listed in the --failed-attachments-report of a transformation, rewriting the report with the ones that still fail.",
+	Example: "  transform retry-attachments --file my_export.zip --report mm_export.jsonl.failed-attachments.json",
+	Args:    cobra.NoArgs,
+	RunE:    transformRetryAttachmentsCmdF,
+}
+
+func init() {
+	TransformRetryAttachmentsCmd.Flags().StringP("file", "f", "", "the Slack export the transformation read, either a zipfile or a directory")
+	if err := TransformRetryAttachmentsCmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+	TransformRetryAttachmentsCmd.Flags().String("report", "", "the failed attachments report written by the transformation")
+	if err := TransformRetryAttachmentsCmd.MarkFlagRequired("report"); err != nil {
+		panic(err)
+	}
+	TransformRetryAttachmentsCmd.Flags().Int("retries", slack.DefaultAttachmentRetries, "the number of times a failed copy is retried")
+
+	TransformCmd.AddCommand(
+		TransformRetryAttachmentsCmd,
+	)
+}
+
+func transformRetryAttachmentsCmdF(cmd *cobra.Command, args []string) error {
+	inputPath, _ := cmd.Flags().GetString("file")
+	reportPath, _ := cmd.Flags().GetString("report")
+	retries, _ := cmd.Flags().GetInt("retries")
+
+	logger := log.New()
+
+	failed, err := slack.ReadFailedAttachmentsReport(reportPath)
+	if err != nil {
+		return err
+	}
+
+	exportFS, closeExport, err := openExportFS(inputPath)
+	if err != nil {
+		return err
+	}
+	defer closeExport()
+
+	stillFailed := slack.RetryFailedAttachments(cmd.Context(), exportFS, failed, retries)
+	if err := slack.WriteFailedAttachmentsReport(reportPath, stillFailed); err != nil {
+		return err
+	}
+	logger.Infof("Copied %d of the %d failed attachments", len(failed)-len(stillFailed), len(failed))
+
+	if len(stillFailed) > 0 {
+		for _, attachment := range stillFailed {
+			logger.Warnf("Failed to copy file %s of channel %s: %s", attachment.FileId, attachment.Channel, attachment.Error)
+		}
+		return fmt.Errorf("%d attachments still fail to copy, see %s", len(stillFailed), reportPath)
+	}
+	return nil
+}
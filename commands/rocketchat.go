@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"io/fs"
+
+	"github.com/mattermost/mmetl/services/rocketchat"
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+var TransformRocketChatCmd = newChatTransformCmd(chatTransformCmd{
+	Use:                  "rocketchat",
+	Short:                "Transforms a Rocket.Chat export.",
+	Long:                 "Transforms a Rocket.Chat export of the collections of its database made with mongoexport, either a zipfile or a directory, into a Mattermost export JSONL file.",
+	Example:              "  transform rocketchat --team myteam --file rocketchat_export.zip --output mm_export.json",
+	Service:              "Rocket.Chat",
+	SkipAttachmentsUsage: "Skips copying the uploads from the export",
+	Transform:            transformRocketChat,
+})
+
+func init() {
+	TransformCmd.AddCommand(
+		TransformRocketChatCmd,
+	)
+}
+
+func transformRocketChat(exportFS fs.FS, options chatTransformOptions) (*slack.Intermediate, func(outputPath string) error, error) {
+	export, err := rocketchat.ParseExport(exportFS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transformer := rocketchat.NewTransformer(options.Team, options.Logger)
+	transformer.AttachmentsDir = options.AttachmentsDir
+	transformer.SkipAttachments = options.SkipAttachments
+	if err := transformer.Transform(export); err != nil {
+		return nil, nil, err
+	}
+	return transformer.Intermediate, transformer.Exporter.Export, nil
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package commands
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// peakRSS returns the maximum resident set size of the process in
+// bytes, and false when it is unknown
+func peakRSS() (int64, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	// it is in bytes on macOS and in kilobytes elsewhere
+	if runtime.GOOS == "darwin" {
+		return int64(usage.Maxrss), true
+	}
+	return int64(usage.Maxrss) * 1024, true
+}
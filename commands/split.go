@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+var SplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Splits a Mattermost bulk import file into smaller ones.",
+	Long: "Splits a Mattermost bulk import JSONL file into a sequence of smaller import files to import one after the other, each starting with the version line and keeping the order of the lines. " +
+		"When the output is a zip file, each chunk is an import archive with the attachments referenced by its posts, read from the paths of the lines relative to the current directory.",
+	Example: "  split --file bulk-export.jsonl --output bulk-import.zip --chunks 10",
+	Args:    cobra.NoArgs,
+	RunE:    splitCmdF,
+}
+
+func init() {
+	SplitCmd.Flags().StringP("file", "f", "", "the bulk import JSONL file to split")
+	if err := SplitCmd.MarkFlagRequired("file"); err != nil {
+		panic(err)
+	}
+	SplitCmd.Flags().StringP("output", "o", "bulk-import.zip", "the output path, numbered for each chunk, e.g. bulk-import.001.zip. The chunks are import archives with their attachments when it has the .zip extension and JSONL files otherwise")
+	SplitCmd.Flags().Int("chunks", 0, "the maximum number of chunks to split the file into, of about the same size")
+	SplitCmd.Flags().String("max-size", "", "the maximum size of a chunk, e.g. 1GB, including the attachments for zip archives, instead of --chunks")
+
+	RootCmd.AddCommand(
+		SplitCmd,
+	)
+}
+
+func splitCmdF(cmd *cobra.Command, args []string) error {
+	inputFilePath, _ := cmd.Flags().GetString("file")
+	outputFilePath, _ := cmd.Flags().GetString("output")
+	chunks, _ := cmd.Flags().GetInt("chunks")
+	maxSizeFlag, _ := cmd.Flags().GetString("max-size")
+
+	logger := log.New()
+
+	if (chunks > 0) == (maxSizeFlag != "") {
+		return fmt.Errorf("either --chunks or --max-size must be set")
+	}
+
+	// the attachments are part of the size of the zip archives
+	isZip := strings.EqualFold(filepath.Ext(outputFilePath), ".zip")
+	var maxBytes int64
+	var err error
+	if maxSizeFlag != "" {
+		if maxBytes, err = slack.ParseByteSize(maxSizeFlag); err != nil {
+			return err
+		}
+	} else if maxBytes, err = slack.ChunkSizeForCount(inputFilePath, chunks, isZip); err != nil {
+		return err
+	}
+
+	inputFile, err := os.Open(inputFilePath)
+	if err != nil {
+		return err
+	}
+	defer inputFile.Close()
+
+	var outputFiles []*os.File
+	defer func() {
+		for _, outputFile := range outputFiles {
+			outputFile.Close()
+		}
+	}()
+	exporter := slack.NewSplittingExporter(maxBytes, isZip, func(index int) (slack.Exporter, error) {
+		chunkPath := slack.ChunkPath(outputFilePath, index)
+		outputFile, err := os.Create(chunkPath)
+		if err != nil {
+			return nil, err
+		}
+		outputFiles = append(outputFiles, outputFile)
+		return slack.NewExporterForPath(outputFile, chunkPath), nil
+	})
+
+	lines := 0
+	err = slack.ReadImportLines(inputFile, func(line *app.LineImportData) error {
+		lines++
+		return exporter.WriteLine(line)
+	})
+	if err != nil {
+		return err
+	}
+	if err := exporter.Close(); err != nil {
+		return err
+	}
+
+	logger.Infof("Split the %d lines of %s into %d chunks, from %s to %s", lines, inputFilePath, exporter.Chunks(), slack.ChunkPath(outputFilePath, 1), slack.ChunkPath(outputFilePath, exporter.Chunks()))
+	return nil
+}
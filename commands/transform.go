@@ -1,16 +1,29 @@
 package commands
 
 import (
-	"archive/zip"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/mattermost/mattermost-server/v6/model"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/mattermost/mmetl/services/slack"
 )
 
+// maxTimestampOffset bounds --timestamp-offset, larger shifts being
+// certainly a mistake
+const maxTimestampOffset = 100 * 365 * 24 * time.Hour
+
+// coldBeforeLayout is the format of the --cold-before date
+const coldBeforeLayout = "2006-01-02"
+
 var TransformCmd = &cobra.Command{
 	Use:   "transform",
 	Short: "Transforms export files into Mattermost import files",
@@ -26,28 +39,120 @@ var TransformSlackCmd = &cobra.Command{
 }
 
 func init() {
-	TransformSlackCmd.Flags().StringP("team", "t", "", "an existing team in Mattermost to import the data into")
+	TransformSlackCmd.Flags().StringP("team", "t", "", "the team in Mattermost to import the data into, which must exist unless --create-team is set")
 	if err := TransformSlackCmd.MarkFlagRequired("team"); err != nil {
 		panic(err)
 	}
-	TransformSlackCmd.Flags().StringP("file", "f", "", "the Slack export file to transform")
+	TransformSlackCmd.Flags().Bool("create-team", false, "adds the team definition to the output so the import creates the team")
+	TransformSlackCmd.Flags().String("team-display-name", "", "the display name of the team created with --create-team, defaults to the team name")
+	TransformSlackCmd.Flags().StringP("file", "f", "", "the Slack export file to transform, either a path, an HTTP(S) URL of a server supporting range requests, or an s3://bucket/key or gs://bucket/key URL")
 	if err := TransformSlackCmd.MarkFlagRequired("file"); err != nil {
 		panic(err)
 	}
-	TransformSlackCmd.Flags().StringP("output", "o", "bulk-export.jsonl", "the output path")
+	TransformSlackCmd.Flags().StringSlice("supplemental-export", []string{}, "a per-user Slack export zipfile whose direct and group messages are merged into the export. Can be repeated")
+	TransformSlackCmd.Flags().StringArray("http-header", []string{}, "a \"Name: value\" header sent when reading the export files from a URL, e.g. for authentication. Can be repeated")
+	TransformSlackCmd.Flags().String("s3-endpoint", "", "the endpoint of the object storage for s3:// and gs:// exports, e.g. for MinIO. Defaults to the AWS S3 or Google Cloud Storage endpoint")
+	TransformSlackCmd.Flags().String("s3-region", "", "the region of the bucket of s3:// exports")
+	TransformSlackCmd.Flags().Bool("s3-insecure", false, "connects to the object storage endpoint over HTTP instead of HTTPS")
+	TransformSlackCmd.Flags().String("zip-password", "", "the password of encrypted export zipfiles (ZipCrypto or AES). Read from the MMETL_ZIP_PASSWORD environment variable when not set")
+	TransformSlackCmd.Flags().StringP("output", "o", "bulk-export.jsonl", "the output path. The output is written to <output>.partial and renamed once the transformation succeeded")
+	TransformSlackCmd.Flags().String("split-bytes", "", "splits the output into files of at most this size, e.g. 1GB, numbered before the extension of --output. Each file starts with the version line and must be imported in order. The attachments count towards the size of zip outputs")
+	TransformSlackCmd.Flags().String("output-per-channel", "", "writes the output to this directory instead of --output, with the posts of each channel in channels/<name>.jsonl, the direct and group messages in direct.jsonl and the other lines in users.jsonl. Import users.jsonl first, then the other files in any order, so the import of a single channel can be retried")
+	TransformSlackCmd.Flags().Bool("resume", false, "resumes an interrupted or crashed transformation from its last completed channel, appending the remaining channels to its <output>.partial file. Needs the checkpoint saved by that run, see --checkpoint-store. The post count, pins, warnings and failed attachments reports of the resumed run only cover the channels it transformed")
+	TransformSlackCmd.Flags().String("checkpoint-store", "file", "where the progress of the transformation is saved after each channel for --resume: the <output>.checkpoint \"file\", or \"redis\" with --redis-endpoint")
+	TransformSlackCmd.Flags().Bool("append", false, "resumes an interrupted transformation")
+	_ = TransformSlackCmd.Flags().MarkDeprecated("append", "use --resume instead")
 	TransformSlackCmd.Flags().StringP("attachments-dir", "d", "bulk-export-attachments", "the path for the attachments directory")
+	TransformSlackCmd.Flags().String("attachments-layout", "flat", "how the attachments are laid out in the attachments directory: \"flat\", \"channel\" for a subdirectory per channel, or \"hash\" for subdirectories named after the hash of the file ids")
+	TransformSlackCmd.Flags().String("scan-command", "", "a scanner command, e.g. \"clamscan --no-summary\", run on each attachment with its path as last argument. The attachments it exits with the status 1 for are moved to --quarantine-dir instead of being attached")
+	TransformSlackCmd.Flags().String("quarantine-dir", "", "the directory of the attachments flagged by --scan-command, with a report.json listing them. Defaults to <attachments-dir>.quarantine")
 	TransformSlackCmd.Flags().BoolP("skip-convert-posts", "c", false, "Skips converting mentions and post markup. Only for testing purposes")
 	TransformSlackCmd.Flags().BoolP("skip-attachments", "a", false, "Skips copying the attachments from the import file")
 	TransformSlackCmd.Flags().BoolP("discard-invalid-props", "p", false, "Skips converting posts with invalid props instead discarding the props themselves")
+	TransformSlackCmd.Flags().Bool("trim-oversized-props", false, "Drops the largest fields of the attachments of the posts whose props are too long, icons first, then text blocks, until they fit, instead of discarding the props or the post")
 	TransformSlackCmd.Flags().Bool("debug", true, "Whether to show debug logs or not")
 	TransformSlackCmd.Flags().Bool("auth-data-as-email", false, "Set auth data the same as user's email")
 	TransformSlackCmd.Flags().StringP("auth-service", "s", "", "Set auth service value for SSO using")
 	TransformSlackCmd.Flags().String("redis-endpoint", "", "redis endpoint")
 	TransformSlackCmd.Flags().String("redis-login", "", "redis user")
 	TransformSlackCmd.Flags().String("redis-password", "", "redis password")
+	TransformSlackCmd.Flags().Int("threads-cache-size", 0, "the maximum number of threads of a channel held in memory, the least recently used ones being evicted to redis, or to a temporary file without --redis-endpoint. Unlimited when zero")
 	TransformSlackCmd.Flags().Bool("import-workflow-messages", false, "import workflow messages")
+	TransformSlackCmd.Flags().Bool("import-slackbot-messages", false, "import the messages of Slackbot with the placeholder user \""+slack.SlackbotUserName+"\" instead of skipping them")
+	TransformSlackCmd.Flags().Bool("prettify-integrations", false, "Converts the attachments of GitHub, Jira and CI notifications into compact Markdown")
+	TransformSlackCmd.Flags().String("erasure-list", "", "a file with the emails or Slack user ids, one per line, of the data subjects whose data must be excluded")
+	TransformSlackCmd.Flags().String("erasure-report", "", "the path for the report of the erased data, defaults to <output>.erasure.json")
+	TransformSlackCmd.Flags().Int("dm-show-days", 0, "only shows in the sidebar the direct and group messages active during this many days before the last message of the export. 0 shows all of them")
+	TransformSlackCmd.Flags().String("channel-notify-props", "", "sets the notification preferences of the public channel memberships, either \"muted\" or \"mentions\"")
+	TransformSlackCmd.Flags().Bool("private-channel-notify-props", false, "also sets the --channel-notify-props preferences on the private channel memberships")
+	TransformSlackCmd.Flags().Int("max-replies-per-line", 0, "embeds at most this many replies in a post line, writing the extra replies to --replies-output. 0 embeds every reply")
+	TransformSlackCmd.Flags().String("cold-before", "", "writes the posts created before this date, as YYYY-MM-DD in UTC, to --cold-output instead of the output, to import the recent history first and the older one later")
+	TransformSlackCmd.Flags().String("cold-output", "", "the path for the posts older than --cold-before, to import once the main import finished. Defaults to <output> with a .cold suffix before the extension")
+	TransformSlackCmd.Flags().String("replies-output", "", "the path for the extra replies of --max-replies-per-line, to import once the main import finished. Defaults to <output> with a .replies suffix before the extension")
+	TransformSlackCmd.Flags().Bool("archive-mode", false, "posts the whole history with a single user, prefixing the author and time to each message, and imports no other user nor the direct and group messages")
+	TransformSlackCmd.Flags().String("archive-username", slack.DefaultArchiveUsername, "the username of the user posting the history with --archive-mode")
+	TransformSlackCmd.Flags().String("channel-name-report", "", "the path for the report of the channels renamed because their name is reserved in Mattermost, such as town-square, defaults to <output>.channel-names.json. Only written when channels were renamed")
+	TransformSlackCmd.Flags().Bool("skip-thumbnails", false, "leaves out the files of which the export only has the thumbnail, e.g. notes_thumb_360.png. The originals are always imported over their thumbnails")
+	TransformSlackCmd.Flags().Bool("thread-file-comments", false, "imports the comments of a file as replies to the message that shared it in the channel, instead of as messages of their own")
+	TransformSlackCmd.Flags().Int("attachment-retries", slack.DefaultAttachmentRetries, "the number of times a failed copy of an attachment is retried, waiting a little longer each time")
+	TransformSlackCmd.Flags().String("failed-attachments-report", "", "the path for the report of the attachments whose copy still failed after the retries, to copy with the transform retry-attachments command before the import, defaults to <output>.failed-attachments.json. With a zip output or --manifest, their posts are imported without them instead. Only written when copies failed")
+	TransformSlackCmd.Flags().Bool("custom-statuses", false, "keeps the status of the Slack profiles which didn't expire as the custom status of the users. The import format can't set it, they are listed in the --custom-status-report")
+	TransformSlackCmd.Flags().String("custom-status-report", "", "the path for the report of the custom statuses of --custom-statuses, to set with the API of the server after the import, defaults to <output>.custom-statuses.json. Only written when users have a custom status")
+	TransformSlackCmd.Flags().String("pins-report", "", "the path for the report of the posts pinned in Slack, which the import format can't pin, defaults to <output>.pins.json. Only written when posts were pinned")
+	TransformSlackCmd.Flags().Int("max-warnings", 10000, "the number of warnings, and of distinct warnings of the --warning-report, kept in memory, so they stay bounded on large exports. The counts still cover every warning, the report counting the ones beyond its distinct warnings by kind. 0 keeps them all")
+	TransformSlackCmd.Flags().String("warning-report", "", "the path for the report of the warnings raised during the transformation, each one once with its count and first occurrence, defaults to <output>.warnings.json. Only written when warnings were raised")
+	TransformSlackCmd.Flags().String("username-report", "", "the path for the report of the Slack usernames changed to be valid in Mattermost, defaults to <output>.usernames.json. Only written when usernames were changed")
+	TransformSlackCmd.Flags().String("id-mapping", "", "the path for a JSON file mapping the Slack ids of the users to their username and email, and of the channels to their name")
+	TransformSlackCmd.Flags().String("imported-mapping", "", "the --id-mapping file of a previous import of the users and channels. Only the posts are exported, those referencing users or channels missing from it are skipped")
+	TransformSlackCmd.Flags().String("post-count-report", "", "the path for a table comparing the messages of each channel of the export with the posts and replies emitted for it")
+	TransformSlackCmd.Flags().Bool("strict", false, "Fails on the first malformed or truncated file of the export instead of recovering its complete entries with a warning")
+	TransformSlackCmd.Flags().String("unknown-subtypes", string(slack.UnknownSubtypeSkip), "what to do with the messages of a subtype the tool does not support: \"skip\" them or import them as \"plain\" messages")
+	TransformSlackCmd.Flags().Duration("timestamp-offset", 0, "shifts the creation time of every post, e.g. \"-3h\" to correct an export produced with a wrong timezone. Posts shifted before 1970 or into the future are skipped")
+	TransformSlackCmd.Flags().String("validate", string(slack.ValidationOff), "validates the lines against the rules of the server import before writing them: \"off\", \"report\" the violations, or \"fix\" them when possible and skip the post lines that can't be fixed. The channel and user lines are always written, as the posts reference them")
+	TransformSlackCmd.Flags().String("validation-report", "", "the path for the report of the violations found with --validate, each one once with its count and first occurrence. Defaults to the output path with a .validation.json suffix, written only when there are violations")
+	TransformSlackCmd.Flags().String("slack-token", "", "a Slack API token used to complete the users missing an email or a name, to list the custom emojis with --emoji-dir and the user groups with --usergroup-default-channels and the custom profile fields of --position-field. Read from the MMETL_SLACK_TOKEN environment variable when not set")
+	TransformSlackCmd.Flags().String("slack-api-cache", "slack-api-cache", "the directory caching the Slack API responses, so a new run does not repeat the calls. Empty to disable the cache")
+	TransformSlackCmd.Flags().Duration("slack-api-interval", slack.DefaultSlackAPIInterval, "the minimum time between two Slack API calls")
+	TransformSlackCmd.Flags().StringSlice("only-channels", []string{}, "transforms only the channels whose Slack name or id matches one of these globs, e.g. \"eng-*\". A value starting with @ is a file of globs, one per line")
+	TransformSlackCmd.Flags().Bool("dms-only", false, "transforms only the direct and group messages and the users taking part in them, leaving out the public and private channels")
+	TransformSlackCmd.Flags().StringSlice("exclude-channels", []string{}, "leaves out the channels whose Slack name or id matches one of these globs, with their memberships, posts and attachments. A value starting with @ is a file of globs, one per line")
+	TransformSlackCmd.Flags().StringSlice("exclude-email-domains", []string{}, "excludes the users whose email is in one of these domains, e.g. contractors.example.com. Their messages are skipped unless --reassign-excluded-to is set")
+	TransformSlackCmd.Flags().String("reassign-excluded-to", "", "the username of the user the messages of the users excluded by --exclude-email-domains are reassigned to")
+	TransformSlackCmd.Flags().Bool("rewrite-permalinks", false, "replaces the links to Slack messages and channels with a reference to the imported channel and the time of the message")
+	TransformSlackCmd.Flags().String("site-url", "", "the URL of the Mattermost site, e.g. https://chat.example.com, to link the rewritten permalinks to the imported channels. Requires --rewrite-permalinks")
+	TransformSlackCmd.Flags().String("link-rewrites", "", "a JSON file mapping the URLs of the tools replaced along with Slack to the new ones, e.g. {\"https://trello.com/b/abc\": \"https://chat.example.com/boards/xyz\"}. The links starting with a URL are rewritten, the rest of the link being kept")
+	TransformSlackCmd.Flags().String("team-mapping", "", "a JSON file spreading the public and private channels over several teams, e.g. {\"teams\": [{\"prefix\": \"sec-\", \"team\": \"security\"}, {\"slack_team\": \"T123\", \"team\": \"core\"}]}. The first rule matching the Slack name or the Enterprise Grid workspace of a channel wins, the other channels are imported into --team. The teams are created with --create-team")
+	TransformSlackCmd.Flags().String("channel-prefix", "", "prepends this workspace identifier and a dash to the name and display name of the public and private channels, to import several workspaces in a team")
+	TransformSlackCmd.Flags().String("position-field", "title", "the comma separated fields of the Slack profiles the position of the users is taken from, the first one set winning: \"title\" or \"field:<id>\" for a custom field, e.g. \"field:Xf01ABCD,title\". With --slack-token, the custom fields can be given by label, e.g. \"field:Team\"")
+	TransformSlackCmd.Flags().String("channel-header", "topic", "what the header of the channels is made of: the \"topic\" of the Slack channel, its \"purpose\", or \"both\"")
+	TransformSlackCmd.Flags().String("custom-emoji-fallback", "", "the emoji replacing the custom emojis of the reactions, e.g. slightly_smiling_face. The reactions with a custom emoji are dropped when not set")
+	TransformSlackCmd.Flags().Bool("skip-one-off-reminders", false, "Skips the Slackbot reminders set up without a recurrence and the delivered reminders. The recurring reminders are summarized in a post per channel")
+	TransformSlackCmd.Flags().Bool("provenance", false, "Writes the source, the date range of the export, the version of mmetl and the flags used to <output>.provenance.json")
+	TransformSlackCmd.Flags().Bool("manifest", false, "Writes the SHA-256 checksums of the output file and the attachments to <output>.sha256")
+	TransformSlackCmd.Flags().Bool("legal-hold", false, "Imports the previous revisions of edited messages and the deleted messages present in compliance exports")
+	TransformSlackCmd.Flags().String("replace-rules", "", "a JSON file of rules replacing texts in the message of the posts, e.g. [{\"search\": \"wiki.old.corp\", \"replace\": \"wiki.corp\"}], with \"regex\": true to search a regular expression")
+	TransformSlackCmd.Flags().String("replace-report", "", "the path for a JSON report of the replacements made by each of the --replace-rules")
+	TransformSlackCmd.Flags().Bool("download-attachment-images", false, "Downloads the images of the message attachments hosted by Slack and imports them as files of their post, as the Slack URLs stop working once the workspace is deleted. Uses --slack-token for the private images")
+	TransformSlackCmd.Flags().Bool("usergroup-default-channels", false, "adds the members of the Slack user groups to the default channels of their groups. The groups are read from the usergroups.json file of the export or, with --slack-token, from the Slack API")
+	TransformSlackCmd.Flags().String("emoji-dir", "", "imports the custom emojis of the workspace, listed by the emoji.json file of the export or, with --slack-token, by the Slack API. Their images are read from this directory, named after the emojis, and the missing ones are downloaded into it")
+	TransformSlackCmd.Flags().Bool("download-avatars", false, "Downloads the profile photos of the users, in the largest size cropped by Slack that fits the profile images of Mattermost, and imports them as their profile image. The placeholders of the users without a photo are left out")
+	TransformSlackCmd.Flags().Bool("thread-participant-props", false, "Copies the reply count and the participants of the threads into the props of their root post, e.g. for analytics")
+	TransformSlackCmd.Flags().Bool("attribute-app-uploads", false, "attributes the files shared by apps to the users that uploaded them instead of the workflow user, when the uploader is known from --audit-log or from the files")
+	TransformSlackCmd.Flags().String("audit-log", "", "a JSON file with the Slack audit log entries, whose file_uploaded actions give the uploaders of the files for --attribute-app-uploads")
+	TransformSlackCmd.Flags().String("user-map", "", "a CSV or JSON file mapping the Slack user ids to the username and email of existing Mattermost accounts, as lines of slack_id,username,email or as {\"U01ABCD\": {\"username\": \"john\", \"email\": \"john@example.com\"}}. The users missing from it are reported in the warnings")
+	TransformSlackCmd.Flags().Bool("bot-users", false, "imports the bot messages with a user per Slack bot, found in the bots.json file of the export and in the messages, instead of the \""+slack.WorkflowUserName+"\" user. The bots missing from both are still handled by --import-workflow-messages")
+	TransformSlackCmd.Flags().String("bot-aliases", "", "a JSON file mapping Slack bot ids or bot usernames to existing Mattermost usernames")
+	TransformSlackCmd.Flags().Bool("archive-slack-archived", false, "lists the channels archived in Slack in the --archived-channel-report, to archive them once the import finished")
+	TransformSlackCmd.Flags().Int("archive-inactive-months", 0, "lists the channels without messages during this number of months before the end of the export in the --archived-channel-report, to archive them once the import finished")
+	TransformSlackCmd.Flags().Int("archive-max-messages", 0, "only lists the inactive channels of --archive-inactive-months with at most this number of messages")
+	TransformSlackCmd.Flags().String("archived-channel-report", "", "the path for the report of the channels to archive once the import finished, as the import can't archive them, defaults to <output>.archived-channels.json. Only written when channels are to be archived")
+	TransformSlackCmd.Flags().Int("group-to-private-months", 0, "converts the group messages started more than this number of months before the end of the export into private channels")
+	TransformSlackCmd.Flags().Int("group-to-private-messages", 0, "converts the group messages with more than this number of messages into private channels")
+	TransformSlackCmd.Flags().Int("skip-bot-channels", 0, "skips the public and private channels where more than this percentage of the messages are bot messages, e.g. 90 for the channels of monitoring alerts")
+	TransformSlackCmd.Flags().Int("bot-channels-keep-every", 0, "keeps the channels found by --skip-bot-channels with one of every N bot messages instead of skipping them")
 	TransformSlackCmd.Flags().Bool("skip-posts", false, "do not import posts")
 	TransformSlackCmd.Flags().Bool("skip-channels", false, "do not import channels and posts")
+	TransformSlackCmd.Flags().Bool("users-only", false, "only import the users and their team membership, e.g. to create the accounts ahead of the migration of the content. It can't be used with --emoji-dir or --custom-statuses")
 	TransformCmd.AddCommand(
 		TransformSlackCmd,
 	)
@@ -59,24 +164,282 @@ func init() {
 
 func transformSlackCmdF(cmd *cobra.Command, args []string) error {
 	team, _ := cmd.Flags().GetString("team")
+	createTeam, _ := cmd.Flags().GetBool("create-team")
+	teamDisplayName, _ := cmd.Flags().GetString("team-display-name")
 	inputFilePath, _ := cmd.Flags().GetString("file")
 	outputFilePath, _ := cmd.Flags().GetString("output")
+	appendOutput, _ := cmd.Flags().GetBool("append")
+	resume, _ := cmd.Flags().GetBool("resume")
+	appendOutput = appendOutput || resume
+	checkpointStoreFlag, _ := cmd.Flags().GetString("checkpoint-store")
+	splitBytesFlag, _ := cmd.Flags().GetString("split-bytes")
+	outputPerChannelDir, _ := cmd.Flags().GetString("output-per-channel")
+	supplementalExportPaths, _ := cmd.Flags().GetStringSlice("supplemental-export")
+	httpHeaders, _ := cmd.Flags().GetStringArray("http-header")
+	s3Endpoint, _ := cmd.Flags().GetString("s3-endpoint")
+	s3Region, _ := cmd.Flags().GetString("s3-region")
+	s3Insecure, _ := cmd.Flags().GetBool("s3-insecure")
+	zipPassword, _ := cmd.Flags().GetString("zip-password")
+	if zipPassword == "" {
+		zipPassword = os.Getenv("MMETL_ZIP_PASSWORD")
+	}
 	attachmentsDir, _ := cmd.Flags().GetString("attachments-dir")
+	attachmentsLayoutFlag, _ := cmd.Flags().GetString("attachments-layout")
+	scanCommand, _ := cmd.Flags().GetString("scan-command")
+	quarantineDir, _ := cmd.Flags().GetString("quarantine-dir")
 	skipConvertPosts, _ := cmd.Flags().GetBool("skip-convert-posts")
 	skipAttachments, _ := cmd.Flags().GetBool("skip-attachments")
 	discardInvalidProps, _ := cmd.Flags().GetBool("discard-invalid-props")
+	trimOversizedProps, _ := cmd.Flags().GetBool("trim-oversized-props")
 	redisEndpoint, _ := cmd.Flags().GetString("redis-endpoint")
 	redisLogin, _ := cmd.Flags().GetString("redis-login")
 	redisPassword, _ := cmd.Flags().GetString("redis-password")
+	threadsCacheSize, _ := cmd.Flags().GetInt("threads-cache-size")
 	debug, _ := cmd.Flags().GetBool("debug")
 	setAuthDataAsEmail, _ := cmd.Flags().GetBool("auth-data-as-email")
 	authService, _ := cmd.Flags().GetString("auth-service")
 	importWorkflowMessages, _ := cmd.Flags().GetBool("import-workflow-messages")
+	importSlackbotMessages, _ := cmd.Flags().GetBool("import-slackbot-messages")
 	skipPosts, _ := cmd.Flags().GetBool("skip-posts")
 	skipChannels, _ := cmd.Flags().GetBool("skip-channels")
+	usersOnly, _ := cmd.Flags().GetBool("users-only")
+	botAliasesPath, _ := cmd.Flags().GetString("bot-aliases")
+	botUsers, _ := cmd.Flags().GetBool("bot-users")
+	userMapPath, _ := cmd.Flags().GetString("user-map")
+	attributeAppUploads, _ := cmd.Flags().GetBool("attribute-app-uploads")
+	auditLogPath, _ := cmd.Flags().GetString("audit-log")
+	replaceRulesPath, _ := cmd.Flags().GetString("replace-rules")
+	replaceReportPath, _ := cmd.Flags().GetString("replace-report")
+	threadParticipantProps, _ := cmd.Flags().GetBool("thread-participant-props")
+	downloadAttachmentImages, _ := cmd.Flags().GetBool("download-attachment-images")
+	downloadAvatars, _ := cmd.Flags().GetBool("download-avatars")
+	emojiDir, _ := cmd.Flags().GetString("emoji-dir")
+	userGroupDefaultChannels, _ := cmd.Flags().GetBool("usergroup-default-channels")
+	archiveSlackArchived, _ := cmd.Flags().GetBool("archive-slack-archived")
+	archiveInactiveMonths, _ := cmd.Flags().GetInt("archive-inactive-months")
+	archiveMaxMessages, _ := cmd.Flags().GetInt("archive-max-messages")
+	archivedChannelReportPath, _ := cmd.Flags().GetString("archived-channel-report")
+	groupToPrivateMonths, _ := cmd.Flags().GetInt("group-to-private-months")
+	groupToPrivateMessages, _ := cmd.Flags().GetInt("group-to-private-messages")
+	skipBotChannels, _ := cmd.Flags().GetInt("skip-bot-channels")
+	botChannelsKeepEvery, _ := cmd.Flags().GetInt("bot-channels-keep-every")
+	prettifyIntegrations, _ := cmd.Flags().GetBool("prettify-integrations")
+	legalHold, _ := cmd.Flags().GetBool("legal-hold")
+	writeManifest, _ := cmd.Flags().GetBool("manifest")
+	writeProvenance, _ := cmd.Flags().GetBool("provenance")
+	dmShowDays, _ := cmd.Flags().GetInt("dm-show-days")
+	channelNotifyPreset, _ := cmd.Flags().GetString("channel-notify-props")
+	privateChannelNotifyProps, _ := cmd.Flags().GetBool("private-channel-notify-props")
+	erasureListPath, _ := cmd.Flags().GetString("erasure-list")
+	erasureReportPath, _ := cmd.Flags().GetString("erasure-report")
+	maxRepliesPerLine, _ := cmd.Flags().GetInt("max-replies-per-line")
+	usernameReportPath, _ := cmd.Flags().GetString("username-report")
+	channelNameReportPath, _ := cmd.Flags().GetString("channel-name-report")
+	pinsReportPath, _ := cmd.Flags().GetString("pins-report")
+	customStatuses, _ := cmd.Flags().GetBool("custom-statuses")
+	skipThumbnails, _ := cmd.Flags().GetBool("skip-thumbnails")
+	attachmentRetries, _ := cmd.Flags().GetInt("attachment-retries")
+	threadFileComments, _ := cmd.Flags().GetBool("thread-file-comments")
+	failedAttachmentsReportPath, _ := cmd.Flags().GetString("failed-attachments-report")
+	customStatusReportPath, _ := cmd.Flags().GetString("custom-status-report")
+	warningReportPath, _ := cmd.Flags().GetString("warning-report")
+	maxWarnings, _ := cmd.Flags().GetInt("max-warnings")
+	postCountReportPath, _ := cmd.Flags().GetString("post-count-report")
+	idMappingPath, _ := cmd.Flags().GetString("id-mapping")
+	importedMappingPath, _ := cmd.Flags().GetString("imported-mapping")
+	archiveMode, _ := cmd.Flags().GetBool("archive-mode")
+	archiveUsername, _ := cmd.Flags().GetString("archive-username")
+	repliesOutputPath, _ := cmd.Flags().GetString("replies-output")
+	coldBeforeFlag, _ := cmd.Flags().GetString("cold-before")
+	coldOutputPath, _ := cmd.Flags().GetString("cold-output")
+	strict, _ := cmd.Flags().GetBool("strict")
+	unknownSubtypesFlag, _ := cmd.Flags().GetString("unknown-subtypes")
+	timestampOffset, _ := cmd.Flags().GetDuration("timestamp-offset")
+	validateFlag, _ := cmd.Flags().GetString("validate")
+	validationReportPath, _ := cmd.Flags().GetString("validation-report")
+	slackToken, _ := cmd.Flags().GetString("slack-token")
+	slackAPICache, _ := cmd.Flags().GetString("slack-api-cache")
+	slackAPIInterval, _ := cmd.Flags().GetDuration("slack-api-interval")
+	excludeEmailDomains, _ := cmd.Flags().GetStringSlice("exclude-email-domains")
+	onlyChannelsFlag, _ := cmd.Flags().GetStringSlice("only-channels")
+	excludeChannelsFlag, _ := cmd.Flags().GetStringSlice("exclude-channels")
+	dmsOnly, _ := cmd.Flags().GetBool("dms-only")
+	reassignExcludedTo, _ := cmd.Flags().GetString("reassign-excluded-to")
+	channelHeaderFlag, _ := cmd.Flags().GetString("channel-header")
+	positionField, _ := cmd.Flags().GetString("position-field")
+	channelPrefix, _ := cmd.Flags().GetString("channel-prefix")
+	teamMappingPath, _ := cmd.Flags().GetString("team-mapping")
+	rewritePermalinks, _ := cmd.Flags().GetBool("rewrite-permalinks")
+	siteURL, _ := cmd.Flags().GetString("site-url")
+	linkRewritesPath, _ := cmd.Flags().GetString("link-rewrites")
+	customEmojiFallback, _ := cmd.Flags().GetString("custom-emoji-fallback")
+	skipOneOffReminders, _ := cmd.Flags().GetBool("skip-one-off-reminders")
 
+	if usersOnly {
+		if archiveMode {
+			return errors.New("--users-only can't be used with --archive-mode, which imports the archive user only")
+		}
+		if emojiDir != "" || customStatuses {
+			return errors.New("--users-only imports the user and team membership lines only, it can't be used with --emoji-dir or --custom-statuses")
+		}
+		skipChannels, skipPosts = true, true
+	}
 	skipConvertPosts = skipConvertPosts || skipPosts
 
+	if createTeam && !model.IsValidTeamName(team) {
+		return fmt.Errorf("\"%s\" is not a valid team name to create", team)
+	}
+	if teamDisplayName != "" && !createTeam {
+		return errors.New("--team-display-name requires --create-team")
+	}
+
+	archiveUser := ""
+	if archiveMode {
+		if !model.IsValidUsername(archiveUsername) {
+			return fmt.Errorf("\"%s\" is not a valid username for the archive user", archiveUsername)
+		}
+		archiveUser = archiveUsername
+	}
+
+	var groupChannelPolicy *slack.GroupChannelPolicy
+	if groupToPrivateMonths < 0 || groupToPrivateMessages < 0 {
+		return errors.New("--group-to-private-months and --group-to-private-messages must not be negative")
+	}
+	if groupToPrivateMonths > 0 || groupToPrivateMessages > 0 {
+		groupChannelPolicy = &slack.GroupChannelPolicy{MinAgeMonths: groupToPrivateMonths, MinMessages: groupToPrivateMessages}
+	}
+
+	var channelArchivePolicy *slack.ChannelArchivePolicy
+	if archiveInactiveMonths < 0 || archiveMaxMessages < 0 {
+		return errors.New("--archive-inactive-months and --archive-max-messages must not be negative")
+	}
+	if archiveMaxMessages > 0 && archiveInactiveMonths == 0 {
+		return errors.New("--archive-max-messages requires --archive-inactive-months")
+	}
+	if archiveSlackArchived || archiveInactiveMonths > 0 {
+		channelArchivePolicy = &slack.ChannelArchivePolicy{SlackArchived: archiveSlackArchived, InactiveMonths: archiveInactiveMonths, MaxMessages: archiveMaxMessages}
+	}
+
+	var botChannelPolicy *slack.BotChannelPolicy
+	if skipBotChannels < 0 || skipBotChannels > 100 {
+		return fmt.Errorf("--skip-bot-channels %d must be a percentage between 0 and 100", skipBotChannels)
+	}
+	if botChannelsKeepEvery < 0 {
+		return fmt.Errorf("--bot-channels-keep-every %d must not be negative", botChannelsKeepEvery)
+	}
+	if botChannelsKeepEvery > 0 && skipBotChannels == 0 {
+		return errors.New("--bot-channels-keep-every requires --skip-bot-channels")
+	}
+	if skipBotChannels > 0 {
+		botChannelPolicy = &slack.BotChannelPolicy{Threshold: float64(skipBotChannels) / 100, KeepEvery: botChannelsKeepEvery}
+	}
+
+	if threadsCacheSize < 0 {
+		return fmt.Errorf("--threads-cache-size %d must not be negative", threadsCacheSize)
+	}
+
+	if timestampOffset%time.Millisecond != 0 {
+		return fmt.Errorf("--timestamp-offset %s is not a whole number of milliseconds", timestampOffset)
+	}
+	if timestampOffset > maxTimestampOffset || timestampOffset < -maxTimestampOffset {
+		return fmt.Errorf("--timestamp-offset %s exceeds the maximum of %s", timestampOffset, maxTimestampOffset)
+	}
+
+	unknownSubtypes, err := slack.ParseUnknownSubtypePolicy(unknownSubtypesFlag)
+	if err != nil {
+		return err
+	}
+
+	attachmentsLayout, err := slack.ParseAttachmentsLayout(attachmentsLayoutFlag)
+	if err != nil {
+		return err
+	}
+
+	if downloadAttachmentImages && skipAttachments {
+		return errors.New("--download-attachment-images cannot be used with --skip-attachments")
+	}
+	if downloadAvatars && skipAttachments {
+		return errors.New("--download-avatars cannot be used with --skip-attachments")
+	}
+
+	var attachmentScanner *slack.AttachmentScanner
+	if scanCommand != "" {
+		if skipAttachments {
+			return errors.New("--scan-command cannot be used with --skip-attachments")
+		}
+		if quarantineDir == "" {
+			quarantineDir = attachmentsDir + ".quarantine"
+		}
+		attachmentScanner, err = slack.NewAttachmentScanner(scanCommand, quarantineDir)
+		if err != nil {
+			return err
+		}
+	} else if quarantineDir != "" {
+		return errors.New("--quarantine-dir requires --scan-command")
+	}
+
+	if customStatusReportPath != "" && !customStatuses {
+		return errors.New("--custom-status-report requires --custom-statuses")
+	}
+
+	channelHeader, err := slack.ParseChannelHeaderSource(channelHeaderFlag)
+	if err != nil {
+		return err
+	}
+
+	positionSource, err := slack.ParsePositionSource(positionField)
+	if err != nil {
+		return err
+	}
+
+	var channelFilter *slack.ChannelFilter
+	if len(onlyChannelsFlag) > 0 || len(excludeChannelsFlag) > 0 || dmsOnly {
+		channelFilter = &slack.ChannelFilter{DirectOnly: dmsOnly}
+		if channelFilter.Only, err = slack.ParseChannelPatterns(onlyChannelsFlag); err != nil {
+			return err
+		}
+		if channelFilter.Exclude, err = slack.ParseChannelPatterns(excludeChannelsFlag); err != nil {
+			return err
+		}
+	}
+
+	if channelPrefix != "" {
+		if err := slack.ValidateChannelPrefix(channelPrefix); err != nil {
+			return err
+		}
+	}
+
+	if siteURL != "" {
+		if !rewritePermalinks {
+			return errors.New("--site-url requires --rewrite-permalinks")
+		}
+		if err := slack.ValidateSiteURL(siteURL); err != nil {
+			return err
+		}
+	}
+
+	if reassignExcludedTo != "" && len(excludeEmailDomains) == 0 {
+		return errors.New("--reassign-excluded-to requires --exclude-email-domains")
+	}
+
+	validationMode, err := slack.ParseValidationMode(validateFlag)
+	if err != nil {
+		return err
+	}
+
+	customEmojiFallback = strings.Trim(customEmojiFallback, ":")
+	if customEmojiFallback != "" && !slack.IsSystemEmoji(customEmojiFallback) {
+		return fmt.Errorf("\"%s\" is not an emoji of Mattermost", customEmojiFallback)
+	}
+
+	var channelNotifyProps *slack.ChannelNotifyProps
+	if channelNotifyPreset != "" {
+		var err error
+		if channelNotifyProps, err = slack.ParseChannelNotifyProps(channelNotifyPreset); err != nil {
+			return err
+		}
+	}
+
 	// output file
 	if fileInfo, err := os.Stat(outputFilePath); err != nil && !os.IsNotExist(err) {
 		return err
@@ -84,6 +447,88 @@ func transformSlackCmdF(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Output file \"%s\" is a directory", outputFilePath)
 	}
 
+	var coldBefore time.Time
+	if coldBeforeFlag != "" {
+		if coldBefore, err = time.Parse(coldBeforeLayout, coldBeforeFlag); err != nil {
+			return fmt.Errorf("invalid --cold-before date %q, expected YYYY-MM-DD", coldBeforeFlag)
+		}
+	}
+
+	var splitBytes int64
+	if splitBytesFlag != "" {
+		if splitBytes, err = slack.ParseByteSize(splitBytesFlag); err != nil {
+			return err
+		}
+		if appendOutput {
+			return errors.New("--resume is not supported with --split-bytes")
+		}
+	}
+
+	if outputPerChannelDir != "" {
+		if splitBytes > 0 {
+			return errors.New("--output-per-channel is not supported with --split-bytes")
+		}
+		if appendOutput {
+			return errors.New("--resume is not supported with --output-per-channel")
+		}
+		if err := os.MkdirAll(filepath.Join(outputPerChannelDir, filepath.Dir(slack.ChannelOutputPath("channel"))), 0755); err != nil {
+			return err
+		}
+		// the reports and side outputs named after the output are
+		// written along the files of the channels
+		outputFilePath = filepath.Join(outputPerChannelDir, filepath.Base(outputFilePath))
+	}
+
+	var redisConfig *slack.RedisConfig
+	if len(redisEndpoint) > 0 {
+		redisConfig = &slack.RedisConfig{
+			Addr:     redisEndpoint,
+			User:     redisLogin,
+			Password: redisPassword,
+		}
+	}
+
+	// the progress is saved after each channel, so an interrupted or
+	// crashed run can be resumed
+	checkpointPath := outputFilePath + ".checkpoint"
+	var checkpointStore slack.CheckpointStore
+	switch checkpointStoreFlag {
+	case "file":
+		checkpointStore = &slack.FileCheckpointStore{Path: checkpointPath}
+	case "redis":
+		if redisConfig == nil {
+			return errors.New("--checkpoint-store redis requires --redis-endpoint")
+		}
+		absOutputPath, err := filepath.Abs(outputFilePath)
+		if err != nil {
+			return err
+		}
+		checkpointPath = "mmetl:checkpoint:" + absOutputPath
+		if checkpointStore, err = slack.NewRedisCheckpointStore(redisConfig, checkpointPath); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown checkpoint store %q, expected \"file\" or \"redis\"", checkpointStoreFlag)
+	}
+	// the runs writing a single JSONL output can be resumed
+	resumable := !strings.EqualFold(filepath.Ext(outputFilePath), ".zip") && splitBytes == 0 && outputPerChannelDir == "" && !writeManifest
+	var checkpoint *slack.Checkpoint
+	if appendOutput {
+		if strings.EqualFold(filepath.Ext(outputFilePath), ".zip") {
+			return fmt.Errorf("--resume is not supported with a zip output")
+		}
+		// the attachments of the lines written by the interrupted run
+		// would be missing from the manifest
+		if writeManifest {
+			return fmt.Errorf("--resume is not supported with --manifest")
+		}
+		if checkpoint, err = checkpointStore.Load(); err != nil {
+			return fmt.Errorf("--resume needs the checkpoint of an interrupted run: %w", err)
+		}
+	} else if err := checkpointStore.Remove(); err != nil {
+		return err
+	}
+
 	// attachments dir
 	if !skipAttachments {
 		if fileInfo, err := os.Stat(attachmentsDir); os.IsNotExist(err) {
@@ -97,21 +542,169 @@ func transformSlackCmdF(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// input file
-	fileReader, err := os.Open(inputFilePath)
-	if err != nil {
-		return err
+	// mapping of the users and channels imported already
+	var importedMapping *slack.IDMapping
+	if importedMappingPath != "" {
+		if usersOnly || skipPosts || skipChannels {
+			return errors.New("--imported-mapping exports only the posts, it can't be used with --users-only, --skip-posts or --skip-channels")
+		}
+		if archiveMode || createTeam {
+			return errors.New("--imported-mapping can't be used with --archive-mode or --create-team")
+		}
+		mappingReader, err := os.Open(importedMappingPath)
+		if err != nil {
+			return err
+		}
+		importedMapping, err = slack.ParseIDMapping(mappingReader)
+		mappingReader.Close()
+		if err != nil {
+			return fmt.Errorf("could not parse imported mapping file \"%s\": %w", importedMappingPath, err)
+		}
+		if importedMapping.Team != team {
+			return fmt.Errorf("the imported mapping is for the team \"%s\", not \"%s\"", importedMapping.Team, team)
+		}
+	}
+
+	// link rewrites file
+	var linkRewrites slack.LinkRewrites
+	if linkRewritesPath != "" {
+		rewritesReader, err := os.Open(linkRewritesPath)
+		if err != nil {
+			return err
+		}
+		linkRewrites, err = slack.ParseLinkRewrites(rewritesReader)
+		rewritesReader.Close()
+		if err != nil {
+			return fmt.Errorf("could not parse link rewrites file \"%s\": %w", linkRewritesPath, err)
+		}
+	}
+
+	// bot aliases file
+	var botAliases slack.BotAliases
+	if botAliasesPath != "" {
+		aliasesReader, err := os.Open(botAliasesPath)
+		if err != nil {
+			return err
+		}
+		defer aliasesReader.Close()
+
+		botAliases, err = slack.ParseBotAliases(aliasesReader)
+		if err != nil {
+			return fmt.Errorf("could not parse bot aliases file \"%s\": %w", botAliasesPath, err)
+		}
+	}
+
+	// audit log file
+	var fileUploaders slack.FileUploaders
+	if auditLogPath != "" {
+		if !attributeAppUploads {
+			return errors.New("--audit-log requires --attribute-app-uploads")
+		}
+		auditLogReader, err := os.Open(auditLogPath)
+		if err != nil {
+			return err
+		}
+		defer auditLogReader.Close()
+
+		fileUploaders, err = slack.ParseAuditLogs(auditLogReader)
+		if err != nil {
+			return fmt.Errorf("could not parse audit log file \"%s\": %w", auditLogPath, err)
+		}
 	}
-	defer fileReader.Close()
 
-	zipFileInfo, err := fileReader.Stat()
+	// replace rules file
+	var replaceRules *slack.ReplaceRules
+	if replaceRulesPath != "" {
+		rulesReader, err := os.Open(replaceRulesPath)
+		if err != nil {
+			return err
+		}
+		defer rulesReader.Close()
+
+		replaceRules, err = slack.ParseReplaceRules(rulesReader)
+		if err != nil {
+			return fmt.Errorf("could not parse replace rules file \"%s\": %w", replaceRulesPath, err)
+		}
+	} else if replaceReportPath != "" {
+		return errors.New("--replace-report requires --replace-rules")
+	}
+
+	// team mapping file
+	var teamMapping slack.TeamMapping
+	if teamMappingPath != "" {
+		teamMappingReader, err := os.Open(teamMappingPath)
+		if err != nil {
+			return err
+		}
+		defer teamMappingReader.Close()
+
+		teamMapping, err = slack.ParseTeamMapping(teamMappingReader)
+		if err != nil {
+			return fmt.Errorf("could not parse team mapping file \"%s\": %w", teamMappingPath, err)
+		}
+	}
+
+	// user map file
+	var userMap slack.UserMap
+	if userMapPath != "" {
+		userMapReader, err := os.Open(userMapPath)
+		if err != nil {
+			return err
+		}
+		defer userMapReader.Close()
+
+		userMap, err = slack.ParseUserMap(userMapReader)
+		if err != nil {
+			return fmt.Errorf("could not parse user map file \"%s\": %w", userMapPath, err)
+		}
+	}
+
+	// erasure list file
+	var erasureList *slack.ErasureList
+	if erasureListPath != "" {
+		erasureReader, err := os.Open(erasureListPath)
+		if err != nil {
+			return err
+		}
+		defer erasureReader.Close()
+
+		erasureList, err = slack.ParseErasureList(erasureReader)
+		if err != nil {
+			return fmt.Errorf("could not parse erasure list file \"%s\": %w", erasureListPath, err)
+		}
+		if erasureReportPath == "" {
+			erasureReportPath = outputFilePath + ".erasure.json"
+		}
+	}
+
+	// input file
+	source := exportSource{
+		httpHeaders: httpHeaders,
+		objectStorage: slack.ObjectStorageConfig{
+			Endpoint: s3Endpoint,
+			Region:   s3Region,
+			Insecure: s3Insecure,
+		},
+	}
+	fileReader, fileSize, closeFile, err := openExport(cmd.Context(), inputFilePath, source)
 	if err != nil {
 		return err
 	}
+	defer closeFile()
 
-	zipReader, err := zip.NewReader(fileReader, zipFileInfo.Size())
-	if err != nil || zipReader.File == nil {
-		return err
+	supplementalExports := []fs.FS{}
+	for _, supplementalExportPath := range supplementalExportPaths {
+		supplementalFile, supplementalFileSize, closeSupplementalFile, err := openExport(cmd.Context(), supplementalExportPath, source)
+		if err != nil {
+			return err
+		}
+		defer closeSupplementalFile()
+
+		supplementalFS, err := slack.OpenZipFS(supplementalFile, supplementalFileSize, zipPassword)
+		if err != nil {
+			return fmt.Errorf("could not open supplemental export \"%s\": %w", supplementalExportPath, err)
+		}
+		supplementalExports = append(supplementalExports, supplementalFS)
 	}
 
 	logger := log.New()
@@ -119,41 +712,669 @@ func transformSlackCmdF(cmd *cobra.Command, args []string) error {
 	if debug {
 		logger.Level = log.DebugLevel
 	}
-	slackTransformer := slack.NewTransformer(team, logger)
 
-	slackExport, err := slackTransformer.ParseSlackExportFile(zipReader, skipConvertPosts)
-	if err != nil {
+	if slackToken == "" {
+		slackToken = os.Getenv("MMETL_SLACK_TOKEN")
+	}
+	var slackAPI *slack.SlackAPIClient
+	if slackToken != "" {
+		slackAPI = slack.NewSlackAPIClient(slackToken, slackAPICache, logger)
+		slackAPI.Interval = slackAPIInterval
+	}
+	var attachmentImages *slack.AttachmentImageDownloader
+	if downloadAttachmentImages {
+		attachmentImages = slack.NewAttachmentImageDownloader(slackToken)
+	}
+	var avatarDownloader *slack.AttachmentImageDownloader
+	if downloadAvatars {
+		avatarDownloader = slack.NewAttachmentImageDownloader("")
+	}
+	var customEmojis *slack.CustomEmojiConfig
+	if emojiDir != "" {
+		customEmojis = &slack.CustomEmojiConfig{
+			Dir:        emojiDir,
+			Downloader: slack.NewAttachmentImageDownloader(""),
+		}
+		if slackAPI != nil {
+			if customEmojis.Emojis, err = slackAPI.EmojiList(cmd.Context()); err != nil {
+				return fmt.Errorf("could not list the custom emojis: %w", err)
+			}
+		}
+	}
+	if slackAPI != nil && strings.Contains(positionField, "field:") {
+		labels, err := slackAPI.ProfileFields(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("could not list the custom profile fields: %w", err)
+		}
+		positionSource = positionSource.WithFieldLabels(labels)
+	}
+	var userGroups []slack.SlackUserGroup
+	if userGroupDefaultChannels && slackAPI != nil {
+		if userGroups, err = slackAPI.UserGroups(cmd.Context()); err != nil {
+			return fmt.Errorf("could not list the user groups: %w", err)
+		}
+	}
+
+	// the output files are the chunks of the output when splitting it
+	var outputFiles []*os.File
+	var outputPaths []string
+	var outputTimings []*slack.TimingExporter
+	defer func() {
+		for _, outputFile := range outputFiles {
+			outputFile.Close()
+		}
+	}()
+	openOutput := func(outputPath string) (slack.Exporter, error) {
+		outputFile, err := openPartialOutput(outputPath, checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		outputFiles = append(outputFiles, outputFile)
+		outputPaths = append(outputPaths, outputPath)
+		timingExporter := slack.NewTimingExporter(slack.NewExporterForPath(outputFile, outputPath))
+		outputTimings = append(outputTimings, timingExporter)
+		return timingExporter, nil
+	}
+
+	timings := &slack.Timings{}
+
+	var exporter slack.Exporter
+	if outputPerChannelDir != "" {
+		exporter = slack.NewChannelExporter(func(name string) (slack.Exporter, error) {
+			return openOutput(filepath.Join(outputPerChannelDir, filepath.FromSlash(name)))
+		})
+	} else if splitBytes > 0 {
+		// the attachments are part of the size of the zip archives
+		isZip := strings.EqualFold(filepath.Ext(outputFilePath), ".zip")
+		exporter = slack.NewSplittingExporter(splitBytes, isZip, func(index int) (slack.Exporter, error) {
+			return openOutput(slack.ChunkPath(outputFilePath, index))
+		})
+	} else if exporter, err = openOutput(outputFilePath); err != nil {
 		return err
 	}
+	manifest := &slack.Manifest{}
+	// the attachments are inside the archive when exporting to a zip
+	// file, so only the archive itself needs a checksum
+	if writeManifest && !strings.EqualFold(filepath.Ext(outputFilePath), ".zip") {
+		exporter = slack.NewManifestExporter(exporter, manifest)
+	}
+	// the lines are validated first, so the skipped ones are not
+	// part of the output
+	validatingExporter := slack.NewValidatingExporter(exporter, validationMode, logger, maxWarnings)
+	exporter = validatingExporter
+	validatingExporters := []*slack.ValidatingExporter{validatingExporter}
 
-	var redisConfig *slack.RedisConfig
-	if len(redisEndpoint) > 0 {
-		redisConfig = &slack.RedisConfig{
-			Addr:     redisEndpoint,
-			User:     redisLogin,
-			Password: redisPassword,
+	var manifestForSideOutputs *slack.Manifest
+	if writeManifest {
+		manifestForSideOutputs = manifest
+	}
+	sideOutputs := []*sideOutput{}
+	defer func() {
+		for _, output := range sideOutputs {
+			output.file.Close()
+		}
+	}()
+	openSideOutput := func(outputPath, suffix string) (slack.Exporter, error) {
+		if outputPath == "" {
+			extension := filepath.Ext(outputFilePath)
+			outputPath = strings.TrimSuffix(outputFilePath, extension) + suffix + extension
+		}
+		output, err := newSideOutput(outputPath, checkpoint, manifestForSideOutputs)
+		if err != nil {
+			return nil, err
+		}
+		sideOutputs = append(sideOutputs, output)
+		validatingSideExporter := slack.NewValidatingExporter(output.exporter, validationMode, logger, maxWarnings)
+		validatingExporters = append(validatingExporters, validatingSideExporter)
+		output.exporter = validatingSideExporter
+		return output.exporter, nil
+	}
+
+	// the extra replies of the split post lines go to a second import
+	var repliesExporter slack.Exporter
+	if maxRepliesPerLine > 0 {
+		if repliesExporter, err = openSideOutput(repliesOutputPath, ".replies"); err != nil {
+			return err
+		}
+	}
+
+	// the posts older than --cold-before go to an archive to import
+	// later
+	var coldExporter slack.Exporter
+	if !coldBefore.IsZero() {
+		if coldExporter, err = openSideOutput(coldOutputPath, ".cold"); err != nil {
+			return err
+		}
+	}
+
+	// the sizes of the outputs are saved with the progress, so the
+	// lines of the channel a crashed run was writing are dropped
+	withOutputSizes := func(checkpoint *slack.Checkpoint) (*slack.Checkpoint, error) {
+		paths := append([]string{}, outputPaths...)
+		for _, output := range sideOutputs {
+			paths = append(paths, output.path)
+		}
+		sizes, err := outputSizes(paths)
+		if err != nil {
+			return nil, err
+		}
+		checkpoint.OutputSizes = sizes
+		return checkpoint, nil
+	}
+	var saveCheckpoint func(checkpoint *slack.Checkpoint) error
+	if resumable {
+		saveCheckpoint = func(checkpoint *slack.Checkpoint) error {
+			checkpoint, err := withOutputSizes(checkpoint)
+			if err != nil {
+				return err
+			}
+			return checkpointStore.Save(checkpoint)
+		}
+	}
+
+	// the attachments whose copy failed can only be copied after the
+	// transformation into JSONL outputs, as the archives and the
+	// manifest read every attachment of the lines when written
+	keepFailedAttachments := !writeManifest
+	for _, outputPath := range []string{outputFilePath, repliesOutputPath, coldOutputPath} {
+		if strings.EqualFold(filepath.Ext(outputPath), ".zip") {
+			keepFailedAttachments = false
+		}
+	}
+	result, err := slack.StreamZip(cmd.Context(), fileReader, fileSize, slack.Options{
+		TeamName:             team,
+		CreateTeam:           createTeam,
+		TeamDisplayName:      teamDisplayName,
+		Logger:               logger,
+		SkipConvertPosts:     skipConvertPosts,
+		ErasureList:          erasureList,
+		SupplementalExports:  supplementalExports,
+		ZipPassword:          zipPassword,
+		MaxRepliesPerLine:    maxRepliesPerLine,
+		ContinuationExporter: repliesExporter,
+		ColdBefore:           coldBefore,
+		ColdExporter:         coldExporter,
+		Strict:               strict,
+		TimestampOffset:      timestampOffset,
+		SlackAPI:             slackAPI,
+		UserGroups:           userGroups,
+		Resume:               checkpoint,
+		SaveCheckpoint:       saveCheckpoint,
+		ExcludeEmailDomains:  excludeEmailDomains,
+		ReassignExcludedTo:   reassignExcludedTo,
+		ChannelHeader:        channelHeader,
+		PositionSource:       positionSource,
+		UserMap:              userMap,
+		ChannelPrefix:        channelPrefix,
+		TeamMapping:          teamMapping,
+		RewritePermalinks:    rewritePermalinks,
+		PermalinkSiteURL:     siteURL,
+		LinkRewrites:         linkRewrites,
+		Observer:             timings,
+		ImportedMapping:      importedMapping,
+		MaxWarnings:          maxWarnings,
+		TransformConfig: slack.TransformConfig{
+			AttachmentsDir:            attachmentsDir,
+			SkipAttachments:           skipAttachments,
+			DiscardInvalidProps:       discardInvalidProps,
+			AuthDataAsEmail:           setAuthDataAsEmail,
+			AuthService:               authService,
+			ImportWorkflowMessages:    importWorkflowMessages,
+			BotUsers:                  botUsers,
+			CustomStatuses:            customStatuses,
+			SkipThumbnails:            skipThumbnails,
+			AttachmentRetries:         attachmentRetries,
+			KeepFailedAttachments:     keepFailedAttachments,
+			ThreadFileComments:        threadFileComments,
+			SkipPosts:                 skipPosts,
+			SkipChannels:              skipChannels,
+			UsersOnly:                 usersOnly,
+			RedisConfig:               redisConfig,
+			BotAliases:                botAliases,
+			PrettifyIntegrations:      prettifyIntegrations,
+			LegalHold:                 legalHold,
+			DirectChannelsShowDays:    dmShowDays,
+			ChannelNotifyProps:        channelNotifyProps,
+			PrivateChannelNotifyProps: privateChannelNotifyProps,
+			ArchiveUser:               archiveUser,
+			UnknownSubtypes:           unknownSubtypes,
+			CustomEmojiFallback:       customEmojiFallback,
+			SkipOneOffReminders:       skipOneOffReminders,
+			AttachmentsLayout:         attachmentsLayout,
+			ImportSlackbotMessages:    importSlackbotMessages,
+			AttachmentScanner:         attachmentScanner,
+			ThreadsCacheSize:          threadsCacheSize,
+			ReplaceRules:              replaceRules,
+			ThreadParticipantProps:    threadParticipantProps,
+			GroupChannelPolicy:        groupChannelPolicy,
+			ChannelArchivePolicy:      channelArchivePolicy,
+			BotChannelPolicy:          botChannelPolicy,
+			AttachmentImages:          attachmentImages,
+			AvatarDownloader:          avatarDownloader,
+			CustomEmojis:              customEmojis,
+			UserGroupDefaultChannels:  userGroupDefaultChannels,
+			AttributeAppUploads:       attributeAppUploads,
+			FileUploaders:             fileUploaders,
+			ChannelFilter:             channelFilter,
+			TrimOversizedProps:        trimOversizedProps,
+		},
+	}, exporter)
+	interrupted := errors.Is(err, slack.ErrInterrupted)
+	if err != nil && !interrupted {
+		exporter.Close()
+		for _, output := range sideOutputs {
+			output.exporter.Close()
+		}
+		return err
+	}
+
+	if err = exporter.Close(); err != nil {
+		return err
+	}
+
+	// the side outputs left empty are removed
+	written := []*sideOutput{}
+	for _, output := range sideOutputs {
+		empty, err := output.close()
+		if err != nil {
+			return err
+		}
+		if !empty {
+			written = append(written, output)
+		}
+	}
+	sideOutputs = written
+	for _, output := range sideOutputs {
+		switch output.exporter {
+		case repliesExporter:
+			logger.Warnf("%d post lines with extra replies were written to %s, import it once the import of %s finished", result.ContinuationLines(), output.path, outputFilePath)
+		case coldExporter:
+			logger.Warnf("%d post lines older than %s were written to %s, import it once the import of %s finished", result.ColdLines(), coldBefore.Format(coldBeforeLayout), output.path, outputFilePath)
+		}
+	}
+
+	if erasureList != nil {
+		if err := slack.WriteErasureReport(erasureReportPath, result.ErasureReport); err != nil {
+			return err
+		}
+		logger.Infof("Erasure report written to %s", erasureReportPath)
+	}
+
+	if err := writeValidationReport(validatingExporters, validationReportPath, outputFilePath, logger); err != nil {
+		return err
+	}
+
+	if shift := result.TimestampShift(); shift != nil {
+		logger.Infof("Timestamp offset: %s", shift)
+	}
+
+	if postCountReportPath != "" {
+		if err := writePostCountReport(postCountReportPath, result.PostCounts()); err != nil {
+			return err
+		}
+		logger.Infof("Post count report written to %s", postCountReportPath)
+	}
+
+	if idMappingPath != "" {
+		if err := slack.WriteIDMapping(idMappingPath, result.IDMapping()); err != nil {
+			return err
+		}
+		logger.Infof("ID mapping written to %s", idMappingPath)
+	}
+
+	if writeProvenance {
+		provenance := slack.NewProvenance(inputFilePath, team, result.SlackExport)
+		provenance.Version = Version + " -- " + BuildHash
+		provenance.GeneratedAt = time.Now().UTC()
+		setProvenanceFlags(provenance, cmd.Flags())
+		provenancePath := outputFilePath + ".provenance.json"
+		if err := slack.WriteProvenance(provenancePath, provenance); err != nil {
+			return err
+		}
+		logger.Infof("Provenance written to %s", provenancePath)
+	}
+
+	if renames := result.UsernameRenames(); len(renames) > 0 {
+		if usernameReportPath == "" {
+			usernameReportPath = outputFilePath + ".usernames.json"
+		}
+		if err := slack.WriteUsernameRenameReport(usernameReportPath, renames); err != nil {
+			return err
+		}
+		logger.Warnf("%d Slack users were renamed, see %s", len(renames), usernameReportPath)
+	}
+
+	if changes := result.ChannelNameChanges(); len(changes) > 0 {
+		if channelNameReportPath == "" {
+			channelNameReportPath = outputFilePath + ".channel-names.json"
+		}
+		if err := slack.WriteChannelNameReport(channelNameReportPath, changes); err != nil {
+			return err
+		}
+		logger.Warnf("%d Slack channels were renamed because their name is reserved, see %s", len(changes), channelNameReportPath)
+	}
+
+	if archived := result.ArchivedChannels(); len(archived) > 0 {
+		if archivedChannelReportPath == "" {
+			archivedChannelReportPath = outputFilePath + ".archived-channels.json"
+		}
+		if err := slack.WriteArchivedChannelReport(archivedChannelReportPath, archived); err != nil {
+			return err
+		}
+		logger.Warnf("%d channels are to be archived once the import finished, e.g. with mmctl channel archive, see %s", len(archived), archivedChannelReportPath)
+	}
+
+	if pinned := result.PinnedPosts(); len(pinned) > 0 {
+		if pinsReportPath == "" {
+			pinsReportPath = outputFilePath + ".pins.json"
+		}
+		if err := slack.WritePinnedPostsReport(pinsReportPath, pinned); err != nil {
+			return err
+		}
+		logger.Warnf("%d posts were pinned in Slack and are imported unpinned, see %s", len(pinned), pinsReportPath)
+	}
+
+	if failed := result.FailedAttachments(); len(failed) > 0 {
+		if failedAttachmentsReportPath == "" {
+			failedAttachmentsReportPath = outputFilePath + ".failed-attachments.json"
+		}
+		if err := slack.WriteFailedAttachmentsReport(failedAttachmentsReportPath, failed); err != nil {
+			return err
+		}
+		if failed[0].Kept {
+			logger.Warnf("%d attachments failed to copy and are missing from the attachments directory, copy them with transform retry-attachments --report %s before the import", len(failed), failedAttachmentsReportPath)
+		} else {
+			logger.Warnf("%d attachments failed to copy and their posts are imported without them, see %s", len(failed), failedAttachmentsReportPath)
+		}
+	}
+
+	if statuses := result.CustomStatuses(); len(statuses) > 0 {
+		if customStatusReportPath == "" {
+			customStatusReportPath = outputFilePath + ".custom-statuses.json"
+		}
+		if err := slack.WriteCustomStatusReport(customStatusReportPath, statuses); err != nil {
+			return err
+		}
+		logger.Warnf("%d users have a custom status to set once the import finished, see %s", len(statuses), customStatusReportPath)
+	}
+
+	if attachmentScanner != nil {
+		if quarantined := attachmentScanner.Quarantined(); len(quarantined) > 0 {
+			reportPath := filepath.Join(quarantineDir, "report.json")
+			if err := slack.WriteQuarantineReport(reportPath, quarantined); err != nil {
+				return err
+			}
+			logger.Warnf("%d attachments were flagged by the scanner and quarantined, see %s", len(quarantined), reportPath)
 		}
 	}
-	err = slackTransformer.Transform(&slack.TransformConfig{
-		AttachmentsDir:         attachmentsDir,
-		SkipAttachments:        skipAttachments,
-		DiscardInvalidProps:    discardInvalidProps,
-		AuthDataAsEmail:        setAuthDataAsEmail,
-		AuthService:            authService,
-		ImportWorkflowMessages: importWorkflowMessages,
-		SkipPosts:              skipPosts,
-		SkipChannels:           skipChannels,
-		RedisConfig:            redisConfig,
-	}, slackExport)
+
+	if groups := result.TransformResult.Deduplicated(); len(groups) > 0 {
+		for _, group := range groups {
+			if group.Count > 1 {
+				logger.Warnf("%q was raised %d times, first in channel %s", group.Message, group.Count, group.Channel)
+			}
+		}
+		if warningReportPath == "" {
+			warningReportPath = outputFilePath + ".warnings.json"
+		}
+		if err := slack.WriteWarningReport(warningReportPath, groups); err != nil {
+			return err
+		}
+		logger.Warnf("%d warnings (%d distinct) were raised and %d entities were skipped during the transformation, see %s", result.TransformResult.Total(), len(groups), result.TransformResult.SkippedCount(), warningReportPath)
+		if result.TransformResult.Truncated() {
+			logger.Warnf("The warning report is truncated at %d distinct warnings, the next ones are only counted by kind, raise --max-warnings to report them all", maxWarnings)
+		}
+	}
+
+	if replaceRules != nil {
+		counts := replaceRules.Counts()
+		for _, count := range counts {
+			logger.Infof("Replace rule %q made %d replacements", count.Search, count.Replacements)
+		}
+		if replaceReportPath != "" {
+			if err := slack.WriteReplaceRuleReport(replaceReportPath, counts); err != nil {
+				return err
+			}
+			logger.Infof("Replace rule report written to %s", replaceReportPath)
+		}
+	}
+
+	logTimingSummary(logger, timings, result, outputTimings)
+
+	if interrupted {
+		checkpoint, err := withOutputSizes(result.Checkpoint())
+		if err != nil {
+			return err
+		}
+		if err := checkpointStore.Save(checkpoint); err != nil {
+			return err
+		}
+		partialPaths := make([]string, 0, len(outputFiles))
+		for _, outputFile := range outputFiles {
+			partialPaths = append(partialPaths, outputFile.Name())
+		}
+		return fmt.Errorf("Transformation interrupted. The completed channels were written to \"%s\" and the resume checkpoint to \"%s\", run the command again with --resume to resume it", strings.Join(partialPaths, "\", \""), checkpointPath)
+	}
+
+	// the output is only complete once renamed, so a failed run never
+	// leaves a file that could be imported
+	for i, outputFile := range outputFiles {
+		if err := outputFile.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(outputFile.Name(), outputPaths[i]); err != nil {
+			return err
+		}
+	}
+	for _, output := range sideOutputs {
+		if err := os.Rename(output.file.Name(), output.path); err != nil {
+			return err
+		}
+	}
+	if err := checkpointStore.Remove(); err != nil {
+		return err
+	}
+
+	if outputPerChannelDir != "" {
+		logger.Infof("The output was written to %d files in %s, import %s first, then the other files in any order", len(outputPaths), outputPerChannelDir, slack.SharedOutputName)
+	} else if len(outputPaths) > 1 {
+		logger.Infof("The output was split into %d files, import them in order", len(outputPaths))
+	}
+
+	if writeManifest {
+		for _, outputPath := range outputPaths {
+			if err := manifest.AddFile(outputPath); err != nil {
+				return err
+			}
+		}
+		for _, output := range sideOutputs {
+			if err := manifest.AddFile(output.path); err != nil {
+				return err
+			}
+		}
+		manifestPath := outputFilePath + ".sha256"
+		if err := slack.WriteManifest(manifestPath, manifest); err != nil {
+			return err
+		}
+		logger.Infof("Manifest written to %s", manifestPath)
+	}
+
+	logger.Info("Transformation succeeded!")
+
+	return nil
+}
+
+// sideOutput is an import written along the main output, e.g. the
+// extra replies, to run once the main import finished
+type sideOutput struct {
+	path     string
+	file     *os.File
+	exporter slack.Exporter
+}
+
+// newSideOutput opens the partial file of a side output. The
+// attachments of its lines are added to the manifest when set.
+func newSideOutput(outputPath string, checkpoint *slack.Checkpoint, manifest *slack.Manifest) (*sideOutput, error) {
+	file, err := openPartialOutput(outputPath, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	output := &sideOutput{
+		path:     outputPath,
+		file:     file,
+		exporter: slack.NewExporterForPath(file, outputPath),
+	}
+	if manifest != nil && !strings.EqualFold(filepath.Ext(outputPath), ".zip") {
+		output.exporter = slack.NewManifestExporter(output.exporter, manifest)
+	}
+	return output, nil
+}
+
+// close flushes the side output, removing its file and returning true
+// when nothing was written to it. The interrupted run may have written
+// lines to the file when resuming.
+func (o *sideOutput) close() (bool, error) {
+	if err := o.exporter.Close(); err != nil {
+		return false, err
+	}
+	if err := o.file.Close(); err != nil {
+		return false, err
+	}
+	info, err := os.Stat(o.file.Name())
+	if err != nil {
+		return false, err
+	}
+	if info.Size() > 0 {
+		return false, nil
+	}
+	return true, os.Remove(o.file.Name())
+}
+
+// openPartialOutput opens the file an output is written to until the
+// transformation succeeds, appending to the file of the interrupted
+// run when resuming from its checkpoint. The lines written after the
+// checkpoint by a crashed run are truncated.
+func openPartialOutput(outputPath string, checkpoint *slack.Checkpoint) (*os.File, error) {
+	partialPath := outputPath + ".partial"
+	if checkpoint == nil {
+		return os.Create(partialPath)
+	}
+	size, ok := checkpoint.OutputSizes[outputPath]
+	if _, err := os.Stat(partialPath); os.IsNotExist(err) && checkpoint.OutputSizes != nil && !ok {
+		// the side outputs left empty by the interrupted run were removed
+		return os.Create(partialPath)
+	} else if err != nil {
+		return nil, fmt.Errorf("--resume needs the partial output of the interrupted run: %w", err)
+	}
+	if ok {
+		if err := os.Truncate(partialPath, size); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(partialPath, os.O_WRONLY|os.O_APPEND, 0)
+}
+
+// outputSizes returns the sizes of the partial files of the outputs by
+// path, for the checkpoint
+func outputSizes(paths []string) (map[string]int64, error) {
+	sizes := make(map[string]int64, len(paths))
+	for _, outputPath := range paths {
+		info, err := os.Stat(outputPath + ".partial")
+		if err != nil {
+			return nil, err
+		}
+		sizes[outputPath] = info.Size()
+	}
+	return sizes, nil
+}
+
+// slowestChannelsInSummary is the number of channels listed in the
+// timing summary
+const slowestChannelsInSummary = 5
+
+// logTimingSummary logs the time spent in each stage, in copying the
+// attachments and in writing the output, the slowest channels, the
+// work of the threads storages and the peak memory of the process
+func logTimingSummary(logger log.FieldLogger, timings *slack.Timings, result *slack.Result, outputTimings []*slack.TimingExporter) {
+	stages := []string{}
+	for _, stage := range timings.Stages() {
+		stages = append(stages, fmt.Sprintf("%s %s", stage.Stage, stage.Elapsed.Round(time.Millisecond)))
+	}
+	logger.Infof("Time spent by stage: %s", strings.Join(stages, ", "))
+
+	var outputElapsed time.Duration
+	for _, timingExporter := range outputTimings {
+		outputElapsed += timingExporter.Elapsed()
+	}
+	copies, copiesElapsed := result.AttachmentCopies()
+	logger.Infof("Time spent copying %d attachments: %s, writing the output: %s", copies, copiesElapsed.Round(time.Millisecond), outputElapsed.Round(time.Millisecond))
+	if skipped := result.SkippedThumbnails(); skipped > 0 {
+		logger.Infof("%d files were left out as the export only has their thumbnail", skipped)
+	}
+
+	if slowest := timings.SlowestChannels(slowestChannelsInSummary); len(slowest) > 0 {
+		channels := make([]string, 0, len(slowest))
+		for _, channel := range slowest {
+			channels = append(channels, fmt.Sprintf("%s %s (%d messages)", channel.Channel, channel.Elapsed.Round(time.Millisecond), channel.Source))
+		}
+		logger.Infof("Slowest channels: %s", strings.Join(channels, ", "))
+	}
+
+	if threads := result.ThreadsStats(); threads.Lookups > 0 || threads.StoredBytes > 0 {
+		logger.Infof("Threads storage: %d lookups with a %.1f%% hit rate, %d evictions, %d reloads, %d redis round trips, %.1f MiB stored", threads.Lookups, threads.HitRate()*100, threads.Evictions, threads.Reloads, threads.RedisRoundTrips, float64(threads.StoredBytes)/(1<<20))
+	}
+
+	if rss, ok := peakRSS(); ok {
+		logger.Infof("Peak memory: %.1f MiB", float64(rss)/(1<<20))
+	}
+}
+
+func writePostCountReport(reportPath string, counts []slack.ChannelPostCount) error {
+	reportFile, err := os.Create(reportPath)
 	if err != nil {
 		return err
 	}
+	defer reportFile.Close()
 
-	if err = slackTransformer.Export(outputFilePath); err != nil {
+	if err := slack.WritePostCountReport(reportFile, counts); err != nil {
 		return err
 	}
+	return reportFile.Close()
+}
 
-	slackTransformer.Logger.Info("Transformation succeeded!")
+// writeValidationReport writes the violations found by the validating
+// exporters, if any
+func writeValidationReport(exporters []*slack.ValidatingExporter, reportPath, outputFilePath string, logger log.FieldLogger) error {
+	groups := []slack.WarningGroup{}
+	total := 0
+	skipped := 0
+	for _, exporter := range exporters {
+		groups = append(groups, exporter.Result().Deduplicated()...)
+		total += exporter.Result().Total()
+		skipped += exporter.Skipped()
+	}
+	if total == 0 {
+		return nil
+	}
 
+	if reportPath == "" {
+		reportPath = outputFilePath + ".validation.json"
+	}
+	if err := slack.WriteValidationReport(reportPath, groups); err != nil {
+		return err
+	}
+	logger.Warnf("%d validation violations (%d distinct) were found and %d lines were skipped, see %s", total, len(groups), skipped, reportPath)
 	return nil
 }
+
+// setProvenanceFlags records the flags set for the run in the
+// provenance
+func setProvenanceFlags(provenance *slack.Provenance, flags *pflag.FlagSet) {
+	flags.Visit(func(flag *pflag.Flag) {
+		values := []string{flag.Value.String()}
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			values = sliceValue.GetSlice()
+		}
+		provenance.SetFlag(flag.Name, values)
+	})
+}
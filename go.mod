@@ -6,9 +6,13 @@ require (
 	github.com/alicebob/miniredis/v2 v2.20.0
 	github.com/go-redis/redis/v8 v8.11.4
 	github.com/mattermost/mattermost-server/v6 v6.5.0
+	github.com/minio/minio-go/v7 v7.0.21
 	github.com/pkg/errors v0.9.1
+	github.com/rivo/uniseg v0.2.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.4.0
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.7.1
+	golang.org/x/crypto v0.0.0-20220208233918-bba287dce954
 	golang.org/x/text v0.3.7
 )
@@ -0,0 +1,175 @@
+// Package discord transforms the exports of Discord servers made with
+// DiscordChatExporter into the intermediate entities of the slack
+// package, which exports them as a Mattermost bulk import file.
+//
+// The export holds a JSON file per channel and thread, with the files
+// attached to the messages when it was made with the --media option,
+// in which case their URL is a path relative to the JSON file.
+package discord
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ChannelTypeDirect = "DirectTextChat"
+	ChannelTypeGroup  = "DirectGroupTextChat"
+
+	MessageTypeDefault = "Default"
+	MessageTypeReply   = "Reply"
+)
+
+type DiscordGuild struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type DiscordChannel struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+	// CategoryId is the parent channel of the threads
+	CategoryId string `json:"categoryId"`
+	Category   string `json:"category"`
+	Name       string `json:"name"`
+	Topic      string `json:"topic"`
+}
+
+// IsThread returns whether the channel is a thread of another channel
+func (c DiscordChannel) IsThread() bool {
+	return strings.HasSuffix(c.Type, "Thread")
+}
+
+// IsDirect returns whether the channel is a direct or group message
+func (c DiscordChannel) IsDirect() bool {
+	return c.Type == ChannelTypeDirect || c.Type == ChannelTypeGroup
+}
+
+type DiscordUser struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	Discriminator string `json:"discriminator"`
+	Nickname      string `json:"nickname"`
+	IsBot         bool   `json:"isBot"`
+}
+
+type DiscordAttachment struct {
+	Id       string `json:"id"`
+	URL      string `json:"url"`
+	FileName string `json:"fileName"`
+}
+
+type DiscordEmoji struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// Code is the name of the standard emojis, the custom ones having
+	// none
+	Code string `json:"code"`
+}
+
+type DiscordReaction struct {
+	Emoji DiscordEmoji `json:"emoji"`
+	// Users is only exported by the recent versions of
+	// DiscordChatExporter
+	Users []DiscordUser `json:"users"`
+}
+
+type DiscordReference struct {
+	MessageId string `json:"messageId"`
+	ChannelId string `json:"channelId"`
+}
+
+type DiscordMessage struct {
+	Id              string              `json:"id"`
+	Type            string              `json:"type"`
+	Timestamp       time.Time           `json:"timestamp"`
+	TimestampEdited *time.Time          `json:"timestampEdited"`
+	IsPinned        bool                `json:"isPinned"`
+	Content         string              `json:"content"`
+	Author          DiscordUser         `json:"author"`
+	Attachments     []DiscordAttachment `json:"attachments"`
+	Reactions       []DiscordReaction   `json:"reactions"`
+	Mentions        []DiscordUser       `json:"mentions"`
+	Reference       *DiscordReference   `json:"reference"`
+}
+
+// DiscordChannelExport is the export of a channel or thread
+type DiscordChannelExport struct {
+	Guild    DiscordGuild     `json:"guild"`
+	Channel  DiscordChannel   `json:"channel"`
+	Messages []DiscordMessage `json:"messages"`
+	// Dir is the directory of the export file, which the paths of the
+	// attachments are relative to
+	Dir string `json:"-"`
+}
+
+type DiscordExport struct {
+	Channels []*DiscordChannelExport
+	FS       fs.FS
+}
+
+// ParseExport reads the channel exports among the JSON files of the
+// export, in any directory. A channel exported several times, for
+// instance over several date ranges, has its messages merged.
+func ParseExport(fsys fs.FS) (*DiscordExport, error) {
+	export := &DiscordExport{FS: fsys}
+	channelsById := map[string]*DiscordChannelExport{}
+	err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(filePath) != ".json" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", filePath)
+		}
+		var channelExport DiscordChannelExport
+		if err := json.Unmarshal(data, &channelExport); err != nil {
+			return errors.Wrapf(err, "failed to parse %s", filePath)
+		}
+		if channelExport.Channel.Id == "" {
+			return nil
+		}
+		channelExport.Dir = path.Dir(filePath)
+
+		if existing, ok := channelsById[channelExport.Channel.Id]; ok {
+			existing.Messages = append(existing.Messages, channelExport.Messages...)
+			return nil
+		}
+		channelsById[channelExport.Channel.Id] = &channelExport
+		export.Channels = append(export.Channels, &channelExport)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(export.Channels) == 0 {
+		return nil, errors.New("the export has no channel export files")
+	}
+
+	for _, channel := range export.Channels {
+		messages := channel.Messages
+		sort.SliceStable(messages, func(i, j int) bool {
+			return messages[i].Timestamp.Before(messages[j].Timestamp)
+		})
+		deduplicated := messages[:0]
+		seen := map[string]bool{}
+		for _, message := range messages {
+			if !seen[message.Id] {
+				seen[message.Id] = true
+				deduplicated = append(deduplicated, message)
+			}
+		}
+		channel.Messages = deduplicated
+	}
+	return export, nil
+}
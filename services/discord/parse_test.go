@@ -0,0 +1,94 @@
+package discord
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testExportFS() fstest.MapFS {
+	return fstest.MapFS{
+		"Server - general.json": &fstest.MapFile{Data: []byte(`{
+			"guild": {"id": "g1", "name": "Server"},
+			"channel": {"id": "c1", "type": "GuildTextChat", "category": "Text", "name": "general", "topic": "Everything"},
+			"messages": [
+				{"id": "m1", "type": "Default", "timestamp": "2021-03-29T03:53:52.035+00:00", "isPinned": true, "content": "Hello @Janie, see the plan",
+				 "author": {"id": "u1", "name": "John", "discriminator": "1234", "nickname": "Johnny"},
+				 "attachments": [
+					{"id": "a1", "url": "Server%20-%20general.json_Files/plan.txt", "fileName": "plan.txt"},
+					{"id": "a2", "url": "https://cdn.discordapp.com/attachments/budget.xlsx", "fileName": "budget.xlsx"}
+				 ],
+				 "reactions": [{"emoji": {"id": "", "name": "👍", "code": "thumbsup"}, "count": 1, "users": [{"id": "u2", "name": "jane", "discriminator": "0000"}]},
+					{"emoji": {"id": "e1", "name": "PartyParrot", "code": ""}, "count": 1, "users": [{"id": "u1", "name": "John", "discriminator": "1234"}]}],
+				 "mentions": [{"id": "u2", "name": "jane", "discriminator": "0000", "nickname": "Janie"}]},
+				{"id": "m2", "type": "GuildMemberJoin", "timestamp": "2021-03-29T03:54:00+00:00", "content": "", "author": {"id": "u3", "name": "jim", "discriminator": "0"}},
+				{"id": "m3", "type": "Reply", "timestamp": "2021-03-29T04:00:00+00:00", "timestampEdited": "2021-03-29T04:05:00+00:00", "content": "Sure",
+				 "author": {"id": "u2", "name": "jane", "discriminator": "0000"}, "reference": {"messageId": "m1", "channelId": "c1"}},
+				{"id": "t1", "type": "Default", "timestamp": "2021-03-29T04:10:00+00:00", "content": "Let's discuss", "author": {"id": "u1", "name": "John", "discriminator": "1234"}}
+			]
+		}`)},
+		"Server - general.json_Files/plan.txt": &fstest.MapFile{Data: []byte("the plan")},
+		"threads/Server - general - plans.json": &fstest.MapFile{Data: []byte(`{
+			"guild": {"id": "g1", "name": "Server"},
+			"channel": {"id": "t1", "type": "GuildPublicThread", "categoryId": "c1", "category": "general", "name": "plans"},
+			"messages": [
+				{"id": "m4", "type": "Default", "timestamp": "2021-03-29T04:11:00+00:00", "content": "In the thread", "author": {"id": "u3", "name": "jim", "discriminator": "0"}}
+			]
+		}`)},
+		"threads/Server - random - orphan.json": &fstest.MapFile{Data: []byte(`{
+			"guild": {"id": "g1", "name": "Server"},
+			"channel": {"id": "t2", "type": "GuildPublicThread", "categoryId": "c1", "category": "general", "name": "orphan"},
+			"messages": [
+				{"id": "m5", "type": "Default", "timestamp": "2021-03-29T05:00:00+00:00", "content": "Started elsewhere", "author": {"id": "u2", "name": "jane", "discriminator": "0000"}},
+				{"id": "m6", "type": "Default", "timestamp": "2021-03-29T05:01:00+00:00", "content": "Reply", "author": {"id": "u1", "name": "John", "discriminator": "1234"}}
+			]
+		}`)},
+		"Direct Messages - jane.json": &fstest.MapFile{Data: []byte(`{
+			"guild": {"id": "0", "name": "Direct Messages"},
+			"channel": {"id": "d1", "type": "DirectTextChat", "name": "jane"},
+			"messages": [
+				{"id": "d2", "type": "Default", "timestamp": "2021-03-30T10:01:00+00:00", "content": "hello", "author": {"id": "u1", "name": "John", "discriminator": "1234"}},
+				{"id": "d1", "type": "Default", "timestamp": "2021-03-30T10:00:00+00:00", "content": "hi", "author": {"id": "u2", "name": "jane", "discriminator": "0000"}}
+			]
+		}`)},
+		"Direct Messages - jane (later).json": &fstest.MapFile{Data: []byte(`{
+			"guild": {"id": "0", "name": "Direct Messages"},
+			"channel": {"id": "d1", "type": "DirectTextChat", "name": "jane"},
+			"messages": [
+				{"id": "d2", "type": "Default", "timestamp": "2021-03-30T10:01:00+00:00", "content": "hello", "author": {"id": "u1", "name": "John", "discriminator": "1234"}}
+			]
+		}`)},
+		"notes.json": &fstest.MapFile{Data: []byte(`{"something": "else"}`)},
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+	require.Len(t, export.Channels, 4)
+
+	channels := map[string]*DiscordChannelExport{}
+	for _, channel := range export.Channels {
+		channels[channel.Channel.Id] = channel
+	}
+	assert.Equal(t, ".", channels["c1"].Dir)
+	assert.Equal(t, "threads", channels["t1"].Dir)
+	assert.True(t, channels["t1"].Channel.IsThread())
+	assert.True(t, channels["d1"].Channel.IsDirect())
+
+	require.Len(t, channels["d1"].Messages, 2)
+	assert.Equal(t, "d1", channels["d1"].Messages[0].Id)
+	assert.Equal(t, "d2", channels["d1"].Messages[1].Id)
+
+	t.Run("without channels", func(t *testing.T) {
+		_, err := ParseExport(fstest.MapFS{"notes.json": &fstest.MapFile{Data: []byte(`{}`)}})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		_, err := ParseExport(fstest.MapFS{"broken.json": &fstest.MapFile{Data: []byte(`{"channel": `)}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,381 @@
+package discord
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mmetl/services/naming"
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+// generalChannelName is the channel the general channel of the server
+// is imported into, the default channel of the Mattermost teams
+const generalChannelName = "town-square"
+
+// Transformer builds the intermediate entities of a Discord export,
+// which are exported by the slack package
+type Transformer struct {
+	TeamName     string
+	Intermediate *slack.Intermediate
+	Logger       log.FieldLogger
+	// AttachmentsDir is where the files of the export attached to the
+	// messages are copied, unless SkipAttachments is set
+	AttachmentsDir  string
+	SkipAttachments bool
+	// Exporter populates the memberships and exports the intermediate
+	// entities like a Slack export
+	Exporter *slack.Transformer
+}
+
+func NewTransformer(teamName string, logger log.FieldLogger) *Transformer {
+	exporter := slack.NewTransformer(teamName, logger)
+	return &Transformer{
+		TeamName:     teamName,
+		Intermediate: exporter.Intermediate,
+		Logger:       logger,
+		Exporter:     exporter,
+	}
+}
+
+// Transform converts the users, channels, threads and messages of the
+// export. The export has neither the members of the server nor those of
+// the channels, so the users are the authors of the messages, the users
+// they mention and those who reacted to them, and the members of a
+// channel are the authors of its messages.
+func (t *Transformer) Transform(export *DiscordExport) error {
+	t.TransformUsers(export.Channels)
+	t.TransformChannels(export.Channels)
+	t.Exporter.PopulateUserMemberships()
+	t.Exporter.PopulateChannelMemberships()
+	return t.TransformMessages(export)
+}
+
+// messageUsers returns the author, the mentioned users and the users
+// who reacted to the message
+func messageUsers(message DiscordMessage) []DiscordUser {
+	users := append([]DiscordUser{message.Author}, message.Mentions...)
+	for _, reaction := range message.Reactions {
+		users = append(users, reaction.Users...)
+	}
+	return users
+}
+
+// TransformUsers converts the users of the messages, in the order they
+// appear in the export, with a nickname they had. The legacy
+// usernames keep their discriminator, which made them unique.
+func (t *Transformer) TransformUsers(channels []*DiscordChannelExport) {
+	t.Logger.Info("Transforming users")
+
+	users := map[string]DiscordUser{}
+	ids := []string{}
+	for _, channel := range channels {
+		for _, message := range channel.Messages {
+			for _, user := range messageUsers(message) {
+				if user.Id == "" {
+					continue
+				}
+				existing, ok := users[user.Id]
+				if !ok {
+					ids = append(ids, user.Id)
+				} else if user.Nickname == "" {
+					user.Nickname = existing.Nickname
+				}
+				users[user.Id] = user
+			}
+		}
+	}
+
+	taken := map[string]bool{}
+	t.Intermediate.UsersById = map[string]*slack.IntermediateUser{}
+	for _, id := range ids {
+		user := users[id]
+		name := user.Name
+		if user.Discriminator != "" && strings.Trim(user.Discriminator, "0") != "" {
+			name += "-" + user.Discriminator
+		}
+		username := naming.Username(name)
+		if username == "" {
+			username = "discord-" + user.Id
+		}
+
+		newUser := &slack.IntermediateUser{
+			Id:        user.Id,
+			Username:  naming.Unique(username, taken),
+			FirstName: user.Nickname,
+			Password:  model.NewId(),
+		}
+		if newUser.FirstName == "" {
+			newUser.FirstName = user.Name
+		}
+		if user.IsBot {
+			newUser.Position = "Discord bot"
+		}
+		newUser.Sanitise(t.Logger)
+		t.Intermediate.UsersById[user.Id] = newUser
+	}
+}
+
+// authors returns the ids of the authors of the messages of the
+// channels
+func (t *Transformer) authors(channels ...*DiscordChannelExport) []string {
+	authors := []string{}
+	seen := map[string]bool{}
+	for _, channel := range channels {
+		for _, message := range channel.Messages {
+			id := message.Author.Id
+			if _, ok := t.Intermediate.UsersById[id]; ok && !seen[id] {
+				seen[id] = true
+				authors = append(authors, id)
+			}
+		}
+	}
+	return authors
+}
+
+// TransformChannels converts the channels of the server into public
+// channels and the direct and group messages into direct and group
+// channels, the threads being imported into their parent channel.
+func (t *Transformer) TransformChannels(channels []*DiscordChannelExport) {
+	t.Logger.Info("Transforming channels")
+
+	threads := map[string][]*DiscordChannelExport{}
+	for _, channel := range channels {
+		if channel.Channel.IsThread() {
+			threads[channel.Channel.CategoryId] = append(threads[channel.Channel.CategoryId], channel)
+		}
+	}
+
+	taken := map[string]bool{}
+	for _, channel := range channels {
+		if channel.Channel.IsThread() {
+			continue
+		}
+
+		if channel.Channel.IsDirect() {
+			members := t.authors(channel)
+			newChannel := &slack.IntermediateChannel{
+				Id:           channel.Channel.Id,
+				OriginalName: channel.Channel.Name,
+				Members:      members,
+			}
+			switch {
+			case len(members) < 2:
+				t.Logger.Warnf("Direct message %s has messages of less than two users. Not importing it", channel.Channel.Name)
+			case len(members) == 2:
+				newChannel.Type = model.ChannelTypeDirect
+				t.Intermediate.DirectChannels = append(t.Intermediate.DirectChannels, newChannel)
+			case len(members) <= model.ChannelGroupMaxUsers:
+				newChannel.Type = model.ChannelTypeGroup
+				t.Intermediate.GroupChannels = append(t.Intermediate.GroupChannels, newChannel)
+			default:
+				t.Logger.Warnf("Group message %s has more than %d users. Not importing it", channel.Channel.Name, model.ChannelGroupMaxUsers)
+			}
+			continue
+		}
+
+		name := naming.ChannelName(channel.Channel.Name)
+		if name == "general" && !taken[generalChannelName] {
+			name = generalChannelName
+		}
+		if len(name) < 2 {
+			name = "discord-" + channel.Channel.Id
+		}
+		newChannel := &slack.IntermediateChannel{
+			Id:           channel.Channel.Id,
+			OriginalName: channel.Channel.Name,
+			Name:         naming.Unique(name, taken),
+			DisplayName:  channel.Channel.Name,
+			Header:       channel.Channel.Topic,
+			Members:      t.authors(append([]*DiscordChannelExport{channel}, threads[channel.Channel.Id]...)...),
+			Type:         model.ChannelTypeOpen,
+		}
+		newChannel.Sanitise(t.Logger)
+		t.Intermediate.PublicChannels = append(t.Intermediate.PublicChannels, newChannel)
+	}
+}
+
+// TransformMessages converts the messages of the channels, those of the
+// threads becoming the replies of the message they were started from,
+// or of their first message when the parent channel doesn't have it.
+func (t *Transformer) TransformMessages(export *DiscordExport) error {
+	t.Logger.Info("Transforming messages")
+
+	channelsById := map[string]*slack.IntermediateChannel{}
+	for _, typeChannels := range [][]*slack.IntermediateChannel{
+		t.Intermediate.PublicChannels,
+		t.Intermediate.GroupChannels,
+		t.Intermediate.DirectChannels,
+	} {
+		for _, channel := range typeChannels {
+			channelsById[channel.Id] = channel
+		}
+	}
+
+	postsById := map[string]*slack.IntermediatePost{}
+	threads := []*DiscordChannelExport{}
+	for _, channelExport := range export.Channels {
+		if channelExport.Channel.IsThread() {
+			threads = append(threads, channelExport)
+			continue
+		}
+		channel, ok := channelsById[channelExport.Channel.Id]
+		if !ok {
+			continue
+		}
+		for _, message := range channelExport.Messages {
+			post, err := t.transformMessage(export, channelExport, channel, message)
+			if err != nil {
+				return err
+			}
+			if post == nil {
+				continue
+			}
+			postsById[message.Id] = post
+			t.Intermediate.Posts = append(t.Intermediate.Posts, post)
+		}
+	}
+
+	for _, thread := range threads {
+		channel, ok := channelsById[thread.Channel.CategoryId]
+		if !ok {
+			t.Logger.Warnf("Thread %s has no parent channel %s. Not importing it", thread.Channel.Name, thread.Channel.CategoryId)
+			continue
+		}
+		root := postsById[thread.Channel.Id]
+		for _, message := range thread.Messages {
+			post, err := t.transformMessage(export, thread, channel, message)
+			if err != nil {
+				return err
+			}
+			if post == nil {
+				continue
+			}
+			if root == nil {
+				root = post
+				root.Message = strings.TrimSpace("**" + thread.Channel.Name + "**\n" + root.Message)
+				t.Intermediate.Posts = append(t.Intermediate.Posts, root)
+				continue
+			}
+			root.Replies = append(root.Replies, post)
+		}
+	}
+
+	sort.SliceStable(t.Intermediate.Posts, func(i, j int) bool {
+		return t.Intermediate.Posts[i].CreateAt < t.Intermediate.Posts[j].CreateAt
+	})
+	return nil
+}
+
+func timeToMillis(value time.Time) int64 {
+	return value.UnixNano() / int64(time.Millisecond)
+}
+
+// transformMessage converts a message of the channel or thread export,
+// returning nil for the system messages and those of unknown users
+func (t *Transformer) transformMessage(export *DiscordExport, channelExport *DiscordChannelExport, channel *slack.IntermediateChannel, message DiscordMessage) (*slack.IntermediatePost, error) {
+	if message.Type != MessageTypeDefault && message.Type != MessageTypeReply {
+		return nil, nil
+	}
+	author, ok := t.Intermediate.UsersById[message.Author.Id]
+	if !ok {
+		t.Logger.Warnf("Message %s of %s was posted by the unknown user %s. Not importing it", message.Id, channelExport.Channel.Name, message.Author.Id)
+		return nil, nil
+	}
+
+	post := &slack.IntermediatePost{
+		User:     author.Username,
+		Channel:  channel.Name,
+		Message:  t.messageText(message),
+		CreateAt: timeToMillis(message.Timestamp),
+		IsPinned: message.IsPinned,
+	}
+	if message.TimestampEdited != nil {
+		post.EditAt = timeToMillis(*message.TimestampEdited)
+	}
+	if channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup {
+		post.IsDirect = true
+		post.ChannelMembers = channel.MembersUsernames
+	}
+
+	for _, attachment := range message.Attachments {
+		destPath := ""
+		if !t.SkipAttachments {
+			var err error
+			if destPath, err = t.copyAttachment(export, channelExport, attachment); err != nil {
+				return nil, err
+			}
+		}
+		if destPath != "" {
+			post.Attachments = append(post.Attachments, destPath)
+		} else if strings.Contains(attachment.URL, "://") {
+			post.Message = strings.TrimSpace(post.Message + "\n[" + attachment.FileName + "](" + attachment.URL + ")")
+		}
+	}
+	if post.Message == "" && len(post.Attachments) == 0 {
+		return nil, nil
+	}
+
+	for _, reaction := range message.Reactions {
+		emoji := reaction.Emoji.Code
+		if emoji == "" {
+			emoji = strings.ToLower(reaction.Emoji.Name)
+		}
+		for _, user := range reaction.Users {
+			if reactor, ok := t.Intermediate.UsersById[user.Id]; ok {
+				post.Reactions = append(post.Reactions, &slack.IntermediateReaction{User: reactor.Username, EmojiName: emoji})
+			}
+		}
+	}
+
+	post.Sanitise()
+	return post, nil
+}
+
+// messageText returns the text of the message with the mentions of the
+// users, which the export renders with their nickname, renamed to their
+// username
+func (t *Transformer) messageText(message DiscordMessage) string {
+	replacements := []string{}
+	for _, mention := range message.Mentions {
+		user, ok := t.Intermediate.UsersById[mention.Id]
+		if !ok {
+			continue
+		}
+		for _, name := range []string{mention.Nickname, mention.Name} {
+			if name != "" {
+				replacements = append(replacements, "@"+name, "@"+user.Username)
+			}
+		}
+	}
+	return strings.NewReplacer(replacements...).Replace(message.Content)
+}
+
+// copyAttachment copies the file of the attachment to the attachments
+// directory, returning an empty path when the export doesn't have it
+func (t *Transformer) copyAttachment(export *DiscordExport, channelExport *DiscordChannelExport, attachment DiscordAttachment) (string, error) {
+	if attachment.URL == "" || strings.Contains(attachment.URL, "://") {
+		return "", nil
+	}
+	filePath := attachment.URL
+	if unescaped, err := url.PathUnescape(filePath); err == nil {
+		filePath = unescaped
+	}
+	filePath = path.Join(channelExport.Dir, filepath.ToSlash(filePath))
+
+	name := attachment.FileName
+	if name == "" {
+		name = path.Base(filePath)
+	}
+	destPath, err := naming.CopyAttachment(export.FS, filePath, t.AttachmentsDir, attachment.Id, name)
+	if destPath == "" && err == nil {
+		t.Logger.Warnf("Attachment %s is not in the export. Not importing it", filePath)
+	}
+	return destPath, err
+}
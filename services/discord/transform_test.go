@@ -0,0 +1,96 @@
+package discord
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+func TestTransform(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	attachmentsDir := t.TempDir()
+	transformer := NewTransformer("team", log.New())
+	transformer.AttachmentsDir = attachmentsDir
+	require.NoError(t, transformer.Transform(export))
+	intermediate := transformer.Intermediate
+
+	t.Run("users", func(t *testing.T) {
+		require.Len(t, intermediate.UsersById, 3)
+		john := intermediate.UsersById["u1"]
+		assert.Equal(t, "john-1234", john.Username)
+		assert.Equal(t, "Johnny", john.FirstName)
+		assert.Equal(t, "jane", intermediate.UsersById["u2"].Username)
+		assert.Equal(t, "jim", intermediate.UsersById["u3"].Username)
+		assert.ElementsMatch(t, []string{"town-square"}, intermediate.UsersById["u3"].Memberships)
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		require.Len(t, intermediate.PublicChannels, 1)
+		general := intermediate.PublicChannels[0]
+		assert.Equal(t, "town-square", general.Name)
+		assert.Equal(t, "Everything", general.Header)
+		assert.ElementsMatch(t, []string{"u1", "u2", "u3"}, general.Members)
+
+		require.Len(t, intermediate.DirectChannels, 1)
+		assert.Equal(t, []string{"jane", "john-1234"}, intermediate.DirectChannels[0].MembersUsernames)
+		assert.Empty(t, intermediate.GroupChannels)
+	})
+
+	t.Run("posts", func(t *testing.T) {
+		require.Len(t, intermediate.Posts, 6)
+
+		root := intermediate.Posts[0]
+		assert.Equal(t, "john-1234", root.User)
+		assert.Equal(t, "town-square", root.Channel)
+		assert.Equal(t, "Hello @jane, see the plan\n[budget.xlsx](https://cdn.discordapp.com/attachments/budget.xlsx)", root.Message)
+		assert.True(t, root.IsPinned)
+		assert.Equal(t, []string{filepath.Join(attachmentsDir, "a1_plan.txt")}, root.Attachments)
+		assert.Equal(t, []*slack.IntermediateReaction{
+			{User: "jane", EmojiName: "thumbsup"},
+			{User: "john-1234", EmojiName: "partyparrot"},
+		}, root.Reactions)
+
+		reply := intermediate.Posts[1]
+		assert.Equal(t, "Sure", reply.Message)
+		assert.Equal(t, int64(1616990700000), reply.EditAt)
+
+		started := intermediate.Posts[2]
+		assert.Equal(t, "Let's discuss", started.Message)
+		require.Len(t, started.Replies, 1)
+		assert.Equal(t, "jim", started.Replies[0].User)
+		assert.Equal(t, "town-square", started.Replies[0].Channel)
+
+		orphan := intermediate.Posts[3]
+		assert.Equal(t, "**orphan**\nStarted elsewhere", orphan.Message)
+		require.Len(t, orphan.Replies, 1)
+		assert.Equal(t, "Reply", orphan.Replies[0].Message)
+
+		direct := intermediate.Posts[4]
+		assert.True(t, direct.IsDirect)
+		assert.Equal(t, "hi", direct.Message)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, transformer.Exporter.ExportWith(slack.NewJSONLExporter(&buffer)))
+		assert.Contains(t, buffer.String(), `"type":"direct_post"`)
+	})
+}
+
+func TestTransformSkipAttachments(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	transformer := NewTransformer("team", log.New())
+	transformer.SkipAttachments = true
+	require.NoError(t, transformer.Transform(export))
+	assert.Empty(t, transformer.Intermediate.Posts[0].Attachments)
+}
@@ -0,0 +1,56 @@
+package msteams
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	mentionTagRegex   = regexp.MustCompile(`(?is)<at[^>]*\bid="(\d+)"[^>]*>(.*?)</at>`)
+	emojiTagRegex     = regexp.MustCompile(`(?is)<emoji[^>]*\balt="([^"]*)"[^>]*>(</emoji>)?`)
+	linkTagRegex      = regexp.MustCompile(`(?is)<a[^>]*\bhref="([^"]*)"[^>]*>(.*?)</a>`)
+	preTagRegex       = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+	codeTagRegex      = regexp.MustCompile(`(?is)<code[^>]*>(.*?)</code>`)
+	lineBreakTagRegex = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|blockquote)>`)
+	listItemTagRegex  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	strongTagRegex    = regexp.MustCompile(`(?i)</?(b|strong)>`)
+	emphasisTagRegex  = regexp.MustCompile(`(?i)</?(i|em)>`)
+	strikeTagRegex    = regexp.MustCompile(`(?i)</?(s|strike|del)>`)
+	anyTagRegex       = regexp.MustCompile(`(?s)<[^>]+>`)
+	blankLinesRegex   = regexp.MustCompile(`\n{3,}`)
+)
+
+// convertHTML converts the HTML body of a message to Markdown, the
+// mentions being replaced with the given text by the id of their <at>
+// element
+func convertHTML(content string, mentions map[string]string) string {
+	content = mentionTagRegex.ReplaceAllStringFunc(content, func(tag string) string {
+		match := mentionTagRegex.FindStringSubmatch(tag)
+		if mention, ok := mentions[match[1]]; ok {
+			return mention
+		}
+		return match[2]
+	})
+	content = emojiTagRegex.ReplaceAllString(content, "$1")
+	content = preTagRegex.ReplaceAllString(content, "\n```\n$1\n```\n")
+	content = codeTagRegex.ReplaceAllString(content, "`$1`")
+	content = linkTagRegex.ReplaceAllStringFunc(content, func(tag string) string {
+		match := linkTagRegex.FindStringSubmatch(tag)
+		text := strings.TrimSpace(anyTagRegex.ReplaceAllString(match[2], ""))
+		if text == "" || text == match[1] {
+			return match[1]
+		}
+		return "[" + text + "](" + match[1] + ")"
+	})
+	content = listItemTagRegex.ReplaceAllString(content, "- ")
+	content = lineBreakTagRegex.ReplaceAllString(content, "\n")
+	content = strongTagRegex.ReplaceAllString(content, "**")
+	content = emphasisTagRegex.ReplaceAllString(content, "_")
+	content = strikeTagRegex.ReplaceAllString(content, "~~")
+	content = anyTagRegex.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+	content = strings.ReplaceAll(content, "\u00a0", " ")
+	content = blankLinesRegex.ReplaceAllString(content, "\n\n")
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,28 @@
+package msteams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertHTML(t *testing.T) {
+	mentions := map[string]string{"0": "@jane"}
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"paragraphs", "<p>first</p><p>second<br>third</p>", "first\nsecond\nthird"},
+		{"mentions", `<div>hi <at id="0">Jane Doe</at> and <at id="1">Jim</at></div>`, "hi @jane and Jim"},
+		{"formatting", "<b>bold</b> <em>italic</em> <s>gone</s> <code>x := 1</code>", "**bold** _italic_ ~~gone~~ `x := 1`"},
+		{"links", `<a href="https://example.com">site</a> <a href="https://example.com/a">https://example.com/a</a>`, "[site](https://example.com) https://example.com/a"},
+		{"lists", "<ul><li>one</li><li>two</li></ul>", "- one\n- two"},
+		{"emojis and entities", `<emoji id="smile" alt="🙂" title="Smile"></emoji> &lt;tag&gt; &amp;&nbsp;more`, "🙂 <tag> & more"},
+		{"code blocks", "<pre>line 1\nline 2</pre>", "```\nline 1\nline 2\n```"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, convertHTML(tc.content, mentions))
+		})
+	}
+}
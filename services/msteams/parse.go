@@ -0,0 +1,208 @@
+// Package msteams transforms the exports of Microsoft Teams made with
+// the Graph API into the intermediate entities of the slack package,
+// which exports them as a Mattermost bulk import file.
+//
+// The export holds the Graph API resources of a team as JSON files,
+// either lists or responses with a "value" list:
+//
+//	users.json                    the users
+//	channels.json                 the channels with their members
+//	channels/<id>/messages.json   the messages and replies of a channel
+//	chats.json                    the chats with their members
+//	chats/<id>/messages.json      the messages of a chat
+//	files/<id>/<name>             the files attached to the messages
+package msteams
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TeamsChannelPrivate is the membership type of the private
+	// channels, the others are public
+	TeamsChannelPrivate = "private"
+
+	TeamsChatOneOnOne = "oneOnOne"
+
+	// TeamsMessageTypeMessage is the type of the messages of the users,
+	// the other types being system events
+	TeamsMessageTypeMessage = "message"
+
+	// TeamsAttachmentReference is the content type of the attachments
+	// linking to a file shared from OneDrive or SharePoint
+	TeamsAttachmentReference = "reference"
+)
+
+type TeamsUser struct {
+	Id                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	GivenName         string `json:"givenName"`
+	Surname           string `json:"surname"`
+	Mail              string `json:"mail"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	JobTitle          string `json:"jobTitle"`
+}
+
+type TeamsMember struct {
+	UserId string   `json:"userId"`
+	Roles  []string `json:"roles"`
+}
+
+type TeamsChannel struct {
+	Id             string        `json:"id"`
+	DisplayName    string        `json:"displayName"`
+	Description    string        `json:"description"`
+	MembershipType string        `json:"membershipType"`
+	Members        []TeamsMember `json:"members"`
+}
+
+type TeamsChat struct {
+	Id       string        `json:"id"`
+	Topic    string        `json:"topic"`
+	ChatType string        `json:"chatType"`
+	Members  []TeamsMember `json:"members"`
+}
+
+type TeamsIdentity struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+// TeamsIdentitySet is the author of a message or a reaction, which is
+// either a user or an application
+type TeamsIdentitySet struct {
+	User        *TeamsIdentity `json:"user"`
+	Application *TeamsIdentity `json:"application"`
+}
+
+type TeamsBody struct {
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+type TeamsAttachment struct {
+	Id          string `json:"id"`
+	ContentType string `json:"contentType"`
+	ContentURL  string `json:"contentUrl"`
+	Name        string `json:"name"`
+}
+
+type TeamsMention struct {
+	// Id is the id of the <at> element of the body
+	Id          int              `json:"id"`
+	MentionText string           `json:"mentionText"`
+	Mentioned   TeamsIdentitySet `json:"mentioned"`
+}
+
+type TeamsReaction struct {
+	ReactionType    string           `json:"reactionType"`
+	CreatedDateTime time.Time        `json:"createdDateTime"`
+	User            TeamsIdentitySet `json:"user"`
+}
+
+type TeamsMessage struct {
+	Id string `json:"id"`
+	// ReplyToId is the id of the root message of the replies
+	ReplyToId          string            `json:"replyToId"`
+	MessageType        string            `json:"messageType"`
+	CreatedDateTime    time.Time         `json:"createdDateTime"`
+	LastEditedDateTime time.Time         `json:"lastEditedDateTime"`
+	DeletedDateTime    time.Time         `json:"deletedDateTime"`
+	Subject            string            `json:"subject"`
+	From               *TeamsIdentitySet `json:"from"`
+	Body               TeamsBody         `json:"body"`
+	Attachments        []TeamsAttachment `json:"attachments"`
+	Mentions           []TeamsMention    `json:"mentions"`
+	Reactions          []TeamsReaction   `json:"reactions"`
+}
+
+// TeamsExport is a Graph API export of a team
+type TeamsExport struct {
+	Users    []TeamsUser
+	Channels []TeamsChannel
+	Chats    []TeamsChat
+	// Messages holds the messages of each channel and chat by id
+	Messages map[string][]TeamsMessage
+	FS       fs.FS
+}
+
+// decodeList reads a list of resources, either a Graph API response
+// with a value list or the list itself
+func decodeList(reader io.Reader, list interface{}) error {
+	var raw json.RawMessage
+	if err := json.NewDecoder(reader).Decode(&raw); err != nil {
+		return err
+	}
+	var response struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &response); err == nil && response.Value != nil {
+		raw = response.Value
+	}
+	return json.Unmarshal(raw, list)
+}
+
+func readList(fsys fs.FS, filePath string, list interface{}) error {
+	reader, err := fsys.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer reader.Close()
+
+	if err := decodeList(reader, list); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", filePath)
+	}
+	return nil
+}
+
+// ParseExport reads the users, channels, chats and messages of the
+// export. The users.json file is required, the others are optional.
+func ParseExport(fsys fs.FS) (*TeamsExport, error) {
+	if _, err := fs.Stat(fsys, "users.json"); err != nil {
+		return nil, errors.Wrap(err, "the export has no users.json file, only the Graph API exports of Microsoft Teams are supported")
+	}
+
+	export := &TeamsExport{
+		Messages: map[string][]TeamsMessage{},
+		FS:       fsys,
+	}
+	if err := readList(fsys, "users.json", &export.Users); err != nil {
+		return nil, err
+	}
+	if err := readList(fsys, "channels.json", &export.Channels); err != nil {
+		return nil, err
+	}
+	if err := readList(fsys, "chats.json", &export.Chats); err != nil {
+		return nil, err
+	}
+
+	for _, channel := range export.Channels {
+		if err := export.readMessages("channels", channel.Id); err != nil {
+			return nil, err
+		}
+	}
+	for _, chat := range export.Chats {
+		if err := export.readMessages("chats", chat.Id); err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
+
+func (e *TeamsExport) readMessages(dir, id string) error {
+	var messages []TeamsMessage
+	if err := readList(e.FS, path.Join(dir, id, "messages.json"), &messages); err != nil {
+		return err
+	}
+	e.Messages[id] = messages
+	return nil
+}
@@ -0,0 +1,73 @@
+package msteams
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testExportFS() fstest.MapFS {
+	return fstest.MapFS{
+		"users.json": &fstest.MapFile{Data: []byte(`{"value": [
+			{"id": "u1", "displayName": "John Doe", "givenName": "John", "surname": "Doe", "mail": "John.Doe@example.com", "userPrincipalName": "john.doe@example.com", "jobTitle": "Engineer"},
+			{"id": "u2", "displayName": "Jane", "userPrincipalName": "jane@example.com"},
+			{"id": "u3", "displayName": "Jim", "userPrincipalName": "jim@example.com"}
+		]}`)},
+		"channels.json": &fstest.MapFile{Data: []byte(`[
+			{"id": "c1", "displayName": "General", "description": "Everyone", "membershipType": "standard", "members": [{"userId": "u1", "roles": ["owner"]}, {"userId": "u2"}, {"userId": "u3"}]},
+			{"id": "c2", "displayName": "Project X", "membershipType": "private", "members": [{"userId": "u1"}, {"userId": "u2"}, {"userId": "missing"}]}
+		]`)},
+		"channels/c1/messages.json": &fstest.MapFile{Data: []byte(`{"value": [
+			{"id": "m1", "messageType": "message", "createdDateTime": "2021-03-29T03:53:52.035Z", "subject": "Kickoff",
+			 "from": {"user": {"id": "u1", "displayName": "John Doe"}},
+			 "body": {"contentType": "html", "content": "<p>Hello <at id=\"0\">Jane</at>, see <a href=\"https://example.com\">the plan</a></p>"},
+			 "mentions": [{"id": 0, "mentionText": "Jane", "mentioned": {"user": {"id": "u2"}}}],
+			 "attachments": [
+				{"id": "a1", "contentType": "reference", "contentUrl": "https://sharepoint.example.com/plan.docx", "name": "plan.docx"},
+				{"id": "a2", "contentType": "reference", "contentUrl": "https://sharepoint.example.com/budget.xlsx", "name": "budget.xlsx"}
+			 ],
+			 "reactions": [{"reactionType": "like", "user": {"user": {"id": "u2"}}}, {"reactionType": "custom", "user": {"user": {"id": "u3"}}}]},
+			{"id": "m2", "replyToId": "m1", "messageType": "message", "createdDateTime": "2021-03-29T04:00:00Z", "lastEditedDateTime": "2021-03-29T04:05:00Z",
+			 "from": {"user": {"id": "u2"}}, "body": {"contentType": "text", "content": "Sounds good"}},
+			{"id": "m3", "messageType": "systemEventMessage", "createdDateTime": "2021-03-29T04:01:00Z", "body": {"contentType": "html", "content": ""}},
+			{"id": "m4", "messageType": "message", "createdDateTime": "2021-03-29T04:02:00Z", "deletedDateTime": "2021-03-29T04:03:00Z",
+			 "from": {"user": {"id": "u3"}}, "body": {"contentType": "text", "content": "deleted"}},
+			{"id": "m5", "messageType": "message", "createdDateTime": "2021-03-29T04:04:00Z",
+			 "from": {"application": {"id": "app", "displayName": "A bot"}}, "body": {"contentType": "text", "content": "from an app"}}
+		]}`)},
+		"files/a1/plan.docx": &fstest.MapFile{Data: []byte("the plan")},
+		"chats.json": &fstest.MapFile{Data: []byte(`[
+			{"id": "ch1", "chatType": "oneOnOne", "members": [{"userId": "u1"}, {"userId": "u2"}]},
+			{"id": "ch2", "chatType": "group", "topic": "Lunch", "members": [{"userId": "u1"}, {"userId": "u2"}, {"userId": "u3"}]},
+			{"id": "ch3", "chatType": "oneOnOne", "members": [{"userId": "u1"}]}
+		]`)},
+		"chats/ch1/messages.json": &fstest.MapFile{Data: []byte(`[
+			{"id": "d1", "messageType": "message", "createdDateTime": "2021-03-30T10:00:00Z", "from": {"user": {"id": "u2"}}, "body": {"contentType": "text", "content": "hi"}}
+		]`)},
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	assert.Len(t, export.Users, 3)
+	assert.Len(t, export.Channels, 2)
+	assert.Len(t, export.Chats, 3)
+	require.Len(t, export.Messages["c1"], 5)
+	assert.Empty(t, export.Messages["c2"])
+	assert.Len(t, export.Messages["ch1"], 1)
+
+	message := export.Messages["c1"][1]
+	assert.Equal(t, "m1", message.ReplyToId)
+	assert.Equal(t, time.Date(2021, 3, 29, 4, 5, 0, 0, time.UTC), message.LastEditedDateTime)
+	assert.True(t, message.DeletedDateTime.IsZero())
+
+	t.Run("not a Graph API export", func(t *testing.T) {
+		_, err := ParseExport(fstest.MapFS{"channels.json": &fstest.MapFile{Data: []byte(`[]`)}})
+		assert.Error(t, err)
+	})
+}
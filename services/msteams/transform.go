@@ -0,0 +1,365 @@
+package msteams
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mmetl/services/naming"
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+// generalChannelName is the channel the General channel of the team is
+// imported into, the default channel of the Mattermost teams
+const generalChannelName = "town-square"
+
+// teamsReactionEmojis maps the reactions of Teams to Mattermost emojis
+var teamsReactionEmojis = map[string]string{
+	"like":      "+1",
+	"heart":     "heart",
+	"laugh":     "laughing",
+	"surprised": "open_mouth",
+	"sad":       "cry",
+	"angry":     "angry",
+}
+
+// Transformer builds the intermediate entities of a Teams export, which
+// are exported by the slack package
+type Transformer struct {
+	TeamName     string
+	Intermediate *slack.Intermediate
+	Logger       log.FieldLogger
+	// AttachmentsDir is where the files of the export attached to the
+	// messages are copied, unless SkipAttachments is set
+	AttachmentsDir  string
+	SkipAttachments bool
+	// Exporter populates the memberships and exports the intermediate
+	// entities like a Slack export
+	Exporter *slack.Transformer
+}
+
+func NewTransformer(teamName string, logger log.FieldLogger) *Transformer {
+	exporter := slack.NewTransformer(teamName, logger)
+	return &Transformer{
+		TeamName:     teamName,
+		Intermediate: exporter.Intermediate,
+		Logger:       logger,
+		Exporter:     exporter,
+	}
+}
+
+// Transform converts the users, channels, chats and messages of the
+// export.
+func (t *Transformer) Transform(export *TeamsExport) error {
+	t.TransformUsers(export.Users)
+	t.TransformChannels(export.Channels)
+	t.TransformChats(export.Chats)
+	t.Exporter.PopulateUserMemberships()
+	t.Exporter.PopulateChannelMemberships()
+	return t.TransformMessages(export)
+}
+
+// usernameFromAddress returns a valid username for the local part of
+// an email address or user principal name
+func usernameFromAddress(address string) string {
+	if i := strings.Index(address, "@"); i >= 0 {
+		address = address[:i]
+	}
+	return naming.Username(address)
+}
+
+func (t *Transformer) TransformUsers(users []TeamsUser) {
+	t.Logger.Info("Transforming users")
+
+	taken := map[string]bool{}
+	t.Intermediate.UsersById = map[string]*slack.IntermediateUser{}
+	for _, user := range users {
+		email := user.Mail
+		if email == "" {
+			email = user.UserPrincipalName
+		}
+		username := usernameFromAddress(user.UserPrincipalName)
+		if username == "" {
+			username = usernameFromAddress(email)
+		}
+		if username == "" {
+			username = "user-" + strings.ToLower(user.Id)
+		}
+
+		newUser := &slack.IntermediateUser{
+			Id:        user.Id,
+			Username:  naming.Unique(username, taken),
+			FirstName: user.GivenName,
+			LastName:  user.Surname,
+			Position:  user.JobTitle,
+			Email:     strings.ToLower(email),
+			Password:  model.NewId(),
+		}
+		if newUser.FirstName == "" && newUser.LastName == "" {
+			newUser.FirstName = user.DisplayName
+		}
+		newUser.Sanitise(t.Logger)
+		t.Intermediate.UsersById[user.Id] = newUser
+	}
+}
+
+// members returns the ids of the members which are users of the export
+// and the first owner
+func (t *Transformer) members(members []TeamsMember) ([]string, string) {
+	ids := []string{}
+	owner := ""
+	for _, member := range members {
+		if _, ok := t.Intermediate.UsersById[member.UserId]; !ok {
+			continue
+		}
+		ids = append(ids, member.UserId)
+		for _, role := range member.Roles {
+			if role == "owner" && owner == "" {
+				owner = member.UserId
+			}
+		}
+	}
+	return ids, owner
+}
+
+func (t *Transformer) TransformChannels(channels []TeamsChannel) {
+	t.Logger.Info("Transforming channels")
+
+	taken := map[string]bool{}
+	for _, channel := range channels {
+		name := naming.ChannelName(channel.DisplayName)
+		if strings.EqualFold(channel.DisplayName, "General") && !taken[generalChannelName] {
+			name = generalChannelName
+		}
+		if len(name) < 2 {
+			name = "teams-" + naming.ChannelName(channel.Id)
+		}
+
+		members, owner := t.members(channel.Members)
+		newChannel := &slack.IntermediateChannel{
+			Id:           channel.Id,
+			OriginalName: channel.DisplayName,
+			Name:         naming.Unique(name, taken),
+			DisplayName:  channel.DisplayName,
+			Purpose:      channel.Description,
+			Members:      members,
+			Creator:      owner,
+			Type:         model.ChannelTypeOpen,
+		}
+		newChannel.Sanitise(t.Logger)
+		if channel.MembershipType == TeamsChannelPrivate {
+			newChannel.Type = model.ChannelTypePrivate
+			t.Intermediate.PrivateChannels = append(t.Intermediate.PrivateChannels, newChannel)
+		} else {
+			t.Intermediate.PublicChannels = append(t.Intermediate.PublicChannels, newChannel)
+		}
+	}
+}
+
+// TransformChats converts the chats of two members into direct
+// channels, of up to eight members into group channels and the larger
+// ones into private channels.
+func (t *Transformer) TransformChats(chats []TeamsChat) {
+	t.Logger.Info("Transforming chats")
+
+	taken := map[string]bool{}
+	for _, channels := range [][]*slack.IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+		for _, channel := range channels {
+			taken[channel.Name] = true
+		}
+	}
+
+	for _, chat := range chats {
+		members, owner := t.members(chat.Members)
+		newChannel := &slack.IntermediateChannel{
+			Id:           chat.Id,
+			OriginalName: chat.Topic,
+			Members:      members,
+			Creator:      owner,
+		}
+		switch {
+		case len(members) < 2:
+			t.Logger.Warnf("Chat %s has less than two members. Not importing it", chat.Id)
+		case len(members) == 2:
+			newChannel.Type = model.ChannelTypeDirect
+			t.Intermediate.DirectChannels = append(t.Intermediate.DirectChannels, newChannel)
+		case len(members) <= model.ChannelGroupMaxUsers:
+			newChannel.Type = model.ChannelTypeGroup
+			t.Intermediate.GroupChannels = append(t.Intermediate.GroupChannels, newChannel)
+		default:
+			name := naming.ChannelName(chat.Topic)
+			if len(name) < 2 {
+				name = "chat"
+			}
+			newChannel.Name = naming.Unique(name, taken)
+			newChannel.DisplayName = chat.Topic
+			if newChannel.DisplayName == "" {
+				newChannel.DisplayName = newChannel.Name
+			}
+			newChannel.Type = model.ChannelTypePrivate
+			newChannel.Sanitise(t.Logger)
+			t.Intermediate.PrivateChannels = append(t.Intermediate.PrivateChannels, newChannel)
+		}
+	}
+}
+
+// TransformMessages converts the messages of the channels and chats,
+// the replies being added to their root message.
+func (t *Transformer) TransformMessages(export *TeamsExport) error {
+	t.Logger.Info("Transforming messages")
+
+	channels := [][]*slack.IntermediateChannel{
+		t.Intermediate.PublicChannels,
+		t.Intermediate.PrivateChannels,
+		t.Intermediate.GroupChannels,
+		t.Intermediate.DirectChannels,
+	}
+	for _, typeChannels := range channels {
+		for _, channel := range typeChannels {
+			posts, err := t.transformChannelMessages(export, channel)
+			if err != nil {
+				return err
+			}
+			t.Intermediate.Posts = append(t.Intermediate.Posts, posts...)
+		}
+	}
+
+	sort.SliceStable(t.Intermediate.Posts, func(i, j int) bool {
+		return t.Intermediate.Posts[i].CreateAt < t.Intermediate.Posts[j].CreateAt
+	})
+	return nil
+}
+
+func (t *Transformer) transformChannelMessages(export *TeamsExport, channel *slack.IntermediateChannel) ([]*slack.IntermediatePost, error) {
+	messages := export.Messages[channel.Id]
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedDateTime.Before(messages[j].CreatedDateTime)
+	})
+
+	isDirect := channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup
+	roots := map[string]*slack.IntermediatePost{}
+	posts := []*slack.IntermediatePost{}
+	for _, message := range messages {
+		if message.MessageType != TeamsMessageTypeMessage || !message.DeletedDateTime.IsZero() {
+			continue
+		}
+		if message.From == nil || message.From.User == nil {
+			t.Logger.Debugf("Message %s of %s has no user author. Not importing it", message.Id, channel.DisplayName)
+			continue
+		}
+		author, ok := t.Intermediate.UsersById[message.From.User.Id]
+		if !ok {
+			t.Logger.Warnf("Message %s of %s was posted by the unknown user %s. Not importing it", message.Id, channel.DisplayName, message.From.User.Id)
+			continue
+		}
+
+		post, err := t.transformMessage(export, channel, message)
+		if err != nil {
+			return nil, err
+		}
+		post.User = author.Username
+		if isDirect {
+			post.IsDirect = true
+			post.ChannelMembers = channel.MembersUsernames
+		}
+
+		if message.ReplyToId != "" && message.ReplyToId != message.Id {
+			root, ok := roots[message.ReplyToId]
+			if !ok {
+				t.Logger.Warnf("Reply %s of %s has no root message %s. Not importing it", message.Id, channel.DisplayName, message.ReplyToId)
+				continue
+			}
+			root.Replies = append(root.Replies, post)
+			continue
+		}
+		roots[message.Id] = post
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+func timeToMillis(value time.Time) int64 {
+	return value.UnixNano() / int64(time.Millisecond)
+}
+
+func (t *Transformer) transformMessage(export *TeamsExport, channel *slack.IntermediateChannel, message TeamsMessage) (*slack.IntermediatePost, error) {
+	post := &slack.IntermediatePost{
+		Channel:  channel.Name,
+		Message:  t.messageText(message),
+		CreateAt: timeToMillis(message.CreatedDateTime),
+	}
+	if !message.LastEditedDateTime.IsZero() {
+		post.EditAt = timeToMillis(message.LastEditedDateTime)
+	}
+
+	for _, attachment := range message.Attachments {
+		if attachment.ContentType != TeamsAttachmentReference {
+			continue
+		}
+		destPath := ""
+		if !t.SkipAttachments {
+			var err error
+			if destPath, err = t.copyAttachment(export, attachment); err != nil {
+				return nil, err
+			}
+		}
+		if destPath != "" {
+			post.Attachments = append(post.Attachments, destPath)
+		} else if attachment.ContentURL != "" {
+			post.Message = strings.TrimSpace(post.Message + "\n[" + attachment.Name + "](" + attachment.ContentURL + ")")
+		}
+	}
+
+	for _, reaction := range message.Reactions {
+		emoji, ok := teamsReactionEmojis[reaction.ReactionType]
+		if !ok || reaction.User.User == nil {
+			continue
+		}
+		user, ok := t.Intermediate.UsersById[reaction.User.User.Id]
+		if !ok {
+			continue
+		}
+		post.Reactions = append(post.Reactions, &slack.IntermediateReaction{User: user.Username, EmojiName: emoji})
+	}
+
+	post.Sanitise()
+	return post, nil
+}
+
+// messageText returns the Markdown text of a message, with the mentions
+// of the users of the export and its subject
+func (t *Transformer) messageText(message TeamsMessage) string {
+	text := message.Body.Content
+	if strings.EqualFold(message.Body.ContentType, "html") {
+		mentions := map[string]string{}
+		for _, mention := range message.Mentions {
+			if mention.Mentioned.User == nil {
+				continue
+			}
+			if user, ok := t.Intermediate.UsersById[mention.Mentioned.User.Id]; ok {
+				mentions[strconv.Itoa(mention.Id)] = "@" + user.Username
+			}
+		}
+		text = convertHTML(text, mentions)
+	}
+
+	if message.Subject != "" {
+		text = strings.TrimSpace("**" + message.Subject + "**\n" + text)
+	}
+
+	return text
+}
+
+// copyAttachment copies the file of the attachment to the attachments
+// directory, returning an empty path when the export doesn't have it
+func (t *Transformer) copyAttachment(export *TeamsExport, attachment TeamsAttachment) (string, error) {
+	if attachment.Id == "" || attachment.Name == "" {
+		return "", nil
+	}
+	return naming.CopyAttachment(export.FS, path.Join("files", attachment.Id, attachment.Name), t.AttachmentsDir, attachment.Id, attachment.Name)
+}
@@ -0,0 +1,104 @@
+package msteams
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+func TestTransform(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	attachmentsDir := t.TempDir()
+	transformer := NewTransformer("team", log.New())
+	transformer.AttachmentsDir = attachmentsDir
+	require.NoError(t, transformer.Transform(export))
+	intermediate := transformer.Intermediate
+
+	t.Run("users", func(t *testing.T) {
+		require.Len(t, intermediate.UsersById, 3)
+		john := intermediate.UsersById["u1"]
+		assert.Equal(t, "john.doe", john.Username)
+		assert.Equal(t, "john.doe@example.com", john.Email)
+		assert.Equal(t, "John", john.FirstName)
+		assert.Equal(t, "Engineer", john.Position)
+		assert.ElementsMatch(t, []string{"town-square", "project-x"}, john.Memberships)
+		assert.Equal(t, []string{"town-square"}, john.AdminMemberships)
+		assert.Equal(t, "Jane", intermediate.UsersById["u2"].FirstName)
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		require.Len(t, intermediate.PublicChannels, 1)
+		assert.Equal(t, "town-square", intermediate.PublicChannels[0].Name)
+		assert.Equal(t, "General", intermediate.PublicChannels[0].DisplayName)
+		assert.Equal(t, "Everyone", intermediate.PublicChannels[0].Purpose)
+		require.Len(t, intermediate.PrivateChannels, 1)
+		assert.Equal(t, "project-x", intermediate.PrivateChannels[0].Name)
+		assert.Equal(t, []string{"u1", "u2"}, intermediate.PrivateChannels[0].Members)
+
+		require.Len(t, intermediate.DirectChannels, 1)
+		assert.Equal(t, []string{"john.doe", "jane"}, intermediate.DirectChannels[0].MembersUsernames)
+		require.Len(t, intermediate.GroupChannels, 1)
+		assert.Equal(t, model.ChannelTypeGroup, intermediate.GroupChannels[0].Type)
+	})
+
+	t.Run("posts", func(t *testing.T) {
+		require.Len(t, intermediate.Posts, 2)
+
+		root := intermediate.Posts[0]
+		assert.Equal(t, "town-square", root.Channel)
+		assert.Equal(t, "john.doe", root.User)
+		assert.Equal(t, "**Kickoff**\nHello @jane, see [the plan](https://example.com)\n[budget.xlsx](https://sharepoint.example.com/budget.xlsx)", root.Message)
+		assert.Equal(t, int64(1616990032035), root.CreateAt)
+		assert.Equal(t, []string{filepath.Join(attachmentsDir, "a1_plan.docx")}, root.Attachments)
+		assert.Equal(t, []*slack.IntermediateReaction{{User: "jane", EmojiName: "+1"}}, root.Reactions)
+
+		require.Len(t, root.Replies, 1)
+		assert.Equal(t, "Sounds good", root.Replies[0].Message)
+		assert.Equal(t, "jane", root.Replies[0].User)
+		assert.Equal(t, int64(1616990700000), root.Replies[0].EditAt)
+
+		direct := intermediate.Posts[1]
+		assert.True(t, direct.IsDirect)
+		assert.Equal(t, []string{"john.doe", "jane"}, direct.ChannelMembers)
+		assert.Equal(t, "hi", direct.Message)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, transformer.Exporter.ExportWith(slack.NewJSONLExporter(&buffer)))
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		assert.Contains(t, lines[0], `"type":"version"`)
+		assert.Contains(t, buffer.String(), `"type":"direct_post"`)
+		assert.Contains(t, buffer.String(), `"channel":"town-square"`)
+	})
+}
+
+func TestTransformSkipAttachments(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	transformer := NewTransformer("team", log.New())
+	transformer.SkipAttachments = true
+	require.NoError(t, transformer.Transform(export))
+
+	root := transformer.Intermediate.Posts[0]
+	assert.Empty(t, root.Attachments)
+	assert.Contains(t, root.Message, "[plan.docx](https://sharepoint.example.com/plan.docx)")
+}
+
+func TestUsernameFromAddress(t *testing.T) {
+	assert.Equal(t, "john.doe", usernameFromAddress("John.Doe@example.com"))
+	assert.Equal(t, "u1john", usernameFromAddress("1john@example.com"))
+	assert.Equal(t, "jane-smith", usernameFromAddress("jane+smith@example.com"))
+	assert.Equal(t, "", usernameFromAddress("@example.com"))
+}
@@ -0,0 +1,53 @@
+package naming
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AttachmentFileName returns the name of the copy of an attachment in
+// the attachments directory, made of the id and the name of the
+// attachment. Both are reduced to their last path element, so that an
+// id or a name of the export can't point outside of the directory.
+func AttachmentFileName(id, name string) string {
+	return baseName(id) + "_" + baseName(name)
+}
+
+func baseName(name string) string {
+	return path.Base(strings.ReplaceAll(name, `\`, "/"))
+}
+
+// CopyAttachment copies the file of the export at filePath to the
+// attachments directory and returns the path of the copy, or an empty
+// path when the export doesn't have the file
+func CopyAttachment(fsys fs.FS, filePath, attachmentsDir, id, name string) (string, error) {
+	reader, err := fsys.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open attachment %s of the export", filePath)
+	}
+	defer reader.Close()
+
+	destPath := filepath.Join(attachmentsDir, AttachmentFileName(id, name))
+	if err := os.MkdirAll(attachmentsDir, 0755); err != nil {
+		return "", errors.Wrap(err, "failed to create the attachments directory")
+	}
+	destFile, err := os.Create(destPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create attachment %s in the attachments directory", id)
+	}
+	defer destFile.Close()
+
+	if _, err := io.Copy(destFile, reader); err != nil {
+		return "", errors.Wrapf(err, "failed to copy attachment %s to the attachments directory", id)
+	}
+	return destPath, nil
+}
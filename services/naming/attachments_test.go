@@ -0,0 +1,45 @@
+package naming
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentFileName(t *testing.T) {
+	assert.Equal(t, "F1_report.pdf", AttachmentFileName("F1", "report.pdf"))
+	assert.Equal(t, "F1_report.pdf", AttachmentFileName("F1", "docs/report.pdf"))
+	assert.Equal(t, "passwd_report.pdf", AttachmentFileName("../../etc/passwd", "report.pdf"))
+	assert.Equal(t, "x_report.pdf", AttachmentFileName(`..\..\x`, "report.pdf"))
+}
+
+func TestCopyAttachment(t *testing.T) {
+	fsys := fstest.MapFS{"files/F1/report.pdf": &fstest.MapFile{Data: []byte("pdf")}}
+	attachmentsDir := filepath.Join(t.TempDir(), "attachments")
+
+	t.Run("copy", func(t *testing.T) {
+		destPath, err := CopyAttachment(fsys, "files/F1/report.pdf", attachmentsDir, "F1", "report.pdf")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(attachmentsDir, "F1_report.pdf"), destPath)
+		data, err := os.ReadFile(destPath)
+		require.NoError(t, err)
+		assert.Equal(t, "pdf", string(data))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		destPath, err := CopyAttachment(fsys, "files/F2/report.pdf", attachmentsDir, "F2", "report.pdf")
+		require.NoError(t, err)
+		assert.Empty(t, destPath)
+	})
+
+	t.Run("crafted id", func(t *testing.T) {
+		destPath, err := CopyAttachment(fsys, "files/F1/report.pdf", attachmentsDir, "../outside", "report.pdf")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(attachmentsDir, "outside_report.pdf"), destPath)
+		assert.NoFileExists(t, filepath.Join(filepath.Dir(attachmentsDir), "outside_report.pdf"))
+	})
+}
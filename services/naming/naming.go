@@ -0,0 +1,65 @@
+// Package naming builds the Mattermost usernames and channel names of
+// the exports of the chat services other than Slack, and the names of
+// the copies of their attachments.
+package naming
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// Username returns a valid Mattermost username for the name of a user,
+// or an empty string when none of its characters can be kept
+func Username(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune('-')
+		}
+	}
+	username := strings.Trim(builder.String(), "-")
+	if username != "" && !unicode.IsLetter(rune(username[0])) {
+		username = "u" + username
+	}
+	if len(username) > model.UserNameMaxLength {
+		username = username[:model.UserNameMaxLength]
+	}
+	return username
+}
+
+// ChannelName returns a channel name for the name of a channel, or an
+// empty string when none of its characters can be kept
+func ChannelName(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune('-')
+		}
+	}
+	channelName := strings.Trim(builder.String(), "-")
+	for strings.Contains(channelName, "--") {
+		channelName = strings.ReplaceAll(channelName, "--", "-")
+	}
+	if len(channelName) > model.ChannelNameMaxLength {
+		channelName = strings.Trim(channelName[:model.ChannelNameMaxLength], "-")
+	}
+	return channelName
+}
+
+// Unique returns the name, suffixed with a number when it is taken,
+// and marks it as taken
+func Unique(name string, taken map[string]bool) string {
+	candidate := name
+	for suffix := 2; taken[candidate]; suffix++ {
+		candidate = fmt.Sprintf("%s-%d", name, suffix)
+	}
+	taken[candidate] = true
+	return candidate
+}
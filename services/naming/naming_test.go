@@ -0,0 +1,33 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsername(t *testing.T) {
+	assert.Equal(t, "john.doe", Username("John.Doe"))
+	assert.Equal(t, "john-doe", Username("John Doe"))
+	assert.Equal(t, "a.b_c", Username("a.b_c"))
+	assert.Equal(t, "u42", Username("42"))
+	assert.Equal(t, "", Username("@@"))
+	assert.Len(t, Username(strings.Repeat("a", 100)), model.UserNameMaxLength)
+}
+
+func TestChannelName(t *testing.T) {
+	assert.Equal(t, "release-notes", ChannelName("Release  Notes!"))
+	assert.Equal(t, "dev_ops", ChannelName("dev_ops"))
+	assert.Equal(t, "", ChannelName("???"))
+	assert.Len(t, ChannelName(strings.Repeat("a", 100)), model.ChannelNameMaxLength)
+}
+
+func TestUnique(t *testing.T) {
+	taken := map[string]bool{}
+	assert.Equal(t, "general", Unique("general", taken))
+	assert.Equal(t, "general-2", Unique("general", taken))
+	assert.Equal(t, "general-3", Unique("general", taken))
+	assert.True(t, taken["general-3"])
+}
@@ -0,0 +1,40 @@
+package rocketchat
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	boldRegex    = regexp.MustCompile(`(^|[\s(])\*([^*\n]+)\*`)
+	strikeRegex  = regexp.MustCompile(`(^|[\s(])~([^~\n]+)~`)
+	mentionRegex = regexp.MustCompile(`@([\w.\-]+)`)
+	codeRegex    = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+)
+
+// convertMarkup converts the Markdown of Rocket.Chat, where single
+// asterisks are bold and single tildes strike through, to the one of
+// Mattermost, renaming the mentions of the renamed users. The code
+// blocks and spans are left as they are.
+func convertMarkup(text string, usernames map[string]string) string {
+	var builder strings.Builder
+	last := 0
+	for _, code := range codeRegex.FindAllStringIndex(text, -1) {
+		builder.WriteString(convertText(text[last:code[0]], usernames))
+		builder.WriteString(text[code[0]:code[1]])
+		last = code[1]
+	}
+	builder.WriteString(convertText(text[last:], usernames))
+	return builder.String()
+}
+
+func convertText(text string, usernames map[string]string) string {
+	text = boldRegex.ReplaceAllString(text, "$1**$2**")
+	text = strikeRegex.ReplaceAllString(text, "$1~~$2~~")
+	return mentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+		if username, ok := usernames[mention[1:]]; ok {
+			return "@" + username
+		}
+		return mention
+	})
+}
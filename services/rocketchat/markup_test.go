@@ -0,0 +1,28 @@
+package rocketchat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertMarkup(t *testing.T) {
+	usernames := map[string]string{"John.Doe": "john.doe", "jane": "jane"}
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"plain", "hello", "hello"},
+		{"bold", "*bold* and (*more*)", "**bold** and (**more**)"},
+		{"strike", "~gone~", "~~gone~~"},
+		{"italic", "_italic_", "_italic_"},
+		{"mentions", "@John.Doe and @jane and @all", "@john.doe and @jane and @all"},
+		{"code", "`*x*` and *y*\n```\n@John.Doe *z*\n```", "`*x*` and **y**\n```\n@John.Doe *z*\n```"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, convertMarkup(tc.text, usernames))
+		})
+	}
+}
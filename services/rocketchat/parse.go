@@ -0,0 +1,222 @@
+// Package rocketchat transforms the exports of Rocket.Chat made with
+// mongoexport into the intermediate entities of the slack package,
+// which exports them as a Mattermost bulk import file.
+//
+// The export holds the collections of the Rocket.Chat database, as
+// JSON lines or JSON arrays in the extended JSON of mongoexport:
+//
+//	users.json                    the users
+//	rocketchat_room.json          the channels, private groups and direct messages
+//	rocketchat_subscription.json  the memberships of the rooms
+//	rocketchat_message.json       the messages
+//	uploads/<id>                  the files of the FileSystem upload storage
+//
+// The BSON files of mongodump must be converted with bsondump first.
+package rocketchat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	RoomTypePublic  = "c"
+	RoomTypePrivate = "p"
+	RoomTypeDirect  = "d"
+
+	// UserTypeBot is the type of the bot users, the others being "user"
+	UserTypeBot = "bot"
+)
+
+// MongoDate is a date of the extended JSON of mongoexport, either
+// {"$date": "2006-01-02T15:04:05Z"}, {"$date": <millis>} or
+// {"$date": {"$numberLong": "<millis>"}}
+type MongoDate struct {
+	time.Time
+}
+
+func (d *MongoDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var wrapper struct {
+		Date json.RawMessage `json:"$date"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err == nil && wrapper.Date != nil {
+		data = wrapper.Date
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		parsed, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return err
+		}
+		d.Time = parsed
+		return nil
+	}
+
+	var numberLong struct {
+		Value string `json:"$numberLong"`
+	}
+	if err := json.Unmarshal(data, &numberLong); err == nil && numberLong.Value != "" {
+		data = []byte(numberLong.Value)
+	}
+	millis, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid date %s", data)
+	}
+	d.Time = time.Unix(0, millis*int64(time.Millisecond)).UTC()
+	return nil
+}
+
+type Email struct {
+	Address string `json:"address"`
+}
+
+type User struct {
+	Id       string   `json:"_id"`
+	Username string   `json:"username"`
+	Name     string   `json:"name"`
+	Emails   []Email  `json:"emails"`
+	Type     string   `json:"type"`
+	Roles    []string `json:"roles"`
+}
+
+// UserRef is the user of a room, a subscription or a message
+type UserRef struct {
+	Id       string `json:"_id"`
+	Username string `json:"username"`
+}
+
+type Room struct {
+	Id          string  `json:"_id"`
+	Type        string  `json:"t"`
+	Name        string  `json:"name"`
+	FullName    string  `json:"fname"`
+	Topic       string  `json:"topic"`
+	Description string  `json:"description"`
+	Creator     UserRef `json:"u"`
+	// UserIds are the members of the direct messages
+	UserIds []string `json:"uids"`
+}
+
+type Subscription struct {
+	RoomId string   `json:"rid"`
+	User   UserRef  `json:"u"`
+	Roles  []string `json:"roles"`
+}
+
+type File struct {
+	Id   string `json:"_id"`
+	Name string `json:"name"`
+}
+
+// Reaction holds the usernames of the users that reacted with an emoji
+type Reaction struct {
+	Usernames []string `json:"usernames"`
+}
+
+type Message struct {
+	Id     string  `json:"_id"`
+	RoomId string  `json:"rid"`
+	Text   string  `json:"msg"`
+	User   UserRef `json:"u"`
+	// Type is only set on the system messages
+	Type     string    `json:"t"`
+	Created  MongoDate `json:"ts"`
+	EditedAt MongoDate `json:"editedAt"`
+	// ThreadId is the id of the root message of the replies
+	ThreadId string `json:"tmid"`
+	// Hidden is set on the previous versions of the edited messages
+	Hidden    bool                `json:"_hidden"`
+	File      *File               `json:"file"`
+	Files     []File              `json:"files"`
+	Reactions map[string]Reaction `json:"reactions"`
+}
+
+// Export holds the collections of a Rocket.Chat export
+type Export struct {
+	Users         []User
+	Rooms         []Room
+	Subscriptions []Subscription
+	Messages      []Message
+	FS            fs.FS
+}
+
+// readCollection reads the documents of a collection, either JSON
+// lines or a JSON array
+func readCollection(fsys fs.FS, filePath string, documents interface{}) error {
+	reader, err := fsys.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer reader.Close()
+
+	buffered := bufio.NewReader(reader)
+	start, _ := buffered.Peek(512)
+	if trimmed := bytes.TrimSpace(start); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.NewDecoder(buffered).Decode(documents); err != nil {
+			return errors.Wrapf(err, "failed to parse %s", filePath)
+		}
+		return nil
+	}
+
+	lines := []json.RawMessage{}
+	decoder := json.NewDecoder(buffered)
+	for {
+		var line json.RawMessage
+		if err := decoder.Decode(&line); err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to parse %s", filePath)
+		}
+		lines = append(lines, line)
+	}
+	data, err := json.Marshal(lines)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, documents); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", filePath)
+	}
+	return nil
+}
+
+// ParseExport reads the collections of the export. The users.json file
+// is required, the others are optional.
+func ParseExport(fsys fs.FS) (*Export, error) {
+	if _, err := fs.Stat(fsys, "users.json"); err != nil {
+		if _, bsonErr := fs.Stat(fsys, "users.bson"); bsonErr == nil {
+			return nil, errors.New("the export holds the BSON files of mongodump, convert them to JSON with bsondump first")
+		}
+		return nil, errors.Wrap(err, "the export has no users.json file")
+	}
+
+	export := &Export{FS: fsys}
+	collections := []struct {
+		filePath  string
+		documents interface{}
+	}{
+		{"users.json", &export.Users},
+		{"rocketchat_room.json", &export.Rooms},
+		{"rocketchat_subscription.json", &export.Subscriptions},
+		{"rocketchat_message.json", &export.Messages},
+	}
+	for _, collection := range collections {
+		if err := readCollection(fsys, collection.filePath, collection.documents); err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
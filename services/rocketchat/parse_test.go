@@ -0,0 +1,91 @@
+package rocketchat
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testExportFS() fstest.MapFS {
+	return fstest.MapFS{
+		"users.json": &fstest.MapFile{Data: []byte(`{"_id": "u1", "username": "John.Doe", "name": "John Doe", "emails": [{"address": "John@example.com"}], "type": "user", "roles": ["user", "admin"]}
+{"_id": "u2", "username": "jane", "name": "Jane", "emails": [{"address": "jane@example.com"}], "type": "user", "roles": ["user"]}
+{"_id": "u3", "username": "jim", "name": "Jim", "emails": [{"address": "jim@example.com"}], "type": "user", "roles": ["guest"]}
+{"_id": "rocket.cat", "username": "rocket.cat", "name": "Rocket.Cat", "type": "bot", "roles": ["bot"]}
+`)},
+		"rocketchat_room.json": &fstest.MapFile{Data: []byte(`[
+			{"_id": "GENERAL", "t": "c", "name": "general", "topic": "Everything", "description": "Everyone"},
+			{"_id": "r2", "t": "p", "name": "project-x", "fname": "Project X", "u": {"_id": "u1", "username": "John.Doe"}},
+			{"_id": "u1u2", "t": "d", "uids": ["u1", "u2"], "usernames": ["John.Doe", "jane"]},
+			{"_id": "r4", "t": "d", "uids": ["u1", "u2", "u3"]},
+			{"_id": "r5", "t": "l", "name": "livechat"}
+		]`)},
+		"rocketchat_subscription.json": &fstest.MapFile{Data: []byte(`{"rid": "GENERAL", "u": {"_id": "u1", "username": "John.Doe"}, "roles": ["owner"]}
+{"rid": "GENERAL", "u": {"_id": "u2", "username": "jane"}}
+{"rid": "GENERAL", "u": {"_id": "u3", "username": "jim"}}
+{"rid": "r2", "u": {"_id": "u1", "username": "John.Doe"}, "roles": ["owner"]}
+{"rid": "r2", "u": {"_id": "u2", "username": "jane"}}
+{"rid": "r2", "u": {"_id": "missing", "username": "missing"}}
+`)},
+		"rocketchat_message.json": &fstest.MapFile{Data: []byte(`{"_id": "m1", "rid": "GENERAL", "msg": "Hello @jane, *this* is ~old~", "u": {"_id": "u1", "username": "John.Doe"}, "ts": {"$date": "2021-03-29T03:53:52.035Z"}, "reactions": {":+1:": {"usernames": ["jane", "unknown"]}}, "file": {"_id": "f1", "name": "plan.pdf"}, "files": [{"_id": "f1", "name": "plan.pdf"}, {"_id": "f2", "name": "missing.pdf"}]}
+{"_id": "m2", "rid": "GENERAL", "tmid": "m1", "msg": "Sounds good @John.Doe", "u": {"_id": "u2", "username": "jane"}, "ts": {"$date": {"$numberLong": "1616990400000"}}, "editedAt": {"$date": 1616990700000}}
+{"_id": "m3", "rid": "GENERAL", "t": "uj", "msg": "jim", "u": {"_id": "u3", "username": "jim"}, "ts": {"$date": "2021-03-29T04:01:00Z"}}
+{"_id": "m4", "rid": "GENERAL", "_hidden": true, "msg": "Hello", "u": {"_id": "u1", "username": "John.Doe"}, "ts": {"$date": "2021-03-29T03:53:52.035Z"}}
+{"_id": "m5", "rid": "GENERAL", "tmid": "gone", "msg": "orphan", "u": {"_id": "u2", "username": "jane"}, "ts": {"$date": "2021-03-29T04:06:00Z"}}
+{"_id": "d1", "rid": "u1u2", "msg": "hi", "u": {"_id": "u2", "username": "jane"}, "ts": {"$date": "2021-03-30T10:00:00Z"}}
+`)},
+		"uploads/f1": &fstest.MapFile{Data: []byte("the plan")},
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	assert.Len(t, export.Users, 4)
+	assert.Len(t, export.Rooms, 5)
+	assert.Len(t, export.Subscriptions, 6)
+	require.Len(t, export.Messages, 6)
+	assert.Equal(t, "m1", export.Messages[1].ThreadId)
+	assert.Equal(t, map[string]Reaction{":+1:": {Usernames: []string{"jane", "unknown"}}}, export.Messages[0].Reactions)
+
+	t.Run("without users", func(t *testing.T) {
+		_, err := ParseExport(fstest.MapFS{})
+		assert.Error(t, err)
+	})
+
+	t.Run("mongodump", func(t *testing.T) {
+		_, err := ParseExport(fstest.MapFS{"users.bson": &fstest.MapFile{}})
+		assert.Contains(t, err.Error(), "bsondump")
+	})
+
+	t.Run("invalid collection", func(t *testing.T) {
+		fsys := testExportFS()
+		fsys["rocketchat_room.json"] = &fstest.MapFile{Data: []byte(`{"_id": `)}
+		_, err := ParseExport(fsys)
+		assert.Error(t, err)
+	})
+}
+
+func TestMongoDate(t *testing.T) {
+	expected := time.Date(2021, 3, 29, 4, 0, 0, 0, time.UTC)
+	for _, data := range []string{
+		`{"$date": "2021-03-29T04:00:00Z"}`,
+		`{"$date": 1616990400000}`,
+		`{"$date": {"$numberLong": "1616990400000"}}`,
+		`"2021-03-29T04:00:00Z"`,
+	} {
+		var date MongoDate
+		require.NoError(t, json.Unmarshal([]byte(data), &date), data)
+		assert.True(t, expected.Equal(date.Time), data)
+	}
+
+	var date MongoDate
+	require.NoError(t, json.Unmarshal([]byte(`null`), &date))
+	assert.True(t, date.IsZero())
+	assert.Error(t, json.Unmarshal([]byte(`{"$date": "yesterday"}`), &date))
+}
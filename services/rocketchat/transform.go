@@ -0,0 +1,343 @@
+package rocketchat
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mmetl/services/naming"
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+// generalChannelName is the channel the general channel of the
+// workspace is imported into, the default channel of the Mattermost
+// teams
+const generalChannelName = "town-square"
+
+// Transformer builds the intermediate entities of a Rocket.Chat export,
+// which are exported by the slack package
+type Transformer struct {
+	TeamName     string
+	Intermediate *slack.Intermediate
+	Logger       log.FieldLogger
+	// AttachmentsDir is where the uploads of the export attached to the
+	// messages are copied, unless SkipAttachments is set
+	AttachmentsDir  string
+	SkipAttachments bool
+	// usernames maps the Rocket.Chat usernames to the imported ones
+	usernames map[string]string
+	// Exporter populates the memberships and exports the intermediate
+	// entities like a Slack export
+	Exporter *slack.Transformer
+}
+
+func NewTransformer(teamName string, logger log.FieldLogger) *Transformer {
+	exporter := slack.NewTransformer(teamName, logger)
+	return &Transformer{
+		TeamName:     teamName,
+		Intermediate: exporter.Intermediate,
+		Logger:       logger,
+		usernames:    map[string]string{},
+		Exporter:     exporter,
+	}
+}
+
+// Transform converts the users, rooms and messages of the export.
+func (t *Transformer) Transform(export *Export) error {
+	t.TransformUsers(export.Users)
+	t.TransformRooms(export.Rooms, export.Subscriptions)
+	t.Exporter.PopulateUserMemberships()
+	t.Exporter.PopulateChannelMemberships()
+	return t.TransformMessages(export)
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// TransformUsers converts the users, the administrators of the
+// workspace becoming team administrators and its guests guests.
+func (t *Transformer) TransformUsers(users []User) {
+	t.Logger.Info("Transforming users")
+
+	taken := map[string]bool{}
+	t.Intermediate.UsersById = map[string]*slack.IntermediateUser{}
+	for _, user := range users {
+		if user.Username == "" {
+			t.Logger.Debugf("User %s has no username. Not importing it", user.Id)
+			continue
+		}
+		username := naming.Username(user.Username)
+		if username == "" {
+			username = "user-" + strings.ToLower(user.Id)
+		}
+
+		newUser := &slack.IntermediateUser{
+			Id:          user.Id,
+			Username:    naming.Unique(username, taken),
+			Password:    model.NewId(),
+			IsGuest:     hasRole(user.Roles, "guest"),
+			IsTeamAdmin: hasRole(user.Roles, "admin"),
+		}
+		if len(user.Emails) > 0 {
+			newUser.Email = strings.ToLower(user.Emails[0].Address)
+		}
+		if user.Type == UserTypeBot {
+			newUser.Position = "Rocket.Chat bot"
+		}
+		names := strings.SplitN(strings.TrimSpace(user.Name), " ", 2)
+		newUser.FirstName = names[0]
+		if len(names) > 1 {
+			newUser.LastName = names[1]
+		}
+		newUser.Sanitise(t.Logger)
+
+		t.usernames[user.Username] = newUser.Username
+		t.Intermediate.UsersById[user.Id] = newUser
+	}
+}
+
+// members returns the ids of the users of the export among the given
+// ones
+func (t *Transformer) members(userIds []string) []string {
+	members := []string{}
+	seen := map[string]bool{}
+	for _, id := range userIds {
+		if _, ok := t.Intermediate.UsersById[id]; ok && !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+	return members
+}
+
+// TransformRooms converts the channels and private groups, with the
+// members of their subscriptions, and the direct messages, those of
+// more than two users becoming group channels.
+func (t *Transformer) TransformRooms(rooms []Room, subscriptions []Subscription) {
+	t.Logger.Info("Transforming rooms")
+
+	subscribers := map[string][]string{}
+	owners := map[string]string{}
+	for _, subscription := range subscriptions {
+		subscribers[subscription.RoomId] = append(subscribers[subscription.RoomId], subscription.User.Id)
+		if hasRole(subscription.Roles, "owner") && owners[subscription.RoomId] == "" {
+			owners[subscription.RoomId] = subscription.User.Id
+		}
+	}
+
+	taken := map[string]bool{}
+	for _, room := range rooms {
+		switch room.Type {
+		case RoomTypePublic, RoomTypePrivate:
+			name := naming.ChannelName(room.Name)
+			if room.Name == "general" && !taken[generalChannelName] {
+				name = generalChannelName
+			}
+			if len(name) < 2 {
+				name = "rocketchat-" + naming.ChannelName(room.Id)
+			}
+			displayName := room.FullName
+			if displayName == "" {
+				displayName = room.Name
+			}
+
+			creator := room.Creator.Id
+			if _, ok := t.Intermediate.UsersById[creator]; !ok {
+				creator = owners[room.Id]
+			}
+			newChannel := &slack.IntermediateChannel{
+				Id:           room.Id,
+				OriginalName: room.Name,
+				Name:         naming.Unique(name, taken),
+				DisplayName:  displayName,
+				Purpose:      room.Description,
+				Header:       room.Topic,
+				Members:      t.members(subscribers[room.Id]),
+				Creator:      creator,
+				Type:         model.ChannelTypeOpen,
+			}
+			newChannel.Sanitise(t.Logger)
+			if room.Type == RoomTypePrivate {
+				newChannel.Type = model.ChannelTypePrivate
+				t.Intermediate.PrivateChannels = append(t.Intermediate.PrivateChannels, newChannel)
+			} else {
+				t.Intermediate.PublicChannels = append(t.Intermediate.PublicChannels, newChannel)
+			}
+		case RoomTypeDirect:
+			members := t.members(room.UserIds)
+			if len(members) == 0 {
+				members = t.members(subscribers[room.Id])
+			}
+			newChannel := &slack.IntermediateChannel{
+				Id:           room.Id,
+				OriginalName: room.Id,
+				Members:      members,
+			}
+			switch {
+			case len(members) < 2:
+				t.Logger.Debugf("Direct message %s has less than two members. Not importing it", room.Id)
+			case len(members) == 2:
+				newChannel.Type = model.ChannelTypeDirect
+				t.Intermediate.DirectChannels = append(t.Intermediate.DirectChannels, newChannel)
+			case len(members) <= model.ChannelGroupMaxUsers:
+				newChannel.Type = model.ChannelTypeGroup
+				t.Intermediate.GroupChannels = append(t.Intermediate.GroupChannels, newChannel)
+			default:
+				t.Logger.Warnf("Direct message %s has more than %d members. Not importing it", room.Id, model.ChannelGroupMaxUsers)
+			}
+		default:
+			t.Logger.Debugf("Room %s has the unsupported type %q. Not importing it", room.Id, room.Type)
+		}
+	}
+}
+
+// TransformMessages converts the messages of the rooms, the thread
+// replies being added to their root message.
+func (t *Transformer) TransformMessages(export *Export) error {
+	t.Logger.Info("Transforming messages")
+
+	messagesByRoom := map[string][]Message{}
+	for _, message := range export.Messages {
+		messagesByRoom[message.RoomId] = append(messagesByRoom[message.RoomId], message)
+	}
+
+	channels := [][]*slack.IntermediateChannel{
+		t.Intermediate.PublicChannels,
+		t.Intermediate.PrivateChannels,
+		t.Intermediate.GroupChannels,
+		t.Intermediate.DirectChannels,
+	}
+	for _, typeChannels := range channels {
+		for _, channel := range typeChannels {
+			posts, err := t.transformChannelMessages(export, channel, messagesByRoom[channel.Id])
+			if err != nil {
+				return err
+			}
+			t.Intermediate.Posts = append(t.Intermediate.Posts, posts...)
+		}
+	}
+
+	sort.SliceStable(t.Intermediate.Posts, func(i, j int) bool {
+		return t.Intermediate.Posts[i].CreateAt < t.Intermediate.Posts[j].CreateAt
+	})
+	return nil
+}
+
+func (t *Transformer) transformChannelMessages(export *Export, channel *slack.IntermediateChannel, messages []Message) ([]*slack.IntermediatePost, error) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Created.Before(messages[j].Created.Time)
+	})
+
+	isDirect := channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup
+	roots := map[string]*slack.IntermediatePost{}
+	posts := []*slack.IntermediatePost{}
+	for _, message := range messages {
+		if message.Type != "" || message.Hidden {
+			continue
+		}
+		author, ok := t.Intermediate.UsersById[message.User.Id]
+		if !ok {
+			t.Logger.Warnf("Message %s of %s was posted by the unknown user %s. Not importing it", message.Id, channel.Name, message.User.Id)
+			continue
+		}
+
+		post, err := t.transformMessage(export, channel, message)
+		if err != nil {
+			return nil, err
+		}
+		if post.Message == "" && len(post.Attachments) == 0 {
+			continue
+		}
+		post.User = author.Username
+		if isDirect {
+			post.IsDirect = true
+			post.ChannelMembers = channel.MembersUsernames
+		}
+
+		if message.ThreadId != "" {
+			root, ok := roots[message.ThreadId]
+			if !ok {
+				t.Logger.Warnf("Reply %s of %s has no root message %s. Not importing it", message.Id, channel.Name, message.ThreadId)
+				continue
+			}
+			root.Replies = append(root.Replies, post)
+			continue
+		}
+		roots[message.Id] = post
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+func timeToMillis(value time.Time) int64 {
+	return value.UnixNano() / int64(time.Millisecond)
+}
+
+func (t *Transformer) transformMessage(export *Export, channel *slack.IntermediateChannel, message Message) (*slack.IntermediatePost, error) {
+	post := &slack.IntermediatePost{
+		Channel:  channel.Name,
+		Message:  convertMarkup(message.Text, t.usernames),
+		CreateAt: timeToMillis(message.Created.Time),
+	}
+	if !message.EditedAt.IsZero() {
+		post.EditAt = timeToMillis(message.EditedAt.Time)
+	}
+
+	files := message.Files
+	if len(files) == 0 && message.File != nil {
+		files = []File{*message.File}
+	}
+	for _, file := range files {
+		if t.SkipAttachments {
+			break
+		}
+		destPath, err := t.copyUpload(export, file)
+		if err != nil {
+			return nil, err
+		}
+		if destPath == "" {
+			t.Logger.Warnf("Upload %s of message %s is not in the export. Not importing it", file.Id, message.Id)
+			continue
+		}
+		post.Attachments = append(post.Attachments, destPath)
+	}
+
+	emojis := make([]string, 0, len(message.Reactions))
+	for emoji := range message.Reactions {
+		emojis = append(emojis, emoji)
+	}
+	sort.Strings(emojis)
+	for _, emoji := range emojis {
+		for _, username := range message.Reactions[emoji].Usernames {
+			if imported, ok := t.usernames[username]; ok {
+				post.Reactions = append(post.Reactions, &slack.IntermediateReaction{User: imported, EmojiName: strings.Trim(emoji, ":")})
+			}
+		}
+	}
+
+	post.Sanitise()
+	return post, nil
+}
+
+// copyUpload copies the upload to the attachments directory, returning
+// an empty path when the export doesn't have it
+func (t *Transformer) copyUpload(export *Export, file File) (string, error) {
+	if file.Id == "" {
+		return "", nil
+	}
+	name := file.Name
+	if name == "" {
+		name = "file"
+	}
+	return naming.CopyAttachment(export.FS, path.Join("uploads", file.Id), t.AttachmentsDir, file.Id, name)
+}
@@ -0,0 +1,96 @@
+package rocketchat
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mmetl/services/slack"
+)
+
+func TestTransform(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	attachmentsDir := t.TempDir()
+	transformer := NewTransformer("team", log.New())
+	transformer.AttachmentsDir = attachmentsDir
+	require.NoError(t, transformer.Transform(export))
+	intermediate := transformer.Intermediate
+
+	t.Run("users", func(t *testing.T) {
+		require.Len(t, intermediate.UsersById, 4)
+		john := intermediate.UsersById["u1"]
+		assert.Equal(t, "john.doe", john.Username)
+		assert.Equal(t, "john@example.com", john.Email)
+		assert.Equal(t, "John", john.FirstName)
+		assert.Equal(t, "Doe", john.LastName)
+		assert.True(t, john.IsTeamAdmin)
+		assert.ElementsMatch(t, []string{"town-square", "project-x"}, john.Memberships)
+		assert.True(t, intermediate.UsersById["u3"].IsGuest)
+		assert.Equal(t, "Rocket.Chat bot", intermediate.UsersById["rocket.cat"].Position)
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		require.Len(t, intermediate.PublicChannels, 1)
+		general := intermediate.PublicChannels[0]
+		assert.Equal(t, "town-square", general.Name)
+		assert.Equal(t, "Everything", general.Header)
+		assert.Equal(t, "Everyone", general.Purpose)
+		assert.Equal(t, "u1", general.Creator)
+		require.Len(t, intermediate.PrivateChannels, 1)
+		assert.Equal(t, "project-x", intermediate.PrivateChannels[0].Name)
+		assert.Equal(t, "Project X", intermediate.PrivateChannels[0].DisplayName)
+		assert.Equal(t, []string{"u1", "u2"}, intermediate.PrivateChannels[0].Members)
+
+		require.Len(t, intermediate.DirectChannels, 1)
+		assert.Equal(t, []string{"john.doe", "jane"}, intermediate.DirectChannels[0].MembersUsernames)
+		require.Len(t, intermediate.GroupChannels, 1)
+		assert.Equal(t, model.ChannelTypeGroup, intermediate.GroupChannels[0].Type)
+	})
+
+	t.Run("posts", func(t *testing.T) {
+		require.Len(t, intermediate.Posts, 2)
+
+		root := intermediate.Posts[0]
+		assert.Equal(t, "town-square", root.Channel)
+		assert.Equal(t, "john.doe", root.User)
+		assert.Equal(t, "Hello @jane, **this** is ~~old~~", root.Message)
+		assert.Equal(t, int64(1616990032035), root.CreateAt)
+		assert.Equal(t, []string{filepath.Join(attachmentsDir, "f1_plan.pdf")}, root.Attachments)
+		require.Len(t, root.Reactions, 1)
+		assert.Equal(t, &slack.IntermediateReaction{User: "jane", EmojiName: "+1"}, root.Reactions[0])
+
+		require.Len(t, root.Replies, 1)
+		assert.Equal(t, "Sounds good @john.doe", root.Replies[0].Message)
+		assert.Equal(t, int64(1616990700000), root.Replies[0].EditAt)
+
+		direct := intermediate.Posts[1]
+		assert.True(t, direct.IsDirect)
+		assert.Equal(t, []string{"john.doe", "jane"}, direct.ChannelMembers)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, transformer.Exporter.ExportWith(slack.NewJSONLExporter(&buffer)))
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		assert.Contains(t, lines[0], `"type":"version"`)
+		assert.Contains(t, buffer.String(), `"type":"direct_post"`)
+	})
+}
+
+func TestTransformSkipAttachments(t *testing.T) {
+	export, err := ParseExport(testExportFS())
+	require.NoError(t, err)
+
+	transformer := NewTransformer("team", log.New())
+	transformer.SkipAttachments = true
+	require.NoError(t, transformer.Transform(export))
+	assert.Empty(t, transformer.Intermediate.Posts[0].Attachments)
+}
@@ -0,0 +1,36 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// BotAliases maps Slack bot ids or bot usernames to the usernames of
+// existing Mattermost accounts that should own the bot's messages.
+type BotAliases map[string]string
+
+func ParseBotAliases(data io.Reader) (BotAliases, error) {
+	decoder := json.NewDecoder(data)
+
+	var aliases BotAliases
+	if err := decoder.Decode(&aliases); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// Lookup returns the Mattermost username for the bot that authored
+// the post, matching by bot id first and by bot username after.
+func (a BotAliases) Lookup(post SlackPost) (string, bool) {
+	if post.BotId != "" {
+		if username, ok := a[post.BotId]; ok {
+			return username, true
+		}
+	}
+	if post.BotUsername != "" {
+		if username, ok := a[post.BotUsername]; ok {
+			return username, true
+		}
+	}
+	return "", false
+}
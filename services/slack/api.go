@@ -0,0 +1,386 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Options configures the transformation of a Slack export when the
+// package is embedded in another tool.
+type Options struct {
+	TeamName string
+	// CreateTeam adds the definition of the team to the output, so it
+	// is created by the import. TeamDisplayName defaults to TeamName.
+	CreateTeam      bool
+	TeamDisplayName string
+	// Logger defaults to the logrus standard logger
+	Logger           log.FieldLogger
+	SkipConvertPosts bool
+	TransformConfig  TransformConfig
+	// SupplementalExports are per-user exports whose direct and group
+	// messages are merged into the export
+	SupplementalExports []fs.FS
+	// ErasureList excludes the data of its subjects from the output,
+	// the removals are reported in Result.ErasureReport
+	ErasureList *ErasureList
+	// ZipPassword decrypts the encrypted entries of the zip files
+	// given to TransformZip and StreamZip
+	ZipPassword string
+	// MaxRepliesPerLine limits the number of replies embedded in a
+	// post line. The extra replies are written to the
+	// ContinuationExporter, as a second import to run once the main
+	// one finished. Both must be set to split the replies.
+	MaxRepliesPerLine    int
+	ContinuationExporter Exporter
+	// ColdBefore writes the posts created before it to the
+	// ColdExporter, as an archive of the older history to import once
+	// the main import finished. Both must be set to split the posts.
+	ColdBefore   time.Time
+	ColdExporter Exporter
+	// Strict fails the transformation on the first malformed file of
+	// the export, which is otherwise recovered with a warning
+	Strict bool
+	// TimestampOffset shifts the creation time of the posts, see
+	// Result.TimestampShift
+	TimestampOffset time.Duration
+	// SlackAPI completes the users missing an email or a name with
+	// the Slack Web API when set
+	SlackAPI *SlackAPIClient
+	// UserGroups are the user groups of the workspace, e.g. from the
+	// Slack API, used when the export has no usergroups.json file
+	UserGroups []SlackUserGroup
+	// ExcludeEmailDomains leaves out the users with an email in these
+	// domains, reassigning their messages to the ReassignExcludedTo
+	// username when set or skipping them otherwise
+	ExcludeEmailDomains []string
+	ReassignExcludedTo  string
+	// ChannelHeader decides whether the header of the channels is
+	// made of the topic, the purpose or both, the topic by default
+	ChannelHeader ChannelHeaderSource
+	// PositionSource decides which fields of the Slack profiles the
+	// position of the users is taken from, the title by default
+	PositionSource PositionSource
+	// UserMap imports the Slack users as existing Mattermost accounts,
+	// overriding their username and email
+	UserMap UserMap
+	// TeamMapping imports the public and private channels into the
+	// team of their first matching rule. The teams are created with
+	// CreateTeam.
+	TeamMapping TeamMapping
+	// ChannelPrefix starts the name and display name of the public and
+	// private channels, to import several workspaces in a team. It
+	// must be valid in a channel name, see ValidateChannelPrefix.
+	ChannelPrefix string
+	// RewritePermalinks replaces the links to Slack messages and
+	// channels with a reference to the imported channel, linked to the
+	// channel on the Mattermost site at PermalinkSiteURL when set, see
+	// ValidateSiteURL
+	RewritePermalinks bool
+	PermalinkSiteURL  string
+	// LinkRewrites replaces the links to the tools replaced along with
+	// Slack, see ParseLinkRewrites
+	LinkRewrites LinkRewrites
+	// SubtypeHandlers are registered on the transformer, adding
+	// support for message subtypes or replacing the default handlers
+	SubtypeHandlers map[string]SubtypeHandler
+	// Resume continues the interrupted StreamFS run that returned the
+	// checkpoint. The header and the posts of the completed channels are
+	// not exported again, the output is to be appended to the output of
+	// the interrupted run.
+	Resume *Checkpoint
+	// PipelineBufferSize is the number of channels buffered between
+	// the stages of StreamFS, DefaultPipelineBufferSize if unset
+	PipelineBufferSize int
+	// Observer is notified of the stages, the channels and the
+	// warnings of the transformation as it progresses
+	Observer Observer
+	// SaveCheckpoint is called by StreamFS with the progress of the
+	// transformation after the posts of each channel are exported, so
+	// a crashed run can be resumed from the last completed channel.
+	// The transformation fails when it returns an error.
+	SaveCheckpoint func(checkpoint *Checkpoint) error
+	// ImportedMapping exports only the posts, checking their references
+	// against the IDMapping of the import of the users and channels
+	ImportedMapping *IDMapping
+	// MaxWarnings keeps only the first warnings in the Warnings of the
+	// TransformResult when positive, and as many deduplicated warnings,
+	// so the memory used by the warnings of large exports stays
+	// bounded. The counts still cover every warning, the ones beyond
+	// the deduplicated warnings being counted by kind.
+	MaxWarnings int
+}
+
+// Result holds the outcome of a transformation.
+type Result struct {
+	SlackExport     *SlackExport
+	Intermediate    *Intermediate
+	TransformResult *TransformResult
+	ErasureReport   *ErasureReport
+	transformer     *Transformer
+}
+
+// ExportTo writes the result as a Mattermost bulk import JSONL stream.
+func (r *Result) ExportTo(writer io.Writer) error {
+	return r.transformer.ExportTo(writer)
+}
+
+// UsernameRenames returns the Slack users whose username was changed
+// to be valid in Mattermost.
+func (r *Result) UsernameRenames() []UsernameRename {
+	return r.transformer.UsernameRenames()
+}
+
+// ChannelNameChanges returns the channels renamed to avoid the names
+// reserved in Mattermost.
+func (r *Result) ChannelNameChanges() []ChannelNameChange {
+	return r.transformer.ChannelNameChanges()
+}
+
+// PinnedPosts returns the posts pinned in Slack, which the import
+// leaves unpinned.
+func (r *Result) PinnedPosts() []PinnedPost {
+	return r.transformer.PinnedPosts()
+}
+
+// CustomStatuses returns the custom statuses of the users, which the
+// import can't set.
+func (r *Result) CustomStatuses() []UserCustomStatus {
+	return r.transformer.CustomStatuses()
+}
+
+// ArchivedChannels returns the channels to archive once the import
+// finished, as the import can't archive them.
+func (r *Result) ArchivedChannels() []ArchivedChannel {
+	return r.transformer.ArchivedChannels()
+}
+
+// PostCounts returns the messages of each channel of the export
+// compared with the posts and replies emitted for it.
+func (r *Result) PostCounts() []ChannelPostCount {
+	return r.transformer.PostCounts()
+}
+
+// TimestampShift reports the posts shifted by the TimestampOffset,
+// nil when no offset is set
+func (r *Result) TimestampShift() *TimestampShift {
+	return r.transformer.TimestampShift()
+}
+
+// ContinuationLines returns the number of lines written to the
+// continuation exporter.
+func (r *Result) ContinuationLines() int {
+	return r.transformer.continuationLines
+}
+
+// ColdLines returns the number of post lines written to the cold
+// exporter.
+func (r *Result) ColdLines() int {
+	return r.transformer.coldLines
+}
+
+// IDMapping returns the identifiers the Slack users and channels are
+// imported with.
+func (r *Result) IDMapping() *IDMapping {
+	return r.transformer.IDMapping()
+}
+
+// AttachmentCopies returns the number of attachments copied and the
+// time spent copying them.
+func (r *Result) AttachmentCopies() (int, time.Duration) {
+	return r.transformer.AttachmentCopies()
+}
+
+// FailedAttachments returns the attachments whose copy failed after
+// the retries, which must be copied before the import.
+func (r *Result) FailedAttachments() []FailedAttachment {
+	return r.transformer.FailedAttachments()
+}
+
+// SkippedThumbnails returns the number of files left out as the export
+// only has their thumbnail.
+func (r *Result) SkippedThumbnails() int {
+	return r.transformer.SkippedThumbnails()
+}
+
+// ThreadsStats returns the work of the threads storages.
+func (r *Result) ThreadsStats() ThreadsStats {
+	return r.transformer.ThreadsStats()
+}
+
+// DiffWithServer compares the transformed team, users and channels
+// with those existing on the target server.
+func (r *Result) DiffWithServer(lookup ServerLookup) (*ServerDiff, error) {
+	return r.transformer.DiffWithServer(lookup)
+}
+
+// Checkpoint returns the progress of the transformation, to be stored
+// when it was interrupted.
+func (r *Result) Checkpoint() *Checkpoint {
+	return r.transformer.Checkpoint()
+}
+
+func (opts Options) newTransformer() *Transformer {
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.StandardLogger()
+	}
+	transformer := NewTransformer(opts.TeamName, logger)
+	transformer.ErasureList = opts.ErasureList
+	transformer.SupplementalExports = opts.SupplementalExports
+	transformer.MaxRepliesPerLine = opts.MaxRepliesPerLine
+	transformer.ContinuationExporter = opts.ContinuationExporter
+	transformer.ColdBefore = opts.ColdBefore
+	transformer.ColdExporter = opts.ColdExporter
+	transformer.Strict = opts.Strict
+	transformer.result.maxWarnings = opts.MaxWarnings
+	transformer.SaveCheckpoint = opts.SaveCheckpoint
+	transformer.TimestampOffset = opts.TimestampOffset
+	transformer.SlackAPI = opts.SlackAPI
+	transformer.UserGroups = opts.UserGroups
+	transformer.ExcludeEmailDomains = opts.ExcludeEmailDomains
+	transformer.ReassignExcludedTo = opts.ReassignExcludedTo
+	transformer.ChannelHeader = opts.ChannelHeader
+	transformer.PositionSource = opts.PositionSource
+	transformer.UserMap = opts.UserMap
+	transformer.TeamMapping = opts.TeamMapping
+	transformer.ChannelPrefix = opts.ChannelPrefix
+	transformer.RewritePermalinks = opts.RewritePermalinks
+	transformer.PermalinkSiteURL = opts.PermalinkSiteURL
+	transformer.LinkRewrites = opts.LinkRewrites
+	transformer.Observer = opts.Observer
+	transformer.ImportedMapping = opts.ImportedMapping
+	if opts.Resume != nil {
+		transformer.completedChannels = append([]string{}, opts.Resume.CompletedChannels...)
+		transformer.continuationLines = opts.Resume.ContinuationLines
+		transformer.coldLines = opts.Resume.ColdLines
+	}
+	for subtype, handler := range opts.SubtypeHandlers {
+		transformer.RegisterSubtypeHandler(subtype, handler)
+	}
+	if opts.CreateTeam {
+		transformer.Intermediate.Team = NewIntermediateTeam(opts.TeamName, opts.TeamDisplayName)
+	}
+	return transformer
+}
+
+// TransformFS parses and transforms a Slack export laid out as in the
+// export zip file, e.g. an extracted export opened with os.DirFS.
+func TransformFS(ctx context.Context, fsys fs.FS, opts Options) (*Result, error) {
+	transformer := opts.newTransformer()
+
+	slackExport, err := transformer.ParseSlackExportFS(ctx, fsys, opts.SkipConvertPosts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		SlackExport:   slackExport,
+		Intermediate:  transformer.Intermediate,
+		ErasureReport: transformer.ErasureReport(),
+		transformer:   transformer,
+	}
+
+	// an interrupted transformation still returns the posts of the
+	// channels that were completed
+	transformResult, err := transformer.Transform(ctx, &opts.TransformConfig, slackExport)
+	result.TransformResult = transformResult
+	result.ErasureReport = transformer.ErasureReport()
+	if errors.Is(err, ErrInterrupted) {
+		return result, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TransformZip parses and transforms a Slack export zip file of the
+// given size.
+func TransformZip(ctx context.Context, reader io.ReaderAt, size int64, opts Options) (*Result, error) {
+	zipFS, err := OpenZipFS(reader, size, opts.ZipPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return TransformFS(ctx, zipFS, opts)
+}
+
+// StreamFS transforms a Slack export and writes it to the exporter as
+// it goes. The users and channels are exported first, then the posts
+// are parsed, transformed and exported channel by channel through a
+// pipeline, so the posts of the whole export are never held in memory.
+// The exporter is not closed. The posts are not kept in the
+// intermediate entities of the returned result.
+func StreamFS(ctx context.Context, fsys fs.FS, opts Options, exporter Exporter) (*Result, error) {
+	transformer := opts.newTransformer()
+	cfg := &opts.TransformConfig
+
+	slackExport, err := transformer.ParseSlackExportMetadataFS(ctx, fsys, opts.SkipConvertPosts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		SlackExport:     slackExport,
+		Intermediate:    transformer.Intermediate,
+		TransformResult: transformer.result,
+		ErasureReport:   transformer.ErasureReport(),
+		transformer:     transformer,
+	}
+
+	if err := transformer.TransformUsersAndChannels(cfg, slackExport); err != nil {
+		return nil, err
+	}
+
+	// the users are exported before any post is transformed, so the
+	// users the workflow and Slackbot messages are attributed to are
+	// created upfront
+	if cfg.ImportWorkflowMessages && !cfg.skipPosts() {
+		transformer.selectOrCreateWorkflowUser(SlackPost{})
+	}
+	if cfg.ImportSlackbotMessages && !cfg.skipPosts() {
+		transformer.selectOrCreateSlackbotUser()
+	}
+
+	// the header was exported by the interrupted run
+	if opts.Resume == nil {
+		finishStage := transformer.startStage(StageExport)
+		if err := transformer.ExportHeader(exporter); err != nil {
+			return nil, err
+		}
+		finishStage()
+	}
+
+	if cfg.skipPosts() {
+		return result, nil
+	}
+
+	err = transformer.ExportPostsPipeline(ctx, cfg, slackExport, exporter, opts.PipelineBufferSize)
+	result.ErasureReport = transformer.ErasureReport()
+	if errors.Is(err, ErrInterrupted) {
+		return result, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// StreamZip transforms a Slack export zip file of the given size and
+// writes it to the exporter as it goes, see StreamFS. Zip64 archives
+// are supported, and only the central directory of the archive and the
+// files of the channels being processed are read into memory, so the
+// size of the export is not limited by the available memory.
+func StreamZip(ctx context.Context, reader io.ReaderAt, size int64, opts Options, exporter Exporter) (*Result, error) {
+	zipFS, err := OpenZipFS(reader, size, opts.ZipPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return StreamFS(ctx, zipFS, opts, exporter)
+}
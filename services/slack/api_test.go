@@ -0,0 +1,176 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testExportFS() fstest.MapFS {
+	return fstest.MapFS{
+		"users.json": &fstest.MapFile{Data: []byte(`[
+			{"id": "U1", "name": "john", "profile": {"email": "john@example.com"}},
+			{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com"}}
+		]`)},
+		"channels.json": &fstest.MapFile{Data: []byte(`[
+			{"id": "C1", "name": "general", "members": ["U1", "U2"]}
+		]`)},
+		"general/2020-01-01.json": &fstest.MapFile{Data: []byte(`[
+			{"type": "message", "user": "U1", "text": "hello <@U2>", "ts": "1577836800.000100"},
+			{"type": "message", "subtype": "file_share", "user": "U2", "text": "a file", "ts": "1577836801.000100", "files": [{"id": "F1", "name": "notes.txt"}]}
+		]`)},
+		"__uploads/F1/notes.txt": &fstest.MapFile{Data: []byte("some notes")},
+	}
+}
+
+func TestTransformFS(t *testing.T) {
+	attachmentsDir := t.TempDir()
+
+	result, err := TransformFS(context.Background(), testExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{AttachmentsDir: attachmentsDir},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Intermediate.PublicChannels, 1)
+	require.Len(t, result.Intermediate.UsersById, 2)
+	require.Len(t, result.Intermediate.Posts, 2)
+
+	messages := map[string]*IntermediatePost{}
+	for _, post := range result.Intermediate.Posts {
+		messages[post.Message] = post
+	}
+	require.Contains(t, messages, "hello @jane")
+	require.Contains(t, messages, "a file")
+	assert.Equal(t, []string{filepath.Join(attachmentsDir, "F1_notes.txt")}, messages["a file"].Attachments)
+
+	var buffer bytes.Buffer
+	require.NoError(t, result.ExportTo(&buffer))
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 1+1+2+2)
+}
+
+func TestTransformFSCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := TransformFS(ctx, testExportFS(), Options{TeamName: "team", Logger: log.New()})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStreamFS(t *testing.T) {
+	fsys := testExportFS()
+	fsys["random/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "lost", "ts": "1577836802.000100"}
+	]`)}
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "other", "members": ["U1"]}
+	]`)}
+	fsys["other/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U2", "text": "see <#C1>", "ts": "1577923200.000100"}
+	]`)}
+
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:           "team",
+		Logger:             log.New(),
+		TransformConfig:    TransformConfig{AttachmentsDir: t.TempDir()},
+		PipelineBufferSize: 1,
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 1+2+2+3)
+	assert.Contains(t, lines[0], `"type":"version"`)
+	for _, line := range lines[5:] {
+		assert.Contains(t, line, `"type":"post"`)
+	}
+	assert.Contains(t, buffer.String(), "hello @jane")
+	assert.Contains(t, buffer.String(), "see ~general")
+
+	assert.Empty(t, result.Intermediate.Posts)
+	assert.Equal(t, []string{"general", "other"}, result.Checkpoint().CompletedChannels)
+	assert.Equal(t, 1, result.TransformResult.Count(WarningUnknownChannel))
+}
+
+func TestStreamFSResume(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "other", "members": ["U1", "U2"]}
+	]`)}
+	fsys["other/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U2", "text": "see <#C1>", "ts": "1577923200.000100"}
+	]`)}
+
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		Resume:          &Checkpoint{CompletedChannels: []string{"general"}},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	// only the posts of the remaining channel are written
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], "see ~general")
+	assert.Equal(t, []string{"general", "other"}, result.Checkpoint().CompletedChannels)
+}
+
+func TestStreamFSCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buffer bytes.Buffer
+	_, err := StreamFS(ctx, testExportFS(), Options{TeamName: "team", Logger: log.New()}, NewJSONLExporter(&buffer))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTransformFSCreateTeam(t *testing.T) {
+	result, err := TransformFS(context.Background(), testExportFS(), Options{
+		TeamName:        "team",
+		CreateTeam:      true,
+		TeamDisplayName: "The Team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	require.NoError(t, result.ExportTo(&buffer))
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 1+1+1+2+2)
+	assert.Contains(t, lines[0], `"type":"version"`)
+	assert.Contains(t, lines[1], `"team":{"name":"team","display_name":"The Team","type":"I"}`)
+	assert.Contains(t, lines[2], `"type":"channel"`)
+}
+
+func TestTransformFSChannelTopicLinks(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"],
+		 "topic": {"value": "<http://wiki|Team wiki> by <@U1>"},
+		 "purpose": {"value": "*Everything* about <#C1>"}}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Intermediate.PublicChannels, 1)
+	assert.Equal(t, "[Team wiki](http://wiki) by @john", result.Intermediate.PublicChannels[0].Header)
+	assert.Equal(t, "**Everything** about ~general", result.Intermediate.PublicChannels[0].Purpose)
+}
@@ -0,0 +1,86 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+const archiveUserID = "slackarchive"
+
+// DefaultArchiveUsername is the name of the user posting the history
+// in archive mode when none is given
+const DefaultArchiveUsername = "slack-archive"
+
+// PrepareArchiveUser switches the transformation to archive mode,
+// where every message is posted by a single user instead of its
+// author, the author and time of the message being prefixed to its
+// text. Only the archive user is exported, as a member of every public
+// and private channel. The direct and group channels are not imported,
+// as their members would need to exist.
+func (t *Transformer) PrepareArchiveUser(username string) {
+	archiveUser := &IntermediateUser{
+		Id:        archiveUserID,
+		Username:  username,
+		FirstName: "Slack",
+		LastName:  "Archive",
+		Email:     fmt.Sprintf("%s@tinkoff.ru", username),
+		Password:  model.NewId(),
+	}
+	archiveUser.Sanitise(t.Logger)
+
+	for _, channel := range append(append([]*IntermediateChannel{}, t.Intermediate.PublicChannels...), t.Intermediate.PrivateChannels...) {
+		archiveUser.Memberships = append(archiveUser.Memberships, channel.Name)
+	}
+
+	for _, channel := range append(append([]*IntermediateChannel{}, t.Intermediate.DirectChannels...), t.Intermediate.GroupChannels...) {
+		t.warn(&Warning{
+			Kind:    WarningArchivedDirectChannel,
+			Skipped: true,
+			Channel: channel.OriginalName,
+			Message: fmt.Sprintf("--- Direct channel %s is not imported in archive mode", channel.OriginalName),
+		})
+	}
+
+	t.authorsByUsername = make(map[string]*IntermediateUser, len(t.Intermediate.UsersById))
+	for _, user := range t.Intermediate.UsersById {
+		t.authorsByUsername[user.Username] = user
+	}
+	t.archiveUser = archiveUser
+}
+
+// archiveAuthor returns how the author of an archived message is
+// shown in its text
+func (t *Transformer) archiveAuthor(username string) string {
+	user, ok := t.authorsByUsername[username]
+	if !ok {
+		return "@" + username
+	}
+	fullName := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if fullName == "" {
+		return "@" + username
+	}
+	return fmt.Sprintf("%s (@%s)", fullName, username)
+}
+
+func (t *Transformer) archiveMessage(post *IntermediatePost) *IntermediatePost {
+	archived := *post
+	archived.User = t.archiveUser.Username
+	// the users reacting are not imported
+	archived.Reactions = nil
+	createdAt := time.Unix(0, post.CreateAt*int64(time.Millisecond)).UTC().Format("2006-01-02 15:04 MST")
+	archived.Message = fmt.Sprintf("**%s** · %s\n%s", t.archiveAuthor(post.User), createdAt, post.Message)
+	return &archived
+}
+
+// archivePost attributes a post and its replies to the archive user
+func (t *Transformer) archivePost(post *IntermediatePost) *IntermediatePost {
+	archived := t.archiveMessage(post)
+	archived.Replies = make([]*IntermediatePost, 0, len(post.Replies))
+	for _, reply := range post.Replies {
+		archived.Replies = append(archived.Replies, t.archiveMessage(reply))
+	}
+	return archived
+}
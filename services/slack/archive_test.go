@@ -0,0 +1,52 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveMode(t *testing.T) {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com", "first_name": "John", "last_name": "Doe"}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com"}}
+	]`)}
+	fsys["dms.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "D1", "members": ["U1", "U2"]}
+	]`)}
+	fsys["D1/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "private", "ts": "1577836802.000100"}
+	]`)}
+
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true, ArchiveUser: "slack-archive"},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 1+1+1+2)
+	assert.Contains(t, lines[2], `"username":"slack-archive"`)
+	assert.Contains(t, lines[2], `"name":"general"`)
+	assert.NotContains(t, buffer.String(), `"username":"john"`)
+	assert.NotContains(t, buffer.String(), "private")
+
+	for _, line := range lines[3:] {
+		assert.Contains(t, line, `"user":"slack-archive"`)
+	}
+	// the posts of a channel are exported in no particular order
+	posts := strings.Join(lines[3:], "\n")
+	assert.Contains(t, posts, `"message":"**John Doe (@john)** · 2020-01-01 00:00 UTC\nhello @jane"`)
+	assert.Contains(t, posts, `"message":"**@jane** · 2020-01-01 00:00 UTC\na file"`)
+
+	assert.Equal(t, 1, result.TransformResult.Count(WarningArchivedDirectChannel))
+}
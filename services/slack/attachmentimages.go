@@ -0,0 +1,170 @@
+package slack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// DefaultAttachmentImageTimeout bounds the download of an image
+const DefaultAttachmentImageTimeout = 30 * time.Second
+
+// DefaultAttachmentImageHosts are the hosts of the images served by
+// Slack, which go away with the workspace
+var DefaultAttachmentImageHosts = []string{"slack.com", "slack-edge.com", "slack-imgs.com", "slack-files.com"}
+
+// AttachmentImageDownloader downloads the images of the message
+// attachments hosted by Slack, so they are imported as files of their
+// post instead of linking to Slack.
+type AttachmentImageDownloader struct {
+	HTTPClient *http.Client
+	// Token authenticates the downloads, the images uploaded to a
+	// workspace being private
+	Token string
+	// Hosts are the hosts, along with their subdomains, whose images
+	// are downloaded
+	Hosts []string
+}
+
+func NewAttachmentImageDownloader(token string) *AttachmentImageDownloader {
+	return &AttachmentImageDownloader{
+		HTTPClient: &http.Client{Timeout: DefaultAttachmentImageTimeout},
+		Token:      token,
+		Hosts:      DefaultAttachmentImageHosts,
+	}
+}
+
+func (d *AttachmentImageDownloader) hosted(imageURL string) bool {
+	u, err := url.Parse(imageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	hostname := strings.ToLower(u.Hostname())
+	for _, host := range d.Hosts {
+		if hostname == host || strings.HasSuffix(hostname, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// download writes the image at imageURL to destPath. A response that
+// is not an image, e.g. the login page served without a valid token,
+// is an error.
+func (d *AttachmentImageDownloader) download(imageURL, destPath string) error {
+	req, err := http.NewRequest(http.MethodGet, imageURL, nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image url %s", imageURL)
+	}
+	if d.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.Token)
+	}
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download the image %s", imageURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download the image %s: status %d", imageURL, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return errors.Errorf("failed to download the image %s: unexpected content type %q", imageURL, contentType)
+	}
+
+	destFile, err := os.Create(destPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the image file %s", destPath)
+	}
+	if _, err := io.Copy(destFile, resp.Body); err != nil {
+		destFile.Close()
+		os.Remove(destPath)
+		return errors.Wrapf(err, "failed to download the image %s", imageURL)
+	}
+	if err := destFile.Close(); err != nil {
+		os.Remove(destPath)
+		return errors.Wrapf(err, "failed to write the image file %s", destPath)
+	}
+	return nil
+}
+
+// attachmentImageFile names the file of an image after the hash of its
+// URL, so an image shared by several posts is downloaded once
+func attachmentImageFile(imageURL string) *SlackFile {
+	hash := sha256.Sum256([]byte(imageURL))
+	name := "image"
+	if u, err := url.Parse(imageURL); err == nil {
+		if base := path.Base(u.Path); base != "." && base != "/" {
+			name = base
+		}
+	}
+	return &SlackFile{Id: "I" + hex.EncodeToString(hash[:8]), Name: name}
+}
+
+// addAttachmentImage adds the image at imageURL to the files of the
+// post, downloading it unless a previous post did
+func (t *Transformer) addAttachmentImage(pc *PostContext, imageURL string, post *IntermediatePost) error {
+	cfg := pc.Config
+	file := attachmentImageFile(imageURL)
+	destFilePath := getNormalisedFilePath(file, cfg.AttachmentsDir, cfg.AttachmentsLayout, post.Channel)
+
+	if _, err := os.Stat(destFilePath); err != nil {
+		if err := os.MkdirAll(path.Dir(destFilePath), 0755); err != nil {
+			return errors.Wrapf(err, "failed to create the directory of file %s in the attachments directory", file.Id)
+		}
+		if err := cfg.AttachmentImages.download(imageURL, destFilePath); err != nil {
+			return err
+		}
+		if cfg.AttachmentScanner != nil {
+			if err := cfg.AttachmentScanner.scan(file, post.Channel, destFilePath); err != nil {
+				return err
+			}
+		}
+		t.Logger.Debugf("SUCCESS DOWNLOADING IMAGE %s TO DEST %s", imageURL, destFilePath)
+	}
+
+	for _, attachment := range post.Attachments {
+		if attachment == destFilePath {
+			return nil
+		}
+	}
+	post.Attachments = append(post.Attachments, destFilePath)
+	return nil
+}
+
+// downloadAttachmentImages adds the images of the attachments hosted by
+// Slack to the files of the post. It returns copies of the attachments
+// whose downloaded images are removed, as they are shown with the
+// files of the post. The images that could not be downloaded are kept.
+func (t *Transformer) downloadAttachmentImages(pc *PostContext, post SlackPost, newPost *IntermediatePost) []*model.SlackAttachment {
+	downloader := pc.Config.AttachmentImages
+	attachments := make([]*model.SlackAttachment, len(post.Attachments))
+	for i, attachment := range post.Attachments {
+		if attachment == nil {
+			continue
+		}
+		rewritten := *attachment
+		for _, imageURL := range []*string{&rewritten.ImageURL, &rewritten.ThumbURL} {
+			if *imageURL == "" || !downloader.hosted(*imageURL) {
+				continue
+			}
+			if err := t.addAttachmentImage(pc, *imageURL, newPost); err != nil {
+				t.warnAttachment(pc, post, err)
+				continue
+			}
+			*imageURL = ""
+		}
+		attachments[i] = &rewritten
+	}
+	return attachments
+}
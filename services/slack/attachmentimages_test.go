@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentImageDownloaderHosted(t *testing.T) {
+	downloader := NewAttachmentImageDownloader("")
+
+	assert.True(t, downloader.hosted("https://files.slack.com/files-pri/T1-F1/chart.png"))
+	assert.True(t, downloader.hosted("https://a.slack-edge.com/logo.png"))
+	assert.True(t, downloader.hosted("https://slack-imgs.com/?c=1&url=https%3A%2F%2Fexample.com%2Fa.png"))
+	assert.False(t, downloader.hosted("https://example.com/chart.png"))
+	assert.False(t, downloader.hosted("https://notslack.com/chart.png"))
+	assert.False(t, downloader.hosted("ftp://files.slack.com/chart.png"))
+}
+
+func TestAttachmentImageFile(t *testing.T) {
+	file := attachmentImageFile("https://files.slack.com/files-pri/T1-F1/chart.png")
+	assert.Equal(t, "chart.png", file.Name)
+	assert.Equal(t, file, attachmentImageFile("https://files.slack.com/files-pri/T1-F1/chart.png"))
+	assert.NotEqual(t, file.Id, attachmentImageFile("https://files.slack.com/files-pri/T1-F2/chart.png").Id)
+
+	assert.Equal(t, "image", attachmentImageFile("https://slack-imgs.com/?url=x").Name)
+}
+
+func TestTransformFSAttachmentImages(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/chart.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png data"))
+		case "/login":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>sign in</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	downloader := NewAttachmentImageDownloader("xoxb-token")
+	downloader.Hosts = []string{serverURL.Hostname()}
+
+	fsys := testExportFS()
+	delete(fsys, "general/2020-01-01.json")
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`[
+		{"type": "message", "user": "U1", "text": "the chart", "ts": "1577923200.000100", "attachments": [
+			{"title": "Chart", "image_url": "%[1]s/chart.png", "thumb_url": "%[1]s/chart.png"},
+			{"title": "Elsewhere", "image_url": "https://example.com/photo.png"}
+		]},
+		{"type": "message", "user": "U2", "text": "the chart again", "ts": "1577923201.000100", "attachments": [
+			{"title": "Chart", "image_url": "%[1]s/chart.png"}
+		]},
+		{"type": "message", "user": "U2", "text": "private", "ts": "1577923202.000100", "attachments": [
+			{"title": "Login", "image_url": "%[1]s/login"}
+		]}
+	]`, server.URL))}
+
+	attachmentsDir := t.TempDir()
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{AttachmentsDir: attachmentsDir, AttachmentImages: downloader},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer xoxb-token", authorization)
+
+	posts := map[string]*IntermediatePost{}
+	for _, post := range result.Intermediate.Posts {
+		posts[post.Message] = post
+	}
+	require.Len(t, posts, 3)
+
+	chart := posts["the chart"]
+	require.Len(t, chart.Attachments, 1)
+	data, err := os.ReadFile(chart.Attachments[0])
+	require.NoError(t, err)
+	assert.Equal(t, "png data", string(data))
+
+	attachments := chart.Props["attachments"].([]*model.SlackAttachment)
+	require.Len(t, attachments, 2)
+	assert.Equal(t, "Chart", attachments[0].Title)
+	assert.Empty(t, attachments[0].ImageURL)
+	assert.Empty(t, attachments[0].ThumbURL)
+	assert.Equal(t, "https://example.com/photo.png", attachments[1].ImageURL)
+
+	assert.Equal(t, chart.Attachments, posts["the chart again"].Attachments)
+
+	private := posts["private"]
+	assert.Empty(t, private.Attachments)
+	attachments = private.Props["attachments"].([]*model.SlackAttachment)
+	assert.Equal(t, server.URL+"/login", attachments[0].ImageURL)
+	assert.Equal(t, 1, result.TransformResult.Count(WarningAttachmentFailed))
+}
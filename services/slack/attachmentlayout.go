@@ -0,0 +1,56 @@
+package slack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// AttachmentsLayout decides how the attachments are laid out in the
+// attachments directory. The paths of the import lines follow it.
+type AttachmentsLayout string
+
+const (
+	// AttachmentsFlat writes all the attachments in the directory
+	AttachmentsFlat AttachmentsLayout = "flat"
+	// AttachmentsPerChannel writes the attachments in a subdirectory
+	// named after the channel of their post
+	AttachmentsPerChannel AttachmentsLayout = "channel"
+	// AttachmentsHashed writes the attachments in two levels of
+	// subdirectories named after the hash of the file id, spreading
+	// them evenly whatever the size of the channels
+	AttachmentsHashed AttachmentsLayout = "hash"
+)
+
+func ParseAttachmentsLayout(layout string) (AttachmentsLayout, error) {
+	switch AttachmentsLayout(layout) {
+	case "", AttachmentsFlat:
+		return AttachmentsFlat, nil
+	case AttachmentsPerChannel, AttachmentsHashed:
+		return AttachmentsLayout(layout), nil
+	}
+	return "", errors.Errorf("unknown attachments layout %q, expected %q, %q or %q", layout, AttachmentsFlat, AttachmentsPerChannel, AttachmentsHashed)
+}
+
+// subdirectory returns the directory of a file of the channel,
+// relative to the attachments directory
+func (l AttachmentsLayout) subdirectory(file *SlackFile, channelName string) string {
+	switch l {
+	case AttachmentsPerChannel:
+		return channelName
+	case AttachmentsHashed:
+		hash := sha256.Sum256([]byte(file.Id))
+		prefix := hex.EncodeToString(hash[:2])
+		return path.Join(prefix[:2], prefix[2:])
+	}
+	return ""
+}
+
+func getNormalisedFilePath(file *SlackFile, attachmentsDir string, layout AttachmentsLayout, channelName string) string {
+	filePath := path.Join(attachmentsDir, layout.subdirectory(file, channelName), fmt.Sprintf("%s_%s", file.Id, file.Name))
+	return string(norm.NFC.Bytes([]byte(filePath)))
+}
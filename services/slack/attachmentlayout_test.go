@@ -0,0 +1,54 @@
+package slack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNormalisedFilePath(t *testing.T) {
+	file := &SlackFile{Id: "F1", Name: "notes.txt"}
+	assert.Equal(t, "attachments/F1_notes.txt", getNormalisedFilePath(file, "attachments", AttachmentsFlat, "general"))
+	assert.Equal(t, "attachments/general/F1_notes.txt", getNormalisedFilePath(file, "attachments", AttachmentsPerChannel, "general"))
+	// the first bytes of the sha256 of F1 are 0xde 0xae
+	assert.Equal(t, "attachments/de/ae/F1_notes.txt", getNormalisedFilePath(file, "attachments", AttachmentsHashed, "general"))
+}
+
+func TestParseAttachmentsLayout(t *testing.T) {
+	layout, err := ParseAttachmentsLayout("")
+	require.NoError(t, err)
+	assert.Equal(t, AttachmentsFlat, layout)
+
+	layout, err = ParseAttachmentsLayout("hash")
+	require.NoError(t, err)
+	assert.Equal(t, AttachmentsHashed, layout)
+
+	_, err = ParseAttachmentsLayout("tree")
+	assert.Error(t, err)
+}
+
+func TestAttachmentsPerChannel(t *testing.T) {
+	attachmentsDir := t.TempDir()
+
+	result, err := TransformFS(context.Background(), testExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{AttachmentsDir: attachmentsDir, AttachmentsLayout: AttachmentsPerChannel},
+	})
+	require.NoError(t, err)
+
+	destFilePath := filepath.Join(attachmentsDir, "general", "F1_notes.txt")
+	for _, post := range result.Intermediate.Posts {
+		if post.Message == "a file" {
+			assert.Equal(t, []string{destFilePath}, post.Attachments)
+		}
+	}
+	content, err := os.ReadFile(destFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "some notes", string(content))
+}
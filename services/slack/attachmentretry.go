@@ -0,0 +1,129 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultAttachmentRetries is the number of times a failed copy of an
+// attachment is retried
+const DefaultAttachmentRetries = 3
+
+// attachmentRetryDelay is multiplied by the attempt number to wait
+// before retrying a failed copy
+var attachmentRetryDelay = time.Second
+
+// FailedAttachment is an attachment whose copy still failed after the
+// retries. When Kept, its post references it, so it must be copied,
+// e.g. with RetryFailedAttachments, before the import. Otherwise the
+// post is imported without it.
+type FailedAttachment struct {
+	FileId     string `json:"file_id"`
+	Channel    string `json:"channel"`
+	UploadPath string `json:"upload_path"`
+	DestPath   string `json:"dest_path"`
+	Error      string `json:"error"`
+	Kept       bool   `json:"kept"`
+}
+
+// copyUpload copies the upload of the export to the destination path.
+// The destination is removed when the copy fails, so no truncated file
+// is imported.
+func copyUpload(fsys fs.FS, uploadPath, destPath string) error {
+	uploadReader, err := fsys.Open(uploadPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s in the export", uploadPath)
+	}
+	defer uploadReader.Close()
+
+	if err := os.MkdirAll(path.Dir(destPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create the directory of %s", destPath)
+	}
+	destFile, err := os.Create(destPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", destPath)
+	}
+	if _, err := io.Copy(destFile, uploadReader); err != nil {
+		destFile.Close()
+		os.Remove(destPath)
+		return errors.Wrapf(err, "failed to copy %s to %s", uploadPath, destPath)
+	}
+	if err := destFile.Close(); err != nil {
+		os.Remove(destPath)
+		return errors.Wrapf(err, "failed to write %s", destPath)
+	}
+	return nil
+}
+
+// copyUploadWithRetries copies the upload, retrying the failed copies
+// with a growing delay, as antivirus locks and disk hiccups are
+// usually transient. The uploads missing from the export are not
+// retried, and the waits stop when the context is done.
+func copyUploadWithRetries(ctx context.Context, fsys fs.FS, uploadPath, destPath string, retries int) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(time.Duration(attempt) * attachmentRetryDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errors.Wrapf(err, "interrupted while retrying the copy of %s", uploadPath)
+			case <-timer.C:
+			}
+		}
+		if err = copyUpload(fsys, uploadPath, destPath); err == nil || errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return err
+}
+
+// FailedAttachments returns the attachments whose copy failed after
+// the retries
+func (t *Transformer) FailedAttachments() []FailedAttachment {
+	return t.failedAttachments
+}
+
+// RetryFailedAttachments copies the failed attachments from the export
+// again, returning the ones that still fail.
+func RetryFailedAttachments(ctx context.Context, fsys fs.FS, failed []FailedAttachment, retries int) []FailedAttachment {
+	stillFailed := []FailedAttachment{}
+	for _, attachment := range failed {
+		if err := copyUploadWithRetries(ctx, fsys, attachment.UploadPath, attachment.DestPath, retries); err != nil {
+			attachment.Error = err.Error()
+			stillFailed = append(stillFailed, attachment)
+		}
+	}
+	return stillFailed
+}
+
+func ReadFailedAttachmentsReport(reportPath string) ([]FailedAttachment, error) {
+	b, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the failed attachments report %s", reportPath)
+	}
+	var failed []FailedAttachment
+	if err := json.Unmarshal(b, &failed); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the failed attachments report %s", reportPath)
+	}
+	return failed, nil
+}
+
+func WriteFailedAttachmentsReport(reportPath string, failed []FailedAttachment) error {
+	b, err := json.MarshalIndent(failed, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the failed attachments report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the failed attachments report %s", reportPath)
+	}
+	return nil
+}
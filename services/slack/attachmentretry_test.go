@@ -0,0 +1,195 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyFS fails to open the given path the given number of times
+type flakyFS struct {
+	fs.FS
+	path     string
+	failures int
+	opens    int
+}
+
+func (f *flakyFS) Open(name string) (fs.File, error) {
+	if name == f.path {
+		f.opens++
+		if f.opens <= f.failures {
+			return nil, errors.New("file locked")
+		}
+	}
+	return f.FS.Open(name)
+}
+
+// truncatedFS fails the reads of the given path after its first byte
+type truncatedFS struct {
+	fs.FS
+	path string
+}
+
+type truncatedFile struct {
+	fs.File
+	read bool
+}
+
+func (f *truncatedFile) Read(p []byte) (int, error) {
+	if f.read {
+		return 0, errors.New("disk hiccup")
+	}
+	f.read = true
+	return f.File.Read(p[:1])
+}
+
+func (f *truncatedFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil || name != f.path {
+		return file, err
+	}
+	return &truncatedFile{File: file}, nil
+}
+
+func withoutRetryDelay(t *testing.T) {
+	delay := attachmentRetryDelay
+	attachmentRetryDelay = 0
+	t.Cleanup(func() { attachmentRetryDelay = delay })
+}
+
+func TestCopyUploadWithRetries(t *testing.T) {
+	withoutRetryDelay(t)
+	exportFS := fstest.MapFS{"__uploads/F1/notes.txt": &fstest.MapFile{Data: []byte("some notes")}}
+
+	t.Run("transient failure", func(t *testing.T) {
+		fsys := &flakyFS{FS: exportFS, path: "__uploads/F1/notes.txt", failures: 2}
+		destPath := filepath.Join(t.TempDir(), "F1", "notes.txt")
+		require.NoError(t, copyUploadWithRetries(context.Background(), fsys, "__uploads/F1/notes.txt", destPath, 2))
+		assert.Equal(t, 3, fsys.opens)
+		content, err := os.ReadFile(destPath)
+		require.NoError(t, err)
+		assert.Equal(t, "some notes", string(content))
+	})
+
+	t.Run("persistent failure", func(t *testing.T) {
+		fsys := &flakyFS{FS: exportFS, path: "__uploads/F1/notes.txt", failures: 10}
+		err := copyUploadWithRetries(context.Background(), fsys, "__uploads/F1/notes.txt", filepath.Join(t.TempDir(), "notes.txt"), 2)
+		require.Error(t, err)
+		assert.Equal(t, 3, fsys.opens)
+	})
+
+	t.Run("missing upload", func(t *testing.T) {
+		fsys := &flakyFS{FS: exportFS, path: "__uploads/F2/missing.txt"}
+		err := copyUploadWithRetries(context.Background(), fsys, "__uploads/F2/missing.txt", filepath.Join(t.TempDir(), "missing.txt"), 2)
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		assert.Equal(t, 1, fsys.opens)
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		attachmentRetryDelay = time.Hour
+		defer func() { attachmentRetryDelay = 0 }()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		fsys := &flakyFS{FS: exportFS, path: "__uploads/F1/notes.txt", failures: 10}
+		err := copyUploadWithRetries(ctx, fsys, "__uploads/F1/notes.txt", filepath.Join(t.TempDir(), "notes.txt"), 2)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file locked")
+		assert.Equal(t, 1, fsys.opens)
+	})
+
+	t.Run("truncated copy", func(t *testing.T) {
+		fsys := &truncatedFS{FS: exportFS, path: "__uploads/F1/notes.txt"}
+		destPath := filepath.Join(t.TempDir(), "notes.txt")
+		err := copyUploadWithRetries(context.Background(), fsys, "__uploads/F1/notes.txt", destPath, 1)
+		require.Error(t, err)
+		assert.NoFileExists(t, destPath)
+	})
+}
+
+func TestTransformFSFailedAttachments(t *testing.T) {
+	withoutRetryDelay(t)
+	attachmentsDir := t.TempDir()
+	fsys := &flakyFS{FS: testExportFS(), path: "__uploads/F1/notes.txt", failures: 10}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			AttachmentsDir:        attachmentsDir,
+			AttachmentRetries:     1,
+			KeepFailedAttachments: true,
+		},
+	})
+	require.NoError(t, err)
+
+	failed := result.FailedAttachments()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "F1", failed[0].FileId)
+	assert.Equal(t, "general", failed[0].Channel)
+	assert.Equal(t, "__uploads/F1/notes.txt", failed[0].UploadPath)
+	assert.Contains(t, failed[0].Error, "file locked")
+	assert.True(t, failed[0].Kept)
+	assert.Equal(t, 1, result.TransformResult.Count(WarningAttachmentFailed))
+
+	// the post keeps the attachment to copy before the import
+	require.Len(t, result.Intermediate.Posts, 2)
+	messages := map[string]*IntermediatePost{}
+	for _, post := range result.Intermediate.Posts {
+		messages[post.Message] = post
+	}
+	require.Contains(t, messages, "a file")
+	assert.Equal(t, []string{failed[0].DestPath}, messages["a file"].Attachments)
+	assert.NoFileExists(t, failed[0].DestPath)
+
+	reportPath := filepath.Join(t.TempDir(), "failed-attachments.json")
+	require.NoError(t, WriteFailedAttachmentsReport(reportPath, failed))
+	read, err := ReadFailedAttachmentsReport(reportPath)
+	require.NoError(t, err)
+	assert.Equal(t, failed, read)
+
+	assert.Empty(t, RetryFailedAttachments(context.Background(), testExportFS(), read, 0))
+	assert.FileExists(t, failed[0].DestPath)
+
+	stillFailed := RetryFailedAttachments(context.Background(), fstest.MapFS{}, read, 0)
+	require.Len(t, stillFailed, 1)
+	assert.Contains(t, stillFailed[0].Error, "file does not exist")
+}
+
+func TestTransformFSFailedAttachmentsDropped(t *testing.T) {
+	withoutRetryDelay(t)
+	fsys := &flakyFS{FS: testExportFS(), path: "__uploads/F1/notes.txt", failures: 10}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			AttachmentsDir:    t.TempDir(),
+			AttachmentRetries: 1,
+		},
+	})
+	require.NoError(t, err)
+
+	failed := result.FailedAttachments()
+	require.Len(t, failed, 1)
+	assert.False(t, failed[0].Kept)
+
+	// the archives can be written, as no line references the
+	// missing file
+	for _, post := range result.Intermediate.Posts {
+		assert.Empty(t, post.Attachments)
+	}
+	var buffer bytes.Buffer
+	exporter := NewZipExporter(&buffer)
+	require.NoError(t, result.transformer.ExportWith(exporter))
+	require.NoError(t, exporter.Close())
+}
@@ -0,0 +1,140 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// errAttachmentQuarantined is returned for the attachments flagged by
+// the AttachmentScanner
+var errAttachmentQuarantined = errors.New("the attachment was flagged by the scanner")
+
+// AttachmentScanner pipes the attachments through an external scanner
+// command before they are shipped with the import. The path of the
+// file is appended to the arguments of the command. As with clamscan,
+// the exit status 1 flags the file, which is moved to the
+// QuarantineDir, and any other failure is an error.
+type AttachmentScanner struct {
+	Command       []string
+	QuarantineDir string
+
+	mu          sync.Mutex
+	quarantined []QuarantinedAttachment
+}
+
+// QuarantinedAttachment is an attachment flagged by the scanner, with
+// what the scanner printed about it.
+type QuarantinedAttachment struct {
+	FileID  string `json:"file_id"`
+	Name    string `json:"name"`
+	Channel string `json:"channel"`
+	Path    string `json:"path"`
+	Output  string `json:"output"`
+}
+
+// NewAttachmentScanner creates a scanner running command, split on
+// whitespace, and moving the flagged files to quarantineDir.
+func NewAttachmentScanner(command, quarantineDir string) (*AttachmentScanner, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("the scanner command is empty")
+	}
+	if quarantineDir == "" {
+		return nil, errors.New("the quarantine directory is empty")
+	}
+	return &AttachmentScanner{Command: args, QuarantineDir: quarantineDir}, nil
+}
+
+// scan runs the scanner on the copied attachment at filePath. The
+// flagged attachments are quarantined and errAttachmentQuarantined is
+// returned, the attachments that could not be scanned are removed.
+func (s *AttachmentScanner) scan(file *SlackFile, channelName, filePath string) error {
+	args := append(append([]string{}, s.Command[1:]...), filePath)
+	output, err := exec.Command(s.Command[0], args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		os.Remove(filePath)
+		return errors.Wrapf(err, "failed to scan file %s: %s", file.Id, strings.TrimSpace(string(output)))
+	}
+
+	if err := os.MkdirAll(s.QuarantineDir, 0700); err != nil {
+		os.Remove(filePath)
+		return errors.Wrap(err, "failed to create the quarantine directory")
+	}
+	quarantinePath := filepath.Join(s.QuarantineDir, filepath.Base(filePath))
+	if err := moveFile(filePath, quarantinePath); err != nil {
+		os.Remove(filePath)
+		return errors.Wrapf(err, "failed to quarantine file %s", file.Id)
+	}
+
+	s.mu.Lock()
+	s.quarantined = append(s.quarantined, QuarantinedAttachment{
+		FileID:  file.Id,
+		Name:    file.Name,
+		Channel: channelName,
+		Path:    quarantinePath,
+		Output:  strings.TrimSpace(string(output)),
+	})
+	s.mu.Unlock()
+
+	return errors.Wrapf(errAttachmentQuarantined, "file %s moved to %s", file.Id, quarantinePath)
+}
+
+// Quarantined returns the attachments flagged by the scanner so far.
+func (s *AttachmentScanner) Quarantined() []QuarantinedAttachment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]QuarantinedAttachment{}, s.quarantined...)
+}
+
+// moveFile renames src to dst, copying it when they are on different
+// devices
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+	if err := dstFile.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// WriteQuarantineReport writes the attachments flagged by the scanner
+// to reportPath as JSON.
+func WriteQuarantineReport(reportPath string, quarantined []QuarantinedAttachment) error {
+	b, err := json.MarshalIndent(quarantined, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the quarantine report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the quarantine report %s", reportPath)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package slack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testScanner creates a scanner running the shell script
+func testScanner(t *testing.T, script string) *AttachmentScanner {
+	dir := t.TempDir()
+	scriptPath := filepath.Join(dir, "scan.sh")
+	require.NoError(t, os.WriteFile(scriptPath, []byte(script), 0700))
+
+	scanner, err := NewAttachmentScanner("sh "+scriptPath, filepath.Join(dir, "quarantine"))
+	require.NoError(t, err)
+	return scanner
+}
+
+func transformScanned(t *testing.T, scanner *AttachmentScanner) (*Result, string) {
+	attachmentsDir := t.TempDir()
+	result, err := TransformFS(context.Background(), testExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{AttachmentsDir: attachmentsDir, AttachmentScanner: scanner},
+	})
+	require.NoError(t, err)
+	return result, filepath.Join(attachmentsDir, "F1_notes.txt")
+}
+
+func fileSharePost(result *Result) *IntermediatePost {
+	for _, post := range result.Intermediate.Posts {
+		if post.Message == "a file" {
+			return post
+		}
+	}
+	return nil
+}
+
+func TestNewAttachmentScanner(t *testing.T) {
+	_, err := NewAttachmentScanner(" ", "quarantine")
+	assert.Error(t, err)
+
+	_, err = NewAttachmentScanner("clamscan", "")
+	assert.Error(t, err)
+
+	scanner, err := NewAttachmentScanner("clamscan --no-summary", "quarantine")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"clamscan", "--no-summary"}, scanner.Command)
+}
+
+func TestAttachmentScannerClean(t *testing.T) {
+	scanner := testScanner(t, "exit 0\n")
+	result, destFilePath := transformScanned(t, scanner)
+
+	assert.Equal(t, []string{destFilePath}, fileSharePost(result).Attachments)
+	assert.FileExists(t, destFilePath)
+	assert.Empty(t, scanner.Quarantined())
+	assert.Zero(t, result.TransformResult.Count(WarningQuarantinedAttachment))
+}
+
+func TestAttachmentScannerFlagged(t *testing.T) {
+	scanner := testScanner(t, "grep -q notes \"$1\" && echo \"$1: Eicar FOUND\" && exit 1\nexit 0\n")
+	result, destFilePath := transformScanned(t, scanner)
+
+	assert.Empty(t, fileSharePost(result).Attachments)
+	assert.NoFileExists(t, destFilePath)
+
+	quarantined := scanner.Quarantined()
+	require.Len(t, quarantined, 1)
+	assert.Equal(t, "F1", quarantined[0].FileID)
+	assert.Equal(t, "notes.txt", quarantined[0].Name)
+	assert.Equal(t, "general", quarantined[0].Channel)
+	assert.Equal(t, filepath.Join(scanner.QuarantineDir, "F1_notes.txt"), quarantined[0].Path)
+	assert.Contains(t, quarantined[0].Output, "Eicar FOUND")
+	content, err := os.ReadFile(quarantined[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "some notes", string(content))
+
+	assert.Equal(t, 1, result.TransformResult.Count(WarningQuarantinedAttachment))
+	assert.Zero(t, result.TransformResult.Count(WarningAttachmentFailed))
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, WriteQuarantineReport(reportPath, quarantined))
+	report, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), `"file_id": "F1"`)
+}
+
+func TestAttachmentScannerFailure(t *testing.T) {
+	scanner := testScanner(t, "echo 'database missing'\nexit 2\n")
+	result, destFilePath := transformScanned(t, scanner)
+
+	// an attachment that could not be scanned is never shipped
+	assert.Empty(t, fileSharePost(result).Attachments)
+	assert.NoFileExists(t, destFilePath)
+	assert.Empty(t, scanner.Quarantined())
+
+	require.Equal(t, 1, result.TransformResult.Count(WarningAttachmentFailed))
+	for _, warning := range result.TransformResult.Warnings {
+		if warning.Kind == WarningAttachmentFailed {
+			assert.Contains(t, warning.Err.Error(), "database missing")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// avatarsSubdir is the directory of the avatars in the attachments
+// directory
+const avatarsSubdir = "avatars"
+
+// SlackAvatar holds the URLs of the sizes of the profile photo of a
+// Slack user. The sizes are squares cropped the way the user chose.
+type SlackAvatar struct {
+	// IsCustomImage is false for the placeholders Slack generates for
+	// the users without a photo
+	IsCustomImage *bool  `json:"is_custom_image"`
+	Image24       string `json:"image_24"`
+	Image32       string `json:"image_32"`
+	Image48       string `json:"image_48"`
+	Image72       string `json:"image_72"`
+	Image192      string `json:"image_192"`
+	Image512      string `json:"image_512"`
+	Image1024     string `json:"image_1024"`
+	ImageOriginal string `json:"image_original"`
+}
+
+// avatarURL returns the URL of the size of the photo closest to what
+// Mattermost shows, preferring the larger cropped sizes over the
+// original, which can exceed the limits of the profile images. It
+// returns an empty URL for the placeholders.
+func (a SlackAvatar) avatarURL() string {
+	if a.IsCustomImage != nil && !*a.IsCustomImage {
+		return ""
+	}
+	for _, imageURL := range []string{a.Image512, a.Image192, a.Image1024, a.Image72, a.Image48, a.Image32, a.Image24, a.ImageOriginal} {
+		if imageURL != "" {
+			return imageURL
+		}
+	}
+	return ""
+}
+
+// avatarPath returns the path of the avatar of a user, keeping the
+// extension of the image
+func avatarPath(attachmentsDir, userID, imageURL string) string {
+	ext := ".jpg"
+	if u, err := url.Parse(imageURL); err == nil {
+		if urlExt := strings.ToLower(path.Ext(u.Path)); urlExt == ".png" || urlExt == ".gif" || urlExt == ".jpeg" {
+			ext = urlExt
+		}
+	}
+	return path.Join(attachmentsDir, avatarsSubdir, userID+ext)
+}
+
+// DownloadAvatars downloads the profile photos of the transformed users
+// into the avatars subdirectory of the attachments directory, setting
+// them as their profile image. The photos already downloaded by a
+// previous run are reused.
+func (t *Transformer) DownloadAvatars(users []SlackUser, downloader *AttachmentImageDownloader, attachmentsDir string) {
+	t.Logger.Info("Downloading avatars")
+	if err := os.MkdirAll(path.Join(attachmentsDir, avatarsSubdir), 0755); err != nil {
+		t.Logger.WithError(err).Error("Failed to create the avatars directory")
+		return
+	}
+
+	for _, user := range users {
+		intermediateUser, ok := t.Intermediate.UsersById[user.Id]
+		if !ok {
+			continue
+		}
+		imageURL := user.Profile.avatarURL()
+		if imageURL == "" {
+			continue
+		}
+
+		destPath := avatarPath(attachmentsDir, user.Id, imageURL)
+		if _, err := os.Stat(destPath); err != nil {
+			if err := downloader.download(imageURL, destPath); err != nil {
+				t.warn(&Warning{
+					Kind:    WarningAvatarFailed,
+					Message: fmt.Sprintf("Unable to download the avatar of user %s", intermediateUser.Username),
+					Err:     err,
+				})
+				continue
+			}
+		}
+		intermediateUser.ProfileImage = destPath
+	}
+}
@@ -0,0 +1,130 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackAvatarURL(t *testing.T) {
+	assert.Equal(t, "https://a/512.jpg", SlackAvatar{Image192: "https://a/192.jpg", Image512: "https://a/512.jpg", ImageOriginal: "https://a/original.jpg"}.avatarURL())
+	assert.Equal(t, "https://a/192.jpg", SlackAvatar{Image192: "https://a/192.jpg", Image1024: "https://a/1024.jpg", ImageOriginal: "https://a/original.jpg"}.avatarURL())
+	assert.Equal(t, "https://a/72.jpg", SlackAvatar{Image24: "https://a/24.jpg", Image72: "https://a/72.jpg"}.avatarURL())
+	assert.Equal(t, "https://a/original.jpg", SlackAvatar{ImageOriginal: "https://a/original.jpg"}.avatarURL())
+	assert.Empty(t, SlackAvatar{IsCustomImage: model.NewBool(false), Image512: "https://a/512.jpg"}.avatarURL())
+	assert.Empty(t, SlackAvatar{}.avatarURL())
+}
+
+func TestAvatarPath(t *testing.T) {
+	assert.Equal(t, "data/avatars/U1.png", avatarPath("data", "U1", "https://avatars.slack-edge.com/2020/abc_512.png"))
+	assert.Equal(t, "data/avatars/U1.jpg", avatarPath("data", "U1", "https://avatars.slack-edge.com/2020/abc_512"))
+}
+
+func TestTransformFSDownloadAvatars(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.URL.Path == "/missing_512.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("avatar " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com", "is_custom_image": true, "image_192": "%[1]s/john_192.png", "image_512": "%[1]s/john_512.png", "image_original": "%[1]s/john_original.png"}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com", "is_custom_image": false, "image_512": "%[1]s/placeholder_512.png"}},
+		{"id": "U3", "name": "jim", "profile": {"email": "jim@example.com", "image_512": "%[1]s/missing_512.png"}}
+	]`, server.URL))}
+
+	attachmentsDir := t.TempDir()
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			AttachmentsDir:   attachmentsDir,
+			AvatarDownloader: NewAttachmentImageDownloader(""),
+		},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/john_512.png", "/missing_512.png"}, requested)
+	avatar := filepath.Join(attachmentsDir, "avatars", "U1.png")
+	data, err := os.ReadFile(avatar)
+	require.NoError(t, err)
+	assert.Equal(t, "avatar /john_512.png", string(data))
+
+	assert.Equal(t, avatar, result.Intermediate.UsersById["U1"].ProfileImage)
+	assert.Empty(t, result.Intermediate.UsersById["U2"].ProfileImage)
+	assert.Empty(t, result.Intermediate.UsersById["U3"].ProfileImage)
+	assert.Equal(t, 1, result.TransformResult.Count(WarningAvatarFailed))
+	assert.Contains(t, buffer.String(), `"profile_image":"`+avatar+`"`)
+
+	// the avatars downloaded by a previous run are reused
+	requested = nil
+	_, err = StreamFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			AttachmentsDir:   attachmentsDir,
+			AvatarDownloader: NewAttachmentImageDownloader(""),
+		},
+	}, NewJSONLExporter(&bytes.Buffer{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/missing_512.png"}, requested)
+}
+
+func TestZipExporterAvatars(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("avatar " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com", "image_512": "%[1]s/john_512.png"}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com"}}
+	]`, server.URL))}
+
+	attachmentsDir := t.TempDir()
+	var buffer bytes.Buffer
+	exporter := NewZipExporter(&buffer)
+	_, err := StreamFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			AttachmentsDir:   attachmentsDir,
+			AvatarDownloader: NewAttachmentImageDownloader(""),
+		},
+	}, exporter)
+	require.NoError(t, err)
+	require.NoError(t, exporter.Close())
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	names := []string{}
+	for _, file := range zipReader.File {
+		names = append(names, file.Name)
+	}
+	avatar := filepath.Join(attachmentsDir, "avatars", "U1.png")
+	assert.Contains(t, names, path.Join(zipExportDataDir, avatar))
+	assert.Contains(t, names, path.Join(zipExportDataDir, attachmentsDir, "F1_notes.txt"))
+}
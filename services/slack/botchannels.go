@@ -0,0 +1,116 @@
+package slack
+
+import "fmt"
+
+// BotChannelPolicy leaves out the public and private channels where the
+// bot messages are more than Threshold of the messages, e.g. the
+// channels of monitoring alerts. When KeepEvery is set, the channels
+// are kept with one of every KeepEvery bot messages instead.
+type BotChannelPolicy struct {
+	Threshold float64
+	KeepEvery int
+}
+
+func isBotPost(post SlackPost) bool {
+	return post.IsBotMessage() || post.BotId != ""
+}
+
+// botPostsRatio returns the share of bot messages among the posts
+func botPostsRatio(posts []SlackPost) float64 {
+	if len(posts) == 0 {
+		return 0
+	}
+	bots := 0
+	for _, post := range posts {
+		if isBotPost(post) {
+			bots++
+		}
+	}
+	return float64(bots) / float64(len(posts))
+}
+
+// ApplyBotChannelPolicy finds the bot channels, reading their posts when
+// they are not parsed yet, and leaves them out unless the policy
+// downsamples them. It must run before the memberships are populated.
+func (t *Transformer) ApplyBotChannelPolicy(slackExport *SlackExport, policy *BotChannelPolicy) error {
+	t.botChannels = map[string]bool{}
+	for _, channels := range []*[]*IntermediateChannel{&t.Intermediate.PublicChannels, &t.Intermediate.PrivateChannels} {
+		kept := []*IntermediateChannel{}
+		for _, channel := range *channels {
+			posts, err := t.peekChannelPosts(slackExport, channel.OriginalName)
+			if err != nil {
+				return err
+			}
+			ratio := botPostsRatio(posts)
+			if ratio <= policy.Threshold {
+				kept = append(kept, channel)
+				continue
+			}
+
+			t.botChannels[channel.OriginalName] = true
+			if policy.KeepEvery > 0 {
+				kept = append(kept, channel)
+				continue
+			}
+			t.warn(&Warning{
+				Kind:    WarningBotChannel,
+				Skipped: true,
+				Channel: channel.OriginalName,
+				Message: fmt.Sprintf("Skipping channel %s as %.0f%% of its messages are bot messages", channel.OriginalName, ratio*100),
+			})
+		}
+		*channels = kept
+	}
+	return nil
+}
+
+// downsampleBotPosts keeps one of every keepEvery bot messages of a bot
+// channel, along with all the messages of the users. The root post of a
+// thread is kept whenever one of its replies is, so that the kept
+// replies are not imported without their thread.
+func (t *Transformer) downsampleBotPosts(originalChannelName string, posts []SlackPost, keepEvery int) []SlackPost {
+	sampled := make([]bool, len(posts))
+	keptThreads := map[string]bool{}
+	bots := 0
+	for i, post := range posts {
+		if isBotPost(post) {
+			bots++
+			if (bots-1)%keepEvery != 0 {
+				continue
+			}
+		}
+		sampled[i] = true
+		if post.ThreadTS != "" && post.ThreadTS != post.TimeStamp {
+			keptThreads[post.ThreadTS] = true
+		}
+	}
+
+	kept := make([]SlackPost, 0, len(posts))
+	for i, post := range posts {
+		if sampled[i] || keptThreads[post.TimeStamp] {
+			kept = append(kept, post)
+		}
+	}
+	if dropped := len(posts) - len(kept); dropped > 0 {
+		t.warn(&Warning{
+			Kind:    WarningBotChannel,
+			Channel: originalChannelName,
+			Message: fmt.Sprintf("Keeping one of every %d bot messages of channel %s, %d messages left out", keepEvery, originalChannelName, dropped),
+		})
+	}
+	return kept
+}
+
+// warnUnknownChannel reports the posts of a channel missing from the
+// transformed channels, unless it was left out as a bot channel
+func (t *Transformer) warnUnknownChannel(originalChannelName string) {
+	if t.botChannels[originalChannelName] {
+		return
+	}
+	t.warn(&Warning{
+		Kind:    WarningUnknownChannel,
+		Skipped: true,
+		Channel: originalChannelName,
+		Message: fmt.Sprintf("--- Couldn't find channel %s referenced by posts", originalChannelName),
+	})
+}
@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// botChannelExportFS has an alerts channel made of 9 bot messages and
+// a message of john
+func botChannelExportFS() fstest.MapFS {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "alerts", "members": ["U1", "U2"]}
+	]`)}
+	posts := []string{`{"type": "message", "user": "U1", "text": "ack", "ts": "1577923200.000100"}`}
+	for i := 1; i <= 9; i++ {
+		posts = append(posts, fmt.Sprintf(`{"type": "message", "subtype": "bot_message", "bot_id": "B1", "username": "monitoring", "text": "alert %d", "ts": "157792320%d.000100"}`, i, i))
+	}
+	fsys["alerts/2020-01-02.json"] = &fstest.MapFile{Data: []byte("[" + strings.Join(posts, ",") + "]")}
+	return fsys
+}
+
+func TestBotPostsRatio(t *testing.T) {
+	assert.Equal(t, float64(0), botPostsRatio(nil))
+	assert.Equal(t, 0.5, botPostsRatio([]SlackPost{
+		{Type: "message", SubType: "bot_message"},
+		{Type: "message", User: "U1"},
+	}))
+}
+
+func TestTransformFSSkipBotChannels(t *testing.T) {
+	result, err := TransformFS(context.Background(), botChannelExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true, BotChannelPolicy: &BotChannelPolicy{Threshold: 0.8}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Intermediate.PublicChannels, 1)
+	assert.Equal(t, "general", result.Intermediate.PublicChannels[0].Name)
+	assert.NotContains(t, result.Intermediate.UsersById["U1"].Memberships, "alerts")
+	for _, post := range result.Intermediate.Posts {
+		assert.Equal(t, "general", post.Channel)
+	}
+	assert.Equal(t, 1, result.TransformResult.Count(WarningBotChannel))
+	assert.Equal(t, 0, result.TransformResult.Count(WarningUnknownChannel))
+}
+
+func TestStreamFSDownsampleBotChannels(t *testing.T) {
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), botChannelExportFS(), Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			SkipAttachments:        true,
+			ImportWorkflowMessages: true,
+			BotChannelPolicy:       &BotChannelPolicy{Threshold: 0.8, KeepEvery: 4},
+		},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	output := buffer.String()
+	assert.Contains(t, output, `"name":"alerts"`)
+	assert.Contains(t, output, `"message":"ack"`)
+	for i := 1; i <= 9; i++ {
+		message := fmt.Sprintf(`"message":"alert %d"`, i)
+		if i == 1 || i == 5 || i == 9 {
+			assert.Contains(t, output, message)
+		} else {
+			assert.NotContains(t, output, message)
+		}
+	}
+	assert.Equal(t, 1, result.TransformResult.Count(WarningBotChannel))
+}
+
+func TestDownsampleBotThreads(t *testing.T) {
+	posts := []SlackPost{
+		{Type: "message", SubType: "bot_message", BotId: "B1", Text: "alert 1", TimeStamp: "1577923201.000100"},
+		{Type: "message", SubType: "bot_message", BotId: "B1", Text: "alert 2", TimeStamp: "1577923202.000100", ThreadTS: "1577923202.000100"},
+		{Type: "message", User: "U1", Text: "looking", TimeStamp: "1577923203.000100", ThreadTS: "1577923202.000100"},
+		{Type: "message", SubType: "bot_message", BotId: "B1", Text: "alert 3", TimeStamp: "1577923204.000100", ThreadTS: "1577923204.000100"},
+		{Type: "message", SubType: "bot_message", BotId: "B1", Text: "resolved", TimeStamp: "1577923205.000100", ThreadTS: "1577923204.000100"},
+	}
+
+	transformer := NewTransformer("team", log.New())
+	kept := transformer.downsampleBotPosts("alerts", posts, 2)
+
+	texts := []string{}
+	for _, post := range kept {
+		texts = append(texts, post.Text)
+	}
+	// alert 2 isn't sampled but is kept for the reply of john
+	assert.Equal(t, []string{"alert 1", "alert 2", "looking", "alert 3"}, texts)
+	assert.Equal(t, 1, transformer.result.Count(WarningBotChannel))
+}
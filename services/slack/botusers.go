@@ -0,0 +1,163 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// botUserPosition is the position of the users created for the bots
+const botUserPosition = "Slack app"
+
+// SlackBot is a bot integration of the workspace, as returned by the
+// bots.info API method
+type SlackBot struct {
+	Id      string `json:"id"`
+	AppId   string `json:"app_id"`
+	Name    string `json:"name"`
+	Deleted bool   `json:"deleted"`
+}
+
+// SlackParseBots reads the bots, either a response with a list of
+// bots or the list itself
+func SlackParseBots(data io.Reader) ([]SlackBot, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(data).Decode(&raw); err != nil {
+		return nil, err
+	}
+	var response struct {
+		Bots []SlackBot `json:"bots"`
+	}
+	if err := json.Unmarshal(raw, &response); err == nil {
+		return response.Bots, nil
+	}
+	bots := []SlackBot{}
+	if err := json.Unmarshal(raw, &bots); err != nil {
+		return nil, err
+	}
+	return bots, nil
+}
+
+// exportBots returns the bots of the bots.json file of the export and
+// the ones only known from the bot_profile or the username of their
+// messages, by bot id
+func (t *Transformer) exportBots(slackExport *SlackExport) (map[string]SlackBot, error) {
+	bots := map[string]SlackBot{}
+	for _, bot := range slackExport.Bots {
+		if bot.Id != "" {
+			bots[bot.Id] = bot
+		}
+	}
+
+	for _, channel := range slackExport.Channels {
+		posts, err := t.peekChannelPosts(slackExport, channel.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			if profile := post.BotProfile; profile != nil && profile.Id != "" {
+				bot := bots[profile.Id]
+				bot.Id = profile.Id
+				if bot.AppId == "" {
+					bot.AppId = profile.AppId
+				}
+				if bot.Name == "" {
+					bot.Name = profile.Name
+				}
+				bots[profile.Id] = bot
+			} else if post.BotId != "" {
+				bot := bots[post.BotId]
+				bot.Id = post.BotId
+				if bot.Name == "" {
+					bot.Name = post.BotUsername
+				}
+				bots[post.BotId] = bot
+			}
+		}
+	}
+	return bots, nil
+}
+
+// CreateBotUsers creates a user for each bot of the export, which the
+// bot messages are attributed to instead of the workflow user. The
+// bots are found in the bots.json file of the export and in the
+// messages, which are all read for it.
+func (t *Transformer) CreateBotUsers(slackExport *SlackExport) error {
+	bots, err := t.exportBots(slackExport)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(bots))
+	for id := range bots {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	taken := make(map[string]bool, len(t.Intermediate.UsersById))
+	for _, user := range t.Intermediate.UsersById {
+		taken[user.Username] = true
+	}
+
+	t.botUsers = make(map[string]*IntermediateUser, len(bots))
+	for _, id := range ids {
+		bot := bots[id]
+		username, _ := fixUsername(bot.Name)
+		if username == "" {
+			username = "bot-" + strings.ToLower(bot.Id)
+		}
+		candidate := username
+		if taken[candidate] {
+			candidate = username + "-bot"
+		}
+		for suffix := 2; taken[candidate]; suffix++ {
+			candidate = fmt.Sprintf("%s-bot-%d", username, suffix)
+		}
+		taken[candidate] = true
+
+		displayName := bot.Name
+		if displayName == "" {
+			displayName = candidate
+		}
+		newUser := &IntermediateUser{
+			Id:        bot.Id,
+			Username:  candidate,
+			FirstName: displayName,
+			Position:  botUserPosition,
+			Email:     fmt.Sprintf("imported-bot-%s@tinkoff.ru", strings.ToLower(bot.Id)),
+			Password:  model.NewId(),
+		}
+		newUser.Sanitise(t.Logger)
+		t.Intermediate.UsersById[newUser.Id] = newUser
+
+		t.botUsers[bot.Id] = newUser
+		if bot.AppId != "" && t.botUsers[bot.AppId] == nil {
+			t.botUsers[bot.AppId] = newUser
+		}
+		t.Logger.Debugf("Slack bot %s (%s) imported as the user %s", bot.Name, bot.Id, candidate)
+	}
+	t.Logger.Infof("Created %d users for the Slack bots", len(ids))
+	return nil
+}
+
+// botUser returns the user created by CreateBotUsers for the bot that
+// authored the post, matching by bot id first and by app id after
+func (t *Transformer) botUser(post SlackPost) *IntermediateUser {
+	if post.BotId != "" {
+		if user, ok := t.botUsers[post.BotId]; ok {
+			return user
+		}
+	}
+	if post.BotProfile != nil {
+		if user, ok := t.botUsers[post.BotProfile.Id]; ok {
+			return user
+		}
+		if user, ok := t.botUsers[post.BotProfile.AppId]; ok {
+			return user
+		}
+	}
+	return nil
+}
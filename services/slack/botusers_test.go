@@ -0,0 +1,82 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackParseBots(t *testing.T) {
+	expected := []SlackBot{{Id: "B1", AppId: "A1", Name: "github"}}
+
+	bots, err := SlackParseBots(strings.NewReader(`{"ok": true, "bots": [{"id": "B1", "app_id": "A1", "name": "github"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, expected, bots)
+
+	bots, err = SlackParseBots(strings.NewReader(`[{"id": "B1", "app_id": "A1", "name": "github"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, expected, bots)
+}
+
+func TestBotUsers(t *testing.T) {
+	exportFS := testExportFS()
+	exportFS["bots.json"] = &fstest.MapFile{Data: []byte(`[{"id": "B1", "app_id": "A1", "name": "GitHub"}]`)}
+	exportFS["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "bot_message", "bot_id": "B1", "text": "a commit", "ts": "1577923200.000100"},
+		{"type": "message", "subtype": "bot_message", "bot_profile": {"id": "B2", "app_id": "A2", "name": "jira"}, "text": "an issue", "ts": "1577923201.000100"},
+		{"type": "message", "subtype": "bot_message", "bot_id": "B3", "username": "john", "text": "a namesake", "ts": "1577923202.000100"},
+		{"type": "message", "subtype": "bot_message", "username": "webhook", "text": "a webhook", "ts": "1577923203.000100"}
+	]`)}
+
+	transform := func(t *testing.T, importWorkflowMessages bool) *Result {
+		result, err := TransformFS(context.Background(), exportFS, Options{
+			TeamName: "team",
+			Logger:   log.New(),
+			TransformConfig: TransformConfig{
+				SkipAttachments:        true,
+				BotUsers:               true,
+				ImportWorkflowMessages: importWorkflowMessages,
+			},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("a user per bot", func(t *testing.T) {
+		result := transform(t, false)
+
+		users := result.Intermediate.UsersById
+		require.Len(t, users, 5)
+		assert.Equal(t, "github", users["B1"].Username)
+		assert.Equal(t, "GitHub", users["B1"].FirstName)
+		assert.Equal(t, botUserPosition, users["B1"].Position)
+		assert.Equal(t, "imported-bot-b1@tinkoff.ru", users["B1"].Email)
+		assert.Equal(t, "jira", users["B2"].Username)
+		assert.Equal(t, "john-bot", users["B3"].Username)
+
+		authors := map[string]string{}
+		for _, post := range result.Intermediate.Posts {
+			authors[post.Message] = post.User
+		}
+		assert.Equal(t, "github", authors["a commit"])
+		assert.Equal(t, "jira", authors["an issue"])
+		assert.Equal(t, "john-bot", authors["a namesake"])
+		assert.NotContains(t, authors, "a webhook")
+	})
+
+	t.Run("the unknown bots with the workflow user", func(t *testing.T) {
+		result := transform(t, true)
+
+		authors := map[string]string{}
+		for _, post := range result.Intermediate.Posts {
+			authors[post.Message] = post.User
+		}
+		assert.Equal(t, "github", authors["a commit"])
+		assert.Equal(t, WorkflowUserName, authors["a webhook"])
+	})
+}
@@ -0,0 +1,116 @@
+package slack
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ChannelArchivePolicy selects the public and private channels to
+// archive after the import: the channels archived in Slack when
+// SlackArchived is set, and the ones with no message during the
+// InactiveMonths months before the last day of the export, holding at
+// most MaxMessages messages when it is set. A zero field is not
+// checked.
+type ChannelArchivePolicy struct {
+	SlackArchived  bool
+	InactiveMonths int
+	MaxMessages    int
+}
+
+// ArchivedChannel is a channel to archive after the import
+type ArchivedChannel struct {
+	Channel      string `json:"channel"`
+	LastActivity string `json:"last_activity,omitempty"`
+	Messages     int    `json:"messages"`
+	Reason       string `json:"reason"`
+}
+
+// reason returns why the channel is archived, and false when it is not
+func (p *ChannelArchivePolicy) reason(t *Transformer, slackExport *SlackExport, channel *IntermediateChannel, exportLastDay time.Time) (string, bool, error) {
+	if p.SlackArchived && channel.archivedInSlack {
+		return "archived in Slack", true, nil
+	}
+	if p.InactiveMonths <= 0 {
+		return "", false, nil
+	}
+	_, last, ok := activityRange(slackExport.PostFiles[channel.OriginalName])
+	if ok && !last.Before(exportLastDay.AddDate(0, -p.InactiveMonths, 0)) {
+		return "", false, nil
+	}
+	if p.MaxMessages > 0 {
+		count, err := t.channelPostCount(slackExport, channel.OriginalName)
+		if err != nil {
+			return "", false, err
+		}
+		if count > p.MaxMessages {
+			return "", false, nil
+		}
+	}
+	return "inactive", true, nil
+}
+
+// ArchiveChannels marks the public and private channels matching the
+// policy as archived. The import can't archive them, they are listed
+// by ArchivedChannels to archive once the import finished.
+func (t *Transformer) ArchiveChannels(slackExport *SlackExport, policy *ChannelArchivePolicy) error {
+	var exportLastDay time.Time
+	for _, postFiles := range slackExport.PostFiles {
+		if _, last, ok := activityRange(postFiles); ok && last.After(exportLastDay) {
+			exportLastDay = last
+		}
+	}
+
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+		for _, channel := range channels {
+			reason, ok, err := policy.reason(t, slackExport, channel, exportLastDay)
+			if err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			messages, err := t.channelPostCount(slackExport, channel.OriginalName)
+			if err != nil {
+				return err
+			}
+			channel.Archived = true
+			archived := ArchivedChannel{
+				Channel:  channel.Name,
+				Messages: messages,
+				Reason:   reason,
+			}
+			if _, last, ok := activityRange(slackExport.PostFiles[channel.OriginalName]); ok {
+				archived.LastActivity = last.Format(slackExportDayLayout)
+			}
+			t.archivedChannels = append(t.archivedChannels, archived)
+		}
+	}
+	sort.Slice(t.archivedChannels, func(i, j int) bool {
+		return t.archivedChannels[i].Channel < t.archivedChannels[j].Channel
+	})
+
+	if len(t.archivedChannels) > 0 {
+		t.Logger.Infof("%d channels are to be archived after the import", len(t.archivedChannels))
+	}
+	return nil
+}
+
+// ArchivedChannels returns the channels to archive after the import
+func (t *Transformer) ArchivedChannels() []ArchivedChannel {
+	return t.archivedChannels
+}
+
+func WriteArchivedChannelReport(reportPath string, channels []ArchivedChannel) error {
+	b, err := json.MarshalIndent(channels, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the archived channel report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the archived channel report %s", reportPath)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func archiveChannelsExportFS() fstest.MapFS {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "old-project", "members": ["U1", "U2"]},
+		{"id": "C3", "name": "old-busy", "members": ["U1", "U2"]},
+		{"id": "C4", "name": "closed", "members": ["U1", "U2"], "is_archived": true}
+	]`)}
+	fsys["general/2021-06-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "recent", "ts": "1622505600.000100"}
+	]`)}
+	fsys["old-project/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "old", "ts": "1577923200.000100"}
+	]`)}
+	fsys["old-busy/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "one", "ts": "1577923200.000100"},
+		{"type": "message", "user": "U1", "text": "two", "ts": "1577923201.000100"}
+	]`)}
+	return fsys
+}
+
+func TestTransformFSArchiveChannels(t *testing.T) {
+	fsys := archiveChannelsExportFS()
+
+	transform := func(policy *ChannelArchivePolicy) *Result {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, ChannelArchivePolicy: policy},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := transform(&ChannelArchivePolicy{SlackArchived: true, InactiveMonths: 6, MaxMessages: 1})
+	assert.Equal(t, []ArchivedChannel{
+		{Channel: "closed", Reason: "archived in Slack"},
+		{Channel: "old-project", LastActivity: "2020-01-02", Messages: 1, Reason: "inactive"},
+	}, result.ArchivedChannels())
+	for _, channel := range result.Intermediate.PublicChannels {
+		assert.Equal(t, channel.Name == "closed" || channel.Name == "old-project", channel.Archived, channel.Name)
+	}
+
+	result = transform(&ChannelArchivePolicy{InactiveMonths: 6})
+	names := []string{}
+	for _, archived := range result.ArchivedChannels() {
+		names = append(names, archived.Channel)
+	}
+	assert.Equal(t, []string{"closed", "old-busy", "old-project"}, names)
+}
+
+func TestStreamFSArchiveChannelsReads(t *testing.T) {
+	policy := &ChannelArchivePolicy{InactiveMonths: 6, MaxMessages: 1}
+	stream := func(fsys fs.FS) (*Result, error) {
+		return StreamFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, ChannelArchivePolicy: policy},
+		}, NewJSONLExporter(&bytes.Buffer{}))
+	}
+
+	t.Run("the posts are counted once", func(t *testing.T) {
+		fsys := &flakyFS{FS: archiveChannelsExportFS(), path: "old-project/2020-01-02.json"}
+		result, err := stream(fsys)
+		require.NoError(t, err)
+		require.Len(t, result.ArchivedChannels(), 2)
+		assert.Equal(t, "old-project", result.ArchivedChannels()[1].Channel)
+		assert.Equal(t, 1, result.ArchivedChannels()[1].Messages)
+		// once by the policy and once to stream the posts
+		assert.Equal(t, 2, fsys.opens)
+	})
+
+	t.Run("corrupt file", func(t *testing.T) {
+		fsys := archiveChannelsExportFS()
+		fsys["old-project/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+			{"type": "message", "user": "U1", "text": "old", "ts": "1577923200.000100"},
+			{"type": "message", "user": "U1", "te`)}
+		result, err := stream(fsys)
+		require.NoError(t, err)
+		require.Len(t, result.ArchivedChannels(), 2)
+		assert.Equal(t, "old-project", result.ArchivedChannels()[1].Channel)
+		assert.Equal(t, 1, result.ArchivedChannels()[1].Messages)
+		assert.Equal(t, 1, result.TransformResult.Count(WarningCorruptFile))
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		fsys := &flakyFS{FS: archiveChannelsExportFS(), path: "old-project/2020-01-02.json", failures: 1}
+		_, err := stream(fsys)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file locked")
+	})
+}
@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"path"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+)
+
+const (
+	// SharedOutputName is the file of the per channel outputs holding
+	// the lines preceding the posts, such as the team, the channels and
+	// the users, to import before the files of the channels
+	SharedOutputName = "users.jsonl"
+	// DirectOutputName is the file of the per channel outputs holding
+	// the posts of the direct and group messages
+	DirectOutputName = "direct.jsonl"
+	// channelOutputsDir is the directory of the files of the channels
+	channelOutputsDir = "channels"
+)
+
+// ChannelOutputPath returns the path of the file holding the posts of
+// a channel, relative to the directory of the per channel outputs
+func ChannelOutputPath(channelName string) string {
+	return path.Join(channelOutputsDir, channelName+".jsonl")
+}
+
+// ChannelExporter writes the posts of each channel to a file of its
+// own and every other line to a shared file, so the import of a
+// channel that failed can be retried without replaying the others.
+// Every file starts with the version line. The shared file must be
+// imported first, then the files of the channels in any order.
+type ChannelExporter struct {
+	// open creates the exporter of the file with the given path,
+	// relative to the directory of the outputs
+	open func(name string) (Exporter, error)
+
+	outputs     map[string]Exporter
+	versionLine *app.LineImportData
+}
+
+func NewChannelExporter(open func(name string) (Exporter, error)) *ChannelExporter {
+	return &ChannelExporter{
+		open:    open,
+		outputs: map[string]Exporter{},
+	}
+}
+
+// outputName returns the file a line belongs to
+func outputName(line *app.LineImportData) string {
+	switch {
+	case line.Post != nil && line.Post.Channel != nil:
+		return ChannelOutputPath(*line.Post.Channel)
+	case line.DirectPost != nil:
+		return DirectOutputName
+	default:
+		return SharedOutputName
+	}
+}
+
+func (e *ChannelExporter) output(name string) (Exporter, error) {
+	if output, ok := e.outputs[name]; ok {
+		return output, nil
+	}
+	output, err := e.open(name)
+	if err != nil {
+		return nil, err
+	}
+	e.outputs[name] = output
+	if e.versionLine != nil {
+		if err := output.WriteLine(e.versionLine); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+func (e *ChannelExporter) WriteLine(line *app.LineImportData) error {
+	// the version line is written by opening the shared file
+	if line.Type == "version" {
+		e.versionLine = line
+		_, err := e.output(SharedOutputName)
+		return err
+	}
+	output, err := e.output(outputName(line))
+	if err != nil {
+		return err
+	}
+	return output.WriteLine(line)
+}
+
+func (e *ChannelExporter) Close() error {
+	var firstErr error
+	for _, name := range e.Outputs() {
+		if err := e.outputs[name].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Outputs returns the paths of the files written so far, relative to
+// the directory of the outputs
+func (e *ChannelExporter) Outputs() []string {
+	names := make([]string, 0, len(e.outputs))
+	for name := range e.outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
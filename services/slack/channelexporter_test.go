@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelExporter(t *testing.T) {
+	outputs := map[string]*recordingExporter{}
+	exporter := NewChannelExporter(func(name string) (Exporter, error) {
+		require.NotContains(t, outputs, name)
+		outputs[name] = &recordingExporter{}
+		return outputs[name], nil
+	})
+
+	version := 1
+	post := func(channel string) *app.LineImportData {
+		return &app.LineImportData{Type: "post", Post: &app.PostImportData{
+			Team:    model.NewString("team"),
+			Channel: model.NewString(channel),
+			User:    model.NewString("john"),
+			Message: model.NewString("hello"),
+		}}
+	}
+	lines := []*app.LineImportData{
+		{Type: "version", Version: &version},
+		{Type: "channel", Channel: &app.ChannelImportData{Name: model.NewString("general")}},
+		{Type: "user", User: &app.UserImportData{Username: model.NewString("john")}},
+		{Type: "direct_channel", DirectChannel: &app.DirectChannelImportData{}},
+		post("general"),
+		post("random"),
+		post("general"),
+		{Type: "direct_post", DirectPost: &app.DirectPostImportData{}},
+	}
+	for _, line := range lines {
+		require.NoError(t, exporter.WriteLine(line))
+	}
+	require.NoError(t, exporter.Close())
+
+	assert.Equal(t, []string{"channels/general.jsonl", "channels/random.jsonl", DirectOutputName, SharedOutputName}, exporter.Outputs())
+	assert.Equal(t, lines[:4], outputs[SharedOutputName].lines)
+	assert.Equal(t, []*app.LineImportData{lines[0], lines[4], lines[6]}, outputs["channels/general.jsonl"].lines)
+	assert.Equal(t, []*app.LineImportData{lines[0], lines[5]}, outputs["channels/random.jsonl"].lines)
+	assert.Equal(t, []*app.LineImportData{lines[0], lines[7]}, outputs[DirectOutputName].lines)
+}
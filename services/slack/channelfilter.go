@@ -0,0 +1,159 @@
+package slack
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// ChannelFilter selects the channels of the export to transform by
+// their Slack name or id. The channels left out are neither created
+// nor part of the memberships, and their posts and attachments are not
+// read.
+type ChannelFilter struct {
+	// Only keeps the channels matching one of the globs when set
+	Only []string
+	// Exclude leaves out the channels matching one of the globs
+	Exclude []string
+	// DirectOnly leaves out the public and private channels, keeping
+	// the direct and group conversations and, see
+	// FilterDirectChannelUsers, their members only
+	DirectOnly bool
+}
+
+// ParseChannelPatterns reads the globs of a channel filter. The values
+// starting with @ are files of globs, one per line, ignoring empty
+// lines and lines starting with #.
+func ParseChannelPatterns(values []string) ([]string, error) {
+	patterns := []string{}
+	for _, value := range values {
+		if !strings.HasPrefix(value, "@") {
+			patterns = append(patterns, value)
+			continue
+		}
+		filePatterns, err := readChannelPatterns(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid channel pattern %q", pattern)
+		}
+	}
+	return patterns, nil
+}
+
+func readChannelPatterns(patternsPath string) ([]string, error) {
+	file, err := os.Open(patternsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the channel list")
+	}
+	defer file.Close()
+
+	patterns := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the channel list %s", patternsPath)
+	}
+	return patterns, nil
+}
+
+func matchChannelPatterns(patterns []string, names ...string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched && name != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keeps tells whether the channel with the given names is transformed
+func (f *ChannelFilter) keeps(names ...string) bool {
+	if len(f.Only) > 0 && !matchChannelPatterns(f.Only, names...) {
+		return false
+	}
+	return !matchChannelPatterns(f.Exclude, names...)
+}
+
+// FilterChannels removes the channels left out by the filter from the
+// export, along with their posts
+func (t *Transformer) FilterChannels(slackExport *SlackExport, filter *ChannelFilter) {
+	removed := map[string]bool{}
+	filterChannels := func(channels []SlackChannel) []SlackChannel {
+		kept := []SlackChannel{}
+		for _, channel := range channels {
+			isChannel := channel.Type == model.ChannelTypeOpen || channel.Type == model.ChannelTypePrivate
+			if !(filter.DirectOnly && isChannel) && filter.keeps(channel.Name, channel.Id) {
+				kept = append(kept, channel)
+			} else {
+				removed[getOriginalName(channel)] = true
+			}
+		}
+		return kept
+	}
+	total := len(slackExport.Channels)
+	slackExport.Channels = filterChannels(slackExport.Channels)
+	slackExport.PublicChannels = filterChannels(slackExport.PublicChannels)
+	slackExport.PrivateChannels = filterChannels(slackExport.PrivateChannels)
+	slackExport.GroupChannels = filterChannels(slackExport.GroupChannels)
+	slackExport.DirectChannels = filterChannels(slackExport.DirectChannels)
+
+	known := map[string]bool{}
+	for _, channel := range slackExport.Channels {
+		known[getOriginalName(channel)] = true
+	}
+	// the posts of the channels missing from the channel lists are
+	// filtered by the name of their directory
+	keepsUnknown := func(channelName string) bool {
+		return !filter.DirectOnly && filter.keeps(channelName)
+	}
+	for channelName := range slackExport.PostFiles {
+		if removed[channelName] || (!known[channelName] && !keepsUnknown(channelName)) {
+			delete(slackExport.PostFiles, channelName)
+			delete(slackExport.Posts, channelName)
+		}
+	}
+	for channelName := range slackExport.Posts {
+		if removed[channelName] || (!known[channelName] && !keepsUnknown(channelName)) {
+			delete(slackExport.Posts, channelName)
+		}
+	}
+	t.Logger.Infof("The channel filter selected %d of the %d channels", len(slackExport.Channels), total)
+}
+
+// FilterDirectChannelUsers removes the users that are members of none
+// of the direct and group channels of the export, once the filter left
+// out the other channels.
+func (t *Transformer) FilterDirectChannelUsers(slackExport *SlackExport) {
+	members := map[string]bool{}
+	for _, channels := range [][]SlackChannel{slackExport.GroupChannels, slackExport.DirectChannels} {
+		for _, channel := range channels {
+			for _, member := range channel.Members {
+				members[member] = true
+			}
+		}
+	}
+
+	total := len(t.Intermediate.UsersById)
+	for id := range t.Intermediate.UsersById {
+		if !members[id] {
+			delete(t.Intermediate.UsersById, id)
+		}
+	}
+	t.Logger.Infof("Kept the %d of the %d users that are members of direct and group channels", len(t.Intermediate.UsersById), total)
+}
@@ -0,0 +1,117 @@
+package slack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChannelPatterns(t *testing.T) {
+	listPath := filepath.Join(t.TempDir(), "channels.txt")
+	require.NoError(t, os.WriteFile(listPath, []byte("# engineering\neng-*\n\nD1\n"), 0600))
+
+	patterns, err := ParseChannelPatterns([]string{"general", "@" + listPath})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"general", "eng-*", "D1"}, patterns)
+
+	_, err = ParseChannelPatterns([]string{"[eng"})
+	require.Error(t, err)
+	_, err = ParseChannelPatterns([]string{"@" + filepath.Join(t.TempDir(), "missing.txt")})
+	require.Error(t, err)
+}
+
+func TestTransformFSChannelFilter(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "eng-backend", "members": ["U1", "U2"]},
+		{"id": "C3", "name": "eng-secret", "members": ["U1"]}
+	]`)}
+	fsys["eng-backend/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "backend", "ts": "1577923200.000100"}
+	]`)}
+	fsys["eng-secret/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "secret", "ts": "1577923201.000100"}
+	]`)}
+	fsys["random/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "unknown channel", "ts": "1577923202.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			SkipAttachments: true,
+			ChannelFilter:   &ChannelFilter{Only: []string{"eng-*"}, Exclude: []string{"C3"}},
+		},
+	})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, channel := range result.Intermediate.PublicChannels {
+		names = append(names, channel.Name)
+	}
+	assert.Equal(t, []string{"eng-backend"}, names)
+	assert.Equal(t, []string{"eng-backend"}, result.Intermediate.UsersById["U1"].Memberships)
+
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Equal(t, []string{"backend"}, messages)
+	assert.Zero(t, result.TransformResult.Count(WarningUnknownChannel))
+	assert.NotContains(t, result.SlackExport.PostFiles, "general")
+}
+
+func TestTransformFSDirectOnly(t *testing.T) {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com"}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com"}},
+		{"id": "U3", "name": "jim", "profile": {"email": "jim@example.com"}}
+	]`)}
+	fsys["groups.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "G1", "name": "secret", "members": ["U1", "U3"]}
+	]`)}
+	fsys["secret/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U3", "text": "secret", "ts": "1577923200.000100"}
+	]`)}
+	fsys["dms.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "D1", "members": ["U1", "U2"]}
+	]`)}
+	fsys["D1/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U2", "text": "direct", "ts": "1577923201.000100"}
+	]`)}
+	fsys["random/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "unknown channel", "ts": "1577923202.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			SkipAttachments: true,
+			ChannelFilter:   &ChannelFilter{DirectOnly: true},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Intermediate.PublicChannels)
+	assert.Empty(t, result.Intermediate.PrivateChannels)
+	require.Len(t, result.Intermediate.DirectChannels, 1)
+	userIds := []string{}
+	for id := range result.Intermediate.UsersById {
+		userIds = append(userIds, id)
+	}
+	assert.ElementsMatch(t, []string{"U1", "U2"}, userIds)
+
+	require.Len(t, result.Intermediate.Posts, 1)
+	assert.Equal(t, "direct", result.Intermediate.Posts[0].Message)
+	assert.True(t, result.Intermediate.Posts[0].IsDirect)
+}
@@ -0,0 +1,73 @@
+package slack
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// ChannelHeaderSource decides what the header of the channels is made
+// of
+type ChannelHeaderSource string
+
+const (
+	// ChannelHeaderTopic uses the topic of the Slack channel
+	ChannelHeaderTopic ChannelHeaderSource = "topic"
+	// ChannelHeaderPurpose uses the purpose of the Slack channel
+	ChannelHeaderPurpose ChannelHeaderSource = "purpose"
+	// ChannelHeaderBoth joins the topic and the purpose, skipping the
+	// empty or repeated one
+	ChannelHeaderBoth ChannelHeaderSource = "both"
+)
+
+// channelHeaderSeparator joins the topic and the purpose in a header
+const channelHeaderSeparator = " | "
+
+func ParseChannelHeaderSource(source string) (ChannelHeaderSource, error) {
+	switch ChannelHeaderSource(source) {
+	case "", ChannelHeaderTopic:
+		return ChannelHeaderTopic, nil
+	case ChannelHeaderPurpose, ChannelHeaderBoth:
+		return ChannelHeaderSource(source), nil
+	}
+	return "", errors.Errorf("unknown channel header source %q, expected %q, %q or %q", source, ChannelHeaderTopic, ChannelHeaderPurpose, ChannelHeaderBoth)
+}
+
+// channelHeader returns the header of a channel with the topic and
+// purpose of the Slack channel
+func (s ChannelHeaderSource) channelHeader(topic, purpose string) string {
+	switch s {
+	case ChannelHeaderPurpose:
+		return purpose
+	case ChannelHeaderBoth:
+		topic, purpose = strings.TrimSpace(topic), strings.TrimSpace(purpose)
+		if topic == "" || topic == purpose {
+			return purpose
+		}
+		if purpose == "" {
+			return topic
+		}
+		return topic + channelHeaderSeparator + purpose
+	}
+	return topic
+}
+
+// truncateAtWord truncates a text to max runes at the last word
+// boundary, marking the cut with an ellipsis. A text without a
+// boundary in the second half of the limit is cut at max runes, see
+// truncateRunes.
+func truncateAtWord(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	// the ellipsis takes a rune
+	runes := []rune(s)[:max-1]
+	for i := len(runes) - 1; i > max/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return strings.TrimRightFunc(string(runes[:i]), unicode.IsSpace) + "…"
+		}
+	}
+	return truncateRunes(s, max)
+}
@@ -0,0 +1,38 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelHeader(t *testing.T) {
+	testCases := []struct {
+		source   ChannelHeaderSource
+		topic    string
+		purpose  string
+		expected string
+	}{
+		{ChannelHeaderTopic, "the topic", "the purpose", "the topic"},
+		{ChannelHeaderPurpose, "the topic", "the purpose", "the purpose"},
+		{ChannelHeaderBoth, "the topic", "the purpose", "the topic | the purpose"},
+		{ChannelHeaderBoth, "", "the purpose", "the purpose"},
+		{ChannelHeaderBoth, "the topic ", "", "the topic"},
+		{ChannelHeaderBoth, "the same", "the same", "the same"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.source.channelHeader(tc.topic, tc.purpose), tc.source)
+	}
+
+	_, err := ParseChannelHeaderSource("name")
+	require.Error(t, err)
+}
+
+func TestTruncateAtWord(t *testing.T) {
+	assert.Equal(t, "short", truncateAtWord("short", 10))
+	assert.Equal(t, "the quick…", truncateAtWord("the quick brown fox", 15))
+	// no boundary in the second half of the limit
+	assert.Equal(t, "a aaaaaaaa", truncateAtWord("a "+strings.Repeat("a", 20), 10))
+}
@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// reservedChannelNames are the default channels every team already has,
+// which the import would merge the Slack channels into, and the names
+// reserved by the routes of the server
+var reservedChannelNames = map[string]bool{
+	model.DefaultChannelName: true,
+	"off-topic":              true,
+	"admin":                  true,
+	"api":                    true,
+	"channel":                true,
+	"claim":                  true,
+	"error":                  true,
+	"files":                  true,
+	"help":                   true,
+	"landing":                true,
+	"login":                  true,
+	"mfa":                    true,
+	"oauth":                  true,
+	"plug":                   true,
+	"plugins":                true,
+	"post":                   true,
+	"signup":                 true,
+	"boards":                 true,
+	"playbooks":              true,
+}
+
+// reservedChannelSuffix is appended to the reserved names
+const reservedChannelSuffix = "-slack"
+
+// ChannelNameChange records a channel whose name was changed to avoid
+// a name reserved in Mattermost
+type ChannelNameChange struct {
+	ChannelId string `json:"channel_id"`
+	Original  string `json:"original"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+// mattermostChannelName returns the name of a channel in Mattermost,
+// prefixed with the prefix of the workspace and suffixed when reserved.
+// The mentions and links of the channels use it as well, so they keep
+// pointing to the renamed channels.
+func mattermostChannelName(prefix, name string, channelType model.ChannelType) string {
+	name = prefixedChannelName(prefix, name, channelType)
+	if (channelType == model.ChannelTypeOpen || channelType == model.ChannelTypePrivate) && reservedChannelNames[name] {
+		return name + reservedChannelSuffix
+	}
+	return name
+}
+
+// channelName returns the name of a channel in Mattermost, recording
+// the change when its name is reserved
+func (t *Transformer) channelName(channelId, name string, channelType model.ChannelType) string {
+	prefixed := prefixedChannelName(t.ChannelPrefix, name, channelType)
+	renamed := mattermostChannelName(t.ChannelPrefix, name, channelType)
+	if renamed != prefixed {
+		t.Logger.Warnf("Slack channel %s renamed to %s: reserved", prefixed, renamed)
+		t.channelNameChanges = append(t.channelNameChanges, ChannelNameChange{
+			ChannelId: channelId,
+			Original:  prefixed,
+			Name:      renamed,
+			Reason:    "reserved",
+		})
+	}
+	return renamed
+}
+
+// ChannelNameChanges returns the channels renamed to avoid the reserved
+// names
+func (t *Transformer) ChannelNameChanges() []ChannelNameChange {
+	return t.channelNameChanges
+}
+
+func WriteChannelNameReport(reportPath string, changes []ChannelNameChange) error {
+	b, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the channel name report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the channel name report %s", reportPath)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMattermostChannelName(t *testing.T) {
+	assert.Equal(t, "general", mattermostChannelName("", "general", model.ChannelTypeOpen))
+	assert.Equal(t, "town-square-slack", mattermostChannelName("", "town-square", model.ChannelTypeOpen))
+	assert.Equal(t, "off-topic-slack", mattermostChannelName("", "off-topic", model.ChannelTypePrivate))
+	assert.Equal(t, "api-slack", mattermostChannelName("", "api", model.ChannelTypeOpen))
+	// the prefixed names are not reserved
+	assert.Equal(t, "acme-town-square", mattermostChannelName("acme", "town-square", model.ChannelTypeOpen))
+	// the direct and group channels are named after their members
+	assert.Equal(t, "help", mattermostChannelName("", "help", model.ChannelTypeGroup))
+}
+
+func TestTransformFSRenamesReservedChannels(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "off-topic", "members": ["U1", "U2"]}
+	]`)}
+	fsys["off-topic/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "see <#C2|off-topic>", "ts": "1577836802.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, channel := range result.Intermediate.PublicChannels {
+		names = append(names, channel.Name)
+	}
+	assert.ElementsMatch(t, []string{"general", "off-topic-slack"}, names)
+	assert.Contains(t, result.Intermediate.UsersById["U1"].Memberships, "off-topic-slack")
+
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Contains(t, messages, "see ~off-topic-slack")
+	assert.Equal(t, []ChannelNameChange{{ChannelId: "C2", Original: "off-topic", Name: "off-topic-slack", Reason: "reserved"}}, result.ChannelNameChanges())
+}
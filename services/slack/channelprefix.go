@@ -0,0 +1,26 @@
+package slack
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// ValidateChannelPrefix checks that a prefix can start the name of a
+// channel
+func ValidateChannelPrefix(prefix string) error {
+	if prefix != strings.ToLower(prefix) || !model.IsValidChannelIdentifier(prefix) {
+		return errors.Errorf("invalid channel prefix %q, expected lowercase letters, digits, - and _", prefix)
+	}
+	return nil
+}
+
+// prefixedChannelName returns the name of a channel in the namespace
+// of the workspace, leaving the direct and group channels as is
+func prefixedChannelName(prefix, name string, channelType model.ChannelType) string {
+	if prefix == "" || (channelType != model.ChannelTypeOpen && channelType != model.ChannelTypePrivate) {
+		return name
+	}
+	return prefix + "-" + name
+}
@@ -0,0 +1,54 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChannelPrefix(t *testing.T) {
+	assert.NoError(t, ValidateChannelPrefix("acme"))
+	assert.NoError(t, ValidateChannelPrefix("acme_2"))
+	assert.Error(t, ValidateChannelPrefix("Acme"))
+	assert.Error(t, ValidateChannelPrefix("acme corp"))
+}
+
+func TestChannelPrefix(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "see <#C1>", "ts": "1577923200.000100"}
+	]`)}
+	fsys["dms.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "D1", "name": "D1", "members": ["U1", "U2"]}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		ChannelPrefix:   "acme",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Intermediate.PublicChannels, 1)
+	channel := result.Intermediate.PublicChannels[0]
+	assert.Equal(t, "acme-general", channel.Name)
+	assert.Equal(t, "acme-general", channel.DisplayName)
+	for _, user := range result.Intermediate.UsersById {
+		assert.Equal(t, []string{"acme-general"}, user.Memberships)
+	}
+
+	require.Len(t, result.Intermediate.DirectChannels, 1)
+	assert.NotContains(t, result.Intermediate.DirectChannels[0].Name, "acme")
+
+	messages := map[string]string{}
+	for _, post := range result.Intermediate.Posts {
+		messages[post.Message] = post.Channel
+	}
+	require.Contains(t, messages, "see ~acme-general")
+	assert.Equal(t, "acme-general", messages["see ~acme-general"])
+}
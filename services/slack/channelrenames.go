@@ -0,0 +1,86 @@
+package slack
+
+import "sort"
+
+// channelRenames reads the previous names of the channels from their
+// channel_name messages. Slack files the day files of a channel under
+// its last name, so every old name maps to the channel the message is
+// in.
+func (t *Transformer) channelRenames(slackExport *SlackExport) (map[string]string, error) {
+	renames := map[string]string{}
+	for channelName := range slackExport.PostFiles {
+		posts, err := t.peekChannelPosts(slackExport, channelName)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			if post.IsChannelNameMessage() && post.OldName != "" && post.OldName != channelName {
+				renames[post.OldName] = channelName
+			}
+		}
+	}
+	return renames, nil
+}
+
+// resolveChannelRename follows the chain of renames of a channel name
+// to its last name, the one of the channel in the export.
+func resolveChannelRename(renames map[string]string, name string) string {
+	seen := map[string]bool{name: true}
+	for {
+		next, ok := renames[name]
+		if !ok || seen[next] {
+			return name
+		}
+		seen[next] = true
+		name = next
+	}
+}
+
+// mergeRenamedChannels finds the channel renames of the export and
+// moves the posts filed under an old name of a channel, as in exports
+// stitched together from exports made before and after the rename, to
+// the channel. The renames are only looked for when some posts are
+// filed under a name matching no channel.
+func (t *Transformer) mergeRenamedChannels(slackExport *SlackExport) error {
+	known := map[string]bool{}
+	for _, channel := range slackExport.Channels {
+		known[getOriginalName(channel)] = true
+	}
+	unknown := []string{}
+	for channelName := range slackExport.PostFiles {
+		if !known[channelName] {
+			unknown = append(unknown, channelName)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+
+	renames, err := t.channelRenames(slackExport)
+	if err != nil {
+		return err
+	}
+	slackExport.ChannelRenames = renames
+	for _, oldName := range unknown {
+		channelName := resolveChannelRename(slackExport.ChannelRenames, oldName)
+		if channelName == oldName || !known[channelName] {
+			continue
+		}
+
+		slackExport.PostFiles[channelName] = append(slackExport.PostFiles[channelName], slackExport.PostFiles[oldName]...)
+		delete(slackExport.PostFiles, oldName)
+		delete(slackExport.postCounts, channelName)
+		delete(slackExport.postCounts, oldName)
+		if posts, ok := slackExport.Posts[oldName]; ok {
+			slackExport.Posts[channelName] = deduplicatePosts(append(slackExport.Posts[channelName], posts...))
+			delete(slackExport.Posts, oldName)
+		}
+		if slackExport.mergedChannels == nil {
+			slackExport.mergedChannels = map[string]bool{}
+		}
+		slackExport.mergedChannels[channelName] = true
+		t.Logger.Infof("Merged the posts of the channel %s into %s, its name since it was renamed", oldName, channelName)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveChannelRename(t *testing.T) {
+	renames := map[string]string{"first": "second", "second": "third", "loop-a": "loop-b", "loop-b": "loop-a"}
+	assert.Equal(t, "third", resolveChannelRename(renames, "first"))
+	assert.Equal(t, "third", resolveChannelRename(renames, "third"))
+	assert.Equal(t, "unknown", resolveChannelRename(renames, "unknown"))
+	assert.Equal(t, "loop-b", resolveChannelRename(renames, "loop-a"))
+}
+
+// renamedChannelExportFS is an export stitched from an export made
+// before general was renamed from lobby, itself renamed from hall
+func renamedChannelExportFS() fstest.MapFS {
+	fsys := testExportFS()
+	fsys["hall/2019-12-30.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "in the hall", "ts": "1577664000.000100", "thread_ts": "1577664000.000100"}
+	]`)}
+	fsys["lobby/2019-12-31.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "channel_name", "user": "U1", "old_name": "hall", "name": "lobby", "text": "renamed", "ts": "1577750400.000100"},
+		{"type": "message", "user": "U1", "text": "in the lobby", "ts": "1577750401.000100"}
+	]`)}
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "channel_name", "user": "U1", "old_name": "lobby", "name": "general", "text": "renamed again", "ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "late reply", "ts": "1577923201.000100", "thread_ts": "1577664000.000100"}
+	]`)}
+	return fsys
+}
+
+func TestTransformFSRenamedChannel(t *testing.T) {
+	result, err := TransformFS(context.Background(), renamedChannelExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"hall": "lobby", "lobby": "general"}, result.SlackExport.ChannelRenames)
+	assert.Equal(t, 0, result.TransformResult.Count(WarningUnknownChannel))
+
+	replies := map[string][]string{}
+	for _, post := range result.Intermediate.Posts {
+		assert.Equal(t, "general", post.Channel)
+		for _, reply := range post.Replies {
+			replies[post.Message] = append(replies[post.Message], reply.Message)
+		}
+	}
+	assert.Equal(t, []string{"late reply"}, replies["in the hall"])
+}
+
+func TestStreamFSRenamedChannel(t *testing.T) {
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), renamedChannelExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, result.TransformResult.Count(WarningUnknownChannel))
+	assert.Contains(t, buffer.String(), "in the hall")
+	assert.Contains(t, buffer.String(), "in the lobby")
+	assert.Equal(t, 1, strings.Count(buffer.String(), "late reply"))
+}
+
+func TestBuildChannelsByOriginalNameMapRenames(t *testing.T) {
+	general := &IntermediateChannel{OriginalName: "general"}
+	lobby := &IntermediateChannel{OriginalName: "lobby"}
+	intermediate := &Intermediate{PublicChannels: []*IntermediateChannel{general, lobby}}
+
+	// the lobby channel created after the rename keeps its name
+	channels := buildChannelsByOriginalNameMap(intermediate, map[string]string{"hall": "lobby", "lobby": "general"})
+	assert.Same(t, general, channels["general"])
+	assert.Same(t, lobby, channels["lobby"])
+	assert.Same(t, general, channels["hall"])
+}
@@ -0,0 +1,97 @@
+package slack
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInterrupted is returned when the context is cancelled while the
+// posts are being transformed. The intermediate keeps the posts of the
+// channels completed before the interruption.
+var ErrInterrupted = errors.New("transformation interrupted")
+
+// Checkpoint records the progress of an interrupted transformation so
+// it can be resumed later.
+type Checkpoint struct {
+	CompletedChannels []string `json:"completed_channels"`
+	// OutputSizes are the sizes of the outputs by path when the
+	// checkpoint was saved. The lines a crashed run wrote after it are
+	// truncated when resuming.
+	OutputSizes map[string]int64 `json:"output_sizes,omitempty"`
+	// ContinuationLines and ColdLines are the lines written to the
+	// ContinuationExporter and the ColdExporter, so a resumed run
+	// doesn't write their version line again
+	ContinuationLines int `json:"continuation_lines,omitempty"`
+	ColdLines         int `json:"cold_lines,omitempty"`
+}
+
+// CheckpointStore keeps the checkpoint of a transformation while it
+// runs, so it can be resumed after an interruption or a crash.
+type CheckpointStore interface {
+	Load() (*Checkpoint, error)
+	Save(checkpoint *Checkpoint) error
+	// Remove deletes the checkpoint once the transformation completed,
+	// it succeeds when there is none
+	Remove() error
+}
+
+// FileCheckpointStore keeps the checkpoint in a file.
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (s *FileCheckpointStore) Load() (*Checkpoint, error) {
+	return ReadCheckpoint(s.Path)
+}
+
+// Save replaces the checkpoint through a temporary file, so a crash
+// never leaves a partial checkpoint
+func (s *FileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	tmpPath := s.Path + ".tmp"
+	if err := WriteCheckpoint(tmpPath, checkpoint); err != nil {
+		return err
+	}
+	return errors.Wrap(os.Rename(tmpPath, s.Path), "failed to replace the checkpoint file")
+}
+
+func (s *FileCheckpointStore) Remove() error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove the checkpoint file")
+	}
+	return nil
+}
+
+func (t *Transformer) Checkpoint() *Checkpoint {
+	return &Checkpoint{
+		CompletedChannels: append([]string{}, t.completedChannels...),
+		ContinuationLines: t.continuationLines,
+		ColdLines:         t.coldLines,
+	}
+}
+
+func WriteCheckpoint(checkpointPath string, checkpoint *Checkpoint) error {
+	b, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the checkpoint")
+	}
+
+	if err := os.WriteFile(checkpointPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the checkpoint file %s", checkpointPath)
+	}
+	return nil
+}
+
+func ReadCheckpoint(checkpointPath string) (*Checkpoint, error) {
+	b, err := os.ReadFile(checkpointPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the checkpoint file %s", checkpointPath)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the checkpoint file %s", checkpointPath)
+	}
+	return &checkpoint, nil
+}
@@ -0,0 +1,58 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// RedisCheckpointStore keeps the checkpoint in redis under a key, e.g.
+// when the transformations run on machines without a shared disk.
+type RedisCheckpointStore struct {
+	client *redis.Client
+	key    string
+}
+
+func NewRedisCheckpointStore(cfg *RedisConfig, key string) (*RedisCheckpointStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: cfg.Addr, Username: cfg.User, Password: cfg.Password})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("ping redis failure: %w", err)
+	}
+	return &RedisCheckpointStore{client: client, key: key}, nil
+}
+
+func (s *RedisCheckpointStore) Load() (*Checkpoint, error) {
+	b, err := s.client.Get(context.Background(), s.key).Bytes()
+	if err == redis.Nil {
+		return nil, errors.Errorf("no checkpoint in redis at %s", s.key)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the checkpoint from redis at %s", s.key)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the checkpoint from redis at %s", s.key)
+	}
+	return &checkpoint, nil
+}
+
+func (s *RedisCheckpointStore) Save(checkpoint *Checkpoint) error {
+	b, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the checkpoint")
+	}
+	if err := s.client.Set(context.Background(), s.key, b, 0).Err(); err != nil {
+		return errors.Wrapf(err, "failed to write the checkpoint to redis at %s", s.key)
+	}
+	return nil
+}
+
+func (s *RedisCheckpointStore) Remove() error {
+	if err := s.client.Del(context.Background(), s.key).Err(); err != nil {
+		return errors.Wrapf(err, "failed to remove the checkpoint from redis at %s", s.key)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := &FileCheckpointStore{Path: filepath.Join(t.TempDir(), "output.jsonl.checkpoint")}
+	_, err := store.Load()
+	require.Error(t, err)
+
+	checkpoint := &Checkpoint{CompletedChannels: []string{"general"}, OutputSizes: map[string]int64{"output.jsonl": 42}}
+	require.NoError(t, store.Save(checkpoint))
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint, loaded)
+
+	require.NoError(t, store.Remove())
+	require.NoError(t, store.Remove())
+	_, err = store.Load()
+	require.Error(t, err)
+}
+
+func TestStreamFSSaveCheckpoint(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "other", "members": ["U1", "U2"]}
+	]`)}
+	fsys["other/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U2", "text": "see <#C1>", "ts": "1577923200.000100"}
+	]`)}
+
+	var buffer bytes.Buffer
+	var saved [][]string
+	_, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		SaveCheckpoint: func(checkpoint *Checkpoint) error {
+			saved = append(saved, checkpoint.CompletedChannels)
+			return nil
+		},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"general"}, {"general", "other"}}, saved)
+
+	// the transformation stops when the progress can't be saved
+	_, err = StreamFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		SaveCheckpoint: func(checkpoint *Checkpoint) error {
+			return errors.New("disk full")
+		},
+	}, NewJSONLExporter(&buffer))
+	require.EqualError(t, err, "disk full")
+}
+
+func TestStreamFSResumeSideOutputs(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "other", "members": ["U1", "U2"]}
+	]`)}
+	thread := []byte(`[
+		{"type": "message", "user": "U1", "text": "old", "ts": "1577836800.000100"},
+		{"type": "message", "user": "U1", "text": "root", "ts": "1578009600.000100"},
+		{"type": "message", "user": "U2", "text": "reply 1", "ts": "1578009601.000100", "thread_ts": "1578009600.000100"},
+		{"type": "message", "user": "U2", "text": "reply 2", "ts": "1578009602.000100", "thread_ts": "1578009600.000100"}
+	]`)
+	fsys["general/2020-01-01.json"] = &fstest.MapFile{Data: thread}
+	fsys["other/2020-01-01.json"] = &fstest.MapFile{Data: thread}
+
+	opts := Options{
+		TeamName:          "team",
+		Logger:            log.New(),
+		TransformConfig:   TransformConfig{SkipAttachments: true},
+		MaxRepliesPerLine: 1,
+		ColdBefore:        time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	// the first run crashes after the first channel
+	var replies, cold recordingExporter
+	var checkpoint *Checkpoint
+	firstRun := opts
+	firstRun.ContinuationExporter = &replies
+	firstRun.ColdExporter = &cold
+	firstRun.SaveCheckpoint = func(saved *Checkpoint) error {
+		if checkpoint == nil {
+			checkpoint = saved
+		}
+		return nil
+	}
+	_, err := StreamFS(context.Background(), fsys, firstRun, &recordingExporter{})
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, 1, checkpoint.ContinuationLines)
+	assert.Equal(t, 1, checkpoint.ColdLines)
+
+	// the resumed run appends to the side outputs already started, so
+	// it writes no version line
+	var resumedReplies, resumedCold recordingExporter
+	resumed := opts
+	resumed.ContinuationExporter = &resumedReplies
+	resumed.ColdExporter = &resumedCold
+	resumed.Resume = checkpoint
+	result, err := StreamFS(context.Background(), fsys, resumed, &recordingExporter{})
+	require.NoError(t, err)
+
+	require.Len(t, resumedReplies.lines, 1)
+	assert.Equal(t, "post", resumedReplies.lines[0].Type)
+	require.Len(t, resumedCold.lines, 1)
+	assert.Equal(t, "post", resumedCold.lines[0].Type)
+	assert.Equal(t, 2, result.ContinuationLines())
+	assert.Equal(t, 2, result.ColdLines())
+}
@@ -0,0 +1,87 @@
+package slack
+
+import (
+	"regexp"
+	"strings"
+)
+
+// slackCodeBlockRegex matches the ``` delimited code blocks of a
+// Slack message, which Slack allows anywhere in a line
+var slackCodeBlockRegex = regexp.MustCompile("(?s)```(.*?)```")
+
+// codeLanguageRegex matches a language hint on the first line of a
+// code block, e.g. ```go
+var codeLanguageRegex = regexp.MustCompile(`^[A-Za-z0-9_+#.-]+$`)
+
+// slackCodeEntities are the characters Slack escapes in the text of
+// messages, code included
+var slackCodeEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+// codeLanguages returns the languages of the preformatted rich text
+// elements of a post, in the order of its code blocks, empty for the
+// ones without a language
+func (p *SlackPost) codeLanguages() []string {
+	languages := []string{}
+	var walk func(elements []*SlackBlockElement)
+	walk = func(elements []*SlackBlockElement) {
+		for _, element := range elements {
+			if element.Type == "rich_text_preformatted" {
+				languages = append(languages, element.Language)
+				continue
+			}
+			walk(element.Elements)
+		}
+	}
+	for _, block := range p.Blocks {
+		if block.Type == "rich_text" {
+			walk(block.Elements)
+		}
+	}
+	return languages
+}
+
+// convertCodeBlock returns a Mattermost fenced code block for the
+// content of a Slack one. A language hint on the first line of the
+// content takes precedence over the language of the rich text.
+func convertCodeBlock(code, language string) string {
+	code = strings.TrimPrefix(code, "\n")
+	if newline := strings.IndexByte(code, '\n'); newline > 0 && codeLanguageRegex.MatchString(code[:newline]) {
+		language = code[:newline]
+		code = code[newline+1:]
+	}
+	code = strings.TrimSuffix(code, "\n")
+	return "```" + language + "\n" + slackCodeEntities.Replace(code) + "\n```"
+}
+
+// convertMarkupWithCode converts the text outside of the code blocks
+// with convert, and the code blocks into fenced code blocks on lines
+// of their own, with the given languages.
+func convertMarkupWithCode(text string, languages []string, convert func(string) string) string {
+	matches := slackCodeBlockRegex.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return convert(text)
+	}
+
+	var builder strings.Builder
+	last := 0
+	for i, match := range matches {
+		before := convert(text[last:match[0]])
+		builder.WriteString(before)
+		if before != "" && !strings.HasSuffix(before, "\n") {
+			builder.WriteString("\n")
+		}
+
+		language := ""
+		if i < len(languages) {
+			language = languages[i]
+		}
+		builder.WriteString(convertCodeBlock(text[match[2]:match[3]], language))
+
+		last = match[1]
+		if last < len(text) && text[last] != '\n' {
+			builder.WriteString("\n")
+		}
+	}
+	builder.WriteString(convert(text[last:]))
+	return builder.String()
+}
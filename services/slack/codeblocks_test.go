@@ -0,0 +1,45 @@
+package slack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackConvertMarkupCodeBlocks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"inline block", "run ```make build``` first", "run \n```\nmake build\n```\n first"},
+		{"language hint", "```go\nfunc main() {}\n```", "```go\nfunc main() {}\n```"},
+		{"escaped characters", "```if a &lt; b &amp;&amp; *p* {}```", "```\nif a < b && *p* {}\n```"},
+		{"markup outside only", "*bold*\n```*not bold*```\n*bold*", "**bold**\n```\n*not bold*\n```\n**bold**"},
+		{"several blocks", "```py\nx = 1``` and ```y```", "```py\nx = 1\n```\n and \n```\ny\n```"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SlackConvertMarkup(tc.text))
+		})
+	}
+}
+
+func TestConvertPostsMarkupCodeLanguages(t *testing.T) {
+	var post SlackPost
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "message",
+		"text": "`+"```print(1)``` then ```ts\\nlet a = 1```"+`",
+		"blocks": [{"type": "rich_text", "elements": [
+			{"type": "rich_text_preformatted", "language": "python", "elements": [{"type": "text", "text": "print(1)"}]},
+			{"type": "rich_text_section", "elements": [{"type": "text", "text": " then "}]},
+			{"type": "rich_text_preformatted", "elements": [{"type": "text", "text": "ts\\nlet a = 1"}]}
+		]}]
+	}`), &post))
+
+	posts := SlackConvertPostsMarkup(map[string][]SlackPost{"general": {post}})
+	assert.Equal(t, "```python\nprint(1)\n```\n then \n```ts\nlet a = 1\n```", posts["general"][0].Text)
+}
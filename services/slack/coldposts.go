@@ -0,0 +1,29 @@
+package slack
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+)
+
+// isColdPost returns whether a post is created before ColdBefore, so
+// it goes to the ColdExporter
+func (t *Transformer) isColdPost(post *IntermediatePost) bool {
+	return t.ColdExporter != nil && !t.ColdBefore.IsZero() && post.CreateAt < t.ColdBefore.UnixNano()/int64(time.Millisecond)
+}
+
+// writeColdLine writes the line of a post older than ColdBefore to the
+// ColdExporter, preceded by the version line for the first one. The
+// replies of the cold lines are not split by MaxRepliesPerLine.
+func (t *Transformer) writeColdLine(line *app.LineImportData) error {
+	if t.coldLines == 0 {
+		if err := t.ExportVersion(t.ColdExporter); err != nil {
+			return err
+		}
+	}
+	if err := t.ColdExporter.WriteLine(line); err != nil {
+		return err
+	}
+	t.coldLines++
+	return nil
+}
@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColdPosts(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-02-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "recent", "ts": "1580515200.000100"},
+		{"type": "message", "user": "U2", "text": "recent reply", "ts": "1580515300.000100", "thread_ts": "1580515200.000100"}
+	]`)}
+
+	var hot, cold recordingExporter
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		ColdBefore:      time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		ColdExporter:    &cold,
+	}, &hot)
+	require.NoError(t, err)
+
+	messages := func(exporter recordingExporter) []string {
+		messages := []string{}
+		for _, line := range exporter.lines {
+			if line.Post != nil {
+				messages = append(messages, *line.Post.Message)
+			}
+		}
+		return messages
+	}
+	assert.Equal(t, []string{"recent"}, messages(hot))
+	assert.ElementsMatch(t, []string{"hello @jane", "a file"}, messages(cold))
+	assert.Equal(t, "version", cold.lines[0].Type)
+	assert.Equal(t, 2, result.ColdLines())
+}
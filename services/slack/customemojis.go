@@ -0,0 +1,261 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// slackEmojiAliasPrefix starts the values of the emojis of the list
+// that are aliases of another emoji
+const slackEmojiAliasPrefix = "alias:"
+
+// maxEmojiAliasDepth stops following the aliases of aliases
+const maxEmojiAliasDepth = 5
+
+// CustomEmojiConfig imports the custom emojis of the workspace along
+// with the export.
+type CustomEmojiConfig struct {
+	// Dir holds the images of the emojis, named after the emojis. The
+	// images already in it are reused and the missing ones are
+	// downloaded into it
+	Dir string
+	// Emojis maps the names of the emojis to the URL of their image or
+	// to "alias:<name>", as returned by the emoji.list API method. The
+	// emoji.json file of the export completes it
+	Emojis map[string]string
+	// Downloader downloads the missing images, which are left out when
+	// not set
+	Downloader *AttachmentImageDownloader
+}
+
+type IntermediateEmoji struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// SlackParseEmojiList reads an emoji list, either the response of the
+// emoji.list API method or its emoji object
+func SlackParseEmojiList(data io.Reader) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(data).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if list, ok := raw["emoji"]; ok {
+		var emojis map[string]string
+		if err := json.Unmarshal(list, &emojis); err == nil {
+			return emojis, nil
+		}
+	}
+
+	emojis := make(map[string]string, len(raw))
+	for name, value := range raw {
+		var imageURL string
+		if err := json.Unmarshal(value, &imageURL); err != nil {
+			return nil, errors.Wrapf(err, "invalid emoji %s", name)
+		}
+		emojis[name] = imageURL
+	}
+	return emojis, nil
+}
+
+// EmojiList returns the custom emojis of the workspace with the
+// emoji.list method.
+func (c *SlackAPIClient) EmojiList(ctx context.Context) (map[string]string, error) {
+	var response struct {
+		Emoji map[string]string `json:"emoji"`
+	}
+	if err := c.Call(ctx, "emoji.list", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Emoji, nil
+}
+
+// resolveEmojiAlias follows the aliases of an emoji of the list,
+// returning the URL of its image, or the name of the system emoji it
+// is an alias of
+func resolveEmojiAlias(emojis map[string]string, name string) (string, string) {
+	value := emojis[name]
+	for i := 0; i < maxEmojiAliasDepth && strings.HasPrefix(value, slackEmojiAliasPrefix); i++ {
+		target := strings.TrimPrefix(value, slackEmojiAliasPrefix)
+		targetValue, ok := emojis[target]
+		if !ok {
+			systemName, _ := systemEmojiName(target)
+			return "", systemName
+		}
+		value = targetValue
+	}
+	if strings.HasPrefix(value, slackEmojiAliasPrefix) {
+		return "", ""
+	}
+	return value, ""
+}
+
+// emojiImageExtension returns the extension of the image of an emoji,
+// .png when its URL has none
+func emojiImageExtension(imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); ext == ".png" || ext == ".gif" || ext == ".jpg" || ext == ".jpeg" {
+			return ext
+		}
+	}
+	return ".png"
+}
+
+// emojiImages lists the images of the directory by emoji name
+func emojiImages(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	images := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".png" && ext != ".gif" && ext != ".jpg" && ext != ".jpeg" {
+			continue
+		}
+		images[strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))] = filepath.Join(dir, entry.Name())
+	}
+	return images, nil
+}
+
+// TransformCustomEmojis adds the custom emojis of the list and of the
+// images of the directory to the intermediate entities, downloading
+// the missing images. The reactions with the imported emojis are kept,
+// and the aliases of system emojis are replaced with them.
+func (t *Transformer) TransformCustomEmojis(cfg *CustomEmojiConfig, slackExport *SlackExport) {
+	t.Logger.Info("Transforming custom emojis")
+
+	emojis := map[string]string{}
+	for name, value := range slackExport.Emojis {
+		emojis[name] = value
+	}
+	for name, value := range cfg.Emojis {
+		emojis[name] = value
+	}
+
+	images, err := emojiImages(cfg.Dir)
+	if err != nil {
+		t.Logger.WithError(err).Error("Failed to list the custom emoji images")
+		return
+	}
+	names := []string{}
+	for name := range images {
+		if _, ok := emojis[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for name := range emojis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if cfg.Downloader != nil {
+		if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+			t.Logger.WithError(err).Error("Failed to create the custom emoji directory")
+			return
+		}
+	}
+
+	// the aliases share the image of their emoji
+	downloaded := map[string]string{}
+	t.customEmojis = map[string]bool{}
+	t.customEmojiAliases = map[string]string{}
+	for _, name := range names {
+		imageURL, systemName := resolveEmojiAlias(emojis, name)
+		if systemName != "" {
+			t.customEmojiAliases[name] = systemName
+			continue
+		}
+		if appErr := model.IsValidEmojiName(name); appErr != nil {
+			t.warn(&Warning{
+				Kind:    WarningCustomEmojiFailed,
+				Skipped: true,
+				Message: fmt.Sprintf("The custom emoji %s is not imported, its name is not valid in Mattermost or taken by a system emoji", name),
+			})
+			continue
+		}
+
+		image, ok := images[name]
+		if !ok {
+			image, ok = downloaded[imageURL]
+		}
+		if !ok {
+			if imageURL == "" || cfg.Downloader == nil {
+				t.warn(&Warning{
+					Kind:    WarningCustomEmojiFailed,
+					Skipped: true,
+					Message: fmt.Sprintf("The custom emoji %s is not imported, its image is missing", name),
+				})
+				continue
+			}
+			image = filepath.Join(cfg.Dir, name+emojiImageExtension(imageURL))
+			if err := cfg.Downloader.download(imageURL, image); err != nil {
+				t.warn(&Warning{
+					Kind:    WarningCustomEmojiFailed,
+					Skipped: true,
+					Message: fmt.Sprintf("The custom emoji %s is not imported, its image could not be downloaded", name),
+					Err:     err,
+				})
+				continue
+			}
+			downloaded[imageURL] = image
+		}
+
+		t.Intermediate.Emojis = append(t.Intermediate.Emojis, &IntermediateEmoji{Name: name, Image: image})
+		t.customEmojis[name] = true
+	}
+	t.Logger.Infof("%d custom emojis transformed", len(t.Intermediate.Emojis))
+}
+
+// importedEmojiName returns the name of an emoji of Slack in
+// Mattermost, and false when it neither is a system emoji nor one of
+// the imported custom emojis
+func (t *Transformer) importedEmojiName(name string) (string, bool) {
+	if systemName, ok := systemEmojiName(name); ok {
+		return systemName, true
+	}
+	if t.customEmojis[name] {
+		return name, true
+	}
+	if systemName, ok := t.customEmojiAliases[name]; ok && systemName != "" {
+		return systemName, true
+	}
+	return "", false
+}
+
+func GetImportLineFromEmoji(emoji *IntermediateEmoji) *app.LineImportData {
+	return &app.LineImportData{
+		Type: "emoji",
+		Emoji: &app.EmojiImportData{
+			Name:  model.NewString(emoji.Name),
+			Image: model.NewString(emoji.Image),
+		},
+	}
+}
+
+func (t *Transformer) ExportEmojis(exporter Exporter) error {
+	for _, emoji := range t.Intermediate.Emojis {
+		if err := exporter.WriteLine(GetImportLineFromEmoji(emoji)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
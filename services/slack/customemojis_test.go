@@ -0,0 +1,96 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackParseEmojiList(t *testing.T) {
+	emojis, err := SlackParseEmojiList(strings.NewReader(`{"ok": true, "emoji": {"parrot": "https://emoji.slack-edge.com/T1/parrot/abc.gif", "bird": "alias:parrot"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"parrot": "https://emoji.slack-edge.com/T1/parrot/abc.gif", "bird": "alias:parrot"}, emojis)
+
+	emojis, err = SlackParseEmojiList(strings.NewReader(`{"parrot": "https://emoji.slack-edge.com/T1/parrot/abc.gif"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"parrot": "https://emoji.slack-edge.com/T1/parrot/abc.gif"}, emojis)
+}
+
+func TestTransformFSCustomEmojis(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/gif")
+		w.Write([]byte("emoji " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	fsys := testExportFS()
+	fsys["emoji.json"] = &fstest.MapFile{Data: []byte(fmt.Sprintf(`{
+		"party-parrot": "%[1]s/party-parrot.gif",
+		"dancing-parrot": "alias:party-parrot",
+		"thumbs": "alias:thumbsup",
+		"broken": "%[1]s/missing.png",
+		"smile": "%[1]s/smile.png"
+	}`, server.URL))}
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "reacted", "ts": "1577923200.000100", "reactions": [
+			{"name": "party-parrot", "users": ["U1"], "count": 1},
+			{"name": "dancing-parrot", "users": ["U2"], "count": 1},
+			{"name": "thumbs", "users": ["U2"], "count": 1},
+			{"name": "blob-dance", "users": ["U2"], "count": 1},
+			{"name": "broken", "users": ["U2"], "count": 1}
+		]}
+	]`)}
+
+	emojiDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(emojiDir, "blob-dance.png"), []byte("blob"), 0600))
+
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			SkipAttachments: true,
+			CustomEmojis: &CustomEmojiConfig{
+				Dir:        emojiDir,
+				Downloader: NewAttachmentImageDownloader(""),
+			},
+		},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"/party-parrot.gif", "/missing.png"}, requested)
+	assert.Equal(t, []*IntermediateEmoji{
+		{Name: "blob-dance", Image: filepath.Join(emojiDir, "blob-dance.png")},
+		{Name: "dancing-parrot", Image: filepath.Join(emojiDir, "dancing-parrot.gif")},
+		// the alias and its emoji share the downloaded image
+		{Name: "party-parrot", Image: filepath.Join(emojiDir, "dancing-parrot.gif")},
+	}, result.Intermediate.Emojis)
+	// the broken image and the name of a system emoji
+	assert.Equal(t, 2, result.TransformResult.Count(WarningCustomEmojiFailed))
+	assert.Equal(t, 1, result.TransformResult.Count(WarningCustomEmojiReaction))
+
+	output := buffer.String()
+	assert.Contains(t, output, `{"type":"emoji","emoji":{"name":"party-parrot","image":"`+filepath.Join(emojiDir, "dancing-parrot.gif")+`"}}`)
+	assert.Less(t, strings.Index(output, `"type":"emoji"`), strings.Index(output, `"type":"post"`))
+	for _, reaction := range []string{"party-parrot", "dancing-parrot", "thumbsup", "blob-dance"} {
+		assert.Contains(t, output, `"emoji_name":"`+reaction+`"`)
+	}
+	assert.NotContains(t, output, `"emoji_name":"broken"`)
+}
@@ -0,0 +1,86 @@
+package slack
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// customStatusDurationDate is the duration of the custom statuses
+// expiring at a given time
+const customStatusDurationDate = "date_and_time"
+
+// UserCustomStatus is the custom status of a user in Slack, for the
+// admins to set it after the import with the custom status API
+type UserCustomStatus struct {
+	Username     string             `json:"username"`
+	CustomStatus model.CustomStatus `json:"custom_status"`
+}
+
+// TransformCustomStatuses sets the custom status of the users from the
+// status of their Slack profile. The expired statuses are left out and
+// the emojis missing from Mattermost replaced with the default one.
+func (t *Transformer) TransformCustomStatuses(users []SlackUser) {
+	now := time.Now()
+	for _, user := range users {
+		profile := user.Profile
+		if profile.StatusText == "" && profile.StatusEmoji == "" {
+			continue
+		}
+		intermediateUser, ok := t.Intermediate.UsersById[user.Id]
+		if !ok {
+			continue
+		}
+
+		status := &model.CustomStatus{
+			Emoji: model.DefaultCustomStatusEmoji,
+			Text:  truncateRunes(profile.StatusText, model.CustomStatusTextMaxRunes),
+		}
+		if profile.StatusExpiration > 0 {
+			status.ExpiresAt = time.Unix(profile.StatusExpiration, 0).UTC()
+			if status.ExpiresAt.Before(now) {
+				t.Logger.Debugf("Custom status of user %s expired on %s", intermediateUser.Username, status.ExpiresAt)
+				continue
+			}
+			status.Duration = customStatusDurationDate
+		}
+		if name := strings.Trim(profile.StatusEmoji, ":"); name != "" {
+			if emoji, ok := t.importedEmojiName(name); ok {
+				status.Emoji = emoji
+			}
+		}
+		intermediateUser.CustomStatus = status
+	}
+}
+
+// CustomStatuses returns the custom statuses of the users, which the
+// import format can't set, sorted by username
+func (t *Transformer) CustomStatuses() []UserCustomStatus {
+	statuses := []UserCustomStatus{}
+	for _, user := range t.Intermediate.UsersById {
+		if user.CustomStatus != nil {
+			statuses = append(statuses, UserCustomStatus{Username: user.Username, CustomStatus: *user.CustomStatus})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Username < statuses[j].Username
+	})
+	return statuses
+}
+
+func WriteCustomStatusReport(reportPath string, statuses []UserCustomStatus) error {
+	b, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the custom status report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the custom status report %s", reportPath)
+	}
+	return nil
+}
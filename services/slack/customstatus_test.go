@@ -0,0 +1,43 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformCustomStatuses(t *testing.T) {
+	expiresAt := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second).UTC()
+	users := []SlackUser{
+		{Id: "U1", Username: "john", Profile: SlackProfile{StatusText: "On parental leave", StatusEmoji: ":baby:"}},
+		{Id: "U2", Username: "jane", Profile: SlackProfile{StatusText: "Vacationing", StatusEmoji: ":palm_tree:", StatusExpiration: expiresAt.Unix()}},
+		{Id: "U3", Username: "jim", Profile: SlackProfile{StatusText: "In a meeting", StatusEmoji: ":calendar:", StatusExpiration: 1577836800}},
+		{Id: "U4", Username: "joe", Profile: SlackProfile{StatusText: strings.Repeat("a", 120), StatusEmoji: ":party-parrot:"}},
+		{Id: "U5", Username: "jill"},
+	}
+
+	transformer := NewTransformer("team", log.New())
+	transformer.TransformUsers(users, false, "")
+	transformer.TransformCustomStatuses(users)
+
+	statuses := transformer.CustomStatuses()
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, "jane", statuses[0].Username)
+	assert.Equal(t, model.CustomStatus{Emoji: "palm_tree", Text: "Vacationing", Duration: "date_and_time", ExpiresAt: expiresAt}, statuses[0].CustomStatus)
+
+	assert.Equal(t, "joe", statuses[1].Username)
+	assert.Equal(t, model.DefaultCustomStatusEmoji, statuses[1].CustomStatus.Emoji)
+	assert.Len(t, statuses[1].CustomStatus.Text, model.CustomStatusTextMaxRunes)
+
+	assert.Equal(t, "john", statuses[2].Username)
+	assert.Equal(t, model.CustomStatus{Emoji: "baby", Text: "On parental leave"}, statuses[2].CustomStatus)
+
+	assert.Nil(t, transformer.Intermediate.UsersById["U3"].CustomStatus)
+	assert.Nil(t, transformer.Intermediate.UsersById["U5"].CustomStatus)
+}
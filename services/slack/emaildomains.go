@@ -0,0 +1,66 @@
+package slack
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// emailDomain returns the lowercased domain of an email address, empty
+// when it has none
+func emailDomain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(email[i+1:])
+}
+
+// excludeEmailDomains leaves out the users whose email is in one of
+// the ExcludeEmailDomains, e.g. the contractors or the apps of the
+// workspace. Their messages are then skipped or reassigned to the
+// ReassignExcludedTo user by postAuthor.
+func (t *Transformer) excludeEmailDomains(slackExport *SlackExport) error {
+	if len(t.ExcludeEmailDomains) == 0 {
+		return nil
+	}
+
+	domains := make(map[string]bool, len(t.ExcludeEmailDomains))
+	for _, domain := range t.ExcludeEmailDomains {
+		domains[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))] = true
+	}
+
+	t.excludedUsers = map[string]bool{}
+	excluded := excludeUsers(slackExport, func(user SlackUser) bool {
+		if !domains[emailDomain(user.Profile.Email)] {
+			return false
+		}
+		t.excludedUsers[user.Id] = true
+		return true
+	})
+	t.Logger.Infof("Excluded %d users by the domain of their email", excluded)
+
+	if t.ReassignExcludedTo == "" {
+		return nil
+	}
+	for _, user := range slackExport.Users {
+		if user.Username == t.ReassignExcludedTo {
+			return nil
+		}
+	}
+	return errors.Errorf("the user %s the messages of the excluded users are reassigned to is not part of the export", t.ReassignExcludedTo)
+}
+
+// excludedUserAuthor returns the user the messages of an excluded
+// user are reassigned to, or nil when they are skipped
+func (t *Transformer) excludedUserAuthor(pc *PostContext, post SlackPost) *IntermediateUser {
+	if t.ReassignExcludedTo != "" {
+		for _, user := range t.Intermediate.UsersById {
+			if user.Username == t.ReassignExcludedTo {
+				return user
+			}
+		}
+	}
+	t.warnPostf(WarningExcludedUser, pc.OriginalChannelName, post, true, nil, "Skipping the message of a user excluded by the domain of their email. user=%s", post.User)
+	return nil
+}
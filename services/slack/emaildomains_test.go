@@ -0,0 +1,70 @@
+package slack
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailDomain(t *testing.T) {
+	assert.Equal(t, "example.com", emailDomain("john@Example.com"))
+	assert.Equal(t, "", emailDomain("john"))
+}
+
+func TestExcludeEmailDomains(t *testing.T) {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com"}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@contractors.example.com"}}
+	]`)}
+
+	transform := func(t *testing.T, reassignTo string) ([]string, *Result) {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:            "team",
+			Logger:              log.New(),
+			TransformConfig:     TransformConfig{SkipAttachments: true},
+			ExcludeEmailDomains: []string{"@Contractors.example.com"},
+			ReassignExcludedTo:  reassignTo,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Intermediate.UsersById, 1)
+		require.Contains(t, result.Intermediate.UsersById, "U1")
+		assert.Equal(t, []string{"U1"}, result.Intermediate.PublicChannels[0].Members)
+
+		messages := []string{}
+		for _, post := range result.Intermediate.Posts {
+			messages = append(messages, post.User+": "+post.Message)
+		}
+		sort.Strings(messages)
+		return messages, result
+	}
+
+	t.Run("skipped", func(t *testing.T) {
+		messages, result := transform(t, "")
+		// the mentions of the excluded users are kept as their name
+		assert.Equal(t, []string{"john: hello jane"}, messages)
+		assert.Equal(t, 1, result.TransformResult.Count(WarningExcludedUser))
+		assert.Equal(t, 0, result.TransformResult.Count(WarningUnknownUser))
+	})
+
+	t.Run("reassigned", func(t *testing.T) {
+		messages, result := transform(t, "john")
+		assert.Equal(t, []string{"john: a file", "john: hello jane"}, messages)
+		assert.Equal(t, 0, result.TransformResult.Count(WarningExcludedUser))
+	})
+
+	t.Run("unknown reassigned user", func(t *testing.T) {
+		_, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:            "team",
+			Logger:              log.New(),
+			ExcludeEmailDomains: []string{"contractors.example.com"},
+			ReassignExcludedTo:  "jane",
+		})
+		assert.Error(t, err)
+	})
+}
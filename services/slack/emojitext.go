@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// slackEmojiShortcodeRegex matches the emoji shortcodes of Slack, with
+// their skin tone, e.g. :wave::skin-tone-2:
+var slackEmojiShortcodeRegex = regexp.MustCompile(`:([a-z0-9_+'-]+(?:::skin-tone-[2-6])?):`)
+
+// emojiShortcodes returns the start and end of the emoji shortcodes of
+// a text, with the start and end of their name. Only the shortcodes
+// that are not part of a word are returned, so times such as 10:30:00
+// are not taken for shortcodes.
+func emojiShortcodes(text string) [][]int {
+	matches := slackEmojiShortcodeRegex.FindAllStringSubmatchIndex(text, -1)
+	shortcodes := matches[:0]
+	for _, match := range matches {
+		if isWordRune(lastRune(text[:match[0]])) || isWordRune(firstRune(text[match[1]:])) {
+			continue
+		}
+		shortcodes = append(shortcodes, match)
+	}
+	return shortcodes
+}
+
+// convertEmojiShortcodes renames the emoji shortcodes of a text to
+// their Mattermost name, see systemEmojiName. The shortcodes that are
+// not system emojis are left as is, as they can be custom emojis of
+// the workspace.
+func convertEmojiShortcodes(text string) string {
+	shortcodes := emojiShortcodes(text)
+	if len(shortcodes) == 0 {
+		return text
+	}
+
+	converted := make([]byte, 0, len(text))
+	last := 0
+	for _, match := range shortcodes {
+		name, ok := systemEmojiName(text[match[2]:match[3]])
+		if !ok {
+			continue
+		}
+		converted = append(converted, text[last:match[0]]...)
+		converted = append(converted, ':')
+		converted = append(converted, name...)
+		converted = append(converted, ':')
+		last = match[1]
+	}
+	converted = append(converted, text[last:]...)
+	return string(converted)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
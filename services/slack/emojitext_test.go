@@ -0,0 +1,53 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertEmojiShortcodes(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Text     string
+		Expected string
+	}{
+		{"system emoji", "Ship it :rocket:", "Ship it :rocket:"},
+		{"alias", ":simple_smile: welcome", ":slightly_smiling_face: welcome"},
+		{"skin tone", "hi :wave::skin-tone-2:", "hi :wave_light_skin_tone:"},
+		{"adjacent emojis", ":simple_smile::simple_smile:", ":slightly_smiling_face::slightly_smiling_face:"},
+		{"custom emoji", "go :partyparrot:", "go :partyparrot:"},
+		{"time", "standup at 10:30:00", "standup at 10:30:00"},
+		{"inside a word", "a:simple_smile:b", "a:simple_smile:b"},
+		{"no emoji", "plain text", "plain text"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, convertEmojiShortcodes(tc.Text))
+		})
+	}
+}
+
+func TestTransformFSChannelHeaderEmojis(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"],
+		 "topic": {"value": ":wave::skin-tone-3: Say hi"},
+		 "purpose": {"value": "Be nice :simple_smile:"}}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Intermediate.PublicChannels, 1)
+	assert.Equal(t, ":wave_medium_light_skin_tone: Say hi", result.Intermediate.PublicChannels[0].Header)
+	assert.Equal(t, "Be nice :slightly_smiling_face:", result.Intermediate.PublicChannels[0].Purpose)
+}
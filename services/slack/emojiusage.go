@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// EmojiUsage is how often a custom emoji of the workspace is used,
+// to know which ones to create in Mattermost before the import.
+type EmojiUsage struct {
+	Name string `json:"name"`
+	// Reactions is the number of users' reactions with the emoji
+	Reactions int `json:"reactions"`
+	// Messages is the number of messages using the emoji in their text
+	Messages int `json:"messages"`
+}
+
+// CustomEmojiUsage counts the custom emojis, the ones that are not
+// system emojis of Mattermost, used in the reactions and the text of
+// the parsed posts, the most used first.
+func CustomEmojiUsage(slackExport *SlackExport) []EmojiUsage {
+	usage := map[string]*EmojiUsage{}
+	emoji := func(name string) *EmojiUsage {
+		if _, ok := usage[name]; !ok {
+			usage[name] = &EmojiUsage{Name: name}
+		}
+		return usage[name]
+	}
+
+	for _, channelPosts := range slackExport.Posts {
+		for _, post := range channelPosts {
+			for _, reaction := range post.Reactions {
+				if _, ok := systemEmojiName(reaction.Name); !ok {
+					emoji(reaction.Name).Reactions += len(reaction.Users)
+				}
+			}
+
+			inMessage := map[string]bool{}
+			for _, match := range emojiShortcodes(post.Text) {
+				name := post.Text[match[2]:match[3]]
+				if _, ok := systemEmojiName(name); ok || inMessage[name] {
+					continue
+				}
+				inMessage[name] = true
+				emoji(name).Messages++
+			}
+		}
+	}
+
+	result := make([]EmojiUsage, 0, len(usage))
+	for _, emojiUsage := range usage {
+		result = append(result, *emojiUsage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		totalI, totalJ := result[i].Reactions+result[i].Messages, result[j].Reactions+result[j].Messages
+		if totalI != totalJ {
+			return totalI > totalJ
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// WriteEmojiUsageReport writes the usage of the custom emojis to
+// reportPath as JSON.
+func WriteEmojiUsageReport(reportPath string, usage []EmojiUsage) error {
+	b, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the emoji usage report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the emoji usage report %s", reportPath)
+	}
+	return nil
+}
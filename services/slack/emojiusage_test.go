@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomEmojiUsage(t *testing.T) {
+	slackExport := &SlackExport{Posts: map[string][]SlackPost{
+		"general": {
+			{Text: "ship it :partyparrot: :partyparrot: :smile:", Reactions: []SlackReaction{
+				{Name: "partyparrot", Users: []string{"U1", "U2"}},
+				{Name: "+1::skin-tone-2", Users: []string{"U1"}},
+			}},
+			{Text: "meeting at 10:30:00 :lgtm:"},
+		},
+		"random": {
+			{Text: "no emoji", Reactions: []SlackReaction{{Name: "lgtm", Users: []string{"U2"}}, {Name: "shipit", Users: []string{"U1"}}}},
+		},
+	}}
+
+	assert.Equal(t, []EmojiUsage{
+		{Name: "partyparrot", Reactions: 2, Messages: 1},
+		{Name: "lgtm", Reactions: 1, Messages: 1},
+		{Name: "shipit", Reactions: 1},
+	}, CustomEmojiUsage(slackExport))
+}
+
+func TestWriteEmojiUsageReport(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "emojis.json")
+	usage := []EmojiUsage{{Name: "partyparrot", Reactions: 2, Messages: 1}}
+	require.NoError(t, WriteEmojiUsageReport(reportPath, usage))
+
+	b, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	var written []EmojiUsage
+	require.NoError(t, json.Unmarshal(b, &written))
+	assert.Equal(t, usage, written)
+}
@@ -0,0 +1,359 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+	// zipMethodAES is the compression method of the WinZip AES
+	// encrypted entries, which store the actual method in an extra
+	// field
+	zipMethodAES       = 99
+	zipExtraAES        = 0x9901
+	zipAESAuthCodeLen  = 10
+	zipAESIterations   = 1000
+	zipCryptoHeaderLen = 12
+)
+
+var (
+	ErrZipPassword       = errors.New("wrong zip password")
+	ErrZipAuthentication = errors.New("zip entry authentication failed")
+	errZipChecksum       = errors.New("zip entry checksum mismatch")
+)
+
+// OpenZipFS returns the file system of a zip archive, decrypting the
+// ZipCrypto and WinZip AES encrypted entries with the password when it
+// is not empty. The entry names that are not valid UTF-8 are decoded,
+// see DecodeZipNames.
+func OpenZipFS(reader io.ReaderAt, size int64, password string) (fs.FS, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+	DecodeZipNames(zipReader)
+	if password == "" {
+		return zipReader, nil
+	}
+
+	files := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		files[file.Name] = file
+	}
+	return &encryptedZipFS{
+		zipReader: zipReader,
+		reader:    reader,
+		password:  []byte(password),
+		files:     files,
+	}, nil
+}
+
+type encryptedZipFS struct {
+	zipReader *zip.Reader
+	reader    io.ReaderAt
+	password  []byte
+	files     map[string]*zip.File
+}
+
+func (z *encryptedZipFS) Open(name string) (fs.File, error) {
+	file, ok := z.files[name]
+	if !ok || file.Flags&zipFlagEncrypted == 0 {
+		return z.zipReader.Open(name)
+	}
+
+	reader, err := z.openEncrypted(file)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &encryptedZipFile{reader: reader, info: file.FileInfo()}, nil
+}
+
+func (z *encryptedZipFS) openEncrypted(file *zip.File) (io.Reader, error) {
+	offset, err := file.DataOffset()
+	if err != nil {
+		return nil, err
+	}
+	raw := io.NewSectionReader(z.reader, offset, int64(file.CompressedSize64))
+
+	method := file.Method
+	checkCRC := true
+	isAES := method == zipMethodAES
+	var compressed io.Reader
+	if isAES {
+		var version uint16
+		compressed, method, version, err = newAESReader(raw, file, z.password)
+		// AE-2 entries don't store the checksum of the content
+		checkCRC = version == 1
+	} else {
+		compressed, err = newZipCryptoReader(raw, file, z.password)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var content io.Reader
+	switch method {
+	case zip.Store:
+		content = compressed
+	case zip.Deflate:
+		content = flate.NewReader(compressed)
+	default:
+		return nil, zip.ErrAlgorithm
+	}
+	if isAES {
+		content = &drainingReader{reader: content, source: compressed}
+	}
+
+	if !checkCRC {
+		return content, nil
+	}
+	return &checksumReader{reader: content, hash: crc32.NewIEEE(), expected: file.CRC32}, nil
+}
+
+type encryptedZipFile struct {
+	reader io.Reader
+	info   fs.FileInfo
+}
+
+func (f *encryptedZipFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *encryptedZipFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *encryptedZipFile) Close() error               { return nil }
+
+// checksumReader verifies the CRC-32 of the content once it is read
+type checksumReader struct {
+	reader   io.Reader
+	hash     hash.Hash32
+	expected uint32
+}
+
+func (r *checksumReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.hash.Write(b[:n])
+	if err == io.EOF && r.hash.Sum32() != r.expected {
+		return n, errZipChecksum
+	}
+	return n, err
+}
+
+// drainingReader reads the rest of the source once the content read
+// from it ends. The decompressor stops at the final block without
+// reading the source to its end, where the aesReader authenticates the
+// entry.
+type drainingReader struct {
+	reader io.Reader
+	source io.Reader
+}
+
+func (r *drainingReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	if err == io.EOF {
+		if _, drainErr := io.Copy(io.Discard, r.source); drainErr != nil {
+			return n, drainErr
+		}
+	}
+	return n, err
+}
+
+// zipCryptoKeys holds the state of the traditional PKWARE encryption
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password []byte) *zipCryptoKeys {
+	keys := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for _, b := range password {
+		keys.update(b)
+	}
+	return keys
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return (crc >> 8) ^ crc32.IEEETable[byte(crc)^b]
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+(k[0]&0xff))*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) streamByte() byte {
+	temp := k[2] | 2
+	return byte((temp * (temp ^ 1)) >> 8)
+}
+
+func (k *zipCryptoKeys) decrypt(b []byte) {
+	for i, c := range b {
+		b[i] = c ^ k.streamByte()
+		k.update(b[i])
+	}
+}
+
+func (k *zipCryptoKeys) encrypt(b []byte) {
+	for i, p := range b {
+		b[i] = p ^ k.streamByte()
+		k.update(p)
+	}
+}
+
+// zipCryptoCheckByte is the value of the last byte of the decrypted
+// encryption header, used to verify the password
+func zipCryptoCheckByte(file *zip.FileHeader) byte {
+	if file.Flags&zipFlagDataDescriptor != 0 {
+		return byte(file.ModifiedTime >> 8)
+	}
+	return byte(file.CRC32 >> 24)
+}
+
+type zipCryptoReader struct {
+	reader io.Reader
+	keys   *zipCryptoKeys
+}
+
+func newZipCryptoReader(raw io.Reader, file *zip.File, password []byte) (io.Reader, error) {
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, zipCryptoHeaderLen)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys.decrypt(header)
+	if header[zipCryptoHeaderLen-1] != zipCryptoCheckByte(&file.FileHeader) {
+		return nil, ErrZipPassword
+	}
+	return &zipCryptoReader{reader: raw, keys: keys}, nil
+}
+
+func (r *zipCryptoReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.keys.decrypt(b[:n])
+	return n, err
+}
+
+// winzipCTR is the AES counter mode used by WinZip, which increments
+// the counter as a little endian integer starting at 1
+type winzipCTR struct {
+	block     cipher.Block
+	counter   [aes.BlockSize]byte
+	keystream [aes.BlockSize]byte
+	pos       int
+}
+
+func newWinzipCTR(block cipher.Block) *winzipCTR {
+	return &winzipCTR{block: block, pos: aes.BlockSize}
+}
+
+func (s *winzipCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.pos == aes.BlockSize {
+			for j := range s.counter {
+				s.counter[j]++
+				if s.counter[j] != 0 {
+					break
+				}
+			}
+			s.block.Encrypt(s.keystream[:], s.counter[:])
+			s.pos = 0
+		}
+		dst[i] = src[i] ^ s.keystream[s.pos]
+		s.pos++
+	}
+}
+
+// zipAESExtra returns the AE version, key length and actual
+// compression method from the AES extra field of the entry
+func zipAESExtra(extra []byte) (version uint16, keyLen int, method uint16, err error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == zipExtraAES && size >= 7 {
+			version = binary.LittleEndian.Uint16(extra)
+			strength := extra[4]
+			method = binary.LittleEndian.Uint16(extra[5:])
+			if strength < 1 || strength > 3 {
+				return 0, 0, 0, errors.Errorf("unsupported AES strength %d", strength)
+			}
+			return version, 8 + 8*int(strength), method, nil
+		}
+		extra = extra[size:]
+	}
+	return 0, 0, 0, errors.New("missing AES extra field")
+}
+
+func zipAESKeys(password, salt []byte, keyLen int) (aesKey, hmacKey, verifier []byte) {
+	derived := pbkdf2.Key(password, salt, zipAESIterations, 2*keyLen+2, sha1.New)
+	return derived[:keyLen], derived[keyLen : 2*keyLen], derived[2*keyLen:]
+}
+
+type aesReader struct {
+	reader   io.Reader
+	trailer  io.Reader
+	stream   cipher.Stream
+	mac      hash.Hash
+	verified bool
+}
+
+func newAESReader(raw *io.SectionReader, file *zip.File, password []byte) (io.Reader, uint16, uint16, error) {
+	version, keyLen, method, err := zipAESExtra(file.Extra)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	saltLen := keyLen / 2
+	header := make([]byte, saltLen+2)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, 0, 0, err
+	}
+	aesKey, hmacKey, verifier := zipAESKeys(password, header[:saltLen], keyLen)
+	if !bytes.Equal(verifier, header[saltLen:]) {
+		return nil, 0, 0, ErrZipPassword
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	dataLen := raw.Size() - int64(len(header)) - zipAESAuthCodeLen
+	if dataLen < 0 {
+		return nil, 0, 0, io.ErrUnexpectedEOF
+	}
+	return &aesReader{
+		reader:  io.NewSectionReader(raw, int64(len(header)), dataLen),
+		trailer: io.NewSectionReader(raw, int64(len(header))+dataLen, zipAESAuthCodeLen),
+		stream:  newWinzipCTR(block),
+		mac:     hmac.New(sha1.New, hmacKey),
+	}, method, version, nil
+}
+
+func (r *aesReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.mac.Write(b[:n])
+	r.stream.XORKeyStream(b[:n], b[:n])
+	if err == io.EOF && !r.verified {
+		authCode := make([]byte, zipAESAuthCodeLen)
+		if _, err := io.ReadFull(r.trailer, authCode); err != nil {
+			return n, err
+		}
+		if !hmac.Equal(authCode, r.mac.Sum(nil)[:zipAESAuthCodeLen]) {
+			return n, ErrZipAuthentication
+		}
+		r.verified = true
+	}
+	return n, err
+}
@@ -0,0 +1,275 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testZipModifiedTime = 0x6a2b
+
+// zipCryptoWriter encrypts the entries with the traditional PKWARE
+// encryption. The zip writer creates the compressor before writing the
+// local file header, so the encryption header is written lazily.
+type zipCryptoWriter struct {
+	writer        io.Writer
+	keys          *zipCryptoKeys
+	headerWritten bool
+}
+
+func (w *zipCryptoWriter) Write(b []byte) (int, error) {
+	if !w.headerWritten {
+		w.headerWritten = true
+		header := make([]byte, zipCryptoHeaderLen)
+		header[zipCryptoHeaderLen-1] = byte(testZipModifiedTime >> 8)
+		if _, err := w.Write(header); err != nil {
+			return 0, err
+		}
+	}
+	encrypted := append([]byte(nil), b...)
+	w.keys.encrypt(encrypted)
+	return w.writer.Write(encrypted)
+}
+
+// zipCryptoCompressor deflates and encrypts the entries
+func zipCryptoCompressor(password string) zip.Compressor {
+	return func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(&zipCryptoWriter{writer: w, keys: newZipCryptoKeys([]byte(password))}, flate.DefaultCompression)
+	}
+}
+
+type aesWriter struct {
+	writer        io.Writer
+	header        []byte
+	headerWritten bool
+	stream        cipher.Stream
+	mac           hash.Hash
+}
+
+func (w *aesWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	_, err := w.writer.Write(w.header)
+	return err
+}
+
+func (w *aesWriter) Write(b []byte) (int, error) {
+	if err := w.writeHeader(); err != nil {
+		return 0, err
+	}
+	encrypted := make([]byte, len(b))
+	w.stream.XORKeyStream(encrypted, b)
+	w.mac.Write(encrypted)
+	return w.writer.Write(encrypted)
+}
+
+func (w *aesWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(w.mac.Sum(nil)[:zipAESAuthCodeLen])
+	return err
+}
+
+// aesDeflateWriter deflates the content before encrypting it
+type aesDeflateWriter struct {
+	*flate.Writer
+	aes *aesWriter
+}
+
+func (w *aesDeflateWriter) Close() error {
+	if err := w.Writer.Close(); err != nil {
+		return err
+	}
+	return w.aes.Close()
+}
+
+// aesCompressor encrypts the entries with AES-128 as WinZip does,
+// deflating them first with the Deflate method
+func aesCompressor(password string, method uint16) zip.Compressor {
+	return func(w io.Writer) (io.WriteCloser, error) {
+		salt := []byte("saltsalt")
+		aesKey, hmacKey, verifier := zipAESKeys([]byte(password), salt, 16)
+		block, err := aes.NewCipher(aesKey)
+		if err != nil {
+			return nil, err
+		}
+		writer := &aesWriter{
+			writer: w,
+			header: append(salt, verifier...),
+			stream: newWinzipCTR(block),
+			mac:    hmac.New(sha1.New, hmacKey),
+		}
+		if method != zip.Deflate {
+			return writer, nil
+		}
+		deflater, err := flate.NewWriter(writer, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		return &aesDeflateWriter{Writer: deflater, aes: writer}, nil
+	}
+}
+
+func aesExtraField(version, method uint16) []byte {
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra, zipExtraAES)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], version)
+	copy(extra[6:], "AE")
+	extra[8] = 1
+	binary.LittleEndian.PutUint16(extra[9:], method)
+	return extra
+}
+
+func createEncryptedZip(t *testing.T, useAES bool, password string, files map[string]string) []byte {
+	if useAES {
+		return createAESZip(t, 1, zip.Store, password, files)
+	}
+	return writeEncryptedZip(t, zipCryptoCompressor(password), func(name string) *zip.FileHeader {
+		return &zip.FileHeader{Name: name, Flags: zipFlagEncrypted, Method: zip.Deflate, ModifiedTime: testZipModifiedTime}
+	}, files)
+}
+
+// createAESZip encrypts the files with the given AE version, the AE-2
+// entries having no CRC-32
+func createAESZip(t *testing.T, version, method uint16, password string, files map[string]string) []byte {
+	return writeEncryptedZip(t, aesCompressor(password, method), func(name string) *zip.FileHeader {
+		return &zip.FileHeader{Name: name, Flags: zipFlagEncrypted, Method: zipMethodAES, ModifiedTime: testZipModifiedTime, Extra: aesExtraField(version, method)}
+	}, files)
+}
+
+func writeEncryptedZip(t *testing.T, compressor zip.Compressor, newHeader func(name string) *zip.FileHeader, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	zipWriter.RegisterCompressor(zip.Deflate, compressor)
+	zipWriter.RegisterCompressor(zipMethodAES, compressor)
+
+	for name, content := range files {
+		writer, err := zipWriter.CreateHeader(newHeader(name))
+		require.NoError(t, err)
+		_, err = writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "plain.txt", Method: zip.Store})
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("not encrypted"))
+	require.NoError(t, err)
+
+	require.NoError(t, zipWriter.Close())
+	return buffer.Bytes()
+}
+
+func TestOpenZipFS(t *testing.T) {
+	files := map[string]string{
+		"users.json":              `[{"id": "U1", "name": "john"}]`,
+		"general/2020-01-01.json": `[{"type": "message", "user": "U1", "text": "hello"}]`,
+	}
+
+	archives := map[string][]byte{
+		"ZipCrypto":    createEncryptedZip(t, false, "secret", files),
+		"AE-1 Store":   createAESZip(t, 1, zip.Store, "secret", files),
+		"AE-2 Deflate": createAESZip(t, 2, zip.Deflate, "secret", files),
+	}
+	for name, data := range archives {
+		isAES := name != "ZipCrypto"
+		t.Run(name, func(t *testing.T) {
+			t.Run("right password", func(t *testing.T) {
+				zipFS, err := OpenZipFS(bytes.NewReader(data), int64(len(data)), "secret")
+				require.NoError(t, err)
+
+				for name, content := range files {
+					read, err := fs.ReadFile(zipFS, name)
+					require.NoError(t, err)
+					assert.Equal(t, content, string(read))
+				}
+
+				read, err := fs.ReadFile(zipFS, "plain.txt")
+				require.NoError(t, err)
+				assert.Equal(t, "not encrypted", string(read))
+
+				entries, err := fs.ReadDir(zipFS, "general")
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				assert.Equal(t, "2020-01-01.json", entries[0].Name())
+			})
+
+			t.Run("wrong password", func(t *testing.T) {
+				zipFS, err := OpenZipFS(bytes.NewReader(data), int64(len(data)), "not the secret")
+				require.NoError(t, err)
+
+				_, err = fs.ReadFile(zipFS, "users.json")
+				require.ErrorIs(t, err, ErrZipPassword)
+			})
+
+			t.Run("tampered content", func(t *testing.T) {
+				tampered := append([]byte(nil), data...)
+				zipReader, err := zip.NewReader(bytes.NewReader(tampered), int64(len(tampered)))
+				require.NoError(t, err)
+				for _, file := range zipReader.File {
+					if file.Name != "users.json" {
+						continue
+					}
+					offset, err := file.DataOffset()
+					require.NoError(t, err)
+					tampered[offset+int64(file.CompressedSize64)-zipAESAuthCodeLen-1] ^= 0xff
+				}
+
+				zipFS, err := OpenZipFS(bytes.NewReader(tampered), int64(len(tampered)), "secret")
+				require.NoError(t, err)
+
+				_, err = fs.ReadFile(zipFS, "users.json")
+				require.Error(t, err)
+			})
+
+			t.Run("tampered authentication code", func(t *testing.T) {
+				if !isAES {
+					t.Skip("only the AES entries are authenticated")
+				}
+				tampered := append([]byte(nil), data...)
+				zipReader, err := zip.NewReader(bytes.NewReader(tampered), int64(len(tampered)))
+				require.NoError(t, err)
+				for _, file := range zipReader.File {
+					if file.Name != "users.json" {
+						continue
+					}
+					offset, err := file.DataOffset()
+					require.NoError(t, err)
+					tampered[offset+int64(file.CompressedSize64)-1] ^= 0xff
+				}
+
+				zipFS, err := OpenZipFS(bytes.NewReader(tampered), int64(len(tampered)), "secret")
+				require.NoError(t, err)
+
+				_, err = fs.ReadFile(zipFS, "users.json")
+				require.ErrorIs(t, err, ErrZipAuthentication)
+			})
+		})
+	}
+
+	t.Run("no password", func(t *testing.T) {
+		data := createEncryptedZip(t, false, "secret", files)
+		zipFS, err := OpenZipFS(bytes.NewReader(data), int64(len(data)), "")
+		require.NoError(t, err)
+		require.IsType(t, &zip.Reader{}, zipFS)
+
+		read, err := fs.ReadFile(zipFS, "plain.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "not encrypted", string(read))
+	})
+}
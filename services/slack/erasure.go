@@ -0,0 +1,328 @@
+package slack
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErasedMentionText replaces the mentions of erased data subjects.
+const ErasedMentionText = "[removed]"
+
+var slackUserMentionRegex = regexp.MustCompile(`<@([A-Z0-9]+)(\|[^>]*)?>`)
+
+// ErasureList holds the data subjects, identified by Slack user id or
+// email, whose data must be excluded from the output.
+type ErasureList struct {
+	Identifiers []string
+}
+
+// ParseErasureList reads one identifier per line, ignoring empty lines
+// and lines starting with #.
+func ParseErasureList(reader io.Reader) (*ErasureList, error) {
+	list := &ErasureList{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list.Identifiers = append(list.Identifiers, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ErasedSubject records what was removed for a data subject.
+type ErasedSubject struct {
+	Identifier         string `json:"identifier"`
+	SlackId            string `json:"slack_id"`
+	Username           string `json:"username,omitempty"`
+	ProfileRemoved     bool   `json:"profile_removed"`
+	ChannelMemberships int    `json:"channel_memberships_removed"`
+	Posts              int    `json:"posts_removed"`
+	Files              int    `json:"files_removed"`
+	Mentions           int    `json:"mentions_removed"`
+
+	// matched is set when the identifier matched a user of the export
+	matched bool
+}
+
+// removedData returns whether anything was removed for the subject
+func (s *ErasedSubject) removedData() bool {
+	return s.ProfileRemoved || s.ChannelMemberships > 0 || s.Posts > 0 || s.Files > 0 || s.Mentions > 0
+}
+
+// ErasureReport certifies the removals done for an erasure list.
+type ErasureReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Subjects    []*ErasedSubject `json:"subjects"`
+	// NotFound holds the identifiers of the list that matched no user
+	// and no data of the export
+	NotFound []string `json:"not_found"`
+
+	subjectsById map[string]*ErasedSubject
+}
+
+func WriteErasureReport(reportPath string, report *ErasureReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the erasure report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the erasure report %s", reportPath)
+	}
+	return nil
+}
+
+// ErasureReport returns the removals done for the erasure list of the
+// transformer, nil if it has none. The user ids missing from the users
+// of the export are reported as subjects only when data was removed for
+// them, and as not found otherwise.
+func (t *Transformer) ErasureReport() *ErasureReport {
+	if t.erasureReport == nil {
+		return nil
+	}
+
+	report := &ErasureReport{
+		GeneratedAt: t.erasureReport.GeneratedAt,
+		Subjects:    []*ErasedSubject{},
+		NotFound:    append([]string{}, t.erasureReport.NotFound...),
+	}
+	for _, subject := range t.erasureReport.Subjects {
+		if !subject.matched && !subject.removedData() {
+			report.NotFound = append(report.NotFound, subject.Identifier)
+			continue
+		}
+		report.Subjects = append(report.Subjects, subject)
+	}
+	return report
+}
+
+func removeMember(members []string, userId string) ([]string, bool) {
+	for i, member := range members {
+		if member == userId {
+			return append(members[:i:i], members[i+1:]...), true
+		}
+	}
+	return members, false
+}
+
+// eraseSubjects resolves the erasure list against the users of the
+// export, removing the profiles and channel memberships of the
+// matching users.
+func (t *Transformer) eraseSubjects(slackExport *SlackExport) {
+	report := &ErasureReport{
+		GeneratedAt:  time.Now().UTC(),
+		Subjects:     []*ErasedSubject{},
+		NotFound:     []string{},
+		subjectsById: map[string]*ErasedSubject{},
+	}
+	t.erasureReport = report
+
+	usersByEmail := map[string]SlackUser{}
+	usersById := map[string]SlackUser{}
+	for _, user := range slackExport.Users {
+		usersById[user.Id] = user
+		if user.Profile.Email != "" {
+			usersByEmail[strings.ToLower(user.Profile.Email)] = user
+		}
+	}
+
+	for _, identifier := range t.ErasureList.Identifiers {
+		subject := &ErasedSubject{Identifier: identifier}
+		if user, ok := usersByEmail[strings.ToLower(identifier)]; ok {
+			subject.SlackId, subject.Username, subject.matched = user.Id, user.Username, true
+		} else if user, ok := usersById[identifier]; ok {
+			subject.SlackId, subject.Username, subject.matched = user.Id, user.Username, true
+		} else if strings.Contains(identifier, "@") {
+			report.NotFound = append(report.NotFound, identifier)
+			continue
+		} else {
+			// the data of users missing from users.json is still
+			// removed by id, the subject being reported as not found
+			// when there is none
+			subject.SlackId = identifier
+		}
+		if _, ok := report.subjectsById[subject.SlackId]; ok {
+			continue
+		}
+		report.subjectsById[subject.SlackId] = subject
+		report.Subjects = append(report.Subjects, subject)
+	}
+
+	users := []SlackUser{}
+	for _, user := range slackExport.Users {
+		if subject, ok := report.subjectsById[user.Id]; ok {
+			subject.ProfileRemoved = true
+			continue
+		}
+		users = append(users, user)
+	}
+	slackExport.Users = users
+
+	// Channels holds a copy of the channels of every other list, whose
+	// removals are counted once
+	for _, channels := range [][]SlackChannel{slackExport.PublicChannels, slackExport.PrivateChannels, slackExport.GroupChannels, slackExport.DirectChannels} {
+		for i := range channels {
+			for userId := range report.subjectsById {
+				channels[i].Members, _ = removeMember(channels[i].Members, userId)
+			}
+			channels[i].Topic.Value = report.replaceMentions(channels[i].Topic.Value, false)
+			channels[i].Purpose.Value = report.replaceMentions(channels[i].Purpose.Value, false)
+		}
+	}
+	for i := range slackExport.Channels {
+		for userId, subject := range report.subjectsById {
+			var removed bool
+			slackExport.Channels[i].Members, removed = removeMember(slackExport.Channels[i].Members, userId)
+			if removed {
+				subject.ChannelMemberships++
+			}
+		}
+		slackExport.Channels[i].Topic.Value = report.eraseMentions(slackExport.Channels[i].Topic.Value)
+		slackExport.Channels[i].Purpose.Value = report.eraseMentions(slackExport.Channels[i].Purpose.Value)
+	}
+
+	t.Logger.Infof("Erasing the data of %d subjects, %d identifiers matched no user", len(report.Subjects), len(report.NotFound))
+}
+
+// erasedAuthor returns the erased subject that authored the post.
+func (r *ErasureReport) erasedAuthor(post *SlackPost) *ErasedSubject {
+	authors := []string{post.User}
+	if post.Comment != nil {
+		authors = append(authors, post.Comment.User)
+	}
+	for _, nested := range post.nestedMessages() {
+		authors = append(authors, nested.User)
+	}
+	for _, author := range authors {
+		if subject, ok := r.subjectsById[author]; ok {
+			return subject
+		}
+	}
+	return nil
+}
+
+func (r *ErasureReport) eraseMentions(text string) string {
+	return r.replaceMentions(text, true)
+}
+
+// replaceMentions replaces the mentions of the erased subjects in the
+// text, counting them in the report when count is set
+func (r *ErasureReport) replaceMentions(text string, count bool) string {
+	return slackUserMentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+		userId := slackUserMentionRegex.FindStringSubmatch(mention)[1]
+		subject, ok := r.subjectsById[userId]
+		if !ok {
+			return mention
+		}
+		if count {
+			subject.Mentions++
+		}
+		return ErasedMentionText
+	})
+}
+
+// eraseBlockElements replaces the mentions in the text of the rich text
+// elements and of their children
+func (r *ErasureReport) eraseBlockElements(elements []*SlackBlockElement) {
+	for _, element := range elements {
+		element.Text = SlackText(r.eraseMentions(string(element.Text)))
+		r.eraseBlockElements(element.Elements)
+	}
+}
+
+// eraseFiles drops the files uploaded by the erased subjects from a
+// post authored by someone else
+func (r *ErasureReport) eraseFiles(post *SlackPost) {
+	if post.File != nil {
+		if subject, ok := r.subjectsById[post.File.User]; ok {
+			subject.Files++
+			post.File = nil
+		}
+	}
+	if post.Files == nil {
+		return
+	}
+	files := []*SlackFile{}
+	for _, file := range post.Files {
+		if subject, ok := r.subjectsById[file.User]; ok {
+			subject.Files++
+			continue
+		}
+		files = append(files, file)
+	}
+	post.Files = files
+}
+
+// eraseFromPost replaces the mentions of the erased subjects in the
+// text, file comment, attachments and blocks of a post and of its
+// revisions, and drops the files they uploaded
+func (r *ErasureReport) eraseFromPost(post *SlackPost) {
+	post.Text = r.eraseMentions(post.Text)
+	if post.Comment != nil {
+		post.Comment.Comment = r.eraseMentions(post.Comment.Comment)
+	}
+	for _, attachment := range post.Attachments {
+		if attachment == nil {
+			continue
+		}
+		attachment.Text = r.eraseMentions(attachment.Text)
+		attachment.Pretext = r.eraseMentions(attachment.Pretext)
+		attachment.Fallback = r.eraseMentions(attachment.Fallback)
+		attachment.Title = r.eraseMentions(attachment.Title)
+		for _, field := range attachment.Fields {
+			if value, ok := field.Value.(string); ok {
+				field.Value = r.eraseMentions(value)
+			}
+		}
+	}
+	for _, block := range post.Blocks {
+		block.Text = SlackText(r.eraseMentions(string(block.Text)))
+		for i, field := range block.Fields {
+			block.Fields[i] = SlackText(r.eraseMentions(string(field)))
+		}
+		r.eraseBlockElements(block.Elements)
+	}
+	r.eraseFiles(post)
+
+	for _, nested := range post.nestedMessages() {
+		r.eraseFromPost(nested)
+	}
+}
+
+// erasePosts drops the posts authored by the erased subjects, along
+// with their files, and removes their mentions and files from the rest
+// of the posts. It must run before the mentions are converted.
+func (t *Transformer) erasePosts(posts []SlackPost) []SlackPost {
+	report := t.erasureReport
+	if report == nil || len(report.subjectsById) == 0 {
+		return posts
+	}
+
+	kept := make([]SlackPost, 0, len(posts))
+	for _, post := range posts {
+		if subject := report.erasedAuthor(&post); subject != nil {
+			subject.Posts++
+			subject.Files += len(post.Files)
+			if post.File != nil {
+				subject.Files++
+			}
+			continue
+		}
+
+		report.eraseFromPost(&post)
+		kept = append(kept, post)
+	}
+	return kept
+}
@@ -0,0 +1,188 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErasureList(t *testing.T) {
+	list, err := ParseErasureList(strings.NewReader("# subjects\nJane@Example.com\n\n  U9  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Jane@Example.com", "U9"}, list.Identifiers)
+}
+
+func TestErasure(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U9", "text": "from a deleted account", "ts": "1577923200.000100"},
+		{"type": "message", "user": "U1", "text": "thanks <@U2|jane> and <@U9>", "ts": "1577923201.000100"}
+	]`)}
+	list := &ErasureList{Identifiers: []string{"JANE@example.com", "U9", "nobody@example.com"}}
+
+	for name, transform := range map[string]func(opts Options) (*Result, error){
+		"TransformFS": func(opts Options) (*Result, error) {
+			return TransformFS(context.Background(), fsys, opts)
+		},
+		"StreamFS": func(opts Options) (*Result, error) {
+			return StreamFS(context.Background(), fsys, opts, NewJSONLExporter(&strings.Builder{}))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := transform(Options{
+				TeamName:        "team",
+				Logger:          log.New(),
+				ErasureList:     list,
+				TransformConfig: TransformConfig{AttachmentsDir: t.TempDir()},
+			})
+			require.NoError(t, err)
+
+			assert.Len(t, result.Intermediate.UsersById, 1)
+			assert.Contains(t, result.Intermediate.UsersById, "U1")
+			assert.Equal(t, []string{"U1"}, result.Intermediate.PublicChannels[0].Members)
+
+			report := result.ErasureReport
+			require.Len(t, report.Subjects, 2)
+			assert.Equal(t, []string{"nobody@example.com"}, report.NotFound)
+
+			jane := report.Subjects[0]
+			assert.Equal(t, "U2", jane.SlackId)
+			assert.True(t, jane.ProfileRemoved)
+			assert.Equal(t, 1, jane.ChannelMemberships)
+			assert.Equal(t, 1, jane.Posts)
+			assert.Equal(t, 1, jane.Files)
+			assert.Equal(t, 2, jane.Mentions)
+
+			unknown := report.Subjects[1]
+			assert.Equal(t, "U9", unknown.SlackId)
+			assert.False(t, unknown.ProfileRemoved)
+			assert.Equal(t, 1, unknown.Posts)
+			assert.Equal(t, 1, unknown.Mentions)
+		})
+	}
+
+	t.Run("mentions are replaced", func(t *testing.T) {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			ErasureList:     list,
+			TransformConfig: TransformConfig{SkipAttachments: true},
+		})
+		require.NoError(t, err)
+
+		messages := []string{}
+		for _, post := range result.Intermediate.Posts {
+			messages = append(messages, post.Message)
+		}
+		assert.ElementsMatch(t, []string{"hello [removed]", "thanks [removed] and [removed]"}, messages)
+	})
+}
+
+func TestErasureBeyondText(t *testing.T) {
+	newTransformer := func(identifiers ...string) (*Transformer, *SlackExport) {
+		channel := SlackChannel{Id: "C1", Name: "general", Members: []string{"U1", "U2"}}
+		return newErasureTransformer(channel, identifiers...)
+	}
+
+	t.Run("channel topic and purpose", func(t *testing.T) {
+		transformer, slackExport := newErasureTransformer(SlackChannel{
+			Id:      "C1",
+			Name:    "general",
+			Members: []string{"U1", "U2"},
+			Topic:   SlackChannelSub{Value: "ask <@U2>"},
+			Purpose: SlackChannelSub{Value: "run by <@U2|jane>"},
+		}, "U2")
+		assert.Equal(t, "ask [removed]", slackExport.Channels[0].Topic.Value)
+		assert.Equal(t, "run by [removed]", slackExport.Channels[0].Purpose.Value)
+		assert.Equal(t, "ask [removed]", slackExport.PublicChannels[0].Topic.Value)
+		assert.Equal(t, 2, transformer.ErasureReport().Subjects[0].Mentions)
+	})
+
+	t.Run("file comments", func(t *testing.T) {
+		transformer, _ := newTransformer("U2")
+		posts := transformer.erasePosts([]SlackPost{
+			{Type: "message", SubType: "file_comment", User: "U1", Comment: &SlackComment{User: "U1", Comment: "see <@U2>"}},
+		})
+		require.Len(t, posts, 1)
+		assert.Equal(t, "see [removed]", posts[0].Comment.Comment)
+		assert.Equal(t, 1, transformer.ErasureReport().Subjects[0].Mentions)
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		transformer, _ := newTransformer("U2")
+		posts := transformer.erasePosts([]SlackPost{
+			{Type: "message", User: "U1", Attachments: []*model.SlackAttachment{{
+				Text:     "quoted from <@U2>",
+				Pretext:  "<@U2> wrote",
+				Fallback: "<@U2>: hello",
+				Fields:   []*model.SlackAttachmentField{{Title: "owner", Value: "<@U2>"}},
+			}}},
+		})
+		require.Len(t, posts, 1)
+		attachment := posts[0].Attachments[0]
+		assert.Equal(t, "quoted from [removed]", attachment.Text)
+		assert.Equal(t, "[removed] wrote", attachment.Pretext)
+		assert.Equal(t, "[removed]: hello", attachment.Fallback)
+		assert.Equal(t, "[removed]", attachment.Fields[0].Value)
+		assert.Equal(t, 4, transformer.ErasureReport().Subjects[0].Mentions)
+	})
+
+	t.Run("blocks", func(t *testing.T) {
+		transformer, _ := newTransformer("U2")
+		posts := transformer.erasePosts([]SlackPost{
+			{Type: "message", User: "U1", Blocks: []*SlackBlock{
+				{Type: "section", Text: "ping <@U2>", Fields: []SlackText{"<@U2>"}},
+				{Type: "rich_text", Elements: []*SlackBlockElement{
+					{Type: "rich_text_section", Elements: []*SlackBlockElement{{Type: "text", Text: "cc <@U2>"}}},
+				}},
+			}},
+		})
+		require.Len(t, posts, 1)
+		assert.Equal(t, SlackText("ping [removed]"), posts[0].Blocks[0].Text)
+		assert.Equal(t, SlackText("[removed]"), posts[0].Blocks[0].Fields[0])
+		assert.Equal(t, SlackText("cc [removed]"), posts[0].Blocks[1].Elements[0].Elements[0].Text)
+		assert.Equal(t, 3, transformer.ErasureReport().Subjects[0].Mentions)
+	})
+
+	t.Run("files shared by someone else", func(t *testing.T) {
+		transformer, _ := newTransformer("U2")
+		posts := transformer.erasePosts([]SlackPost{
+			{Type: "message", SubType: "file_share", User: "U1", Files: []*SlackFile{{Id: "F1", User: "U2"}, {Id: "F2", User: "U1"}}},
+			{Type: "message", SubType: "file_share", User: "U1", File: &SlackFile{Id: "F3", User: "U2"}},
+		})
+		require.Len(t, posts, 2)
+		require.Len(t, posts[0].Files, 1)
+		assert.Equal(t, "F2", posts[0].Files[0].Id)
+		assert.Nil(t, posts[1].File)
+		assert.Equal(t, 2, transformer.ErasureReport().Subjects[0].Files)
+	})
+
+	t.Run("unknown ids", func(t *testing.T) {
+		transformer, _ := newTransformer("U404", "U9")
+		transformer.erasePosts([]SlackPost{
+			{Type: "message", User: "U9", Text: "from a deleted account"},
+		})
+		report := transformer.ErasureReport()
+		require.Len(t, report.Subjects, 1)
+		assert.Equal(t, "U9", report.Subjects[0].SlackId)
+		assert.Equal(t, []string{"U404"}, report.NotFound)
+	})
+}
+
+func newErasureTransformer(channel SlackChannel, identifiers ...string) (*Transformer, *SlackExport) {
+	slackExport := &SlackExport{
+		Users:          []SlackUser{{Id: "U1", Username: "john"}, {Id: "U2", Username: "jane"}},
+		Channels:       []SlackChannel{channel},
+		PublicChannels: []SlackChannel{channel},
+	}
+	transformer := NewTransformer("team", log.New())
+	transformer.ErasureList = &ErasureList{Identifiers: identifiers}
+	transformer.eraseSubjects(slackExport)
+	return transformer, slackExport
+}
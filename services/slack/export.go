@@ -6,13 +6,16 @@ import (
 	"log"
 	"math"
 	"os"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/v6/app"
 	"github.com/mattermost/mattermost-server/v6/model"
 	"github.com/pkg/errors"
+	"github.com/rivo/uniseg"
 )
 
 const (
@@ -21,12 +24,22 @@ const (
 
 var isValidChannelNameCharacters = regexp.MustCompile(`^[a-z0-9\-_]+$`).MatchString
 
-func truncateRunes(s string, i int) string {
-	runes := []rune(s)
-	if len(runes) > i {
-		return string(runes[:i])
+// truncateRunes truncates s to at most max runes between two grapheme
+// clusters, so emoji sequences and combining characters are kept whole
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
 	}
-	return s
+	graphemes := uniseg.NewGraphemes(s)
+	runes, end := 0, 0
+	for graphemes.Next() {
+		runes += len(graphemes.Runes())
+		if runes > max {
+			break
+		}
+		_, end = graphemes.Positions()
+	}
+	return s[:end]
 }
 
 func SlackConvertTimeStamp(ts string) int64 {
@@ -72,6 +85,20 @@ func SplitChannelsByMemberSize(channels []SlackChannel, limit int) (regularChann
 	return
 }
 
+func GetImportLineFromTeam(team *IntermediateTeam) *app.LineImportData {
+	return &app.LineImportData{
+		Type: "team",
+		Team: &app.TeamImportData{
+			Name:        model.NewString(team.Name),
+			DisplayName: model.NewString(team.DisplayName),
+			Type:        model.NewString(team.Type),
+		},
+	}
+}
+
+// GetImportLineFromChannel returns the import line of a channel. The
+// import format can't archive the channels, the archived ones are
+// listed by the archived channel report instead.
 func GetImportLineFromChannel(team string, channel *IntermediateChannel) *app.LineImportData {
 	newChannel := &app.ChannelImportData{
 		Team:        model.NewString(team),
@@ -89,25 +116,65 @@ func GetImportLineFromChannel(team string, channel *IntermediateChannel) *app.Li
 }
 
 func GetImportLineFromDirectChannel(team string, channel *IntermediateChannel) *app.LineImportData {
+	directChannel := &app.DirectChannelImportData{
+		Header:  &channel.Topic,
+		Members: &channel.MembersUsernames,
+	}
+	if len(channel.FavoritedBy) > 0 {
+		directChannel.FavoritedBy = &channel.FavoritedBy
+	}
+
 	return &app.LineImportData{
-		Type: "direct_channel",
-		DirectChannel: &app.DirectChannelImportData{
-			Header:  &channel.Topic,
-			Members: &channel.MembersUsernames,
-		},
+		Type:          "direct_channel",
+		DirectChannel: directChannel,
 	}
 }
 
+// userRoles returns the system, team and channel roles of the user,
+// derived from its Slack guest and admin flags.
+func userRoles(user *IntermediateUser) (systemRoles, teamRoles, channelRoles, channelAdminRoles string) {
+	if user.IsGuest {
+		return model.SystemGuestRoleId, model.TeamGuestRoleId, model.ChannelGuestRoleId, model.ChannelGuestRoleId
+	}
+
+	teamRoles = model.TeamUserRoleId
+	if user.IsTeamAdmin {
+		teamRoles += " " + model.TeamAdminRoleId
+	}
+	return model.SystemUserRoleId, teamRoles, model.ChannelUserRoleId, model.ChannelUserRoleId + " " + model.ChannelAdminRoleId
+}
+
+// GetImportLineFromUser returns the import line of a user. The import
+// format has no custom status, the custom statuses are listed by the
+// custom status report instead.
 func GetImportLineFromUser(user *IntermediateUser, team string) *app.LineImportData {
+	systemRoles, teamRoles, channelRoles, channelAdminRoles := userRoles(user)
+	adminOf := map[string]bool{}
+	for _, channelName := range user.AdminMemberships {
+		adminOf[channelName] = true
+	}
+	favorites := map[string]bool{}
+	for _, channelName := range user.FavoriteChannels {
+		favorites[channelName] = true
+	}
+
 	channelMemberships := []app.UserChannelImportData{}
 	for _, channelName := range user.Memberships {
-		channelMemberships = append(channelMemberships, app.UserChannelImportData{
+		roles := channelRoles
+		if adminOf[channelName] {
+			roles = channelAdminRoles
+		}
+		membership := app.UserChannelImportData{
 			Name:  model.NewString(channelName),
-			Roles: model.NewString(model.ChannelUserRoleId),
-		})
+			Roles: model.NewString(roles),
+		}
+		if favorites[channelName] {
+			membership.Favorite = model.NewBool(true)
+		}
+		channelMemberships = append(channelMemberships, membership)
 	}
 
-	return &app.LineImportData{
+	line := &app.LineImportData{
 		Type: "user",
 		User: &app.UserImportData{
 			Username:    model.NewString(user.Username),
@@ -116,18 +183,22 @@ func GetImportLineFromUser(user *IntermediateUser, team string) *app.LineImportD
 			FirstName:   model.NewString(user.FirstName),
 			LastName:    model.NewString(user.LastName),
 			Position:    model.NewString(user.Position),
-			Roles:       model.NewString(model.SystemUserRoleId),
+			Roles:       model.NewString(systemRoles),
 			AuthService: model.NewString(user.AuthService),
 			AuthData:    user.AuthData,
 			Teams: &[]app.UserTeamImportData{
 				{
 					Name:     model.NewString(team),
 					Channels: &channelMemberships,
-					Roles:    model.NewString(model.TeamUserRoleId),
+					Roles:    model.NewString(teamRoles),
 				},
 			},
 		},
 	}
+	if user.ProfileImage != "" {
+		line.User.ProfileImage = model.NewString(user.ProfileImage)
+	}
+	return line
 }
 
 func GetAttachmentImportDataFromPaths(paths []string) []app.AttachmentImportData {
@@ -171,6 +242,18 @@ func createRepliesForAttachments(attachments []app.AttachmentImportData, user st
 	return replies
 }
 
+// editAt returns the edit time of the post, only set for the edited
+// posts imported in legal-hold mode
+func editAt(post *IntermediatePost) *int64 {
+	if post.EditAt == 0 {
+		return nil
+	}
+	return &post.EditAt
+}
+
+// GetImportLineFromPost returns the import line of a post. The import
+// format has no pinned flag, so the pinned posts are listed by the pins
+// report instead.
 func GetImportLineFromPost(post *IntermediatePost, team string) *app.LineImportData {
 	replies := []app.ReplyImportData{}
 	postAttachments := GetAttachmentImportDataFromPaths(post.Attachments)
@@ -196,6 +279,8 @@ func GetImportLineFromPost(post *IntermediatePost, team string) *app.LineImportD
 			User:        &reply.User,
 			Message:     &reply.Message,
 			CreateAt:    &reply.CreateAt,
+			EditAt:      editAt(reply),
+			Reactions:   getReactionImportData(reply.Reactions, reply.CreateAt),
 			Attachments: &replyAttachments,
 		}
 		replies = append(replies, newReply)
@@ -211,6 +296,8 @@ func GetImportLineFromPost(post *IntermediatePost, team string) *app.LineImportD
 				Message:        &post.Message,
 				Props:          &post.Props,
 				CreateAt:       &post.CreateAt,
+				EditAt:         editAt(post),
+				Reactions:      getReactionImportData(post.Reactions, post.CreateAt),
 				Replies:        &replies,
 				Attachments:    &postAttachments,
 			},
@@ -225,6 +312,8 @@ func GetImportLineFromPost(post *IntermediatePost, team string) *app.LineImportD
 				Message:     &post.Message,
 				Props:       &post.Props,
 				CreateAt:    &post.CreateAt,
+				EditAt:      editAt(post),
+				Reactions:   getReactionImportData(post.Reactions, post.CreateAt),
 				Replies:     &replies,
 				Attachments: &postAttachments,
 			},
@@ -247,21 +336,42 @@ func ExportWriteLine(writer io.Writer, line *app.LineImportData) error {
 	return nil
 }
 
-func (t *Transformer) ExportVersion(writer io.Writer) error {
+func (t *Transformer) ExportVersion(exporter Exporter) error {
 	version := 1
 	versionLine := &app.LineImportData{
 		Type:    "version",
 		Version: &version,
 	}
 
-	return ExportWriteLine(writer, versionLine)
+	return exporter.WriteLine(versionLine)
+}
+
+// ExportTeam writes the team line when the output defines the team,
+// followed by the teams of the TeamMapping
+func (t *Transformer) ExportTeam(exporter Exporter) error {
+	if t.Intermediate.Team == nil {
+		return nil
+	}
+
+	if err := exporter.WriteLine(GetImportLineFromTeam(t.Intermediate.Team)); err != nil {
+		return err
+	}
+	for _, team := range t.TeamMapping.Teams() {
+		if team == t.Intermediate.Team.Name {
+			continue
+		}
+		if err := exporter.WriteLine(GetImportLineFromTeam(NewIntermediateTeam(team, ""))); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // valid for open or private, as they export with no members
-func (t *Transformer) ExportChannels(channels []*IntermediateChannel, writer io.Writer) error {
+func (t *Transformer) ExportChannels(channels []*IntermediateChannel, exporter Exporter) error {
 	for _, channel := range channels {
-		line := GetImportLineFromChannel(t.TeamName, channel)
-		if err := ExportWriteLine(writer, line); err != nil {
+		line := GetImportLineFromChannel(t.teamOrDefault(channel.Team), channel)
+		if err := exporter.WriteLine(line); err != nil {
 			return err
 		}
 	}
@@ -270,10 +380,19 @@ func (t *Transformer) ExportChannels(channels []*IntermediateChannel, writer io.
 }
 
 // valid for group or direct, as they export with members
-func (t *Transformer) ExportDirectChannels(channels []*IntermediateChannel, writer io.Writer) error {
+func (t *Transformer) ExportDirectChannels(channels []*IntermediateChannel, exporter Exporter) error {
+	if t.archiveUser != nil {
+		return nil
+	}
+
 	for _, channel := range channels {
+		// the direct channel line shows the channel in the sidebar of
+		// its members, the posts create it otherwise
+		if channel.Hidden {
+			continue
+		}
 		line := GetImportLineFromDirectChannel(t.TeamName, channel)
-		if err := ExportWriteLine(writer, line); err != nil {
+		if err := exporter.WriteLine(line); err != nil {
 			return err
 		}
 	}
@@ -281,10 +400,18 @@ func (t *Transformer) ExportDirectChannels(channels []*IntermediateChannel, writ
 	return nil
 }
 
-func (t *Transformer) ExportUsers(writer io.Writer) error {
-	for _, user := range t.Intermediate.UsersById {
+func (t *Transformer) ExportUsers(exporter Exporter) error {
+	users := t.Intermediate.UsersById
+	if t.archiveUser != nil {
+		users = map[string]*IntermediateUser{t.archiveUser.Id: t.archiveUser}
+	}
+
+	notifyPropsByName := t.channelNotifyPropsByName()
+	for _, user := range users {
 		line := GetImportLineFromUser(user, t.TeamName)
-		if err := ExportWriteLine(writer, line); err != nil {
+		setMembershipNotifyProps(line, notifyPropsByName)
+		t.splitTeamMemberships(line)
+		if err := exporter.WriteLine(line); err != nil {
 			return err
 		}
 	}
@@ -292,16 +419,18 @@ func (t *Transformer) ExportUsers(writer io.Writer) error {
 	return nil
 }
 
-func (t *Transformer) ExportPosts(writer io.Writer) error {
+func (t *Transformer) ExportPosts(exporter Exporter) error {
 	for _, post := range t.Intermediate.Posts {
-		line := GetImportLineFromPost(post, t.TeamName)
-		if err := ExportWriteLine(writer, line); err != nil {
+		if err := t.writePostLine(exporter, post); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// Export writes the intermediate entities to the given path, as an
+// import archive if it has the .zip extension and as a JSONL file
+// otherwise.
 func (t *Transformer) Export(outputFilePath string) error {
 	outputFile, err := os.Create(outputFilePath)
 	if err != nil {
@@ -309,38 +438,93 @@ func (t *Transformer) Export(outputFilePath string) error {
 	}
 	defer outputFile.Close()
 
+	exporter := NewExporterForPath(outputFile, outputFilePath)
+
+	if err := t.ExportWith(exporter); err != nil {
+		exporter.Close()
+		return err
+	}
+	return exporter.Close()
+}
+
+// NewExporterForPath returns the exporter matching the extension of
+// the output path, writing to the given writer.
+func NewExporterForPath(writer io.Writer, outputFilePath string) Exporter {
+	if strings.EqualFold(path.Ext(outputFilePath), ".zip") {
+		return NewZipExporter(writer)
+	}
+	return NewJSONLExporter(writer)
+}
+
+// ExportTo writes the Mattermost bulk import lines of the intermediate
+// entities to the given writer.
+func (t *Transformer) ExportTo(writer io.Writer) error {
+	return t.ExportWith(NewJSONLExporter(writer))
+}
+
+// ExportWith sends the bulk import lines of the intermediate entities
+// to the exporter in the order expected by the import.
+func (t *Transformer) ExportWith(exporter Exporter) error {
+	finishStage := t.startStage(StageExport)
+	if err := t.ExportHeader(exporter); err != nil {
+		return err
+	}
+
+	t.Logger.Info("Exporting posts")
+	if err := t.ExportPosts(exporter); err != nil {
+		return err
+	}
+
+	finishStage()
+	return nil
+}
+
+// ExportHeader sends every line preceding the posts to the exporter.
+func (t *Transformer) ExportHeader(exporter Exporter) error {
 	t.Logger.Info("Exporting version")
-	if err := t.ExportVersion(outputFile); err != nil {
+	if err := t.ExportVersion(exporter); err != nil {
 		return err
 	}
 
+	// the users and channels were imported already
+	if t.ImportedMapping != nil {
+		return nil
+	}
+
+	t.Logger.Info("Exporting team")
+	if err := t.ExportTeam(exporter); err != nil {
+		return err
+	}
+
+	if len(t.Intermediate.Emojis) > 0 {
+		t.Logger.Info("Exporting custom emojis")
+		if err := t.ExportEmojis(exporter); err != nil {
+			return err
+		}
+	}
+
 	t.Logger.Info("Exporting public channels")
-	if err := t.ExportChannels(t.Intermediate.PublicChannels, outputFile); err != nil {
+	if err := t.ExportChannels(t.Intermediate.PublicChannels, exporter); err != nil {
 		return err
 	}
 
 	t.Logger.Info("Exporting private channels")
-	if err := t.ExportChannels(t.Intermediate.PrivateChannels, outputFile); err != nil {
+	if err := t.ExportChannels(t.Intermediate.PrivateChannels, exporter); err != nil {
 		return err
 	}
 
 	t.Logger.Info("Exporting users")
-	if err := t.ExportUsers(outputFile); err != nil {
+	if err := t.ExportUsers(exporter); err != nil {
 		return err
 	}
 
 	t.Logger.Info("Exporting group channels")
-	if err := t.ExportDirectChannels(t.Intermediate.GroupChannels, outputFile); err != nil {
+	if err := t.ExportDirectChannels(t.Intermediate.GroupChannels, exporter); err != nil {
 		return err
 	}
 
 	t.Logger.Info("Exporting direct channels")
-	if err := t.ExportDirectChannels(t.Intermediate.DirectChannels, outputFile); err != nil {
-		return err
-	}
-
-	t.Logger.Info("Exporting posts")
-	if err := t.ExportPosts(outputFile); err != nil {
+	if err := t.ExportDirectChannels(t.Intermediate.DirectChannels, exporter); err != nil {
 		return err
 	}
 
@@ -1,11 +1,42 @@
 package slack
 
 import (
+	"archive/zip"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/mattermost/mattermost-server/v6/app"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// recordingExporter keeps the exported lines in memory
+type recordingExporter struct {
+	lines []*app.LineImportData
+}
+
+func (e *recordingExporter) WriteLine(line *app.LineImportData) error {
+	e.lines = append(e.lines, line)
+	return nil
+}
+
+func (e *recordingExporter) Close() error {
+	return nil
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "short", truncateRunes("short", 10))
+	assert.Equal(t, "abc", truncateRunes("abcdef", 3))
+	// the family emoji is a single grapheme of seven runes
+	assert.Equal(t, "hi ", truncateRunes("hi 👩‍👩‍👧‍👦!", 5))
+	assert.Equal(t, "hi 👩‍👩‍👧‍👦", truncateRunes("hi 👩‍👩‍👧‍👦!", 10))
+	// e followed by a combining acute accent
+	assert.Equal(t, "caf", truncateRunes("cafe\u0301s", 4))
+	assert.Equal(t, "日本", truncateRunes("日本語", 2))
+}
+
 func TestSlackConvertTimeStamp(t *testing.T) {
 	testCases := []struct {
 		Name           string
@@ -36,3 +67,84 @@ func TestSlackConvertTimeStamp(t *testing.T) {
 		})
 	}
 }
+
+func TestZipExport(t *testing.T) {
+	dir := t.TempDir()
+	attachmentPath := filepath.Join(dir, "F1_notes.txt")
+	require.NoError(t, os.WriteFile(attachmentPath, []byte("some notes"), 0600))
+
+	transformer := NewTransformer("team", log.New())
+	transformer.Intermediate.UsersById = map[string]*IntermediateUser{}
+	transformer.Intermediate.Posts = []*IntermediatePost{
+		{
+			User:    "user1",
+			Channel: "general",
+			Message: "root",
+			Replies: []*IntermediatePost{
+				{User: "user2", Message: "reply", Attachments: []string{attachmentPath}},
+			},
+		},
+	}
+
+	outputPath := filepath.Join(dir, "export.zip")
+	require.NoError(t, transformer.Export(outputPath))
+
+	zipReader, err := zip.OpenReader(outputPath)
+	require.NoError(t, err)
+	defer zipReader.Close()
+
+	names := []string{}
+	for _, file := range zipReader.File {
+		names = append(names, file.Name)
+	}
+	require.Equal(t, []string{"import.jsonl", filepath.ToSlash(filepath.Join("data", attachmentPath))}, names)
+}
+
+func TestGetImportLineFromUserRoles(t *testing.T) {
+	testCases := []struct {
+		Name                 string
+		User                 *IntermediateUser
+		ExpectedSystemRoles  string
+		ExpectedTeamRoles    string
+		ExpectedChannelRoles []string
+	}{
+		{
+			Name:                 "Regular member",
+			User:                 &IntermediateUser{Memberships: []string{"c1", "c2"}, AdminMemberships: []string{"c2"}},
+			ExpectedSystemRoles:  "system_user",
+			ExpectedTeamRoles:    "team_user",
+			ExpectedChannelRoles: []string{"channel_user", "channel_user channel_admin"},
+		},
+		{
+			Name:                 "Team admin",
+			User:                 &IntermediateUser{IsTeamAdmin: true, Memberships: []string{"c1"}},
+			ExpectedSystemRoles:  "system_user",
+			ExpectedTeamRoles:    "team_user team_admin",
+			ExpectedChannelRoles: []string{"channel_user"},
+		},
+		{
+			Name:                 "Guest",
+			User:                 &IntermediateUser{IsGuest: true, Memberships: []string{"c1"}},
+			ExpectedSystemRoles:  "system_guest",
+			ExpectedTeamRoles:    "team_guest",
+			ExpectedChannelRoles: []string{"channel_guest"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			line := GetImportLineFromUser(tc.User, "team")
+			require.Equal(t, tc.ExpectedSystemRoles, *line.User.Roles)
+
+			teams := *line.User.Teams
+			require.Len(t, teams, 1)
+			require.Equal(t, tc.ExpectedTeamRoles, *teams[0].Roles)
+
+			channelRoles := []string{}
+			for _, channel := range *teams[0].Channels {
+				channelRoles = append(channelRoles, *channel.Roles)
+			}
+			require.Equal(t, tc.ExpectedChannelRoles, channelRoles)
+		})
+	}
+}
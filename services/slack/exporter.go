@@ -0,0 +1,165 @@
+package slack
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/pkg/errors"
+)
+
+// Exporter receives the bulk import lines produced by the
+// transformation and writes them to an output target.
+type Exporter interface {
+	WriteLine(line *app.LineImportData) error
+	// Close flushes the output. The exporter can't be used after
+	// closing it.
+	Close() error
+}
+
+// JSONLExporter writes the lines as a JSONL stream. Closing it does
+// not close the underlying writer.
+type JSONLExporter struct {
+	writer io.Writer
+}
+
+func NewJSONLExporter(writer io.Writer) *JSONLExporter {
+	return &JSONLExporter{writer: writer}
+}
+
+func (e *JSONLExporter) WriteLine(line *app.LineImportData) error {
+	return ExportWriteLine(e.writer, line)
+}
+
+func (e *JSONLExporter) Close() error {
+	return nil
+}
+
+const (
+	zipExportJSONLName = "import.jsonl"
+	// zipExportDataDir is the directory of the archive where the
+	// server looks for the attachments referenced by the lines
+	zipExportDataDir = "data"
+)
+
+// ZipExporter writes an import archive that can be uploaded with
+// mmctl, containing the JSONL file and every attachment referenced
+// by the post lines. The lines are buffered in a temporary file until
+// the exporter is closed.
+type ZipExporter struct {
+	writer      io.Writer
+	lines       *os.File
+	attachments []string
+	seen        map[string]bool
+}
+
+func NewZipExporter(writer io.Writer) *ZipExporter {
+	return &ZipExporter{
+		writer: writer,
+		seen:   map[string]bool{},
+	}
+}
+
+func (e *ZipExporter) WriteLine(line *app.LineImportData) error {
+	if e.lines == nil {
+		lines, err := os.CreateTemp("", "mmetl-*.jsonl")
+		if err != nil {
+			return errors.Wrap(err, "failed to create the temporary lines file")
+		}
+		e.lines = lines
+	}
+
+	for _, attachmentPath := range lineAttachmentPaths(line) {
+		if !e.seen[attachmentPath] {
+			e.seen[attachmentPath] = true
+			e.attachments = append(e.attachments, attachmentPath)
+		}
+	}
+
+	return ExportWriteLine(e.lines, line)
+}
+
+func addFileToZip(zipWriter *zip.Writer, name string, reader io.Reader) error {
+	entry, err := zipWriter.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s in the archive", name)
+	}
+	if _, err := io.Copy(entry, reader); err != nil {
+		return errors.Wrapf(err, "failed to write %s to the archive", name)
+	}
+	return nil
+}
+
+func (e *ZipExporter) Close() error {
+	if e.lines == nil {
+		return nil
+	}
+	defer os.Remove(e.lines.Name())
+	defer e.lines.Close()
+
+	zipWriter := zip.NewWriter(e.writer)
+
+	if _, err := e.lines.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to rewind the temporary lines file")
+	}
+	if err := addFileToZip(zipWriter, zipExportJSONLName, e.lines); err != nil {
+		return err
+	}
+
+	for _, attachmentPath := range e.attachments {
+		attachment, err := os.Open(attachmentPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open attachment %s", attachmentPath)
+		}
+		err = addFileToZip(zipWriter, path.Join(zipExportDataDir, attachmentPath), attachment)
+		attachment.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+func attachmentImportDataPaths(attachments *[]app.AttachmentImportData) []string {
+	paths := []string{}
+	if attachments == nil {
+		return paths
+	}
+	for _, attachment := range *attachments {
+		if attachment.Path != nil {
+			paths = append(paths, *attachment.Path)
+		}
+	}
+	return paths
+}
+
+// lineAttachmentPaths returns the paths of the attachments of a post
+// line, including the ones of its replies, the image of an emoji line
+// or the profile image of a user line.
+func lineAttachmentPaths(line *app.LineImportData) []string {
+	var attachments *[]app.AttachmentImportData
+	var replies *[]app.ReplyImportData
+	switch {
+	case line.Post != nil:
+		attachments, replies = line.Post.Attachments, line.Post.Replies
+	case line.DirectPost != nil:
+		attachments, replies = line.DirectPost.Attachments, line.DirectPost.Replies
+	case line.Emoji != nil && line.Emoji.Image != nil:
+		return []string{*line.Emoji.Image}
+	case line.User != nil && line.User.ProfileImage != nil:
+		return []string{*line.User.ProfileImage}
+	default:
+		return nil
+	}
+
+	paths := attachmentImportDataPaths(attachments)
+	if replies != nil {
+		for _, reply := range *replies {
+			paths = append(paths, attachmentImportDataPaths(reply.Attachments)...)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,50 @@
+package slack
+
+// sharedFiles returns the files shared by a message, the comments
+// being left out as they only reference the file they are about
+func sharedFiles(post SlackPost) []*SlackFile {
+	if post.IsFileComment() {
+		return nil
+	}
+	if post.File != nil {
+		return []*SlackFile{post.File}
+	}
+	return post.Files
+}
+
+// recordSharedFiles remembers the thread of the message sharing files,
+// which is the message itself unless it is a reply. Only the first
+// share of a file is kept.
+func (pc *PostContext) recordSharedFiles(post SlackPost) {
+	files := sharedFiles(post)
+	if len(files) == 0 {
+		return
+	}
+	if pc.sharedFiles == nil {
+		pc.sharedFiles = map[string]string{}
+	}
+	threadTS := post.TimeStamp
+	if post.ThreadTS != "" {
+		threadTS = post.ThreadTS
+	}
+	for _, file := range files {
+		if _, ok := pc.sharedFiles[file.Id]; !ok {
+			pc.sharedFiles[file.Id] = threadTS
+		}
+	}
+}
+
+// threadFileComment returns the file comment as a reply to the thread
+// of the message that shared its file in the channel, or as it is when
+// that message was not imported.
+func (pc *PostContext) threadFileComment(post SlackPost, threads ThreadsStorage) SlackPost {
+	if !post.IsFileComment() || post.File == nil || post.ThreadTS != "" {
+		return post
+	}
+	threadTS, ok := pc.sharedFiles[post.File.Id]
+	if !ok || !threads.HasThread(threadTS) {
+		return post
+	}
+	post.ThreadTS = threadTS
+	return post
+}
@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformFSThreadFileComments(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "file_comment", "ts": "1577923200.000100", "file": {"id": "F1"}, "comment": {"user": "U1", "comment": "nice notes"}},
+		{"type": "message", "subtype": "file_comment", "ts": "1577923201.000100", "file": {"id": "F2"}, "comment": {"user": "U2", "comment": "unknown file"}},
+		{"type": "message", "user": "U1", "text": "the plan", "ts": "1577923202.000100", "thread_ts": "1577923202.000100"},
+		{"type": "message", "subtype": "file_share", "user": "U2", "text": "in the thread", "ts": "1577923203.000100", "thread_ts": "1577923202.000100", "file": {"id": "F3", "name": "plan.txt"}},
+		{"type": "message", "subtype": "file_comment", "ts": "1577923204.000100", "file": {"id": "F3"}, "comment": {"user": "U1", "comment": "about the plan"}}
+	]`)}
+
+	transform := func(threadFileComments bool) *Result {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName: "team",
+			Logger:   log.New(),
+			TransformConfig: TransformConfig{
+				SkipAttachments:    true,
+				ThreadFileComments: threadFileComments,
+			},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("threaded", func(t *testing.T) {
+		posts := transform(true).Intermediate.Posts
+		messages := []string{}
+		for _, post := range posts {
+			messages = append(messages, post.Message)
+		}
+		assert.ElementsMatch(t, []string{"hello @jane", "a file", "unknown file", "the plan"}, messages)
+
+		for _, post := range posts {
+			replies := []string{}
+			for _, reply := range post.Replies {
+				replies = append(replies, reply.Message)
+			}
+			switch post.Message {
+			case "a file":
+				assert.Equal(t, []string{"nice notes"}, replies)
+			case "the plan":
+				assert.Equal(t, []string{"in the thread", "about the plan"}, replies)
+			default:
+				assert.Empty(t, replies, post.Message)
+			}
+		}
+	})
+
+	t.Run("standalone", func(t *testing.T) {
+		assert.Len(t, transform(false).Intermediate.Posts, 6)
+	})
+}
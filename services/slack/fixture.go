@@ -0,0 +1,265 @@
+package slack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// FixtureCase is a group of edge cases of the synthetic exports written
+// by WriteFixture.
+type FixtureCase string
+
+const (
+	// FixtureThreads adds threads spanning several days, a broadcast
+	// reply and a reply whose root is missing
+	FixtureThreads FixtureCase = "threads"
+	// FixtureBots adds the messages of bots and integrations, with
+	// attachments
+	FixtureBots FixtureCase = "bots"
+	// FixtureUnicode adds names, purposes, messages and reactions
+	// mixing scripts, combining characters and emojis
+	FixtureUnicode FixtureCase = "unicode"
+	// FixtureHugePosts adds messages and attachments exceeding the
+	// limits of Mattermost
+	FixtureHugePosts FixtureCase = "huge"
+	// FixtureFiles adds shared files, one of them missing from the
+	// export
+	FixtureFiles FixtureCase = "files"
+)
+
+// FixtureCases are all the cases, in the order they are written.
+var FixtureCases = []FixtureCase{FixtureThreads, FixtureBots, FixtureUnicode, FixtureHugePosts, FixtureFiles}
+
+// ParseFixtureCases validates the names of the cases, returning all
+// of them when names is empty.
+func ParseFixtureCases(names []string) ([]FixtureCase, error) {
+	if len(names) == 0 {
+		return FixtureCases, nil
+	}
+	cases := []FixtureCase{}
+	for _, name := range names {
+		found := false
+		for _, fixtureCase := range FixtureCases {
+			if FixtureCase(name) == fixtureCase {
+				cases = append(cases, fixtureCase)
+				found = true
+				break
+			}
+		}
+		if !found {
+			valid := make([]string, len(FixtureCases))
+			for i, fixtureCase := range FixtureCases {
+				valid[i] = string(fixtureCase)
+			}
+			return nil, errors.Errorf("unknown fixture case %q, expected one of %s", name, strings.Join(valid, ", "))
+		}
+	}
+	return cases, nil
+}
+
+// fixtureStart is the time of the first message of the fixtures, so
+// they are the same on every run
+var fixtureStart = time.Date(2021, time.January, 4, 9, 0, 0, 0, time.UTC)
+
+type fixtureObject map[string]interface{}
+
+// fixtureBuilder collects the files of a synthetic export
+type fixtureBuilder struct {
+	users    []fixtureObject
+	channels []fixtureObject
+	dms      []fixtureObject
+	posts    map[string][]fixtureObject
+	uploads  map[string]string
+	messages int
+}
+
+// post adds a message to the channel directory on the given day after
+// the start, returning its timestamp
+func (b *fixtureBuilder) post(channelDir string, day int, message fixtureObject) string {
+	b.messages++
+	createAt := fixtureStart.AddDate(0, 0, day).Add(time.Duration(b.messages) * time.Minute)
+	ts := fmt.Sprintf("%d.%06d", createAt.Unix(), b.messages)
+	message["ts"] = ts
+	if _, ok := message["type"]; !ok {
+		message["type"] = "message"
+	}
+	dayFile := path.Join(channelDir, createAt.Format("2006-01-02")+".json")
+	b.posts[dayFile] = append(b.posts[dayFile], message)
+	return ts
+}
+
+func (b *fixtureBuilder) channel(id, name, purpose string, members ...string) {
+	b.channels = append(b.channels, fixtureObject{
+		"id":      id,
+		"name":    name,
+		"creator": members[0],
+		"members": members,
+		"purpose": fixtureObject{"value": purpose},
+		"topic":   fixtureObject{"value": ""},
+	})
+}
+
+func (b *fixtureBuilder) user(id, username, realName, email string, extra fixtureObject) {
+	profile := fixtureObject{"real_name": realName, "display_name": username, "email": email}
+	if names := strings.SplitN(realName, " ", 2); len(names) == 2 {
+		profile["first_name"] = names[0]
+		profile["last_name"] = names[1]
+	}
+	user := fixtureObject{"id": id, "name": username, "profile": profile}
+	for key, value := range extra {
+		user[key] = value
+	}
+	b.users = append(b.users, user)
+}
+
+func (b *fixtureBuilder) base() {
+	b.user("U001", "alice", "Alice Adams", "alice@example.com", fixtureObject{"is_admin": true})
+	b.user("U002", "bob", "Bob Brown", "bob@example.com", nil)
+	b.channel("C001", "general", "Company wide announcements", "U001", "U002")
+	b.post("general", 0, fixtureObject{"user": "U001", "text": "Welcome to the fixture export <@U002>!"})
+
+	b.dms = append(b.dms, fixtureObject{"id": "D001", "members": []string{"U001", "U002"}})
+	b.post("D001", 0, fixtureObject{"user": "U002", "text": "a direct message"})
+}
+
+func (b *fixtureBuilder) threads() {
+	b.channel("C002", "threads", "Threads edge cases", "U001", "U002")
+	rootMessage := fixtureObject{
+		"user": "U001", "text": "a thread spanning two days",
+		"reply_count": 3, "reply_users_count": 2, "reply_users": []string{"U002", "U001"},
+	}
+	root := b.post("threads", 0, rootMessage)
+	rootMessage["thread_ts"] = root
+	b.post("threads", 0, fixtureObject{"user": "U002", "text": "a reply", "thread_ts": root, "parent_user_id": "U001"})
+	b.post("threads", 0, fixtureObject{"user": "U001", "subtype": "thread_broadcast", "text": "a reply also sent to the channel", "thread_ts": root})
+	b.post("threads", 1, fixtureObject{"user": "U002", "text": "a late reply on the next day", "thread_ts": root, "parent_user_id": "U001"})
+	b.post("threads", 1, fixtureObject{"user": "U002", "text": "a reply to a thread missing from the export", "thread_ts": "1500000000.000100"})
+}
+
+func (b *fixtureBuilder) bots() {
+	b.channel("C003", "alerts", "Bots and integrations", "U001", "U002")
+	b.post("alerts", 0, fixtureObject{
+		"subtype": "bot_message", "bot_id": "B001", "username": "deploy-bot",
+		"text": "Deployment finished",
+		"attachments": []*model.SlackAttachment{{
+			Color:  "good",
+			Title:  "api v1.2.3",
+			Text:   "deployed to *production*",
+			Fields: []*model.SlackAttachmentField{{Title: "Duration", Value: "42s", Short: true}},
+		}},
+	})
+	b.post("alerts", 0, fixtureObject{
+		"bot_id": "B002", "text": "an integration posting with a bot profile",
+		"bot_profile": fixtureObject{"id": "B002", "app_id": "A001", "name": "monitoring"},
+	})
+	b.post("alerts", 0, fixtureObject{"subtype": "channel_join", "user": "U002", "text": "<@U002> has joined the channel"})
+}
+
+func (b *fixtureBuilder) unicode() {
+	b.user("U003", "zoe", "Zoë Ørsted", "zoe@example.com", fixtureObject{"is_restricted": true})
+	b.channel("C004", "unicode", "Ünïcödé — 日本語 — עברית — 🎉", "U001", "U003")
+	b.post("unicode", 0, fixtureObject{
+		"user": "U003", "text": "Zoé says こんにちは, שלום and 👩‍👩‍👧‍👦 to <@U001> :partyparrot:",
+		"reactions": []SlackReaction{
+			{Name: "+1::skin-tone-3", Users: []string{"U001"}, Count: 1},
+			{Name: "partyparrot", Users: []string{"U001", "U003"}, Count: 2},
+		},
+	})
+	b.post("unicode", 0, fixtureObject{"user": "U001", "text": "`code with ünïcödé` and <https://example.com/ä?q=ü|a link with ümlauts>"})
+}
+
+func (b *fixtureBuilder) hugePosts() {
+	b.channel("C005", "huge", "Messages exceeding the limits of Mattermost", "U001", "U002")
+	b.post("huge", 0, fixtureObject{"user": "U001", "text": strings.Repeat("Привет, мир! ", PosgreSQLMaxPostSize/13+100)})
+	b.post("huge", 0, fixtureObject{
+		"subtype": "bot_message", "bot_id": "B001", "username": "deploy-bot",
+		"text":        "an attachment exceeding the props limit",
+		"attachments": []*model.SlackAttachment{{Title: "log", Text: strings.Repeat("x", model.PostPropsMaxRunes+1)}},
+	})
+	b.post("huge", 0, fixtureObject{"user": "U002", "text": strings.Repeat(":wave: ", 2000)})
+}
+
+func (b *fixtureBuilder) files() {
+	b.channel("C006", "files", "Shared files", "U001", "U002")
+	b.uploads[path.Join("__uploads", "F001", "report.txt")] = "the quarterly report\n"
+	b.uploads[path.Join("__uploads", "F002", "отчёт 2021.txt")] = "a file with a unicode name\n"
+	b.post("files", 0, fixtureObject{
+		"subtype": "file_share", "user": "U001", "text": "two files", "upload": true,
+		"files": []*SlackFile{{Id: "F001", Name: "report.txt"}, {Id: "F002", Name: "отчёт 2021.txt"}},
+	})
+	b.post("files", 0, fixtureObject{
+		"subtype": "file_share", "user": "U002", "text": "a file missing from the export", "upload": true,
+		"files": []*SlackFile{{Id: "F003", Name: "missing.pdf"}},
+	})
+}
+
+// WriteFixture writes a small synthetic Slack export zip covering the
+// given cases, to test a whole migration pipeline against a Mattermost
+// server. The export is the same on every run.
+func WriteFixture(writer io.Writer, cases []FixtureCase) error {
+	b := &fixtureBuilder{posts: map[string][]fixtureObject{}, uploads: map[string]string{}}
+	b.base()
+	for _, fixtureCase := range cases {
+		switch fixtureCase {
+		case FixtureThreads:
+			b.threads()
+		case FixtureBots:
+			b.bots()
+		case FixtureUnicode:
+			b.unicode()
+		case FixtureHugePosts:
+			b.hugePosts()
+		case FixtureFiles:
+			b.files()
+		default:
+			return errors.Errorf("unknown fixture case %q", fixtureCase)
+		}
+	}
+
+	files := map[string]interface{}{
+		"users.json":    b.users,
+		"channels.json": b.channels,
+		"dms.json":      b.dms,
+	}
+	for dayFile, posts := range b.posts {
+		files[dayFile] = posts
+	}
+	for uploadPath, content := range b.uploads {
+		files[uploadPath] = content
+	}
+
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	zipWriter := zip.NewWriter(writer)
+	for _, filePath := range paths {
+		data, ok := files[filePath].(string)
+		if !ok {
+			encoded, err := json.MarshalIndent(files[filePath], "", "    ")
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal the fixture file %s", filePath)
+			}
+			data = string(encoded)
+		}
+		fileWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: filePath, Method: zip.Deflate, Modified: fixtureStart})
+		if err != nil {
+			return errors.Wrapf(err, "failed to add the fixture file %s", filePath)
+		}
+		if _, err := io.WriteString(fileWriter, data); err != nil {
+			return errors.Wrapf(err, "failed to write the fixture file %s", filePath)
+		}
+	}
+	return errors.Wrap(zipWriter.Close(), "failed to write the fixture")
+}
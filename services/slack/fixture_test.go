@@ -0,0 +1,77 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFixtureCases(t *testing.T) {
+	cases, err := ParseFixtureCases(nil)
+	require.NoError(t, err)
+	assert.Equal(t, FixtureCases, cases)
+
+	cases, err = ParseFixtureCases([]string{"unicode", "threads"})
+	require.NoError(t, err)
+	assert.Equal(t, []FixtureCase{FixtureUnicode, FixtureThreads}, cases)
+
+	_, err = ParseFixtureCases([]string{"threads", "emails"})
+	assert.Error(t, err)
+}
+
+func TestWriteFixture(t *testing.T) {
+	var first, second bytes.Buffer
+	require.NoError(t, WriteFixture(&first, FixtureCases))
+	require.NoError(t, WriteFixture(&second, FixtureCases))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+
+	result, err := TransformZip(context.Background(), bytes.NewReader(first.Bytes()), int64(first.Len()), Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			AttachmentsDir:         t.TempDir(),
+			ImportWorkflowMessages: true,
+		},
+	})
+	require.NoError(t, err)
+
+	// the bot messages are posted by the workflow user
+	assert.Len(t, result.Intermediate.UsersById, 4)
+	assert.Len(t, result.Intermediate.PublicChannels, 6)
+	assert.Len(t, result.Intermediate.DirectChannels, 1)
+	assert.Zero(t, result.TransformResult.Count(WarningUnknownChannel))
+	assert.Equal(t, 1, result.TransformResult.Count(WarningMissingThreadRoot))
+	assert.Equal(t, 1, result.TransformResult.Count(WarningAttachmentFailed))
+	assert.Equal(t, 1, result.TransformResult.Count(WarningPropsTooLarge))
+
+	var replies int
+	for _, post := range result.Intermediate.Posts {
+		if post.Message == "a thread spanning two days" {
+			replies = len(post.Replies)
+		}
+		assert.LessOrEqual(t, len([]rune(post.Message)), PosgreSQLMaxPostSize)
+	}
+	assert.Equal(t, 3, replies)
+
+	var buffer bytes.Buffer
+	require.NoError(t, result.ExportTo(&buffer))
+	assert.Contains(t, buffer.String(), "отчёт 2021.txt")
+}
+
+func TestWriteFixtureCases(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, WriteFixture(&buffer, []FixtureCase{FixtureThreads}))
+
+	result, err := TransformZip(context.Background(), bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Intermediate.UsersById, 2)
+	assert.Len(t, result.Intermediate.PublicChannels, 2)
+}
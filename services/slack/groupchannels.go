@@ -0,0 +1,81 @@
+package slack
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// GroupChannelPolicy converts the group messages into private channels
+// when they started more than MinAgeMonths months before the last day
+// of the export or hold more than MinMessages messages, as the long
+// running group messages are often de facto project channels. A zero
+// field is not checked.
+type GroupChannelPolicy struct {
+	MinAgeMonths int
+	MinMessages  int
+}
+
+func (p *GroupChannelPolicy) matches(t *Transformer, slackExport *SlackExport, channelName string, exportLastDay time.Time) (bool, error) {
+	if p.MinAgeMonths > 0 {
+		first, _, ok := activityRange(slackExport.PostFiles[channelName])
+		if ok && first.Before(exportLastDay.AddDate(0, -p.MinAgeMonths, 0)) {
+			return true, nil
+		}
+	}
+	if p.MinMessages <= 0 {
+		return false, nil
+	}
+	count, err := t.channelPostCount(slackExport, channelName)
+	if err != nil {
+		return false, err
+	}
+	return count > p.MinMessages, nil
+}
+
+// ConvertGroupChannels turns the group channels matching the policy into
+// private channels named after their members, which keep their
+// original name so their posts are imported in them.
+func (t *Transformer) ConvertGroupChannels(slackExport *SlackExport, policy *GroupChannelPolicy) error {
+	var exportLastDay time.Time
+	for _, postFiles := range slackExport.PostFiles {
+		if _, last, ok := activityRange(postFiles); ok && last.After(exportLastDay) {
+			exportLastDay = last
+		}
+	}
+
+	groupChannels := []*IntermediateChannel{}
+	converted := 0
+	for _, channel := range t.Intermediate.GroupChannels {
+		matches, err := policy.matches(t, slackExport, channel.OriginalName, exportLastDay)
+		if err != nil {
+			return err
+		}
+		if !matches {
+			groupChannels = append(groupChannels, channel)
+			continue
+		}
+
+		usernames := []string{}
+		for _, member := range channel.Members {
+			if user, ok := t.Intermediate.UsersById[member]; ok {
+				usernames = append(usernames, user.Username)
+			}
+		}
+		// mpdm-john--jane--bob-1 is named john--jane--bob
+		name := strings.TrimSuffix(strings.TrimPrefix(channel.OriginalName, "mpdm-"), "-1")
+		channel.Type = model.ChannelTypePrivate
+		channel.Name = t.channelName(channel.Id, SlackConvertChannelName(name, channel.Id), channel.Type)
+		channel.DisplayName = prefixedChannelName(t.ChannelPrefix, strings.Join(usernames, ", "), channel.Type)
+		channel.Sanitise(t.Logger)
+		t.Intermediate.PrivateChannels = append(t.Intermediate.PrivateChannels, channel)
+		converted++
+	}
+	t.Intermediate.GroupChannels = groupChannels
+
+	if converted > 0 {
+		t.Logger.Infof("Converted %d group channels into private channels", converted)
+	}
+	return nil
+}
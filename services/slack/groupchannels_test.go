@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func groupChannelsExportFS() fstest.MapFS {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john"},
+		{"id": "U2", "name": "jane"},
+		{"id": "U3", "name": "bob"}
+	]`)}
+	fsys["mpims.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "G1", "name": "mpdm-john--jane--bob-1", "members": ["U1", "U2", "U3"]},
+		{"id": "G2", "name": "mpdm-jane--bob--john-1", "members": ["U1", "U2", "U3"]},
+		{"id": "G3", "name": "mpdm-bob--john--jane-1", "members": ["U1", "U2", "U3"]}
+	]`)}
+	fsys["mpdm-john--jane--bob-1/2019-06-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "project kickoff", "ts": "1559347200.000100"}
+	]`)}
+	fsys["mpdm-jane--bob--john-1/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "one", "ts": "1577836800.000200"},
+		{"type": "message", "user": "U2", "text": "two", "ts": "1577836800.000300"},
+		{"type": "message", "user": "U3", "text": "three", "ts": "1577836800.000400"}
+	]`)}
+	fsys["mpdm-bob--john--jane-1/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U3", "text": "lunch?", "ts": "1577836800.000500"}
+	]`)}
+	return fsys
+}
+
+func TestTransformFSGroupChannelPolicy(t *testing.T) {
+	transform := func(policy *GroupChannelPolicy) *Result {
+		result, err := TransformFS(context.Background(), groupChannelsExportFS(), Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, GroupChannelPolicy: policy},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := transform(&GroupChannelPolicy{MinAgeMonths: 6, MinMessages: 2})
+	require.Len(t, result.Intermediate.GroupChannels, 1)
+	assert.Equal(t, "mpdm-bob--john--jane-1", result.Intermediate.GroupChannels[0].OriginalName)
+
+	private := map[string]*IntermediateChannel{}
+	for _, channel := range result.Intermediate.PrivateChannels {
+		private[channel.Name] = channel
+	}
+	require.Contains(t, private, "john--jane--bob")
+	require.Contains(t, private, "jane--bob--john")
+	assert.Equal(t, "john, jane, bob", private["john--jane--bob"].DisplayName)
+	assert.Equal(t, model.ChannelTypePrivate, private["john--jane--bob"].Type)
+
+	// the posts follow their channel, the members are set as for any
+	// private channel
+	for _, post := range result.Intermediate.Posts {
+		if post.Message == "project kickoff" {
+			assert.False(t, post.IsDirect)
+			assert.Equal(t, "john--jane--bob", post.Channel)
+		}
+		if post.Message == "lunch?" {
+			assert.True(t, post.IsDirect)
+		}
+	}
+	assert.Contains(t, result.Intermediate.UsersById["U2"].Memberships, "jane--bob--john")
+
+	assert.Len(t, transform(nil).Intermediate.GroupChannels, 3)
+}
@@ -0,0 +1,67 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SlackHuddleRoom is the huddle a huddle_thread message was started
+// for
+type SlackHuddleRoom struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	DateStart int64  `json:"date_start"`
+	DateEnd   int64  `json:"date_end"`
+	// ParticipantHistory are the users that joined the huddle
+	ParticipantHistory []string `json:"participant_history"`
+}
+
+// huddleMessage labels the text of a huddle thread with its
+// participants and duration
+func (t *Transformer) huddleMessage(post SlackPost) string {
+	lines := []string{"**Slack huddle**"}
+	if room := post.Room; room != nil {
+		participants := []string{}
+		for _, userId := range room.ParticipantHistory {
+			if user, ok := t.Intermediate.UsersById[userId]; ok {
+				participants = append(participants, "@"+user.Username)
+			}
+		}
+		if len(participants) > 0 {
+			lines = append(lines, "Participants: "+strings.Join(participants, ", "))
+		}
+		if room.DateStart > 0 && room.DateEnd > room.DateStart {
+			duration := time.Duration(room.DateEnd-room.DateStart) * time.Second
+			if minutes := int(duration.Round(time.Minute).Minutes()); minutes > 0 {
+				lines = append(lines, fmt.Sprintf("Duration: %d min", minutes))
+			} else {
+				lines = append(lines, "Duration: less than a minute")
+			}
+		}
+	}
+	if text := strings.TrimSpace(post.Text); text != "" {
+		lines = append(lines, "", "Transcript:", text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleHuddleThread imports the root message of the thread of a
+// huddle, with the transcript and the files shared in the huddle, so
+// the messages of the thread keep their root
+func handleHuddleThread(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	author := t.postAuthor(pc, post, post.User)
+	if author == nil {
+		return nil
+	}
+	newPost := &IntermediatePost{
+		User:     author.Username,
+		Channel:  pc.Channel.Name,
+		Message:  t.huddleMessage(post),
+		CreateAt: SlackConvertTimeStamp(post.TimeStamp),
+	}
+	if !t.addPostContent(pc, post, newPost) {
+		return nil
+	}
+	return newPost
+}
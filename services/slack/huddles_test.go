@@ -0,0 +1,42 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformFSHuddleThread(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "huddle_thread", "user": "U1", "text": "We agreed to ship on Friday.", "ts": "1577923200.000100", "thread_ts": "1577923200.000100",
+			"room": {"id": "R1", "date_start": 1577923200, "date_end": 1577924820, "participant_history": ["U1", "U2", "U9"]},
+			"files": [{"id": "F1", "name": "notes.txt"}]},
+		{"type": "message", "user": "U2", "text": "thanks", "ts": "1577923300.000100", "thread_ts": "1577923200.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{AttachmentsDir: t.TempDir()},
+	})
+	require.NoError(t, err)
+
+	var huddle *IntermediatePost
+	for _, post := range result.Intermediate.Posts {
+		if post.CreateAt == 1577923200000 {
+			huddle = post
+		}
+	}
+	require.NotNil(t, huddle)
+	assert.Equal(t, "john", huddle.User)
+	assert.Equal(t, "**Slack huddle**\nParticipants: @john, @jane\nDuration: 27 min\n\nTranscript:\nWe agreed to ship on Friday.", huddle.Message)
+	assert.Len(t, huddle.Attachments, 1)
+	require.Len(t, huddle.Replies, 1)
+	assert.Equal(t, "thanks", huddle.Replies[0].Message)
+	assert.Zero(t, result.TransformResult.Count(WarningUnsupportedPostType))
+}
@@ -0,0 +1,90 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// IDMapping maps the Slack ids of the users and channels to the
+// identifiers they are imported with, for the tools rewriting the
+// links to Slack once the migration is done.
+type IDMapping struct {
+	Team     string                    `json:"team"`
+	Users    map[string]UserMapping    `json:"users"`
+	Channels map[string]ChannelMapping `json:"channels"`
+}
+
+// UserMapping identifies an imported user.
+type UserMapping struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// ChannelMapping identifies an imported channel. The direct and group
+// channels have no name, they are identified by their members.
+type ChannelMapping struct {
+	Name        string            `json:"name,omitempty"`
+	DisplayName string            `json:"display_name,omitempty"`
+	Type        model.ChannelType `json:"type"`
+	Members     []string          `json:"members,omitempty"`
+}
+
+// IDMapping returns the mapping of the users and channels transformed
+// so far. The users left out of the import are not part of it.
+func (t *Transformer) IDMapping() *IDMapping {
+	mapping := &IDMapping{
+		Team:     t.TeamName,
+		Users:    make(map[string]UserMapping, len(t.Intermediate.UsersById)),
+		Channels: map[string]ChannelMapping{},
+	}
+
+	for id, user := range t.Intermediate.UsersById {
+		mapping.Users[id] = UserMapping{Username: user.Username, Email: user.Email}
+	}
+
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+		for _, channel := range channels {
+			mapping.Channels[channel.Id] = ChannelMapping{
+				Name:        channel.Name,
+				DisplayName: channel.DisplayName,
+				Type:        channel.Type,
+			}
+		}
+	}
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.GroupChannels, t.Intermediate.DirectChannels} {
+		for _, channel := range channels {
+			mapping.Channels[channel.Id] = ChannelMapping{
+				Type:    channel.Type,
+				Members: channel.MembersUsernames,
+			}
+		}
+	}
+
+	return mapping
+}
+
+// ParseIDMapping reads a mapping written by WriteIDMapping.
+func ParseIDMapping(data io.Reader) (*IDMapping, error) {
+	var mapping IDMapping
+	if err := json.NewDecoder(data).Decode(&mapping); err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+// WriteIDMapping writes the mapping to mappingPath as JSON.
+func WriteIDMapping(mappingPath string, mapping *IDMapping) error {
+	b, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the id mapping")
+	}
+
+	if err := os.WriteFile(mappingPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the id mapping %s", mappingPath)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDMapping(t *testing.T) {
+	fsys := testExportFS()
+	fsys["dms.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "D1", "name": "D1", "members": ["U1", "U2"]}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		ChannelPrefix:   "acme",
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	mapping := result.IDMapping()
+	assert.Equal(t, "team", mapping.Team)
+	assert.Equal(t, "john", mapping.Users["U1"].Username)
+	assert.Equal(t, "jane", mapping.Users["U2"].Username)
+	assert.Equal(t, ChannelMapping{Name: "acme-general", DisplayName: "acme-general", Type: model.ChannelTypeOpen}, mapping.Channels["C1"])
+	assert.Equal(t, model.ChannelTypeDirect, mapping.Channels["D1"].Type)
+	assert.ElementsMatch(t, []string{"john", "jane"}, mapping.Channels["D1"].Members)
+
+	mappingPath := filepath.Join(t.TempDir(), "mapping.json")
+	require.NoError(t, WriteIDMapping(mappingPath, mapping))
+	b, err := os.ReadFile(mappingPath)
+	require.NoError(t, err)
+
+	var written IDMapping
+	require.NoError(t, json.Unmarshal(b, &written))
+	assert.Equal(t, *mapping, written)
+}
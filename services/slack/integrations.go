@@ -0,0 +1,147 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+type integrationConverter struct {
+	// botNames are lowercased substrings matched against the name of
+	// the bot that posted the message
+	botNames []string
+	convert  func(attachment *model.SlackAttachment) string
+}
+
+var integrationConverters = []integrationConverter{
+	// GitHub commits and pull requests
+	{
+		botNames: []string{"github"},
+		convert: func(attachment *model.SlackAttachment) string {
+			return compactAttachment(attachment, "", false)
+		},
+	},
+	// Jira issue updates
+	{
+		botNames: []string{"jira"},
+		convert: func(attachment *model.SlackAttachment) string {
+			return compactAttachment(attachment, "", true)
+		},
+	},
+	// CI notifications
+	{
+		botNames: []string{"jenkins", "circleci", "gitlab", "travis", "buildkite", "teamcity"},
+		convert: func(attachment *model.SlackAttachment) string {
+			return compactAttachment(attachment, ciStatusEmoji(attachment.Color), true)
+		},
+	},
+}
+
+func ciStatusEmoji(color string) string {
+	switch color {
+	case "good", "#36a64f":
+		return ":white_check_mark:"
+	case "danger", "#d00000":
+		return ":x:"
+	case "warning":
+		return ":warning:"
+	default:
+		return ""
+	}
+}
+
+func (p *SlackPost) botName() string {
+	if p.BotProfile != nil && p.BotProfile.Name != "" {
+		return strings.ToLower(p.BotProfile.Name)
+	}
+	return strings.ToLower(p.BotUsername)
+}
+
+// compactAttachment renders an attachment as a few lines of Slack
+// mrkdwn, with the linked title first and, if inlineFields is set, all
+// the fields in a single line.
+func compactAttachment(attachment *model.SlackAttachment, prefix string, inlineFields bool) string {
+	lines := []string{}
+	if attachment.Pretext != "" {
+		lines = append(lines, attachment.Pretext)
+	}
+
+	title := attachment.Title
+	if title != "" && attachment.TitleLink != "" {
+		title = fmt.Sprintf("<%s|%s>", attachment.TitleLink, title)
+	}
+	if title != "" {
+		title = "*" + title + "*"
+	}
+	if prefix != "" {
+		title = strings.TrimSpace(prefix + " " + title)
+	}
+	if title != "" {
+		lines = append(lines, title)
+	}
+
+	if attachment.Text != "" {
+		lines = append(lines, attachment.Text)
+	}
+
+	fields := []string{}
+	for _, field := range attachment.Fields {
+		value := strings.TrimSpace(fmt.Sprintf("%v", field.Value))
+		if value == "" {
+			continue
+		}
+		if field.Title == "" {
+			fields = append(fields, value)
+		} else {
+			fields = append(fields, fmt.Sprintf("*%s:* %s", field.Title, value))
+		}
+	}
+	if len(fields) > 0 {
+		if inlineFields {
+			lines = append(lines, strings.Join(fields, " · "))
+		} else {
+			lines = append(lines, fields...)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// PrettifyIntegrationPost replaces the attachments of a message posted
+// by a known integration with a compact Markdown rendering appended to
+// the post text. It returns false if the post was left untouched.
+func PrettifyIntegrationPost(post SlackPost) (SlackPost, bool) {
+	if len(post.Attachments) == 0 {
+		return post, false
+	}
+
+	name := post.botName()
+	if name == "" {
+		return post, false
+	}
+
+	for _, converter := range integrationConverters {
+		for _, botName := range converter.botNames {
+			if !strings.Contains(name, botName) {
+				continue
+			}
+
+			parts := []string{}
+			if text := strings.TrimSpace(post.Text); text != "" {
+				parts = append(parts, text)
+			}
+			for _, attachment := range post.Attachments {
+				if rendered := converter.convert(attachment); rendered != "" {
+					parts = append(parts, SlackConvertMarkup(rendered))
+				}
+			}
+
+			post.Text = strings.Join(parts, "\n\n")
+			post.Attachments = nil
+			return post, true
+		}
+	}
+
+	return post, false
+}
@@ -0,0 +1,57 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettifyIntegrationPost(t *testing.T) {
+	t.Run("Jira updates render fields inline", func(t *testing.T) {
+		post := SlackPost{
+			BotUsername: "Jira Cloud",
+			Attachments: []*model.SlackAttachment{
+				{
+					Title:     "PROJ-1: Fix login",
+					TitleLink: "https://jira.example.com/browse/PROJ-1",
+					Fields: []*model.SlackAttachmentField{
+						{Title: "Status", Value: "Done"},
+						{Title: "Assignee", Value: "john"},
+					},
+				},
+			},
+		}
+
+		result, ok := PrettifyIntegrationPost(post)
+		assert.True(t, ok)
+		assert.Nil(t, result.Attachments)
+		assert.Equal(t, "**[PROJ-1: Fix login](https://jira.example.com/browse/PROJ-1)**\n**Status:** Done · **Assignee:** john", result.Text)
+	})
+
+	t.Run("CI notifications get a status prefix", func(t *testing.T) {
+		post := SlackPost{
+			Text:       "Build finished",
+			BotProfile: &SlackBotProfile{Name: "CircleCI"},
+			Attachments: []*model.SlackAttachment{
+				{Color: "danger", Title: "main #42", Text: "<https://ci.example.com/42|Details>"},
+			},
+		}
+
+		result, ok := PrettifyIntegrationPost(post)
+		assert.True(t, ok)
+		assert.Equal(t, "Build finished\n\n:x: **main #42**\n[Details](https://ci.example.com/42)", result.Text)
+	})
+
+	t.Run("Unknown integrations are left untouched", func(t *testing.T) {
+		post := SlackPost{
+			BotUsername: "deploybot",
+			Text:        "deployed",
+			Attachments: []*model.SlackAttachment{{Title: "v1.0"}},
+		}
+
+		result, ok := PrettifyIntegrationPost(post)
+		assert.False(t, ok)
+		assert.Equal(t, post, result)
+	})
+}
@@ -1,20 +1,18 @@
 package slack
 
 import (
-	"archive/zip"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
-	"os"
-	"path"
+	"io/fs"
 	"sort"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/v6/model"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/text/unicode/norm"
 )
 
 type IntermediateChannel struct {
@@ -28,6 +26,25 @@ type IntermediateChannel struct {
 	Header           string            `json:"header"`
 	Topic            string            `json:"topic"`
 	Type             model.ChannelType `json:"type"`
+	Creator          string            `json:"creator"`
+	// PinnedTimestamps are the timestamps of the messages pinned to
+	// the channel
+	PinnedTimestamps []string `json:"pinned_timestamps"`
+	// Archived channels are to be archived after the import, see
+	// ArchiveChannels
+	Archived        bool `json:"archived"`
+	archivedInSlack bool
+	// Team is the team of the public and private channels spread over
+	// several teams by the TeamMapping, empty for the team of the
+	// transformation
+	Team string `json:"team"`
+	// Hidden direct and group channels are not shown in the sidebar
+	Hidden bool `json:"hidden"`
+	// FavoritedBy holds the usernames of the members that starred a
+	// direct or group channel
+	FavoritedBy []string `json:"favorited_by"`
+	// NotifyProps are set on the memberships of every member
+	NotifyProps *ChannelNotifyProps `json:"notify_props"`
 }
 
 const WorkflowUserName = "imported-workflow"
@@ -55,7 +72,7 @@ func (c *IntermediateChannel) Sanitise(logger log.FieldLogger) {
 	c.DisplayName = strings.Trim(c.DisplayName, "_-")
 	if utf8.RuneCountInString(c.DisplayName) > model.ChannelDisplayNameMaxRunes {
 		logger.Warnf("Channel %s display name exceeds the maximum length. It will be truncated when imported.", c.DisplayName)
-		c.DisplayName = truncateRunes(c.DisplayName, model.ChannelDisplayNameMaxRunes)
+		c.DisplayName = truncateAtWord(c.DisplayName, model.ChannelDisplayNameMaxRunes)
 	}
 	if len(c.DisplayName) == 1 {
 		c.DisplayName = "slack-channel-" + c.DisplayName
@@ -63,12 +80,12 @@ func (c *IntermediateChannel) Sanitise(logger log.FieldLogger) {
 
 	if utf8.RuneCountInString(c.Purpose) > model.ChannelPurposeMaxRunes {
 		logger.Warnf("Channel %s purpose exceeds the maximum length. It will be truncated when imported.", c.DisplayName)
-		c.Purpose = truncateRunes(c.Purpose, model.ChannelPurposeMaxRunes)
+		c.Purpose = truncateAtWord(c.Purpose, model.ChannelPurposeMaxRunes)
 	}
 
 	if utf8.RuneCountInString(c.Header) > model.ChannelHeaderMaxRunes {
 		logger.Warnf("Channel %s header exceeds the maximum length. It will be truncated when imported.", c.DisplayName)
-		c.Header = truncateRunes(c.Header, model.ChannelHeaderMaxRunes)
+		c.Header = truncateAtWord(c.Header, model.ChannelHeaderMaxRunes)
 	}
 }
 
@@ -83,6 +100,20 @@ type IntermediateUser struct {
 	Memberships []string `json:"memberships"`
 	AuthData    *string  `json:"auth_data"`
 	AuthService string   `json:"auth_service"`
+	IsGuest     bool     `json:"is_guest"`
+	IsTeamAdmin bool     `json:"is_team_admin"`
+	// AdminMemberships holds the channels of Memberships the user
+	// administers
+	AdminMemberships []string `json:"admin_memberships"`
+	// FavoriteChannels holds the channels of Memberships the user
+	// starred
+	FavoriteChannels []string `json:"favorite_channels"`
+	// ProfileImage is the path of the avatar downloaded by
+	// DownloadAvatars
+	ProfileImage string `json:"profile_image"`
+	// CustomStatus is the status of the Slack profile, set by
+	// TransformCustomStatuses
+	CustomStatus *model.CustomStatus `json:"custom_status"`
 }
 
 func (u *IntermediateUser) Sanitise(logger log.FieldLogger) {
@@ -93,17 +124,17 @@ func (u *IntermediateUser) Sanitise(logger log.FieldLogger) {
 
 	if utf8.RuneCountInString(u.Position) > model.UserPositionMaxRunes {
 		logger.Warnf("User %s position %s is too long. Field will be truncated", u.Username, u.Position)
-		u.Position = u.Position[0:model.UserPositionMaxRunes]
+		u.Position = truncateAtWord(u.Position, model.UserPositionMaxRunes)
 	}
 
 	if utf8.RuneCountInString(u.FirstName) > model.UserFirstNameMaxRunes {
 		logger.Warnf("User %s first name %s is too long. Field will be truncated", u.Username, u.FirstName)
-		u.FirstName = u.FirstName[0:model.UserFirstNameMaxRunes]
+		u.FirstName = truncateRunes(u.FirstName, model.UserFirstNameMaxRunes)
 	}
 
 	if utf8.RuneCountInString(u.LastName) > model.UserLastNameMaxRunes {
 		logger.Warnf("User %s last name %s is too long. Field will be truncated", u.Username, u.LastName)
-		u.LastName = u.LastName[0:model.UserLastNameMaxRunes]
+		u.LastName = truncateRunes(u.LastName, model.UserLastNameMaxRunes)
 	}
 }
 
@@ -113,26 +144,51 @@ type IntermediatePost struct {
 	Message  string                `json:"message"`
 	Props    model.StringInterface `json:"props"`
 	CreateAt int64                 `json:"create_at"`
+	EditAt   int64                 `json:"edit_at"`
 	// Type           string              `json:"type"`
-	Attachments    []string            `json:"attachments"`
-	Replies        []*IntermediatePost `json:"replies"`
-	IsDirect       bool                `json:"is_direct"`
-	ChannelMembers []string            `json:"channel_members"`
+	Attachments    []string                `json:"attachments"`
+	Replies        []*IntermediatePost     `json:"replies"`
+	Reactions      []*IntermediateReaction `json:"reactions"`
+	IsDirect       bool                    `json:"is_direct"`
+	ChannelMembers []string                `json:"channel_members"`
+	IsPinned       bool                    `json:"is_pinned"`
 }
 
 func (s *IntermediatePost) Sanitise() {
 	if utf8.RuneCountInString(s.Message) > PosgreSQLMaxPostSize {
-		s.Message = string([]rune(s.Message)[:PosgreSQLMaxPostSize])
+		s.Message = truncateRunes(s.Message, PosgreSQLMaxPostSize)
+	}
+}
+
+type IntermediateTeam struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+}
+
+// NewIntermediateTeam returns an invite only team, using the name as
+// display name when none is given.
+func NewIntermediateTeam(name, displayName string) *IntermediateTeam {
+	if displayName == "" {
+		displayName = name
+	}
+	return &IntermediateTeam{
+		Name:        name,
+		DisplayName: displayName,
+		Type:        model.TeamInvite,
 	}
 }
 
 type Intermediate struct {
+	// Team is only set when the output defines the team itself
+	Team            *IntermediateTeam            `json:"team"`
 	PublicChannels  []*IntermediateChannel       `json:"public_channels"`
 	PrivateChannels []*IntermediateChannel       `json:"private_channels"`
 	GroupChannels   []*IntermediateChannel       `json:"group_channels"`
 	DirectChannels  []*IntermediateChannel       `json:"direct_channels"`
 	UsersById       map[string]*IntermediateUser `json:"users"`
 	Posts           []*IntermediatePost          `json:"posts"`
+	Emojis          []*IntermediateEmoji         `json:"emojis"`
 }
 
 func (t *Transformer) TransformUsers(users []SlackUser, authDataAsEmail bool, authService string) {
@@ -145,9 +201,12 @@ func (t *Transformer) TransformUsers(users []SlackUser, authDataAsEmail bool, au
 			Username:  user.Username,
 			FirstName: user.Profile.FirstName,
 			LastName:  user.Profile.LastName,
-			Position:  user.Profile.Title,
+			Position:  t.PositionSource.position(user.Profile),
 			Email:     user.Profile.Email,
+			IsGuest:   user.IsGuest(),
 		}
+		newUser.IsTeamAdmin = !newUser.IsGuest && (user.IsAdmin || user.IsOwner)
+		t.mapUser(newUser)
 
 		newUser.Sanitise(t.Logger)
 
@@ -187,7 +246,12 @@ func (t *Transformer) TransformChannels(channels []SlackChannel) []*Intermediate
 	for _, channel := range channels {
 		validMembers := filterValidMembers(channel.Members, t.Intermediate.UsersById)
 		if (channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup) && len(validMembers) <= 1 {
-			t.Logger.Warnf("Bulk export for direct channels containing a single member is not supported. Not importing channel %s", channel.Name)
+			t.warn(&Warning{
+				Kind:    WarningSingleMemberChannel,
+				Skipped: true,
+				Channel: getOriginalName(channel),
+				Message: fmt.Sprintf("Bulk export for direct channels containing a single member is not supported. Not importing channel %s", channel.Name),
+			})
 			continue
 		}
 
@@ -196,15 +260,27 @@ func (t *Transformer) TransformChannels(channels []SlackChannel) []*Intermediate
 			channel.Type = model.ChannelTypePrivate
 		}
 
-		name := SlackConvertChannelName(channel.Name, channel.Id)
+		name := t.channelName(channel.Id, SlackConvertChannelName(channel.Name, channel.Id), channel.Type)
 		newChannel := &IntermediateChannel{
+			Id:           channel.Id,
 			OriginalName: getOriginalName(channel),
 			Name:         name,
-			DisplayName:  getOriginalName(channel),
+			DisplayName:  prefixedChannelName(t.ChannelPrefix, getOriginalName(channel), channel.Type),
 			Members:      validMembers,
 			Purpose:      channel.Purpose.Value,
-			Header:       channel.Topic.Value,
+			Header:       t.ChannelHeader.channelHeader(channel.Topic.Value, channel.Purpose.Value),
 			Type:         channel.Type,
+			Creator:      channel.Creator,
+
+			archivedInSlack: channel.IsArchived,
+		}
+		if channel.Type == model.ChannelTypeOpen || channel.Type == model.ChannelTypePrivate {
+			newChannel.Team = t.TeamMapping.team(channel)
+		}
+		for _, pin := range channel.Pins {
+			if pin.Type == slackPinTypeMessage {
+				newChannel.PinnedTimestamps = append(newChannel.PinnedTimestamps, pin.Id)
+			}
 		}
 
 		newChannel.Sanitise(t.Logger)
@@ -219,23 +295,23 @@ func (t *Transformer) PopulateUserMemberships() {
 
 	for userId, user := range t.Intermediate.UsersById {
 		memberships := []string{}
-		for _, channel := range t.Intermediate.PublicChannels {
-			for _, memberId := range channel.Members {
-				if userId == memberId {
-					memberships = append(memberships, channel.Name)
-					break
-				}
-			}
-		}
-		for _, channel := range t.Intermediate.PrivateChannels {
-			for _, memberId := range channel.Members {
-				if userId == memberId {
-					memberships = append(memberships, channel.Name)
-					break
+		adminMemberships := []string{}
+		for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+			for _, channel := range channels {
+				for _, memberId := range channel.Members {
+					if userId == memberId {
+						memberships = append(memberships, channel.Name)
+						// guests can't administer channels
+						if channel.Creator == userId && !user.IsGuest {
+							adminMemberships = append(adminMemberships, channel.Name)
+						}
+						break
+					}
 				}
 			}
 		}
 		user.Memberships = memberships
+		user.AdminMemberships = adminMemberships
 	}
 }
 
@@ -286,7 +362,40 @@ func (t *Transformer) TransformAllChannels(slackExport *SlackExport) error {
 	return nil
 }
 
-func AddPostToThreads(original SlackPost, post *IntermediatePost, threads ThreadsStorage, channel *IntermediateChannel, timestamps map[int64]bool, importWorkflowPosts bool) {
+// PopulateFavoriteChannels marks the channels starred by each user as
+// favorites.
+func (t *Transformer) PopulateFavoriteChannels(stars map[string][]SlackStar) {
+	t.Logger.Info("Populating favorite channels")
+
+	channelsById := map[string]*IntermediateChannel{}
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels, t.Intermediate.GroupChannels, t.Intermediate.DirectChannels} {
+		for _, channel := range channels {
+			channelsById[channel.Id] = channel
+		}
+	}
+
+	for userId, userStars := range stars {
+		user, ok := t.Intermediate.UsersById[userId]
+		if !ok {
+			continue
+		}
+
+		for _, star := range userStars {
+			channel, ok := channelsById[star.Channel]
+			if star.Channel == "" || !ok {
+				continue
+			}
+
+			if channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup {
+				channel.FavoritedBy = append(channel.FavoritedBy, user.Username)
+			} else {
+				user.FavoriteChannels = append(user.FavoriteChannels, channel.Name)
+			}
+		}
+	}
+}
+
+func (t *Transformer) AddPostToThreads(original SlackPost, post *IntermediatePost, threads ThreadsStorage, channel *IntermediateChannel, timestamps map[int64]bool, importWorkflowPosts bool) {
 	// direct and group posts need the channel members in the import line
 	if channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup {
 		post.IsDirect = true
@@ -305,11 +414,21 @@ func AddPostToThreads(original SlackPost, post *IntermediatePost, threads Thread
 	}
 	timestamps[post.CreateAt] = true
 
+	if original.legalHold != nil {
+		if original.legalHold.Kind == legalHoldEdited {
+			post.EditAt = t.shiftTimestamp(SlackConvertTimeStamp(original.legalHold.TimeStamp))
+		}
+		if post.Props == nil {
+			post.Props = model.StringInterface{}
+		}
+		post.Props["legal_hold"] = original.legalHold
+	}
+
 	// if post is part of a thread
 	if original.ThreadTS != "" && original.ThreadTS != original.TimeStamp {
 		rootPost := threads.LookupThread(original.ThreadTS)
 		if rootPost == nil {
-			log.Printf("ERROR processing post in thread, couldn't find rootPost: %+v\n", original)
+			t.warnPostf(WarningMissingThreadRoot, channel.OriginalName, original, true, nil, "Unable to import the reply as the root post of its thread is missing. thread_ts=%s", original.ThreadTS)
 			return
 		}
 		if !importWorkflowPosts && rootPost.User == WorkflowUserName {
@@ -323,19 +442,22 @@ func AddPostToThreads(original SlackPost, post *IntermediatePost, threads Thread
 	// if post is the root of a thread
 	if original.TimeStamp == original.ThreadTS {
 		if threads.HasThread(original.ThreadTS) {
-			log.Println("WARNING: overwriting root post for thread " + original.ThreadTS)
+			t.warnPostf(WarningDuplicateThreadRoot, channel.OriginalName, original, false, nil, "Replacing the root post of the thread with a later post of the same timestamp. thread_ts=%s", original.ThreadTS)
 		}
 		threads.StoreThread(original.ThreadTS, post)
 		return
 	}
 
 	if threads.HasThread(original.TimeStamp) {
-		log.Println("WARNING: overwriting root post for thread " + original.TimeStamp)
+		t.warnPostf(WarningDuplicateThreadRoot, channel.OriginalName, original, false, nil, "Replacing the root post of the thread with a later post of the same timestamp. thread_ts=%s", original.TimeStamp)
 	}
 	threads.StoreThread(original.TimeStamp, post)
 }
 
-func buildChannelsByOriginalNameMap(intermediate *Intermediate) map[string]*IntermediateChannel {
+// buildChannelsByOriginalNameMap indexes the channels by original
+// name, and by their previous names when they are not used by another
+// channel.
+func buildChannelsByOriginalNameMap(intermediate *Intermediate, renames map[string]string) map[string]*IntermediateChannel {
 	channelsByName := map[string]*IntermediateChannel{}
 	for _, channel := range intermediate.PublicChannels {
 		channelsByName[channel.OriginalName] = channel
@@ -349,57 +471,85 @@ func buildChannelsByOriginalNameMap(intermediate *Intermediate) map[string]*Inte
 	for _, channel := range intermediate.DirectChannels {
 		channelsByName[channel.OriginalName] = channel
 	}
+	for oldName := range renames {
+		if _, ok := channelsByName[oldName]; ok {
+			continue
+		}
+		if channel, ok := channelsByName[resolveChannelRename(renames, oldName)]; ok {
+			channelsByName[oldName] = channel
+		}
+	}
 	return channelsByName
 }
 
-func getNormalisedFilePath(file *SlackFile, attachmentsDir string) string {
-	filePath := path.Join(attachmentsDir, fmt.Sprintf("%s_%s", file.Id, file.Name))
-	return string(norm.NFC.Bytes([]byte(filePath)))
-}
+func (t *Transformer) addFileToPost(ctx context.Context, file *SlackFile, slackExport *SlackExport, post *IntermediatePost, attachmentsDir string, layout AttachmentsLayout, scanner *AttachmentScanner, retries int, keepFailed bool) error {
+	start := time.Now()
+	defer func() {
+		t.attachmentCopies++
+		t.attachmentsElapsed += time.Since(start)
+	}()
 
-func addFileToPost(file *SlackFile, uploads map[string]*zip.File, post *IntermediatePost, attachmentsDir string) error {
-	zipFile, ok := uploads[file.Id]
+	uploadPath, ok := slackExport.Uploads[file.Id]
 	if !ok {
 		return errors.Errorf("failed to retrieve file with id %s", file.Id)
 	}
 
-	zipFileReader, err := zipFile.Open()
-	if err != nil {
-		return errors.Wrapf(err, "failed to open attachment from zipfile for id %s", file.Id)
-	}
-	defer zipFileReader.Close()
-
-	destFilePath := getNormalisedFilePath(file, attachmentsDir)
-	destFile, err := os.Create(destFilePath)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create file %s in the attachments directory", file.Id)
+	destFilePath := getNormalisedFilePath(file, attachmentsDir, layout, post.Channel)
+	if err := copyUploadWithRetries(ctx, slackExport.FS, uploadPath, destFilePath, retries); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return errors.Wrapf(err, "failed to open attachment from the export for id %s", file.Id)
+		}
+		t.failedAttachments = append(t.failedAttachments, FailedAttachment{
+			FileId:     file.Id,
+			Channel:    post.Channel,
+			UploadPath: uploadPath,
+			DestPath:   destFilePath,
+			Error:      err.Error(),
+			Kept:       keepFailed,
+		})
+		// the post keeps the attachment only when it is copied again
+		// before the import, the archives and manifests needing the
+		// file when they are written
+		if keepFailed {
+			post.Attachments = append(post.Attachments, destFilePath)
+		}
+		return errors.Wrapf(err, "failed to create file %s in the attachments directory after %d retries", file.Id, retries)
 	}
-	defer destFile.Close()
 
-	_, err = io.Copy(destFile, zipFileReader)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create file %s in the attachments directory", file.Id)
+	if scanner != nil {
+		if err := scanner.scan(file, post.Channel, destFilePath); err != nil {
+			return err
+		}
 	}
 
-	log.Printf("SUCCESS COPYING FILE %s TO DEST %s", file.Id, destFilePath)
+	t.Logger.Debugf("SUCCESS COPYING FILE %s TO DEST %s", file.Id, destFilePath)
 
 	post.Attachments = append(post.Attachments, destFilePath)
 
 	return nil
 }
 
-func (t *Transformer) newChannelThreadsStorage(channelName, attachmentsDir string, redisConfig *RedisConfig) (ThreadsStorage, error) {
+// newChannelThreadsStorage creates the threads storage of a channel,
+// counting its work in the ThreadsStats of the transformer
+func (t *Transformer) newChannelThreadsStorage(channelName, attachmentsDir string, redisConfig *RedisConfig, cacheSize int) (ThreadsStorage, error) {
 	if redisConfig == nil {
-		return newMemoryStorage(), nil
+		if cacheSize <= 0 {
+			return &countingStorage{ThreadsStorage: newMemoryStorage(), stats: &t.threadsStats}, nil
+		}
+		spill, err := newFileSpill()
+		if err != nil {
+			return nil, err
+		}
+		return &countingStorage{ThreadsStorage: newLRUStorage(cacheSize, spill, &t.threadsStats), stats: &t.threadsStats}, nil
 	}
 	if t.redisFactory == nil {
-		factory, err := newRedisFactory(redisConfig)
+		factory, err := newRedisFactory(redisConfig, &t.threadsStats)
 		if err != nil {
 			return nil, err
 		}
 		t.redisFactory = factory
 	}
-	return t.redisFactory.newRedisStorage(channelName, attachmentsDir), nil
+	return &countingStorage{ThreadsStorage: t.redisFactory.newRedisStorage(channelName, attachmentsDir, cacheSize), stats: &t.threadsStats}, nil
 }
 
 func (t *Transformer) selectOrCreateWorkflowUser(post SlackPost) *IntermediateUser {
@@ -422,241 +572,120 @@ func (t *Transformer) selectOrCreateWorkflowUser(post SlackPost) *IntermediateUs
 	return newUser
 }
 
-func (t *Transformer) TransformPosts(cfg *TransformConfig, slackExport *SlackExport) error {
+// transformChannelPosts converts the posts of a single channel,
+// assembling the threads and copying the attachments of the posts.
+func (t *Transformer) transformChannelPosts(ctx context.Context, cfg *TransformConfig, slackExport *SlackExport, channel *IntermediateChannel, originalChannelName string, channelPosts []SlackPost) ([]*IntermediatePost, error) {
+	timestamps := make(map[int64]bool)
+	if cfg.LegalHold {
+		channelPosts = applyLegalHold(channelPosts)
+	}
+	sort.Slice(channelPosts, func(i, j int) bool {
+		return SlackConvertTimeStamp(channelPosts[i].TimeStamp) < SlackConvertTimeStamp(channelPosts[j].TimeStamp)
+	})
+	if cfg.BotChannelPolicy != nil && cfg.BotChannelPolicy.KeepEvery > 0 && t.botChannels[originalChannelName] {
+		channelPosts = t.downsampleBotPosts(originalChannelName, channelPosts, cfg.BotChannelPolicy.KeepEvery)
+	}
+	threads, err := t.newChannelThreadsStorage(originalChannelName, cfg.AttachmentsDir, cfg.RedisConfig, cfg.ThreadsCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := threads.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	pc := &PostContext{
+		ctx:                 ctx,
+		Config:              cfg,
+		SlackExport:         slackExport,
+		Channel:             channel,
+		OriginalChannelName: originalChannelName,
+	}
+	for _, post := range channelPosts {
+		if cfg.PrettifyIntegrations {
+			post, _ = PrettifyIntegrationPost(post)
+		}
+
+		newPost := t.transformMessage(pc, post)
+		if newPost == nil || !t.shiftPost(originalChannelName, post, newPost) {
+			continue
+		}
+		if cfg.ReplaceRules != nil {
+			newPost.Message = cfg.ReplaceRules.apply(newPost.Message)
+		}
+		if cfg.ThreadParticipantProps {
+			t.addThreadParticipantProps(pc, post, newPost)
+		}
+		newPost.Reactions = t.transformReactions(pc, post)
+		if isPinned(channel, post) {
+			t.pinPost(channel, newPost)
+		}
+		if cfg.ThreadFileComments {
+			post = pc.threadFileComment(post, threads)
+			pc.recordSharedFiles(post)
+		}
+		t.AddPostToThreads(post, newPost, threads, channel, timestamps, cfg.ImportWorkflowMessages)
+	}
+
+	if reminder, summary := t.reminderSummary(pc); summary != nil && t.shiftPost(originalChannelName, reminder, summary) {
+		t.AddPostToThreads(reminder, summary, threads, channel, timestamps, cfg.ImportWorkflowMessages)
+	}
+
+	return threads.GetChangedThreads(), nil
+}
+
+func (t *Transformer) TransformPosts(ctx context.Context, cfg *TransformConfig, slackExport *SlackExport) error {
 	t.Logger.Info("Transforming posts")
+	finishStage := t.startStage(StagePosts)
 
 	newGroupChannels := []*IntermediateChannel{}
 	newDirectChannels := []*IntermediateChannel{}
-	channelsByOriginalName := buildChannelsByOriginalNameMap(t.Intermediate)
+	channelsByOriginalName := buildChannelsByOriginalNameMap(t.Intermediate, slackExport.ChannelRenames)
 
 	resultPosts := []*IntermediatePost{}
+	interrupted := false
 	for originalChannelName, channelPosts := range slackExport.Posts {
+		// the channel in progress is always finished, so the posts
+		// transformed so far can be exported when interrupted
+		if ctx.Err() != nil {
+			t.Logger.Warnf("Transformation interrupted after processing the posts of %d channels", len(t.completedChannels))
+			interrupted = true
+			break
+		}
+		t.countSourcePosts(originalChannelName, len(channelPosts))
+
 		channel, ok := channelsByOriginalName[originalChannelName]
 		if !ok {
-			t.Logger.Warnf("--- Couldn't find channel %s referenced by posts", originalChannelName)
+			t.warnUnknownChannel(originalChannelName)
 			continue
 		}
 
-		timestamps := make(map[int64]bool)
-		sort.Slice(channelPosts, func(i, j int) bool {
-			return SlackConvertTimeStamp(channelPosts[i].TimeStamp) < SlackConvertTimeStamp(channelPosts[j].TimeStamp)
-		})
-		threads, err := t.newChannelThreadsStorage(originalChannelName, cfg.AttachmentsDir, cfg.RedisConfig)
+		start := time.Now()
+		posts, err := t.transformChannelPosts(ctx, cfg, slackExport, channel, originalChannelName, channelPosts)
 		if err != nil {
 			return err
 		}
-
-		for _, post := range channelPosts {
-			switch {
-			// plain message that can have files attached
-			case post.IsPlainMessage():
-				if post.User == "" {
-					t.Logger.Warn("Unable to import the message as the user field is missing.")
-					continue
-				}
-				author := t.Intermediate.UsersById[post.User]
-				if author == nil {
-					t.Logger.Warnf("Unable to add the message as the Slack user does not exist in Mattermost. user=%s", post.User)
-					continue
-				}
-				newPost := &IntermediatePost{
-					User:     author.Username,
-					Channel:  channel.Name,
-					Message:  post.Text,
-					CreateAt: SlackConvertTimeStamp(post.TimeStamp),
-				}
-				if (post.File != nil || post.Files != nil) && !cfg.SkipAttachments {
-					if post.File != nil {
-						err := addFileToPost(post.File, slackExport.Uploads, newPost, cfg.AttachmentsDir)
-						if err != nil {
-							t.Logger.WithError(err).Error("Failed to add file to post")
-						}
-					} else if post.Files != nil {
-						for _, file := range post.Files {
-							err := addFileToPost(file, slackExport.Uploads, newPost, cfg.AttachmentsDir)
-							if err != nil {
-								t.Logger.WithError(err).Error("Failed to add file to post")
-							}
-						}
-					}
-				}
-
-				if len(post.Attachments) > 0 {
-					props := model.StringInterface{"attachments": post.Attachments}
-					propsB, _ := json.Marshal(props)
-
-					if utf8.RuneCountInString(string(propsB)) <= model.PostPropsMaxRunes {
-						newPost.Props = props
-					} else {
-						if cfg.DiscardInvalidProps {
-							t.Logger.Warn("Unable import post as props exceed the maximum character count. Skipping as --discard-invalid-props is enabled.")
-							continue
-						} else {
-							t.Logger.Warn("Unable to add props to post as they exceed the maximum character count.")
-						}
-					}
-				}
-
-				AddPostToThreads(post, newPost, threads, channel, timestamps, cfg.ImportWorkflowMessages)
-
-			// file comment
-			case post.IsFileComment():
-				if post.Comment == nil {
-					t.Logger.Warn("Unable to import the message as it has no comments.")
-					continue
-				}
-				if post.Comment.User == "" {
-					t.Logger.Warn("Unable to import the message as the user field is missing.")
-					continue
-				}
-				author := t.Intermediate.UsersById[post.Comment.User]
-				if author == nil {
-					t.Logger.Warnf("Unable to add the message as the Slack user does not exist in Mattermost. user=%s", post.Comment.User)
-					continue
-				}
-				newPost := &IntermediatePost{
-					User:     author.Username,
-					Channel:  channel.Name,
-					Message:  post.Comment.Comment,
-					CreateAt: SlackConvertTimeStamp(post.TimeStamp),
-				}
-
-				AddPostToThreads(post, newPost, threads, channel, timestamps, cfg.ImportWorkflowMessages)
-
-			// bot message
-			case post.IsBotMessage():
-				if !cfg.ImportWorkflowMessages {
-					continue
-				}
-				author := t.selectOrCreateWorkflowUser(post)
-				newPost := &IntermediatePost{
-					User:     author.Username,
-					Channel:  channel.Name,
-					Message:  post.Text,
-					CreateAt: SlackConvertTimeStamp(post.TimeStamp),
-				}
-				if (post.File != nil || post.Files != nil) && !cfg.SkipAttachments {
-					if post.File != nil {
-						err := addFileToPost(post.File, slackExport.Uploads, newPost, cfg.AttachmentsDir)
-						if err != nil {
-							t.Logger.WithError(err).Error("Failed to add file to post")
-						}
-					} else if post.Files != nil {
-						for _, file := range post.Files {
-							err := addFileToPost(file, slackExport.Uploads, newPost, cfg.AttachmentsDir)
-							if err != nil {
-								t.Logger.WithError(err).Error("Failed to add file to post")
-							}
-						}
-					}
-				}
-
-				if len(post.Attachments) > 0 {
-					props := model.StringInterface{"attachments": post.Attachments}
-					propsB, _ := json.Marshal(props)
-
-					if utf8.RuneCountInString(string(propsB)) <= model.PostPropsMaxRunes {
-						newPost.Props = props
-					} else {
-						if cfg.DiscardInvalidProps {
-							t.Logger.Warn("Unable import post as props exceed the maximum character count. Skipping as --discard-invalid-props is enabled.")
-							continue
-						} else {
-							t.Logger.Warn("Unable to add props to post as they exceed the maximum character count.")
-						}
-					}
-				}
-
-				AddPostToThreads(post, newPost, threads, channel, timestamps, cfg.ImportWorkflowMessages)
-
-			// channel join/leave messages
-			case post.IsJoinLeaveMessage():
-				// log.Println("Slack Import: Join/Leave messages are not yet supported")
-				break
-
-			// me message
-			case post.IsMeMessage():
-				// log.Println("Slack Import: me messages are not yet supported")
-				break
-
-			// change topic message
-			case post.IsChannelTopicMessage():
-				if post.User == "" {
-					t.Logger.Warn("Unable to import the message as the user field is missing.")
-					continue
-				}
-				author := t.Intermediate.UsersById[post.User]
-				if author == nil {
-					t.Logger.Warnf("Unable to add the message as the Slack user does not exist in Mattermost. user=%s", post.User)
-					continue
-				}
-
-				newPost := &IntermediatePost{
-					User:     author.Username,
-					Channel:  channel.Name,
-					Message:  post.Text,
-					CreateAt: SlackConvertTimeStamp(post.TimeStamp),
-					// Type:     model.POST_HEADER_CHANGE,
-				}
-
-				AddPostToThreads(post, newPost, threads, channel, timestamps, cfg.ImportWorkflowMessages)
-
-			// change channel purpose message
-			case post.IsChannelPurposeMessage():
-				if post.User == "" {
-					t.Logger.Warn("Unable to import the message as the user field is missing.")
-					continue
-				}
-				author := t.Intermediate.UsersById[post.User]
-				if author == nil {
-					t.Logger.Warnf("Unable to add the message as the Slack user does not exist in Mattermost. user=%s", post.User)
-					continue
-				}
-
-				newPost := &IntermediatePost{
-					User:     author.Username,
-					Channel:  channel.Name,
-					Message:  post.Text,
-					CreateAt: SlackConvertTimeStamp(post.TimeStamp),
-					// Type:     model.POST_HEADER_CHANGE,
-				}
-
-				AddPostToThreads(post, newPost, threads, channel, timestamps, cfg.ImportWorkflowMessages)
-
-			// change channel name message
-			case post.IsChannelNameMessage():
-				if post.User == "" {
-					t.Logger.Warn("Slack Import: Unable to import the message as the user field is missing.")
-					continue
-				}
-				author := t.Intermediate.UsersById[post.User]
-				if author == nil {
-					t.Logger.Warnf("Slack Import: Unable to add the message as the Slack user does not exist in Mattermost. user=%s", post.User)
-					continue
-				}
-
-				newPost := &IntermediatePost{
-					User:     author.Username,
-					Channel:  channel.Name,
-					Message:  post.Text,
-					CreateAt: SlackConvertTimeStamp(post.TimeStamp),
-					// Type:     model.POST_DISPLAYNAME_CHANGE,
-				}
-
-				AddPostToThreads(post, newPost, threads, channel, timestamps, cfg.ImportWorkflowMessages)
-
-			default:
-				t.Logger.Warnf("Unable to import the message as its type is not supported. post_type=%s, post_subtype=%s", post.Type, post.SubType)
-			}
-		}
-
-		resultPosts = append(resultPosts, threads.GetChangedThreads()...)
+		elapsed := time.Since(start)
+		t.countEmittedPosts(originalChannelName, posts)
+
+		resultPosts = append(resultPosts, posts...)
+		t.completedChannels = append(t.completedChannels, originalChannelName)
+		t.channelDone(ChannelProgress{
+			Channel: originalChannelName,
+			Source:  len(channelPosts),
+			Emitted: countPosts(posts),
+			Total:   len(slackExport.Posts),
+			Elapsed: elapsed,
+		})
 	}
 
 	t.Intermediate.Posts = resultPosts
 	t.Intermediate.GroupChannels = append(t.Intermediate.GroupChannels, newGroupChannels...)
 	t.Intermediate.DirectChannels = append(t.Intermediate.DirectChannels, newDirectChannels...)
 
+	if interrupted {
+		return ErrInterrupted
+	}
+	finishStage()
 	return nil
 }
 
@@ -669,26 +698,215 @@ type TransformConfig struct {
 	ImportWorkflowMessages bool
 	SkipPosts              bool
 	SkipChannels           bool
-	RedisConfig            *RedisConfig
+	// UsersOnly transforms only the users and their team membership,
+	// leaving out the channels and posts as with SkipChannels and
+	// SkipPosts, and the custom emojis and statuses
+	UsersOnly            bool
+	RedisConfig          *RedisConfig
+	BotAliases           BotAliases
+	PrettifyIntegrations bool
+	// DirectChannelsShowDays shows in the sidebar only the direct and
+	// group channels active during the given number of days before
+	// the last message of the export, all of them when zero
+	DirectChannelsShowDays int
+	// ChannelNotifyProps are set on the memberships of the public
+	// channels, and of the private ones with PrivateChannelNotifyProps
+	ChannelNotifyProps        *ChannelNotifyProps
+	PrivateChannelNotifyProps bool
+	// LegalHold imports the previous revisions of edited messages and
+	// the deleted messages found in the export
+	LegalHold bool
+	// ArchiveUser enables the archive mode when set, where the whole
+	// history is posted by the user with this name, see
+	// PrepareArchiveUser
+	ArchiveUser string
+	// UnknownSubtypes decides what happens to the messages whose
+	// subtype has no handler, see RegisterSubtypeHandler
+	UnknownSubtypes UnknownSubtypePolicy
+	// CustomEmojiFallback replaces the custom emojis of the reactions,
+	// which are dropped when it is empty
+	CustomEmojiFallback string
+	// SkipOneOffReminders leaves out the reminders set up without a
+	// recurrence and the reminders delivered by Slackbot. The recurring
+	// reminders are always summarized in a post per channel.
+	SkipOneOffReminders bool
+	// ImportSlackbotMessages imports the messages of Slackbot with a
+	// placeholder user, they are skipped otherwise
+	ImportSlackbotMessages bool
+	// AttachmentsLayout shards the attachments in subdirectories of
+	// AttachmentsDir, which holds them all when it is empty
+	AttachmentsLayout AttachmentsLayout
+	// AttachmentScanner checks the attachments once copied, the flagged
+	// ones are quarantined instead of attached when it is set
+	AttachmentScanner *AttachmentScanner
+	// ThreadsCacheSize bounds the threads of a channel held in memory
+	// while its posts are transformed, the least recently used ones
+	// being evicted to redis or to a temporary file. They are all
+	// held in memory when it is zero.
+	ThreadsCacheSize int
+	// ReplaceRules rewrite the message of the posts when set, e.g. to
+	// replace the hostnames of an old intranet
+	ReplaceRules *ReplaceRules
+	// ThreadParticipantProps copies the reply count and the participants
+	// of the threads into the props of their root post
+	ThreadParticipantProps bool
+	// GroupChannelPolicy converts the group channels matching it into
+	// private channels when set
+	GroupChannelPolicy *GroupChannelPolicy
+	// BotChannelPolicy leaves out or downsamples the channels made
+	// mostly of bot messages when set
+	BotChannelPolicy *BotChannelPolicy
+	// AttachmentImages downloads the images of the message attachments
+	// hosted by Slack into AttachmentsDir when set, see
+	// AttachmentImageDownloader
+	AttachmentImages *AttachmentImageDownloader
+	// AvatarDownloader downloads the profile photos of the users into
+	// AttachmentsDir when set, see DownloadAvatars
+	AvatarDownloader *AttachmentImageDownloader
+	// TrimOversizedProps drops the largest fields of the attachments of
+	// the posts whose props exceed the maximum character count until
+	// they fit, instead of dropping all the props or, with
+	// DiscardInvalidProps, the post
+	TrimOversizedProps bool
+	// CustomEmojis imports the custom emojis of the workspace when set,
+	// keeping the reactions using them
+	CustomEmojis *CustomEmojiConfig
+	// UserGroupDefaultChannels adds the members of the user groups to
+	// the default channels of their groups
+	UserGroupDefaultChannels bool
+	// AttributeAppUploads attributes the files shared by the apps to
+	// the users that uploaded them, from FileUploaders or else from the
+	// files, instead of the workflow user
+	AttributeAppUploads bool
+	FileUploaders       FileUploaders
+	// SkipThumbnails leaves out the files of which the export only has
+	// a thumbnail. The originals are always preferred to the thumbnails.
+	SkipThumbnails bool
+	// CustomStatuses sets the custom status of the users from their
+	// Slack profile, see TransformCustomStatuses
+	CustomStatuses bool
+	// BotUsers attributes the bot messages to a user per Slack bot,
+	// see CreateBotUsers, instead of the workflow user
+	BotUsers bool
+	// ChannelFilter transforms only the channels it selects when set
+	ChannelFilter *ChannelFilter
+	// ChannelArchivePolicy lists the channels matching it to archive
+	// after the import
+	ChannelArchivePolicy *ChannelArchivePolicy
+	// ThreadFileComments imports the file comments as replies to the
+	// message that shared their file in the channel, instead of as
+	// messages of their own
+	ThreadFileComments bool
+	// AttachmentRetries is the number of times a failed copy of an
+	// attachment is retried, the attachments still failing being
+	// listed by FailedAttachments
+	AttachmentRetries int
+	// KeepFailedAttachments keeps the attachments whose copy failed in
+	// their post, to copy with RetryFailedAttachments before the
+	// import. Only for the JSONL outputs, as the archives and the
+	// manifests read the attachments when they are written.
+	KeepFailedAttachments bool
 }
 
-func (t *Transformer) Transform(cfg *TransformConfig, slackExport *SlackExport) error {
-	t.TransformUsers(slackExport.Users, cfg.AuthDataAsEmail, cfg.AuthService)
+func (cfg *TransformConfig) skipChannels() bool {
+	return cfg.SkipChannels || cfg.UsersOnly
+}
 
-	if !cfg.SkipChannels {
-		if err := t.TransformAllChannels(slackExport); err != nil {
+func (cfg *TransformConfig) skipPosts() bool {
+	return cfg.SkipPosts || cfg.UsersOnly
+}
+
+// TransformUsersAndChannels converts the users and, unless skipped, the
+// channels and memberships of the export.
+func (t *Transformer) TransformUsersAndChannels(cfg *TransformConfig, slackExport *SlackExport) error {
+	finishStage := t.startStage(StageUsersAndChannels)
+	if cfg.ChannelFilter != nil {
+		t.FilterChannels(slackExport, cfg.ChannelFilter)
+	}
+	t.TransformUsers(slackExport.Users, cfg.AuthDataAsEmail, cfg.AuthService)
+	if cfg.ChannelFilter != nil && cfg.ChannelFilter.DirectOnly {
+		t.FilterDirectChannelUsers(slackExport)
+	}
+	if cfg.BotUsers && !cfg.skipPosts() {
+		if err := t.CreateBotUsers(slackExport); err != nil {
 			return err
 		}
+	}
+	if cfg.AvatarDownloader != nil && !cfg.SkipAttachments {
+		t.DownloadAvatars(slackExport.Users, cfg.AvatarDownloader, cfg.AttachmentsDir)
+	}
+	if cfg.CustomEmojis != nil && !cfg.UsersOnly {
+		t.TransformCustomEmojis(cfg.CustomEmojis, slackExport)
+	}
+	if cfg.CustomStatuses && !cfg.UsersOnly {
+		t.TransformCustomStatuses(slackExport.Users)
+	}
 
-		t.PopulateUserMemberships()
-		t.PopulateChannelMemberships()
+	if cfg.skipChannels() {
+		if cfg.ArchiveUser != "" {
+			t.PrepareArchiveUser(cfg.ArchiveUser)
+		}
+		finishStage()
+		return nil
 	}
 
-	if !cfg.SkipPosts {
-		if err := t.TransformPosts(cfg, slackExport); err != nil {
+	if err := t.TransformAllChannels(slackExport); err != nil {
+		return err
+	}
+	if cfg.GroupChannelPolicy != nil {
+		if err := t.ConvertGroupChannels(slackExport, cfg.GroupChannelPolicy); err != nil {
+			return err
+		}
+	}
+	if cfg.BotChannelPolicy != nil {
+		if err := t.ApplyBotChannelPolicy(slackExport, cfg.BotChannelPolicy); err != nil {
+			return err
+		}
+	}
+	if cfg.ChannelArchivePolicy != nil {
+		if err := t.ArchiveChannels(slackExport, cfg.ChannelArchivePolicy); err != nil {
 			return err
 		}
 	}
+	if cfg.UserGroupDefaultChannels {
+		t.AddUserGroupMemberships(slackExport.UserGroups)
+	}
+
+	t.PopulateUserMemberships()
+	t.PopulateChannelMemberships()
 
+	if len(slackExport.Stars) > 0 {
+		t.PopulateFavoriteChannels(slackExport.Stars)
+	}
+
+	if cfg.ChannelNotifyProps != nil {
+		t.ApplyChannelNotifyProps(cfg.ChannelNotifyProps, cfg.PrivateChannelNotifyProps)
+	}
+
+	if cfg.DirectChannelsShowDays > 0 {
+		t.HideInactiveDirectChannels(slackExport, cfg.DirectChannelsShowDays)
+	}
+
+	if cfg.ArchiveUser != "" {
+		t.PrepareArchiveUser(cfg.ArchiveUser)
+	}
+
+	finishStage()
 	return nil
 }
+
+// Transform converts the parsed Slack export into the intermediate
+// entities, returning the warnings raised along the way.
+func (t *Transformer) Transform(ctx context.Context, cfg *TransformConfig, slackExport *SlackExport) (*TransformResult, error) {
+	if err := t.TransformUsersAndChannels(cfg, slackExport); err != nil {
+		return t.result, err
+	}
+
+	if !cfg.skipPosts() {
+		if err := t.TransformPosts(ctx, cfg, slackExport); err != nil {
+			return t.result, err
+		}
+	}
+
+	return t.result, nil
+}
@@ -1,10 +1,12 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -479,6 +481,31 @@ func TestIntermediateUserSanitise(t *testing.T) {
 		assert.Equal(t, badFirstNmae[0:model.UserFirstNameMaxRunes], user.FirstName)
 	})
 
+	t.Run("If user's first name is too long, it is truncated between graphemes", func(t *testing.T) {
+		user := IntermediateUser{
+			Username:  "test-username",
+			Email:     "someone@test.com",
+			FirstName: strings.Repeat("a", model.UserFirstNameMaxRunes-1) + "👍🏽",
+		}
+
+		user.Sanitise(log.New())
+
+		assert.Equal(t, strings.Repeat("a", model.UserFirstNameMaxRunes-1), user.FirstName)
+	})
+
+	t.Run("If user's position is too long, it is truncated at a word boundary", func(t *testing.T) {
+		user := IntermediateUser{
+			Username: "test-username",
+			Email:    "someone@test.com",
+			Position: strings.Repeat("Senior ", 20),
+		}
+
+		user.Sanitise(log.New())
+
+		assert.LessOrEqual(t, utf8.RuneCountInString(user.Position), model.UserPositionMaxRunes)
+		assert.True(t, strings.HasSuffix(user.Position, "Senior…"), user.Position)
+	})
+
 	t.Run("If user's last name is too long, the value will be cleared", func(t *testing.T) {
 		badLastName := strings.Repeat("some", 17)
 
@@ -550,11 +577,12 @@ func TestPopulateUserMemberships(t *testing.T) {
 	slackTransformer := NewTransformer("test", log.New())
 
 	slackTransformer.Intermediate = &Intermediate{
-		UsersById: map[string]*IntermediateUser{"id1": {}, "id2": {}, "id3": {}},
+		UsersById: map[string]*IntermediateUser{"id1": {}, "id2": {}, "id3": {IsGuest: true}},
 		PublicChannels: []*IntermediateChannel{
 			{
 				Name:    "c1",
 				Members: []string{"id1", "id3"},
+				Creator: "id1",
 			},
 			{
 				Name:    "c2",
@@ -565,6 +593,7 @@ func TestPopulateUserMemberships(t *testing.T) {
 			{
 				Name:    "c3",
 				Members: []string{"id3"},
+				Creator: "id3",
 			},
 		},
 	}
@@ -574,6 +603,10 @@ func TestPopulateUserMemberships(t *testing.T) {
 	assert.Equal(t, []string{"c1", "c2"}, slackTransformer.Intermediate.UsersById["id1"].Memberships)
 	assert.Equal(t, []string{"c2"}, slackTransformer.Intermediate.UsersById["id2"].Memberships)
 	assert.Equal(t, []string{"c1", "c3"}, slackTransformer.Intermediate.UsersById["id3"].Memberships)
+
+	assert.Equal(t, []string{"c1"}, slackTransformer.Intermediate.UsersById["id1"].AdminMemberships)
+	assert.Empty(t, slackTransformer.Intermediate.UsersById["id2"].AdminMemberships)
+	assert.Empty(t, slackTransformer.Intermediate.UsersById["id3"].AdminMemberships)
 }
 
 func TestPopulateChannelMemberships(t *testing.T) {
@@ -647,7 +680,7 @@ func TestAddPostToThreads(t *testing.T) {
 				channel := &IntermediateChannel{Type: model.ChannelTypeOpen}
 				threads := newMemoryStorage()
 
-				AddPostToThreads(original, tc.Post, threads, channel, tc.Timestamps, true)
+				NewTransformer("test", log.New()).AddPostToThreads(original, tc.Post, threads, channel, tc.Timestamps, true)
 				newPost := threads.LookupThread("thread-ts")
 				require.NotNil(t, newPost)
 				require.Equal(t, tc.Post, newPost)
@@ -663,7 +696,7 @@ func TestWorkflow(t *testing.T) {
         "type": "message",
         "subtype": "bot_message",
         "text": "Some text",
-        "ts": "1,
+        "ts": "1",
         "username": "k8s PR",
         "bot_id": "B01",
         "thread_ts": "1",
@@ -731,7 +764,7 @@ func TestWorkflow(t *testing.T) {
 	transformer := NewTransformer("team", log.New())
 	transformer.Intermediate.PublicChannels = transformer.TransformChannels(slackData.Channels)
 	transformer.Intermediate.UsersById = make(map[string]*IntermediateUser)
-	if !assert.NoError(t, transformer.TransformPosts(&TransformConfig{
+	if !assert.NoError(t, transformer.TransformPosts(context.Background(), &TransformConfig{
 		ImportWorkflowMessages: true,
 	}, slackData)) {
 		return
@@ -741,3 +774,170 @@ func TestWorkflow(t *testing.T) {
 	}
 	assert.Equal(t, WorkflowUserName, transformer.Intermediate.Posts[0].User)
 }
+
+func TestBotAliases(t *testing.T) {
+	aliases, err := ParseBotAliases(strings.NewReader(`{"B01": "jira", "GitHub": "github"}`))
+	require.NoError(t, err)
+
+	slackData := &SlackExport{
+		TeamName: "team",
+		Channels: []SlackChannel{
+			{
+				Id:   "channel",
+				Name: "channel",
+			},
+		},
+		Posts: map[string][]SlackPost{
+			"channel": {
+				{Type: "message", SubType: "bot_message", BotId: "B01", Text: "issue created", TimeStamp: "1"},
+				{Type: "message", SubType: "bot_message", BotId: "B02", BotUsername: "GitHub", Text: "PR merged", TimeStamp: "2"},
+				{Type: "message", SubType: "bot_message", BotId: "B03", Text: "unmapped", TimeStamp: "3"},
+			},
+		},
+	}
+
+	t.Run("aliased bots are imported even without workflow messages", func(t *testing.T) {
+		transformer := NewTransformer("team", log.New())
+		transformer.Intermediate.PublicChannels = transformer.TransformChannels(slackData.Channels)
+		transformer.Intermediate.UsersById = make(map[string]*IntermediateUser)
+		require.NoError(t, transformer.TransformPosts(context.Background(), &TransformConfig{BotAliases: aliases}, slackData))
+
+		users := []string{}
+		for _, post := range transformer.Intermediate.Posts {
+			users = append(users, post.User)
+		}
+		assert.ElementsMatch(t, []string{"jira", "github"}, users)
+		assert.Empty(t, transformer.Intermediate.UsersById)
+	})
+
+	t.Run("unmapped bots fall back to the workflow user", func(t *testing.T) {
+		transformer := NewTransformer("team", log.New())
+		transformer.Intermediate.PublicChannels = transformer.TransformChannels(slackData.Channels)
+		transformer.Intermediate.UsersById = make(map[string]*IntermediateUser)
+		require.NoError(t, transformer.TransformPosts(context.Background(), &TransformConfig{BotAliases: aliases, ImportWorkflowMessages: true}, slackData))
+
+		users := []string{}
+		for _, post := range transformer.Intermediate.Posts {
+			users = append(users, post.User)
+		}
+		assert.ElementsMatch(t, []string{"jira", "github", WorkflowUserName}, users)
+	})
+}
+
+// interruptingContext reports a cancellation after its Err method has
+// been checked a given number of times.
+type interruptingContext struct {
+	context.Context
+	checks int
+}
+
+func (c *interruptingContext) Err() error {
+	if c.checks == 0 {
+		return context.Canceled
+	}
+	c.checks--
+	return nil
+}
+
+func TestTransformPostsInterrupted(t *testing.T) {
+	slackData := &SlackExport{
+		TeamName: "team",
+		Channels: []SlackChannel{
+			{Id: "c1", Name: "c1"},
+			{Id: "c2", Name: "c2"},
+		},
+		Posts: map[string][]SlackPost{
+			"c1": {{Type: "message", User: "u1", Text: "first", TimeStamp: "1"}},
+			"c2": {{Type: "message", User: "u1", Text: "second", TimeStamp: "2"}},
+		},
+	}
+	transformer := NewTransformer("team", log.New())
+	transformer.Intermediate.UsersById = map[string]*IntermediateUser{"u1": {Username: "user1"}}
+	transformer.Intermediate.PublicChannels = transformer.TransformChannels(slackData.Channels)
+
+	ctx := &interruptingContext{Context: context.Background(), checks: 1}
+	err := transformer.TransformPosts(ctx, &TransformConfig{}, slackData)
+	require.ErrorIs(t, err, ErrInterrupted)
+
+	require.Len(t, transformer.Intermediate.Posts, 1)
+	checkpoint := transformer.Checkpoint()
+	require.Len(t, checkpoint.CompletedChannels, 1)
+	assert.Equal(t, transformer.Intermediate.Posts[0].Channel, checkpoint.CompletedChannels[0])
+
+	checkpointPath := t.TempDir() + "/checkpoint.json"
+	require.NoError(t, WriteCheckpoint(checkpointPath, checkpoint))
+	readCheckpoint, err := ReadCheckpoint(checkpointPath)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint, readCheckpoint)
+}
+
+func TestTransformResultWarnings(t *testing.T) {
+	slackData := &SlackExport{
+		TeamName: "team",
+		Users:    []SlackUser{{Id: "u1", Username: "user1"}},
+		PublicChannels: []SlackChannel{
+			{Id: "c1", Name: "c1", Members: []string{"u1"}},
+		},
+		Posts: map[string][]SlackPost{
+			"c1": {
+				{Type: "message", User: "u1", Text: "imported", TimeStamp: "1"},
+				{Type: "message", User: "u2", Text: "unknown user", TimeStamp: "2"},
+				{Type: "message", Text: "missing user", TimeStamp: "3"},
+				{Type: "message", SubType: "pinned_item", User: "u1", TimeStamp: "4"},
+			},
+			"missing": {
+				{Type: "message", User: "u1", Text: "unknown channel", TimeStamp: "5"},
+			},
+		},
+	}
+
+	transformer := NewTransformer("team", log.New())
+	result, err := transformer.Transform(context.Background(), &TransformConfig{}, slackData)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Count(WarningUnknownUser))
+	assert.Equal(t, 1, result.Count(WarningMissingUser))
+	assert.Equal(t, 1, result.Count(WarningUnsupportedPostType))
+	assert.Equal(t, 1, result.Count(WarningUnknownChannel))
+	assert.Equal(t, 4, result.SkippedCount())
+
+	for _, warning := range result.Warnings {
+		if warning.Kind == WarningUnknownUser {
+			assert.Equal(t, "c1", warning.Channel)
+			assert.Equal(t, "2", warning.TimeStamp)
+		}
+	}
+}
+
+func TestPopulateFavoriteChannels(t *testing.T) {
+	slackTransformer := NewTransformer("test", log.New())
+	slackTransformer.Intermediate = &Intermediate{
+		UsersById: map[string]*IntermediateUser{
+			"id1": {Username: "user1"},
+			"id2": {Username: "user2"},
+		},
+		PublicChannels: []*IntermediateChannel{
+			{Id: "C1", Name: "c1", Type: model.ChannelTypeOpen},
+		},
+		PrivateChannels: []*IntermediateChannel{
+			{Id: "G1", Name: "g1", Type: model.ChannelTypePrivate},
+		},
+		DirectChannels: []*IntermediateChannel{
+			{Id: "D1", Name: "d1", Type: model.ChannelTypeDirect},
+		},
+	}
+
+	slackTransformer.PopulateFavoriteChannels(map[string][]SlackStar{
+		"id1":     {{Type: "channel", Channel: "C1"}, {Type: "im", Channel: "D1"}, {Type: "message", Channel: "C9"}},
+		"id2":     {{Type: "group", Channel: "G1"}},
+		"unknown": {{Type: "channel", Channel: "C1"}},
+	})
+
+	assert.Equal(t, []string{"c1"}, slackTransformer.Intermediate.UsersById["id1"].FavoriteChannels)
+	assert.Equal(t, []string{"g1"}, slackTransformer.Intermediate.UsersById["id2"].FavoriteChannels)
+	assert.Equal(t, []string{"user1"}, slackTransformer.Intermediate.DirectChannels[0].FavoritedBy)
+
+	line := GetImportLineFromDirectChannel("test", slackTransformer.Intermediate.DirectChannels[0])
+	require.NotNil(t, line.DirectChannel.FavoritedBy)
+	assert.Equal(t, []string{"user1"}, *line.DirectChannel.FavoritedBy)
+}
@@ -0,0 +1,130 @@
+package slack
+
+const (
+	legalHoldEdited   = "edited"
+	legalHoldRevision = "revision"
+	legalHoldDeletion = "deletion"
+
+	legalHoldRevisionPrefix  = "**[Previous revision]** "
+	legalHoldDeletionMessage = "**[Deleted]** This message was deleted."
+)
+
+// legalHoldRecord describes how a post relates to the edits and
+// deletions of the original message. It is stored in the "legal_hold"
+// prop of the root posts created or annotated in legal-hold mode.
+type legalHoldRecord struct {
+	Kind       string `json:"kind"`
+	OriginalTS string `json:"original_ts"`
+	// User and TimeStamp identify who edited or deleted the message
+	// and when, if the export contains them
+	User      string `json:"user,omitempty"`
+	TimeStamp string `json:"ts,omitempty"`
+}
+
+func (p *SlackPost) IsMessageChangedEvent() bool {
+	return p.Type == "message" && p.SubType == "message_changed"
+}
+
+func (p *SlackPost) IsMessageDeletedEvent() bool {
+	return p.Type == "message" && p.SubType == "message_deleted"
+}
+
+func (p *SlackPost) threadRoot() string {
+	if p.ThreadTS != "" {
+		return p.ThreadTS
+	}
+	return p.TimeStamp
+}
+
+func markEdited(post SlackPost) SlackPost {
+	if post.Edited != nil {
+		post.legalHold = &legalHoldRecord{
+			Kind:       legalHoldEdited,
+			OriginalTS: post.TimeStamp,
+			User:       post.Edited.User,
+			TimeStamp:  post.Edited.TimeStamp,
+		}
+	}
+	return post
+}
+
+// legalHoldReply creates a reply in the thread of the original message
+// that keeps its author but carries the given text and no files.
+func legalHoldReply(original SlackPost, text, ts string, record *legalHoldRecord) SlackPost {
+	reply := original
+	if reply.SubType == "file_share" || reply.SubType == "thread_broadcast" {
+		reply.SubType = ""
+	}
+	reply.Text = text
+	reply.TimeStamp = ts
+	reply.ThreadTS = original.threadRoot()
+	reply.File = nil
+	reply.Files = nil
+	reply.Blocks = nil
+	reply.Edited = nil
+	reply.legalHold = record
+	return reply
+}
+
+// applyLegalHold expands the message_changed and message_deleted
+// events of a channel, which only compliance exports contain, into
+// posts. Every previous revision of an edited message becomes a reply
+// to it, and every deleted message is restored from the event followed
+// by a reply marking its deletion.
+func applyLegalHold(posts []SlackPost) []SlackPost {
+	existing := map[string]bool{}
+	for _, post := range posts {
+		if !post.IsMessageChangedEvent() && !post.IsMessageDeletedEvent() {
+			existing[post.TimeStamp] = true
+		}
+	}
+
+	result := make([]SlackPost, 0, len(posts))
+	for _, post := range posts {
+		switch {
+		case post.IsMessageChangedEvent() && post.Message != nil:
+			current := *post.Message
+			if !existing[current.TimeStamp] {
+				existing[current.TimeStamp] = true
+				result = append(result, markEdited(current))
+			}
+			if post.PreviousMessage == nil {
+				continue
+			}
+			record := &legalHoldRecord{
+				Kind:       legalHoldRevision,
+				OriginalTS: current.TimeStamp,
+				TimeStamp:  post.TimeStamp,
+			}
+			if current.Edited != nil {
+				record.User = current.Edited.User
+			}
+			revision := *post.PreviousMessage
+			revision.ThreadTS = current.ThreadTS
+			revision.TimeStamp = current.TimeStamp
+			result = append(result, legalHoldReply(revision, legalHoldRevisionPrefix+revision.Text, post.TimeStamp, record))
+
+		case post.IsMessageDeletedEvent() && post.PreviousMessage != nil:
+			deleted := *post.PreviousMessage
+			if post.DeletedTS != "" {
+				deleted.TimeStamp = post.DeletedTS
+			}
+			if !existing[deleted.TimeStamp] {
+				existing[deleted.TimeStamp] = true
+				result = append(result, markEdited(deleted))
+			}
+			record := &legalHoldRecord{
+				Kind:       legalHoldDeletion,
+				OriginalTS: deleted.TimeStamp,
+				User:       post.User,
+				TimeStamp:  post.TimeStamp,
+			}
+			result = append(result, legalHoldReply(deleted, legalHoldDeletionMessage, post.TimeStamp, record))
+
+		default:
+			result = append(result, markEdited(post))
+		}
+	}
+
+	return result
+}
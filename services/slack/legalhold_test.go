@@ -0,0 +1,75 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegalHold(t *testing.T) {
+	const postsJson = `[
+		{"type": "message", "user": "u1", "text": "final text", "ts": "1.000000", "edited": {"user": "u1", "ts": "3.000000"}},
+		{"type": "message", "subtype": "message_changed", "ts": "2.000000",
+			"message": {"type": "message", "user": "u1", "text": "second text", "ts": "1.000000", "edited": {"user": "u1", "ts": "2.000000"}},
+			"previous_message": {"type": "message", "user": "u1", "text": "first text", "ts": "1.000000"}},
+		{"type": "message", "subtype": "message_deleted", "ts": "5.000000", "deleted_ts": "4.000000",
+			"previous_message": {"type": "message", "user": "u2", "text": "oops", "ts": "4.000000"}}
+	]`
+	var posts []SlackPost
+	require.NoError(t, json.Unmarshal([]byte(postsJson), &posts))
+
+	slackData := &SlackExport{
+		TeamName: "team",
+		Channels: []SlackChannel{{Id: "c1", Name: "c1"}},
+		Posts:    map[string][]SlackPost{"c1": posts},
+	}
+	users := map[string]*IntermediateUser{"u1": {Username: "user1"}, "u2": {Username: "user2"}}
+
+	t.Run("edits and deletions are ignored by default", func(t *testing.T) {
+		transformer := NewTransformer("team", log.New())
+		transformer.Intermediate.UsersById = users
+		transformer.Intermediate.PublicChannels = transformer.TransformChannels(slackData.Channels)
+		require.NoError(t, transformer.TransformPosts(context.Background(), &TransformConfig{}, slackData))
+
+		require.Len(t, transformer.Intermediate.Posts, 1)
+		assert.Equal(t, "final text", transformer.Intermediate.Posts[0].Message)
+		assert.Zero(t, transformer.Intermediate.Posts[0].EditAt)
+		assert.Empty(t, transformer.Intermediate.Posts[0].Replies)
+	})
+
+	t.Run("legal hold keeps every revision and deletion", func(t *testing.T) {
+		transformer := NewTransformer("team", log.New())
+		transformer.Intermediate.UsersById = users
+		transformer.Intermediate.PublicChannels = transformer.TransformChannels(slackData.Channels)
+		require.NoError(t, transformer.TransformPosts(context.Background(), &TransformConfig{LegalHold: true}, slackData))
+
+		messages := map[string]*IntermediatePost{}
+		for _, post := range transformer.Intermediate.Posts {
+			messages[post.Message] = post
+		}
+		require.Len(t, messages, 2)
+
+		edited := messages["final text"]
+		require.NotNil(t, edited)
+		assert.Equal(t, int64(3000), edited.EditAt)
+		assert.Equal(t, legalHoldEdited, edited.Props["legal_hold"].(*legalHoldRecord).Kind)
+		require.Len(t, edited.Replies, 1)
+		assert.Equal(t, legalHoldRevisionPrefix+"first text", edited.Replies[0].Message)
+		assert.Equal(t, int64(2000), edited.Replies[0].CreateAt)
+
+		deleted := messages["oops"]
+		require.NotNil(t, deleted)
+		assert.Equal(t, "user2", deleted.User)
+		require.Len(t, deleted.Replies, 1)
+		assert.Equal(t, legalHoldDeletionMessage, deleted.Replies[0].Message)
+		assert.Equal(t, int64(5000), deleted.Replies[0].CreateAt)
+
+		line := GetImportLineFromPost(edited, "team")
+		require.NotNil(t, line.Post.EditAt)
+		assert.Equal(t, int64(3000), *line.Post.EditAt)
+	})
+}
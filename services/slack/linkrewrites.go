@@ -0,0 +1,83 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// slackLinkRegex matches the links of the Slack markup, with their
+// optional label: <https://trello.com/c/abc|the card>
+var slackLinkRegex = regexp.MustCompile(`<(https?://[^|>]+)(\|[^>]*)?>`)
+
+// LinkRewrites maps the URLs of the tools replaced along with Slack,
+// e.g. Trello cards or Asana tasks, to their URL in the new tools. A
+// URL matches the links starting with it followed by a path, a query
+// or a fragment, which are appended to the new URL, so it maps either
+// a single item or a whole board.
+type LinkRewrites map[string]string
+
+// ParseLinkRewrites reads a JSON object mapping the old URLs to the new
+// ones, e.g. {"https://trello.com/b/abc": "https://mm.example.com/boards/xyz"}.
+func ParseLinkRewrites(data io.Reader) (LinkRewrites, error) {
+	var rewrites LinkRewrites
+	if err := json.NewDecoder(data).Decode(&rewrites); err != nil {
+		return nil, err
+	}
+	for from, to := range rewrites {
+		if from == "" || to == "" {
+			return nil, errors.Errorf("invalid link rewrite %q to %q, both URLs are required", from, to)
+		}
+	}
+	return rewrites, nil
+}
+
+// linkRewriter applies LinkRewrites to the links of the posts, the
+// longest matching URL first
+type linkRewriter struct {
+	prefixes []string
+	rewrites LinkRewrites
+}
+
+func newLinkRewriter(rewrites LinkRewrites) *linkRewriter {
+	prefixes := make([]string, 0, len(rewrites))
+	for from := range rewrites {
+		prefixes = append(prefixes, from)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return &linkRewriter{prefixes: prefixes, rewrites: rewrites}
+}
+
+func (r *linkRewriter) rewrite(posts map[string][]SlackPost) map[string][]SlackPost {
+	return replacePostsText(posts, r.rewriteText)
+}
+
+func (r *linkRewriter) rewriteText(text string) string {
+	return slackLinkRegex.ReplaceAllStringFunc(text, func(link string) string {
+		match := slackLinkRegex.FindStringSubmatch(link)
+		newURL, ok := r.rewriteURL(match[1])
+		if !ok {
+			return link
+		}
+		return "<" + newURL + match[2] + ">"
+	})
+}
+
+func (r *linkRewriter) rewriteURL(linkURL string) (string, bool) {
+	for _, from := range r.prefixes {
+		if !strings.HasPrefix(linkURL, from) {
+			continue
+		}
+		// https://trello.com/b/abc doesn't match https://trello.com/b/abcd
+		rest := linkURL[len(from):]
+		if rest != "" && !strings.HasSuffix(from, "/") && !strings.ContainsAny(rest[:1], "/?#") {
+			continue
+		}
+		return r.rewrites[from] + rest, true
+	}
+	return "", false
+}
@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLinkRewrites(t *testing.T) {
+	rewrites, err := ParseLinkRewrites(strings.NewReader(`{"https://trello.com/b/abc": "https://chat.example.com/boards/xyz"}`))
+	require.NoError(t, err)
+	assert.Equal(t, LinkRewrites{"https://trello.com/b/abc": "https://chat.example.com/boards/xyz"}, rewrites)
+
+	_, err = ParseLinkRewrites(strings.NewReader(`{"https://trello.com/b/abc": ""}`))
+	assert.Error(t, err)
+
+	_, err = ParseLinkRewrites(strings.NewReader(`[]`))
+	assert.Error(t, err)
+}
+
+func TestLinkRewriter(t *testing.T) {
+	rewriter := newLinkRewriter(LinkRewrites{
+		"https://trello.com/b/abc":          "https://chat.example.com/boards/xyz",
+		"https://trello.com/c/card1":        "https://chat.example.com/boards/xyz/card/1",
+		"https://app.asana.com/0/":          "https://chat.example.com/playbooks/",
+		"https://trello.com/b/abc/old-name": "https://chat.example.com/boards/renamed",
+	})
+
+	for _, tc := range []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"exact", "see <https://trello.com/c/card1>", "see <https://chat.example.com/boards/xyz/card/1>"},
+		{"label", "see <https://trello.com/c/card1|the card>", "see <https://chat.example.com/boards/xyz/card/1|the card>"},
+		{"path kept", "<https://trello.com/b/abc/some-board>", "<https://chat.example.com/boards/xyz/some-board>"},
+		{"query kept", "<https://trello.com/b/abc?filter=me>", "<https://chat.example.com/boards/xyz?filter=me>"},
+		{"longest first", "<https://trello.com/b/abc/old-name>", "<https://chat.example.com/boards/renamed>"},
+		{"trailing slash", "<https://app.asana.com/0/123/456>", "<https://chat.example.com/playbooks/123/456>"},
+		{"partial id", "<https://trello.com/b/abcd>", "<https://trello.com/b/abcd>"},
+		{"unknown", "<https://example.com/b/abc>", "<https://example.com/b/abc>"},
+		{"not a link", "https://trello.com/c/card1", "https://trello.com/c/card1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, rewriter.rewriteText(tc.text))
+		})
+	}
+}
+
+func TestTransformFSLinkRewrites(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "moved to <https://trello.com/c/card1|the card>", "ts": "1577923200.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		LinkRewrites:    LinkRewrites{"https://trello.com/c/card1": "https://chat.example.com/boards/xyz/card/1"},
+	})
+	require.NoError(t, err)
+
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Contains(t, messages, "moved to [the card](https://chat.example.com/boards/xyz/card/1)")
+}
@@ -0,0 +1,150 @@
+package slack
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/pkg/errors"
+)
+
+// Manifest lists the SHA-256 checksums of the files of a
+// transformation output. It is written in the sha256sum format, so it
+// can be verified with VerifyManifest or with `sha256sum -c`.
+type Manifest struct {
+	Entries []ManifestEntry
+	seen    map[string]bool
+}
+
+type ManifestEntry struct {
+	Path   string
+	SHA256 string
+}
+
+func fileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// AddFile computes the checksum of the file and adds it to the
+// manifest. Files already in the manifest are ignored.
+func (m *Manifest) AddFile(filePath string) error {
+	if m.seen == nil {
+		m.seen = map[string]bool{}
+	}
+	if m.seen[filePath] {
+		return nil
+	}
+
+	checksum, err := fileSHA256(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compute the checksum of %s", filePath)
+	}
+	m.seen[filePath] = true
+	m.Entries = append(m.Entries, ManifestEntry{Path: filePath, SHA256: checksum})
+	return nil
+}
+
+func (m *Manifest) Write(writer io.Writer) error {
+	for _, entry := range m.Entries {
+		if _, err := fmt.Fprintf(writer, "%s  %s\n", entry.SHA256, entry.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func WriteManifest(manifestPath string, manifest *Manifest) error {
+	file, err := os.Create(manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the manifest file %s", manifestPath)
+	}
+	defer file.Close()
+
+	if err := manifest.Write(file); err != nil {
+		return errors.Wrapf(err, "failed to write the manifest file %s", manifestPath)
+	}
+	return nil
+}
+
+func ReadManifest(reader io.Reader) (*Manifest, error) {
+	manifest := &Manifest{}
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid manifest line %d", lineNumber)
+		}
+		manifest.Entries = append(manifest.Entries, ManifestEntry{Path: parts[1], SHA256: parts[0]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// VerifyManifest checks the files of the manifest, resolving the
+// relative paths against baseDir, and returns the paths of the files
+// that are missing or whose checksum doesn't match.
+func VerifyManifest(manifest *Manifest, baseDir string) []string {
+	failed := []string{}
+	for _, entry := range manifest.Entries {
+		filePath := entry.Path
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(baseDir, filePath)
+		}
+		checksum, err := fileSHA256(filePath)
+		if err != nil || checksum != entry.SHA256 {
+			failed = append(failed, entry.Path)
+		}
+	}
+	return failed
+}
+
+// ManifestExporter wraps an exporter, adding the attachments referenced
+// by the exported lines to the manifest when it is closed.
+type ManifestExporter struct {
+	Exporter
+	manifest    *Manifest
+	attachments []string
+}
+
+func NewManifestExporter(exporter Exporter, manifest *Manifest) *ManifestExporter {
+	return &ManifestExporter{Exporter: exporter, manifest: manifest}
+}
+
+func (e *ManifestExporter) WriteLine(line *app.LineImportData) error {
+	e.attachments = append(e.attachments, lineAttachmentPaths(line)...)
+	return e.Exporter.WriteLine(line)
+}
+
+func (e *ManifestExporter) Close() error {
+	if err := e.Exporter.Close(); err != nil {
+		return err
+	}
+	for _, attachmentPath := range e.attachments {
+		if err := e.manifest.AddFile(attachmentPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package slack
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	attachmentPath := filepath.Join(dir, "F1_notes.txt")
+	require.NoError(t, os.WriteFile(attachmentPath, []byte("some notes"), 0600))
+	outputPath := filepath.Join(dir, "export.jsonl")
+
+	transformer := NewTransformer("team", log.New())
+	transformer.Intermediate.UsersById = map[string]*IntermediateUser{}
+	transformer.Intermediate.Posts = []*IntermediatePost{
+		{User: "user1", Channel: "general", Message: "first", Attachments: []string{attachmentPath}},
+		{User: "user1", Channel: "general", Message: "second", Attachments: []string{attachmentPath}},
+	}
+
+	outputFile, err := os.Create(outputPath)
+	require.NoError(t, err)
+	manifest := &Manifest{}
+	exporter := NewManifestExporter(NewJSONLExporter(outputFile), manifest)
+	require.NoError(t, transformer.ExportWith(exporter))
+	require.NoError(t, exporter.Close())
+	require.NoError(t, outputFile.Close())
+	require.NoError(t, manifest.AddFile(outputPath))
+
+	require.Len(t, manifest.Entries, 2)
+	assert.Equal(t, attachmentPath, manifest.Entries[0].Path)
+	// sha256 of "some notes"
+	assert.Equal(t, "237bac67a518e2dfc901ecbf060f59cde465d4644cf9d0783a877a321f9bbcb3", manifest.Entries[0].SHA256)
+
+	var buffer bytes.Buffer
+	require.NoError(t, manifest.Write(&buffer))
+	readManifest, err := ReadManifest(&buffer)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Entries, readManifest.Entries)
+	assert.Empty(t, VerifyManifest(readManifest, dir))
+
+	require.NoError(t, os.WriteFile(attachmentPath, []byte("tampered"), 0600))
+	assert.Equal(t, []string{attachmentPath}, VerifyManifest(readManifest, dir))
+}
@@ -0,0 +1,136 @@
+package slack
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// memoryBaseline is the memory of the process besides the export
+	memoryBaseline = 64 << 20
+	// metadataMemoryFactor is the memory of the users and channels per
+	// byte of their JSON
+	metadataMemoryFactor = 4
+	// parsedPostsMemoryFactor is the memory of the parsed posts of a
+	// channel per byte of their JSON, and threadsMemoryFactor the
+	// memory of their threads while they are transformed
+	parsedPostsMemoryFactor = 3
+	threadsMemoryFactor     = 2
+	// recommendedThreadsCacheSize is the --threads-cache-size
+	// recommended when the threads don't fit in memory
+	recommendedThreadsCacheSize = 10000
+)
+
+// MemoryEstimate is the peak memory of a transformation estimated from
+// the sizes of the files of the export, without reading them.
+type MemoryEstimate struct {
+	Channels      int
+	DayFiles      int
+	PostBytes     int64
+	MetadataBytes int64
+	// LargestChannels are the channels with the most posts by size,
+	// the largest first, which are in memory at the same time
+	LargestChannels []ChannelSize
+	// InMemory is the peak memory with the threads held in memory
+	InMemory int64
+	// WithThreadsCache is the peak memory with --threads-cache-size,
+	// the threads being evicted to temporary files or to redis, which
+	// hold up to SpillBytes
+	WithThreadsCache int64
+	SpillBytes       int64
+}
+
+// ChannelSize is the size of the day files of a channel
+type ChannelSize struct {
+	Channel string
+	Bytes   int64
+}
+
+// EstimateMemory estimates the peak memory of the transformation of
+// the export from the central directory of its zip file, the pipeline
+// holding up to twice bufferSize channels besides the ones being
+// parsed, transformed and exported.
+func EstimateMemory(files []*zip.File, bufferSize int) *MemoryEstimate {
+	if bufferSize <= 0 {
+		bufferSize = DefaultPipelineBufferSize
+	}
+
+	estimate := &MemoryEstimate{}
+	channelBytes := map[string]int64{}
+	for _, file := range files {
+		if file.FileInfo().IsDir() || !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+		size := int64(file.UncompressedSize64)
+		dir, name := path.Split(file.Name)
+		if dir == "" {
+			estimate.MetadataBytes += size
+			continue
+		}
+		if _, err := time.Parse(slackExportDayLayout, strings.TrimSuffix(name, ".json")); err != nil {
+			continue
+		}
+		channelBytes[strings.TrimSuffix(dir, "/")] += size
+		estimate.DayFiles++
+		estimate.PostBytes += size
+	}
+
+	channels := make([]ChannelSize, 0, len(channelBytes))
+	for channel, size := range channelBytes {
+		channels = append(channels, ChannelSize{Channel: channel, Bytes: size})
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Bytes != channels[j].Bytes {
+			return channels[i].Bytes > channels[j].Bytes
+		}
+		return channels[i].Channel < channels[j].Channel
+	})
+	estimate.Channels = len(channels)
+
+	inFlight := 2*bufferSize + 3
+	if len(channels) < inFlight {
+		inFlight = len(channels)
+	}
+	estimate.LargestChannels = channels[:inFlight]
+	var inFlightBytes int64
+	for _, channel := range estimate.LargestChannels {
+		inFlightBytes += channel.Bytes
+	}
+
+	base := int64(memoryBaseline) + estimate.MetadataBytes*metadataMemoryFactor
+	estimate.InMemory = base + inFlightBytes*(parsedPostsMemoryFactor+threadsMemoryFactor)
+	estimate.WithThreadsCache = base + inFlightBytes*parsedPostsMemoryFactor
+	if len(channels) > 0 {
+		estimate.SpillBytes = channels[0].Bytes * threadsMemoryFactor
+	}
+	return estimate
+}
+
+// Recommend returns the flags keeping the transformation under the
+// memory limit, with the reason for each of them. None are needed when
+// the threads fit in memory.
+func (e *MemoryEstimate) Recommend(limit int64) []string {
+	if e.InMemory <= limit {
+		return nil
+	}
+	recommendations := []string{
+		fmt.Sprintf("--threads-cache-size %d: the threads don't fit in memory, they are evicted to temporary files, up to %s", recommendedThreadsCacheSize, formatBytes(e.SpillBytes)),
+		fmt.Sprintf("--redis-endpoint: evicts the threads to redis instead of temporary files, using up to %s of its memory", formatBytes(e.SpillBytes)),
+	}
+	if e.WithThreadsCache > limit && len(e.LargestChannels) > 0 {
+		recommendations = append(recommendations, fmt.Sprintf("--exclude-channels %s: the largest channels don't fit in memory even with a threads cache, transform them in separate runs with --only-channels", e.LargestChannels[0].Channel))
+	}
+	return recommendations
+}
+
+// formatBytes formats a size in MiB, or GiB when larger
+func formatBytes(size int64) string {
+	if size >= 1<<30 {
+		return fmt.Sprintf("%.1f GiB", float64(size)/(1<<30))
+	}
+	return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+}
@@ -0,0 +1,89 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func memoryTestZip(t *testing.T, files map[string]int) *zip.Reader {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, size := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(strings.Repeat(" ", size)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return r
+}
+
+func TestEstimateMemory(t *testing.T) {
+	r := memoryTestZip(t, map[string]int{
+		"users.json":              100,
+		"channels.json":           50,
+		"general/2020-01-01.json": 1000,
+		"general/2020-01-02.json": 2000,
+		"random/2020-01-01.json":  500,
+		"small/2020-01-01.json":   10,
+		"general/canvas.json":     5000,
+		"__uploads/F1/notes.txt":  9000,
+	})
+
+	estimate := EstimateMemory(r.File, 0)
+	assert.Equal(t, 3, estimate.Channels)
+	assert.Equal(t, 4, estimate.DayFiles)
+	assert.EqualValues(t, 3510, estimate.PostBytes)
+	assert.EqualValues(t, 150, estimate.MetadataBytes)
+	assert.Equal(t, []ChannelSize{
+		{Channel: "general", Bytes: 3000},
+		{Channel: "random", Bytes: 500},
+		{Channel: "small", Bytes: 10},
+	}, estimate.LargestChannels)
+	assert.EqualValues(t, memoryBaseline+150*4+3510*5, estimate.InMemory)
+	assert.EqualValues(t, memoryBaseline+150*4+3510*3, estimate.WithThreadsCache)
+	assert.EqualValues(t, 6000, estimate.SpillBytes)
+
+	t.Run("only the channels in flight", func(t *testing.T) {
+		estimate := EstimateMemory(r.File, 1)
+		assert.Len(t, estimate.LargestChannels, 3)
+
+		r := memoryTestZip(t, map[string]int{
+			"a/2020-01-01.json": 10, "b/2020-01-01.json": 20, "c/2020-01-01.json": 30,
+			"d/2020-01-01.json": 40, "e/2020-01-01.json": 50, "f/2020-01-01.json": 60,
+		})
+		estimate = EstimateMemory(r.File, 1)
+		require.Len(t, estimate.LargestChannels, 5)
+		assert.Equal(t, "f", estimate.LargestChannels[0].Channel)
+		assert.EqualValues(t, memoryBaseline+(60+50+40+30+20)*5, estimate.InMemory)
+	})
+}
+
+func TestMemoryEstimateRecommend(t *testing.T) {
+	estimate := &MemoryEstimate{
+		LargestChannels:  []ChannelSize{{Channel: "general", Bytes: 1 << 30}},
+		InMemory:         4 << 30,
+		WithThreadsCache: 2 << 30,
+		SpillBytes:       1 << 30,
+	}
+
+	assert.Empty(t, estimate.Recommend(8<<30))
+
+	recommendations := estimate.Recommend(3 << 30)
+	require.Len(t, recommendations, 2)
+	assert.True(t, strings.HasPrefix(recommendations[0], "--threads-cache-size 10000:"))
+	assert.Contains(t, recommendations[0], "1.0 GiB")
+	assert.True(t, strings.HasPrefix(recommendations[1], "--redis-endpoint:"))
+
+	recommendations = estimate.Recommend(1 << 30)
+	require.Len(t, recommendations, 3)
+	assert.True(t, strings.HasPrefix(recommendations[2], "--exclude-channels general:"))
+}
@@ -0,0 +1,90 @@
+package slack
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// ChannelNotifyProps holds the notification preferences set on the
+// memberships of the imported channels.
+type ChannelNotifyProps struct {
+	Desktop    string `json:"desktop"`
+	Mobile     string `json:"mobile"`
+	MarkUnread string `json:"mark_unread"`
+}
+
+var channelNotifyPropsPresets = map[string]ChannelNotifyProps{
+	// muted channels don't show unread badges
+	"muted": {
+		Desktop:    model.ChannelNotifyDefault,
+		Mobile:     model.ChannelNotifyDefault,
+		MarkUnread: model.ChannelMarkUnreadMention,
+	},
+	"mentions": {
+		Desktop:    model.ChannelNotifyMention,
+		Mobile:     model.ChannelNotifyMention,
+		MarkUnread: model.ChannelMarkUnreadAll,
+	},
+}
+
+// ParseChannelNotifyProps returns the notify props of a preset, either
+// "muted" or "mentions".
+func ParseChannelNotifyProps(preset string) (*ChannelNotifyProps, error) {
+	props, ok := channelNotifyPropsPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel notification preset \"%s\", valid values are \"muted\" and \"mentions\"", preset)
+	}
+	return &props, nil
+}
+
+// ApplyChannelNotifyProps sets the notify props on the public channels,
+// and on the private ones if includePrivate is set.
+func (t *Transformer) ApplyChannelNotifyProps(props *ChannelNotifyProps, includePrivate bool) {
+	for _, channel := range t.Intermediate.PublicChannels {
+		channel.NotifyProps = props
+	}
+	if includePrivate {
+		for _, channel := range t.Intermediate.PrivateChannels {
+			channel.NotifyProps = props
+		}
+	}
+}
+
+func (t *Transformer) channelNotifyPropsByName() map[string]*ChannelNotifyProps {
+	propsByName := map[string]*ChannelNotifyProps{}
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+		for _, channel := range channels {
+			if channel.NotifyProps != nil {
+				propsByName[channel.Name] = channel.NotifyProps
+			}
+		}
+	}
+	return propsByName
+}
+
+// setMembershipNotifyProps adds the notify props of the channels to
+// the memberships of a user line.
+func setMembershipNotifyProps(line *app.LineImportData, propsByName map[string]*ChannelNotifyProps) {
+	if len(propsByName) == 0 || line.User == nil || line.User.Teams == nil {
+		return
+	}
+
+	for _, team := range *line.User.Teams {
+		if team.Channels == nil {
+			continue
+		}
+		for i, membership := range *team.Channels {
+			props, ok := propsByName[*membership.Name]
+			if !ok {
+				continue
+			}
+			(*team.Channels)[i].NotifyProps = &app.UserChannelNotifyPropsImportData{
+				Desktop:    model.NewString(props.Desktop),
+				Mobile:     model.NewString(props.Mobile),
+				MarkUnread: model.NewString(props.MarkUnread),
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package slack
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelNotifyProps(t *testing.T) {
+	_, err := ParseChannelNotifyProps("loud")
+	require.Error(t, err)
+
+	props, err := ParseChannelNotifyProps("muted")
+	require.NoError(t, err)
+
+	transformer := NewTransformer("team", log.New())
+	transformer.Intermediate.PublicChannels = []*IntermediateChannel{{Name: "public"}}
+	transformer.Intermediate.PrivateChannels = []*IntermediateChannel{{Name: "private"}}
+	transformer.Intermediate.UsersById = map[string]*IntermediateUser{
+		"u1": {Username: "user1", Memberships: []string{"public", "private"}},
+	}
+	transformer.ApplyChannelNotifyProps(props, false)
+
+	exporter := &recordingExporter{}
+	require.NoError(t, transformer.ExportUsers(exporter))
+	require.Len(t, exporter.lines, 1)
+
+	memberships := *(*exporter.lines[0].User.Teams)[0].Channels
+	require.Len(t, memberships, 2)
+	require.NotNil(t, memberships[0].NotifyProps)
+	assert.Equal(t, "mention", *memberships[0].NotifyProps.MarkUnread)
+	assert.Equal(t, "default", *memberships[0].NotifyProps.Desktop)
+	assert.Nil(t, memberships[1].NotifyProps)
+}
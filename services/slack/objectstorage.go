@@ -0,0 +1,112 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultS3Endpoint  = "s3.amazonaws.com"
+	defaultGCSEndpoint = "storage.googleapis.com"
+)
+
+// ObjectStorageConfig configures the access to the S3 and GCS buckets.
+// The credentials are read from the AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY or MINIO_ACCESS_KEY and MINIO_SECRET_KEY
+// environment variables, the AWS credentials file or the EC2 instance
+// role. GCS buckets are read through their S3 compatible API, with an
+// HMAC key of a service account as the credentials.
+type ObjectStorageConfig struct {
+	// Endpoint overrides the default endpoint of the scheme, e.g. for
+	// MinIO or a regional endpoint
+	Endpoint string
+	Region   string
+	Insecure bool
+}
+
+// objectStorageFetcher reads ranges of an object of a bucket
+type objectStorageFetcher struct {
+	client *minio.Client
+	bucket string
+	key    string
+}
+
+func (o *objectStorageFetcher) FetchRange(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(offset, offset+length-1); err != nil {
+		return nil, err
+	}
+	return o.client.GetObject(ctx, o.bucket, o.key, opts)
+}
+
+// IsObjectStorageURL returns whether the path of the export is an
+// s3:// or gs:// URL
+func IsObjectStorageURL(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "gs://")
+}
+
+// parseObjectStorageURL splits an s3://bucket/key or gs://bucket/key
+// URL
+func parseObjectStorageURL(path string) (scheme, bucket, key string, err error) {
+	schemeEnd := strings.Index(path, "://")
+	if schemeEnd == -1 {
+		return "", "", "", fmt.Errorf("invalid object storage URL %q", path)
+	}
+	scheme = path[:schemeEnd]
+	parts := strings.SplitN(path[schemeEnd+3:], "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid object storage URL %q, expected %s://bucket/key", path, scheme)
+	}
+	return scheme, parts[0], parts[1], nil
+}
+
+// OpenObject opens an export stored in an S3 or GCS bucket, given as
+// an s3://bucket/key or gs://bucket/key URL.
+func OpenObject(ctx context.Context, path string, cfg ObjectStorageConfig) (*RemoteFile, error) {
+	scheme, bucket, key, err := parseObjectStorageURL(path)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := cfg.Endpoint
+	lookup := minio.BucketLookupAuto
+	switch {
+	case endpoint != "":
+		lookup = minio.BucketLookupPath
+	case scheme == "s3":
+		endpoint = defaultS3Endpoint
+	case scheme == "gs":
+		endpoint = defaultGCSEndpoint
+	default:
+		return nil, fmt.Errorf("unsupported object storage scheme %q", scheme)
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+		}),
+		Secure:       !cfg.Insecure,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the object storage client")
+	}
+
+	info, err := client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+
+	return NewRemoteFile(ctx, &objectStorageFetcher{client: client, bucket: bucket, key: key}, info.Size), nil
+}
@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseObjectStorageURL(t *testing.T) {
+	scheme, bucket, key, err := parseObjectStorageURL("s3://exports/slack/2022/export.zip")
+	require.NoError(t, err)
+	assert.Equal(t, "s3", scheme)
+	assert.Equal(t, "exports", bucket)
+	assert.Equal(t, "slack/2022/export.zip", key)
+
+	for _, path := range []string{"s3://exports", "gs:///export.zip", "s3://exports/"} {
+		_, _, _, err := parseObjectStorageURL(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestOpenObject(t *testing.T) {
+	for name, value := range map[string]string{"AWS_ACCESS_KEY_ID": "access", "AWS_SECRET_ACCESS_KEY": "secret"} {
+		defer func(name, value string, ok bool) {
+			if ok {
+				os.Setenv(name, value)
+			} else {
+				os.Unsetenv(name)
+			}
+		}(name, os.Getenv(name), os.Getenv(name) != "")
+		os.Setenv(name, value)
+	}
+
+	data := createEncryptedZip(t, false, "", map[string]string{"channels.json": `[]`})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/exports/slack/export.zip" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		http.ServeContent(w, r, "export.zip", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	remoteFile, err := OpenObject(context.Background(), "s3://exports/slack/export.zip", ObjectStorageConfig{
+		Endpoint: strings.TrimPrefix(server.URL, "http://"),
+		Region:   "us-east-1",
+		Insecure: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), remoteFile.Size())
+
+	zipFS, err := OpenZipFS(remoteFile, remoteFile.Size(), "")
+	require.NoError(t, err)
+	content, err := fs.ReadFile(zipFS, "plain.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "not encrypted", string(content))
+
+	_, err = OpenObject(context.Background(), "s3://exports/missing.zip", ObjectStorageConfig{
+		Endpoint: strings.TrimPrefix(server.URL, "http://"),
+		Region:   "us-east-1",
+		Insecure: true,
+	})
+	require.Error(t, err)
+}
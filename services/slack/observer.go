@@ -0,0 +1,84 @@
+package slack
+
+import "time"
+
+// Stage is a step of the transformation reported to the Observer.
+type Stage string
+
+const (
+	// StageParse parses the export, only its users and channels when
+	// the posts are streamed
+	StageParse Stage = "parse"
+	// StageUsersAndChannels converts the users, the channels and the
+	// memberships
+	StageUsersAndChannels Stage = "users_and_channels"
+	// StagePosts converts the posts channel by channel, and exports
+	// them as well when they are streamed
+	StagePosts Stage = "posts"
+	// StageExport writes the bulk import lines, only those preceding
+	// the posts when the posts are streamed
+	StageExport Stage = "export"
+)
+
+// ChannelProgress is reported once the posts of a channel were
+// transformed, or exported when they are streamed.
+type ChannelProgress struct {
+	Channel string
+	// Source is the number of messages of the channel in the export,
+	// Emitted the number of posts and replies produced for it
+	Source  int
+	Emitted int
+	// Done is the number of channels whose posts were processed,
+	// including this one, out of the Total channels with posts
+	Done  int
+	Total int
+	// Elapsed is the time spent transforming the posts of the channel
+	Elapsed time.Duration
+}
+
+// Observer is notified of the progress of a transformation, e.g. to
+// show it in the UI of a tool embedding the transformation. Its
+// methods are called from the concurrent stages of the pipeline, so
+// they must be safe for concurrent use, and they should return quickly
+// as the transformation waits for them.
+type Observer interface {
+	// StageStarted and StageFinished surround each stage. A stage
+	// that failed or was interrupted is not reported as finished.
+	StageStarted(stage Stage)
+	StageFinished(stage Stage, elapsed time.Duration)
+	ChannelDone(progress ChannelProgress)
+	// Warning is called for each warning as it is raised
+	Warning(warning *Warning)
+}
+
+// NopObserver ignores every notification. It can be embedded to only
+// implement some of the methods of Observer.
+type NopObserver struct{}
+
+func (NopObserver) StageStarted(Stage)                 {}
+func (NopObserver) StageFinished(Stage, time.Duration) {}
+func (NopObserver) ChannelDone(ChannelProgress)        {}
+func (NopObserver) Warning(*Warning)                   {}
+
+// startStage notifies the observer of the start of the stage, and
+// returns the function notifying it of its end
+func (t *Transformer) startStage(stage Stage) func() {
+	if t.Observer == nil {
+		return func() {}
+	}
+	t.Observer.StageStarted(stage)
+	start := time.Now()
+	return func() {
+		t.Observer.StageFinished(stage, time.Since(start))
+	}
+}
+
+// channelDone notifies the observer of the channel of progress, once
+// appended to the completed channels
+func (t *Transformer) channelDone(progress ChannelProgress) {
+	if t.Observer == nil {
+		return
+	}
+	progress.Done = len(t.completedChannels)
+	t.Observer.ChannelDone(progress)
+}
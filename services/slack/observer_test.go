@@ -0,0 +1,140 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver keeps the notifications in memory
+type recordingObserver struct {
+	mu       sync.Mutex
+	stages   []string
+	channels []ChannelProgress
+	warnings []*Warning
+}
+
+func (o *recordingObserver) StageStarted(stage Stage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stages = append(o.stages, "start "+string(stage))
+}
+
+func (o *recordingObserver) StageFinished(stage Stage, elapsed time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stages = append(o.stages, "finish "+string(stage))
+}
+
+func (o *recordingObserver) ChannelDone(progress ChannelProgress) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.channels = append(o.channels, progress)
+}
+
+func (o *recordingObserver) Warning(warning *Warning) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.warnings = append(o.warnings, warning)
+}
+
+func observedExportFS() fstest.MapFS {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "other", "members": ["U1"]}
+	]`)}
+	fsys["other/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "first", "ts": "1577836801.000100"},
+		{"type": "message", "user": "U9", "text": "unknown", "ts": "1577836802.000100"}
+	]`)}
+	return fsys
+}
+
+func TestObserverStreamFS(t *testing.T) {
+	observer := &recordingObserver{}
+	result, err := StreamFS(context.Background(), observedExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		Observer:        observer,
+	}, &recordingExporter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"start parse", "finish parse",
+		"start users_and_channels", "finish users_and_channels",
+		"start export", "finish export",
+		"start posts", "finish posts",
+	}, observer.stages)
+
+	// the pipeline handles the channels in order
+	for i := range observer.channels {
+		assert.Greater(t, int64(observer.channels[i].Elapsed), int64(0))
+		observer.channels[i].Elapsed = 0
+	}
+	assert.Equal(t, []ChannelProgress{
+		{Channel: "general", Source: 2, Emitted: 2, Done: 1, Total: 2},
+		{Channel: "other", Source: 2, Emitted: 1, Done: 2, Total: 2},
+	}, observer.channels)
+
+	assert.Equal(t, result.TransformResult.Warnings, observer.warnings)
+	assert.Equal(t, 1, result.TransformResult.Count(WarningUnknownUser))
+}
+
+func TestObserverTransformFS(t *testing.T) {
+	observer := &recordingObserver{}
+	result, err := TransformFS(context.Background(), observedExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		Observer:        observer,
+	})
+	require.NoError(t, err)
+	require.NoError(t, result.ExportTo(&bytes.Buffer{}))
+
+	assert.Equal(t, []string{
+		"start parse", "finish parse",
+		"start users_and_channels", "finish users_and_channels",
+		"start posts", "finish posts",
+		"start export", "finish export",
+	}, observer.stages)
+
+	require.Len(t, observer.channels, 2)
+	assert.ElementsMatch(t, []string{"general", "other"}, []string{observer.channels[0].Channel, observer.channels[1].Channel})
+	assert.Equal(t, 2, observer.channels[1].Done)
+	assert.Equal(t, 2, observer.channels[1].Total)
+
+	assert.Equal(t, result.TransformResult.Warnings, observer.warnings)
+}
+
+func TestObserverFailedStage(t *testing.T) {
+	observer := &recordingObserver{}
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[{"id": "U1"`)}
+
+	_, err := TransformFS(context.Background(), fsys, Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		Strict:   true,
+		Observer: observer,
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"start parse"}, observer.stages)
+}
+
+func TestNopObserver(t *testing.T) {
+	var observer Observer = NopObserver{}
+	observer.StageStarted(StageParse)
+	observer.StageFinished(StageParse, time.Second)
+	observer.ChannelDone(ChannelProgress{})
+	observer.Warning(&Warning{})
+}
@@ -0,0 +1,73 @@
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// directChannelIDRegex matches the directories of the direct channels,
+// named after their id in the export
+var directChannelIDRegex = regexp.MustCompile(`^D[A-Z0-9]{8,}$`)
+
+// addOrphanedDirectChannels adds the direct channels whose posts are in
+// the export but which are missing from dms.json, as happens with
+// stitched exports, so their posts are not dropped. Their members are
+// the authors of the posts.
+func (t *Transformer) addOrphanedDirectChannels(slackExport *SlackExport) error {
+	known := map[string]bool{}
+	for _, channels := range [][]SlackChannel{slackExport.Channels, slackExport.PublicChannels, slackExport.PrivateChannels, slackExport.GroupChannels, slackExport.DirectChannels} {
+		for _, channel := range channels {
+			known[getOriginalName(channel)] = true
+		}
+	}
+
+	channelNames := make([]string, 0, len(slackExport.PostFiles))
+	for channelName := range slackExport.PostFiles {
+		if !known[channelName] && directChannelIDRegex.MatchString(channelName) {
+			channelNames = append(channelNames, channelName)
+		}
+	}
+	sort.Strings(channelNames)
+
+	for _, channelName := range channelNames {
+		members, err := t.postAuthors(slackExport, channelName)
+		if err != nil {
+			return err
+		}
+		channel := SlackChannel{
+			Id:      channelName,
+			Members: members,
+			Type:    model.ChannelTypeDirect,
+		}
+		slackExport.DirectChannels = append(slackExport.DirectChannels, channel)
+		slackExport.Channels = append(slackExport.Channels, channel)
+		t.warn(&Warning{
+			Kind:    WarningOrphanedDirectChannel,
+			Channel: channelName,
+			Message: fmt.Sprintf("Direct channel %s is missing from dms.json, creating it with the authors of its posts as members: %v", channelName, channel.Members),
+		})
+	}
+	return nil
+}
+
+// postAuthors returns the authors of the posts of a channel
+func (t *Transformer) postAuthors(slackExport *SlackExport, channelName string) ([]string, error) {
+	posts, err := t.peekChannelPosts(slackExport, channelName)
+	if err != nil {
+		return nil, err
+	}
+	authors := []string{}
+	seen := map[string]bool{}
+	for _, post := range posts {
+		if post.User == "" || seen[post.User] {
+			continue
+		}
+		seen[post.User] = true
+		authors = append(authors, post.User)
+	}
+	sort.Strings(authors)
+	return authors, nil
+}
@@ -0,0 +1,64 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func orphanedDirectChannelExportFS() fstest.MapFS {
+	fsys := testExportFS()
+	fsys["D01ABCDEF23/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "are you there?", "ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "yes", "ts": "1577923201.000100"}
+	]`)}
+	fsys["D01SINGLE00/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "note to self", "ts": "1577923202.000100"}
+	]`)}
+	return fsys
+}
+
+func TestTransformFSOrphanedDirectChannels(t *testing.T) {
+	result, err := TransformFS(context.Background(), orphanedDirectChannelExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Intermediate.DirectChannels, 1)
+	channel := result.Intermediate.DirectChannels[0]
+	assert.Equal(t, "D01ABCDEF23", channel.OriginalName)
+	assert.Equal(t, []string{"U1", "U2"}, channel.Members)
+
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.ElementsMatch(t, []string{"hello @jane", "a file", "are you there?", "yes"}, messages)
+
+	// the channel with a single author can't be imported
+	assert.Equal(t, 2, result.TransformResult.Count(WarningOrphanedDirectChannel))
+	assert.Equal(t, 1, result.TransformResult.Count(WarningSingleMemberChannel))
+}
+
+func TestStreamFSOrphanedDirectChannels(t *testing.T) {
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), orphanedDirectChannelExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	assert.Contains(t, buffer.String(), `"type":"direct_channel"`)
+	assert.Contains(t, buffer.String(), "are you there?")
+	assert.Equal(t, 2, result.TransformResult.Count(WarningOrphanedDirectChannel))
+	// the posts of the channel with a single author are still dropped
+	assert.Equal(t, 1, result.TransformResult.Count(WarningUnknownChannel))
+}
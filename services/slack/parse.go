@@ -2,12 +2,16 @@ package slack
 
 import (
 	"archive/zip"
+	"context"
 	"encoding/json"
 	"io"
+	"io/fs"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mattermost/mattermost-server/v6/model"
@@ -20,7 +24,14 @@ type SlackChannel struct {
 	Members []string        `json:"members"`
 	Purpose SlackChannelSub `json:"purpose"`
 	Topic   SlackChannelSub `json:"topic"`
-	Type    model.ChannelType
+	Pins    []SlackPin      `json:"pins"`
+	// IsArchived is set on the channels archived in Slack
+	IsArchived bool `json:"is_archived"`
+	// ContextTeamId is the workspace of the channel in an Enterprise
+	// Grid export, SharedTeamIds the workspaces it is shared with
+	ContextTeamId string   `json:"context_team_id"`
+	SharedTeamIds []string `json:"shared_team_ids"`
+	Type          model.ChannelType
 }
 
 type SlackChannelSub struct {
@@ -28,27 +39,94 @@ type SlackChannelSub struct {
 }
 
 type SlackProfile struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email"`
-	Title     string `json:"title"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	RealName    string `json:"real_name"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Title       string `json:"title"`
+	// StatusExpiration is the unix time the status expires at, zero
+	// when it doesn't expire
+	StatusText       string `json:"status_text"`
+	StatusEmoji      string `json:"status_emoji"`
+	StatusExpiration int64  `json:"status_expiration"`
+	// Fields are the custom fields of the profile
+	Fields SlackProfileFields `json:"fields"`
+	SlackAvatar
 }
 
 type SlackUser struct {
 	Id       string       `json:"id"`
 	Username string       `json:"name"`
 	Profile  SlackProfile `json:"profile"`
+	IsAdmin  bool         `json:"is_admin"`
+	IsOwner  bool         `json:"is_owner"`
+	// restricted users are multi-channel guests and ultra restricted
+	// users are single-channel guests
+	IsRestricted      bool `json:"is_restricted"`
+	IsUltraRestricted bool `json:"is_ultra_restricted"`
+}
+
+func (u *SlackUser) IsGuest() bool {
+	return u.IsRestricted || u.IsUltraRestricted
 }
 
 type SlackFile struct {
 	Id   string `json:"id"`
 	Name string `json:"name"`
+	// User uploaded the file, it can differ from the author of the
+	// message sharing it
+	User string `json:"user"`
+}
+
+type SlackBotProfile struct {
+	Id    string `json:"id"`
+	AppId string `json:"app_id"`
+	Name  string `json:"name"`
+}
+
+// SlackText holds a block kit text value, which Slack encodes either
+// as a plain string or as a text object depending on the element.
+type SlackText string
+
+func (t *SlackText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = SlackText(text)
+		return nil
+	}
+
+	var textObject struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &textObject); err != nil {
+		return err
+	}
+	*t = SlackText(textObject.Text)
+	return nil
+}
+
+type SlackBlockElement struct {
+	Type     string               `json:"type"`
+	Text     SlackText            `json:"text"`
+	Elements []*SlackBlockElement `json:"elements"`
+	// Language is set on the rich_text_preformatted elements of code
+	// blocks with a language
+	Language string `json:"language"`
+}
+
+type SlackBlock struct {
+	Type     string               `json:"type"`
+	Text     SlackText            `json:"text"`
+	Fields   []SlackText          `json:"fields"`
+	Elements []*SlackBlockElement `json:"elements"`
 }
 
 type SlackPost struct {
 	User        string                   `json:"user"`
 	BotId       string                   `json:"bot_id"`
 	BotUsername string                   `json:"username"`
+	BotProfile  *SlackBotProfile         `json:"bot_profile"`
 	Text        string                   `json:"text"`
 	TimeStamp   string                   `json:"ts"`
 	ThreadTS    string                   `json:"thread_ts"`
@@ -59,6 +137,45 @@ type SlackPost struct {
 	File        *SlackFile               `json:"file"`
 	Files       []*SlackFile             `json:"files"`
 	Attachments []*model.SlackAttachment `json:"attachments"`
+	Blocks      []*SlackBlock            `json:"blocks"`
+	Reactions   []SlackReaction          `json:"reactions"`
+	Edited      *SlackEdited             `json:"edited"`
+	// Room is only set on huddle_thread messages
+	Room *SlackHuddleRoom `json:"room"`
+	// PinnedTo are the channels the message is pinned to
+	PinnedTo []string `json:"pinned_to"`
+	// OldName and Name are only set on channel_name messages
+	OldName string `json:"old_name"`
+	Name    string `json:"name"`
+	// ReplyCount, ReplyUsersCount and ReplyUsers are only set on the
+	// root post of a thread
+	ReplyCount      int      `json:"reply_count"`
+	ReplyUsersCount int      `json:"reply_users_count"`
+	ReplyUsers      []string `json:"reply_users"`
+	// Message, PreviousMessage and DeletedTS are only present in the
+	// message_changed and message_deleted events of compliance exports
+	Message         *SlackPost `json:"message"`
+	PreviousMessage *SlackPost `json:"previous_message"`
+	DeletedTS       string     `json:"deleted_ts"`
+
+	legalHold *legalHoldRecord
+}
+
+type SlackEdited struct {
+	User      string `json:"user"`
+	TimeStamp string `json:"ts"`
+}
+
+// nestedMessages returns the message revisions embedded in the post
+func (p *SlackPost) nestedMessages() []*SlackPost {
+	nested := []*SlackPost{}
+	if p.Message != nil {
+		nested = append(nested, p.Message)
+	}
+	if p.PreviousMessage != nil {
+		nested = append(nested, p.PreviousMessage)
+	}
+	return nested
 }
 
 func (p *SlackPost) IsPlainMessage() bool {
@@ -106,8 +223,57 @@ type SlackExport struct {
 	GroupChannels   []SlackChannel
 	DirectChannels  []SlackChannel
 	Users           []SlackUser
-	Posts           map[string][]SlackPost
-	Uploads         map[string]*zip.File
+	// ExcludedUsers are the users of the export left out of the
+	// import, whose mentions are rendered as their name
+	ExcludedUsers []SlackUser
+	Posts         map[string][]SlackPost
+	// Stars holds the starred items of each user id, only present in
+	// exports including a stars.json file
+	Stars map[string][]SlackStar
+	// Emojis holds the custom emojis of the workspace, only present in
+	// exports including an emoji.json file, see CustomEmojiConfig
+	Emojis map[string]string
+	// UserGroups holds the user groups of the workspace, from the
+	// usergroups.json file of the export or the UserGroups of the
+	// transformer
+	UserGroups []SlackUserGroup
+	// Bots holds the bots of the workspace, only present in exports
+	// including a bots.json file
+	Bots []SlackBot
+	// PostFiles holds the paths of the day files of each channel
+	PostFiles map[string][]string
+	// Uploads holds the path of each uploaded file in FS by file id
+	Uploads map[string]string
+	// Schema is the layout of the export, see DetectExportSchema
+	Schema    ExportSchema
+	FS        fs.FS
+	converter *postsConverter
+	// ChannelRenames maps the previous names of the channels to the
+	// name they were renamed to, only set when some posts are filed
+	// under a previous name, see resolveChannelRename
+	ChannelRenames map[string]string
+	// mergedChannels holds the channels with posts from supplemental
+	// exports or filed under a previous name, which can overlap with
+	// the main export
+	mergedChannels map[string]bool
+	// postCounts holds the number of posts of the channels read by
+	// peekChannelPosts
+	postCounts map[string]int
+}
+
+// SlackStar is an item starred by a user, as returned by the
+// stars.list API method
+type SlackStar struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+}
+
+func SlackParseStars(data io.Reader) (map[string][]SlackStar, error) {
+	decoder := json.NewDecoder(data)
+
+	var stars map[string][]SlackStar
+	err := decoder.Decode(&stars)
+	return stars, err
 }
 
 func SlackParseUsers(data io.Reader) ([]SlackUser, error) {
@@ -148,7 +314,7 @@ func SlackParsePosts(data io.Reader) ([]SlackPost, error) {
 	return posts, nil
 }
 
-func SlackConvertUserMentions(users []SlackUser, posts map[string][]SlackPost) map[string][]SlackPost {
+func slackUserMentionRegexes(users []SlackUser) map[string]*regexp.Regexp {
 	var regexes = make(map[string]*regexp.Regexp, len(users))
 	for _, user := range users {
 		r, err := regexp.Compile("<@" + user.Id + `(\|` + user.Username + ")?>")
@@ -164,19 +330,12 @@ func SlackConvertUserMentions(users []SlackUser, posts map[string][]SlackPost) m
 	regexes["@channel"], _ = regexp.Compile("<!channel>")
 	regexes["@all"], _ = regexp.Compile("<!everyone>")
 
-	for channelName, channelPosts := range posts {
-		for postIdx, post := range channelPosts {
-			for mention, r := range regexes {
-				post.Text = r.ReplaceAllString(post.Text, mention)
-				posts[channelName][postIdx] = post
-			}
-		}
-	}
-
-	return posts
+	return regexes
 }
 
-func SlackConvertChannelMentions(channels []SlackChannel, posts map[string][]SlackPost) map[string][]SlackPost {
+// slackChannelMentionRegexes converts the mentions of channels, whose
+// name starts with the ChannelPrefix of the transformer when set
+func slackChannelMentionRegexes(channels []SlackChannel, channelPrefix string) map[string]*regexp.Regexp {
 	var regexes = make(map[string]*regexp.Regexp, len(channels))
 	for _, channel := range channels {
 		r, err := regexp.Compile("<#" + channel.Id + `(\|` + channel.Name + ")?>")
@@ -184,13 +343,20 @@ func SlackConvertChannelMentions(channels []SlackChannel, posts map[string][]Sla
 			log.Println("Slack Import: Unable to compile the !channel, matching regular expression for the Slack channel. channel_id=" + channel.Id + " channel_name" + channel.Name)
 			continue
 		}
-		regexes["~"+channel.Name] = r
+		regexes["~"+mattermostChannelName(channelPrefix, channel.Name, channel.Type)] = r
 	}
 
+	return regexes
+}
+
+func replaceMentions(posts map[string][]SlackPost, regexes map[string]*regexp.Regexp) map[string][]SlackPost {
 	for channelName, channelPosts := range posts {
 		for postIdx, post := range channelPosts {
-			for channelReplace, r := range regexes {
-				post.Text = r.ReplaceAllString(post.Text, channelReplace)
+			for mention, r := range regexes {
+				post.Text = r.ReplaceAllString(post.Text, mention)
+				for _, nested := range post.nestedMessages() {
+					nested.Text = r.ReplaceAllString(nested.Text, mention)
+				}
 				posts[channelName][postIdx] = post
 			}
 		}
@@ -199,64 +365,146 @@ func SlackConvertChannelMentions(channels []SlackChannel, posts map[string][]Sla
 	return posts
 }
 
-func SlackConvertPostsMarkup(posts map[string][]SlackPost) map[string][]SlackPost {
-	regexReplaceAllString := []struct {
-		regex *regexp.Regexp
-		rpl   string
-	}{
-		// URL
-		{
-			regexp.MustCompile(`<([^|<>]+)\|([^|<>]+)>`),
-			"[$2]($1)",
-		},
-		// bold
-		{
-			regexp.MustCompile(`(^|[\s.;,])\*(\S[^*\n]+)\*`),
-			"$1**$2**",
-		},
-		// strikethrough
-		{
-			regexp.MustCompile(`(^|[\s.;,])\~(\S[^~\n]+)\~`),
-			"$1~~$2~~",
-		},
-		// single paragraph blockquote
-		// Slack converts > character to &gt;
-		{
-			regexp.MustCompile(`(?sm)^&gt;`),
-			">",
-		},
+func SlackConvertUserMentions(users []SlackUser, posts map[string][]SlackPost) map[string][]SlackPost {
+	return replaceMentions(posts, slackUserMentionRegexes(users))
+}
+
+func SlackConvertChannelMentions(channels []SlackChannel, posts map[string][]SlackPost) map[string][]SlackPost {
+	return replaceMentions(posts, slackChannelMentionRegexes(channels, ""))
+}
+
+// postsConverter applies the mention and markup conversions to posts,
+// compiling the mention expressions only once for the whole export.
+type postsConverter struct {
+	userMentions     map[string]*regexp.Regexp
+	excludedMentions map[string]*regexp.Regexp
+	importedUsers    map[string]bool
+	channelMentions  map[string]*regexp.Regexp
+	// userGroups are the handles of the user groups by Slack id
+	userGroups map[string]string
+	// permalinks rewrites the links to Slack messages when set
+	permalinks *permalinkRewriter
+	// links rewrites the links to the tools replaced along with Slack
+	// when set
+	links *linkRewriter
+}
+
+func newPostsConverter(users, excludedUsers []SlackUser, channels []SlackChannel, channelPrefix string) *postsConverter {
+	importedUsers := make(map[string]bool, len(users))
+	for _, user := range users {
+		importedUsers[user.Id] = true
+	}
+	return &postsConverter{
+		userMentions:     slackUserMentionRegexes(users),
+		excludedMentions: slackExcludedUserMentionRegexes(excludedUsers),
+		importedUsers:    importedUsers,
+		channelMentions:  slackChannelMentionRegexes(channels, channelPrefix),
 	}
+}
 
-	regexReplaceAllStringFunc := []struct {
-		regex *regexp.Regexp
-		fn    func(string) string
-	}{
-		// multiple paragraphs blockquotes
-		{
-			regexp.MustCompile(`(?sm)^>&gt;&gt;(.+)$`),
-			func(src string) string {
-				// remove >>> prefix, might have leading \n
-				prefixRegexp := regexp.MustCompile(`^([\n])?>&gt;&gt;(.*)`)
-				src = prefixRegexp.ReplaceAllString(src, "$1$2")
-				// append > to start of line
-				appendRegexp := regexp.MustCompile(`(?m)^`)
-				return appendRegexp.ReplaceAllString(src, ">$0")
-			},
+func (c *postsConverter) convert(posts map[string][]SlackPost) map[string][]SlackPost {
+	if c.permalinks != nil {
+		posts = c.permalinks.rewrite(posts)
+	}
+	if c.links != nil {
+		posts = c.links.rewrite(posts)
+	}
+	posts = replaceMentions(posts, c.userMentions)
+	posts = replaceMentions(posts, c.excludedMentions)
+	posts = replaceUnknownUserMentions(posts, c.importedUsers)
+	posts = replaceUserGroupMentions(posts, c.userGroups)
+	posts = replaceMentions(posts, c.channelMentions)
+	return SlackConvertPostsMarkup(posts)
+}
+
+// convertText applies the conversions of the posts to a single text
+func (c *postsConverter) convertText(text string) string {
+	if text == "" {
+		return text
+	}
+	posts := c.convert(map[string][]SlackPost{"": {{Text: text}}})
+	return posts[""][0].Text
+}
+
+// convertChannels converts the mentions, markup and emojis of the
+// topic and purpose of channels, e.g. the links to a wiki
+func (c *postsConverter) convertChannels(channels []SlackChannel) {
+	for i := range channels {
+		channels[i].Topic.Value = convertEmojiShortcodes(c.convertText(channels[i].Topic.Value))
+		channels[i].Purpose.Value = convertEmojiShortcodes(c.convertText(channels[i].Purpose.Value))
+	}
+}
+
+var markupReplaceAllString = []struct {
+	regex *regexp.Regexp
+	rpl   string
+}{
+	// URL
+	{
+		regexp.MustCompile(`<([^|<>]+)\|([^|<>]+)>`),
+		"[$2]($1)",
+	},
+	// bold
+	{
+		regexp.MustCompile(`(^|[\s.;,])\*(\S[^*\n]+)\*`),
+		"$1**$2**",
+	},
+	// strikethrough
+	{
+		regexp.MustCompile(`(^|[\s.;,])\~(\S[^~\n]+)\~`),
+		"$1~~$2~~",
+	},
+	// single paragraph blockquote
+	// Slack converts > character to &gt;
+	{
+		regexp.MustCompile(`(?sm)^&gt;`),
+		">",
+	},
+}
+
+var markupReplaceAllStringFunc = []struct {
+	regex *regexp.Regexp
+	fn    func(string) string
+}{
+	// multiple paragraphs blockquotes
+	{
+		regexp.MustCompile(`(?sm)^>&gt;&gt;(.+)$`),
+		func(src string) string {
+			// remove >>> prefix, might have leading \n
+			prefixRegexp := regexp.MustCompile(`^([\n])?>&gt;&gt;(.*)`)
+			src = prefixRegexp.ReplaceAllString(src, "$1$2")
+			// append > to start of line
+			appendRegexp := regexp.MustCompile(`(?m)^`)
+			return appendRegexp.ReplaceAllString(src, ">$0")
 		},
+	},
+}
+
+// SlackConvertMarkup converts the Slack mrkdwn of a single text into
+// Mattermost Markdown.
+func SlackConvertMarkup(text string) string {
+	return convertMarkupWithCode(text, nil, convertMarkup)
+}
+
+// convertMarkup converts mrkdwn without code blocks
+func convertMarkup(text string) string {
+	for _, rule := range markupReplaceAllString {
+		text = rule.regex.ReplaceAllString(text, rule.rpl)
 	}
 
+	for _, rule := range markupReplaceAllStringFunc {
+		text = rule.regex.ReplaceAllStringFunc(text, rule.fn)
+	}
+	return text
+}
+
+func SlackConvertPostsMarkup(posts map[string][]SlackPost) map[string][]SlackPost {
 	for channelName, channelPosts := range posts {
 		for postIdx, post := range channelPosts {
-			result := post.Text
-
-			for _, rule := range regexReplaceAllString {
-				result = rule.regex.ReplaceAllString(result, rule.rpl)
-			}
-
-			for _, rule := range regexReplaceAllStringFunc {
-				result = rule.regex.ReplaceAllStringFunc(result, rule.fn)
+			posts[channelName][postIdx].Text = convertMarkupWithCode(post.Text, post.codeLanguages(), convertMarkup)
+			for _, nested := range post.nestedMessages() {
+				nested.Text = convertMarkupWithCode(nested.Text, nested.codeLanguages(), convertMarkup)
 			}
-			posts[channelName][postIdx].Text = result
 		}
 	}
 
@@ -264,55 +512,283 @@ func SlackConvertPostsMarkup(posts map[string][]SlackPost) map[string][]SlackPos
 }
 
 func (t *Transformer) ParseSlackExportFile(zipReader *zip.Reader, skipConvertPosts bool) (*SlackExport, error) {
-	slackExport := SlackExport{TeamName: t.TeamName}
-	slackExport.Posts = make(map[string][]SlackPost)
-	slackExport.Uploads = make(map[string]*zip.File)
+	return t.ParseSlackExportFS(context.Background(), zipReader, skipConvertPosts)
+}
 
-	for _, file := range zipReader.File {
-		reader, err := file.Open()
-		if err != nil {
-			return nil, err
+func (t *Transformer) parseSlackExportEntry(slackExport *SlackExport, fsys fs.FS, filePath string, parsePosts bool) error {
+	spl := strings.Split(filePath, "/")
+	if len(spl) == 3 && spl[0] == "__uploads" {
+		slackExport.addUpload(spl[1], filePath)
+		return nil
+	}
+	if !strings.HasSuffix(filePath, ".json") || len(spl) > 2 {
+		return nil
+	}
+	if len(spl) == 2 {
+		slackExport.PostFiles[spl[0]] = append(slackExport.PostFiles[spl[0]], filePath)
+		if !parsePosts {
+			return nil
 		}
+	}
 
-		if file.Name == "channels.json" {
-			slackExport.PublicChannels, _ = SlackParseChannels(reader, model.ChannelTypeOpen)
-			slackExport.Channels = append(slackExport.Channels, slackExport.PublicChannels...)
-		} else if file.Name == "dms.json" {
-			slackExport.DirectChannels, _ = SlackParseChannels(reader, model.ChannelTypeDirect)
+	reader, err := fsys.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	switch filePath {
+	case "channels.json":
+		slackExport.PublicChannels, err = t.parseChannelsFile(filePath, reader, model.ChannelTypeOpen)
+		slackExport.Channels = append(slackExport.Channels, slackExport.PublicChannels...)
+	case "dms.json":
+		slackExport.DirectChannels, err = t.parseChannelsFile(filePath, reader, model.ChannelTypeDirect)
+		slackExport.Channels = append(slackExport.Channels, slackExport.DirectChannels...)
+	case "ims.json":
+		if slackExport.Schema == SchemaLegacyIMs {
+			slackExport.DirectChannels, err = t.parseChannelsFile(filePath, reader, model.ChannelTypeDirect)
 			slackExport.Channels = append(slackExport.Channels, slackExport.DirectChannels...)
-		} else if file.Name == "groups.json" {
-			slackExport.PrivateChannels, _ = SlackParseChannels(reader, model.ChannelTypePrivate)
-			slackExport.Channels = append(slackExport.Channels, slackExport.PrivateChannels...)
-		} else if file.Name == "mpims.json" {
-			slackExport.GroupChannels, _ = SlackParseChannels(reader, model.ChannelTypeGroup)
-			slackExport.Channels = append(slackExport.Channels, slackExport.GroupChannels...)
-		} else if file.Name == "users.json" {
-			slackExport.Users, _ = SlackParseUsers(reader)
-		} else {
-			spl := strings.Split(file.Name, "/")
-			if len(spl) == 2 && strings.HasSuffix(spl[1], ".json") {
-				newposts, _ := SlackParsePosts(reader)
-				channel := spl[0]
-				if _, ok := slackExport.Posts[channel]; !ok {
-					slackExport.Posts[channel] = newposts
-				} else {
-					slackExport.Posts[channel] = append(slackExport.Posts[channel], newposts...)
-				}
-			} else if len(spl) == 3 && spl[0] == "__uploads" {
-				slackExport.Uploads[spl[1]] = file
-			}
 		}
+	case "groups.json":
+		slackExport.PrivateChannels, err = t.parseChannelsFile(filePath, reader, model.ChannelTypePrivate)
+		slackExport.Channels = append(slackExport.Channels, slackExport.PrivateChannels...)
+	case "mpims.json":
+		slackExport.GroupChannels, err = t.parseChannelsFile(filePath, reader, model.ChannelTypeGroup)
+		slackExport.Channels = append(slackExport.Channels, slackExport.GroupChannels...)
+	case "users.json":
+		slackExport.Users, err = t.parseUsersFile(filePath, reader)
+	case "stars.json":
+		slackExport.Stars, err = t.parseStarsFile(filePath, reader)
+	case "usergroups.json":
+		if slackExport.UserGroups, err = SlackParseUserGroups(reader); err != nil {
+			err = errors.Wrapf(err, "failed to parse %s", filePath)
+		}
+	case "bots.json":
+		if slackExport.Bots, err = SlackParseBots(reader); err != nil {
+			err = errors.Wrapf(err, "failed to parse %s", filePath)
+		}
+	case "emoji.json":
+		if slackExport.Emojis, err = SlackParseEmojiList(reader); err != nil {
+			err = errors.Wrapf(err, "failed to parse %s", filePath)
+		}
+	default:
+		if len(spl) == 2 {
+			var newposts []SlackPost
+			newposts, err = t.parsePostsFile(filePath, spl[0], reader)
+			channel := spl[0]
+			slackExport.Posts[channel] = append(slackExport.Posts[channel], newposts...)
+		}
+	}
+
+	return err
+}
+
+func newSlackExport(teamName string, fsys fs.FS) *SlackExport {
+	return &SlackExport{
+		TeamName:  teamName,
+		FS:        fsys,
+		Posts:     make(map[string][]SlackPost),
+		PostFiles: make(map[string][]string),
+		Uploads:   make(map[string]string),
+	}
+}
+
+func (t *Transformer) walkSlackExport(ctx context.Context, slackExport *SlackExport, fsys fs.FS, parsePosts bool) error {
+	return fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		return t.parseSlackExportEntry(slackExport, fsys, filePath, parsePosts)
+	})
+}
+
+func (t *Transformer) parseSlackExportFS(ctx context.Context, fsys fs.FS, skipConvertPosts, parsePosts bool) (*SlackExport, error) {
+	schema, err := DetectExportSchema(fsys)
+	if err != nil {
+		return nil, err
+	}
+	t.Logger.Infof("Parsing a Slack export with the %s layout", schema)
+
+	slackExport := newSlackExport(t.TeamName, fsys)
+	slackExport.Schema = schema
+	if err := t.walkSlackExport(ctx, slackExport, fsys, parsePosts); err != nil {
+		return nil, err
+	}
+
+	for i, supplementFS := range t.SupplementalExports {
+		supplement := newSlackExport(t.TeamName, supplementFS)
+		if err := t.walkSlackExport(ctx, supplement, supplementFS, parsePosts); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse supplemental export %d", i+1)
+		}
+		t.mergeSupplementalExport(slackExport, supplement, i)
+	}
+	if len(t.SupplementalExports) > 0 {
+		slackExport.FS = &supplementalFS{main: fsys, supplements: t.SupplementalExports}
+	}
+
+	if err := t.addOrphanedDirectChannels(slackExport); err != nil {
+		return nil, err
+	}
+	if err := t.mergeRenamedChannels(slackExport); err != nil {
+		return nil, err
+	}
+
+	if t.ErasureList != nil {
+		t.eraseSubjects(slackExport)
+		for channelName, channelPosts := range slackExport.Posts {
+			slackExport.Posts[channelName] = t.erasePosts(channelPosts)
+		}
+	}
+
+	if err := t.EnrichUsers(ctx, slackExport.Users); err != nil {
+		return nil, err
+	}
+
+	t.FixUsernames(slackExport.Users)
+
+	if len(slackExport.UserGroups) == 0 {
+		slackExport.UserGroups = t.UserGroups
+	}
+
+	// the emails completed by the Slack API are excluded as well
+	if err := t.excludeEmailDomains(slackExport); err != nil {
+		return nil, err
 	}
 
 	if !skipConvertPosts {
+		slackExport.converter = newPostsConverter(slackExport.Users, slackExport.ExcludedUsers, slackExport.Channels, t.ChannelPrefix)
+		slackExport.converter.userGroups = userGroupHandles(slackExport.UserGroups)
+		if t.RewritePermalinks {
+			slackExport.converter.permalinks = newPermalinkRewriter(t.PermalinkSiteURL, t.TeamName, slackExport.Channels, t.ChannelPrefix)
+			slackExport.converter.permalinks.teams = t.TeamMapping
+		}
+		if len(t.LinkRewrites) > 0 {
+			slackExport.converter.links = newLinkRewriter(t.LinkRewrites)
+		}
+		// Channels holds a copy of the channels of every other list
+		for _, channels := range [][]SlackChannel{slackExport.Channels, slackExport.PublicChannels, slackExport.PrivateChannels, slackExport.GroupChannels, slackExport.DirectChannels} {
+			slackExport.converter.convertChannels(channels)
+		}
+	}
+
+	return slackExport, nil
+}
+
+// ParseSlackExportFS parses a Slack export laid out as in the export
+// zip file. Both a *zip.Reader and an extracted export opened with
+// os.DirFS can be used as the file system.
+func (t *Transformer) ParseSlackExportFS(ctx context.Context, fsys fs.FS, skipConvertPosts bool) (*SlackExport, error) {
+	finishStage := t.startStage(StageParse)
+	slackExport, err := t.parseSlackExportFS(ctx, fsys, skipConvertPosts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	slackExport.Posts = SlackConvertPollMessages(slackExport.Posts)
+
+	if slackExport.converter != nil {
 		t.Logger.Info("Converting post mentions and markup")
 		start := time.Now()
-		slackExport.Posts = SlackConvertUserMentions(slackExport.Users, slackExport.Posts)
-		slackExport.Posts = SlackConvertChannelMentions(slackExport.Channels, slackExport.Posts)
-		slackExport.Posts = SlackConvertPostsMarkup(slackExport.Posts)
+		slackExport.Posts = slackExport.converter.convert(slackExport.Posts)
 		elapsed := time.Since(start)
-		t.Logger.Debug("Converting mentions finished (%s)", elapsed)
+		t.Logger.Debugf("Converting mentions finished (%s)", elapsed)
+	}
+
+	finishStage()
+	return slackExport, nil
+}
+
+// ParseSlackExportMetadataFS parses the users and channels of a Slack
+// export, only recording where the post files are so the posts can be
+// parsed channel by channel with ParseChannelPosts.
+func (t *Transformer) ParseSlackExportMetadataFS(ctx context.Context, fsys fs.FS, skipConvertPosts bool) (*SlackExport, error) {
+	finishStage := t.startStage(StageParse)
+	slackExport, err := t.parseSlackExportFS(ctx, fsys, skipConvertPosts, false)
+	if err != nil {
+		return nil, err
 	}
 
-	return &slackExport, nil
+	finishStage()
+	return slackExport, nil
+}
+
+// peekChannelPosts returns the posts of a channel without converting
+// them, reading its files when the posts are not parsed yet, e.g. to
+// inspect them before they are streamed. The number of posts read is
+// kept for channelPostCount. A file that can't be opened fails, as a
+// corrupt one does in strict mode, otherwise the posts recovered from
+// a corrupt file are returned with a warning.
+func (t *Transformer) peekChannelPosts(slackExport *SlackExport, channelName string) ([]SlackPost, error) {
+	if posts, ok := slackExport.Posts[channelName]; ok {
+		return posts, nil
+	}
+	channelPosts := []SlackPost{}
+	for _, filePath := range slackExport.PostFiles[channelName] {
+		reader, err := slackExport.FS.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		posts, err := t.parsePostsFile(filePath, channelName, reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		channelPosts = append(channelPosts, posts...)
+	}
+
+	if slackExport.postCounts == nil {
+		slackExport.postCounts = map[string]int{}
+	}
+	slackExport.postCounts[channelName] = len(channelPosts)
+	return channelPosts, nil
+}
+
+// channelPostCount returns the number of posts of a channel, reading
+// its files only when no policy read them before
+func (t *Transformer) channelPostCount(slackExport *SlackExport, channelName string) (int, error) {
+	if posts, ok := slackExport.Posts[channelName]; ok {
+		return len(posts), nil
+	}
+	if count, ok := slackExport.postCounts[channelName]; ok {
+		return count, nil
+	}
+	posts, err := t.peekChannelPosts(slackExport, channelName)
+	if err != nil {
+		return 0, err
+	}
+	return len(posts), nil
+}
+
+// ParseChannelPosts parses and converts the posts of a single channel
+// of the export.
+func (t *Transformer) ParseChannelPosts(slackExport *SlackExport, channelName string) ([]SlackPost, error) {
+	channelPosts := []SlackPost{}
+	for _, filePath := range slackExport.PostFiles[channelName] {
+		reader, err := slackExport.FS.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		newposts, err := t.parsePostsFile(filePath, channelName, reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		channelPosts = append(channelPosts, newposts...)
+	}
+	if slackExport.mergedChannels[channelName] {
+		channelPosts = deduplicatePosts(channelPosts)
+	}
+	channelPosts = t.erasePosts(channelPosts)
+
+	posts := SlackConvertPollMessages(map[string][]SlackPost{channelName: channelPosts})
+	if slackExport.converter != nil {
+		posts = slackExport.converter.convert(posts)
+	}
+	return posts[channelName], nil
 }
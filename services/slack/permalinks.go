@@ -0,0 +1,124 @@
+package slack
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// slackPermalinkRegex matches the links to the channels and messages
+// of Slack, either from a workspace or from the web client, with the
+// label Slack sometimes adds to them:
+// https://acme.slack.com/archives/C1/p1577836800000100
+// https://app.slack.com/client/T1/C1/thread/C1-1577836800.000100
+var slackPermalinkRegex = regexp.MustCompile(`<https?://(?:[a-z0-9-]+\.)*slack\.com/` +
+	`(?:archives/([A-Z0-9]+)(?:/p(\d{10})(\d{6}))?|client/[A-Z0-9]+/([A-Z0-9]+)(?:/thread/[A-Z0-9]+-(\d{10})\.(\d{6}))?)` +
+	`[^|>]*(?:\|([^>]*))?>`)
+
+const permalinkTimeLayout = "2006-01-02 15:04 MST"
+
+// permalinkRewriter replaces the Slack permalinks of the posts with a
+// reference to the imported channel, linked to the channel on the
+// Mattermost site when its URL is known. The posts are given new ids
+// by the import, so the reference can't link to the message itself.
+type permalinkRewriter struct {
+	siteURL  string
+	teamName string
+	channels map[string]SlackChannel
+	prefix   string
+	// teams links the channels of the TeamMapping to their team
+	teams TeamMapping
+}
+
+func newPermalinkRewriter(siteURL, teamName string, channels []SlackChannel, channelPrefix string) *permalinkRewriter {
+	channelsByID := make(map[string]SlackChannel, len(channels))
+	for _, channel := range channels {
+		channelsByID[channel.Id] = channel
+	}
+	return &permalinkRewriter{
+		siteURL:  strings.TrimSuffix(siteURL, "/"),
+		teamName: teamName,
+		channels: channelsByID,
+		prefix:   channelPrefix,
+	}
+}
+
+// ValidateSiteURL checks that the site URL the permalinks are
+// rewritten to is an absolute http or https URL.
+func ValidateSiteURL(siteURL string) error {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid site URL %s", siteURL)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("the site URL %s must be an absolute http or https URL", siteURL)
+	}
+	return nil
+}
+
+func (r *permalinkRewriter) rewrite(posts map[string][]SlackPost) map[string][]SlackPost {
+	return replacePostsText(posts, r.rewriteText)
+}
+
+func (r *permalinkRewriter) rewriteText(text string) string {
+	return slackPermalinkRegex.ReplaceAllStringFunc(text, func(link string) string {
+		match := slackPermalinkRegex.FindStringSubmatch(link)
+		channelID, seconds, micros := match[1], match[2], match[3]
+		if channelID == "" {
+			channelID, seconds, micros = match[4], match[5], match[6]
+		}
+		channel, ok := r.channels[channelID]
+		if !ok {
+			return link
+		}
+		return r.reference(channel, seconds, micros, match[7])
+	})
+}
+
+// reference renders the permalink of a message of the channel, or of
+// the channel itself when seconds is empty
+func (r *permalinkRewriter) reference(channel SlackChannel, seconds, micros, label string) string {
+	direct := channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup
+	name := mattermostChannelName(r.prefix, SlackConvertChannelName(channel.Name, channel.Id), channel.Type)
+
+	var reference string
+	switch {
+	case seconds != "" && direct:
+		reference = "direct message of " + permalinkTime(seconds, micros)
+	case seconds != "":
+		reference = fmt.Sprintf("message of %s in ~%s", permalinkTime(seconds, micros), name)
+	case direct:
+		reference = "direct message"
+	default:
+		reference = "~" + name
+	}
+
+	if r.siteURL == "" || direct {
+		if label != "" && label != reference {
+			return fmt.Sprintf("%s (%s)", label, reference)
+		}
+		return reference
+	}
+
+	// the mentions are not rendered in the text of links
+	if label == "" {
+		label = strings.Replace(reference, "~"+name, name, 1)
+	}
+	teamName := r.teamName
+	if team := r.teams.team(channel); team != "" {
+		teamName = team
+	}
+	return fmt.Sprintf("<%s/%s/channels/%s|%s>", r.siteURL, teamName, name, label)
+}
+
+func permalinkTime(seconds, micros string) string {
+	sec, _ := strconv.ParseInt(seconds, 10, 64)
+	usec, _ := strconv.ParseInt(micros, 10, 64)
+	return time.Unix(sec, usec*int64(time.Microsecond)).UTC().Format(permalinkTimeLayout)
+}
@@ -0,0 +1,116 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermalinkRewriter(t *testing.T) {
+	channels := []SlackChannel{
+		{Id: "C1", Name: "general", Type: model.ChannelTypeOpen},
+		{Id: "D1", Name: "D1", Type: model.ChannelTypeDirect},
+	}
+
+	testCases := []struct {
+		Name     string
+		SiteURL  string
+		Text     string
+		Expected string
+	}{
+		{
+			Name:     "message of a workspace",
+			Text:     "see <https://acme.slack.com/archives/C1/p1577836800000100>",
+			Expected: "see message of 2020-01-01 00:00 UTC in ~general",
+		},
+		{
+			Name:     "message of a thread with a label",
+			Text:     "<https://acme.slack.com/archives/C1/p1577836800000100?thread_ts=1577836700.000100&cid=C1|this one>",
+			Expected: "this one (message of 2020-01-01 00:00 UTC in ~general)",
+		},
+		{
+			Name:     "thread of the web client",
+			Text:     "<https://app.slack.com/client/T1/C1/thread/C1-1577836800.000100>",
+			Expected: "message of 2020-01-01 00:00 UTC in ~general",
+		},
+		{
+			Name:     "channel",
+			Text:     "<https://app.slack.com/client/T1/C1>",
+			Expected: "~general",
+		},
+		{
+			Name:     "direct message",
+			Text:     "<https://acme.enterprise.slack.com/archives/D1/p1577836800000100>",
+			Expected: "direct message of 2020-01-01 00:00 UTC",
+		},
+		{
+			Name:     "unknown channel",
+			Text:     "<https://acme.slack.com/archives/C9/p1577836800000100>",
+			Expected: "<https://acme.slack.com/archives/C9/p1577836800000100>",
+		},
+		{
+			Name:     "other link",
+			Text:     "<https://slack.com/help|help>",
+			Expected: "<https://slack.com/help|help>",
+		},
+		{
+			Name:     "message linked to the site",
+			SiteURL:  "https://chat.example.com/",
+			Text:     "<https://acme.slack.com/archives/C1/p1577836800000100>",
+			Expected: "<https://chat.example.com/team/channels/general|message of 2020-01-01 00:00 UTC in general>",
+		},
+		{
+			Name:     "channel linked to the site with a label",
+			SiteURL:  "https://chat.example.com",
+			Text:     "<https://acme.slack.com/archives/C1|the channel>",
+			Expected: "<https://chat.example.com/team/channels/general|the channel>",
+		},
+		{
+			Name:     "direct message not linked to the site",
+			SiteURL:  "https://chat.example.com",
+			Text:     "<https://acme.slack.com/archives/D1/p1577836800000100>",
+			Expected: "direct message of 2020-01-01 00:00 UTC",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			rewriter := newPermalinkRewriter(tc.SiteURL, "team", channels, "")
+			assert.Equal(t, tc.Expected, rewriter.rewriteText(tc.Text))
+		})
+	}
+}
+
+func TestValidateSiteURL(t *testing.T) {
+	assert.NoError(t, ValidateSiteURL("https://chat.example.com"))
+	assert.Error(t, ValidateSiteURL("chat.example.com"))
+	assert.Error(t, ValidateSiteURL("ftp://chat.example.com"))
+}
+
+func TestTransformFSRewritePermalinks(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "as said in <https://acme.slack.com/archives/C1/p1577836800000100|this message>", "ts": "1577923200.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:          "team",
+		Logger:            log.New(),
+		ChannelPrefix:     "acme",
+		RewritePermalinks: true,
+		PermalinkSiteURL:  "https://chat.example.com",
+		TransformConfig:   TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Contains(t, messages, "as said in [this message](https://chat.example.com/team/channels/acme-general)")
+}
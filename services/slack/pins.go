@@ -0,0 +1,86 @@
+package slack
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// slackPinTypeMessage is the type of the pins of messages, the other
+// pins being of files
+const slackPinTypeMessage = "C"
+
+// SlackPin is an item pinned to a channel, as listed by the pins of
+// the channels of the export
+type SlackPin struct {
+	// Id is the timestamp of the pinned message
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	User    string `json:"user"`
+}
+
+// PinnedPost is a post pinned in Slack, for the admins to pin it again
+// after the import
+type PinnedPost struct {
+	Channel string `json:"channel"`
+	// ChannelMembers are the usernames of the members of the direct
+	// and group channels
+	ChannelMembers []string `json:"channel_members,omitempty"`
+	User           string   `json:"user"`
+	CreateAt       int64    `json:"create_at"`
+}
+
+// isPinned tells whether the message is pinned to the channel, either
+// by the pins of the channel or by the message itself
+func isPinned(channel *IntermediateChannel, post SlackPost) bool {
+	for _, channelId := range post.PinnedTo {
+		if channelId == channel.Id {
+			return true
+		}
+	}
+	for _, timestamp := range channel.PinnedTimestamps {
+		if timestamp == post.TimeStamp {
+			return true
+		}
+	}
+	return false
+}
+
+// pinPost marks the post of the channel as pinned and records it for
+// the pins report
+func (t *Transformer) pinPost(channel *IntermediateChannel, post *IntermediatePost) {
+	post.IsPinned = true
+	pinned := PinnedPost{
+		Channel:  channel.Name,
+		User:     post.User,
+		CreateAt: post.CreateAt,
+	}
+	if channel.Type == model.ChannelTypeDirect || channel.Type == model.ChannelTypeGroup {
+		for _, member := range channel.Members {
+			if user, ok := t.Intermediate.UsersById[member]; ok {
+				pinned.ChannelMembers = append(pinned.ChannelMembers, user.Username)
+			}
+		}
+	}
+	t.pinnedPosts = append(t.pinnedPosts, pinned)
+}
+
+// PinnedPosts returns the posts pinned in Slack
+func (t *Transformer) PinnedPosts() []PinnedPost {
+	return t.pinnedPosts
+}
+
+func WritePinnedPostsReport(reportPath string, pinned []PinnedPost) error {
+	b, err := json.MarshalIndent(pinned, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the pins report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the pins report %s", reportPath)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformFSPinnedPosts(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"], "pins": [
+			{"id": "1577923200.000100", "type": "C", "created": 1577923300, "user": "U2"},
+			{"id": "F1", "type": "F", "created": 1577923300, "user": "U2"}
+		]}
+	]`)}
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "pinned by the channel", "ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "pinned by the message", "ts": "1577923201.000100", "pinned_to": ["C1"]},
+		{"type": "message", "user": "U2", "text": "not pinned", "ts": "1577923202.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	pinned := map[string]bool{}
+	for _, post := range result.Intermediate.Posts {
+		pinned[post.Message] = post.IsPinned
+	}
+	assert.Equal(t, map[string]bool{"hello @jane": false, "a file": false, "pinned by the channel": true, "pinned by the message": true, "not pinned": false}, pinned)
+	assert.Equal(t, []PinnedPost{
+		{Channel: "general", User: "john", CreateAt: 1577923200000},
+		{Channel: "general", User: "jane", CreateAt: 1577923201000},
+	}, result.PinnedPosts())
+}
@@ -0,0 +1,193 @@
+package slack
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPipelineBufferSize is the number of channels that can wait
+// between two stages of the pipeline when no buffer size is set.
+const DefaultPipelineBufferSize = 4
+
+type parsedChannel struct {
+	name  string
+	posts []SlackPost
+}
+
+type transformedChannel struct {
+	name    string
+	posts   []*IntermediatePost
+	source  int
+	elapsed time.Duration
+}
+
+// pipelineErrors keeps the first error raised by a stage and signals
+// the rest of the stages to stop through the abort channel.
+type pipelineErrors struct {
+	once  sync.Once
+	err   error
+	abort chan struct{}
+}
+
+func (e *pipelineErrors) fail(err error) {
+	e.once.Do(func() {
+		e.err = err
+		close(e.abort)
+	})
+}
+
+// parseStage parses the posts of each channel of the export but the
+// completed ones, blocking while the buffer to the transform stage is
+// full. It returns whether it was interrupted before parsing every
+// channel.
+func (t *Transformer) parseStage(ctx context.Context, slackExport *SlackExport, completed map[string]bool, parsed chan<- parsedChannel, errs *pipelineErrors) bool {
+	defer close(parsed)
+
+	channelNames := make([]string, 0, len(slackExport.PostFiles))
+	for channelName := range slackExport.PostFiles {
+		if !completed[channelName] {
+			channelNames = append(channelNames, channelName)
+		}
+	}
+	sort.Strings(channelNames)
+
+	for _, channelName := range channelNames {
+		if ctx.Err() != nil {
+			return true
+		}
+
+		posts, err := t.ParseChannelPosts(slackExport, channelName)
+		if err != nil {
+			errs.fail(err)
+			return false
+		}
+
+		select {
+		case parsed <- parsedChannel{name: channelName, posts: posts}:
+		case <-ctx.Done():
+			return true
+		case <-errs.abort:
+			return false
+		}
+	}
+	return false
+}
+
+// transformStage converts the parsed channels. A channel that started
+// being transformed is always handed to the export stage, so an
+// interruption only stops the pipeline between channels. It returns
+// whether it was interrupted before transforming every channel.
+func (t *Transformer) transformStage(ctx context.Context, cfg *TransformConfig, slackExport *SlackExport, parsed <-chan parsedChannel, transformed chan<- transformedChannel, errs *pipelineErrors) bool {
+	defer close(transformed)
+
+	channelsByOriginalName := buildChannelsByOriginalNameMap(t.Intermediate, slackExport.ChannelRenames)
+	for channel := range parsed {
+		if ctx.Err() != nil {
+			return true
+		}
+		t.countSourcePosts(channel.name, len(channel.posts))
+
+		intermediateChannel, ok := channelsByOriginalName[channel.name]
+		if !ok {
+			t.warnUnknownChannel(channel.name)
+			continue
+		}
+
+		start := time.Now()
+		posts, err := t.transformChannelPosts(ctx, cfg, slackExport, intermediateChannel, channel.name, channel.posts)
+		if err != nil {
+			errs.fail(err)
+			return false
+		}
+		elapsed := time.Since(start)
+		t.countEmittedPosts(channel.name, posts)
+
+		select {
+		case transformed <- transformedChannel{name: channel.name, posts: posts, source: len(channel.posts), elapsed: elapsed}:
+		case <-errs.abort:
+			return false
+		}
+	}
+	return false
+}
+
+// ExportPostsPipeline parses, transforms and exports the posts of the
+// export channel by channel, running each step as a concurrent stage.
+// The stages are connected by buffers of bufferSize channels, so only
+// a few channels are held in memory at any time. The users and
+// channels must have been transformed and exported beforehand.
+func (t *Transformer) ExportPostsPipeline(ctx context.Context, cfg *TransformConfig, slackExport *SlackExport, exporter Exporter, bufferSize int) error {
+	t.Logger.Info("Transforming and exporting posts")
+	finishStage := t.startStage(StagePosts)
+	if bufferSize <= 0 {
+		bufferSize = DefaultPipelineBufferSize
+	}
+
+	// the channels completed by a resumed run are skipped
+	completed := map[string]bool{}
+	for _, channelName := range t.completedChannels {
+		completed[channelName] = true
+	}
+	if len(completed) > 0 {
+		t.Logger.Infof("Resuming the transformation after the posts of %d channels", len(completed))
+	}
+
+	errs := &pipelineErrors{abort: make(chan struct{})}
+	parsed := make(chan parsedChannel, bufferSize)
+	transformed := make(chan transformedChannel, bufferSize)
+
+	var wg sync.WaitGroup
+	var parseInterrupted, transformInterrupted bool
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		parseInterrupted = t.parseStage(ctx, slackExport, completed, parsed, errs)
+	}()
+	go func() {
+		defer wg.Done()
+		transformInterrupted = t.transformStage(ctx, cfg, slackExport, parsed, transformed, errs)
+	}()
+
+	for channel := range transformed {
+		if err := t.exportChannelPosts(channel.posts, exporter); err != nil {
+			errs.fail(err)
+			break
+		}
+		t.completedChannels = append(t.completedChannels, channel.name)
+		if t.SaveCheckpoint != nil {
+			if err := t.SaveCheckpoint(t.Checkpoint()); err != nil {
+				errs.fail(err)
+				break
+			}
+		}
+		t.channelDone(ChannelProgress{
+			Channel: channel.name,
+			Source:  channel.source,
+			Emitted: countPosts(channel.posts),
+			Total:   len(slackExport.PostFiles),
+			Elapsed: channel.elapsed,
+		})
+	}
+	wg.Wait()
+
+	if errs.err != nil {
+		return errs.err
+	}
+	if parseInterrupted || transformInterrupted {
+		t.Logger.Warnf("Transformation interrupted after processing the posts of %d channels", len(t.completedChannels))
+		return ErrInterrupted
+	}
+	finishStage()
+	return nil
+}
+
+func (t *Transformer) exportChannelPosts(posts []*IntermediatePost, exporter Exporter) error {
+	for _, post := range posts {
+		if err := t.writePostLine(exporter, post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingFS records the files opened through it and the highest
+// number of post files open at the same time
+type trackingFS struct {
+	fsys fs.FS
+
+	mu          sync.Mutex
+	opened      []string
+	openPosts   int
+	maxOpenPost int
+}
+
+func (t *trackingFS) Open(name string) (fs.File, error) {
+	file, err := t.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if name != "." {
+		t.opened = append(t.opened, name)
+	}
+	isPost := strings.Count(name, "/") == 1 && strings.HasSuffix(name, ".json")
+	if isPost {
+		t.openPosts++
+		if t.openPosts > t.maxOpenPost {
+			t.maxOpenPost = t.openPosts
+		}
+	}
+	return &trackedFile{File: file, fsys: t, isPost: isPost}, nil
+}
+
+func (t *trackingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(t.fsys, name)
+}
+
+type trackedFile struct {
+	fs.File
+	fsys   *trackingFS
+	isPost bool
+}
+
+func (f *trackedFile) Close() error {
+	if f.isPost {
+		f.fsys.mu.Lock()
+		f.fsys.openPosts--
+		f.fsys.mu.Unlock()
+	}
+	return f.File.Close()
+}
+
+// createLargeExportZip builds an export with more entries than fit in
+// a regular end of central directory record, so the archive can only
+// be read through its zip64 records.
+func createLargeExportZip(t *testing.T, channels []string, uploads int) []byte {
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	writeFile := func(name, content string) {
+		writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		require.NoError(t, err)
+		_, err = writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	writeFile("users.json", `[{"id": "U1", "name": "john", "profile": {"email": "john@example.com"}}]`)
+	channelsJSON := []string{}
+	for i, channel := range channels {
+		channelsJSON = append(channelsJSON, fmt.Sprintf(`{"id": "C%d", "name": "%s", "members": ["U1"]}`, i, channel))
+		for day := 1; day <= 2; day++ {
+			writeFile(fmt.Sprintf("%s/2020-01-0%d.json", channel, day), fmt.Sprintf(
+				`[{"type": "message", "user": "U1", "text": "%s %d", "ts": "157783%d800.000100"}]`, channel, day, day))
+		}
+	}
+	writeFile("channels.json", "["+strings.Join(channelsJSON, ",")+"]")
+
+	for i := 0; i < uploads; i++ {
+		writeFile(fmt.Sprintf("__uploads/F%d/file.txt", i), "")
+	}
+
+	require.NoError(t, zipWriter.Close())
+	return buffer.Bytes()
+}
+
+func TestExportPostsPipelineLargeArchive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the large archive test in short mode")
+	}
+
+	channels := []string{"dev", "general", "random"}
+	data := createLargeExportZip(t, channels, 1<<16)
+
+	zip64EndSignature := make([]byte, 4)
+	binary.LittleEndian.PutUint32(zip64EndSignature, 0x06064b50)
+	require.True(t, bytes.Contains(data[len(data)-1024:], zip64EndSignature), "the archive should use zip64 records")
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	fsys := &trackingFS{fsys: zipReader}
+
+	var output bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:           "team",
+		Logger:             log.New(),
+		TransformConfig:    TransformConfig{SkipAttachments: true},
+		PipelineBufferSize: 1,
+	}, NewJSONLExporter(&output))
+	require.NoError(t, err)
+
+	expectedPostFiles := []string{}
+	for _, channel := range channels {
+		expectedPostFiles = append(expectedPostFiles, channel+"/2020-01-01.json", channel+"/2020-01-02.json")
+	}
+
+	// the metadata is read first and then each post file is opened
+	// once, channel after channel
+	require.Len(t, fsys.opened, 2+len(expectedPostFiles))
+	assert.ElementsMatch(t, []string{"users.json", "channels.json"}, fsys.opened[:2])
+	assert.Equal(t, expectedPostFiles, fsys.opened[2:])
+	assert.Equal(t, 1, fsys.maxOpenPost)
+
+	assert.Equal(t, channels, result.Checkpoint().CompletedChannels)
+	for _, channel := range channels {
+		assert.Contains(t, output.String(), channel+" 1")
+		assert.Contains(t, output.String(), channel+" 2")
+	}
+}
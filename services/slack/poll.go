@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pollBotNames are the lowercased names of the Slack poll apps whose
+// interactive messages are converted to plain Markdown summaries.
+var pollBotNames = map[string]bool{
+	"polly":       true,
+	"simple poll": true,
+}
+
+func (p *SlackPost) IsPollMessage() bool {
+	if p.Type != "message" || (p.BotId == "" && p.SubType != "bot_message") {
+		return false
+	}
+	if pollBotNames[strings.ToLower(p.BotUsername)] {
+		return true
+	}
+	return p.BotProfile != nil && pollBotNames[strings.ToLower(p.BotProfile.Name)]
+}
+
+func pollLines(text string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func contextText(elements []*SlackBlockElement) string {
+	texts := []string{}
+	for _, element := range elements {
+		if text := strings.TrimSpace(string(element.Text)); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+// SlackConvertPollMessage flattens the blocks and attachments of a
+// poll message into a Markdown summary with the question followed by
+// the options and their results.
+func SlackConvertPollMessage(post SlackPost) string {
+	lines := []string{}
+	for _, block := range post.Blocks {
+		switch block.Type {
+		case "header", "section":
+			lines = append(lines, pollLines(string(block.Text))...)
+			for _, field := range block.Fields {
+				lines = append(lines, pollLines(string(field))...)
+			}
+		case "context":
+			if text := contextText(block.Elements); text != "" {
+				lines = append(lines, "_"+text+"_")
+			}
+		}
+	}
+
+	for _, attachment := range post.Attachments {
+		lines = append(lines, pollLines(attachment.Pretext)...)
+		lines = append(lines, pollLines(attachment.Title)...)
+		lines = append(lines, pollLines(attachment.Text)...)
+		for _, field := range attachment.Fields {
+			lines = append(lines, pollLines(fmt.Sprintf("%s: %v", field.Title, field.Value))...)
+		}
+	}
+
+	if len(lines) == 0 {
+		return post.Text
+	}
+
+	question := strings.Trim(lines[0], "*_ ")
+	result := "**Poll: " + question + "**\n"
+	for _, line := range lines[1:] {
+		result += "\n- " + line
+	}
+	return result
+}
+
+// SlackConvertPollMessages replaces the text of the poll messages with
+// their Markdown summary and drops the interactive attachments, as
+// they can't be used after the import.
+func SlackConvertPollMessages(posts map[string][]SlackPost) map[string][]SlackPost {
+	for channelName, channelPosts := range posts {
+		for postIdx, post := range channelPosts {
+			if !post.IsPollMessage() {
+				continue
+			}
+			post.Text = SlackConvertPollMessage(post)
+			post.Attachments = nil
+			post.Blocks = nil
+			posts[channelName][postIdx] = post
+		}
+	}
+
+	return posts
+}
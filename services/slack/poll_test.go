@@ -0,0 +1,69 @@
+package slack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackConvertPollMessage(t *testing.T) {
+	t.Run("Simple Poll blocks are converted to a summary", func(t *testing.T) {
+		const postJson = `{
+			"type": "message",
+			"subtype": "bot_message",
+			"bot_id": "B01",
+			"username": "Simple Poll",
+			"text": "",
+			"ts": "1",
+			"blocks": [
+				{"type": "section", "text": {"type": "mrkdwn", "text": "*Where do we go for lunch?*"}},
+				{"type": "section", "text": {"type": "mrkdwn", "text": ":one: Pizza \u00602\u0060"}, "accessory": {"type": "button", "text": {"type": "plain_text", "text": "Vote"}}},
+				{"type": "context", "elements": [{"type": "image", "image_url": "https://example.com/a.png"}, {"type": "mrkdwn", "text": "2 votes"}]},
+				{"type": "section", "text": {"type": "mrkdwn", "text": ":two: Sushi"}},
+				{"type": "divider"}
+			]
+		}`
+		var post SlackPost
+		require.NoError(t, json.Unmarshal([]byte(postJson), &post))
+		require.True(t, post.IsPollMessage())
+
+		expected := "**Poll: Where do we go for lunch?**\n\n- :one: Pizza `2`\n- _2 votes_\n- :two: Sushi"
+		assert.Equal(t, expected, SlackConvertPollMessage(post))
+	})
+
+	t.Run("Polly attachments are converted to a summary", func(t *testing.T) {
+		post := SlackPost{
+			Type:       "message",
+			SubType:    "bot_message",
+			BotId:      "B02",
+			BotProfile: &SlackBotProfile{Name: "Polly"},
+			Attachments: []*model.SlackAttachment{
+				{
+					Title: "Favourite editor?",
+					Fields: []*model.SlackAttachmentField{
+						{Title: "vim", Value: "3 votes"},
+						{Title: "emacs", Value: "1 vote"},
+					},
+				},
+			},
+		}
+		require.True(t, post.IsPollMessage())
+
+		expected := "**Poll: Favourite editor?**\n\n- vim: 3 votes\n- emacs: 1 vote"
+		assert.Equal(t, expected, SlackConvertPollMessage(post))
+	})
+
+	t.Run("Regular bot messages are left untouched", func(t *testing.T) {
+		posts := map[string][]SlackPost{
+			"channel": {
+				{Type: "message", SubType: "bot_message", BotId: "B03", BotUsername: "jira", Text: "issue created"},
+			},
+		}
+
+		result := SlackConvertPollMessages(posts)
+		assert.Equal(t, "issue created", result["channel"][0].Text)
+	})
+}
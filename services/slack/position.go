@@ -0,0 +1,122 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// positionTitle is the title of the Slack profile
+	positionTitle = "title"
+	// positionFieldPrefix starts the custom fields of the profile,
+	// by id or by label
+	positionFieldPrefix = "field:"
+)
+
+// PositionSource lists the fields of the Slack profiles the position
+// of the users is taken from, the first one set winning. It defaults
+// to the title.
+type PositionSource []string
+
+// ParsePositionSource reads a comma separated list of fields, either
+// title or field:<id> for a custom field of the profiles, e.g.
+// "field:Xf01ABCD,title".
+func ParsePositionSource(source string) (PositionSource, error) {
+	if strings.TrimSpace(source) == "" {
+		return PositionSource{positionTitle}, nil
+	}
+	fields := PositionSource{}
+	for _, field := range strings.Split(source, ",") {
+		field = strings.TrimSpace(field)
+		if field != positionTitle && (!strings.HasPrefix(field, positionFieldPrefix) || field == positionFieldPrefix) {
+			return nil, errors.Errorf("unknown position field %q, expected %q or %q followed by the id of a custom field", field, positionTitle, positionFieldPrefix)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// WithFieldLabels replaces the labels of the custom fields of the
+// source with their id, from the ids of the fields by label
+func (s PositionSource) WithFieldLabels(labels map[string]string) PositionSource {
+	ids := make(map[string]string, len(labels))
+	for label, id := range labels {
+		ids[strings.ToLower(label)] = id
+	}
+	fields := make(PositionSource, 0, len(s))
+	for _, field := range s {
+		if strings.HasPrefix(field, positionFieldPrefix) {
+			if id, ok := ids[strings.ToLower(strings.TrimPrefix(field, positionFieldPrefix))]; ok {
+				field = positionFieldPrefix + id
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// position returns the position of a user from the first field of the
+// profile that is set
+func (s PositionSource) position(profile SlackProfile) string {
+	if len(s) == 0 {
+		return profile.Title
+	}
+	for _, field := range s {
+		value := profile.Title
+		if strings.HasPrefix(field, positionFieldPrefix) {
+			value = profile.Fields[strings.TrimPrefix(field, positionFieldPrefix)].Value
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// SlackProfileField is the value of a custom field of a profile
+type SlackProfileField struct {
+	Value string `json:"value"`
+	Alt   string `json:"alt"`
+}
+
+// SlackProfileFields are the custom fields of a profile by id
+type SlackProfileFields map[string]SlackProfileField
+
+func (f *SlackProfileFields) UnmarshalJSON(data []byte) error {
+	// the profiles without custom fields have an empty list
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err == nil {
+		*f = nil
+		return nil
+	}
+	var fields map[string]SlackProfileField
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*f = fields
+	return nil
+}
+
+// ProfileFields returns the ids of the custom profile fields of the
+// workspace by label, with the team.profile.get method.
+func (c *SlackAPIClient) ProfileFields(ctx context.Context) (map[string]string, error) {
+	var response struct {
+		Profile struct {
+			Fields []struct {
+				Id    string `json:"id"`
+				Label string `json:"label"`
+			} `json:"fields"`
+		} `json:"profile"`
+	}
+	if err := c.Call(ctx, "team.profile.get", nil, &response); err != nil {
+		return nil, err
+	}
+	labels := make(map[string]string, len(response.Profile.Fields))
+	for _, field := range response.Profile.Fields {
+		labels[field.Label] = field.Id
+	}
+	return labels, nil
+}
@@ -0,0 +1,54 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePositionSource(t *testing.T) {
+	source, err := ParsePositionSource("")
+	require.NoError(t, err)
+	assert.Equal(t, PositionSource{"title"}, source)
+
+	source, err = ParsePositionSource("field:Xf01, title")
+	require.NoError(t, err)
+	assert.Equal(t, PositionSource{"field:Xf01", "title"}, source)
+
+	for _, invalid := range []string{"team", "field:", "title,"} {
+		_, err = ParsePositionSource(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	assert.Equal(t, PositionSource{"field:Xf02", "field:Xf01", "title"}, PositionSource{"field:team", "field:Xf01", "title"}.WithFieldLabels(map[string]string{"Team": "Xf02"}))
+}
+
+func TestTransformFSPositionSource(t *testing.T) {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com", "title": "Engineer", "fields": {"Xf01": {"value": "Platform team", "alt": ""}}}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com", "title": "Manager", "fields": []}}
+	]`)}
+
+	positions := func(source PositionSource) map[string]string {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			PositionSource:  source,
+			TransformConfig: TransformConfig{SkipAttachments: true},
+		})
+		require.NoError(t, err)
+		return map[string]string{
+			"U1": result.Intermediate.UsersById["U1"].Position,
+			"U2": result.Intermediate.UsersById["U2"].Position,
+		}
+	}
+
+	assert.Equal(t, map[string]string{"U1": "Engineer", "U2": "Manager"}, positions(nil))
+	assert.Equal(t, map[string]string{"U1": "Platform team", "U2": ""}, positions(PositionSource{"field:Xf01"}))
+	assert.Equal(t, map[string]string{"U1": "Platform team", "U2": "Manager"}, positions(PositionSource{"field:Xf01", "title"}))
+}
@@ -0,0 +1,92 @@
+package slack
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// ChannelPostCount compares the messages of a channel in the export
+// with the posts and replies emitted for it
+type ChannelPostCount struct {
+	Channel string
+	// Source is the number of messages of the day files of the channel
+	Source int
+	// Emitted is the number of posts and replies produced for the
+	// channel, which can exceed Source when posts are added, e.g. for
+	// the revisions of legal hold exports
+	Emitted int
+	// Skipped is the number of messages of the channel that raised a
+	// warning and were left out
+	Skipped int
+}
+
+// Difference is the number of messages missing from the output
+func (c ChannelPostCount) Difference() int {
+	return c.Source - c.Emitted
+}
+
+type channelPostCounts struct {
+	source  int
+	emitted int
+}
+
+func countPosts(posts []*IntermediatePost) int {
+	count := 0
+	for _, post := range posts {
+		count += 1 + len(post.Replies)
+	}
+	return count
+}
+
+func (t *Transformer) countSourcePosts(originalChannelName string, count int) {
+	if t.postCounts == nil {
+		t.postCounts = map[string]*channelPostCounts{}
+	}
+	if _, ok := t.postCounts[originalChannelName]; !ok {
+		t.postCounts[originalChannelName] = &channelPostCounts{}
+	}
+	t.postCounts[originalChannelName].source += count
+}
+
+func (t *Transformer) countEmittedPosts(originalChannelName string, posts []*IntermediatePost) {
+	t.countSourcePosts(originalChannelName, 0)
+	t.postCounts[originalChannelName].emitted += countPosts(posts)
+}
+
+// PostCounts returns the message counts of every channel with posts
+// in the export, sorted by channel name.
+func (t *Transformer) PostCounts() []ChannelPostCount {
+	skipped := t.result.skippedPosts
+	counts := make([]ChannelPostCount, 0, len(t.postCounts))
+	for channel, count := range t.postCounts {
+		counts = append(counts, ChannelPostCount{
+			Channel: channel,
+			Source:  count.source,
+			Emitted: count.emitted,
+			Skipped: skipped[channel],
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Channel < counts[j].Channel
+	})
+	return counts
+}
+
+// WritePostCountReport writes the post counts as a table, with the
+// totals on the last row.
+func WritePostCountReport(writer io.Writer, counts []ChannelPostCount) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CHANNEL\tSOURCE\tEMITTED\tDIFFERENCE\tSKIPPED")
+
+	total := ChannelPostCount{Channel: "TOTAL"}
+	for _, count := range counts {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\n", count.Channel, count.Source, count.Emitted, count.Difference(), count.Skipped)
+		total.Source += count.Source
+		total.Emitted += count.Emitted
+		total.Skipped += count.Skipped
+	}
+	fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\n", total.Channel, total.Source, total.Emitted, total.Difference(), total.Skipped)
+	return table.Flush()
+}
@@ -0,0 +1,58 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostCounts(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "root", "ts": "1577923200.000100", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "reply", "ts": "1577923201.000100", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U9", "text": "ghost", "ts": "1577923202.000100"}
+	]`)}
+	fsys["random/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "lost", "ts": "1577836802.000100"}
+	]`)}
+
+	for name, stream := range map[string]bool{"transform": false, "stream": true} {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{TeamName: "team", Logger: log.New(), TransformConfig: TransformConfig{SkipAttachments: true}}
+			var result *Result
+			var err error
+			if stream {
+				result, err = StreamFS(context.Background(), fsys, opts, &recordingExporter{})
+			} else {
+				result, err = TransformFS(context.Background(), fsys, opts)
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, []ChannelPostCount{
+				{Channel: "general", Source: 5, Emitted: 4, Skipped: 1},
+				{Channel: "random", Source: 1, Emitted: 0, Skipped: 0},
+			}, result.PostCounts())
+		})
+	}
+}
+
+func TestWritePostCountReport(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, WritePostCountReport(&buffer, []ChannelPostCount{
+		{Channel: "general", Source: 5, Emitted: 4, Skipped: 1},
+		{Channel: "random", Source: 1},
+	}))
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"CHANNEL", "SOURCE", "EMITTED", "DIFFERENCE", "SKIPPED"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"general", "5", "4", "1", "1"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"TOTAL", "6", "4", "2", "1"}, strings.Fields(lines[3]))
+}
@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// importedReferences holds the users and channels of an IDMapping, the
+// ones a posts only run can reference
+type importedReferences struct {
+	usernames    map[string]bool
+	channelNames map[string]bool
+}
+
+func newImportedReferences(mapping *IDMapping) *importedReferences {
+	references := &importedReferences{
+		usernames:    make(map[string]bool, len(mapping.Users)),
+		channelNames: map[string]bool{},
+	}
+	for _, user := range mapping.Users {
+		references.usernames[user.Username] = true
+	}
+	for _, channel := range mapping.Channels {
+		if channel.Type == model.ChannelTypeOpen || channel.Type == model.ChannelTypePrivate {
+			references.channelNames[channel.Name] = true
+		}
+	}
+	return references
+}
+
+// checkImportedReferences returns the post without the replies and the
+// reactions of the users missing from the ImportedMapping, or nil when
+// its channel or its author is missing, raising a warning for each
+// reference left out. The direct channels are created by the import of
+// their posts, their members must have been imported.
+func (t *Transformer) checkImportedReferences(post *IntermediatePost) *IntermediatePost {
+	if t.importedReferences == nil {
+		t.importedReferences = newImportedReferences(t.ImportedMapping)
+	}
+	references := t.importedReferences
+
+	if post.IsDirect {
+		for _, member := range post.ChannelMembers {
+			if !references.usernames[member] {
+				t.warnUnimported(post.Channel, true, "Skipping the post as the member %s of its direct channel was not imported.", member)
+				return nil
+			}
+		}
+	} else if !references.channelNames[post.Channel] {
+		t.warnUnimported(post.Channel, true, "Skipping the post as its channel %s was not imported.", post.Channel)
+		return nil
+	}
+	if !references.usernames[post.User] {
+		t.warnUnimported(post.Channel, true, "Skipping the post as its author %s was not imported.", post.User)
+		return nil
+	}
+
+	checked := *post
+	checked.Reactions = t.checkImportedReactions(post.Channel, post.Reactions)
+	checked.Replies = make([]*IntermediatePost, 0, len(post.Replies))
+	for _, reply := range post.Replies {
+		if !references.usernames[reply.User] {
+			t.warnUnimported(post.Channel, true, "Skipping the reply as its author %s was not imported.", reply.User)
+			continue
+		}
+		checkedReply := *reply
+		checkedReply.Reactions = t.checkImportedReactions(post.Channel, reply.Reactions)
+		checked.Replies = append(checked.Replies, &checkedReply)
+	}
+	return &checked
+}
+
+func (t *Transformer) checkImportedReactions(channel string, postReactions []*IntermediateReaction) []*IntermediateReaction {
+	reactions := make([]*IntermediateReaction, 0, len(postReactions))
+	for _, reaction := range postReactions {
+		if !t.importedReferences.usernames[reaction.User] {
+			t.warnUnimported(channel, false, "Dropping the reaction %s as its user %s was not imported.", reaction.EmojiName, reaction.User)
+			continue
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions
+}
+
+func (t *Transformer) warnUnimported(channel string, skipped bool, format string, args ...interface{}) {
+	t.warn(&Warning{
+		Kind:    WarningUnimportedReference,
+		Skipped: skipped,
+		Channel: channel,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,82 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIDMapping(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "mapping.json")
+	mapping := &IDMapping{
+		Team:     "team",
+		Users:    map[string]UserMapping{"U1": {Username: "john", Email: "john@example.com"}},
+		Channels: map[string]ChannelMapping{"C1": {Name: "general", DisplayName: "general", Type: model.ChannelTypeOpen}},
+	}
+	require.NoError(t, WriteIDMapping(mappingPath, mapping))
+
+	file, err := os.Open(mappingPath)
+	require.NoError(t, err)
+	defer file.Close()
+	parsed, err := ParseIDMapping(file)
+	require.NoError(t, err)
+	assert.Equal(t, mapping, parsed)
+
+	_, err = ParseIDMapping(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+func TestStreamFSImportedMapping(t *testing.T) {
+	fsys := testExportFS()
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "later", "members": ["U1", "U2"]}
+	]`)}
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "root", "ts": "1577923200.000100", "thread_ts": "1577923200.000100",
+			"reactions": [{"name": "smile", "users": ["U1", "U2"]}]},
+		{"type": "message", "user": "U2", "text": "reply of jane", "ts": "1577923201.000100", "thread_ts": "1577923200.000100"}
+	]`)}
+	fsys["later/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "in a channel created later", "ts": "1577923202.000100"}
+	]`)}
+
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+		ImportedMapping: &IDMapping{
+			Team:     "team",
+			Users:    map[string]UserMapping{"U1": {Username: "john"}},
+			Channels: map[string]ChannelMapping{"C1": {Name: "general", Type: model.ChannelTypeOpen}},
+		},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+
+	output := buffer.String()
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Contains(t, lines[0], `"type":"version"`)
+	for _, line := range lines[1:] {
+		assert.Contains(t, line, `"type":"post"`)
+	}
+	assert.Contains(t, output, "hello @jane")
+	assert.Contains(t, output, `"message":"root"`)
+	assert.NotContains(t, output, "a file")
+	assert.NotContains(t, output, "reply of jane")
+	assert.NotContains(t, output, "in a channel created later")
+	assert.NotContains(t, output, `"user":"jane"`)
+
+	// the post of jane, her reply, her reaction and the post of the
+	// channel missing from the mapping
+	assert.Equal(t, 4, result.TransformResult.Count(WarningUnimportedReference))
+}
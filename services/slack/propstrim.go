@@ -0,0 +1,137 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// attachmentPiece is a field of an attachment that can be dropped to
+// make the props of its post fit
+type attachmentPiece struct {
+	name string
+	// decorative pieces are dropped before the content ones
+	decorative bool
+	size       int
+	drop       func()
+}
+
+func attachmentsPropsRunes(attachments []*model.SlackAttachment) int {
+	propsB, _ := json.Marshal(model.StringInterface{"attachments": attachments})
+	return utf8.RuneCountInString(string(propsB))
+}
+
+func jsonRunes(value interface{}) int {
+	b, _ := json.Marshal(value)
+	return utf8.RuneCountInString(string(b))
+}
+
+// attachmentPieces lists the fields of the attachment that can be
+// dropped. The title, the links and the color are always kept.
+func attachmentPieces(i int, attachment *model.SlackAttachment) []*attachmentPiece {
+	texts := []struct {
+		name       string
+		decorative bool
+		value      *string
+	}{
+		{"footer_icon", true, &attachment.FooterIcon},
+		{"author_icon", true, &attachment.AuthorIcon},
+		{"thumb_url", true, &attachment.ThumbURL},
+		{"fallback", true, &attachment.Fallback},
+		{"footer", true, &attachment.Footer},
+		{"pretext", false, &attachment.Pretext},
+		{"text", false, &attachment.Text},
+		{"image_url", false, &attachment.ImageURL},
+	}
+
+	pieces := []*attachmentPiece{}
+	for _, field := range texts {
+		value := field.value
+		if *value == "" {
+			continue
+		}
+		pieces = append(pieces, &attachmentPiece{
+			name:       fmt.Sprintf("attachments[%d].%s", i, field.name),
+			decorative: field.decorative,
+			size:       utf8.RuneCountInString(*value),
+			drop:       func() { *value = "" },
+		})
+	}
+	if len(attachment.Actions) > 0 {
+		pieces = append(pieces, &attachmentPiece{
+			name:       fmt.Sprintf("attachments[%d].actions", i),
+			decorative: true,
+			size:       jsonRunes(attachment.Actions),
+			drop:       func() { attachment.Actions = nil },
+		})
+	}
+	for j, field := range attachment.Fields {
+		if field == nil {
+			continue
+		}
+		j := j
+		pieces = append(pieces, &attachmentPiece{
+			name: fmt.Sprintf("attachments[%d].fields[%d]", i, j),
+			size: jsonRunes(field),
+			drop: func() { attachment.Fields[j] = nil },
+		})
+	}
+	return pieces
+}
+
+// trimAttachments drops the largest fields of copies of the attachments
+// until their props fit in maxRunes, the decorative ones such as the
+// icons first, then the content ones such as the text blocks. It
+// returns the trimmed attachments, the dropped fields and whether the
+// props fit.
+func trimAttachments(attachments []*model.SlackAttachment, maxRunes int) ([]*model.SlackAttachment, []string, bool) {
+	trimmed := make([]*model.SlackAttachment, len(attachments))
+	pieces := []*attachmentPiece{}
+	for i, attachment := range attachments {
+		if attachment == nil {
+			continue
+		}
+		attachmentCopy := *attachment
+		if attachment.Fields != nil {
+			attachmentCopy.Fields = append([]*model.SlackAttachmentField{}, attachment.Fields...)
+		}
+		trimmed[i] = &attachmentCopy
+		pieces = append(pieces, attachmentPieces(i, &attachmentCopy)...)
+	}
+
+	dropped := []string{}
+	for attachmentsPropsRunes(trimmed) > maxRunes {
+		largest := -1
+		for i, piece := range pieces {
+			if piece == nil {
+				continue
+			}
+			if largest == -1 || piece.decorative && !pieces[largest].decorative ||
+				piece.decorative == pieces[largest].decorative && piece.size > pieces[largest].size {
+				largest = i
+			}
+		}
+		if largest == -1 {
+			return trimmed, dropped, false
+		}
+		pieces[largest].drop()
+		dropped = append(dropped, pieces[largest].name)
+		pieces[largest] = nil
+	}
+
+	for _, attachment := range trimmed {
+		if attachment == nil || attachment.Fields == nil {
+			continue
+		}
+		fields := attachment.Fields[:0]
+		for _, field := range attachment.Fields {
+			if field != nil {
+				fields = append(fields, field)
+			}
+		}
+		attachment.Fields = fields
+	}
+	return trimmed, dropped, true
+}
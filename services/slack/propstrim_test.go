@@ -0,0 +1,91 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrimAttachments(t *testing.T) {
+	attachments := []*model.SlackAttachment{
+		{
+			Title:      "Build failed",
+			TitleLink:  "https://ci.example.com/1",
+			Text:       strings.Repeat("log line\n", 20),
+			FooterIcon: "https://ci.example.com/" + strings.Repeat("i", 100) + ".png",
+			Fields: []*model.SlackAttachmentField{
+				{Title: "Branch", Value: "main", Short: true},
+				{Title: "Output", Value: strings.Repeat("o", 300)},
+			},
+		},
+		{Title: "Second", Pretext: "short"},
+	}
+	size := attachmentsPropsRunes(attachments)
+
+	// the decorative icon goes first, even though it is smaller
+	trimmed, dropped, ok := trimAttachments(attachments, size-50)
+	require.True(t, ok)
+	assert.Equal(t, []string{"attachments[0].footer_icon"}, dropped)
+	assert.Empty(t, trimmed[0].FooterIcon)
+	assert.NotEmpty(t, attachments[0].FooterIcon, "the attachments are copied")
+
+	// then the largest content fields
+	trimmed, dropped, ok = trimAttachments(attachments, size-250)
+	require.True(t, ok)
+	assert.Equal(t, []string{"attachments[0].footer_icon", "attachments[0].fields[1]"}, dropped)
+	require.Len(t, trimmed[0].Fields, 1)
+	assert.Equal(t, "Branch", trimmed[0].Fields[0].Title)
+	assert.Len(t, attachments[0].Fields, 2)
+	assert.Equal(t, "Build failed", trimmed[0].Title)
+	assert.Equal(t, "short", trimmed[1].Pretext)
+
+	// the titles and links are always kept
+	_, _, ok = trimAttachments(attachments, 10)
+	assert.False(t, ok)
+}
+
+func TestTransformFSTrimOversizedProps(t *testing.T) {
+	fsys := testExportFS()
+	attachments, err := json.Marshal([]*model.SlackAttachment{{
+		Title:      "Report",
+		Text:       strings.Repeat("x", model.PostPropsMaxRunes),
+		FooterIcon: "https://example.com/icon.png",
+	}})
+	require.NoError(t, err)
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "a report", "ts": "1577923200.000100", "attachments": ` + string(attachments) + `}
+	]`)}
+
+	transform := func(trim bool) *IntermediatePost {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, TrimOversizedProps: trim},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.TransformResult.Count(WarningPropsTooLarge))
+		for _, post := range result.Intermediate.Posts {
+			if post.Message == "a report" {
+				return post
+			}
+		}
+		require.FailNow(t, "the post is missing")
+		return nil
+	}
+
+	assert.Nil(t, transform(false).Props)
+
+	props := transform(true).Props
+	require.NotNil(t, props)
+	trimmed := props["attachments"].([]*model.SlackAttachment)
+	assert.Equal(t, "Report", trimmed[0].Title)
+	assert.Empty(t, trimmed[0].Text)
+	assert.Empty(t, trimmed[0].FooterIcon)
+}
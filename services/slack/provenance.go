@@ -0,0 +1,123 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// slackExportFileRegex matches the name Slack gives to the export
+// files, e.g. "Acme Slack export Jan 1 2020 - Dec 31 2020.zip"
+var slackExportFileRegex = regexp.MustCompile(`^(.+) Slack export `)
+
+// sensitiveFlags are the flags whose values are redacted from the
+// provenance
+var sensitiveFlags = map[string]bool{
+	"zip-password":   true,
+	"redis-login":    true,
+	"redis-password": true,
+	"slack-token":    true,
+}
+
+// Provenance records how an import file was produced, so the audits of
+// the migration can tell where its content comes from.
+type Provenance struct {
+	// Workspace is taken from the name of the export file, it is empty
+	// when the file was renamed
+	Workspace string `json:"workspace,omitempty"`
+	Source    string `json:"source"`
+	Team      string `json:"team"`
+	// FirstDay and LastDay are the days of the first and last day files
+	// of the export
+	FirstDay    string            `json:"first_day,omitempty"`
+	LastDay     string            `json:"last_day,omitempty"`
+	Users       int               `json:"users"`
+	Channels    int               `json:"channels"`
+	Version     string            `json:"version"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Flags       map[string]string `json:"flags"`
+}
+
+// NewProvenance describes the export read from source, whose query is
+// removed when it is a URL. The version, the flags and the generation
+// time are up to the caller, see SetFlag.
+func NewProvenance(source, teamName string, slackExport *SlackExport) *Provenance {
+	if IsURL(source) || IsObjectStorageURL(source) {
+		source = redactURL(source)
+	}
+	provenance := &Provenance{
+		Source:   source,
+		Team:     teamName,
+		Users:    len(slackExport.Users),
+		Channels: len(slackExport.Channels),
+		Flags:    map[string]string{},
+	}
+	if match := slackExportFileRegex.FindStringSubmatch(path.Base(source)); match != nil {
+		provenance.Workspace = match[1]
+	}
+
+	var first, last time.Time
+	for _, postFiles := range slackExport.PostFiles {
+		channelFirst, channelLast, ok := activityRange(postFiles)
+		if !ok {
+			continue
+		}
+		if first.IsZero() || channelFirst.Before(first) {
+			first = channelFirst
+		}
+		if channelLast.After(last) {
+			last = channelLast
+		}
+	}
+	if !first.IsZero() {
+		provenance.FirstDay = first.Format(slackExportDayLayout)
+		provenance.LastDay = last.Format(slackExportDayLayout)
+	}
+	return provenance
+}
+
+// SetFlag records the values of a flag of the run. The values of the
+// sensitive flags are redacted, only the names of the --http-header
+// headers are kept and the credentials and query of the URLs are
+// removed, as they can hold the signature of a download URL.
+func (p *Provenance) SetFlag(name string, values []string) {
+	if sensitiveFlags[name] {
+		p.Flags[name] = "REDACTED"
+		return
+	}
+
+	recorded := make([]string, 0, len(values))
+	for _, value := range values {
+		if name == "http-header" {
+			if colon := strings.Index(value, ":"); colon >= 0 {
+				value = value[:colon]
+			}
+			recorded = append(recorded, strings.TrimSpace(value))
+			continue
+		}
+		if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+			value = redactURL(value)
+		}
+		recorded = append(recorded, value)
+	}
+	p.Flags[name] = strings.Join(recorded, ",")
+}
+
+// WriteProvenance writes the provenance to provenancePath as JSON.
+func WriteProvenance(provenancePath string, provenance *Provenance) error {
+	b, err := json.MarshalIndent(provenance, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the provenance")
+	}
+
+	if err := os.WriteFile(provenancePath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the provenance %s", provenancePath)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvenance(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2019-12-30.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+	fsys["general/2020-02-01.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+
+	transformer := NewTransformer("team", log.New())
+	slackExport, err := transformer.ParseSlackExportMetadataFS(context.Background(), fsys, false)
+	require.NoError(t, err)
+
+	provenance := NewProvenance("/exports/Acme Corp Slack export Dec 30 2019 - Feb 1 2020.zip", "team", slackExport)
+	assert.Equal(t, "Acme Corp", provenance.Workspace)
+	assert.Equal(t, "team", provenance.Team)
+	assert.Equal(t, "2019-12-30", provenance.FirstDay)
+	assert.Equal(t, "2020-02-01", provenance.LastDay)
+	assert.Equal(t, 2, provenance.Users)
+	assert.Equal(t, 1, provenance.Channels)
+
+	// a renamed export file doesn't tell the workspace
+	assert.Empty(t, NewProvenance("export.zip", "team", slackExport).Workspace)
+}
+
+func TestWriteProvenance(t *testing.T) {
+	provenancePath := filepath.Join(t.TempDir(), "out.jsonl.provenance.json")
+	provenance := &Provenance{Source: "export.zip", Team: "team", Version: "0.1.0", Flags: map[string]string{"team": "team"}}
+	require.NoError(t, WriteProvenance(provenancePath, provenance))
+
+	b, err := os.ReadFile(provenancePath)
+	require.NoError(t, err)
+	var written Provenance
+	require.NoError(t, json.Unmarshal(b, &written))
+	assert.Equal(t, *provenance, written)
+}
+
+func TestProvenanceRedaction(t *testing.T) {
+	transformer := NewTransformer("team", log.New())
+	slackExport, err := transformer.ParseSlackExportMetadataFS(context.Background(), testExportFS(), false)
+	require.NoError(t, err)
+
+	exportURL := "https://exports.example.com/Acme%20Slack%20export.zip?X-Amz-Signature=signed-secret"
+	provenance := NewProvenance(exportURL, "team", slackExport)
+	provenance.SetFlag("file", []string{exportURL})
+	provenance.SetFlag("http-header", []string{"Authorization: Bearer header-secret", "X-Request-Source: mmetl"})
+	provenance.SetFlag("zip-password", []string{"zip-secret"})
+	provenance.SetFlag("team", []string{"team"})
+	assert.Equal(t, "https://exports.example.com/Acme%20Slack%20export.zip", provenance.Source)
+	assert.Equal(t, "Authorization,X-Request-Source", provenance.Flags["http-header"])
+	assert.Equal(t, "team", provenance.Flags["team"])
+
+	provenancePath := filepath.Join(t.TempDir(), "out.jsonl.provenance.json")
+	require.NoError(t, WriteProvenance(provenancePath, provenance))
+	b, err := os.ReadFile(provenancePath)
+	require.NoError(t, err)
+	for _, secret := range []string{"signed-secret", "header-secret", "Bearer", "zip-secret"} {
+		assert.NotContains(t, string(b), secret)
+	}
+}
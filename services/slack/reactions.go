@@ -0,0 +1,136 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+type SlackReaction struct {
+	Name  string   `json:"name"`
+	Users []string `json:"users"`
+	Count int      `json:"count"`
+}
+
+// IntermediateReaction is imported with the creation time of its post,
+// as the export does not record when the reaction was added, nor in
+// which order
+type IntermediateReaction struct {
+	User      string `json:"user"`
+	EmojiName string `json:"emoji_name"`
+}
+
+// slackSkinTones maps the skin tone modifiers of Slack to the suffixes
+// of the Mattermost emoji names
+var slackSkinTones = map[string]string{
+	"2": "light_skin_tone",
+	"3": "medium_light_skin_tone",
+	"4": "medium_skin_tone",
+	"5": "medium_dark_skin_tone",
+	"6": "dark_skin_tone",
+}
+
+// slackEmojiAliases are the Slack emoji names missing from Mattermost
+// for which it has an equivalent
+var slackEmojiAliases = map[string]string{
+	"simple_smile": "slightly_smiling_face",
+}
+
+// systemEmojiName returns the Mattermost name of a Slack emoji, and
+// false when it is not a system emoji of Mattermost, e.g. a custom
+// emoji of the workspace.
+func systemEmojiName(name string) (string, bool) {
+	base, tone := name, ""
+	if i := strings.Index(name, "::skin-tone-"); i >= 0 {
+		base = name[:i]
+		tone = slackSkinTones[name[i+len("::skin-tone-"):]]
+	}
+	if alias, ok := slackEmojiAliases[base]; ok {
+		base = alias
+	}
+
+	if tone != "" {
+		if _, ok := model.GetSystemEmojiId(base + "_" + tone); ok {
+			return base + "_" + tone, true
+		}
+	}
+	if _, ok := model.GetSystemEmojiId(base); ok {
+		return base, true
+	}
+	return "", false
+}
+
+// IsSystemEmoji returns whether an emoji name exists in Mattermost
+// without being created
+func IsSystemEmoji(name string) bool {
+	_, ok := model.GetSystemEmojiId(name)
+	return ok
+}
+
+// transformReactions converts the reactions of a message. The
+// reactions with a custom emoji that is not imported are replaced with
+// the CustomEmojiFallback of the configuration, or dropped when it is
+// not set, as the server rejects emojis that do not exist.
+func (t *Transformer) transformReactions(pc *PostContext, post SlackPost) []*IntermediateReaction {
+	reactions := []*IntermediateReaction{}
+	seen := map[string]bool{}
+	customEmojis := []string{}
+	truncated := []string{}
+	for _, reaction := range post.Reactions {
+		// Slack caps the users of a reaction, its count being the
+		// number of users who reacted
+		if reaction.Count > len(reaction.Users) {
+			truncated = append(truncated, fmt.Sprintf("%s (%d of %d users)", reaction.Name, len(reaction.Users), reaction.Count))
+		}
+		emojiName, ok := t.importedEmojiName(reaction.Name)
+		if !ok {
+			customEmojis = append(customEmojis, reaction.Name)
+			if pc.Config.CustomEmojiFallback == "" {
+				continue
+			}
+			emojiName = pc.Config.CustomEmojiFallback
+		}
+
+		for _, userID := range reaction.Users {
+			user := t.Intermediate.UsersById[userID]
+			// a user can react with several custom emojis, which
+			// are only replaced once
+			if user == nil || seen[user.Username+":"+emojiName] {
+				continue
+			}
+			seen[user.Username+":"+emojiName] = true
+			reactions = append(reactions, &IntermediateReaction{User: user.Username, EmojiName: emojiName})
+		}
+	}
+
+	if len(customEmojis) > 0 {
+		action := "Dropped"
+		if pc.Config.CustomEmojiFallback != "" {
+			action = fmt.Sprintf("Replaced with :%s:", pc.Config.CustomEmojiFallback)
+		}
+		t.warnPostf(WarningCustomEmojiReaction, pc.OriginalChannelName, post, false, nil, "%s the reactions with the custom emojis %s", action, strings.Join(customEmojis, ", "))
+	}
+	if len(truncated) > 0 {
+		t.warnPostf(WarningTruncatedReactions, pc.OriginalChannelName, post, false, nil, "Only the users listed by the export are imported for the reactions %s", strings.Join(truncated, ", "))
+	}
+	return reactions
+}
+
+// getReactionImportData returns the reactions of a post, nil when it
+// has none
+func getReactionImportData(reactions []*IntermediateReaction, createAt int64) *[]app.ReactionImportData {
+	if len(reactions) == 0 {
+		return nil
+	}
+	data := make([]app.ReactionImportData, 0, len(reactions))
+	for _, reaction := range reactions {
+		data = append(data, app.ReactionImportData{
+			User:      model.NewString(reaction.User),
+			EmojiName: model.NewString(reaction.EmojiName),
+			CreateAt:  model.NewInt64(createAt),
+		})
+	}
+	return &data
+}
@@ -0,0 +1,112 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemEmojiName(t *testing.T) {
+	testCases := []struct {
+		slackName string
+		name      string
+		ok        bool
+	}{
+		{"thumbsup", "thumbsup", true},
+		{"+1::skin-tone-3", "+1_medium_light_skin_tone", true},
+		{"simple_smile", "slightly_smiling_face", true},
+		{"party-parrot", "", false},
+	}
+	for _, tc := range testCases {
+		name, ok := systemEmojiName(tc.slackName)
+		assert.Equal(t, tc.name, name, tc.slackName)
+		assert.Equal(t, tc.ok, ok, tc.slackName)
+	}
+}
+
+func TestReactions(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "reacted", "ts": "1577923200.000100", "reactions": [
+			{"name": "thumbsup", "users": ["U1", "U2", "U9"], "count": 3},
+			{"name": "party-parrot", "users": ["U2"], "count": 1},
+			{"name": "blob-dance", "users": ["U2"], "count": 1}
+		]}
+	]`)}
+
+	reactions := func(t *testing.T, fallback string) []*IntermediateReaction {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, CustomEmojiFallback: fallback},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.TransformResult.Count(WarningCustomEmojiReaction))
+		for _, post := range result.Intermediate.Posts {
+			if post.Message == "reacted" {
+				return post.Reactions
+			}
+		}
+		require.Fail(t, "post not found")
+		return nil
+	}
+
+	t.Run("dropped", func(t *testing.T) {
+		assert.Equal(t, []*IntermediateReaction{
+			{User: "john", EmojiName: "thumbsup"},
+			{User: "jane", EmojiName: "thumbsup"},
+		}, reactions(t, ""))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		assert.Equal(t, []*IntermediateReaction{
+			{User: "john", EmojiName: "thumbsup"},
+			{User: "jane", EmojiName: "thumbsup"},
+			{User: "jane", EmojiName: "slightly_smiling_face"},
+		}, reactions(t, "slightly_smiling_face"))
+	})
+
+	t.Run("export", func(t *testing.T) {
+		line := GetImportLineFromPost(&IntermediatePost{
+			User:      "john",
+			Channel:   "general",
+			Message:   "reacted",
+			CreateAt:  1577923200000,
+			Reactions: []*IntermediateReaction{{User: "jane", EmojiName: "thumbsup"}},
+		}, "team")
+		require.NotNil(t, line.Post.Reactions)
+		require.Len(t, *line.Post.Reactions, 1)
+		assert.Equal(t, "thumbsup", *(*line.Post.Reactions)[0].EmojiName)
+		assert.Equal(t, int64(1577923200000), *(*line.Post.Reactions)[0].CreateAt)
+	})
+}
+
+func TestTruncatedReactions(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "popular", "ts": "1577923200.000100", "reactions": [
+			{"name": "tada", "users": ["U1", "U2"], "count": 60},
+			{"name": "eyes", "users": ["U2"], "count": 1}
+		]}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TransformResult.Count(WarningTruncatedReactions))
+	popular := []*IntermediatePost{}
+	for _, post := range result.Intermediate.Posts {
+		if post.Message == "popular" {
+			popular = append(popular, post)
+		}
+	}
+	require.Len(t, popular, 1)
+	assert.Len(t, popular[0].Reactions, 3)
+}
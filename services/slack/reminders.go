@@ -0,0 +1,83 @@
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// recurringReminderRegex matches the reminders set up with a
+// recurrence, e.g. "at 9AM every weekday"
+var recurringReminderRegex = regexp.MustCompile(`\bevery\b`)
+
+func isRecurringReminder(post SlackPost) bool {
+	return recurringReminderRegex.MatchString(post.Text)
+}
+
+// isReminderDelivery returns whether a message is a reminder posted by
+// Slackbot when it is due
+func isReminderDelivery(post SlackPost) bool {
+	return post.User == slackbotUserID && strings.HasPrefix(post.Text, "Reminder: ")
+}
+
+// handleReminderAdd collects the recurring reminders of the channel,
+// which are summarized in a single post once the channel is
+// transformed, and imports the one-off reminders as the message of
+// their user unless SkipOneOffReminders is set
+func handleReminderAdd(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	if isRecurringReminder(post) {
+		pc.reminders = append(pc.reminders, post)
+		return nil
+	}
+	if pc.Config.SkipOneOffReminders {
+		return nil
+	}
+	return handleUserMessage(t, pc, post)
+}
+
+// handleMessage imports the messages without subtype, leaving out the
+// reminders delivered by Slackbot when SkipOneOffReminders is set
+func handleMessage(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	if pc.Config.SkipOneOffReminders && isReminderDelivery(post) {
+		return nil
+	}
+	return handlePlainMessage(t, pc, post)
+}
+
+// reminderSummary returns the post listing the recurring reminders of
+// the channel, posted by the user of the last one when it was set up,
+// or nil when the channel has none. The reminder the post is created
+// from is returned along with it.
+func (t *Transformer) reminderSummary(pc *PostContext) (SlackPost, *IntermediatePost) {
+	var last SlackPost
+	var author *IntermediateUser
+	lines := []string{"Recurring reminders set up in this channel in Slack:"}
+	seen := map[string]bool{}
+	for _, reminder := range pc.reminders {
+		user := t.Intermediate.UsersById[reminder.User]
+		if user == nil {
+			t.warnPostf(WarningUnknownUser, pc.OriginalChannelName, reminder, true, nil, "Unable to add the reminder to the summary as the Slack user does not exist in Mattermost. user=%s", reminder.User)
+			continue
+		}
+		// the reminders set up again are listed once
+		if seen[user.Username+"\n"+reminder.Text] {
+			continue
+		}
+		seen[user.Username+"\n"+reminder.Text] = true
+		lines = append(lines, fmt.Sprintf("- @%s %s", user.Username, reminder.Text))
+		last, author = reminder, user
+	}
+	if author == nil {
+		return SlackPost{}, nil
+	}
+
+	// the summary is not part of the thread the reminder was set up in
+	last.ThreadTS = ""
+	last.legalHold = nil
+	return last, &IntermediatePost{
+		User:     author.Username,
+		Channel:  pc.Channel.Name,
+		Message:  strings.Join(lines, "\n"),
+		CreateAt: SlackConvertTimeStamp(last.TimeStamp),
+	}
+}
@@ -0,0 +1,59 @@
+package slack
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminders(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "reminder_add", "user": "U1", "text": "set up a reminder “standup” in this channel at 10AM every weekday.", "ts": "1577923200.000100"},
+		{"type": "message", "subtype": "reminder_add", "user": "U2", "text": "set up a reminder “retro” in this channel at 4PM every other Friday.", "ts": "1577923300.000100"},
+		{"type": "message", "subtype": "reminder_add", "user": "U1", "text": "set up a reminder “standup” in this channel at 10AM every weekday.", "ts": "1577923400.000100"},
+		{"type": "message", "subtype": "reminder_add", "user": "U2", "text": "set up a reminder “lunch” in this channel at 12PM tomorrow.", "ts": "1577923500.000100"},
+		{"type": "message", "user": "USLACKBOT", "text": "Reminder: lunch.", "ts": "1578009600.000100"}
+	]`)}
+
+	messages := func(t *testing.T, skipOneOff bool) ([]string, *TransformResult) {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, SkipOneOffReminders: skipOneOff},
+		})
+		require.NoError(t, err)
+		messages := []string{}
+		for _, post := range result.Intermediate.Posts {
+			if post.CreateAt >= 1577923200000 {
+				messages = append(messages, post.User+": "+post.Message)
+			}
+		}
+		sort.Strings(messages)
+		return messages, result.TransformResult
+	}
+
+	summary := "jane: Recurring reminders set up in this channel in Slack:\n" +
+		"- @john set up a reminder “standup” in this channel at 10AM every weekday.\n" +
+		"- @jane set up a reminder “retro” in this channel at 4PM every other Friday."
+
+	t.Run("one-off reminders imported", func(t *testing.T) {
+		posts, result := messages(t, false)
+		assert.Equal(t, []string{
+			summary,
+			"jane: set up a reminder “lunch” in this channel at 12PM tomorrow.",
+		}, posts)
+		assert.Equal(t, 0, result.Count(WarningUnknownUser))
+	})
+
+	t.Run("one-off reminders skipped", func(t *testing.T) {
+		posts, result := messages(t, true)
+		assert.Equal(t, []string{summary}, posts)
+		assert.Equal(t, 0, result.Count(WarningUnknownUser))
+	})
+}
@@ -0,0 +1,265 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultRemoteBlockSize is the size of the ranges read from a
+	// remote export at a time
+	DefaultRemoteBlockSize = 4 * 1024 * 1024
+	// DefaultRemoteCachedBlocks is the number of blocks of a remote
+	// export kept in memory
+	DefaultRemoteCachedBlocks = 16
+	remoteFetchAttempts       = 3
+)
+
+// remoteRetryDelay is multiplied by the attempt number to wait before
+// retrying a failed read
+var remoteRetryDelay = time.Second
+
+var ErrRangeNotSupported = errors.New("the server does not support range requests")
+
+// RangeFetcher reads byte ranges of a remote object
+type RangeFetcher interface {
+	// FetchRange returns the length bytes of the object starting at
+	// offset
+	FetchRange(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+}
+
+// RemoteFile reads a remote object through ranged requests, so an
+// export zip file can be read without a local copy. The blocks read
+// are cached, as the zip reader does many small reads.
+type RemoteFile struct {
+	ctx       context.Context
+	fetcher   RangeFetcher
+	size      int64
+	blockSize int64
+	maxBlocks int
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	// order holds the cached block indexes from the least to the
+	// most recently used
+	order []int64
+}
+
+// NewRemoteFile returns a RemoteFile of the given size read with the
+// fetcher.
+func NewRemoteFile(ctx context.Context, fetcher RangeFetcher, size int64) *RemoteFile {
+	return &RemoteFile{
+		ctx:       ctx,
+		fetcher:   fetcher,
+		size:      size,
+		blockSize: DefaultRemoteBlockSize,
+		maxBlocks: DefaultRemoteCachedBlocks,
+		blocks:    make(map[int64][]byte),
+	}
+}
+
+// Size returns the size of the remote object
+func (f *RemoteFile) Size() int64 {
+	return f.size
+}
+
+func (f *RemoteFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+	for n < len(p) {
+		if off+int64(n) >= f.size {
+			return n, io.EOF
+		}
+		index := (off + int64(n)) / f.blockSize
+		block, err := f.block(index)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[off+int64(n)-index*f.blockSize:])
+	}
+	return n, nil
+}
+
+func (f *RemoteFile) block(index int64) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if block, ok := f.blocks[index]; ok {
+		f.touch(index)
+		return block, nil
+	}
+
+	offset := index * f.blockSize
+	length := f.blockSize
+	if offset+length > f.size {
+		length = f.size - offset
+	}
+	block, err := f.fetch(offset, length)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.order) >= f.maxBlocks {
+		delete(f.blocks, f.order[0])
+		f.order = f.order[1:]
+	}
+	f.blocks[index] = block
+	f.order = append(f.order, index)
+	return block, nil
+}
+
+func (f *RemoteFile) touch(index int64) {
+	for i, cached := range f.order {
+		if cached == index {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			break
+		}
+	}
+	f.order = append(f.order, index)
+}
+
+// fetch reads a range of the object, retrying the interrupted reads
+func (f *RemoteFile) fetch(offset, length int64) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt < remoteFetchAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * remoteRetryDelay):
+			case <-f.ctx.Done():
+				return nil, f.ctx.Err()
+			}
+		}
+
+		var body io.ReadCloser
+		body, err = f.fetcher.FetchRange(f.ctx, offset, length)
+		if err != nil {
+			if errors.Is(err, ErrRangeNotSupported) || f.ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		block := make([]byte, length)
+		_, err = io.ReadFull(body, block)
+		body.Close()
+		if err == nil {
+			return block, nil
+		}
+	}
+	return nil, errors.Wrapf(err, "failed to read bytes %d-%d of the remote file", offset, offset+length-1)
+}
+
+// HTTPFetcher reads ranges of a file served over HTTP
+type HTTPFetcher struct {
+	Client  *http.Client
+	URL     string
+	Headers http.Header
+}
+
+func (h *HTTPFetcher) FetchRange(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	response, err := h.get(ctx, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusPartialContent {
+		response.Body.Close()
+		if response.StatusCode == http.StatusOK {
+			return nil, ErrRangeNotSupported
+		}
+		return nil, fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return response.Body, nil
+}
+
+func (h *HTTPFetcher) get(ctx context.Context, offset, length int64) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range h.Headers {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if urlErr, ok := err.(*url.Error); ok {
+		urlErr.URL = redactURL(urlErr.URL)
+	}
+	return response, err
+}
+
+// size requests the first byte of the file to read its size from the
+// Content-Range header, as signed download URLs are often only valid
+// for GET requests
+func (h *HTTPFetcher) size(ctx context.Context) (int64, error) {
+	response, err := h.get(ctx, 0, 1)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return 0, ErrRangeNotSupported
+	default:
+		return 0, fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	contentRange := response.Header.Get("Content-Range")
+	slash := strings.LastIndex(contentRange, "/")
+	if slash == -1 {
+		return 0, fmt.Errorf("invalid Content-Range header %q", contentRange)
+	}
+	size, err := strconv.ParseInt(contentRange[slash+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("the size of the file is unknown, Content-Range header %q", contentRange)
+	}
+	return size, nil
+}
+
+// OpenURL opens an export served over HTTP or HTTPS, sending the
+// headers with every request. The server must support range requests.
+func OpenURL(ctx context.Context, rawURL string, headers http.Header) (*RemoteFile, error) {
+	fetcher := &HTTPFetcher{URL: rawURL, Headers: headers}
+	size, err := fetcher.size(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", redactURL(rawURL))
+	}
+	return NewRemoteFile(ctx, fetcher, size), nil
+}
+
+// redactURL removes the query of the URL, which holds the signature
+// of the signed download URLs
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "the export URL"
+	}
+	parsed.RawQuery = ""
+	parsed.User = nil
+	return parsed.String()
+}
+
+// IsURL returns whether the path of the export is an HTTP or HTTPS URL
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
@@ -0,0 +1,107 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenURL(t *testing.T) {
+	files := map[string]string{
+		"channels.json":           `[{"id": "C1", "name": "general"}]`,
+		"general/2020-01-01.json": `[{"type": "message", "user": "U1", "text": "hello"}]`,
+	}
+	data := createEncryptedZip(t, false, "", files)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.ServeContent(w, r, "export.zip", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	t.Run("ranged reads", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		remoteFile, err := OpenURL(context.Background(), server.URL+"/export.zip?signature=secret", http.Header{"Authorization": {"Bearer token"}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), remoteFile.Size())
+		remoteFile.blockSize = 64
+
+		zipFS, err := OpenZipFS(remoteFile, remoteFile.Size(), "")
+		require.NoError(t, err)
+		content, err := fs.ReadFile(zipFS, "plain.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "not encrypted", string(content))
+
+		// the size request and at most one request per block
+		blocks := (int64(len(data)) + remoteFile.blockSize - 1) / remoteFile.blockSize
+		assert.LessOrEqual(t, int64(atomic.LoadInt32(&requests)), 1+blocks)
+	})
+
+	t.Run("missing authorization", func(t *testing.T) {
+		_, err := OpenURL(context.Background(), server.URL+"/export.zip?signature=secret", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+		assert.NotContains(t, err.Error(), "secret")
+	})
+
+	t.Run("ranges not supported", func(t *testing.T) {
+		plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(data)
+		}))
+		defer plainServer.Close()
+
+		_, err := OpenURL(context.Background(), plainServer.URL, nil)
+		require.ErrorIs(t, err, ErrRangeNotSupported)
+	})
+}
+
+type flakyFetcher struct {
+	data     []byte
+	failures int
+}
+
+func (f *flakyFetcher) FetchRange(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("connection reset")
+	}
+	return io.NopCloser(bytes.NewReader(f.data[offset : offset+length])), nil
+}
+
+func TestRemoteFileRetries(t *testing.T) {
+	defer func(delay time.Duration) { remoteRetryDelay = delay }(remoteRetryDelay)
+	remoteRetryDelay = time.Millisecond
+
+	data := []byte("0123456789")
+	remoteFile := NewRemoteFile(context.Background(), &flakyFetcher{data: data, failures: 1}, int64(len(data)))
+	remoteFile.blockSize = 4
+	remoteFile.maxBlocks = 1
+
+	buffer := make([]byte, 6)
+	n, err := remoteFile.ReadAt(buffer, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "345678", string(buffer[:n]))
+
+	n, err = remoteFile.ReadAt(buffer, 8)
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "89", string(buffer[:n]))
+
+	remoteFile = NewRemoteFile(context.Background(), &flakyFetcher{data: data, failures: remoteFetchAttempts}, int64(len(data)))
+	_, err = remoteFile.ReadAt(buffer, 0)
+	require.Error(t, err)
+}
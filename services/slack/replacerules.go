@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReplaceRule replaces a literal text in the message of the posts, or
+// the matches of a regular expression when Regex is set, in which case
+// Replace can refer to the groups of the match, e.g. $1.
+type ReplaceRule struct {
+	Search  string `json:"search"`
+	Replace string `json:"replace"`
+	Regex   bool   `json:"regex"`
+}
+
+// ReplaceRuleCount is the number of replacements made by a rule.
+type ReplaceRuleCount struct {
+	ReplaceRule
+	Replacements int `json:"replacements"`
+}
+
+// ReplaceRules applies its rules in order to the message of each post,
+// counting the replacements of each rule. It is used by a single
+// transformation at a time.
+type ReplaceRules struct {
+	rules   []ReplaceRule
+	regexes []*regexp.Regexp
+	counts  []int
+}
+
+// ParseReplaceRules reads a JSON array of rules, e.g.
+// [{"search": "wiki.old.corp", "replace": "wiki.corp"}].
+func ParseReplaceRules(data io.Reader) (*ReplaceRules, error) {
+	var rules []ReplaceRule
+	if err := json.NewDecoder(data).Decode(&rules); err != nil {
+		return nil, err
+	}
+	return NewReplaceRules(rules)
+}
+
+// NewReplaceRules validates the rules, compiling their regular
+// expressions.
+func NewReplaceRules(rules []ReplaceRule) (*ReplaceRules, error) {
+	replaceRules := &ReplaceRules{
+		rules:   rules,
+		regexes: make([]*regexp.Regexp, len(rules)),
+		counts:  make([]int, len(rules)),
+	}
+	for i, rule := range rules {
+		if rule.Search == "" {
+			return nil, errors.Errorf("the search of the replace rule %d is empty", i+1)
+		}
+		if !rule.Regex {
+			continue
+		}
+		regex, err := regexp.Compile(rule.Search)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regular expression in the replace rule %d", i+1)
+		}
+		replaceRules.regexes[i] = regex
+	}
+	return replaceRules, nil
+}
+
+func (r *ReplaceRules) apply(text string) string {
+	for i, rule := range r.rules {
+		if regex := r.regexes[i]; regex != nil {
+			matches := len(regex.FindAllStringIndex(text, -1))
+			if matches > 0 {
+				text = regex.ReplaceAllString(text, rule.Replace)
+				r.counts[i] += matches
+			}
+			continue
+		}
+		if matches := strings.Count(text, rule.Search); matches > 0 {
+			text = strings.ReplaceAll(text, rule.Search, rule.Replace)
+			r.counts[i] += matches
+		}
+	}
+	return text
+}
+
+// Counts returns the replacements made by each rule so far, in the
+// order of the rules.
+func (r *ReplaceRules) Counts() []ReplaceRuleCount {
+	counts := make([]ReplaceRuleCount, len(r.rules))
+	for i, rule := range r.rules {
+		counts[i] = ReplaceRuleCount{ReplaceRule: rule, Replacements: r.counts[i]}
+	}
+	return counts
+}
+
+// WriteReplaceRuleReport writes the replacements made by each rule to
+// reportPath as JSON.
+func WriteReplaceRuleReport(reportPath string, counts []ReplaceRuleCount) error {
+	b, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the replace rule report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the replace rule report %s", reportPath)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplaceRules(t *testing.T) {
+	rules, err := ParseReplaceRules(strings.NewReader(`[
+		{"search": "wiki.old.corp", "replace": "wiki.corp"},
+		{"search": "PROJ-(\\d+)", "replace": "TASK-$1", "regex": true}
+	]`))
+	require.NoError(t, err)
+	assert.Len(t, rules.Counts(), 2)
+
+	_, err = ParseReplaceRules(strings.NewReader(`[{"search": "", "replace": "x"}]`))
+	assert.Error(t, err)
+
+	_, err = ParseReplaceRules(strings.NewReader(`[{"search": "(", "regex": true}]`))
+	assert.Error(t, err)
+
+	_, err = ParseReplaceRules(strings.NewReader(`{`))
+	assert.Error(t, err)
+}
+
+func TestReplaceRulesApply(t *testing.T) {
+	rules, err := NewReplaceRules([]ReplaceRule{
+		{Search: "wiki.old.corp", Replace: "wiki.corp"},
+		{Search: `PROJ-(\d+)`, Replace: "TASK-${1}", Regex: true},
+		{Search: "Bluebird", Replace: "the mobile app"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "see http://wiki.corp/TASK-1 and TASK-22", rules.apply("see http://wiki.old.corp/PROJ-1 and PROJ-22"))
+	assert.Equal(t, "nothing to replace", rules.apply("nothing to replace"))
+
+	counts := rules.Counts()
+	assert.Equal(t, 1, counts[0].Replacements)
+	assert.Equal(t, 2, counts[1].Replacements)
+	assert.Equal(t, 0, counts[2].Replacements)
+	assert.Equal(t, "Bluebird", counts[2].Search)
+}
+
+func TestTransformFSReplaceRules(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "docs at <http://wiki.old.corp/start|the wiki>", "ts": "1577923200.000100"}
+	]`)}
+
+	rules, err := NewReplaceRules([]ReplaceRule{{Search: "wiki.old.corp", Replace: "wiki.corp"}})
+	require.NoError(t, err)
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true, ReplaceRules: rules},
+	})
+	require.NoError(t, err)
+
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Contains(t, messages, "docs at [the wiki](http://wiki.corp/start)")
+	assert.Equal(t, 1, rules.Counts()[0].Replacements)
+}
@@ -0,0 +1,97 @@
+package slack
+
+import (
+	"github.com/mattermost/mattermost-server/v6/app"
+)
+
+// splitPostLineReplies splits a post line with more than maxReplies
+// replies into lines holding at most maxReplies replies each. Every
+// line repeats the root post, which the import matches by channel,
+// creation time and message, so the replies of the extra lines are
+// added to the root post imported by the first line.
+func splitPostLineReplies(line *app.LineImportData, maxReplies int) []*app.LineImportData {
+	var replies *[]app.ReplyImportData
+	switch {
+	case line.Post != nil:
+		replies = line.Post.Replies
+	case line.DirectPost != nil:
+		replies = line.DirectPost.Replies
+	}
+	if maxReplies <= 0 || replies == nil || len(*replies) <= maxReplies {
+		return []*app.LineImportData{line}
+	}
+
+	lines := []*app.LineImportData{}
+	for start := 0; start < len(*replies); start += maxReplies {
+		end := start + maxReplies
+		if end > len(*replies) {
+			end = len(*replies)
+		}
+		chunk := (*replies)[start:end]
+
+		chunkLine := *line
+		if line.Post != nil {
+			post := *line.Post
+			post.Replies = &chunk
+			chunkLine.Post = &post
+		} else {
+			directPost := *line.DirectPost
+			directPost.Replies = &chunk
+			chunkLine.DirectPost = &directPost
+		}
+		lines = append(lines, &chunkLine)
+	}
+	return lines
+}
+
+// writePostLine writes the line of a post to the exporter, attributed
+// to the archive user in archive mode. When the post has more than
+// MaxRepliesPerLine replies, the extra replies are written to the
+// ContinuationExporter as lines repeating the root post. Those lines
+// must be imported after the main import finished, as the import
+// creates two root posts when both lines are processed in the same
+// batch. The posts older than ColdBefore are written to the
+// ColdExporter instead.
+func (t *Transformer) writePostLine(exporter Exporter, post *IntermediatePost) error {
+	if t.ImportedMapping != nil {
+		if post = t.checkImportedReferences(post); post == nil {
+			return nil
+		}
+	}
+	if t.archiveUser != nil {
+		// the direct channels are not imported in archive mode
+		if post.IsDirect {
+			return nil
+		}
+		post = t.archivePost(post)
+	}
+
+	team := t.TeamName
+	if !post.IsDirect {
+		team = t.channelTeam(post.Channel)
+	}
+	line := GetImportLineFromPost(post, team)
+	if t.isColdPost(post) {
+		return t.writeColdLine(line)
+	}
+	if t.MaxRepliesPerLine <= 0 || t.ContinuationExporter == nil {
+		return exporter.WriteLine(line)
+	}
+
+	lines := splitPostLineReplies(line, t.MaxRepliesPerLine)
+	if err := exporter.WriteLine(lines[0]); err != nil {
+		return err
+	}
+	for _, continuation := range lines[1:] {
+		if t.continuationLines == 0 {
+			if err := t.ExportVersion(t.ContinuationExporter); err != nil {
+				return err
+			}
+		}
+		if err := t.ContinuationExporter.WriteLine(continuation); err != nil {
+			return err
+		}
+		t.continuationLines++
+	}
+	return nil
+}
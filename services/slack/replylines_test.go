@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func threadPost(replies int, isDirect bool) *IntermediatePost {
+	post := &IntermediatePost{
+		User:           "john",
+		Channel:        "general",
+		Message:        "root",
+		CreateAt:       1000,
+		IsDirect:       isDirect,
+		ChannelMembers: []string{"john", "jane"},
+	}
+	for i := 0; i < replies; i++ {
+		post.Replies = append(post.Replies, &IntermediatePost{User: "jane", Message: "reply", CreateAt: int64(1001 + i)})
+	}
+	return post
+}
+
+func TestWritePostLine(t *testing.T) {
+	for name, isDirect := range map[string]bool{"post": false, "direct post": true} {
+		t.Run(name, func(t *testing.T) {
+			transformer := NewTransformer("team", log.New())
+			transformer.MaxRepliesPerLine = 2
+			continuations := &recordingExporter{}
+			transformer.ContinuationExporter = continuations
+			exporter := &recordingExporter{}
+
+			require.NoError(t, transformer.writePostLine(exporter, threadPost(2, isDirect)))
+			require.NoError(t, transformer.writePostLine(exporter, threadPost(5, isDirect)))
+
+			require.Len(t, exporter.lines, 2)
+			for _, line := range exporter.lines {
+				if isDirect {
+					assert.Len(t, *line.DirectPost.Replies, 2)
+				} else {
+					assert.Len(t, *line.Post.Replies, 2)
+				}
+			}
+
+			require.Len(t, continuations.lines, 1+2)
+			assert.Equal(t, "version", continuations.lines[0].Type)
+			replyTimes := []int64{}
+			for _, line := range continuations.lines[1:] {
+				if isDirect {
+					assert.Equal(t, "root", *line.DirectPost.Message)
+					assert.Equal(t, int64(1000), *line.DirectPost.CreateAt)
+					for _, reply := range *line.DirectPost.Replies {
+						replyTimes = append(replyTimes, *reply.CreateAt)
+					}
+				} else {
+					assert.Equal(t, "root", *line.Post.Message)
+					assert.Equal(t, int64(1000), *line.Post.CreateAt)
+					for _, reply := range *line.Post.Replies {
+						replyTimes = append(replyTimes, *reply.CreateAt)
+					}
+				}
+			}
+			assert.Equal(t, []int64{1003, 1004, 1005}, replyTimes)
+			assert.Equal(t, 2, transformer.continuationLines)
+		})
+	}
+
+	t.Run("without continuation exporter", func(t *testing.T) {
+		transformer := NewTransformer("team", log.New())
+		transformer.MaxRepliesPerLine = 2
+		exporter := &recordingExporter{}
+
+		require.NoError(t, transformer.writePostLine(exporter, threadPost(5, false)))
+		require.Len(t, exporter.lines, 1)
+		assert.Len(t, *exporter.lines[0].Post.Replies, 5)
+	})
+}
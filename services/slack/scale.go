@@ -0,0 +1,373 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ScaleOptions configures the copies of an export written by
+// ScaleExport.
+type ScaleOptions struct {
+	// Copies is the number of times the export is contained in the
+	// scaled one, including the original
+	Copies int
+	// Shift is the time the messages of each copy are moved forward by
+	// from the previous one
+	Shift time.Duration
+}
+
+// scaleIDRegex matches the Slack ids in the texts, so the mentions of
+// the users and channels of a copy point to the copy
+var scaleIDRegex = regexp.MustCompile(`\b[A-Z][A-Z0-9]+\b`)
+
+// scaleTimestampKeys are the keys of the timestamps of the messages
+var scaleTimestampKeys = map[string]bool{
+	"ts":           true,
+	"thread_ts":    true,
+	"latest_reply": true,
+	"last_read":    true,
+	"deleted_ts":   true,
+	"event_ts":     true,
+}
+
+// scaleCopy renames the users, the conversations and the files of a
+// copy of the export
+type scaleCopy struct {
+	index     int
+	ids       map[string]string
+	usernames map[string]string
+	dirs      map[string]string
+	shift     time.Duration
+}
+
+func (c *scaleCopy) suffix() string {
+	return fmt.Sprintf("-%d", c.index+1)
+}
+
+func (c *scaleCopy) id(id string) string {
+	return fmt.Sprintf("%sS%d", id, c.index+1)
+}
+
+// timestamp shifts a Slack timestamp, keeping its microseconds
+func (c *scaleCopy) timestamp(ts string) string {
+	seconds, micros := ts, ""
+	if i := strings.IndexByte(ts, '.'); i >= 0 {
+		seconds, micros = ts[:i], ts[i:]
+	}
+	value, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return ts
+	}
+	return strconv.FormatInt(value+int64(c.shift/time.Second), 10) + micros
+}
+
+// dayFile shifts the date of a day file of a conversation
+func (c *scaleCopy) dayFile(name string) string {
+	day, err := time.Parse("2006-01-02.json", name)
+	if err != nil {
+		return name
+	}
+	return day.Add(c.shift).Format("2006-01-02.json")
+}
+
+func (c *scaleCopy) text(text string) string {
+	return scaleIDRegex.ReplaceAllStringFunc(text, func(id string) string {
+		if mapped, ok := c.ids[id]; ok {
+			return mapped
+		}
+		return id
+	})
+}
+
+// value renames the ids and shifts the timestamps of a decoded JSON
+// value of a message
+func (c *scaleCopy) value(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			copied[k] = c.value(k, item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = c.value(key, item)
+		}
+		return copied
+	case string:
+		if mapped, ok := c.ids[v]; ok {
+			return mapped
+		}
+		if scaleTimestampKeys[key] {
+			return c.timestamp(v)
+		}
+		if key == "text" {
+			return c.text(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func (c *scaleCopy) user(user map[string]interface{}) map[string]interface{} {
+	copied := c.value("", user).(map[string]interface{})
+	if name, ok := user["name"].(string); ok {
+		copied["name"] = c.usernames[name]
+	}
+	if profile, ok := copied["profile"].(map[string]interface{}); ok {
+		if email, ok := profile["email"].(string); ok {
+			if at := strings.LastIndexByte(email, '@'); at > 0 {
+				profile["email"] = fmt.Sprintf("%s+%d%s", email[:at], c.index+1, email[at:])
+			}
+		}
+		if displayName, ok := profile["display_name"].(string); ok && displayName != "" {
+			profile["display_name"] = displayName + c.suffix()
+		}
+	}
+	return copied
+}
+
+func (c *scaleCopy) channel(channel map[string]interface{}) map[string]interface{} {
+	copied := c.value("", channel).(map[string]interface{})
+	if name, ok := channel["name"].(string); ok && name != "" {
+		copied["name"] = c.channelName(name)
+	}
+	return copied
+}
+
+// channelName renames a channel, building the names of the group
+// messages from the names of the users of the copy like Slack does
+func (c *scaleCopy) channelName(name string) string {
+	if strings.HasPrefix(name, "mpdm-") && strings.HasSuffix(name, "-1") {
+		usernames := strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, "mpdm-"), "-1"), "--")
+		renamed := true
+		for i, username := range usernames {
+			if usernames[i], renamed = c.usernames[username]; !renamed {
+				break
+			}
+		}
+		if renamed {
+			return "mpdm-" + strings.Join(usernames, "--") + "-1"
+		}
+	}
+	return name + c.suffix()
+}
+
+// scaleRootFiles are the files listing the users and conversations,
+// which hold the objects of every copy
+var scaleRootFiles = []string{"users.json", "channels.json", "groups.json", "dms.json", "ims.json", "mpims.json"}
+
+type scaleObject = map[string]interface{}
+
+func readScaleObjects(fsys fs.FS, name string) ([]scaleObject, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	objects := []scaleObject{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", name)
+	}
+	return objects, nil
+}
+
+// marshalScaled encodes a file of the scaled export without escaping
+// the markup of the messages, as Slack does
+func marshalScaled(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// ScaleExport writes a zip of the export multiplied to the number of
+// copies of the options, to produce load testing corpora matching the
+// capacity a server is planned for. The users, conversations and files
+// of each copy get their ids suffixed, the users and channels are
+// renamed and the messages are moved forward by the shift of the
+// options. The mentions in the messages of a copy point to its users
+// and channels.
+func ScaleExport(fsys fs.FS, writer io.Writer, opts ScaleOptions) error {
+	if opts.Copies < 1 {
+		return errors.Errorf("the number of copies must be at least 1, got %d", opts.Copies)
+	}
+	if opts.Shift < 0 {
+		return errors.Errorf("the shift of the copies cannot be negative, got %s", opts.Shift)
+	}
+
+	roots := map[string][]scaleObject{}
+	for _, name := range scaleRootFiles {
+		objects, err := readScaleObjects(fsys, name)
+		if err != nil {
+			return err
+		}
+		if objects != nil {
+			roots[name] = objects
+		}
+	}
+	if _, ok := roots["users.json"]; !ok {
+		return errors.New("users.json is missing, this is not a Slack export")
+	}
+
+	uploads, err := fs.ReadDir(fsys, "__uploads")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrap(err, "failed to list the uploads")
+	}
+
+	copies := make([]*scaleCopy, opts.Copies-1)
+	for i := range copies {
+		c := &scaleCopy{
+			index:     i + 1,
+			ids:       map[string]string{},
+			usernames: map[string]string{},
+			dirs:      map[string]string{},
+			shift:     time.Duration(i+1) * opts.Shift,
+		}
+		for _, user := range roots["users.json"] {
+			if id, ok := user["id"].(string); ok && id != "" {
+				c.ids[id] = c.id(id)
+			}
+			if name, ok := user["name"].(string); ok && name != "" {
+				c.usernames[name] = name + c.suffix()
+			}
+		}
+		for _, upload := range uploads {
+			if upload.IsDir() {
+				c.ids[upload.Name()] = c.id(upload.Name())
+			}
+		}
+		for _, name := range scaleRootFiles[1:] {
+			for _, channel := range roots[name] {
+				if id, ok := channel["id"].(string); ok && id != "" {
+					c.ids[id] = c.id(id)
+				}
+			}
+		}
+		for _, name := range scaleRootFiles[1:] {
+			for _, channel := range roots[name] {
+				id, _ := channel["id"].(string)
+				if channelName, ok := channel["name"].(string); ok && channelName != "" {
+					c.dirs[channelName] = c.channelName(channelName)
+				} else if id != "" {
+					c.dirs[id] = c.ids[id]
+				}
+			}
+		}
+		copies[i] = c
+	}
+
+	zipWriter := zip.NewWriter(writer)
+	writeFile := func(name string, data []byte) error {
+		fileWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: fixtureStart})
+		if err != nil {
+			return errors.Wrapf(err, "failed to add the file %s", name)
+		}
+		if _, err := fileWriter.Write(data); err != nil {
+			return errors.Wrapf(err, "failed to write the file %s", name)
+		}
+		return nil
+	}
+
+	for _, name := range scaleRootFiles {
+		objects, ok := roots[name]
+		if !ok {
+			continue
+		}
+		scaled := append([]scaleObject{}, objects...)
+		for _, c := range copies {
+			for _, object := range objects {
+				if name == "users.json" {
+					scaled = append(scaled, c.user(object))
+				} else {
+					scaled = append(scaled, c.channel(object))
+				}
+			}
+		}
+		data, err := marshalScaled(scaled)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s", name)
+		}
+		if err := writeFile(name, data); err != nil {
+			return err
+		}
+	}
+
+	err = fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		spl := strings.Split(filePath, "/")
+		if len(spl) == 1 {
+			for _, name := range scaleRootFiles {
+				if name == filePath {
+					return nil
+				}
+			}
+		}
+
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", filePath)
+		}
+		if err := writeFile(filePath, data); err != nil {
+			return err
+		}
+
+		switch {
+		case len(spl) == 3 && spl[0] == "__uploads":
+			for _, c := range copies {
+				if err := writeFile(path.Join(spl[0], c.id(spl[1]), spl[2]), data); err != nil {
+					return err
+				}
+			}
+		case len(spl) == 2 && path.Ext(filePath) == ".json":
+			if len(copies) == 0 {
+				return nil
+			}
+			if _, known := copies[0].dirs[spl[0]]; !known {
+				return nil
+			}
+			posts := []interface{}{}
+			if err := json.Unmarshal(data, &posts); err != nil {
+				return errors.Wrapf(err, "failed to parse %s", filePath)
+			}
+			for _, c := range copies {
+				scaled, err := marshalScaled(c.value("", posts))
+				if err != nil {
+					return errors.Wrapf(err, "failed to marshal %s", filePath)
+				}
+				if err := writeFile(path.Join(c.dirs[spl[0]], c.dayFile(spl[1])), scaled); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(zipWriter.Close(), "failed to write the scaled export")
+}
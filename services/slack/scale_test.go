@@ -0,0 +1,61 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaleExport(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, ScaleExport(testExportFS(), &buffer, ScaleOptions{Copies: 3, Shift: 48 * time.Hour}))
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, file := range zipReader.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		files[file.Name] = string(data)
+	}
+
+	assert.Contains(t, files, "general/2020-01-01.json")
+	assert.Contains(t, files, "general-2/2020-01-03.json")
+	assert.Contains(t, files, "general-3/2020-01-05.json")
+	assert.Equal(t, "some notes", files["__uploads/F1S3/notes.txt"])
+	assert.Contains(t, files["general-2/2020-01-03.json"], `"hello <@U2S2>"`)
+	assert.Contains(t, files["general-2/2020-01-03.json"], `"1578009600.000100"`)
+	assert.Contains(t, files["users.json"], `"john+3@example.com"`)
+
+	result, err := TransformZip(context.Background(), bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Intermediate.UsersById, 6)
+	assert.Len(t, result.Intermediate.PublicChannels, 3)
+	assert.Len(t, result.Intermediate.Posts, 6)
+
+	messages := map[string]int{}
+	for _, post := range result.Intermediate.Posts {
+		messages[post.Message]++
+	}
+	assert.Equal(t, 1, messages["hello @jane"])
+	assert.Equal(t, 1, messages["hello @jane-2"])
+	assert.Equal(t, 1, messages["hello @jane-3"])
+}
+
+func TestScaleExportInvalid(t *testing.T) {
+	assert.Error(t, ScaleExport(testExportFS(), io.Discard, ScaleOptions{Copies: 0}))
+	assert.Error(t, ScaleExport(testExportFS(), io.Discard, ScaleOptions{Copies: 2, Shift: -time.Hour}))
+}
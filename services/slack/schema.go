@@ -0,0 +1,149 @@
+package slack
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExportSchema is the layout of a Slack export, which changed over the
+// years and differs between the export tools.
+type ExportSchema string
+
+const (
+	// SchemaStandard is the layout of the exports of Slack, with the
+	// channels.json and users.json files and a directory of day files
+	// per conversation. The exports including the private conversations
+	// add the groups.json, dms.json and mpims.json files.
+	SchemaStandard ExportSchema = "standard"
+	// SchemaLegacyIMs lists the direct conversations in ims.json, as
+	// named by the Slack API, instead of dms.json
+	SchemaLegacyIMs ExportSchema = "legacy-ims"
+)
+
+// conversationTypeDirs are the directories some export tools file the
+// conversations of each type in, which the standard layout does not
+// have
+var conversationTypeDirs = []string{"channels", "groups", "dms", "ims", "mpims"}
+
+// ExportContents summarizes the files of an export, to explain what it
+// holds when its layout is not supported.
+type ExportContents struct {
+	// RootFiles are the files at the root of the export
+	RootFiles []string
+	// Directories are the top level directories of the export
+	Directories []string
+	// NestedFiles counts the JSON files below the second level of
+	// the top level directories, by directory
+	NestedFiles map[string]int
+}
+
+// maxListedDirectories is the number of directories named in the
+// description of the contents of an export
+const maxListedDirectories = 5
+
+func (c *ExportContents) String() string {
+	parts := []string{}
+	if len(c.RootFiles) > 0 {
+		parts = append(parts, strings.Join(c.RootFiles, ", "))
+	}
+	if len(c.Directories) > 0 {
+		listed := c.Directories
+		if len(listed) > maxListedDirectories {
+			listed = listed[:maxListedDirectories]
+		}
+		names := make([]string, len(listed))
+		for i, dir := range listed {
+			names[i] = dir + "/"
+			if nested := c.NestedFiles[dir]; nested > 0 {
+				names[i] = fmt.Sprintf("%s (%d nested JSON files)", names[i], nested)
+			}
+		}
+		more := ""
+		if len(c.Directories) > len(listed) {
+			more = fmt.Sprintf(" and %d more", len(c.Directories)-len(listed))
+		}
+		parts = append(parts, fmt.Sprintf("%d directories: %s%s", len(c.Directories), strings.Join(names, ", "), more))
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (c *ExportContents) hasRootFile(name string) bool {
+	for _, file := range c.RootFiles {
+		if file == name {
+			return true
+		}
+	}
+	return false
+}
+
+// listExportContents lists the files of the export without opening them
+func listExportContents(fsys fs.FS) (*ExportContents, error) {
+	contents := &ExportContents{NestedFiles: map[string]int{}}
+	err := fs.WalkDir(fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == "." {
+			return nil
+		}
+		spl := strings.Split(filePath, "/")
+		switch {
+		case len(spl) == 1 && entry.IsDir():
+			contents.Directories = append(contents.Directories, filePath)
+		case len(spl) == 1:
+			contents.RootFiles = append(contents.RootFiles, filePath)
+		case len(spl) > 2 && !entry.IsDir() && path.Ext(filePath) == ".json" && spl[0] != "__uploads":
+			contents.NestedFiles[spl[0]]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(contents.RootFiles)
+	sort.Strings(contents.Directories)
+	return contents, nil
+}
+
+// DetectExportSchema finds out the layout of the export, returning an
+// error describing what the export contains when it is not a Slack
+// export or its layout is not supported, rather than importing it
+// partially.
+func DetectExportSchema(fsys fs.FS) (ExportSchema, error) {
+	contents, err := listExportContents(fsys)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list the files of the export")
+	}
+
+	if !contents.hasRootFile("users.json") {
+		if len(contents.RootFiles) == 0 && len(contents.Directories) == 1 {
+			if _, err := fs.Stat(fsys, path.Join(contents.Directories[0], "users.json")); err == nil {
+				return "", errors.Errorf("the export is nested in the %s directory, the archive must contain its files directly. The archive contains %s", contents.Directories[0], contents)
+			}
+		}
+		return "", errors.Errorf("users.json is missing, this is not a Slack export or it is incomplete. The archive contains %s", contents)
+	}
+
+	typeDirs := []string{}
+	for _, dir := range conversationTypeDirs {
+		if contents.NestedFiles[dir] > 0 {
+			typeDirs = append(typeDirs, dir+"/")
+		}
+	}
+	if len(typeDirs) > 0 {
+		return "", errors.Errorf("the export files the conversations in the %s directories, a layout that is not supported. Only the exports of Slack, with a directory per conversation at the root, can be imported. The archive contains %s", strings.Join(typeDirs, ", "), contents)
+	}
+
+	if contents.hasRootFile("ims.json") && !contents.hasRootFile("dms.json") {
+		return SchemaLegacyIMs, nil
+	}
+	return SchemaStandard, nil
+}
@@ -0,0 +1,89 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectExportSchema(t *testing.T) {
+	schema, err := DetectExportSchema(testExportFS())
+	require.NoError(t, err)
+	assert.Equal(t, SchemaStandard, schema)
+
+	fsys := testExportFS()
+	fsys["ims.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+	schema, err = DetectExportSchema(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaLegacyIMs, schema)
+
+	// dms.json takes precedence
+	fsys["dms.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+	schema, err = DetectExportSchema(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaStandard, schema)
+}
+
+func TestDetectExportSchemaErrors(t *testing.T) {
+	nested := fstest.MapFS{}
+	for filePath, file := range testExportFS() {
+		nested["export/"+filePath] = file
+	}
+	_, err := DetectExportSchema(nested)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nested in the export directory")
+
+	_, err = DetectExportSchema(fstest.MapFS{
+		"messages.csv": &fstest.MapFile{Data: []byte("a,b")},
+		"notes/a.txt":  &fstest.MapFile{Data: []byte("a")},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "users.json is missing")
+	assert.Contains(t, err.Error(), "messages.csv; 1 directories: notes/")
+
+	typeDirs := testExportFS()
+	typeDirs["ims/D1/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+	typeDirs["ims/D2/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+	typeDirs["mpims/mpdm-a--b-1/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[]`)}
+	_, err = DetectExportSchema(typeDirs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "in the ims/, mpims/ directories")
+	assert.Contains(t, err.Error(), "ims/ (2 nested JSON files)")
+
+	_, err = TransformFS(context.Background(), typeDirs, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	assert.Error(t, err)
+}
+
+func TestTransformFSLegacyIMs(t *testing.T) {
+	fsys := testExportFS()
+	fsys["ims.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "D1", "members": ["U1", "U2"]}
+	]`)}
+	fsys["D1/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "direct", "ts": "1577836802.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, SchemaLegacyIMs, result.SlackExport.Schema)
+	require.Len(t, result.Intermediate.DirectChannels, 1)
+	assert.Zero(t, result.TransformResult.Count(WarningOrphanedDirectChannel))
+
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Contains(t, messages, "direct")
+}
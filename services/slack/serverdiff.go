@@ -0,0 +1,246 @@
+package slack
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// DiffAction is what importing an entity does to the target server
+type DiffAction string
+
+const (
+	// DiffCreate is for the entities missing from the server
+	DiffCreate DiffAction = "create"
+	// DiffMerge is for the entities found on the server, which are
+	// updated by the import
+	DiffMerge DiffAction = "merge"
+	// DiffConflict is for the entities the import fails for or
+	// imports in an unexpected way, see DiffEntry.Detail
+	DiffConflict DiffAction = "conflict"
+)
+
+// DiffEntry is the effect of the import on an entity.
+type DiffEntry struct {
+	Action DiffAction
+	// Kind is "team", "user" or "channel"
+	Kind   string
+	Name   string
+	Detail string
+}
+
+// ServerDiff compares the team, users and channels to import with
+// those existing on the target server. The direct and group channels
+// are left out, as they are always merged by their members.
+type ServerDiff struct {
+	Entries []DiffEntry
+}
+
+// Count returns the number of entries with the action.
+func (d *ServerDiff) Count(action DiffAction) int {
+	count := 0
+	for _, entry := range d.Entries {
+		if entry.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// ServerLookup finds the entities of the target server, returning
+// nil without an error for the entities that do not exist.
+type ServerLookup interface {
+	TeamByName(name string) (*model.Team, error)
+	UserByUsername(username string) (*model.User, error)
+	UserByEmail(email string) (*model.User, error)
+	ChannelByName(teamID, name string) (*model.Channel, error)
+}
+
+// client4Lookup looks the entities up with the API of the server
+type client4Lookup struct {
+	client *model.Client4
+}
+
+// NewServerLookup returns a lookup calling the API of the server at
+// siteURL with the token, which should be a system administrator's to
+// find the private channels.
+func NewServerLookup(siteURL, token string) ServerLookup {
+	client := model.NewAPIv4Client(siteURL)
+	client.SetToken(token)
+	return &client4Lookup{client: client}
+}
+
+// notFound returns whether the request failed as the entity does not
+// exist, or the error of the request otherwise
+func notFound(response *model.Response, err error) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+	if response != nil && response.StatusCode == http.StatusNotFound {
+		return true, nil
+	}
+	return false, err
+}
+
+func (l *client4Lookup) TeamByName(name string) (*model.Team, error) {
+	team, response, err := l.client.GetTeamByName(name, "")
+	if missing, err := notFound(response, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the team %s", name)
+	} else if missing {
+		return nil, nil
+	}
+	return team, nil
+}
+
+func (l *client4Lookup) UserByUsername(username string) (*model.User, error) {
+	user, response, err := l.client.GetUserByUsername(username, "")
+	if missing, err := notFound(response, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the user %s", username)
+	} else if missing {
+		return nil, nil
+	}
+	return user, nil
+}
+
+func (l *client4Lookup) UserByEmail(email string) (*model.User, error) {
+	user, response, err := l.client.GetUserByEmail(email, "")
+	if missing, err := notFound(response, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the user with email %s", email)
+	} else if missing {
+		return nil, nil
+	}
+	return user, nil
+}
+
+func (l *client4Lookup) ChannelByName(teamID, name string) (*model.Channel, error) {
+	channel, response, err := l.client.GetChannelByName(name, teamID, "")
+	if missing, err := notFound(response, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the channel %s", name)
+	} else if missing {
+		return nil, nil
+	}
+	return channel, nil
+}
+
+// DiffWithServer compares the transformed team, users and channels
+// with those of the server, as the bulk import matches them: the team
+// and channels by name and the users by username, their email having
+// to be unique.
+func (t *Transformer) DiffWithServer(lookup ServerLookup) (*ServerDiff, error) {
+	diff := &ServerDiff{}
+
+	team, err := lookup.TeamByName(t.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case team != nil:
+		diff.Entries = append(diff.Entries, DiffEntry{Action: DiffMerge, Kind: "team", Name: t.TeamName})
+	case t.Intermediate.Team != nil:
+		diff.Entries = append(diff.Entries, DiffEntry{Action: DiffCreate, Kind: "team", Name: t.TeamName})
+	default:
+		diff.Entries = append(diff.Entries, DiffEntry{Action: DiffConflict, Kind: "team", Name: t.TeamName, Detail: "the team does not exist and is not created by the import"})
+	}
+
+	users := make([]*IntermediateUser, 0, len(t.Intermediate.UsersById))
+	for _, user := range t.Intermediate.UsersById {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	for _, user := range users {
+		entry, err := diffUser(lookup, user)
+		if err != nil {
+			return nil, err
+		}
+		diff.Entries = append(diff.Entries, entry)
+	}
+
+	channels := append(append([]*IntermediateChannel{}, t.Intermediate.PublicChannels...), t.Intermediate.PrivateChannels...)
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	for _, channel := range channels {
+		if team == nil {
+			diff.Entries = append(diff.Entries, DiffEntry{Action: DiffCreate, Kind: "channel", Name: channel.Name})
+			continue
+		}
+		entry, err := diffChannel(lookup, team.Id, channel)
+		if err != nil {
+			return nil, err
+		}
+		diff.Entries = append(diff.Entries, entry)
+	}
+
+	return diff, nil
+}
+
+func diffUser(lookup ServerLookup, user *IntermediateUser) (DiffEntry, error) {
+	entry := DiffEntry{Kind: "user", Name: user.Username}
+
+	existing, err := lookup.UserByUsername(user.Username)
+	if err != nil {
+		return entry, err
+	}
+	if existing != nil && strings.EqualFold(existing.Email, user.Email) {
+		entry.Action = DiffMerge
+		return entry, nil
+	}
+
+	byEmail, err := lookup.UserByEmail(user.Email)
+	if err != nil {
+		return entry, err
+	}
+	switch {
+	case byEmail != nil && (existing == nil || byEmail.Id != existing.Id):
+		entry.Action = DiffConflict
+		entry.Detail = fmt.Sprintf("the email %s belongs to the user %s", user.Email, byEmail.Username)
+	case existing != nil:
+		entry.Action = DiffMerge
+		entry.Detail = fmt.Sprintf("the email changes from %s to %s", existing.Email, user.Email)
+	default:
+		entry.Action = DiffCreate
+	}
+	return entry, nil
+}
+
+func diffChannel(lookup ServerLookup, teamID string, channel *IntermediateChannel) (DiffEntry, error) {
+	entry := DiffEntry{Kind: "channel", Name: channel.Name}
+
+	existing, err := lookup.ChannelByName(teamID, channel.Name)
+	if err != nil {
+		return entry, err
+	}
+	switch {
+	case existing == nil:
+		entry.Action = DiffCreate
+	case existing.DeleteAt != 0:
+		entry.Action = DiffConflict
+		entry.Detail = "the channel is archived"
+	case existing.Type != channel.Type:
+		entry.Action = DiffConflict
+		entry.Detail = fmt.Sprintf("the channel exists with the type %s instead of %s", existing.Type, channel.Type)
+	default:
+		entry.Action = DiffMerge
+	}
+	return entry, nil
+}
+
+// WriteServerDiff writes the entries of the diff as a table, followed
+// by the number of entries of each action.
+func WriteServerDiff(writer io.Writer, diff *ServerDiff) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tKIND\tNAME\tDETAIL")
+	for _, entry := range diff.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Action, entry.Kind, entry.Name, entry.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(writer, "\n%d to create, %d to merge, %d conflicts\n", diff.Count(DiffCreate), diff.Count(DiffMerge), diff.Count(DiffConflict))
+	return err
+}
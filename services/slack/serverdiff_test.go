@@ -0,0 +1,131 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServerLookup finds the entities in memory
+type fakeServerLookup struct {
+	teams    map[string]*model.Team
+	users    []*model.User
+	channels map[string]*model.Channel
+}
+
+func (l *fakeServerLookup) TeamByName(name string) (*model.Team, error) {
+	return l.teams[name], nil
+}
+
+func (l *fakeServerLookup) UserByUsername(username string) (*model.User, error) {
+	for _, user := range l.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (l *fakeServerLookup) UserByEmail(email string) (*model.User, error) {
+	for _, user := range l.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (l *fakeServerLookup) ChannelByName(teamID, name string) (*model.Channel, error) {
+	return l.channels[teamID+"/"+name], nil
+}
+
+func transformUsersAndChannels(t *testing.T, createTeam bool) *Result {
+	result, err := TransformFS(context.Background(), testExportFS(), Options{
+		TeamName:        "team",
+		CreateTeam:      createTeam,
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipPosts: true, SkipAttachments: true},
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestDiffWithServer(t *testing.T) {
+	result := transformUsersAndChannels(t, false)
+	john := result.Intermediate.UsersById["U1"]
+	jane := result.Intermediate.UsersById["U2"]
+
+	lookup := &fakeServerLookup{
+		teams: map[string]*model.Team{"team": {Id: "T1", Name: "team"}},
+		users: []*model.User{
+			{Id: "1", Username: "john", Email: john.Email},
+			{Id: "2", Username: "janedoe", Email: jane.Email},
+		},
+		channels: map[string]*model.Channel{"T1/general": {Id: "C1", Name: "general", Type: model.ChannelTypePrivate}},
+	}
+
+	diff, err := result.DiffWithServer(lookup)
+	require.NoError(t, err)
+	assert.Equal(t, []DiffEntry{
+		{Action: DiffMerge, Kind: "team", Name: "team"},
+		{Action: DiffConflict, Kind: "user", Name: "jane", Detail: "the email " + jane.Email + " belongs to the user janedoe"},
+		{Action: DiffMerge, Kind: "user", Name: "john"},
+		{Action: DiffConflict, Kind: "channel", Name: "general", Detail: "the channel exists with the type P instead of O"},
+	}, diff.Entries)
+
+	var buffer bytes.Buffer
+	require.NoError(t, WriteServerDiff(&buffer, diff))
+	assert.Contains(t, buffer.String(), "0 to create, 2 to merge, 2 conflicts")
+}
+
+func TestDiffWithServerEmptyServer(t *testing.T) {
+	lookup := &fakeServerLookup{}
+
+	diff, err := transformUsersAndChannels(t, false).DiffWithServer(lookup)
+	require.NoError(t, err)
+	assert.Equal(t, DiffEntry{Action: DiffConflict, Kind: "team", Name: "team", Detail: "the team does not exist and is not created by the import"}, diff.Entries[0])
+	assert.Equal(t, 3, diff.Count(DiffCreate))
+
+	diff, err = transformUsersAndChannels(t, true).DiffWithServer(lookup)
+	require.NoError(t, err)
+	assert.Equal(t, 4, diff.Count(DiffCreate))
+	assert.Zero(t, diff.Count(DiffConflict))
+}
+
+func TestServerLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "BEARER token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v4/users/username/john":
+			json.NewEncoder(w).Encode(&model.User{Id: "1", Username: "john"})
+		case "/api/v4/teams/name/team":
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(&model.AppError{Id: "boom", StatusCode: http.StatusInternalServerError})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(&model.AppError{Id: "not_found", StatusCode: http.StatusNotFound})
+		}
+	}))
+	defer server.Close()
+
+	lookup := NewServerLookup(server.URL, "token")
+
+	user, err := lookup.UserByUsername("john")
+	require.NoError(t, err)
+	assert.Equal(t, "1", user.Id)
+
+	user, err = lookup.UserByEmail("jane@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, user)
+
+	_, err = lookup.TeamByName("team")
+	assert.Error(t, err)
+}
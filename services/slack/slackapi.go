@@ -0,0 +1,298 @@
+package slack
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultSlackAPIURL = "https://slack.com/api"
+	// DefaultSlackAPIInterval spaces the calls to stay under the
+	// limits of the tier 3 methods, around 50 calls per minute
+	DefaultSlackAPIInterval = 1200 * time.Millisecond
+	slackAPIAttempts        = 5
+)
+
+// slackAPIRetryDelay is multiplied by the attempt number to wait
+// before retrying a failed call, when Slack does not say how long to
+// wait
+var slackAPIRetryDelay = time.Second
+
+// SlackAPIError is an error returned by a Slack Web API method
+type SlackAPIError struct {
+	Method string
+	Code   string
+}
+
+func (e *SlackAPIError) Error() string {
+	return fmt.Sprintf("slack api %s: %s", e.Method, e.Code)
+}
+
+// SlackAPIClient calls the Slack Web API to enrich the data of the
+// export. The calls are spaced by Interval and retried when rate
+// limited, and their responses are cached on disk when CacheDir is
+// set, so an interrupted run does not call the API again.
+type SlackAPIClient struct {
+	Token      string
+	BaseURL    string
+	HTTPClient *http.Client
+	// Interval is the minimum time between two calls
+	Interval time.Duration
+	CacheDir string
+	Logger   log.FieldLogger
+
+	mu       sync.Mutex
+	lastCall time.Time
+	calls    int
+	hits     int
+}
+
+func NewSlackAPIClient(token, cacheDir string, logger log.FieldLogger) *SlackAPIClient {
+	return &SlackAPIClient{
+		Token:      token,
+		BaseURL:    DefaultSlackAPIURL,
+		HTTPClient: http.DefaultClient,
+		Interval:   DefaultSlackAPIInterval,
+		CacheDir:   cacheDir,
+		Logger:     logger,
+	}
+}
+
+// Stats returns the number of calls made to the API and the number of
+// responses read from the cache
+func (c *SlackAPIClient) Stats() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls, c.hits
+}
+
+func (c *SlackAPIClient) cachePath(method string, params url.Values) string {
+	// Encode sorts the parameters by key
+	hash := sha256.Sum256([]byte(method + "?" + params.Encode()))
+	return filepath.Join(c.CacheDir, method, hex.EncodeToString(hash[:])+".json")
+}
+
+func (c *SlackAPIClient) readCache(method string, params url.Values) ([]byte, bool) {
+	if c.CacheDir == "" {
+		return nil, false
+	}
+	body, err := os.ReadFile(c.cachePath(method, params))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// writeCache stores a response through a temporary file, so an
+// interrupted run never leaves a partial response in the cache
+func (c *SlackAPIClient) writeCache(method string, params url.Values, body []byte) error {
+	if c.CacheDir == "" {
+		return nil
+	}
+	cachePath := c.cachePath(method, params)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		return errors.Wrap(err, "failed to create the slack api cache directory")
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(cachePath), ".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the slack api cache file")
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(body); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "failed to write the slack api cache file")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the slack api cache file")
+	}
+	return os.Rename(tmpFile.Name(), cachePath)
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wait spaces the calls by Interval
+func (c *SlackAPIClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	delay := time.Until(c.lastCall.Add(c.Interval))
+	c.lastCall = time.Now()
+	if delay > 0 {
+		c.lastCall = c.lastCall.Add(delay)
+	}
+	c.calls++
+	c.mu.Unlock()
+	return sleepContext(ctx, delay)
+}
+
+// post makes a single call. When it can be retried, it returns
+// whether to retry and how long Slack asked to wait, zero when it did
+// not say.
+func (c *SlackAPIClient) post(ctx context.Context, method string, params url.Values) ([]byte, bool, time.Duration, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.BaseURL, "/")+"/"+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, false, 0, err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.Token)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return nil, true, 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusTooManyRequests {
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, true, retryAfter, &SlackAPIError{Method: method, Code: "ratelimited"}
+	}
+	if response.StatusCode != http.StatusOK {
+		retry := response.StatusCode >= http.StatusInternalServerError
+		return nil, retry, 0, errors.Errorf("slack api %s: unexpected status %s", method, response.Status)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, true, 0, err
+	}
+	return body, false, 0, nil
+}
+
+// Call calls a Web API method and decodes its response into result,
+// returning a *SlackAPIError when the response is not ok. The
+// successful responses are cached.
+func (c *SlackAPIClient) Call(ctx context.Context, method string, params url.Values, result interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	body, cached := c.readCache(method, params)
+	if cached {
+		c.mu.Lock()
+		c.hits++
+		c.mu.Unlock()
+	} else {
+		var err error
+		for attempt := 1; ; attempt++ {
+			if err = c.wait(ctx); err != nil {
+				return err
+			}
+			var retry bool
+			var retryAfter time.Duration
+			body, retry, retryAfter, err = c.post(ctx, method, params)
+			if err == nil || !retry || attempt == slackAPIAttempts {
+				break
+			}
+			if retryAfter == 0 {
+				retryAfter = time.Duration(attempt) * slackAPIRetryDelay
+			}
+			c.Logger.WithError(err).Debugf("Retrying the slack api call %s in %s", method, retryAfter)
+			if err = sleepContext(ctx, retryAfter); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var status struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return errors.Wrapf(err, "failed to decode the response of the slack api %s", method)
+	}
+	if !status.Ok {
+		return &SlackAPIError{Method: method, Code: status.Error}
+	}
+	if !cached {
+		if err := c.writeCache(method, params, body); err != nil {
+			c.Logger.WithError(err).Warn("Unable to cache the slack api response")
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(body, result), "failed to decode the response of the slack api %s", method)
+}
+
+// UserInfo returns a user with the users.info method. The email is
+// only returned with the users:read.email scope.
+func (c *SlackAPIClient) UserInfo(ctx context.Context, userID string) (*SlackUser, error) {
+	var response struct {
+		User SlackUser `json:"user"`
+	}
+	if err := c.Call(ctx, "users.info", url.Values{"user": {userID}}, &response); err != nil {
+		return nil, err
+	}
+	return &response.User, nil
+}
+
+// EnrichUsers completes the users of the export missing an email or a
+// name with the Slack API, leaving the users it fails to get as is.
+func (t *Transformer) EnrichUsers(ctx context.Context, users []SlackUser) error {
+	if t.SlackAPI == nil {
+		return nil
+	}
+
+	enriched := 0
+	for i := range users {
+		user := &users[i]
+		if user.Profile.Email != "" && (user.Profile.FirstName != "" || user.Profile.LastName != "") {
+			continue
+		}
+
+		apiUser, err := t.SlackAPI.UserInfo(ctx, user.Id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			t.Logger.WithError(err).Warnf("Unable to get the Slack user %s from the API", user.Username)
+			continue
+		}
+
+		if user.Profile.Email == "" {
+			user.Profile.Email = apiUser.Profile.Email
+		}
+		if user.Profile.FirstName == "" && user.Profile.LastName == "" {
+			user.Profile.FirstName = apiUser.Profile.FirstName
+			user.Profile.LastName = apiUser.Profile.LastName
+		}
+		if user.Profile.RealName == "" {
+			user.Profile.RealName = apiUser.Profile.RealName
+		}
+		enriched++
+	}
+
+	calls, hits := t.SlackAPI.Stats()
+	t.Logger.Infof("Completed %d users with the Slack API (%d calls, %d cached)", enriched, calls, hits)
+	return nil
+}
@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackAPIClient(t *testing.T) {
+	oldRetryDelay := slackAPIRetryDelay
+	slackAPIRetryDelay = time.Millisecond
+	defer func() { slackAPIRetryDelay = oldRetryDelay }()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+			w.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+			return
+		}
+		// the first call is rate limited and the second one fails
+		switch call {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		switch r.FormValue("user") {
+		case "U1":
+			w.Write([]byte(`{"ok": true, "user": {"id": "U1", "name": "john", "profile": {"email": "john@example.com", "first_name": "John"}}}`))
+		default:
+			w.Write([]byte(`{"ok": false, "error": "user_not_found"}`))
+		}
+	}))
+	defer server.Close()
+
+	newClient := func(cacheDir string) *SlackAPIClient {
+		client := NewSlackAPIClient("xoxb-token", cacheDir, log.New())
+		client.BaseURL = server.URL
+		client.Interval = 0
+		return client
+	}
+	cacheDir := t.TempDir()
+
+	client := newClient(cacheDir)
+	user, err := client.UserInfo(context.Background(), "U1")
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", user.Profile.Email)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	_, err = client.UserInfo(context.Background(), "U2")
+	var apiErr *SlackAPIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "user_not_found", apiErr.Code)
+
+	t.Run("cached", func(t *testing.T) {
+		client := newClient(cacheDir)
+		user, err := client.UserInfo(context.Background(), "U1")
+		require.NoError(t, err)
+		assert.Equal(t, "john", user.Username)
+		calls, hits := client.Stats()
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, 1, hits)
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		client := newClient("")
+		client.Interval = 50 * time.Millisecond
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := client.UserInfo(context.Background(), "U1")
+			require.NoError(t, err)
+		}
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	})
+
+	t.Run("enrich users", func(t *testing.T) {
+		transformer := NewTransformer("team", log.New())
+		transformer.SlackAPI = newClient(cacheDir)
+		users := []SlackUser{
+			{Id: "U1", Username: "john"},
+			{Id: "U2", Username: "jane"},
+			{Id: "U3", Username: "bob", Profile: SlackProfile{Email: "bob@example.com", FirstName: "Bob"}},
+		}
+		require.NoError(t, transformer.EnrichUsers(context.Background(), users))
+		assert.Equal(t, "john@example.com", users[0].Profile.Email)
+		assert.Equal(t, "John", users[0].Profile.FirstName)
+		assert.Empty(t, users[1].Profile.Email)
+	})
+}
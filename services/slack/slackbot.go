@@ -0,0 +1,41 @@
+package slack
+
+import "github.com/mattermost/mattermost-server/v6/model"
+
+// slackbotUserID is the user of the messages posted by Slackbot, which
+// is not part of the users of the export
+const slackbotUserID = "USLACKBOT"
+
+const SlackbotUserName = "imported-slackbot"
+
+// selectOrCreateSlackbotUser returns the placeholder user the messages
+// of Slackbot are imported with
+func (t *Transformer) selectOrCreateSlackbotUser() *IntermediateUser {
+	existingUser, ok := t.Intermediate.UsersById[slackbotUserID]
+	if ok {
+		return existingUser
+	}
+	newUser := &IntermediateUser{
+		Id:        slackbotUserID,
+		Username:  SlackbotUserName,
+		FirstName: "Slackbot",
+		LastName:  "",
+		Email:     "imported-slackbot@tinkoff.ru",
+		Password:  model.NewId(),
+	}
+
+	newUser.Sanitise(t.Logger)
+	t.Intermediate.UsersById[slackbotUserID] = newUser
+	return newUser
+}
+
+// slackbotAuthor returns the placeholder user of the Slackbot messages
+// when ImportSlackbotMessages is set, or nil as they are skipped
+// without a warning otherwise
+func (t *Transformer) slackbotAuthor(pc *PostContext, post SlackPost) *IntermediateUser {
+	if pc.Config.ImportSlackbotMessages {
+		return t.selectOrCreateSlackbotUser()
+	}
+	t.Logger.Debugf("Skipping the Slackbot message %s of channel %s", post.TimeStamp, pc.OriginalChannelName)
+	return nil
+}
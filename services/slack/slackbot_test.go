@@ -0,0 +1,69 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackbotMessages(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "USLACKBOT", "text": "You have been removed from #secret", "ts": "1577923200.000100"}
+	]`)}
+
+	transform := func(t *testing.T, importSlackbot bool) *Result {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, ImportSlackbotMessages: importSlackbot},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.TransformResult.Count(WarningUnknownUser))
+		return result
+	}
+
+	t.Run("skipped", func(t *testing.T) {
+		result := transform(t, false)
+		assert.Len(t, result.Intermediate.Posts, 2)
+		assert.NotContains(t, result.Intermediate.UsersById, slackbotUserID)
+	})
+
+	t.Run("imported", func(t *testing.T) {
+		result := transform(t, true)
+		require.Len(t, result.Intermediate.Posts, 3)
+		require.Contains(t, result.Intermediate.UsersById, slackbotUserID)
+		assert.Equal(t, SlackbotUserName, result.Intermediate.UsersById[slackbotUserID].Username)
+
+		found := false
+		for _, post := range result.Intermediate.Posts {
+			if post.Message == "You have been removed from #secret" {
+				found = true
+				assert.Equal(t, SlackbotUserName, post.User)
+			}
+		}
+		assert.True(t, found)
+	})
+}
+
+func TestStreamFSSlackbotUser(t *testing.T) {
+	var exporter recordingExporter
+	_, err := StreamFS(context.Background(), testExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true, ImportSlackbotMessages: true},
+	}, &exporter)
+	require.NoError(t, err)
+
+	usernames := []string{}
+	for _, line := range exporter.lines {
+		if line.User != nil {
+			usernames = append(usernames, *line.User.Username)
+		}
+	}
+	assert.Contains(t, usernames, SlackbotUserName)
+}
@@ -0,0 +1,76 @@
+package slack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/pkg/errors"
+)
+
+// ReadImportLines reads the lines of a bulk import file, calling handle
+// for each of them in order. The file must start with the version line.
+func ReadImportLines(reader io.Reader, handle func(line *app.LineImportData) error) error {
+	buffered := bufio.NewReader(reader)
+	for number := 1; ; number++ {
+		b, err := buffered.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrap(err, "failed to read the import file")
+		}
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			var line app.LineImportData
+			if jsonErr := json.Unmarshal(b, &line); jsonErr != nil {
+				return errors.Wrapf(jsonErr, "invalid line %d of the import file", number)
+			}
+			if number == 1 && line.Type != "version" {
+				return errors.Errorf("the import file starts with a %s line instead of the version line", line.Type)
+			}
+			if handleErr := handle(&line); handleErr != nil {
+				return handleErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// ChunkSizeForCount returns the MaxBytes of a SplittingExporter writing
+// the lines of the import file in at most count chunks. Each chunk
+// gets its share of the lines plus the room for the largest line, so
+// that a line not fitting in a chunk never adds one at the end.
+func ChunkSizeForCount(inputPath string, count int, countAttachments bool) (int64, error) {
+	if count <= 0 {
+		return 0, errors.Errorf("invalid chunk count %d", count)
+	}
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open the import file")
+	}
+	defer file.Close()
+
+	sizer := &SplittingExporter{CountAttachments: countAttachments}
+	var total, largest, versionSize int64
+	err = ReadImportLines(file, func(line *app.LineImportData) error {
+		size, err := sizer.lineSize(line)
+		if err != nil {
+			return err
+		}
+		if line.Type == "version" {
+			versionSize = size
+			return nil
+		}
+		total += size
+		if size > largest {
+			largest = size
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return versionSize + (total+int64(count)-1)/int64(count) + largest, nil
+}
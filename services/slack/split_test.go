@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImportFile(t *testing.T, posts int) string {
+	lines := []string{
+		`{"type":"version","version":1}`,
+		`{"type":"channel","channel":{"team":"team","name":"general","display_name":"General","type":"O"}}`,
+	}
+	for i := 0; i < posts; i++ {
+		lines = append(lines, fmt.Sprintf(`{"type":"post","post":{"team":"team","channel":"general","user":"john","message":"message %d","create_at":%d}}`, i, 1577836800000+int64(i)))
+	}
+	inputPath := filepath.Join(t.TempDir(), "bulk-export.jsonl")
+	require.NoError(t, os.WriteFile(inputPath, []byte(strings.Join(lines, "\n")), 0600))
+	return inputPath
+}
+
+func TestReadImportLines(t *testing.T) {
+	types := []string{}
+	file, err := os.Open(testImportFile(t, 2))
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, ReadImportLines(file, func(line *app.LineImportData) error {
+		types = append(types, line.Type)
+		return nil
+	}))
+	assert.Equal(t, []string{"version", "channel", "post", "post"}, types)
+
+	noop := func(*app.LineImportData) error { return nil }
+	err = ReadImportLines(strings.NewReader(`{"type":"user","user":{}}`), noop)
+	assert.Contains(t, err.Error(), "instead of the version line")
+	err = ReadImportLines(strings.NewReader("{\"type\":\"version\",\"version\":1}\n{\"type\":"), noop)
+	assert.Contains(t, err.Error(), "invalid line 2")
+}
+
+func TestChunkSizeForCount(t *testing.T) {
+	inputPath := testImportFile(t, 25)
+
+	for _, count := range []int{1, 2, 3, 7} {
+		maxBytes, err := ChunkSizeForCount(inputPath, count, false)
+		require.NoError(t, err)
+
+		chunks := []*recordingExporter{}
+		exporter := NewSplittingExporter(maxBytes, false, func(index int) (Exporter, error) {
+			chunks = append(chunks, &recordingExporter{})
+			return chunks[len(chunks)-1], nil
+		})
+		file, err := os.Open(inputPath)
+		require.NoError(t, err)
+		require.NoError(t, ReadImportLines(file, exporter.WriteLine))
+		file.Close()
+
+		assert.LessOrEqual(t, len(chunks), count, "count %d", count)
+		lines := 0
+		for _, chunk := range chunks {
+			assert.Equal(t, "version", chunk.lines[0].Type)
+			lines += len(chunk.lines) - 1
+		}
+		assert.Equal(t, 26, lines, "count %d", count)
+	}
+
+	_, err := ChunkSizeForCount(inputPath, 0, false)
+	assert.Error(t, err)
+}
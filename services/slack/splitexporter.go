@@ -0,0 +1,158 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/pkg/errors"
+)
+
+// byteSizeUnits are the suffixes of ParseByteSize, in powers of 1024
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as 1GB, 512MB or 1048576, the
+// units being powers of 1024
+func ParseByteSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.size
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return 0, errors.Errorf("invalid size %q, expected a positive number of bytes with an optional KB, MB or GB unit", size)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// ChunkPath returns the path of a chunk of a split output, numbered
+// from 1 before the extension, e.g. bulk-export.002.jsonl
+func ChunkPath(outputPath string, index int) string {
+	extension := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s.%03d%s", strings.TrimSuffix(outputPath, extension), index, extension)
+}
+
+// SplittingExporter writes the lines to a sequence of chunks, starting
+// a new chunk before a line would take the current one over MaxBytes.
+// Every chunk starts with the version line, so the chunks can be
+// imported one after the other. A line larger than MaxBytes gets a
+// chunk of its own.
+type SplittingExporter struct {
+	// MaxBytes is the size of the JSONL lines of a chunk, including
+	// the size of their attachments when CountAttachments is set, e.g.
+	// for zip archives
+	MaxBytes         int64
+	CountAttachments bool
+	// open creates the exporter of the chunk with the given index,
+	// starting at 1
+	open func(index int) (Exporter, error)
+
+	current     Exporter
+	chunks      int
+	size        int64
+	versionLine *app.LineImportData
+	versionSize int64
+}
+
+func NewSplittingExporter(maxBytes int64, countAttachments bool, open func(index int) (Exporter, error)) *SplittingExporter {
+	return &SplittingExporter{
+		MaxBytes:         maxBytes,
+		CountAttachments: countAttachments,
+		open:             open,
+	}
+}
+
+// lineSize returns the size a line takes in a chunk
+func (e *SplittingExporter) lineSize(line *app.LineImportData) (int64, error) {
+	b, err := json.Marshal(line)
+	if err != nil {
+		return 0, errors.Wrap(err, "An error occurred marshalling the JSON data for export.")
+	}
+	size := int64(len(b) + 1)
+	if e.CountAttachments {
+		for _, attachmentPath := range lineAttachmentPaths(line) {
+			info, err := os.Stat(attachmentPath)
+			if err != nil {
+				return 0, errors.Wrapf(err, "failed to get the size of attachment %s", attachmentPath)
+			}
+			size += info.Size()
+		}
+	}
+	return size, nil
+}
+
+func (e *SplittingExporter) nextChunk() error {
+	if e.current != nil {
+		if err := e.current.Close(); err != nil {
+			return err
+		}
+	}
+
+	e.chunks++
+	exporter, err := e.open(e.chunks)
+	if err != nil {
+		return err
+	}
+	e.current, e.size = exporter, 0
+
+	if e.versionLine == nil {
+		return nil
+	}
+	e.size = e.versionSize
+	return e.current.WriteLine(e.versionLine)
+}
+
+func (e *SplittingExporter) WriteLine(line *app.LineImportData) error {
+	size, err := e.lineSize(line)
+	if err != nil {
+		return err
+	}
+	if line.Type == "version" {
+		e.versionLine, e.versionSize = line, size
+	}
+
+	// a chunk holding only the version line takes the line whatever
+	// its size
+	if e.current == nil || (e.size+size > e.MaxBytes && e.size > e.versionSize) {
+		if err := e.nextChunk(); err != nil {
+			return err
+		}
+		if line == e.versionLine {
+			return nil
+		}
+	}
+
+	e.size += size
+	return e.current.WriteLine(line)
+}
+
+func (e *SplittingExporter) Close() error {
+	if e.current == nil {
+		return nil
+	}
+	return e.current.Close()
+}
+
+// Chunks returns the number of chunks written so far
+func (e *SplittingExporter) Chunks() int {
+	return e.chunks
+}
@@ -0,0 +1,81 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	testCases := []struct {
+		size     string
+		expected int64
+	}{
+		{"1048576", 1048576},
+		{"1GB", 1 << 30},
+		{"512mb", 512 << 20},
+		{"1.5K", 1536},
+		{"10 B", 10},
+	}
+	for _, tc := range testCases {
+		size, err := ParseByteSize(tc.size)
+		require.NoError(t, err, tc.size)
+		assert.Equal(t, tc.expected, size, tc.size)
+	}
+
+	for _, size := range []string{"", "GB", "-1MB", "1TB"} {
+		_, err := ParseByteSize(size)
+		assert.Error(t, err, size)
+	}
+}
+
+func TestChunkPath(t *testing.T) {
+	assert.Equal(t, "out/bulk-export.002.jsonl", ChunkPath("out/bulk-export.jsonl", 2))
+	assert.Equal(t, "export.010.zip", ChunkPath("export.zip", 10))
+}
+
+func TestSplittingExporter(t *testing.T) {
+	chunks := []*recordingExporter{}
+	exporter := NewSplittingExporter(400, false, func(index int) (Exporter, error) {
+		require.Equal(t, len(chunks)+1, index)
+		chunks = append(chunks, &recordingExporter{})
+		return chunks[len(chunks)-1], nil
+	})
+
+	version := 1
+	require.NoError(t, exporter.WriteLine(&app.LineImportData{Type: "version", Version: &version}))
+	post := func(message string) *app.LineImportData {
+		return &app.LineImportData{Type: "post", Post: &app.PostImportData{
+			Team:     model.NewString("team"),
+			Channel:  model.NewString("general"),
+			User:     model.NewString("john"),
+			Message:  model.NewString(message),
+			CreateAt: model.NewInt64(1577836800000),
+		}}
+	}
+	// two post lines fit in a chunk along with the version line
+	for _, message := range []string{"one", "two", "three"} {
+		require.NoError(t, exporter.WriteLine(post(message)))
+	}
+	// a line larger than the maximum gets its own chunk
+	long := string(make([]byte, 300))
+	require.NoError(t, exporter.WriteLine(post(long)))
+	require.NoError(t, exporter.WriteLine(post("four")))
+	require.NoError(t, exporter.Close())
+
+	require.Len(t, chunks, 4)
+	assert.Equal(t, 4, exporter.Chunks())
+	messages := [][]string{}
+	for _, chunk := range chunks {
+		require.Equal(t, "version", chunk.lines[0].Type)
+		chunkMessages := []string{}
+		for _, line := range chunk.lines[1:] {
+			chunkMessages = append(chunkMessages, *line.Post.Message)
+		}
+		messages = append(messages, chunkMessages)
+	}
+	assert.Equal(t, [][]string{{"one", "two"}, {"three"}, {long}, {"four"}}, messages)
+}
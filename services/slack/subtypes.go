@@ -0,0 +1,268 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// UnknownSubtypePolicy decides what happens to the messages whose
+// subtype has no registered handler
+type UnknownSubtypePolicy string
+
+const (
+	// UnknownSubtypeSkip leaves the messages out with a warning
+	UnknownSubtypeSkip UnknownSubtypePolicy = "skip"
+	// UnknownSubtypePlain imports the messages as plain messages of
+	// their user, with a warning
+	UnknownSubtypePlain UnknownSubtypePolicy = "plain"
+)
+
+func ParseUnknownSubtypePolicy(policy string) (UnknownSubtypePolicy, error) {
+	switch UnknownSubtypePolicy(policy) {
+	case "", UnknownSubtypeSkip:
+		return UnknownSubtypeSkip, nil
+	case UnknownSubtypePlain:
+		return UnknownSubtypePlain, nil
+	}
+	return "", errors.Errorf("unknown subtype policy %q, expected %q or %q", policy, UnknownSubtypeSkip, UnknownSubtypePlain)
+}
+
+// PostContext is the channel whose messages are being transformed
+type PostContext struct {
+	// ctx interrupts the waits of the transformation, such as the
+	// delays between the retries of the attachment copies
+	ctx context.Context
+
+	Config              *TransformConfig
+	SlackExport         *SlackExport
+	Channel             *IntermediateChannel
+	OriginalChannelName string
+
+	// reminders are the recurring reminders set up in the channel
+	reminders []SlackPost
+	// sharedFiles holds the thread of the message sharing each file
+	// of the channel, see ThreadFileComments
+	sharedFiles map[string]string
+}
+
+// SubtypeHandler converts a message of the export into a post. It
+// returns nil when the message is not imported, after raising a
+// warning when that is unexpected.
+type SubtypeHandler func(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost
+
+// defaultSubtypeHandlers returns the handlers of the message subtypes
+// known to the transformer
+func defaultSubtypeHandlers() map[string]SubtypeHandler {
+	return map[string]SubtypeHandler{
+		// plain message that can have files attached
+		"":                 handleMessage,
+		"file_share":       handlePlainMessage,
+		"thread_broadcast": handlePlainMessage,
+		"file_comment":     handleFileComment,
+		"bot_message":      handleBotMessage,
+		// join/leave and me messages are not yet supported
+		"channel_join":  ignoreMessage,
+		"channel_leave": ignoreMessage,
+		"me_message":    ignoreMessage,
+		// the topic, purpose and name changes are imported as the
+		// message of their user
+		"channel_topic":   handleUserMessage,
+		"channel_purpose": handleUserMessage,
+		"channel_name":    handleUserMessage,
+		"reminder_add":    handleReminderAdd,
+		// the thread of a huddle, holding its transcript and files
+		"huddle_thread": handleHuddleThread,
+	}
+}
+
+// RegisterSubtypeHandler sets the handler of the messages of a
+// subtype, replacing the default one if any
+func (t *Transformer) RegisterSubtypeHandler(subtype string, handler SubtypeHandler) {
+	if t.subtypeHandlers == nil {
+		t.subtypeHandlers = defaultSubtypeHandlers()
+	}
+	t.subtypeHandlers[subtype] = handler
+}
+
+// transformMessage converts a message with the handler of its
+// subtype, or following the UnknownSubtypes policy of the
+// configuration when there is none
+func (t *Transformer) transformMessage(pc *PostContext, post SlackPost) *IntermediatePost {
+	if t.subtypeHandlers == nil {
+		t.subtypeHandlers = defaultSubtypeHandlers()
+	}
+
+	if post.Type == "message" {
+		if handler, ok := t.subtypeHandlers[post.SubType]; ok {
+			return handler(t, pc, post)
+		}
+
+		if pc.Config.UnknownSubtypes == UnknownSubtypePlain {
+			t.warnPostf(WarningUnsupportedPostType, pc.OriginalChannelName, post, false, nil, "Importing the message as a plain message as its subtype is not supported. post_subtype=%s", post.SubType)
+			return handlePlainMessage(t, pc, post)
+		}
+	}
+
+	t.warnPostf(WarningUnsupportedPostType, pc.OriginalChannelName, post, true, nil, "Unable to import the message as its type is not supported. post_type=%s, post_subtype=%s", post.Type, post.SubType)
+	return nil
+}
+
+func ignoreMessage(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	return nil
+}
+
+// postAuthor returns the user of a message, raising a warning when
+// it is missing
+func (t *Transformer) postAuthor(pc *PostContext, post SlackPost, userID string) *IntermediateUser {
+	if userID == "" {
+		t.warnPostf(WarningMissingUser, pc.OriginalChannelName, post, true, nil, "Unable to import the message as the user field is missing.")
+		return nil
+	}
+	author := t.Intermediate.UsersById[userID]
+	if author == nil && t.excludedUsers[userID] {
+		return t.excludedUserAuthor(pc, post)
+	}
+	if author == nil && userID == slackbotUserID {
+		return t.slackbotAuthor(pc, post)
+	}
+	if author == nil {
+		t.warnPostf(WarningUnknownUser, pc.OriginalChannelName, post, true, nil, "Unable to add the message as the Slack user does not exist in Mattermost. user=%s", userID)
+		return nil
+	}
+	return author
+}
+
+// warnAttachment raises the warning of a file that could not be added
+// to its post, if any
+func (t *Transformer) warnAttachment(pc *PostContext, post SlackPost, err error) {
+	if errors.Is(err, errAttachmentQuarantined) {
+		t.warnPostf(WarningQuarantinedAttachment, pc.OriginalChannelName, post, false, err, "The file was flagged by the scanner and quarantined")
+	} else if err != nil {
+		t.warnPostf(WarningAttachmentFailed, pc.OriginalChannelName, post, false, err, "Failed to add file to post")
+	}
+}
+
+// addPostContent adds the files and the attachments of a message to
+// its post, returning false when the post must be skipped
+func (t *Transformer) addPostContent(pc *PostContext, post SlackPost, newPost *IntermediatePost) bool {
+	cfg := pc.Config
+	if (post.File != nil || post.Files != nil) && !cfg.SkipAttachments {
+		files := post.Files
+		if post.File != nil {
+			files = []*SlackFile{post.File}
+		}
+		for _, file := range files {
+			if cfg.SkipThumbnails && pc.SlackExport.isThumbnail(file) {
+				t.Logger.Debugf("Skipping the thumbnail of file %s", file.Id)
+				t.skippedThumbnails++
+				continue
+			}
+			err := t.addFileToPost(pc.ctx, file, pc.SlackExport, newPost, cfg.AttachmentsDir, cfg.AttachmentsLayout, cfg.AttachmentScanner, cfg.AttachmentRetries, cfg.KeepFailedAttachments)
+			t.warnAttachment(pc, post, err)
+		}
+	}
+
+	if len(post.Attachments) > 0 {
+		attachments := post.Attachments
+		if cfg.AttachmentImages != nil && !cfg.SkipAttachments {
+			attachments = t.downloadAttachmentImages(pc, post, newPost)
+		}
+		props := model.StringInterface{"attachments": attachments}
+		propsB, _ := json.Marshal(props)
+
+		if utf8.RuneCountInString(string(propsB)) <= model.PostPropsMaxRunes {
+			newPost.Props = props
+		} else {
+			if cfg.TrimOversizedProps {
+				if trimmed, dropped, ok := trimAttachments(attachments, model.PostPropsMaxRunes); ok {
+					newPost.Props = model.StringInterface{"attachments": trimmed}
+					t.warnPostf(WarningPropsTooLarge, pc.OriginalChannelName, post, false, nil, "Trimmed the attachments of the post as its props exceed the maximum character count, dropping %s", strings.Join(dropped, ", "))
+					return true
+				}
+			}
+			if cfg.DiscardInvalidProps {
+				t.warnPostf(WarningPropsTooLarge, pc.OriginalChannelName, post, true, nil, "Unable import post as props exceed the maximum character count. Skipping as --discard-invalid-props is enabled.")
+				return false
+			}
+			t.warnPostf(WarningPropsTooLarge, pc.OriginalChannelName, post, false, nil, "Unable to add props to post as they exceed the maximum character count.")
+		}
+	}
+	return true
+}
+
+func handlePlainMessage(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	newPost := handleUserMessage(t, pc, post)
+	if newPost == nil || !t.addPostContent(pc, post, newPost) {
+		return nil
+	}
+	return newPost
+}
+
+// handleUserMessage imports the text of a message of a user
+func handleUserMessage(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	author := t.postAuthor(pc, post, post.User)
+	if author == nil {
+		return nil
+	}
+	return &IntermediatePost{
+		User:     author.Username,
+		Channel:  pc.Channel.Name,
+		Message:  post.Text,
+		CreateAt: SlackConvertTimeStamp(post.TimeStamp),
+	}
+}
+
+func handleFileComment(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	if post.Comment == nil {
+		t.warnPostf(WarningMissingComment, pc.OriginalChannelName, post, true, nil, "Unable to import the message as it has no comments.")
+		return nil
+	}
+	author := t.postAuthor(pc, post, post.Comment.User)
+	if author == nil {
+		return nil
+	}
+	return &IntermediatePost{
+		User:     author.Username,
+		Channel:  pc.Channel.Name,
+		Message:  post.Comment.Comment,
+		CreateAt: SlackConvertTimeStamp(post.TimeStamp),
+	}
+}
+
+func handleBotMessage(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+	authorName, aliased := pc.Config.BotAliases.Lookup(post)
+	if !aliased {
+		var uploader, botUser *IntermediateUser
+		if pc.Config.AttributeAppUploads {
+			uploader = t.fileUploader(pc.Config, post)
+		}
+		if pc.Config.BotUsers {
+			botUser = t.botUser(post)
+		}
+		switch {
+		case uploader != nil:
+			authorName = uploader.Username
+		case botUser != nil:
+			authorName = botUser.Username
+		case !pc.Config.ImportWorkflowMessages:
+			return nil
+		default:
+			authorName = t.selectOrCreateWorkflowUser(post).Username
+		}
+	}
+	newPost := &IntermediatePost{
+		User:     authorName,
+		Channel:  pc.Channel.Name,
+		Message:  post.Text,
+		CreateAt: SlackConvertTimeStamp(post.TimeStamp),
+	}
+	if !t.addPostContent(pc, post, newPost) {
+		return nil
+	}
+	return newPost
+}
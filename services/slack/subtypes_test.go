@@ -0,0 +1,76 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtypeHandlers(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "channel_topic", "user": "U1", "text": "set the topic", "ts": "1577923200.000100"},
+		{"type": "message", "subtype": "channel_join", "user": "U2", "text": "joined", "ts": "1577923201.000100"},
+		{"type": "message", "subtype": "sh_room", "user": "U2", "text": "a call", "ts": "1577923202.000100"},
+		{"type": "event", "user": "U2", "text": "not a message", "ts": "1577923203.000100"}
+	]`)}
+
+	transform := func(t *testing.T, opts Options) *Result {
+		opts.TeamName = "team"
+		opts.Logger = log.New()
+		opts.TransformConfig.SkipAttachments = true
+		result, err := TransformFS(context.Background(), fsys, opts)
+		require.NoError(t, err)
+		return result
+	}
+	messages := func(result *Result) []string {
+		var messages []string
+		for _, post := range result.Intermediate.Posts {
+			messages = append(messages, post.Message)
+		}
+		return messages
+	}
+
+	t.Run("skip unknown subtypes", func(t *testing.T) {
+		result := transform(t, Options{})
+		assert.ElementsMatch(t, []string{"hello @jane", "a file", "set the topic"}, messages(result))
+		assert.Equal(t, 2, result.TransformResult.Count(WarningUnsupportedPostType))
+		assert.Equal(t, 2, result.TransformResult.SkippedCount())
+	})
+
+	t.Run("import unknown subtypes as plain messages", func(t *testing.T) {
+		result := transform(t, Options{TransformConfig: TransformConfig{UnknownSubtypes: UnknownSubtypePlain}})
+		assert.ElementsMatch(t, []string{"hello @jane", "a file", "set the topic", "a call"}, messages(result))
+		assert.Equal(t, 2, result.TransformResult.Count(WarningUnsupportedPostType))
+		assert.Equal(t, 1, result.TransformResult.SkippedCount())
+	})
+
+	t.Run("registered handlers", func(t *testing.T) {
+		result := transform(t, Options{SubtypeHandlers: map[string]SubtypeHandler{
+			"channel_join": func(t *Transformer, pc *PostContext, post SlackPost) *IntermediatePost {
+				newPost := handleUserMessage(t, pc, post)
+				newPost.Message = "_" + newPost.Message + "_"
+				return newPost
+			},
+			"channel_topic": ignoreMessage,
+		}})
+		assert.ElementsMatch(t, []string{"hello @jane", "a file", "_joined_"}, messages(result))
+	})
+}
+
+func TestParseUnknownSubtypePolicy(t *testing.T) {
+	policy, err := ParseUnknownSubtypePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, UnknownSubtypeSkip, policy)
+
+	policy, err = ParseUnknownSubtypePolicy("plain")
+	require.NoError(t, err)
+	assert.Equal(t, UnknownSubtypePlain, policy)
+
+	_, err = ParseUnknownSubtypePolicy("drop")
+	require.Error(t, err)
+}
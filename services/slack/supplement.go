@@ -0,0 +1,138 @@
+package slack
+
+import (
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// supplementsDir is the virtual directory under which the files of the
+// supplemental exports are served, as <supplementsDir>/<index>/<path>
+const supplementsDir = "__supplements"
+
+// supplementalFS serves the files of the main export along with the
+// files of the supplemental exports merged into it.
+type supplementalFS struct {
+	main        fs.FS
+	supplements []fs.FS
+}
+
+func supplementPath(index int, filePath string) string {
+	return path.Join(supplementsDir, strconv.Itoa(index), filePath)
+}
+
+func (s *supplementalFS) Open(name string) (fs.File, error) {
+	if !strings.HasPrefix(name, supplementsDir+"/") {
+		return s.main.Open(name)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(name, supplementsDir+"/"), "/", 2)
+	index, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) != 2 || index < 0 || index >= len(s.supplements) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return s.supplements[index].Open(parts[1])
+}
+
+// mergeChannels adds the channels missing from channels, merging the
+// members of the ones present in both. It returns the merged list and
+// the channels that were added.
+func mergeChannels(channels, supplementChannels []SlackChannel) ([]SlackChannel, []SlackChannel) {
+	indexById := map[string]int{}
+	for i, channel := range channels {
+		indexById[channel.Id] = i
+	}
+
+	added := []SlackChannel{}
+	for _, channel := range supplementChannels {
+		i, ok := indexById[channel.Id]
+		if !ok {
+			indexById[channel.Id] = len(channels)
+			channels = append(channels, channel)
+			added = append(added, channel)
+			continue
+		}
+
+		members := channels[i].Members
+		for _, member := range channel.Members {
+			if _, found := findMember(members, member); !found {
+				members = append(members[:len(members):len(members)], member)
+			}
+		}
+		channels[i].Members = members
+	}
+	return channels, added
+}
+
+func findMember(members []string, userId string) (int, bool) {
+	for i, member := range members {
+		if member == userId {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// mergeSupplementalExport merges the direct and group messages of a
+// per-user export into the main export. The conversations present in
+// both are merged, dropping the posts already in the main export.
+func (t *Transformer) mergeSupplementalExport(slackExport, supplement *SlackExport, index int) {
+	usersById := map[string]bool{}
+	for _, user := range slackExport.Users {
+		usersById[user.Id] = true
+	}
+	for _, user := range supplement.Users {
+		if !usersById[user.Id] {
+			usersById[user.Id] = true
+			slackExport.Users = append(slackExport.Users, user)
+		}
+	}
+
+	var addedDirect, addedGroup []SlackChannel
+	slackExport.DirectChannels, addedDirect = mergeChannels(slackExport.DirectChannels, supplement.DirectChannels)
+	slackExport.GroupChannels, addedGroup = mergeChannels(slackExport.GroupChannels, supplement.GroupChannels)
+	// Channels holds copies of the channels, so it is rebuilt with
+	// the merged members
+	slackExport.Channels = []SlackChannel{}
+	for _, channels := range [][]SlackChannel{slackExport.PublicChannels, slackExport.DirectChannels, slackExport.PrivateChannels, slackExport.GroupChannels} {
+		slackExport.Channels = append(slackExport.Channels, channels...)
+	}
+
+	if slackExport.mergedChannels == nil {
+		slackExport.mergedChannels = map[string]bool{}
+	}
+	for _, channel := range append(supplement.DirectChannels, supplement.GroupChannels...) {
+		channelName := getOriginalName(channel)
+		for _, filePath := range supplement.PostFiles[channelName] {
+			slackExport.PostFiles[channelName] = append(slackExport.PostFiles[channelName], supplementPath(index, filePath))
+		}
+		if posts, ok := supplement.Posts[channelName]; ok {
+			slackExport.Posts[channelName] = deduplicatePosts(append(slackExport.Posts[channelName], posts...))
+		}
+		slackExport.mergedChannels[channelName] = true
+	}
+
+	for fileId, filePath := range supplement.Uploads {
+		if _, ok := slackExport.Uploads[fileId]; !ok {
+			slackExport.Uploads[fileId] = supplementPath(index, filePath)
+		}
+	}
+
+	t.Logger.Infof("Merged supplemental export %d: %d new direct channels, %d new group channels", index+1, len(addedDirect), len(addedGroup))
+}
+
+// deduplicatePosts drops the posts with a timestamp already seen,
+// keeping the first one.
+func deduplicatePosts(posts []SlackPost) []SlackPost {
+	seen := map[string]bool{}
+	result := make([]SlackPost, 0, len(posts))
+	for _, post := range posts {
+		if seen[post.TimeStamp] {
+			continue
+		}
+		seen[post.TimeStamp] = true
+		result = append(result, post)
+	}
+	return result
+}
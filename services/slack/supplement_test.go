@@ -0,0 +1,86 @@
+package slack
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupplementalExports(t *testing.T) {
+	mainFS := testExportFS()
+	mainFS["dms.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "D1", "members": ["U1", "U2"]}
+	]`)}
+	mainFS["D1/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "in both", "ts": "1577836800.000100"}
+	]`)}
+
+	supplementFS := fstest.MapFS{
+		"users.json": &fstest.MapFile{Data: []byte(`[
+			{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com"}},
+			{"id": "U3", "name": "joe", "profile": {"email": "joe@example.com"}}
+		]`)},
+		"dms.json": &fstest.MapFile{Data: []byte(`[
+			{"id": "D1", "members": ["U1", "U2"]},
+			{"id": "D2", "members": ["U2", "U3"]}
+		]`)},
+		"D1/2020-01-01.json": &fstest.MapFile{Data: []byte(`[
+			{"type": "message", "user": "U1", "text": "in both", "ts": "1577836800.000100"},
+			{"type": "message", "user": "U2", "text": "only in supplement", "ts": "1577836900.000100"}
+		]`)},
+		"D2/2020-01-02.json": &fstest.MapFile{Data: []byte(`[
+			{"type": "message", "subtype": "file_share", "user": "U3", "text": "a file", "ts": "1577923200.000100", "files": [{"id": "F2", "name": "doc.txt"}]}
+		]`)},
+		"__uploads/F2/doc.txt": &fstest.MapFile{Data: []byte("a document")},
+	}
+
+	check := func(t *testing.T, result *Result, messages []string) {
+		assert.Len(t, result.Intermediate.UsersById, 3)
+		assert.Len(t, result.Intermediate.DirectChannels, 2)
+		assert.ElementsMatch(t, []string{"hello @jane", "a file", "in both", "only in supplement", "a file"}, messages)
+	}
+
+	t.Run("TransformFS", func(t *testing.T) {
+		result, err := TransformFS(context.Background(), mainFS, Options{
+			TeamName:            "team",
+			Logger:              log.New(),
+			SupplementalExports: []fs.FS{supplementFS},
+			TransformConfig:     TransformConfig{AttachmentsDir: t.TempDir()},
+		})
+		require.NoError(t, err)
+
+		messages := []string{}
+		for _, post := range result.Intermediate.Posts {
+			messages = append(messages, post.Message)
+			if post.Message == "a file" && post.IsDirect {
+				assert.Len(t, post.Attachments, 1)
+			}
+		}
+		check(t, result, messages)
+	})
+
+	t.Run("StreamFS", func(t *testing.T) {
+		var output strings.Builder
+		result, err := StreamFS(context.Background(), mainFS, Options{
+			TeamName:            "team",
+			Logger:              log.New(),
+			SupplementalExports: []fs.FS{supplementFS},
+			TransformConfig:     TransformConfig{AttachmentsDir: t.TempDir()},
+		}, NewJSONLExporter(&output))
+		require.NoError(t, err)
+
+		messages := []string{}
+		for _, message := range []string{"hello @jane", "a file", "in both", "only in supplement"} {
+			for i := 0; i < strings.Count(output.String(), `"message":"`+message+`"`); i++ {
+				messages = append(messages, message)
+			}
+		}
+		check(t, result, messages)
+	})
+}
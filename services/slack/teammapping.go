@@ -0,0 +1,160 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// TeamMappingRule imports the channels whose Slack name starts with
+// Prefix, or which belong to the SlackTeam of an Enterprise Grid or
+// merged export, into the Team
+type TeamMappingRule struct {
+	Prefix    string `json:"prefix,omitempty"`
+	SlackTeam string `json:"slack_team,omitempty"`
+	Team      string `json:"team"`
+}
+
+// TeamMapping spreads the public and private channels of the export
+// over several teams, the first matching rule winning. The channels
+// no rule matches, the direct and group channels are imported into
+// the team of the transformation.
+type TeamMapping []TeamMappingRule
+
+// ParseTeamMapping reads the rules, either a list or the "teams" list
+// of a configuration object, e.g.
+// {"teams": [{"prefix": "sec-", "team": "security"}, {"slack_team": "T123", "team": "core"}]}
+func ParseTeamMapping(reader io.Reader) (TeamMapping, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(reader).Decode(&raw); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the team mapping")
+	}
+	var config struct {
+		Teams TeamMapping `json:"teams"`
+	}
+	mapping := TeamMapping{}
+	if err := json.Unmarshal(raw, &config); err == nil {
+		mapping = config.Teams
+	} else if err := json.Unmarshal(raw, &mapping); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the team mapping")
+	}
+
+	for i, rule := range mapping {
+		if (rule.Prefix == "") == (rule.SlackTeam == "") {
+			return nil, fmt.Errorf("rule %d of the team mapping must have either a prefix or a slack_team", i+1)
+		}
+		if !model.IsValidTeamName(rule.Team) {
+			return nil, fmt.Errorf("rule %d of the team mapping has the invalid team name \"%s\"", i+1, rule.Team)
+		}
+	}
+	return mapping, nil
+}
+
+// team returns the team of the channel, or an empty string when no
+// rule matches it
+func (m TeamMapping) team(channel SlackChannel) string {
+	for _, rule := range m {
+		if rule.Prefix != "" && strings.HasPrefix(channel.Name, rule.Prefix) {
+			return rule.Team
+		}
+		if rule.SlackTeam != "" && channel.inSlackTeam(rule.SlackTeam) {
+			return rule.Team
+		}
+	}
+	return ""
+}
+
+// Teams returns the teams of the rules, sorted
+func (m TeamMapping) Teams() []string {
+	seen := map[string]bool{}
+	teams := []string{}
+	for _, rule := range m {
+		if !seen[rule.Team] {
+			seen[rule.Team] = true
+			teams = append(teams, rule.Team)
+		}
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+func (c SlackChannel) inSlackTeam(teamId string) bool {
+	if c.ContextTeamId == teamId {
+		return true
+	}
+	for _, sharedTeamId := range c.SharedTeamIds {
+		if sharedTeamId == teamId {
+			return true
+		}
+	}
+	return false
+}
+
+// teamOrDefault returns the team, or the team of the transformation
+// when it is empty
+func (t *Transformer) teamOrDefault(team string) string {
+	if team == "" {
+		return t.TeamName
+	}
+	return team
+}
+
+// channelTeam returns the team of a public or private channel by name
+func (t *Transformer) channelTeam(channelName string) string {
+	if len(t.TeamMapping) == 0 {
+		return t.TeamName
+	}
+	if t.channelTeams == nil {
+		t.channelTeams = map[string]string{}
+		for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+			for _, channel := range channels {
+				if channel.Team != "" {
+					t.channelTeams[channel.Name] = channel.Team
+				}
+			}
+		}
+	}
+	return t.teamOrDefault(t.channelTeams[channelName])
+}
+
+// splitTeamMemberships moves the channel memberships of a user line to
+// the teams of the channels, the user staying a member of the team of
+// the transformation.
+func (t *Transformer) splitTeamMemberships(line *app.LineImportData) {
+	if len(t.TeamMapping) == 0 || line.User == nil || line.User.Teams == nil || len(*line.User.Teams) == 0 {
+		return
+	}
+
+	defaultTeam := (*line.User.Teams)[0]
+	if defaultTeam.Channels == nil {
+		return
+	}
+	teams := []app.UserTeamImportData{}
+	channelsByTeam := map[string]*[]app.UserChannelImportData{}
+	addTeam := func(name string) *[]app.UserChannelImportData {
+		if channels, ok := channelsByTeam[name]; ok {
+			return channels
+		}
+		channels := &[]app.UserChannelImportData{}
+		channelsByTeam[name] = channels
+		teams = append(teams, app.UserTeamImportData{
+			Name:     model.NewString(name),
+			Roles:    defaultTeam.Roles,
+			Channels: channels,
+		})
+		return channels
+	}
+
+	addTeam(t.TeamName)
+	for _, membership := range *defaultTeam.Channels {
+		channels := addTeam(t.channelTeam(*membership.Name))
+		*channels = append(*channels, membership)
+	}
+	line.User.Teams = &teams
+}
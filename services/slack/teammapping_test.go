@@ -0,0 +1,101 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTeamMapping(t *testing.T) {
+	expected := TeamMapping{
+		{Prefix: "sec-", Team: "security"},
+		{SlackTeam: "T123", Team: "core"},
+	}
+
+	mapping, err := ParseTeamMapping(strings.NewReader(`{"teams": [{"prefix": "sec-", "team": "security"}, {"slack_team": "T123", "team": "core"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, expected, mapping)
+	assert.Equal(t, []string{"core", "security"}, mapping.Teams())
+
+	mapping, err = ParseTeamMapping(strings.NewReader(`[{"prefix": "sec-", "team": "security"}, {"slack_team": "T123", "team": "core"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, expected, mapping)
+
+	for _, invalid := range []string{
+		`[{"team": "security"}]`,
+		`[{"prefix": "sec-", "slack_team": "T123", "team": "security"}]`,
+		`[{"prefix": "sec-", "team": "Not a team"}]`,
+		`{"teams": 1}`,
+	} {
+		_, err := ParseTeamMapping(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestTeamMapping(t *testing.T) {
+	exportFS := testExportFS()
+	exportFS["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "sec-alerts", "members": ["U1"]},
+		{"id": "C3", "name": "platform", "members": ["U2"], "context_team_id": "T123"}
+	]`)}
+	exportFS["sec-alerts/2020-01-01.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "an alert", "ts": "1577836802.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), exportFS, Options{
+		TeamName:        "team",
+		CreateTeam:      true,
+		Logger:          log.New(),
+		TeamMapping:     TeamMapping{{Prefix: "sec-", Team: "security"}, {SlackTeam: "T123", Team: "core"}},
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	require.NoError(t, result.ExportTo(&buffer))
+
+	teams := []string{}
+	channelTeams := map[string]string{}
+	postTeams := map[string]string{}
+	userTeams := map[string][]app.UserTeamImportData{}
+	for _, data := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var line app.LineImportData
+		require.NoError(t, json.Unmarshal([]byte(data), &line))
+		switch line.Type {
+		case "team":
+			teams = append(teams, *line.Team.Name)
+		case "channel":
+			channelTeams[*line.Channel.Name] = *line.Channel.Team
+		case "post":
+			postTeams[*line.Post.Message] = *line.Post.Team
+		case "user":
+			userTeams[*line.User.Username] = *line.User.Teams
+		}
+	}
+
+	assert.Equal(t, []string{"team", "core", "security"}, teams)
+	assert.Equal(t, map[string]string{"general": "team", "sec-alerts": "security", "platform": "core"}, channelTeams)
+	assert.Equal(t, "team", postTeams["hello @jane"])
+	assert.Equal(t, "security", postTeams["an alert"])
+
+	john := userTeams["john"]
+	require.Len(t, john, 2)
+	assert.Equal(t, "team", *john[0].Name)
+	require.Len(t, *john[0].Channels, 1)
+	assert.Equal(t, "general", *(*john[0].Channels)[0].Name)
+	assert.Equal(t, "security", *john[1].Name)
+	assert.Equal(t, "sec-alerts", *(*john[1].Channels)[0].Name)
+
+	jane := userTeams["jane"]
+	require.Len(t, jane, 2)
+	assert.Equal(t, "core", *jane[1].Name)
+}
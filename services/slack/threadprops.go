@@ -0,0 +1,36 @@
+package slack
+
+import (
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// addThreadParticipantProps copies the reply count and the usernames of
+// the participants of a thread into the props of its root post, so the
+// engagement can be analysed without rebuilding the threads. The
+// participants are left out when they don't fit in the props.
+func (t *Transformer) addThreadParticipantProps(pc *PostContext, post SlackPost, newPost *IntermediatePost) {
+	if post.ReplyCount == 0 || (post.ThreadTS != "" && post.ThreadTS != post.TimeStamp) {
+		return
+	}
+
+	if newPost.Props == nil {
+		newPost.Props = model.StringInterface{}
+	}
+	newPost.Props["slack_reply_count"] = post.ReplyCount
+	newPost.Props["slack_reply_users_count"] = post.ReplyUsersCount
+
+	usernames := []string{}
+	for _, userID := range post.ReplyUsers {
+		if user, ok := t.Intermediate.UsersById[userID]; ok {
+			usernames = append(usernames, user.Username)
+		}
+	}
+	newPost.Props["slack_reply_users"] = usernames
+
+	if utf8.RuneCountInString(model.StringInterfaceToJSON(newPost.Props)) > model.PostPropsMaxRunes {
+		delete(newPost.Props, "slack_reply_users")
+		t.warnPostf(WarningPropsTooLarge, pc.OriginalChannelName, post, false, nil, "Unable to add the participants of the thread to the props as they exceed the maximum character count.")
+	}
+}
@@ -0,0 +1,61 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformFSThreadParticipantProps(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "root", "ts": "1577923200.000100", "thread_ts": "1577923200.000100", "reply_count": 2, "reply_users_count": 2, "reply_users": ["U2", "U1", "U404"]},
+		{"type": "message", "user": "U2", "text": "reply", "ts": "1577923201.000100", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U1", "text": "another reply", "ts": "1577923202.000100", "thread_ts": "1577923200.000100"}
+	]`)}
+
+	transform := func(threadProps bool) *IntermediatePost {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, ThreadParticipantProps: threadProps},
+		})
+		require.NoError(t, err)
+
+		for _, post := range result.Intermediate.Posts {
+			if post.Message == "root" {
+				return post
+			}
+		}
+		require.FailNow(t, "the root post is missing")
+		return nil
+	}
+
+	root := transform(true)
+	assert.Equal(t, 2, root.Props["slack_reply_count"])
+	assert.Equal(t, 2, root.Props["slack_reply_users_count"])
+	assert.Equal(t, []string{"jane", "john"}, root.Props["slack_reply_users"])
+	require.Len(t, root.Replies, 2)
+	assert.Nil(t, root.Replies[0].Props)
+
+	assert.Nil(t, transform(false).Props)
+}
+
+func TestThreadParticipantPropsTooLarge(t *testing.T) {
+	transformer := NewTransformer("team", log.New())
+	post := SlackPost{TimeStamp: "1577923200.000100", ReplyCount: 1, ReplyUsersCount: 1, ReplyUsers: []string{"U1"}}
+	transformer.Intermediate.UsersById = map[string]*IntermediateUser{}
+	transformer.Intermediate.UsersById["U1"] = &IntermediateUser{Username: strings.Repeat("a", model.PostPropsMaxRunes)}
+
+	newPost := &IntermediatePost{}
+	transformer.addThreadParticipantProps(&PostContext{OriginalChannelName: "general"}, post, newPost)
+	assert.Equal(t, 1, newPost.Props["slack_reply_count"])
+	assert.NotContains(t, newPost.Props, "slack_reply_users")
+	assert.Equal(t, 1, transformer.result.Count(WarningPropsTooLarge))
+}
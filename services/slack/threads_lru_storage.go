@@ -0,0 +1,232 @@
+package slack
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// threadsSpill keeps the threads evicted from an lruStorage, with their
+// replies, until they are needed again
+type threadsSpill interface {
+	// save returns the number of bytes stored
+	save(threadTS string, rootPost *IntermediatePost) (int, error)
+	load(threadTS string) (*IntermediatePost, error)
+	io.Closer
+}
+
+type lruEntry struct {
+	threadTS string
+	rootPost *IntermediatePost
+}
+
+// lruStorage holds at most capacity threads in memory, evicting the
+// least recently used ones to the spill. An evicted thread is loaded
+// back when a late reply looks it up.
+type lruStorage struct {
+	capacity int
+	order    *list.List
+	threads  map[string]*list.Element
+	spill    threadsSpill
+	spilled  map[string]bool
+	stats    *ThreadsStats
+}
+
+func newLRUStorage(capacity int, spill threadsSpill, stats *ThreadsStats) *lruStorage {
+	return &lruStorage{
+		capacity: capacity,
+		order:    list.New(),
+		threads:  make(map[string]*list.Element),
+		spill:    spill,
+		spilled:  make(map[string]bool),
+		stats:    stats,
+	}
+}
+
+func (s *lruStorage) LookupThread(threadTS string) *IntermediatePost {
+	if element, ok := s.threads[threadTS]; ok {
+		s.order.MoveToFront(element)
+		return element.Value.(*lruEntry).rootPost
+	}
+	if !s.spilled[threadTS] {
+		return nil
+	}
+
+	rootPost, err := s.spill.load(threadTS)
+	if err != nil {
+		log.Errorf("could not load evicted thread %s: %v", threadTS, err)
+		return nil
+	}
+	s.stats.Reloads++
+	s.StoreThread(threadTS, rootPost)
+	return rootPost
+}
+
+func (s *lruStorage) HasThread(threadTS string) bool {
+	_, ok := s.threads[threadTS]
+	return ok || s.spilled[threadTS]
+}
+
+func (s *lruStorage) StoreThread(threadTS string, rootPost *IntermediatePost) {
+	if element, ok := s.threads[threadTS]; ok {
+		element.Value.(*lruEntry).rootPost = rootPost
+		s.order.MoveToFront(element)
+		return
+	}
+	// the spilled version is outdated once the thread is stored again
+	delete(s.spilled, threadTS)
+	s.threads[threadTS] = s.order.PushFront(&lruEntry{threadTS: threadTS, rootPost: rootPost})
+
+	for s.order.Len() > s.capacity {
+		if !s.evict() {
+			break
+		}
+	}
+}
+
+// evict spills the least recently used thread, returning false when
+// it could not be spilled and is kept in memory
+func (s *lruStorage) evict() bool {
+	element := s.order.Back()
+	entry := element.Value.(*lruEntry)
+	size, err := s.spill.save(entry.threadTS, entry.rootPost)
+	if err != nil {
+		log.Errorf("could not evict thread %s, keeping it in memory: %v", entry.threadTS, err)
+		return false
+	}
+	s.stats.Evictions++
+	s.stats.StoredBytes += int64(size)
+	s.order.Remove(element)
+	delete(s.threads, entry.threadTS)
+	s.spilled[entry.threadTS] = true
+	return true
+}
+
+// GetChangedThreads returns every thread, loading the evicted ones
+// back, so it is meant to be called once the channel is complete.
+func (s *lruStorage) GetChangedThreads() []*IntermediatePost {
+	result := make([]*IntermediatePost, 0, len(s.threads)+len(s.spilled))
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		result = append(result, element.Value.(*lruEntry).rootPost)
+	}
+	for threadTS := range s.spilled {
+		rootPost, err := s.spill.load(threadTS)
+		if err != nil {
+			log.Errorf("could not load evicted thread %s: %v", threadTS, err)
+			continue
+		}
+		result = append(result, rootPost)
+	}
+	return result
+}
+
+func (s *lruStorage) Close() error {
+	return s.spill.Close()
+}
+
+// fileSpill appends the evicted threads to a temporary file, indexed
+// by their offset
+type fileSpill struct {
+	file  *os.File
+	size  int64
+	index map[string][2]int64
+}
+
+func newFileSpill() (*fileSpill, error) {
+	file, err := os.CreateTemp("", "mmetl-threads-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the file of the evicted threads")
+	}
+	return &fileSpill{file: file, index: make(map[string][2]int64)}, nil
+}
+
+func (s *fileSpill) save(threadTS string, rootPost *IntermediatePost) (int, error) {
+	data, err := json.Marshal(rootPost)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.file.WriteAt(data, s.size); err != nil {
+		return 0, err
+	}
+	s.index[threadTS] = [2]int64{s.size, int64(len(data))}
+	s.size += int64(len(data))
+	return len(data), nil
+}
+
+func (s *fileSpill) load(threadTS string) (*IntermediatePost, error) {
+	location, ok := s.index[threadTS]
+	if !ok {
+		return nil, errors.Errorf("thread %s was not evicted", threadTS)
+	}
+	data := make([]byte, location[1])
+	if _, err := s.file.ReadAt(data, location[0]); err != nil {
+		return nil, err
+	}
+	var rootPost IntermediatePost
+	if err := json.Unmarshal(data, &rootPost); err != nil {
+		return nil, err
+	}
+	return &rootPost, nil
+}
+
+func (s *fileSpill) Close() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
+
+// redisSpill stores the evicted threads in redis, apart from the root
+// posts kept by the redisStorage
+type redisSpill struct {
+	client  *redis.Client
+	channel string
+	keys    map[string]bool
+	stats   *ThreadsStats
+}
+
+func (s *redisSpill) key(threadTS string) string {
+	return s.channel + ":" + threadTS + ":evicted"
+}
+
+func (s *redisSpill) save(threadTS string, rootPost *IntermediatePost) (int, error) {
+	data, err := json.Marshal(rootPost)
+	if err != nil {
+		return 0, err
+	}
+	s.stats.RedisRoundTrips++
+	if err := s.client.Set(context.TODO(), s.key(threadTS), data, 0).Err(); err != nil {
+		return 0, err
+	}
+	s.keys[s.key(threadTS)] = true
+	return len(data), nil
+}
+
+func (s *redisSpill) load(threadTS string) (*IntermediatePost, error) {
+	s.stats.RedisRoundTrips++
+	data, err := s.client.Get(context.TODO(), s.key(threadTS)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var rootPost IntermediatePost
+	if err := json.Unmarshal(data, &rootPost); err != nil {
+		return nil, err
+	}
+	return &rootPost, nil
+}
+
+func (s *redisSpill) Close() error {
+	if len(s.keys) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	s.stats.RedisRoundTrips++
+	return s.client.Del(context.TODO(), keys...).Err()
+}
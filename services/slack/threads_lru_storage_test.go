@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLRUStorage(t *testing.T, storage *lruStorage) {
+	storage.StoreThread("1", &IntermediatePost{Message: "first"})
+	storage.StoreThread("2", &IntermediatePost{Message: "second"})
+	storage.StoreThread("3", &IntermediatePost{Message: "third"})
+
+	// the first thread was evicted
+	assert.Equal(t, 2, storage.order.Len())
+	assert.True(t, storage.spilled["1"])
+	assert.True(t, storage.HasThread("1"))
+	assert.False(t, storage.HasThread("4"))
+	assert.Nil(t, storage.LookupThread("4"))
+
+	// a late reply loads it back with its replies, evicting the second
+	root := storage.LookupThread("1")
+	require.NotNil(t, root)
+	assert.Equal(t, "first", root.Message)
+	root.Replies = append(root.Replies, &IntermediatePost{Message: "late reply"})
+	assert.True(t, storage.spilled["2"])
+
+	storage.StoreThread("4", &IntermediatePost{Message: "fourth"})
+	root = storage.LookupThread("1")
+	require.NotNil(t, root)
+	require.Len(t, root.Replies, 1)
+	assert.Equal(t, "late reply", root.Replies[0].Message)
+
+	messages := []string{}
+	for _, post := range storage.GetChangedThreads() {
+		messages = append(messages, post.Message)
+	}
+	sort.Strings(messages)
+	assert.Equal(t, []string{"first", "fourth", "second", "third"}, messages)
+
+	require.NoError(t, storage.Close())
+}
+
+func TestLRUStorageFileSpill(t *testing.T) {
+	spill, err := newFileSpill()
+	require.NoError(t, err)
+
+	testLRUStorage(t, newLRUStorage(2, spill, &ThreadsStats{}))
+
+	_, err = os.Stat(spill.file.Name())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLRUStorageRedisSpill(t *testing.T) {
+	redis, err := miniredis.Run()
+	require.NoError(t, err)
+	defer redis.Close()
+
+	factory, err := newRedisFactory(&RedisConfig{Addr: redis.Addr()}, &ThreadsStats{})
+	require.NoError(t, err)
+
+	storage := factory.newRedisStorage("channel", "", 2).(*redisStorage)
+	testLRUStorage(t, storage.memory.(*lruStorage))
+
+	// the evicted threads are removed once the storage is closed, the
+	// root posts kept for the later runs are not
+	assert.False(t, redis.Exists("channel:1:evicted"))
+}
+
+func TestTransformFSThreadsCacheSize(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "root 1", "ts": "1577923200.000100", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U1", "text": "root 2", "ts": "1577923201.000100", "thread_ts": "1577923201.000100"},
+		{"type": "message", "user": "U1", "text": "root 3", "ts": "1577923202.000100", "thread_ts": "1577923202.000100"},
+		{"type": "message", "user": "U2", "text": "late reply", "ts": "1577923203.000100", "thread_ts": "1577923200.000100"}
+	]`)}
+
+	transform := func(cacheSize int) map[string][]string {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, ThreadsCacheSize: cacheSize},
+		})
+		require.NoError(t, err)
+
+		threads := map[string][]string{}
+		for _, post := range result.Intermediate.Posts {
+			replies := []string{}
+			for _, reply := range post.Replies {
+				replies = append(replies, reply.Message)
+			}
+			threads[post.Message] = replies
+		}
+		return threads
+	}
+
+	threads := transform(1)
+	assert.Equal(t, []string{"late reply"}, threads["root 1"])
+	assert.Equal(t, transform(0), threads)
+}
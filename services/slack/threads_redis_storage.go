@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/go-redis/redis/v8"
@@ -21,6 +22,7 @@ type redisStorage struct {
 	client         *redis.Client
 	attachmentsDir string
 	channel        string
+	stats          *ThreadsStats
 }
 
 func (s *redisStorage) threadKey(threadTS string) string {
@@ -32,6 +34,7 @@ func (s *redisStorage) LookupThread(threadTS string) *IntermediatePost {
 	if rootPost != nil {
 		return rootPost
 	}
+	s.stats.RedisRoundTrips++
 	data, err := s.client.Get(context.TODO(), s.threadKey(threadTS)).Result()
 	if err != nil || len(data) == 0 {
 		return nil
@@ -43,6 +46,7 @@ func (s *redisStorage) LookupThread(threadTS string) *IntermediatePost {
 	}
 	log.Printf("Found thread root post for thread %s in redis for channel %s", threadTS, s.channel)
 	result.Sanitise()
+	s.stats.Reloads++
 	s.memory.StoreThread(threadTS, &result)
 	return &result
 }
@@ -72,6 +76,8 @@ func (s *redisStorage) StoreThread(threadTS string, rootPost *IntermediatePost)
 		return
 	}
 
+	s.stats.RedisRoundTrips++
+	s.stats.StoredBytes += int64(len(postJson))
 	if err := s.client.Set(context.TODO(), s.threadKey(threadTS), postJson, 0).Err(); err != nil {
 		log.Errorf("could not store stripped post %s: %v", threadTS, err)
 	}
@@ -81,11 +87,21 @@ func (s *redisStorage) GetChangedThreads() []*IntermediatePost {
 	return s.memory.GetChangedThreads()
 }
 
+func (s *redisStorage) Close() error {
+	if closer, ok := s.memory.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 type redisFactory struct {
 	client *redis.Client
+	stats  *ThreadsStats
 }
 
-func newRedisFactory(cfg *RedisConfig) (*redisFactory, error) {
+// newRedisFactory connects to redis, the storages it creates counting
+// their work in stats
+func newRedisFactory(cfg *RedisConfig, stats *ThreadsStats) (*redisFactory, error) {
 	opts := &redis.Options{Addr: cfg.Addr, Username: cfg.User, Password: cfg.Password}
 	client := redis.NewClient(opts)
 	if err := client.Ping(context.Background()).Err(); err != nil {
@@ -93,14 +109,22 @@ func newRedisFactory(cfg *RedisConfig) (*redisFactory, error) {
 	}
 	return &redisFactory{
 		client: client,
+		stats:  stats,
 	}, nil
 }
 
-func (s *redisFactory) newRedisStorage(channel, attachmentsdir string) ThreadsStorage {
+// newRedisStorage creates the storage of a channel, evicting the
+// threads to redis when more than cacheSize are in memory
+func (s *redisFactory) newRedisStorage(channel, attachmentsdir string, cacheSize int) ThreadsStorage {
+	memory := newMemoryStorage()
+	if cacheSize > 0 {
+		memory = newLRUStorage(cacheSize, &redisSpill{client: s.client, channel: channel, keys: map[string]bool{}, stats: s.stats}, s.stats)
+	}
 	return &redisStorage{
-		memory:         newMemoryStorage(),
+		memory:         memory,
 		client:         s.client,
 		channel:        channel,
 		attachmentsDir: attachmentsdir,
+		stats:          s.stats,
 	}
 }
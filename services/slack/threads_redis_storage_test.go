@@ -14,11 +14,11 @@ func TestRedisStorage(t *testing.T) {
 	redisCfg := &RedisConfig{
 		Addr: redis.Addr(),
 	}
-	factory, err := newRedisFactory(redisCfg)
+	factory, err := newRedisFactory(redisCfg, &ThreadsStats{})
 	assert.NoError(t, err)
 
 	t.Run("store, lookup post", func(t *testing.T) {
-		storage := factory.newRedisStorage("channel", "")
+		storage := factory.newRedisStorage("channel", "", 0)
 
 		threadTS := "11"
 		post := &IntermediatePost{
@@ -35,7 +35,7 @@ func TestRedisStorage(t *testing.T) {
 	})
 
 	t.Run("lookup post from another storage", func(t *testing.T) {
-		storage := factory.newRedisStorage("channel", "")
+		storage := factory.newRedisStorage("channel", "", 0)
 
 		threadTS := "21"
 		post := &IntermediatePost{
@@ -45,14 +45,14 @@ func TestRedisStorage(t *testing.T) {
 		storage.StoreThread("22", post)
 		assert.Equal(t, 2, len(storage.GetChangedThreads()))
 
-		anotherStorage := factory.newRedisStorage("channel", "")
+		anotherStorage := factory.newRedisStorage("channel", "", 0)
 		assert.NotNil(t, anotherStorage.LookupThread(threadTS))
 		assert.Equal(t, "msg", anotherStorage.LookupThread(threadTS).Message)
 		assert.Equal(t, 1, len(anotherStorage.GetChangedThreads())) // only the post that was looked up should be marked as changed
 	})
 
 	t.Run("post should retain replies", func(t *testing.T) {
-		storage := factory.newRedisStorage("channel", "")
+		storage := factory.newRedisStorage("channel", "", 0)
 
 		threadTS := "31"
 		post := &IntermediatePost{
@@ -67,7 +67,7 @@ func TestRedisStorage(t *testing.T) {
 	})
 
 	t.Run("should strip attachments from threads", func(t *testing.T) {
-		storage := factory.newRedisStorage("channel", "my_dir/")
+		storage := factory.newRedisStorage("channel", "my_dir/", 0)
 
 		threadTS := "41"
 		post := &IntermediatePost{
@@ -80,7 +80,7 @@ func TestRedisStorage(t *testing.T) {
 		}
 		storage.StoreThread(threadTS, post)
 
-		storage = factory.newRedisStorage("channel", "my_dir/")
+		storage = factory.newRedisStorage("channel", "my_dir/", 0)
 		thread := storage.LookupThread(threadTS)
 		assert.Equal(t, []string{"a", "b"}, thread.Attachments)
 	})
@@ -0,0 +1,58 @@
+package slack
+
+import "io"
+
+// ThreadsStats counts the work of the threads storages of the channels,
+// to tell whether caching the threads and spilling them to redis or to
+// disk pays off for an export.
+type ThreadsStats struct {
+	// Lookups counts the lookups of the thread of a reply, Misses the
+	// ones that found no thread
+	Lookups int
+	Misses  int
+	// Evictions counts the threads spilled out of the cache, Reloads
+	// the ones loaded back from the spill
+	Evictions int
+	Reloads   int
+	// RedisRoundTrips counts the calls made to redis
+	RedisRoundTrips int
+	// StoredBytes is the size of the threads written to redis or to
+	// the spill file
+	StoredBytes int64
+}
+
+// HitRate returns the share of the lookups that found their thread,
+// zero when there was no lookup.
+func (s ThreadsStats) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Lookups-s.Misses) / float64(s.Lookups)
+}
+
+// countingStorage counts the lookups of the storage it wraps
+type countingStorage struct {
+	ThreadsStorage
+	stats *ThreadsStats
+}
+
+func (s *countingStorage) LookupThread(threadTS string) *IntermediatePost {
+	rootPost := s.ThreadsStorage.LookupThread(threadTS)
+	s.stats.Lookups++
+	if rootPost == nil {
+		s.stats.Misses++
+	}
+	return rootPost
+}
+
+func (s *countingStorage) Close() error {
+	if closer, ok := s.ThreadsStorage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ThreadsStats returns the work of the threads storages so far.
+func (t *Transformer) ThreadsStats() ThreadsStats {
+	return t.threadsStats
+}
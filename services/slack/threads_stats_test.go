@@ -0,0 +1,67 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alicebob/miniredis/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func threadsStatsExportFS() fstest.MapFS {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "root 1", "ts": "1577923200.000100", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U1", "text": "root 2", "ts": "1577923201.000100", "thread_ts": "1577923201.000100"},
+		{"type": "message", "user": "U2", "text": "late reply", "ts": "1577923203.000100", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "lost reply", "ts": "1577923204.000100", "thread_ts": "1577920000.000100"}
+	]`)}
+	return fsys
+}
+
+func TestThreadsStatsHitRate(t *testing.T) {
+	assert.Equal(t, 0.0, ThreadsStats{}.HitRate())
+	assert.Equal(t, 0.75, ThreadsStats{Lookups: 4, Misses: 1}.HitRate())
+}
+
+func TestTransformFSThreadsStats(t *testing.T) {
+	result, err := TransformFS(context.Background(), threadsStatsExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true, ThreadsCacheSize: 1},
+	})
+	require.NoError(t, err)
+
+	stats := result.ThreadsStats()
+	assert.Equal(t, 2, stats.Lookups)
+	assert.Equal(t, 1, stats.Misses)
+	assert.Equal(t, 4, stats.Evictions)
+	assert.Equal(t, 1, stats.Reloads)
+	assert.Zero(t, stats.RedisRoundTrips)
+	assert.Positive(t, stats.StoredBytes)
+}
+
+func TestTransformFSThreadsStatsRedis(t *testing.T) {
+	redis, err := miniredis.Run()
+	require.NoError(t, err)
+	defer redis.Close()
+
+	result, err := TransformFS(context.Background(), threadsStatsExportFS(), Options{
+		TeamName: "team",
+		Logger:   log.New(),
+		TransformConfig: TransformConfig{
+			SkipAttachments: true,
+			RedisConfig:     &RedisConfig{Addr: redis.Addr()},
+		},
+	})
+	require.NoError(t, err)
+
+	stats := result.ThreadsStats()
+	assert.Equal(t, 2, stats.Lookups)
+	assert.Equal(t, 1, stats.Misses)
+	assert.Positive(t, stats.RedisRoundTrips)
+	assert.Positive(t, stats.StoredBytes)
+}
@@ -0,0 +1,40 @@
+package slack
+
+import (
+	"path"
+	"regexp"
+)
+
+// thumbnailNameRegex matches the names of the thumbnails Slack exports
+// alongside some files, e.g. notes_thumb_360.png or thumb_pdf.png
+var thumbnailNameRegex = regexp.MustCompile(`(?i)(^|[_.-])thumb(nail)?(_(\d+|pdf|video|tiny|gif))*\.[a-z0-9]+$`)
+
+func isThumbnailName(name string) bool {
+	return thumbnailNameRegex.MatchString(name)
+}
+
+// addUpload records the path of a file of the __uploads directory of
+// the export, keeping the original over its thumbnails
+func (slackExport *SlackExport) addUpload(fileId, filePath string) {
+	if existing, ok := slackExport.Uploads[fileId]; ok && !isThumbnailName(path.Base(existing)) {
+		return
+	}
+	slackExport.Uploads[fileId] = filePath
+}
+
+// isThumbnail tells whether the export only has a thumbnail of the
+// file. A file whose own name looks like a thumbnail is not one.
+func (slackExport *SlackExport) isThumbnail(file *SlackFile) bool {
+	uploadPath, ok := slackExport.Uploads[file.Id]
+	if !ok {
+		return false
+	}
+	name := path.Base(uploadPath)
+	return name != file.Name && isThumbnailName(name)
+}
+
+// SkippedThumbnails returns the number of files left out by
+// SkipThumbnails as the export only has their thumbnail
+func (t *Transformer) SkippedThumbnails() int {
+	return t.skippedThumbnails
+}
@@ -0,0 +1,64 @@
+package slack
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsThumbnailName(t *testing.T) {
+	for _, name := range []string{"notes_thumb_360.png", "thumb_pdf.png", "image_thumb_video.jpg", "photo-thumbnail.jpg", "THUMB_64.PNG", "report_thumb_720_gif.gif"} {
+		assert.True(t, isThumbnailName(name), name)
+	}
+	for _, name := range []string{"notes.txt", "thumbs-up.png", "thumbnails.zip", "my_thumb_drive.txt"} {
+		assert.False(t, isThumbnailName(name), name)
+	}
+}
+
+func TestSkipThumbnails(t *testing.T) {
+	exportFS := testExportFS()
+	exportFS["__uploads/F1/notes_thumb_360.png"] = &fstest.MapFile{Data: []byte("a thumbnail")}
+	exportFS["__uploads/F2/image_thumb_360.png"] = &fstest.MapFile{Data: []byte("another thumbnail")}
+	exportFS["__uploads/F3/thumb_64.png"] = &fstest.MapFile{Data: []byte("an image named like a thumbnail")}
+	exportFS["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "file_share", "user": "U1", "text": "images", "ts": "1577923200.000100", "files": [
+			{"id": "F2", "name": "image.png"},
+			{"id": "F3", "name": "thumb_64.png"}
+		]}
+	]`)}
+
+	transform := func(t *testing.T, skipThumbnails bool) (map[string][]string, *Result, string) {
+		attachmentsDir := t.TempDir()
+		result, err := TransformFS(context.Background(), exportFS, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{AttachmentsDir: attachmentsDir, SkipThumbnails: skipThumbnails},
+		})
+		require.NoError(t, err)
+
+		attachments := map[string][]string{}
+		for _, post := range result.Intermediate.Posts {
+			attachments[post.Message] = post.Attachments
+		}
+		return attachments, result, attachmentsDir
+	}
+
+	t.Run("originals over thumbnails", func(t *testing.T) {
+		attachments, result, attachmentsDir := transform(t, false)
+		assert.Equal(t, []string{filepath.Join(attachmentsDir, "F1_notes.txt")}, attachments["a file"])
+		assert.Len(t, attachments["images"], 2)
+		assert.Equal(t, 0, result.SkippedThumbnails())
+	})
+
+	t.Run("thumbnails skipped", func(t *testing.T) {
+		attachments, result, attachmentsDir := transform(t, true)
+		assert.Equal(t, []string{filepath.Join(attachmentsDir, "F1_notes.txt")}, attachments["a file"])
+		assert.Equal(t, []string{filepath.Join(attachmentsDir, "F3_thumb_64.png")}, attachments["images"])
+		assert.Equal(t, 1, result.SkippedThumbnails())
+	})
+}
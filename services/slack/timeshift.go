@@ -0,0 +1,81 @@
+package slack
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimestampShift reports the posts moved by the TimestampOffset of
+// the transformer
+type TimestampShift struct {
+	Offset time.Duration
+	// Posts is the number of shifted posts and replies
+	Posts int
+	// Skipped is the number of posts left out as their shifted
+	// timestamp is before the Unix epoch or in the future
+	Skipped int
+	// Earliest and Latest are the shifted creation times of the first
+	// and last shifted posts
+	Earliest time.Time
+	Latest   time.Time
+}
+
+func millisToTime(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond)).UTC()
+}
+
+// shiftTimestamp applies the TimestampOffset to a timestamp in
+// milliseconds
+func (t *Transformer) shiftTimestamp(millis int64) int64 {
+	if millis == 0 {
+		return 0
+	}
+	return millis + t.TimestampOffset.Milliseconds()
+}
+
+// shiftPost applies the TimestampOffset to the creation time of a
+// post, returning false when the shifted time is out of range and the
+// post is skipped
+func (t *Transformer) shiftPost(originalChannelName string, original SlackPost, post *IntermediatePost) bool {
+	if t.TimestampOffset == 0 {
+		return true
+	}
+
+	shift := &t.timestampShift
+	shift.Offset = t.TimestampOffset
+	createAt := t.shiftTimestamp(post.CreateAt)
+	if createAt <= 0 || millisToTime(createAt).After(time.Now()) {
+		shift.Skipped++
+		t.warnPostf(WarningTimestampOutOfRange, originalChannelName, original, true, nil, "Unable to import the message as its timestamp shifted by %s is out of range. create_at=%s", t.TimestampOffset, millisToTime(createAt).Format(time.RFC3339))
+		return false
+	}
+
+	post.CreateAt = createAt
+	shifted := millisToTime(createAt)
+	if shift.Posts == 0 || shifted.Before(shift.Earliest) {
+		shift.Earliest = shifted
+	}
+	if shift.Posts == 0 || shifted.After(shift.Latest) {
+		shift.Latest = shifted
+	}
+	shift.Posts++
+	return true
+}
+
+// TimestampShift returns the report of the TimestampOffset, nil when
+// no offset is set
+func (t *Transformer) TimestampShift() *TimestampShift {
+	if t.TimestampOffset == 0 {
+		return nil
+	}
+	shift := t.timestampShift
+	shift.Offset = t.TimestampOffset
+	return &shift
+}
+
+func (s *TimestampShift) String() string {
+	if s.Posts == 0 {
+		return fmt.Sprintf("no post shifted by %s, %d skipped", s.Offset, s.Skipped)
+	}
+	return fmt.Sprintf("%d posts shifted by %s to %s - %s, %d skipped", s.Posts, s.Offset, s.Earliest.Format(time.RFC3339), s.Latest.Format(time.RFC3339), s.Skipped)
+}
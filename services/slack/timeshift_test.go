@@ -0,0 +1,62 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampOffset(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "root", "ts": "1577923200.000100", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "reply", "ts": "1577923260.000100", "thread_ts": "1577923200.000100"}
+	]`)}
+	opts := Options{TeamName: "team", Logger: log.New(), TransformConfig: TransformConfig{SkipAttachments: true}}
+
+	t.Run("shifted", func(t *testing.T) {
+		opts := opts
+		opts.TimestampOffset = -3 * time.Hour
+		result, err := TransformFS(context.Background(), fsys, opts)
+		require.NoError(t, err)
+
+		createAts := map[string]int64{}
+		for _, post := range result.Intermediate.Posts {
+			createAts[post.Message] = post.CreateAt
+			for _, reply := range post.Replies {
+				createAts[reply.Message] = reply.CreateAt
+			}
+		}
+		assert.Equal(t, int64(1577923200000-3*3600*1000), createAts["root"])
+		assert.Equal(t, int64(1577923260000-3*3600*1000), createAts["reply"])
+
+		shift := result.TimestampShift()
+		require.NotNil(t, shift)
+		assert.Equal(t, 4, shift.Posts)
+		assert.Equal(t, 0, shift.Skipped)
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(-3*time.Hour), shift.Earliest.Truncate(time.Second))
+		assert.Equal(t, time.Date(2020, 1, 1, 21, 1, 0, 0, time.UTC), shift.Latest.Truncate(time.Second))
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		opts := opts
+		opts.TimestampOffset = -51 * 365 * 24 * time.Hour
+		result, err := TransformFS(context.Background(), fsys, opts)
+		require.NoError(t, err)
+
+		assert.Empty(t, result.Intermediate.Posts)
+		assert.Equal(t, 4, result.TransformResult.Count(WarningTimestampOutOfRange))
+		assert.Equal(t, 4, result.TimestampShift().Skipped)
+	})
+
+	t.Run("no offset", func(t *testing.T) {
+		result, err := TransformFS(context.Background(), fsys, opts)
+		require.NoError(t, err)
+		assert.Nil(t, result.TimestampShift())
+	})
+}
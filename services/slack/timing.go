@@ -0,0 +1,95 @@
+package slack
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+)
+
+// StageTiming is the time spent in a stage of the transformation.
+type StageTiming struct {
+	Stage   Stage
+	Elapsed time.Duration
+}
+
+// Timings is an Observer collecting the time spent in each stage and
+// in transforming the posts of each channel, to find out why a
+// transformation is slow.
+type Timings struct {
+	NopObserver
+
+	mu       sync.Mutex
+	stages   []StageTiming
+	channels []ChannelProgress
+}
+
+func (t *Timings) StageFinished(stage Stage, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stages = append(t.stages, StageTiming{Stage: stage, Elapsed: elapsed})
+}
+
+func (t *Timings) ChannelDone(progress ChannelProgress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channels = append(t.channels, progress)
+}
+
+// Stages returns the finished stages in the order they finished.
+func (t *Timings) Stages() []StageTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]StageTiming{}, t.stages...)
+}
+
+// SlowestChannels returns at most n channels, by decreasing time spent
+// transforming their posts.
+func (t *Timings) SlowestChannels(n int) []ChannelProgress {
+	t.mu.Lock()
+	channels := append([]ChannelProgress{}, t.channels...)
+	t.mu.Unlock()
+
+	sort.SliceStable(channels, func(i, j int) bool { return channels[i].Elapsed > channels[j].Elapsed })
+	if len(channels) > n {
+		channels = channels[:n]
+	}
+	return channels
+}
+
+// TimingExporter measures the time spent writing the lines to the
+// exporter it wraps, closing it included.
+type TimingExporter struct {
+	exporter Exporter
+	elapsed  time.Duration
+}
+
+func NewTimingExporter(exporter Exporter) *TimingExporter {
+	return &TimingExporter{exporter: exporter}
+}
+
+func (e *TimingExporter) WriteLine(line *app.LineImportData) error {
+	start := time.Now()
+	err := e.exporter.WriteLine(line)
+	e.elapsed += time.Since(start)
+	return err
+}
+
+func (e *TimingExporter) Close() error {
+	start := time.Now()
+	err := e.exporter.Close()
+	e.elapsed += time.Since(start)
+	return err
+}
+
+// Elapsed returns the time spent in the wrapped exporter.
+func (e *TimingExporter) Elapsed() time.Duration {
+	return e.elapsed
+}
+
+// AttachmentCopies returns the number of attachments copied and the
+// time spent copying them, scanning included.
+func (t *Transformer) AttachmentCopies() (int, time.Duration) {
+	return t.attachmentCopies, t.attachmentsElapsed
+}
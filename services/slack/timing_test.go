@@ -0,0 +1,53 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimings(t *testing.T) {
+	timings := &Timings{}
+	timings.StageFinished(StageParse, time.Second)
+	timings.ChannelDone(ChannelProgress{Channel: "fast", Elapsed: time.Millisecond})
+	timings.ChannelDone(ChannelProgress{Channel: "slow", Elapsed: time.Second})
+	timings.ChannelDone(ChannelProgress{Channel: "medium", Elapsed: 10 * time.Millisecond})
+
+	assert.Equal(t, []StageTiming{{Stage: StageParse, Elapsed: time.Second}}, timings.Stages())
+
+	slowest := timings.SlowestChannels(2)
+	require.Len(t, slowest, 2)
+	assert.Equal(t, "slow", slowest[0].Channel)
+	assert.Equal(t, "medium", slowest[1].Channel)
+	assert.Len(t, timings.SlowestChannels(5), 3)
+}
+
+func TestStreamFSTimings(t *testing.T) {
+	timings := &Timings{}
+	exporter := NewTimingExporter(&recordingExporter{})
+
+	result, err := StreamFS(context.Background(), testExportFS(), Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{AttachmentsDir: t.TempDir()},
+		Observer:        timings,
+	}, exporter)
+	require.NoError(t, err)
+	require.NoError(t, exporter.Close())
+
+	stages := []Stage{}
+	for _, stage := range timings.Stages() {
+		stages = append(stages, stage.Stage)
+	}
+	assert.Equal(t, []Stage{StageParse, StageUsersAndChannels, StageExport, StagePosts}, stages)
+	assert.Len(t, timings.SlowestChannels(5), 1)
+	assert.Greater(t, int64(exporter.Elapsed()), int64(0))
+
+	copies, elapsed := result.AttachmentCopies()
+	assert.Equal(t, 1, copies)
+	assert.Greater(t, int64(elapsed), int64(0))
+}
@@ -0,0 +1,184 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// isCorruptJSON returns whether a decoding error comes from a
+// malformed or truncated file. Type mismatches are not considered, as
+// the decoder still fills the rest of the value and Slack exports
+// contain a few of them.
+func isCorruptJSON(err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// splitJSONArray returns the complete objects at the top level of a
+// possibly malformed JSON array, and the number of the objects that
+// are invalid or cut by the end of the data.
+func splitJSONArray(data []byte) ([][]byte, int) {
+	elements := [][]byte{}
+	lost := 0
+	depth := 0
+	start := -1
+	inString := false
+	escaped := false
+	for i, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				if element := data[start : i+1]; json.Valid(element) {
+					elements = append(elements, element)
+				} else {
+					lost++
+				}
+			}
+		}
+	}
+	if depth > 0 {
+		lost++
+	}
+	return elements, lost
+}
+
+// decodeExportFile decodes an export file into v. When the file is
+// malformed, it fails in strict mode and otherwise returns the objects
+// of the top level array that can be recovered, to be decoded one by
+// one by the caller, raising a warning.
+func (t *Transformer) decodeExportFile(filePath, channel string, reader io.Reader, v interface{}) ([][]byte, error) {
+	data, readErr := io.ReadAll(reader)
+	err := readErr
+	if err == nil {
+		err = json.Unmarshal(data, v)
+		if len(data) == 0 {
+			err = io.EOF
+		}
+		if err == nil || !isCorruptJSON(err) {
+			return nil, nil
+		}
+	}
+
+	if t.Strict {
+		return nil, errors.Wrapf(err, "failed to parse %s", filePath)
+	}
+
+	elements, lost := splitJSONArray(data)
+	t.warnMu.Lock()
+	warned := t.corruptFiles[filePath]
+	if t.corruptFiles == nil {
+		t.corruptFiles = map[string]bool{}
+	}
+	t.corruptFiles[filePath] = true
+	t.warnMu.Unlock()
+	if warned {
+		return elements, nil
+	}
+
+	t.warn(&Warning{
+		Kind:    WarningCorruptFile,
+		Skipped: lost > 0,
+		Channel: channel,
+		Message: fmt.Sprintf("--- File %s is corrupt, recovered %d entries and lost %d", filePath, len(elements), lost),
+		Err:     err,
+	})
+	return elements, nil
+}
+
+// parsePostsFile parses a day file of a channel
+func (t *Transformer) parsePostsFile(filePath, channel string, reader io.Reader) ([]SlackPost, error) {
+	posts := []SlackPost{}
+	elements, err := t.decodeExportFile(filePath, channel, reader, &posts)
+	if err != nil || elements == nil {
+		return posts, err
+	}
+
+	posts = []SlackPost{}
+	for _, element := range elements {
+		var post SlackPost
+		if err := json.Unmarshal(element, &post); !isCorruptJSON(err) {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func (t *Transformer) parseUsersFile(filePath string, reader io.Reader) ([]SlackUser, error) {
+	users := []SlackUser{}
+	elements, err := t.decodeExportFile(filePath, "", reader, &users)
+	if err != nil || elements == nil {
+		return users, err
+	}
+
+	users = []SlackUser{}
+	for _, element := range elements {
+		var user SlackUser
+		if err := json.Unmarshal(element, &user); !isCorruptJSON(err) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (t *Transformer) parseChannelsFile(filePath string, reader io.Reader, channelType model.ChannelType) ([]SlackChannel, error) {
+	channels := []SlackChannel{}
+	elements, err := t.decodeExportFile(filePath, "", reader, &channels)
+	if err != nil {
+		return nil, err
+	}
+
+	if elements != nil {
+		channels = []SlackChannel{}
+		for _, element := range elements {
+			var channel SlackChannel
+			if err := json.Unmarshal(element, &channel); !isCorruptJSON(err) {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	for i := range channels {
+		channels[i].Type = channelType
+	}
+	return channels, nil
+}
+
+func (t *Transformer) parseStarsFile(filePath string, reader io.Reader) (map[string][]SlackStar, error) {
+	stars := map[string][]SlackStar{}
+	elements, err := t.decodeExportFile(filePath, "", reader, &stars)
+	if err != nil {
+		return nil, err
+	}
+	// the stars are an object, so nothing can be recovered from them
+	if elements != nil {
+		return map[string][]SlackStar{}, nil
+	}
+	return stars, nil
+}
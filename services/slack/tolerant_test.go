@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitJSONArray(t *testing.T) {
+	t.Run("truncated", func(t *testing.T) {
+		elements, lost := splitJSONArray([]byte(`[{"text": "a}"}, {"text": "b", "files": [{"id": "F1"}]}, {"text": "c`))
+		require.Len(t, elements, 2)
+		assert.Equal(t, `{"text": "a}"}`, string(elements[0]))
+		assert.Equal(t, `{"text": "b", "files": [{"id": "F1"}]}`, string(elements[1]))
+		assert.Equal(t, 1, lost)
+	})
+
+	t.Run("invalid element", func(t *testing.T) {
+		elements, lost := splitJSONArray([]byte(`[{"text": "a"}, {"text": "b",, "ts": "1"}, {"text": "\"c\""}]`))
+		require.Len(t, elements, 2)
+		assert.Equal(t, `{"text": "\"c\""}`, string(elements[1]))
+		assert.Equal(t, 1, lost)
+	})
+}
+
+func TestCorruptExportFiles(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "kept", "ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "cut`)}
+
+	for name, stream := range map[string]bool{"transform": false, "stream": true} {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{TeamName: "team", Logger: log.New(), TransformConfig: TransformConfig{SkipAttachments: true}}
+			var result *Result
+			var err error
+			if stream {
+				result, err = StreamFS(context.Background(), fsys, opts, &recordingExporter{})
+			} else {
+				result, err = TransformFS(context.Background(), fsys, opts)
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, 1, result.TransformResult.Count(WarningCorruptFile))
+			assert.Equal(t, []ChannelPostCount{
+				{Channel: "general", Source: 3, Emitted: 3, Skipped: 0},
+			}, result.PostCounts())
+		})
+	}
+
+	t.Run("corrupt users", func(t *testing.T) {
+		fsys := testExportFS()
+		fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+			{"id": "U1", "name": "john", "profile": {"email": "john@example.com"}},
+			{"id": "U2", "name": "jane", "profile": {"email": "jane@ex`)}
+
+		result, err := TransformFS(context.Background(), fsys, Options{TeamName: "team", Logger: log.New(), TransformConfig: TransformConfig{SkipAttachments: true}})
+		require.NoError(t, err)
+		assert.Len(t, result.SlackExport.Users, 1)
+		assert.Equal(t, 1, result.TransformResult.Count(WarningCorruptFile))
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		opts := Options{TeamName: "team", Logger: log.New(), Strict: true, TransformConfig: TransformConfig{SkipAttachments: true}}
+
+		_, err := TransformFS(context.Background(), fsys, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "general/2020-01-02.json")
+
+		_, err = StreamFS(context.Background(), fsys, opts, &recordingExporter{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "general/2020-01-02.json")
+	})
+}
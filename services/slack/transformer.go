@@ -1,12 +1,129 @@
 package slack
 
-import log "github.com/sirupsen/logrus"
+import (
+	"io/fs"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
 
 type Transformer struct {
 	TeamName     string
 	Intermediate *Intermediate
 	Logger       log.FieldLogger
-	redisFactory *redisFactory
+	// ErasureList excludes the data of its subjects from the parsed
+	// export when set
+	ErasureList   *ErasureList
+	erasureReport *ErasureReport
+	// SupplementalExports are per-user exports whose direct and group
+	// messages are merged into the export
+	SupplementalExports []fs.FS
+	// MaxRepliesPerLine limits the replies of a post line, the extra
+	// replies are written to the ContinuationExporter when it is set
+	MaxRepliesPerLine    int
+	ContinuationExporter Exporter
+	continuationLines    int
+	// ColdBefore sends the posts created before it to the
+	// ColdExporter, for a migration importing the recent history
+	// first and the older one later
+	ColdBefore   time.Time
+	ColdExporter Exporter
+	coldLines    int
+	// Strict fails on the first malformed file of the export instead
+	// of recovering what it can from it
+	Strict bool
+	// TimestampOffset is added to the creation time of every post,
+	// to correct exports with a wrong time
+	TimestampOffset time.Duration
+	timestampShift  TimestampShift
+	// SlackAPI completes the data missing from the export when set
+	SlackAPI *SlackAPIClient
+	// UserGroups are the user groups of the workspace, used when the
+	// export has no usergroups.json file
+	UserGroups []SlackUserGroup
+	// ExcludeEmailDomains leaves out the users with an email in these
+	// domains. Their messages are reassigned to the ReassignExcludedTo
+	// username, or skipped when it is empty.
+	ExcludeEmailDomains []string
+	ReassignExcludedTo  string
+	excludedUsers       map[string]bool
+	// ChannelHeader decides whether the header of the channels is
+	// made of the topic, the purpose or both, the topic by default
+	ChannelHeader ChannelHeaderSource
+	// PositionSource decides which fields of the profiles the position
+	// of the users is taken from
+	PositionSource PositionSource
+	// UserMap overrides the username and email of the Slack users
+	UserMap UserMap
+	// TeamMapping spreads the channels over several teams
+	TeamMapping  TeamMapping
+	channelTeams map[string]string
+	// ChannelPrefix starts the name and display name of the public and
+	// private channels, to import several workspaces in a team
+	ChannelPrefix string
+	// RewritePermalinks replaces the links to Slack messages with a
+	// reference to the imported channel, linked to the channel on the
+	// Mattermost site at PermalinkSiteURL when set
+	RewritePermalinks bool
+	PermalinkSiteURL  string
+	// LinkRewrites replaces the links to the tools replaced along with
+	// Slack, e.g. Trello or Asana, with links to the new tools
+	LinkRewrites LinkRewrites
+	// Observer is notified of the progress of the transformation
+	Observer Observer
+	// SaveCheckpoint is called after the posts of each channel are
+	// exported, see Options.SaveCheckpoint
+	SaveCheckpoint func(checkpoint *Checkpoint) error
+	// ImportedMapping is the IDMapping of the import of the users and
+	// channels, when only the posts are exported. The posts referencing
+	// users or channels missing from it are left out.
+	ImportedMapping    *IDMapping
+	importedReferences *importedReferences
+	usernameRenames    []UsernameRename
+	channelNameChanges []ChannelNameChange
+	pinnedPosts        []PinnedPost
+	archivedChannels   []ArchivedChannel
+	// customEmojis are the custom emojis imported with the export and
+	// customEmojiAliases the ones that are aliases of system emojis
+	customEmojis       map[string]bool
+	customEmojiAliases map[string]string
+	// postCounts are the source and emitted posts by original
+	// channel name
+	postCounts map[string]*channelPostCounts
+	// attachmentCopies and attachmentsElapsed measure the copies of
+	// the attachments
+	attachmentCopies   int
+	skippedThumbnails  int
+	attachmentsElapsed time.Duration
+	// failedAttachments are the attachments whose copy failed after
+	// the retries
+	failedAttachments []FailedAttachment
+	// archiveUser posts every message in archive mode, see
+	// PrepareArchiveUser
+	archiveUser       *IntermediateUser
+	authorsByUsername map[string]*IntermediateUser
+	redisFactory      *redisFactory
+	threadsStats      ThreadsStats
+	// botChannels holds the original names of the channels found by
+	// ApplyBotChannelPolicy
+	botChannels map[string]bool
+	// botUsers holds the users created by CreateBotUsers by bot id and
+	// app id
+	botUsers map[string]*IntermediateUser
+	// completedChannels holds the original names of the channels
+	// whose posts have been fully transformed
+	completedChannels []string
+	result            *TransformResult
+	// corruptFiles holds the corrupt files already reported, as the
+	// files read by the channel policies are parsed again
+	corruptFiles map[string]bool
+	// subtypeHandlers convert the messages by subtype, see
+	// RegisterSubtypeHandler
+	subtypeHandlers map[string]SubtypeHandler
+	// warnMu protects result and corruptFiles, as the pipeline parses and transforms
+	// the channels concurrently
+	warnMu sync.Mutex
 }
 
 func NewTransformer(teamName string, logger log.FieldLogger) *Transformer {
@@ -14,5 +131,6 @@ func NewTransformer(teamName string, logger log.FieldLogger) *Transformer {
 		TeamName:     teamName,
 		Intermediate: &Intermediate{},
 		Logger:       logger,
+		result:       newTransformResult(),
 	}
 }
@@ -0,0 +1,82 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// auditActionFileUploaded is the action of the audit log entries of
+// the file uploads
+const auditActionFileUploaded = "file_uploaded"
+
+// FileUploaders maps the ids of the files to the ids of the users that
+// uploaded them.
+type FileUploaders map[string]string
+
+// ParseAuditLogs reads the uploaders of the files from the audit logs
+// of the workspace, either the response of the audit logs API or its
+// list of entries
+func ParseAuditLogs(data io.Reader) (FileUploaders, error) {
+	type auditEntry struct {
+		Action string `json:"action"`
+		Actor  struct {
+			Type string `json:"type"`
+			User struct {
+				Id string `json:"id"`
+			} `json:"user"`
+		} `json:"actor"`
+		Entity struct {
+			Type string `json:"type"`
+			File struct {
+				Id string `json:"id"`
+			} `json:"file"`
+		} `json:"entity"`
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(data).Decode(&raw); err != nil {
+		return nil, err
+	}
+	var response struct {
+		Entries []auditEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		if err := json.Unmarshal(raw, &response.Entries); err != nil {
+			return nil, err
+		}
+	}
+
+	uploaders := FileUploaders{}
+	for _, entry := range response.Entries {
+		if entry.Action != auditActionFileUploaded || entry.Actor.Type != "user" || entry.Entity.Type != "file" {
+			continue
+		}
+		if entry.Actor.User.Id != "" && entry.Entity.File.Id != "" {
+			uploaders[entry.Entity.File.Id] = entry.Actor.User.Id
+		}
+	}
+	return uploaders, nil
+}
+
+// fileUploader returns the user that uploaded the files of a message
+// without author, from the audit logs or else from the files
+// themselves, and nil when none of them is known
+func (t *Transformer) fileUploader(cfg *TransformConfig, post SlackPost) *IntermediateUser {
+	files := post.Files
+	if post.File != nil {
+		files = append([]*SlackFile{post.File}, files...)
+	}
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+		userId, ok := cfg.FileUploaders[file.Id]
+		if !ok {
+			userId = file.User
+		}
+		if user, ok := t.Intermediate.UsersById[userId]; ok {
+			return user
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuditLogs(t *testing.T) {
+	uploaders, err := ParseAuditLogs(strings.NewReader(`{"entries": [
+		{"action": "file_uploaded", "actor": {"type": "user", "user": {"id": "U1"}}, "entity": {"type": "file", "file": {"id": "F1"}}},
+		{"action": "file_downloaded", "actor": {"type": "user", "user": {"id": "U2"}}, "entity": {"type": "file", "file": {"id": "F2"}}}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, FileUploaders{"F1": "U1"}, uploaders)
+
+	uploaders, err = ParseAuditLogs(strings.NewReader(`[
+		{"action": "file_uploaded", "actor": {"type": "user", "user": {"id": "U2"}}, "entity": {"type": "file", "file": {"id": "F2"}}}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, FileUploaders{"F2": "U2"}, uploaders)
+}
+
+func TestAttributeAppUploads(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "subtype": "bot_message", "bot_id": "B1", "text": "from the audit log", "ts": "1577923200.000100", "files": [{"id": "F2", "name": "a.txt"}]},
+		{"type": "message", "subtype": "bot_message", "bot_id": "B1", "text": "from the file", "ts": "1577923201.000100", "files": [{"id": "F3", "name": "b.txt", "user": "U1"}]},
+		{"type": "message", "subtype": "bot_message", "bot_id": "B1", "text": "unknown uploader", "ts": "1577923202.000100", "files": [{"id": "F4", "name": "c.txt"}]}
+	]`)}
+
+	transform := func(attribute bool) map[string]string {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName: "team",
+			Logger:   log.New(),
+			TransformConfig: TransformConfig{
+				SkipAttachments:     true,
+				AttributeAppUploads: attribute,
+				FileUploaders:       FileUploaders{"F2": "U2"},
+			},
+		})
+		require.NoError(t, err)
+		authors := map[string]string{}
+		for _, post := range result.Intermediate.Posts {
+			authors[post.Message] = post.User
+		}
+		return authors
+	}
+
+	authors := transform(true)
+	assert.Equal(t, "jane", authors["from the audit log"])
+	assert.Equal(t, "john", authors["from the file"])
+	assert.NotContains(t, authors, "unknown uploader")
+
+	authors = transform(false)
+	assert.NotContains(t, authors, "from the audit log")
+}
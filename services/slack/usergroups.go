@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// SlackUserGroup is a user group of the workspace, as returned by the
+// usergroups.list API method with its users
+type SlackUserGroup struct {
+	Id         string   `json:"id"`
+	Handle     string   `json:"handle"`
+	Name       string   `json:"name"`
+	DateDelete int64    `json:"date_delete"`
+	Users      []string `json:"users"`
+	Prefs      struct {
+		// Channels and Groups are the public and private channels
+		// the members of the group are added to by default
+		Channels []string `json:"channels"`
+		Groups   []string `json:"groups"`
+	} `json:"prefs"`
+}
+
+// SlackParseUserGroups reads the user groups, either the response of
+// the usergroups.list API method or its list of groups
+func SlackParseUserGroups(data io.Reader) ([]SlackUserGroup, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(data).Decode(&raw); err != nil {
+		return nil, err
+	}
+	var response struct {
+		UserGroups []SlackUserGroup `json:"usergroups"`
+	}
+	if err := json.Unmarshal(raw, &response); err == nil {
+		return response.UserGroups, nil
+	}
+	groups := []SlackUserGroup{}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// UserGroups returns the user groups of the workspace with their
+// users, with the usergroups.list method.
+func (c *SlackAPIClient) UserGroups(ctx context.Context) ([]SlackUserGroup, error) {
+	var response struct {
+		UserGroups []SlackUserGroup `json:"usergroups"`
+	}
+	if err := c.Call(ctx, "usergroups.list", url.Values{"include_users": {"true"}}, &response); err != nil {
+		return nil, err
+	}
+	return response.UserGroups, nil
+}
+
+// userGroupHandles returns the handles of the user groups by id, to
+// render their mentions
+func userGroupHandles(groups []SlackUserGroup) map[string]string {
+	handles := make(map[string]string, len(groups))
+	for _, group := range groups {
+		if group.Handle != "" {
+			handles[group.Id] = group.Handle
+		}
+	}
+	return handles
+}
+
+// AddUserGroupMemberships adds the members of the user groups to the
+// default channels of their groups, as Slack does when they join the
+// group, so they keep the access they had. The deleted groups are left
+// out. It runs before the memberships of the users are populated.
+func (t *Transformer) AddUserGroupMemberships(groups []SlackUserGroup) {
+	channelsById := map[string]*IntermediateChannel{}
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.PublicChannels, t.Intermediate.PrivateChannels} {
+		for _, channel := range channels {
+			channelsById[channel.Id] = channel
+		}
+	}
+
+	added := 0
+	for _, group := range groups {
+		if group.DateDelete != 0 {
+			continue
+		}
+		for _, channelId := range append(append([]string{}, group.Prefs.Channels...), group.Prefs.Groups...) {
+			channel, ok := channelsById[channelId]
+			if !ok || (channel.Type != model.ChannelTypeOpen && channel.Type != model.ChannelTypePrivate) {
+				continue
+			}
+			members := make(map[string]bool, len(channel.Members))
+			for _, member := range channel.Members {
+				members[member] = true
+			}
+			for _, userId := range group.Users {
+				if _, ok := t.Intermediate.UsersById[userId]; !ok || members[userId] {
+					continue
+				}
+				channel.Members = append(channel.Members, userId)
+				members[userId] = true
+				added++
+			}
+		}
+	}
+	t.Logger.Infof("Added %d channel memberships from the default channels of the user groups", added)
+}
@@ -0,0 +1,71 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackParseUserGroups(t *testing.T) {
+	groups, err := SlackParseUserGroups(strings.NewReader(`{"ok": true, "usergroups": [{"id": "S1", "handle": "devs", "users": ["U1"], "prefs": {"channels": ["C1"], "groups": ["G1"]}}]}`))
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "devs", groups[0].Handle)
+	assert.Equal(t, []string{"C1"}, groups[0].Prefs.Channels)
+	assert.Equal(t, []string{"G1"}, groups[0].Prefs.Groups)
+
+	groups, err = SlackParseUserGroups(strings.NewReader(`[{"id": "S1", "handle": "devs"}]`))
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "S1", groups[0].Id)
+}
+
+func TestUserGroupDefaultChannels(t *testing.T) {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com"}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com"}},
+		{"id": "U3", "name": "jim", "profile": {"email": "jim@example.com"}}
+	]`)}
+	fsys["channels.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "C1", "name": "general", "members": ["U1", "U2"]},
+		{"id": "C2", "name": "devs", "members": ["U1"]}
+	]`)}
+	fsys["groups.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "G1", "name": "secret", "members": ["U1"]}
+	]`)}
+	fsys["usergroups.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "S1", "handle": "developers", "users": ["U2", "U3", "U9"], "prefs": {"channels": ["C2"], "groups": ["G1"]}},
+		{"id": "S2", "handle": "gone", "date_delete": 1600000000, "users": ["U3"], "prefs": {"channels": ["C1"]}}
+	]`)}
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "hello <!subteam^S1>", "ts": "1577923200.000100"}
+	]`)}
+
+	transform := func(defaultChannels bool) *Result {
+		result, err := TransformFS(context.Background(), fsys, Options{
+			TeamName:        "team",
+			Logger:          log.New(),
+			TransformConfig: TransformConfig{SkipAttachments: true, UserGroupDefaultChannels: defaultChannels},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := transform(true)
+	assert.ElementsMatch(t, []string{"devs", "secret"}, result.Intermediate.UsersById["U3"].Memberships)
+	assert.ElementsMatch(t, []string{"general", "devs", "secret"}, result.Intermediate.UsersById["U2"].Memberships)
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Contains(t, messages, "hello @developers")
+
+	result = transform(false)
+	assert.Empty(t, result.Intermediate.UsersById["U3"].Memberships)
+}
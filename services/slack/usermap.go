@@ -0,0 +1,95 @@
+package slack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// UserMap maps the ids of the Slack users to the Mattermost accounts
+// they are imported as. An empty username or email keeps the one of
+// the Slack profile.
+type UserMap map[string]UserMapping
+
+// ParseUserMap reads either a JSON object keyed by the Slack user ids,
+// e.g. {"U01ABCD": {"username": "john", "email": "john@example.com"}},
+// or CSV lines of the Slack user id, the username and the email, with
+// an optional slack_id,username,email header.
+func ParseUserMap(reader io.Reader) (UserMap, error) {
+	buffered := bufio.NewReader(reader)
+	start, err := buffered.Peek(1)
+	for err == nil && len(bytes.TrimSpace(start)) == 0 {
+		if _, err = buffered.ReadByte(); err == nil {
+			start, err = buffered.Peek(1)
+		}
+	}
+	if err == io.EOF {
+		return UserMap{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if start[0] == '{' {
+		var userMap UserMap
+		if err := json.NewDecoder(buffered).Decode(&userMap); err != nil {
+			return nil, errors.Wrap(err, "failed to decode the JSON user map")
+		}
+		return userMap, nil
+	}
+
+	csvReader := csv.NewReader(buffered)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the CSV user map")
+	}
+
+	userMap := UserMap{}
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "slack_id") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d of the CSV user map must have the Slack user id, the username and optionally the email", i+1)
+		}
+		mapping := UserMapping{Username: strings.TrimSpace(record[1])}
+		if len(record) == 3 {
+			mapping.Email = strings.TrimSpace(record[2])
+		}
+		userMap[strings.TrimSpace(record[0])] = mapping
+	}
+	return userMap, nil
+}
+
+// mapUser overrides the username and email of the user with its
+// mapping, warning about the users missing from a non empty map.
+func (t *Transformer) mapUser(user *IntermediateUser) {
+	if len(t.UserMap) == 0 {
+		return
+	}
+
+	mapping, ok := t.UserMap[user.Id]
+	if !ok {
+		t.warn(&Warning{
+			Kind:    WarningUnmappedUser,
+			Message: fmt.Sprintf("User %s (%s) is not in the user map, imported with their Slack username and email", user.Username, user.Id),
+		})
+		return
+	}
+
+	if mapping.Username != "" {
+		t.Logger.Debugf("Slack user %s mapped to the username %s", user.Username, mapping.Username)
+		user.Username = mapping.Username
+	}
+	if mapping.Email != "" {
+		user.Email = mapping.Email
+	}
+}
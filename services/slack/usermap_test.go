@@ -0,0 +1,77 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUserMap(t *testing.T) {
+	expected := UserMap{
+		"U1": {Username: "john.doe", Email: "john.doe@example.com"},
+		"U2": {Username: "jane"},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		userMap, err := ParseUserMap(strings.NewReader(`
+			{"U1": {"username": "john.doe", "email": "john.doe@example.com"}, "U2": {"username": "jane"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, expected, userMap)
+	})
+
+	t.Run("csv with a header", func(t *testing.T) {
+		userMap, err := ParseUserMap(strings.NewReader("slack_id,username,email\nU1, john.doe, john.doe@example.com\nU2,jane\n"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, userMap)
+	})
+
+	t.Run("csv without a header", func(t *testing.T) {
+		userMap, err := ParseUserMap(strings.NewReader("U1,john.doe,john.doe@example.com\nU2,jane,\n"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, userMap)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		userMap, err := ParseUserMap(strings.NewReader("\n "))
+		require.NoError(t, err)
+		assert.Empty(t, userMap)
+	})
+
+	t.Run("invalid lines", func(t *testing.T) {
+		_, err := ParseUserMap(strings.NewReader("U1\n"))
+		assert.Error(t, err)
+
+		_, err = ParseUserMap(strings.NewReader(`{"U1": "john"}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestTransformUsersUserMap(t *testing.T) {
+	users := []SlackUser{
+		{Id: "U1", Username: "john", Profile: SlackProfile{Email: "john@slack.example.com"}},
+		{Id: "U2", Username: "jane", Profile: SlackProfile{Email: "jane@slack.example.com"}},
+	}
+
+	transformer := NewTransformer("team", log.New())
+	transformer.UserMap = UserMap{"U1": {Username: "john.doe", Email: "john.doe@example.com"}}
+	transformer.TransformUsers(users, false, "")
+
+	john := transformer.Intermediate.UsersById["U1"]
+	assert.Equal(t, "john.doe", john.Username)
+	assert.Equal(t, "john.doe@example.com", john.Email)
+	jane := transformer.Intermediate.UsersById["U2"]
+	assert.Equal(t, "jane", jane.Username)
+	assert.Equal(t, "jane@slack.example.com", jane.Email)
+
+	assert.Equal(t, 1, transformer.result.Count(WarningUnmappedUser))
+	assert.Equal(t, 0, transformer.result.SkippedCount())
+
+	t.Run("no warnings without a map", func(t *testing.T) {
+		transformer := NewTransformer("team", log.New())
+		transformer.TransformUsers(users, false, "")
+		assert.Equal(t, 0, transformer.result.Total())
+	})
+}
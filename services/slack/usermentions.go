@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"regexp"
+	"strings"
+)
+
+// slackMentionLabelRegex matches the mentions of users, with the name
+// Slack sometimes adds to them
+var slackMentionLabelRegex = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|([^>]*))?>`)
+
+// slackUserName returns the name a user is shown with in Slack
+func slackUserName(user SlackUser) string {
+	if name := strings.TrimSpace(user.Profile.RealName); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(user.Profile.DisplayName); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(user.Profile.FirstName + " " + user.Profile.LastName); name != "" {
+		return name
+	}
+	return user.Username
+}
+
+// slackExcludedUserMentionRegexes renders the mentions of the users
+// left out of the import as their name in plain text, as there is no
+// Mattermost user to mention.
+func slackExcludedUserMentionRegexes(users []SlackUser) map[string]*regexp.Regexp {
+	regexes := make(map[string]*regexp.Regexp, len(users))
+	for _, user := range users {
+		// the key is used as the replacement, where $ expands
+		name := strings.ReplaceAll(slackUserName(user), "$", "$$")
+		regexes[name] = regexp.MustCompile("<@" + regexp.QuoteMeta(user.Id) + `(\|[^>]*)?>`)
+	}
+	return regexes
+}
+
+// replaceUnknownUserMentions renders the mentions of users missing
+// from the export, e.g. external users of shared channels, as the name
+// Slack added to the mention, leaving them as is when there is none.
+func replaceUnknownUserMentions(posts map[string][]SlackPost, importedUsers map[string]bool) map[string][]SlackPost {
+	return replacePostsText(posts, func(text string) string {
+		return slackMentionLabelRegex.ReplaceAllStringFunc(text, func(mention string) string {
+			match := slackMentionLabelRegex.FindStringSubmatch(mention)
+			if importedUsers[match[1]] || match[2] == "" {
+				return mention
+			}
+			return match[2]
+		})
+	})
+}
+
+// slackSubteamMentionRegex matches the mentions of user groups, with
+// the handle Slack sometimes adds to them
+var slackSubteamMentionRegex = regexp.MustCompile(`<!subteam\^([A-Z0-9]+)(?:\|([^>]*))?>`)
+
+// replaceUserGroupMentions renders the mentions of user groups as the
+// handle of the group in userGroups, by Slack id, or as the handle
+// Slack added to the mention, so they mention the group once it exists
+// in Mattermost. The mentions with neither are left as is.
+func replaceUserGroupMentions(posts map[string][]SlackPost, userGroups map[string]string) map[string][]SlackPost {
+	return replacePostsText(posts, func(text string) string {
+		return slackSubteamMentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+			match := slackSubteamMentionRegex.FindStringSubmatch(mention)
+			handle := userGroups[match[1]]
+			if handle == "" {
+				handle = match[2]
+			}
+			if handle == "" {
+				return mention
+			}
+			return "@" + strings.TrimPrefix(handle, "@")
+		})
+	})
+}
+
+// replacePostsText applies replace to the text of the posts and of
+// their nested messages
+func replacePostsText(posts map[string][]SlackPost, replace func(string) string) map[string][]SlackPost {
+	for channelName, channelPosts := range posts {
+		for postIdx := range channelPosts {
+			post := &posts[channelName][postIdx]
+			post.Text = replace(post.Text)
+			for _, nested := range post.nestedMessages() {
+				nested.Text = replace(nested.Text)
+			}
+		}
+	}
+	return posts
+}
+
+// excludeUsers moves the users of the export matching exclude to its
+// ExcludedUsers, returning how many were excluded. It must run before
+// the mentions are converted.
+func excludeUsers(slackExport *SlackExport, exclude func(user SlackUser) bool) int {
+	users := slackExport.Users[:0]
+	excluded := 0
+	for _, user := range slackExport.Users {
+		if exclude(user) {
+			slackExport.ExcludedUsers = append(slackExport.ExcludedUsers, user)
+			excluded++
+			continue
+		}
+		users = append(users, user)
+	}
+	slackExport.Users = users
+	return excluded
+}
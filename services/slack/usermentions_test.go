@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertMentionsOfUsersNotImported(t *testing.T) {
+	slackExport := &SlackExport{Users: []SlackUser{
+		{Id: "U1", Username: "john"},
+		{Id: "U2", Username: "jane", Profile: SlackProfile{RealName: "Jane $mith"}},
+		{Id: "U3", Username: "bob", Profile: SlackProfile{FirstName: "Bob", LastName: "Stone"}},
+		{Id: "U4", Username: "alice"},
+	}}
+	excluded := excludeUsers(slackExport, func(user SlackUser) bool {
+		return user.Id != "U1"
+	})
+	require.Equal(t, 3, excluded)
+	require.Len(t, slackExport.Users, 1)
+
+	converter := newPostsConverter(slackExport.Users, slackExport.ExcludedUsers, nil, "")
+	posts := converter.convert(map[string][]SlackPost{"general": {
+		{Text: "<@U1> <@U2> <@U3|bob> <@U4>", Message: &SlackPost{Text: "cc <@U2>"}},
+		{Text: "from <@W9|Ext User> and <@W8>"},
+	}})
+
+	assert.Equal(t, "@john Jane $mith Bob Stone alice", posts["general"][0].Text)
+	assert.Equal(t, "cc Jane $mith", posts["general"][0].Message.Text)
+	assert.Equal(t, "from Ext User and <@W8>", posts["general"][1].Text)
+}
+
+func TestConvertUserGroupMentions(t *testing.T) {
+	posts := replaceUserGroupMentions(map[string][]SlackPost{"general": {
+		{Text: "<!subteam^S1|@backend> <!subteam^S2> <!subteam^S3|frontend>", Message: &SlackPost{Text: "cc <!subteam^S1|@backend>"}},
+	}}, map[string]string{"S2": "ops"})
+
+	assert.Equal(t, "@backend @ops @frontend", posts["general"][0].Text)
+	assert.Equal(t, "cc @backend", posts["general"][0].Message.Text)
+
+	posts = replaceUserGroupMentions(map[string][]SlackPost{"general": {{Text: "<!subteam^S2>"}}}, nil)
+	assert.Equal(t, "<!subteam^S2>", posts["general"][0].Text)
+}
@@ -0,0 +1,153 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+)
+
+// reservedUsernames are rejected by the server or taken by the users
+// it and its bundled plugins create
+var reservedUsernames = map[string]bool{
+	"all":         true,
+	"channel":     true,
+	"here":        true,
+	"matterbot":   true,
+	"system":      true,
+	"admin":       true,
+	"surveybot":   true,
+	"feedbackbot": true,
+	"playbooks":   true,
+}
+
+// UsernameRename records a Slack username that was changed to be
+// valid in Mattermost
+type UsernameRename struct {
+	UserId   string `json:"user_id"`
+	Original string `json:"original"`
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+}
+
+// fixUsername returns a username following the rules of the server
+// for the Slack one, with the reason it was changed
+func fixUsername(username string) (string, string) {
+	fixed := strings.ToLower(username)
+	var reasons []string
+	if fixed != username {
+		reasons = append(reasons, "uppercase letters")
+	}
+
+	var builder strings.Builder
+	invalidChars := false
+	for _, r := range fixed {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			builder.WriteRune(r)
+		} else {
+			invalidChars = true
+			builder.WriteRune('-')
+		}
+	}
+	if invalidChars {
+		fixed = strings.Trim(builder.String(), "-")
+		reasons = append(reasons, "invalid characters")
+	}
+
+	if fixed == "" {
+		return "", strings.Join(append(reasons, "empty"), ", ")
+	}
+
+	if !unicode.IsLetter(rune(fixed[0])) {
+		fixed = "u" + fixed
+		reasons = append(reasons, "does not start with a letter")
+	}
+
+	if reservedUsernames[fixed] {
+		fixed += "-slack"
+		reasons = append(reasons, "reserved")
+	}
+
+	if len(fixed) > model.UserNameMaxLength {
+		fixed = fixed[:model.UserNameMaxLength]
+		reasons = append(reasons, "too long")
+	}
+
+	return fixed, strings.Join(reasons, ", ")
+}
+
+// uniqueUsername returns the username, suffixed with a number when it
+// is taken, and marks it as taken. The username is truncated before the
+// suffix so the result is never longer than the server allows.
+func uniqueUsername(username string, taken map[string]bool) string {
+	candidate := username
+	for suffix := 2; taken[candidate] || !model.IsValidUsername(candidate); suffix++ {
+		suffixText := fmt.Sprintf("-%d", suffix)
+		base := username
+		if len(base)+len(suffixText) > model.UserNameMaxLength {
+			base = base[:model.UserNameMaxLength-len(suffixText)]
+		}
+		candidate = base + suffixText
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+// FixUsernames renames the users of the export whose username would be
+// rejected by the server, keeping the usernames unique. It runs before
+// the mentions are converted, so they use the new usernames.
+func (t *Transformer) FixUsernames(users []SlackUser) []UsernameRename {
+	taken := make(map[string]bool, len(users))
+	for _, user := range users {
+		if fixed, reason := fixUsername(user.Username); reason == "" {
+			taken[fixed] = true
+		}
+	}
+
+	renames := []UsernameRename{}
+	for i := range users {
+		user := &users[i]
+		fixed, reason := fixUsername(user.Username)
+		if reason == "" {
+			continue
+		}
+
+		if fixed == "" {
+			fixed, _ = fixUsername("user-" + user.Id)
+		}
+		candidate := uniqueUsername(fixed, taken)
+
+		t.Logger.Warnf("Slack user %s renamed to %s: %s", user.Username, candidate, reason)
+		renames = append(renames, UsernameRename{
+			UserId:   user.Id,
+			Original: user.Username,
+			Username: candidate,
+			Reason:   reason,
+		})
+		user.Username = candidate
+	}
+
+	t.usernameRenames = append(t.usernameRenames, renames...)
+	return renames
+}
+
+// UsernameRenames returns the users renamed by FixUsernames
+func (t *Transformer) UsernameRenames() []UsernameRename {
+	return t.usernameRenames
+}
+
+func WriteUsernameRenameReport(reportPath string, renames []UsernameRename) error {
+	b, err := json.MarshalIndent(renames, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the username rename report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the username rename report %s", reportPath)
+	}
+	return nil
+}
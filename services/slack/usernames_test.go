@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixUsername(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Username string
+		Expected string
+		Reason   string
+	}{
+		{"valid username", "john.doe-2_x", "john.doe-2_x", ""},
+		{"uppercase letters", "John", "john", "uppercase letters"},
+		{"invalid characters", "jöhn doe!", "j-hn-doe", "invalid characters"},
+		{"starting with a digit", "1337", "u1337", "does not start with a letter"},
+		{"reserved", "admin", "admin-slack", "reserved"},
+		{"only invalid characters", "иван", "", "invalid characters, empty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			fixed, reason := fixUsername(tc.Username)
+			assert.Equal(t, tc.Expected, fixed)
+			assert.Equal(t, tc.Reason, reason)
+			if fixed != "" {
+				assert.True(t, model.IsValidUsername(fixed))
+			}
+		})
+	}
+}
+
+func TestFixUsernames(t *testing.T) {
+	transformer := NewTransformer("team", log.New())
+	users := []SlackUser{
+		{Id: "U1", Username: "John"},
+		{Id: "U2", Username: "john"},
+		{Id: "U3", Username: "system"},
+		{Id: "U4", Username: "иван"},
+		{Id: "U5", Username: "jane"},
+	}
+
+	renames := transformer.FixUsernames(users)
+	assert.Equal(t, []string{"john-2", "john", "system-slack", "user-u4", "jane"}, []string{
+		users[0].Username, users[1].Username, users[2].Username, users[3].Username, users[4].Username,
+	})
+	require.Len(t, renames, 3)
+	assert.Equal(t, UsernameRename{UserId: "U1", Original: "John", Username: "john-2", Reason: "uppercase letters"}, renames[0])
+	assert.Equal(t, renames, transformer.UsernameRenames())
+
+	t.Run("long colliding usernames", func(t *testing.T) {
+		long := strings.Repeat("a", model.UserNameMaxLength)
+		users := []SlackUser{
+			{Id: "U1", Username: long},
+			{Id: "U2", Username: strings.ToUpper(long)},
+			{Id: "U3", Username: strings.ToUpper(long) + "B"},
+		}
+
+		NewTransformer("team", log.New()).FixUsernames(users)
+		assert.Equal(t, long, users[0].Username)
+		assert.Equal(t, long[:model.UserNameMaxLength-2]+"-2", users[1].Username)
+		assert.Equal(t, long[:model.UserNameMaxLength-2]+"-3", users[2].Username)
+		for _, user := range users {
+			assert.True(t, model.IsValidUsername(user.Username), user.Username)
+		}
+	})
+}
+
+func TestTransformFSRenamesMentions(t *testing.T) {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com"}},
+		{"id": "U2", "name": "Admin", "profile": {"email": "admin@example.com"}}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "admin-slack", result.Intermediate.UsersById["U2"].Username)
+	messages := []string{}
+	for _, post := range result.Intermediate.Posts {
+		messages = append(messages, post.Message)
+	}
+	assert.Contains(t, messages, "hello @admin-slack")
+	require.Len(t, result.UsernameRenames(), 1)
+}
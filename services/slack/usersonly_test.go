@@ -0,0 +1,62 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamFSUsersOnly(t *testing.T) {
+	fsys := testExportFS()
+	fsys["users.json"] = &fstest.MapFile{Data: []byte(`[
+		{"id": "U1", "name": "john", "profile": {"email": "john@example.com", "status_text": "On leave", "status_emoji": ":palm_tree:"}},
+		{"id": "U2", "name": "jane", "profile": {"email": "jane@example.com"}}
+	]`)}
+	fsys["emoji.json"] = &fstest.MapFile{Data: []byte(`{"blob-dance": "https://emoji.example.com/blob-dance.png"}`)}
+	emojiDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(emojiDir, "blob-dance.png"), []byte("blob"), 0600))
+
+	var buffer bytes.Buffer
+	result, err := StreamFS(context.Background(), fsys, Options{
+		TeamName:   "team",
+		CreateTeam: true,
+		Logger:     log.New(),
+		TransformConfig: TransformConfig{
+			AttachmentsDir: t.TempDir(),
+			UsersOnly:      true,
+			CustomStatuses: true,
+			CustomEmojis:   &CustomEmojiConfig{Dir: emojiDir},
+		},
+	}, NewJSONLExporter(&buffer))
+	require.NoError(t, err)
+	assert.Empty(t, result.Intermediate.Emojis)
+	assert.Empty(t, result.CustomStatuses())
+
+	types := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var parsed struct {
+			Type string `json:"type"`
+			User *struct {
+				Teams []struct {
+					Channels []json.RawMessage `json:"channels"`
+				} `json:"teams"`
+			} `json:"user"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &parsed))
+		types = append(types, parsed.Type)
+		if parsed.User != nil {
+			require.Len(t, parsed.User.Teams, 1)
+			assert.Empty(t, parsed.User.Teams[0].Channels)
+		}
+	}
+	assert.Equal(t, []string{"version", "team", "user", "user"}, types)
+}
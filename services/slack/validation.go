@@ -0,0 +1,335 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ValidationMode decides what the ValidatingExporter does with the
+// lines the server would reject
+type ValidationMode string
+
+const (
+	ValidationOff ValidationMode = "off"
+	// ValidationReport reports the violations and writes the lines
+	// unchanged
+	ValidationReport ValidationMode = "report"
+	// ValidationFix fixes the violations when possible, e.g. by
+	// truncating fields, and skips the post lines that can't be fixed.
+	// The channel, user and direct channel lines are always written, as
+	// the lines after them reference them.
+	ValidationFix ValidationMode = "fix"
+)
+
+func ParseValidationMode(mode string) (ValidationMode, error) {
+	switch ValidationMode(mode) {
+	case "", ValidationOff:
+		return ValidationOff, nil
+	case ValidationReport, ValidationFix:
+		return ValidationMode(mode), nil
+	}
+	return "", errors.Errorf("unknown validation mode %q, expected %q, %q or %q", mode, ValidationOff, ValidationReport, ValidationFix)
+}
+
+// ValidationIssue is a field of an import line that the server import
+// validation rejects
+type ValidationIssue struct {
+	// Line is the number of the line among the lines given to the
+	// exporter, starting at 1
+	Line    int    `json:"line"`
+	Type    string `json:"type"`
+	Entity  string `json:"entity"`
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+	// Fixed is set when the field was fixed in the written line
+	Fixed bool `json:"fixed"`
+}
+
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("line %d: %s %s: %s %s", i.Line, i.Type, i.Entity, i.Field, i.Problem)
+}
+
+// lineValidator checks a line against the rules the server applies
+// when importing it, fixing the fields it can when fix is set
+type lineValidator struct {
+	line   int
+	fix    bool
+	issues []ValidationIssue
+}
+
+func (v *lineValidator) report(lineType, entity, field, problem string, fixable bool) {
+	v.issues = append(v.issues, ValidationIssue{
+		Line:    v.line,
+		Type:    lineType,
+		Entity:  entity,
+		Field:   field,
+		Problem: problem,
+		Fixed:   fixable && v.fix,
+	})
+}
+
+// maxRunes checks the length of an optional field, truncating it when
+// fixing
+func (v *lineValidator) maxRunes(lineType, entity, field string, value *string, max int) {
+	if value == nil || utf8.RuneCountInString(*value) <= max {
+		return
+	}
+	v.report(lineType, entity, field, fmt.Sprintf("is longer than %d characters", max), true)
+	if v.fix {
+		*value = truncateRunes(*value, max)
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func (v *lineValidator) validateChannel(channel *app.ChannelImportData) {
+	name := stringValue(channel.Name)
+	if name == "" || len(name) > model.ChannelNameMaxLength || !model.IsValidChannelIdentifier(name) {
+		v.report("channel", name, "name", "is not a valid channel name", false)
+	}
+	if channel.Type == nil || (*channel.Type != model.ChannelTypeOpen && *channel.Type != model.ChannelTypePrivate) {
+		v.report("channel", name, "type", "is neither open nor private", false)
+	}
+	v.maxRunes("channel", name, "display_name", channel.DisplayName, model.ChannelDisplayNameMaxRunes)
+	v.maxRunes("channel", name, "header", channel.Header, model.ChannelHeaderMaxRunes)
+	v.maxRunes("channel", name, "purpose", channel.Purpose, model.ChannelPurposeMaxRunes)
+}
+
+func (v *lineValidator) validateUser(user *app.UserImportData) {
+	username := stringValue(user.Username)
+	if !model.IsValidUsername(username) {
+		v.report("user", username, "username", "is not a valid username", false)
+	}
+	if email := stringValue(user.Email); email == "" || len(email) > model.UserEmailMaxLength {
+		v.report("user", username, "email", "is empty or too long", false)
+	}
+	if user.AuthData != nil && user.Password != nil {
+		v.report("user", username, "password", "is set along with the auth data", true)
+		if v.fix {
+			user.Password = nil
+		}
+	}
+	if user.AuthData != nil && len(*user.AuthData) > model.UserAuthDataMaxLength {
+		v.report("user", username, "auth_data", fmt.Sprintf("is longer than %d bytes", model.UserAuthDataMaxLength), false)
+	}
+	if user.Password != nil && (*user.Password == "" || len(*user.Password) > model.UserPasswordMaxLength) {
+		v.report("user", username, "password", "is empty or too long", true)
+		if v.fix {
+			password := model.NewId()
+			user.Password = &password
+		}
+	}
+	v.maxRunes("user", username, "nickname", user.Nickname, model.UserNicknameMaxRunes)
+	v.maxRunes("user", username, "first_name", user.FirstName, model.UserFirstNameMaxRunes)
+	v.maxRunes("user", username, "last_name", user.LastName, model.UserLastNameMaxRunes)
+	v.maxRunes("user", username, "position", user.Position, model.UserPositionMaxRunes)
+}
+
+func (v *lineValidator) validateMembers(lineType, entity string, members *[]string) {
+	if members == nil {
+		v.report(lineType, entity, "members", "are missing", false)
+		return
+	}
+	if count := len(*members); count != 2 && (count < model.ChannelGroupMinUsers || count > model.ChannelGroupMaxUsers) {
+		v.report(lineType, entity, "members", fmt.Sprintf("are %d, not between 2 and %d", count, model.ChannelGroupMaxUsers), false)
+	}
+}
+
+// validateMessage checks the fields common to posts and replies
+func (v *lineValidator) validateMessage(lineType, entity string, user, message *string, createAt *int64, parentCreateAt int64) {
+	if user == nil {
+		v.report(lineType, entity, "user", "is missing", false)
+	}
+	if message == nil {
+		v.report(lineType, entity, "message", "is missing", false)
+	}
+	v.maxRunes(lineType, entity, "message", message, PosgreSQLMaxPostSize)
+	switch {
+	case createAt == nil || *createAt == 0:
+		v.report(lineType, entity, "create_at", "is missing", false)
+	case *createAt < parentCreateAt:
+		v.report(lineType, entity, "create_at", "is before the create_at of the root post", true)
+		if v.fix {
+			*createAt = parentCreateAt
+		}
+	}
+}
+
+func (v *lineValidator) validateReplies(entity string, replies *[]app.ReplyImportData, parentCreateAt int64) {
+	if replies == nil {
+		return
+	}
+	for i := range *replies {
+		reply := &(*replies)[i]
+		v.validateMessage("reply", entity, reply.User, reply.Message, reply.CreateAt, parentCreateAt)
+	}
+}
+
+func (v *lineValidator) validateProps(lineType, entity string, props **model.StringInterface) {
+	if *props == nil || utf8.RuneCountInString(model.StringInterfaceToJSON(**props)) <= model.PostPropsMaxRunes {
+		return
+	}
+	v.report(lineType, entity, "props", fmt.Sprintf("are longer than %d characters", model.PostPropsMaxRunes), true)
+	if v.fix {
+		*props = nil
+	}
+}
+
+func (v *lineValidator) validatePost(post *app.PostImportData) {
+	entity := fmt.Sprintf("%s/%d", stringValue(post.Channel), int64Value(post.CreateAt))
+	if stringValue(post.Team) == "" {
+		v.report("post", entity, "team", "is missing", false)
+	}
+	if stringValue(post.Channel) == "" {
+		v.report("post", entity, "channel", "is missing", false)
+	}
+	v.validateMessage("post", entity, post.User, post.Message, post.CreateAt, 0)
+	v.validateProps("post", entity, &post.Props)
+	v.validateReplies(entity, post.Replies, int64Value(post.CreateAt))
+}
+
+func (v *lineValidator) validateDirectPost(post *app.DirectPostImportData) {
+	entity := fmt.Sprintf("direct/%d", int64Value(post.CreateAt))
+	v.validateMembers("direct_post", entity, post.ChannelMembers)
+	v.validateMessage("direct_post", entity, post.User, post.Message, post.CreateAt, 0)
+	v.validateProps("direct_post", entity, &post.Props)
+	v.validateReplies(entity, post.Replies, int64Value(post.CreateAt))
+}
+
+func int64Value(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// ValidateLine checks a line against the validation of the server
+// import, fixing the fields it can when fix is set. The line can be
+// written when none of the returned issues is left unfixed.
+func ValidateLine(line *app.LineImportData, lineNumber int, fix bool) []ValidationIssue {
+	v := &lineValidator{line: lineNumber, fix: fix}
+	switch {
+	case line.Channel != nil:
+		v.validateChannel(line.Channel)
+	case line.User != nil:
+		v.validateUser(line.User)
+	case line.Post != nil:
+		v.validatePost(line.Post)
+	case line.DirectChannel != nil:
+		v.validateMembers("direct_channel", "direct", line.DirectChannel.Members)
+		v.maxRunes("direct_channel", "direct", "header", line.DirectChannel.Header, model.ChannelHeaderMaxRunes)
+	case line.DirectPost != nil:
+		v.validateDirectPost(line.DirectPost)
+	}
+	return v.issues
+}
+
+// isPostLine returns whether the line is a post, which can be skipped
+// without breaking the lines after it
+func isPostLine(line *app.LineImportData) bool {
+	return line.Post != nil || line.DirectPost != nil
+}
+
+// ValidatingExporter validates the lines before writing them to the
+// wrapped exporter, so the violations are found before the import.
+// The violations are raised as warnings, deduplicated and bounded as
+// the warnings of the transformation.
+type ValidatingExporter struct {
+	exporter Exporter
+	mode     ValidationMode
+	logger   log.FieldLogger
+	lines    int
+	skipped  int
+	result   *TransformResult
+}
+
+// NewValidatingExporter creates the exporter, keeping at most
+// maxWarnings violations when positive, see Options.MaxWarnings.
+func NewValidatingExporter(exporter Exporter, mode ValidationMode, logger log.FieldLogger, maxWarnings int) *ValidatingExporter {
+	result := newTransformResult()
+	result.maxWarnings = maxWarnings
+	return &ValidatingExporter{
+		exporter: exporter,
+		mode:     mode,
+		logger:   logger,
+		result:   result,
+	}
+}
+
+func (e *ValidatingExporter) WriteLine(line *app.LineImportData) error {
+	if e.mode == ValidationOff {
+		return e.exporter.WriteLine(line)
+	}
+
+	e.lines++
+	issues := ValidateLine(line, e.lines, e.mode == ValidationFix)
+	skip := false
+	for _, issue := range issues {
+		skipped := !issue.Fixed && e.mode == ValidationFix && isPostLine(line)
+		skip = skip || skipped
+		e.warn(issue, skipped)
+	}
+
+	if skip {
+		e.skipped++
+		return nil
+	}
+	return e.exporter.WriteLine(line)
+}
+
+// warn raises the violation as a warning, grouped with the same
+// violation of the other lines
+func (e *ValidatingExporter) warn(issue ValidationIssue, skipped bool) {
+	message := fmt.Sprintf("Invalid import %s line: %s %s", issue.Type, issue.Field, issue.Problem)
+	if issue.Fixed {
+		message += ", fixed"
+	}
+	warning := &Warning{
+		Kind:    WarningInvalidLine,
+		Skipped: skipped,
+		Channel: issue.Entity,
+		Message: message,
+	}
+	logWarning(e.logger.WithField("line", issue.Line), warning, e.result.add(warning))
+}
+
+func (e *ValidatingExporter) Close() error {
+	return e.exporter.Close()
+}
+
+// Result returns the violations found in the lines written so far
+func (e *ValidatingExporter) Result() *TransformResult {
+	return e.result
+}
+
+// Skipped returns the number of lines left out as they could not be
+// fixed
+func (e *ValidatingExporter) Skipped() int {
+	return e.skipped
+}
+
+// WriteValidationReport writes the deduplicated violations of the
+// validating exporters
+func WriteValidationReport(reportPath string, groups []WarningGroup) error {
+	b, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the validation report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the validation report %s", reportPath)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v6/app"
+	"github.com/mattermost/mattermost-server/v6/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatingExporter(t *testing.T) {
+	newLines := func() []*app.LineImportData {
+		longHeader := strings.Repeat("h", model.ChannelHeaderMaxRunes+1)
+		authData := "john@example.com"
+		password := "secret"
+		parentCreateAt := int64(2000)
+		replyCreateAt := int64(1000)
+		return []*app.LineImportData{
+			{Type: "channel", Channel: &app.ChannelImportData{Name: model.NewString("general"), Type: channelTypePtr(model.ChannelTypeOpen), Header: &longHeader}},
+			{Type: "user", User: &app.UserImportData{Username: model.NewString("john"), Email: model.NewString("john@example.com"), AuthData: &authData, AuthService: model.NewString("gitlab"), Password: &password}},
+			{Type: "user", User: &app.UserImportData{Username: model.NewString("Not Valid"), Email: model.NewString("x@example.com")}},
+			{Type: "post", Post: &app.PostImportData{Team: model.NewString("team"), Channel: model.NewString("general"), User: model.NewString("john"), Message: model.NewString("root"), CreateAt: &parentCreateAt, Replies: &[]app.ReplyImportData{
+				{User: model.NewString("john"), Message: model.NewString("reply"), CreateAt: &replyCreateAt},
+			}}},
+			{Type: "post", Post: &app.PostImportData{User: model.NewString("john"), Message: model.NewString("no channel"), CreateAt: &parentCreateAt}},
+		}
+	}
+
+	t.Run("report", func(t *testing.T) {
+		recorder := &recordingExporter{}
+		exporter := NewValidatingExporter(recorder, ValidationReport, log.New(), 0)
+		for _, line := range newLines() {
+			require.NoError(t, exporter.WriteLine(line))
+		}
+
+		require.Len(t, recorder.lines, 5)
+		assert.Len(t, *recorder.lines[0].Channel.Header, model.ChannelHeaderMaxRunes+1)
+		groups := exporter.Result().Deduplicated()
+		require.Len(t, groups, 6)
+		assert.Equal(t, WarningGroup{Kind: WarningInvalidLine, Message: "Invalid import channel line: header is longer than 1024 characters", Channel: "general", Count: 1}, groups[0])
+		assert.Equal(t, "Invalid import user line: password is set along with the auth data", groups[1].Message)
+		assert.Equal(t, "Invalid import user line: username is not a valid username", groups[2].Message)
+		assert.Equal(t, "Invalid import reply line: create_at is before the create_at of the root post", groups[3].Message)
+		assert.Equal(t, "Invalid import post line: team is missing", groups[4].Message)
+		assert.Equal(t, "Invalid import post line: channel is missing", groups[5].Message)
+		assert.Equal(t, 0, exporter.Skipped())
+		assert.Zero(t, exporter.Result().SkippedCount())
+	})
+
+	t.Run("fix", func(t *testing.T) {
+		recorder := &recordingExporter{}
+		exporter := NewValidatingExporter(recorder, ValidationFix, log.New(), 0)
+		for _, line := range newLines() {
+			require.NoError(t, exporter.WriteLine(line))
+		}
+
+		// the user that can't be fixed is written, as the lines after
+		// it can reference it, while the post is skipped
+		require.Len(t, recorder.lines, 4)
+		assert.Len(t, *recorder.lines[0].Channel.Header, model.ChannelHeaderMaxRunes)
+		assert.Nil(t, recorder.lines[1].User.Password)
+		assert.Equal(t, "Not Valid", *recorder.lines[2].User.Username)
+		assert.Equal(t, int64(2000), *(*recorder.lines[3].Post.Replies)[0].CreateAt)
+		assert.Equal(t, 1, exporter.Skipped())
+		for _, group := range exporter.Result().Deduplicated() {
+			fixable := !strings.Contains(group.Message, "username") && !strings.Contains(group.Message, "is missing")
+			assert.Equal(t, fixable, strings.HasSuffix(group.Message, ", fixed"), group.Message)
+		}
+	})
+
+	t.Run("repeated violations", func(t *testing.T) {
+		var output bytes.Buffer
+		logger := log.New()
+		logger.SetOutput(&output)
+		exporter := NewValidatingExporter(&recordingExporter{}, ValidationReport, logger, 1)
+		for i := 0; i < 3; i++ {
+			for _, line := range newLines() {
+				require.NoError(t, exporter.WriteLine(line))
+			}
+		}
+
+		assert.Equal(t, 18, exporter.Result().Total())
+		assert.Len(t, exporter.Result().Warnings, 1)
+		assert.Equal(t, 1, strings.Count(output.String(), "header is longer than 1024 characters"))
+		groups := exporter.Result().Deduplicated()
+		require.Len(t, groups, 2)
+		assert.Equal(t, 3, groups[0].Count)
+		assert.True(t, groups[1].Truncated)
+		assert.Equal(t, 15, groups[1].Count)
+	})
+}
+
+func channelTypePtr(channelType model.ChannelType) *model.ChannelType {
+	return &channelType
+}
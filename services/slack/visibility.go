@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+const slackExportDayLayout = "2006-01-02"
+
+// lastActivityDay returns the day of the most recent post file of a
+// channel, without parsing the posts.
+func lastActivityDay(postFiles []string) (time.Time, bool) {
+	var last time.Time
+	for _, filePath := range postFiles {
+		day, err := time.Parse(slackExportDayLayout, strings.TrimSuffix(path.Base(filePath), ".json"))
+		if err != nil {
+			continue
+		}
+		if day.After(last) {
+			last = day
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// activityRange returns the days of the first and the most recent post
+// files of a channel, without parsing the posts.
+func activityRange(postFiles []string) (first, last time.Time, ok bool) {
+	for _, filePath := range postFiles {
+		day, err := time.Parse(slackExportDayLayout, strings.TrimSuffix(path.Base(filePath), ".json"))
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+		if day.After(last) {
+			last = day
+		}
+	}
+	return first, last, !first.IsZero()
+}
+
+// HideInactiveDirectChannels marks the direct and group channels with
+// no activity during the given number of days before the last
+// activity of the export as hidden. Hidden channels are not exported
+// as direct channel lines, which show the conversation in the sidebar
+// of its members, but their posts are still imported.
+func (t *Transformer) HideInactiveDirectChannels(slackExport *SlackExport, days int) {
+	lastActivity := map[string]time.Time{}
+	var exportLastActivity time.Time
+	for channelName, postFiles := range slackExport.PostFiles {
+		if day, ok := lastActivityDay(postFiles); ok {
+			lastActivity[channelName] = day
+			if day.After(exportLastActivity) {
+				exportLastActivity = day
+			}
+		}
+	}
+	cutoff := exportLastActivity.AddDate(0, 0, -days)
+
+	hidden := 0
+	for _, channels := range [][]*IntermediateChannel{t.Intermediate.GroupChannels, t.Intermediate.DirectChannels} {
+		for _, channel := range channels {
+			if day, ok := lastActivity[channel.OriginalName]; !ok || day.Before(cutoff) {
+				channel.Hidden = true
+				hidden++
+			}
+		}
+	}
+
+	t.Logger.Infof("Hiding %d direct and group channels with no activity since %s", hidden, cutoff.Format(slackExportDayLayout))
+}
@@ -0,0 +1,41 @@
+package slack
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHideInactiveDirectChannels(t *testing.T) {
+	slackExport := &SlackExport{
+		PostFiles: map[string][]string{
+			"general": {"general/2021-03-01.json"},
+			"D1":      {"D1/2020-01-01.json", "D1/2021-02-20.json"},
+			"D2":      {"D2/2020-01-01.json"},
+			"G1":      {"G1/2021-01-15.json"},
+		},
+	}
+
+	transformer := NewTransformer("team", log.New())
+	transformer.Intermediate.DirectChannels = []*IntermediateChannel{
+		{OriginalName: "D1", Members: []string{"u1", "u2"}},
+		{OriginalName: "D2", Members: []string{"u1", "u3"}},
+		{OriginalName: "D3", Members: []string{"u2", "u3"}},
+	}
+	transformer.Intermediate.GroupChannels = []*IntermediateChannel{
+		{OriginalName: "G1", Members: []string{"u1", "u2", "u3"}},
+	}
+
+	transformer.HideInactiveDirectChannels(slackExport, 30)
+
+	assert.False(t, transformer.Intermediate.DirectChannels[0].Hidden)
+	assert.True(t, transformer.Intermediate.DirectChannels[1].Hidden)
+	assert.True(t, transformer.Intermediate.DirectChannels[2].Hidden)
+	assert.True(t, transformer.Intermediate.GroupChannels[0].Hidden)
+
+	exporter := &recordingExporter{}
+	require.NoError(t, transformer.ExportDirectChannels(transformer.Intermediate.DirectChannels, exporter))
+	require.Len(t, exporter.lines, 1)
+}
@@ -0,0 +1,284 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type WarningKind string
+
+const (
+	WarningSingleMemberChannel WarningKind = "single_member_channel"
+	WarningUnknownChannel      WarningKind = "unknown_channel"
+	WarningMissingThreadRoot   WarningKind = "missing_thread_root"
+	WarningDuplicateThreadRoot WarningKind = "duplicate_thread_root"
+	WarningMissingUser         WarningKind = "missing_user"
+	WarningUnknownUser         WarningKind = "unknown_user"
+	WarningMissingComment      WarningKind = "missing_comment"
+	WarningAttachmentFailed    WarningKind = "attachment_failed"
+	WarningPropsTooLarge       WarningKind = "props_too_large"
+	WarningUnsupportedPostType WarningKind = "unsupported_post_type"
+	// WarningArchivedDirectChannel is raised for the direct and group
+	// channels left out in archive mode
+	WarningArchivedDirectChannel WarningKind = "archived_direct_channel"
+	// WarningCorruptFile is raised for the malformed or truncated files
+	// of the export, of which only the complete entries are kept
+	WarningCorruptFile WarningKind = "corrupt_file"
+	// WarningTimestampOutOfRange is raised for the posts whose time
+	// shifted by the TimestampOffset is before 1970 or in the future
+	WarningTimestampOutOfRange WarningKind = "timestamp_out_of_range"
+	// WarningCustomEmojiReaction is raised for the messages with
+	// reactions using custom emojis, replaced or dropped
+	WarningCustomEmojiReaction WarningKind = "custom_emoji_reaction"
+	// WarningExcludedUser is raised for the messages of the users
+	// excluded by ExcludeEmailDomains, when they are not reassigned
+	WarningExcludedUser WarningKind = "excluded_user"
+	// WarningQuarantinedAttachment is raised for the attachments flagged
+	// by the AttachmentScanner, which are not attached to their post
+	WarningQuarantinedAttachment WarningKind = "quarantined_attachment"
+	// WarningOrphanedDirectChannel is raised for the direct channels
+	// missing from dms.json, created from the authors of their posts
+	WarningOrphanedDirectChannel WarningKind = "orphaned_direct_channel"
+	// WarningUnimportedReference is raised for the posts, replies and
+	// reactions left out as they reference users or channels missing
+	// from the ImportedMapping
+	WarningUnimportedReference WarningKind = "unimported_reference"
+	// WarningBotChannel is raised for the channels made mostly of bot
+	// messages, left out or downsampled by the BotChannelPolicy
+	WarningBotChannel WarningKind = "bot_channel"
+	// WarningAvatarFailed is raised for the users whose profile photo
+	// could not be downloaded, imported without it
+	WarningAvatarFailed WarningKind = "avatar_failed"
+	// WarningTruncatedReactions is raised for the messages with
+	// reactions whose users Slack capped in the export, of which only
+	// the listed users are imported
+	WarningTruncatedReactions WarningKind = "truncated_reactions"
+	// WarningCustomEmojiFailed is raised for the custom emojis left out
+	// as their name is not valid or their image is missing
+	WarningCustomEmojiFailed WarningKind = "custom_emoji_failed"
+	// WarningUnmappedUser is raised for the users missing from the
+	// UserMap, imported with their Slack username and email
+	WarningUnmappedUser WarningKind = "unmapped_user"
+	// WarningInvalidLine is raised by the ValidatingExporter for the
+	// fields of the import lines the server would reject
+	WarningInvalidLine WarningKind = "invalid_line"
+)
+
+// Warning describes an entity of the Slack export that was skipped or
+// altered during the transformation.
+type Warning struct {
+	Kind WarningKind
+	// Skipped is set when the entity is not part of the output at all
+	Skipped   bool
+	Channel   string
+	TimeStamp string
+	Message   string
+	Err       error
+}
+
+func (w *Warning) Error() string {
+	if w.Err != nil {
+		return fmt.Sprintf("%s: %s", w.Message, w.Err)
+	}
+	return w.Message
+}
+
+func (w *Warning) Unwrap() error {
+	return w.Err
+}
+
+// TransformResult aggregates the warnings raised while transforming
+// an export.
+type TransformResult struct {
+	// Warnings are the warnings raised, only the first ones when the
+	// transformation keeps a maximum of them. The counts and the
+	// deduplicated warnings cover all of them.
+	Warnings []*Warning
+
+	maxWarnings int
+	total       int
+	skipped     int
+	kinds       map[WarningKind]int
+	// skippedPosts are the skipped posts by channel
+	skippedPosts map[string]int
+	groups       []WarningGroup
+	groupIndexes map[string]int
+	// ungrouped counts by kind the warnings of the groups beyond
+	// maxWarnings, which are not kept
+	ungrouped     map[WarningKind]*WarningGroup
+	ungroupedKeys []WarningKind
+}
+
+func newTransformResult() *TransformResult {
+	return &TransformResult{
+		kinds:        map[WarningKind]int{},
+		skippedPosts: map[string]int{},
+		groupIndexes: map[string]int{},
+		ungrouped:    map[WarningKind]*WarningGroup{},
+	}
+}
+
+// add records the warning, returning whether it is the first
+// occurrence of its group
+func (r *TransformResult) add(warning *Warning) bool {
+	if r.maxWarnings <= 0 || len(r.Warnings) < r.maxWarnings {
+		r.Warnings = append(r.Warnings, warning)
+	}
+	r.total++
+	r.kinds[warning.Kind]++
+	if warning.Skipped {
+		r.skipped++
+		if warning.TimeStamp != "" {
+			r.skippedPosts[warning.Channel]++
+		}
+	}
+
+	key := warningKey(warning)
+	index, ok := r.groupIndexes[key]
+	if !ok && r.maxWarnings > 0 && len(r.groups) >= r.maxWarnings {
+		return r.addUngrouped(warning)
+	}
+	if !ok {
+		index = len(r.groups)
+		r.groupIndexes[key] = index
+		r.groups = append(r.groups, WarningGroup{
+			Kind:      warning.Kind,
+			Message:   warning.Error(),
+			Channel:   warning.Channel,
+			TimeStamp: warning.TimeStamp,
+		})
+	}
+	r.groups[index].Count++
+	if warning.Skipped {
+		r.groups[index].Skipped++
+	}
+	return !ok
+}
+
+// addUngrouped counts the warning of a group beyond maxWarnings with
+// the other ones of its kind, returning whether it is the first of them
+func (r *TransformResult) addUngrouped(warning *Warning) bool {
+	group, ok := r.ungrouped[warning.Kind]
+	if !ok {
+		group = &WarningGroup{
+			Kind:      warning.Kind,
+			Message:   fmt.Sprintf("%s warnings beyond the first %d distinct warnings, only counted", warning.Kind, r.maxWarnings),
+			Channel:   warning.Channel,
+			TimeStamp: warning.TimeStamp,
+			Truncated: true,
+		}
+		r.ungrouped[warning.Kind] = group
+		r.ungroupedKeys = append(r.ungroupedKeys, warning.Kind)
+	}
+	group.Count++
+	if warning.Skipped {
+		group.Skipped++
+	}
+	return len(r.ungroupedKeys) == 1 && group.Count == 1
+}
+
+// Truncated returns whether the deduplicated warnings were truncated
+// at the maximum number of warnings.
+func (r *TransformResult) Truncated() bool {
+	return len(r.ungroupedKeys) > 0
+}
+
+func (r *TransformResult) Count(kind WarningKind) int {
+	return r.kinds[kind]
+}
+
+// Total returns the number of warnings raised.
+func (r *TransformResult) Total() int {
+	return r.total
+}
+
+// SkippedCount returns the number of entities that were dropped from
+// the output.
+func (r *TransformResult) SkippedCount() int {
+	return r.skipped
+}
+
+// WarningGroup is a warning raised repeatedly, with the channel and
+// timestamp of its first occurrence.
+type WarningGroup struct {
+	Kind      WarningKind `json:"kind"`
+	Message   string      `json:"message"`
+	Channel   string      `json:"channel,omitempty"`
+	TimeStamp string      `json:"timestamp,omitempty"`
+	Count     int         `json:"count"`
+	Skipped   int         `json:"skipped"`
+	// Truncated is set on the groups counting by kind the warnings
+	// beyond the maximum number of groups
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// warningKey identifies the repetitions of a warning
+func warningKey(warning *Warning) string {
+	return string(warning.Kind) + "\x00" + warning.Error()
+}
+
+// Deduplicated groups the repeated warnings, in the order of their
+// first occurrence. When there are more groups than the maximum number
+// of warnings, the warnings beyond it are counted by kind in the
+// Truncated groups ending the list.
+func (r *TransformResult) Deduplicated() []WarningGroup {
+	groups := append([]WarningGroup{}, r.groups...)
+	for _, kind := range r.ungroupedKeys {
+		groups = append(groups, *r.ungrouped[kind])
+	}
+	return groups
+}
+
+func WriteWarningReport(reportPath string, groups []WarningGroup) error {
+	b, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the warning report")
+	}
+
+	if err := os.WriteFile(reportPath, b, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the warning report %s", reportPath)
+	}
+	return nil
+}
+
+// warn records the warning, logging only its first occurrence as the
+// same warning can be raised for thousands of messages
+func (t *Transformer) warn(warning *Warning) {
+	t.warnMu.Lock()
+	first := t.result.add(warning)
+	t.warnMu.Unlock()
+
+	logWarning(t.Logger, warning, first)
+
+	if t.Observer != nil {
+		t.Observer.Warning(warning)
+	}
+}
+
+// logWarning logs the first occurrence of a warning, the repeated ones
+// being logged at the debug level
+func logWarning(logger log.FieldLogger, warning *Warning, first bool) {
+	if warning.Err != nil {
+		logger = logger.WithError(warning.Err)
+	}
+	if first {
+		logger.Warn(warning.Message)
+	} else {
+		logger.Debug(warning.Message)
+	}
+}
+
+func (t *Transformer) warnPostf(kind WarningKind, channel string, post SlackPost, skipped bool, err error, format string, args ...interface{}) {
+	t.warn(&Warning{
+		Kind:      kind,
+		Skipped:   skipped,
+		Channel:   channel,
+		TimeStamp: post.TimeStamp,
+		Message:   fmt.Sprintf(format, args...),
+		Err:       err,
+	})
+}
@@ -0,0 +1,103 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarningDeduplication(t *testing.T) {
+	var output bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&output)
+	transformer := NewTransformer("team", logger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transformer.warnPostf(WarningMissingUser, "general", SlackPost{TimeStamp: "1"}, true, nil, "Missing user U123")
+		}()
+	}
+	wg.Wait()
+	transformer.warnPostf(WarningMissingUser, "random", SlackPost{TimeStamp: "2"}, false, errors.New("boom"), "Missing user U123")
+	transformer.warnPostf(WarningAttachmentFailed, "random", SlackPost{TimeStamp: "3"}, false, nil, "Failed to add file to post")
+
+	assert.Equal(t, 1, strings.Count(output.String(), `msg="Missing user U123" `))
+	assert.Equal(t, 12, len(transformer.result.Warnings))
+	assert.Equal(t, []WarningGroup{
+		{Kind: WarningMissingUser, Message: "Missing user U123", Channel: "general", TimeStamp: "1", Count: 10, Skipped: 10},
+		{Kind: WarningMissingUser, Message: "Missing user U123: boom", Channel: "random", TimeStamp: "2", Count: 1},
+		{Kind: WarningAttachmentFailed, Message: "Failed to add file to post", Channel: "random", TimeStamp: "3", Count: 1},
+	}, transformer.result.Deduplicated())
+}
+
+func TestMaxWarnings(t *testing.T) {
+	transformer := Options{TeamName: "team", Logger: log.New(), MaxWarnings: 2}.newTransformer()
+	for i := 0; i < 5; i++ {
+		transformer.warnPostf(WarningMissingUser, "general", SlackPost{TimeStamp: "1"}, true, nil, "Missing user U123")
+	}
+	transformer.warnPostf(WarningAttachmentFailed, "general", SlackPost{TimeStamp: "2"}, false, nil, "Failed to add file to post")
+
+	result := transformer.result
+	assert.Len(t, result.Warnings, 2)
+	assert.Equal(t, 6, result.Total())
+	assert.Equal(t, 5, result.Count(WarningMissingUser))
+	assert.Equal(t, 1, result.Count(WarningAttachmentFailed))
+	assert.Equal(t, 5, result.SkippedCount())
+	assert.Len(t, result.Deduplicated(), 2)
+}
+
+func TestMaxWarningsGroups(t *testing.T) {
+	transformer := Options{TeamName: "team", Logger: log.New(), MaxWarnings: 2}.newTransformer()
+	for i := 0; i < 3; i++ {
+		transformer.warnPostf(WarningMissingUser, "general", SlackPost{TimeStamp: "1"}, true, nil, "Missing user U%d", i)
+	}
+	transformer.warnPostf(WarningMissingUser, "general", SlackPost{TimeStamp: "2"}, true, nil, "Missing user U0")
+	transformer.warnPostf(WarningAttachmentFailed, "random", SlackPost{TimeStamp: "3"}, false, nil, "Failed to add file F1 to post")
+	transformer.warnPostf(WarningAttachmentFailed, "random", SlackPost{TimeStamp: "4"}, false, nil, "Failed to add file F2 to post")
+
+	result := transformer.result
+	assert.True(t, result.Truncated())
+	assert.Equal(t, 6, result.Total())
+	assert.Equal(t, []WarningGroup{
+		{Kind: WarningMissingUser, Message: "Missing user U0", Channel: "general", TimeStamp: "1", Count: 2, Skipped: 2},
+		{Kind: WarningMissingUser, Message: "Missing user U1", Channel: "general", TimeStamp: "1", Count: 1, Skipped: 1},
+		{Kind: WarningMissingUser, Message: "missing_user warnings beyond the first 2 distinct warnings, only counted", Channel: "general", TimeStamp: "1", Count: 1, Skipped: 1, Truncated: true},
+		{Kind: WarningAttachmentFailed, Message: "attachment_failed warnings beyond the first 2 distinct warnings, only counted", Channel: "random", TimeStamp: "3", Count: 2, Truncated: true},
+	}, result.Deduplicated())
+}
+
+func TestMissingThreadRootWarning(t *testing.T) {
+	fsys := testExportFS()
+	fsys["general/2020-01-02.json"] = &fstest.MapFile{Data: []byte(`[
+		{"type": "message", "user": "U1", "text": "orphan", "ts": "1577923200.000200", "thread_ts": "1577923200.000100"},
+		{"type": "message", "user": "U2", "text": "another orphan", "ts": "1577923300.000100", "thread_ts": "1577923200.000100"}
+	]`)}
+
+	result, err := TransformFS(context.Background(), fsys, Options{
+		TeamName:        "team",
+		Logger:          log.New(),
+		TransformConfig: TransformConfig{SkipAttachments: true},
+	})
+	require.NoError(t, err)
+
+	// the replies of a missing thread are reported once
+	assert.Equal(t, []WarningGroup{{
+		Kind:      WarningMissingThreadRoot,
+		Message:   "Unable to import the reply as the root post of its thread is missing. thread_ts=1577923200.000100",
+		Channel:   "general",
+		TimeStamp: "1577923200.000200",
+		Count:     2,
+		Skipped:   2,
+	}}, result.TransformResult.Deduplicated())
+}
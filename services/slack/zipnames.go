@@ -0,0 +1,122 @@
+package slack
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"hash/crc32"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// zipExtraUnicodePath is the Info-ZIP extra field holding the UTF-8
+// name of an entry whose name is stored in a legacy encoding
+const zipExtraUnicodePath = 0x7075
+
+// zipNameEncodings are the legacy encodings tried for the entry names
+// that are not valid UTF-8, as used by the old Russian archivers
+var zipNameEncodings = []struct {
+	name    string
+	charmap *charmap.Charmap
+}{
+	{"CP866", charmap.CodePage866},
+	{"CP1251", charmap.Windows1251},
+}
+
+// DecodeZipNames converts the entry names of the archive that are not
+// valid UTF-8 in place. The UTF-8 name of the Info-ZIP unicode path
+// extra field is used when present, otherwise the names are decoded
+// with the legacy encoding that produces the most readable names. It
+// returns the legacy encoding used, if any, and the number of renamed
+// entries. It must be called before the files are opened by name.
+func DecodeZipNames(zipReader *zip.Reader) (string, int) {
+	legacyNames := []*zip.File{}
+	renamed := 0
+	for _, file := range zipReader.File {
+		if utf8.ValidString(file.Name) {
+			continue
+		}
+		if name, ok := zipUnicodePath(file); ok {
+			file.Name = name
+			renamed++
+			continue
+		}
+		legacyNames = append(legacyNames, file)
+	}
+	if len(legacyNames) == 0 {
+		return "", renamed
+	}
+
+	// the encoding is chosen for the whole archive so the channel
+	// directories and the files in them are decoded the same way
+	bestIndex, bestScore := 0, -1
+	for i, encoding := range zipNameEncodings {
+		score := 0
+		for _, file := range legacyNames {
+			score += zipNameScore(encoding.charmap, file.Name)
+		}
+		if score > bestScore {
+			bestIndex, bestScore = i, score
+		}
+	}
+
+	decoder := zipNameEncodings[bestIndex].charmap.NewDecoder()
+	for _, file := range legacyNames {
+		name, err := decoder.String(file.Name)
+		if err != nil {
+			continue
+		}
+		file.Name = name
+		renamed++
+	}
+	return zipNameEncodings[bestIndex].name, renamed
+}
+
+// zipNameScore rates how readable a name decoded with the charmap is,
+// favouring letters over the box drawing and other symbols that
+// decoding with the wrong code page produces
+func zipNameScore(cm *charmap.Charmap, name string) int {
+	decoded, err := cm.NewDecoder().String(name)
+	if err != nil {
+		return 0
+	}
+
+	score := 0
+	for _, r := range decoded {
+		switch {
+		case r < utf8.RuneSelf:
+			continue
+		case unicode.Is(unicode.Cyrillic, r) && unicode.IsLower(r):
+			score += 2
+		case unicode.IsLetter(r):
+			score++
+		default:
+			score -= 2
+		}
+	}
+	return score
+}
+
+// zipUnicodePath returns the name of the Info-ZIP unicode path extra
+// field of the entry, if it is present and matches the stored name
+func zipUnicodePath(file *zip.File) (string, bool) {
+	extra := file.Extra
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == zipExtraUnicodePath && size >= 5 && extra[0] == 1 {
+			nameCRC := binary.LittleEndian.Uint32(extra[1:])
+			name := string(extra[5:size])
+			if nameCRC == crc32.ChecksumIEEE([]byte(file.Name)) && utf8.ValidString(name) {
+				return name, true
+			}
+		}
+		extra = extra[size:]
+	}
+	return "", false
+}
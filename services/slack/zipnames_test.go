@@ -0,0 +1,94 @@
+package slack
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func createNamesZip(t *testing.T, headers []*zip.FileHeader, contents []string) []byte {
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for i, header := range headers {
+		writer, err := zipWriter.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = writer.Write([]byte(contents[i]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	return buffer.Bytes()
+}
+
+func encodeName(t *testing.T, cm *charmap.Charmap, name string) string {
+	encoded, err := cm.NewEncoder().String(name)
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestDecodeZipNames(t *testing.T) {
+	names := []string{
+		"channels.json",
+		"общий/2020-01-01.json",
+		"разработка/2020-01-01.json",
+		"__uploads/F1/Отчёт за квартал.txt",
+	}
+
+	for encodingName, cm := range map[string]*charmap.Charmap{"CP866": charmap.CodePage866, "CP1251": charmap.Windows1251} {
+		t.Run(encodingName, func(t *testing.T) {
+			headers := []*zip.FileHeader{}
+			for _, name := range names {
+				headers = append(headers, &zip.FileHeader{Name: encodeName(t, cm, name), Method: zip.Store, NonUTF8: true})
+			}
+			data := createNamesZip(t, headers, names)
+
+			zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			require.NoError(t, err)
+			encoding, renamed := DecodeZipNames(zipReader)
+			assert.Equal(t, encodingName, encoding)
+			assert.Equal(t, 3, renamed)
+
+			for _, name := range names {
+				content, err := fs.ReadFile(zipReader, name)
+				require.NoError(t, err)
+				assert.Equal(t, name, string(content))
+			}
+		})
+	}
+
+	t.Run("unicode path extra field", func(t *testing.T) {
+		name := "общий/2020-01-01.json"
+		legacyName := encodeName(t, charmap.CodePage866, name)
+		extra := make([]byte, 9, 9+len(name))
+		binary.LittleEndian.PutUint16(extra, zipExtraUnicodePath)
+		binary.LittleEndian.PutUint16(extra[2:], uint16(5+len(name)))
+		extra[4] = 1
+		binary.LittleEndian.PutUint32(extra[5:], crc32.ChecksumIEEE([]byte(legacyName)))
+		extra = append(extra, name...)
+
+		data := createNamesZip(t, []*zip.FileHeader{{Name: legacyName, Method: zip.Store, NonUTF8: true, Extra: extra}}, []string{"posts"})
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		encoding, renamed := DecodeZipNames(zipReader)
+		assert.Empty(t, encoding)
+		assert.Equal(t, 1, renamed)
+		assert.Equal(t, name, zipReader.File[0].Name)
+	})
+
+	t.Run("utf-8 names are kept", func(t *testing.T) {
+		data := createNamesZip(t, []*zip.FileHeader{{Name: "общий/2020-01-01.json", Method: zip.Store}}, []string{"posts"})
+		zipFS, err := OpenZipFS(bytes.NewReader(data), int64(len(data)), "")
+		require.NoError(t, err)
+
+		content, err := fs.ReadFile(zipFS, "общий/2020-01-01.json")
+		require.NoError(t, err)
+		assert.Equal(t, "posts", string(content))
+	})
+}